@@ -1,103 +1,976 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/reillywatson/statstracker/internal/argocd"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/businesshours"
 	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/cloudrun"
+	"github.com/reillywatson/statstracker/internal/config"
 	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/errorrate"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/expr"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/harness"
+	"github.com/reillywatson/statstracker/internal/heroku"
+	"github.com/reillywatson/statstracker/internal/hooks"
+	"github.com/reillywatson/statstracker/internal/incremental"
+	"github.com/reillywatson/statstracker/internal/linear"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/netlify"
+	"github.com/reillywatson/statstracker/internal/pagerduty"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/progress"
+	"github.com/reillywatson/statstracker/internal/render"
+	"github.com/reillywatson/statstracker/internal/retry"
+	"github.com/reillywatson/statstracker/internal/sentry"
+	"github.com/reillywatson/statstracker/internal/spinnaker"
+	"github.com/reillywatson/statstracker/internal/stats"
+	"github.com/reillywatson/statstracker/internal/vercel"
 )
 
 func main() {
-	// Define command line flags
-	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
-	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
-	projectID := flag.String("project", "", "Google Cloud project ID (required)")
-	region := flag.String("region", "us-east4", "Google Cloud region (defaults to us-east4)")
-	githubOrg := flag.String("github-org", "", "GitHub organization name (required)")
-	tagsRepo := flag.String("tags-repo", "", "Repository containing deployment tags (required)")
-	servicesRepo := flag.String("services-repo", "", "Repository containing the actual service code (required)")
-
-	// Parse flags
+	f := registerFlags()
 	flag.Parse()
+	t := newTracker(f)
+	closeClients := t.connect()
+	defer closeClients()
 
-	// Validate required parameters
-	if *projectID == "" || *githubOrg == "" || *tagsRepo == "" || *servicesRepo == "" {
+	ctx := context.Background()
+	results := t.fetchResults(ctx)
+	t.markDeployments(ctx, results)
+	t.joinReleaseHealth(ctx, results)
+	t.checkErrorRates(ctx, results)
+	t.joinIncidents(ctx, results)
+	results = t.addFields(results)
+	t.report(ctx, results)
+	t.export(results)
+}
+
+// flags are deploy-tracker's command line flags
+type flags struct {
+	startDateStr           *string
+	endDateStr             *string
+	provider               *string
+	projectID              *string
+	regions                *deploy.Regions
+	allRegions             *bool
+	githubOrg              *string
+	pipelineRegex          *string
+	pipelineLabels         *string
+	pipelineAnnotations    *string
+	tagsRepo               *string
+	servicesRepo           *string
+	harnessOrg             *string
+	harnessProject         *string
+	harnessEnvironmentsStr *string
+	herokuAppsStr          *string
+	renderServicesStr      *string
+	vercelProjectsStr      *string
+	netlifySitesStr        *string
+	argoCDServer           *string
+	argoCDAppsStr          *string
+	spinnakerGate          *string
+	spinnakerAppsStr       *string
+	spinnakerPipelinesStr  *string
+	cloudRunServicesStr    *string
+	cloudRunCommitKeysStr  *string
+	deployTarget           *string
+	sentryOrg              *string
+	sentryProject          *string
+	sentryURL              *string
+	errorRateSource        *string
+	errorRateQuery         *string
+	errorRateProject       *string
+	errorRateWindow        *time.Duration
+	errorRateIncrease      *float64
+	rollbackOperations     *bool
+	rolloutPhases          *bool
+	hotfixLabelsStr        *string
+	priorities             github.Priorities
+	reportFormat           *string
+	outputFormat           *string
+	outFile                *string
+	maxAPICalls            *int
+	maxDeployCalls         *int
+	maxGitHubCalls         *int
+	maxErrorRateCalls      *int
+	maxSentryCalls         *int
+	pagerDutyServices      *string
+	pagerDutyServiceNames  *string
+	incidentWindow         *time.Duration
+	maxPagerDutyCalls      *int
+	periodFlags            *period.Flags
+	businessHoursFlags     *businesshours.Flags
+	exprFlags              *expr.Flags
+	retryFlags             *retry.Flags
+	hookCommand            *string
+	staleWhileRevalidate   *bool
+	incrementalPath        *string
+	quiet                  *bool
+	configPath             *string
+}
+
+// registerFlags defines deploy-tracker's flags on the command line
+func registerFlags() *flags {
+	f := &flags{}
+	f.startDateStr = flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	f.endDateStr = flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	f.provider = flag.String("provider", "clouddeploy", "Where deployments happen: clouddeploy, harness, heroku, render, vercel, netlify, argocd, spinnaker or cloudrun")
+	f.projectID = flag.String("project", "", "Google Cloud project ID (required for clouddeploy and cloudrun)")
+	f.regions = deploy.NewRegions("us-east4")
+	flag.Var(f.regions, "region", "Google Cloud region, or comma-separated regions, to fetch Cloud Deploy releases or Cloud Run revisions from (defaults to us-east4; repeatable)")
+	f.allRegions = flag.Bool("all-regions", false, "Fetch Cloud Deploy releases from every region the project can use, instead of -region")
+	f.githubOrg = flag.String("github-org", "", "GitHub organization name (required)")
+	f.pipelineRegex = flag.String("pipeline-regex", "", "Count releases from Cloud Deploy pipelines whose ID matches this regular expression, e.g. '^(test|qa)-' (defaults to IDs containing 'test' unless -pipeline-labels or -pipeline-annotations is given)")
+	f.pipelineLabels = flag.String("pipeline-labels", "", "Comma-separated key=value labels, or bare keys, that Cloud Deploy pipelines must have for their releases to count")
+	f.pipelineAnnotations = flag.String("pipeline-annotations", "", "Comma-separated key=value annotations, or bare keys, that Cloud Deploy pipelines must have for their releases to count")
+	f.tagsRepo = flag.String("tags-repo", "", "Repository containing deployment tags (required for clouddeploy)")
+	f.servicesRepo = flag.String("services-repo", "", "Repository containing the actual service code (required)")
+	f.harnessOrg = flag.String("harness-org", "", "Harness organization identifier (required for harness)")
+	f.harnessProject = flag.String("harness-project", "", "Harness project identifier (required for harness)")
+	f.harnessEnvironmentsStr = flag.String("harness-environments", "", "Comma-separated Harness environment identifiers to count deployments to, e.g. the test environment (defaults to all)")
+	f.herokuAppsStr = flag.String("heroku-apps", "", "Comma-separated Heroku apps whose releases to count (required for heroku)")
+	f.renderServicesStr = flag.String("render-services", "", "Comma-separated Render service IDs (srv-...) whose deploys to count (required for render)")
+	f.vercelProjectsStr = flag.String("vercel-projects", "", "Comma-separated Vercel project names or IDs whose deployments to count (required for vercel)")
+	f.netlifySitesStr = flag.String("netlify-sites", "", "Comma-separated Netlify site IDs or names whose deploys to count (required for netlify)")
+	f.argoCDServer = flag.String("argocd-server", "", "URL of the Argo CD server, e.g. https://argocd.example.com (required for argocd)")
+	f.argoCDAppsStr = flag.String("argocd-apps", "", "Comma-separated Argo CD Applications whose syncs to count (required for argocd)")
+	f.spinnakerGate = flag.String("spinnaker-gate", "", "URL of Spinnaker's Gate API, e.g. https://gate.spinnaker.example.com (required for spinnaker)")
+	f.spinnakerAppsStr = flag.String("spinnaker-apps", "", "Comma-separated Spinnaker applications whose pipeline executions to count (required for spinnaker)")
+	f.spinnakerPipelinesStr = flag.String("spinnaker-pipelines", "", "Comma-separated names of the Spinnaker pipelines that deploy, e.g. 'Deploy to prod' (defaults to every pipeline)")
+	f.cloudRunServicesStr = flag.String("cloudrun-services", "", "Comma-separated Cloud Run services whose revisions to count (required for cloudrun)")
+	f.cloudRunCommitKeysStr = flag.String("cloudrun-commit-keys", cloudrun.DefaultCommitKey, "Comma-separated revision labels or annotations holding the commit SHA, first found wins; images tagged with a full SHA are used otherwise")
+	f.deployTarget = flag.String("deploy-target", "production", "Which Vercel or Netlify deployments to count: production, or preview for PR and branch previews")
+	f.sentryOrg = flag.String("sentry-org", "", "Sentry organization slug; with -sentry-project, reports the release health of what each deployment shipped (needs SENTRY_AUTH_TOKEN)")
+	f.sentryProject = flag.String("sentry-project", "", "Sentry project slug whose releases the deployments ship")
+	f.sentryURL = flag.String("sentry-url", sentry.DefaultURL, "URL of the Sentry instance, for self-hosted Sentry")
+	f.errorRateSource = flag.String("error-rate-source", "", "Where to read error rates from, to flag deployments followed by a rise in errors as failed changes: datadog or cloudmonitoring")
+	f.errorRateQuery = flag.String("error-rate-query", "", "The error rate to watch: a Datadog metric query, or a Cloud Monitoring time series filter for a counter of errors")
+	f.errorRateProject = flag.String("error-rate-project", "", "Google Cloud project to read Cloud Monitoring metrics from (defaults to -project)")
+	f.errorRateWindow = flag.Duration("error-rate-window", 30*time.Minute, "How long before and after each deployment went live to compare error rates over")
+	f.errorRateIncrease = flag.Float64("error-rate-increase", errorrate.DefaultThreshold.Increase, "Relative rise in the mean error rate that counts as a regression, e.g. 0.5 for 50%")
+	f.rollbackOperations = flag.Bool("rollback-operations", true, "Also ask Cloud Deploy which releases a rollback operation rolled back, one more API call per release (cached); redeploys of an earlier commit count as rollbacks either way")
+	f.rolloutPhases = flag.Bool("rollout-phases", false, "Also time each target's rollout of each release: queued, deploying and verifying, from Cloud Deploy's rollouts and job runs, one more API call per release plus one per target (cached)")
+	f.hotfixLabelsStr = flag.String("hotfix-labels", "hotfix,emergency", "Comma-separated labels that mark PRs as hotfixes; their deployments are reported separately (empty to disable)")
+	flag.Var(&f.priorities, "priority", "Priority level and the labels that put a PR at it, as level=label,label..., e.g. P0=sev0,urgent; deployments are reported per level of the PR they deployed; give the most urgent first (repeatable)")
+	f.reportFormat = flag.String("format", "text", "Console report format: text, or markdown for a compact summary to paste into Slack or GitHub")
+	f.outputFormat = flag.String("output", "", "Also export per-deployment metrics to -out-file in this format (csv, parquet)")
+	f.outFile = flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	f.maxAPICalls = flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	f.maxDeployCalls = flag.Int("max-deploy-calls", 0, "Stop gracefully with partial results after this many deployment provider (Cloud Deploy, Harness, Heroku, Render, Vercel, Netlify, Argo CD, Spinnaker or Cloud Run) API calls (0 = unlimited)")
+	f.maxGitHubCalls = flag.Int("max-github-calls", 0, "Stop gracefully with partial results after this many GitHub API calls (0 = unlimited)")
+	f.maxErrorRateCalls = flag.Int("max-error-rate-calls", 0, "Stop reading error rates after this many Datadog or Cloud Monitoring API calls (0 = unlimited)")
+	f.maxSentryCalls = flag.Int("max-sentry-calls", 0, "Stop fetching Sentry releases after this many Sentry API calls (0 = unlimited)")
+	f.pagerDutyServices = flag.String("pagerduty-services", "", "Comma-separated PagerDuty service IDs whose incidents to match to deployments, to report which were followed by one (needs PAGERDUTY_API_KEY)")
+	f.pagerDutyServiceNames = flag.String("pagerduty-service-names", "", "Comma-separated pipeline=service pairs naming the PagerDuty service a pipeline, app, service or site deploys, where the names differ")
+	f.incidentWindow = flag.Duration("incident-window", 4*time.Hour, "How soon after a deployment went live an incident on its service counts as following it")
+	f.maxPagerDutyCalls = flag.Int("max-pagerduty-calls", 0, "Stop fetching incidents after this many PagerDuty API calls (0 = unlimited)")
+	f.periodFlags = period.RegisterFlags(flag.CommandLine)
+	f.businessHoursFlags = businesshours.RegisterFlags(flag.CommandLine)
+	f.exprFlags = expr.RegisterFlags(flag.CommandLine)
+	f.retryFlags = retry.RegisterFlags(flag.CommandLine)
+	f.hookCommand = flag.String("hook", "", "Program to run on each deployment, given its export record as JSON on stdin, whose JSON output adds fields to the report and export")
+	f.staleWhileRevalidate = flag.Bool("stale-while-revalidate", false, "Serve expired cache entries immediately and refresh them in the background")
+	f.incrementalPath = flag.String("incremental", "", "Keep results in this file and on later runs only fetch releases created since the last run (or still rolling out then), merging them with the stored results")
+	f.quiet = flag.Bool("quiet", false, "Don't print progress messages, such as the count of releases processed so far")
+	f.configPath = config.RegisterFlag(flag.CommandLine)
+	return f
+}
+
+// tracker is a deploy-tracker run: its flags, what they were checked and parsed
+// into, and, once connected, the clients deployments are fetched and checked with
+type tracker struct {
+	*flags
+
+	deployRegions      []string // The regions releases are fetched from, nil for all of them
+	regionsDescription string
+	pipelines          deploy.PipelineSelector
+	format             export.Format
+	useMarkdown        bool
+	schedule           *businesshours.Schedule
+	rules              *expr.Rules
+	status             io.Writer // Where progress messages go
+	startDate, endDate time.Time
+	githubToken        string
+
+	cacheImpl       cache.Cache
+	apiBudget       *budget.Budget
+	githubBudget    *budget.Budget
+	githubClient    *github.CachedGitHubClient
+	client          deploy.DeployProvider
+	project         string                 // What's deployed, for report titles and incremental results
+	rollbackChecker deploy.RollbackChecker // Only set for providers with rollback operations
+	phaseTimer      deploy.PhaseTimer      // Only set for providers that record rollout phases
+	deployBudget    *budget.Budget
+	sentryBudget    *budget.Budget // nil unless release health is joined from Sentry
+	errorRateBudget *budget.Budget // nil unless error rates are read
+	pagerDutyBudget *budget.Budget // nil unless incidents are read
+	serviceNames    map[string]string
+	incidents       []pagerduty.Incident
+}
+
+// newTracker checks the parsed flags, and the config file, before any work is
+// done, exiting with a message if they're wrong
+// validate exits with the usage if a required flag is missing, or with an
+// error if one is invalid
+func (f *flags) validate() {
+	// Required parameters depend on the provider
+	missing := *f.githubOrg == "" || *f.servicesRepo == ""
+	switch *f.provider {
+	case "clouddeploy":
+		missing = missing || *f.projectID == "" || *f.tagsRepo == ""
+	case "harness":
+		missing = missing || *f.harnessOrg == "" || *f.harnessProject == ""
+	case "heroku":
+		missing = missing || *f.herokuAppsStr == ""
+	case "render":
+		missing = missing || *f.renderServicesStr == ""
+	case "vercel":
+		missing = missing || *f.vercelProjectsStr == ""
+	case "netlify":
+		missing = missing || *f.netlifySitesStr == ""
+	case "argocd":
+		missing = missing || *f.argoCDServer == "" || *f.argoCDAppsStr == ""
+	case "spinnaker":
+		missing = missing || *f.spinnakerGate == "" || *f.spinnakerAppsStr == ""
+	case "cloudrun":
+		missing = missing || *f.projectID == "" || *f.cloudRunServicesStr == ""
+		if *f.allRegions {
+			log.Fatal("-all-regions only works with -provider clouddeploy; list Cloud Run's regions with -region")
+		}
+	default:
+		log.Fatalf("Unknown -provider %q; expected clouddeploy, harness, heroku, render, vercel, netlify, argocd, spinnaker or cloudrun", *f.provider)
+	}
+	if missing {
 		fmt.Println("Usage: deploy-tracker [flags]")
 		fmt.Println("Flags:")
 		flag.PrintDefaults()
 		fmt.Println("\nRequired:")
-		fmt.Println("  -project: Google Cloud project ID")
 		fmt.Println("  -github-org: GitHub organization name")
-		fmt.Println("  -tags-repo: Repository containing deployment tags")
 		fmt.Println("  -services-repo: Repository containing the actual service code")
+		fmt.Println("  -project, -tags-repo: Google Cloud project ID and repository containing deployment tags (clouddeploy)")
+		fmt.Println("  -harness-org, -harness-project: Harness organization and project identifiers (harness)")
+		fmt.Println("  -heroku-apps: Heroku apps (heroku)")
+		fmt.Println("  -render-services: Render service IDs (render)")
+		fmt.Println("  -vercel-projects: Vercel projects (vercel)")
+		fmt.Println("  -netlify-sites: Netlify sites (netlify)")
+		fmt.Println("  -argocd-server, -argocd-apps: Argo CD server URL and Applications (argocd)")
+		fmt.Println("  -spinnaker-gate, -spinnaker-apps: Spinnaker Gate URL and applications (spinnaker)")
+		fmt.Println("  -project, -cloudrun-services: Google Cloud project ID and Cloud Run services (cloudrun)")
 		os.Exit(1)
 	}
+	if (*f.sentryOrg == "") != (*f.sentryProject == "") {
+		log.Fatal("-sentry-org and -sentry-project must be given together")
+	}
+	switch *f.errorRateSource {
+	case "":
+	case "datadog", "cloudmonitoring":
+		if *f.errorRateQuery == "" {
+			log.Fatal("-error-rate-source needs -error-rate-query")
+		}
+	default:
+		log.Fatalf("Unknown -error-rate-source %q; expected datadog or cloudmonitoring", *f.errorRateSource)
+	}
+	if *f.deployTarget != "production" && *f.deployTarget != "preview" {
+		log.Fatalf("Unknown -deploy-target %q; expected production or preview", *f.deployTarget)
+	}
+}
+
+func newTracker(f *flags) *tracker {
+	t := &tracker{flags: f}
+
+	var err error
+	// Fill in anything not given on the command line from the config file
+	if _, err := config.Apply(*t.configPath, "deploy-tracker", flag.CommandLine); err != nil {
+		log.Fatal(err)
+	}
+
+	f.validate()
+
+	// Releases from all the regions are merged, and the incremental results kept
+	// for that set of regions
+	t.deployRegions, t.regionsDescription = t.regions.List(), t.regions.String()
+	if *t.allRegions {
+		t.deployRegions, t.regionsDescription = nil, "all regions"
+	}
+	t.pipelines, err = deploy.ParsePipelineSelector(*t.pipelineRegex, *t.pipelineLabels, *t.pipelineAnnotations)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Validate output options before doing any work
+	t.format, err = export.ParseOutputFlags(*t.outputFormat, *t.outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.useMarkdown, err = markdown.ParseFormat(*t.reportFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := t.periodFlags.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	t.schedule, err = t.businessHoursFlags.Schedule()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.rules, err = t.exprFlags.Rules()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.serviceNames, err = parseServiceNames(*t.pagerDutyServiceNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Keep progress messages out of the Markdown so it can be piped straight into a post
+	t.status = os.Stdout
+	if t.useMarkdown {
+		t.status = os.Stderr
+	}
+	if *t.quiet {
+		t.status = io.Discard
+	}
 
 	// Parse start date
-	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
-	if *startDateStr != "" {
-		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+	t.startDate = time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *t.startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *t.startDateStr)
 		if err != nil {
 			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
 		}
-		startDate = parsedDate
+		t.startDate = parsedDate
 	}
-	endDate := time.Now() // Default to now
-	if *endDateStr != "" {
-		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+	t.endDate = time.Now() // Default to now
+	if *t.endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *t.endDateStr)
 		if err != nil {
 			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
 		}
-		endDate = parsedDate
+		t.endDate = parsedDate
 	}
-	if startDate.After(endDate) {
+	if t.startDate.After(t.endDate) {
 		log.Fatal("Start date cannot be after end date")
 	}
 
 	// Get GitHub token from environment
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
+	t.githubToken = os.Getenv("GITHUB_TOKEN")
+	if t.githubToken == "" {
 		log.Fatal("GITHUB_TOKEN environment variable not set")
 	}
+	return t
+}
 
+// connect creates the cache, the GitHub client and the client for wherever
+// deployments happen, capped by the API budgets. The returned function closes them.
+func (t *tracker) connect() func() {
+	var err error
 	// Create cache
-	cacheImpl, err := cache.NewDefaultCache()
+	t.cacheImpl, err = cache.NewDefaultCache()
 	if err != nil {
 		log.Fatalf("Error creating cache: %v", err)
 	}
-	defer cacheImpl.Close()
+	closers := []io.Closer{t.cacheImpl}
 
-	// Create a cached Deploy client
-	client, err := deploy.NewCachedDeployClient(*projectID, *region, githubToken, *githubOrg, *tagsRepo, *servicesRepo, cacheImpl)
-	if err != nil {
-		log.Fatalf("Error creating deploy client: %v", err)
+	// Cap API usage so large scans can't exhaust shared rate limits
+	t.apiBudget = budget.New("API", *t.maxAPICalls)
+	t.githubBudget = t.apiBudget.Child("GitHub API", *t.maxGitHubCalls)
+
+	t.githubClient = github.NewCachedGitHubClient(t.githubToken, t.cacheImpl)
+	t.githubClient.SetStaleWhileRevalidate(*t.staleWhileRevalidate)
+	t.githubClient.SetBudget(t.githubBudget)
+	t.githubClient.SetRetryPolicy(t.retryFlags.Policy())
+	closers = append(closers, t.githubClient)
+	if c := t.connectProvider(); c != nil {
+		closers = append(closers, c)
 	}
-	defer client.Close()
 
-	// Fetch test environment releases
-	fmt.Printf("Fetching test environment releases for project %s in region %s from %s to %s...\n",
-		*projectID, *region, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	return func() {
+		for _, c := range slices.Backward(closers) {
+			c.Close()
+		}
+	}
+}
 
-	releases, err := client.FetchTestEnvironmentReleases(startDate, endDate)
-	if err != nil {
-		log.Fatalf("Error fetching releases: %v", err)
+// connectProvider creates the client for wherever deployments happen, returning
+// it if it needs closing
+func (t *tracker) connectProvider() io.Closer {
+	var closer io.Closer
+	// Harness, Heroku, Vercel and Netlify don't know when the deployed commits were made, so GitHub is asked
+	commitTime := func(ctx context.Context, sha string) (time.Time, error) {
+		commit, err := t.githubClient.FetchCommit(ctx, *t.githubOrg, *t.servicesRepo, sha)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return commit.Commit.Committer.Date, nil
+	}
+
+	// Argo CD and Spinnaker can say which repository a deployed commit is in, and
+	// need its message for the PR; the services repository is the fallback, and
+	// where Cloud Run's commits are looked up
+	repoCommit := func(ctx context.Context, owner, repo, sha string) (string, time.Time, error) {
+		if owner == "" {
+			owner, repo = *t.githubOrg, *t.servicesRepo
+		}
+		commit, err := t.githubClient.FetchCommit(ctx, owner, repo, sha)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return commit.Commit.Message, commit.Commit.Committer.Date, nil
+	}
+
+	t.project = *t.projectID
+	switch *t.provider {
+	case "clouddeploy":
+		// Create a cached Deploy client
+		deployClient, err := deploy.NewCachedDeployClient(*t.projectID, t.deployRegions, t.githubToken, *t.githubOrg, *t.tagsRepo, *t.servicesRepo, t.cacheImpl)
+		if err != nil {
+			log.Fatalf("Error creating deploy client: %v", err)
+		}
+		deployClient.SetStaleWhileRevalidate(*t.staleWhileRevalidate)
+		deployClient.SetPipelineSelector(t.pipelines)
+		closer = deployClient
+		t.deployBudget = t.apiBudget.Child("Cloud Deploy API", *t.maxDeployCalls)
+		deployClient.SetBudgets(t.deployBudget, t.githubBudget)
+		deployClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = deployClient
+		if *t.rollbackOperations {
+			t.rollbackChecker = deployClient
+		}
+		if *t.rolloutPhases {
+			t.phaseTimer = deployClient
+		}
+
+		fmt.Fprintf(t.status, "Fetching test environment releases for project %s in %s from %s to %s...\n",
+			*t.projectID, t.regionsDescription, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "harness":
+		apiKey := os.Getenv("HARNESS_API_KEY")
+		if apiKey == "" {
+			log.Fatal("HARNESS_API_KEY environment variable not set")
+		}
+		accountID := os.Getenv("HARNESS_ACCOUNT_ID")
+		if accountID == "" {
+			log.Fatal("HARNESS_ACCOUNT_ID environment variable not set")
+		}
+		harnessClient := harness.NewHarnessClient(apiKey, accountID, *t.harnessOrg, *t.harnessProject, commitTime)
+		if *t.harnessEnvironmentsStr != "" {
+			harnessClient.SetEnvironments(strings.Split(*t.harnessEnvironmentsStr, ","))
+		}
+		t.deployBudget = t.apiBudget.Child("Harness API", *t.maxDeployCalls)
+		harnessClient.SetBudget(t.deployBudget)
+		harnessClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = harnessClient
+		t.project = *t.harnessOrg + "/" + *t.harnessProject
+
+		fmt.Fprintf(t.status, "Fetching Harness deployments for project %s from %s to %s...\n",
+			t.project, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "heroku":
+		apiKey := os.Getenv("HEROKU_API_KEY")
+		if apiKey == "" {
+			log.Fatal("HEROKU_API_KEY environment variable not set")
+		}
+		herokuClient := heroku.NewHerokuClient(apiKey, strings.Split(*t.herokuAppsStr, ","), commitTime)
+		t.deployBudget = t.apiBudget.Child("Heroku API", *t.maxDeployCalls)
+		herokuClient.SetBudget(t.deployBudget)
+		herokuClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = herokuClient
+		t.project = *t.herokuAppsStr
+
+		fmt.Fprintf(t.status, "Fetching Heroku releases for %s from %s to %s...\n",
+			t.project, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "render":
+		apiKey := os.Getenv("RENDER_API_KEY")
+		if apiKey == "" {
+			log.Fatal("RENDER_API_KEY environment variable not set")
+		}
+		renderClient := render.NewRenderClient(apiKey, strings.Split(*t.renderServicesStr, ","))
+		t.deployBudget = t.apiBudget.Child("Render API", *t.maxDeployCalls)
+		renderClient.SetBudget(t.deployBudget)
+		renderClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = renderClient
+		t.project = *t.renderServicesStr
+
+		fmt.Fprintf(t.status, "Fetching Render deploys for %s from %s to %s...\n",
+			t.project, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "vercel":
+		token := os.Getenv("VERCEL_TOKEN")
+		if token == "" {
+			log.Fatal("VERCEL_TOKEN environment variable not set")
+		}
+		vercelClient := vercel.NewVercelClient(token, os.Getenv("VERCEL_TEAM_ID"), strings.Split(*t.vercelProjectsStr, ","), commitTime)
+		vercelClient.SetTarget(*t.deployTarget)
+		t.deployBudget = t.apiBudget.Child("Vercel API", *t.maxDeployCalls)
+		vercelClient.SetBudget(t.deployBudget)
+		vercelClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = vercelClient
+		t.project = *t.vercelProjectsStr
+
+		fmt.Fprintf(t.status, "Fetching Vercel %s deployments for %s from %s to %s...\n",
+			*t.deployTarget, t.project, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "netlify":
+		token := os.Getenv("NETLIFY_AUTH_TOKEN")
+		if token == "" {
+			log.Fatal("NETLIFY_AUTH_TOKEN environment variable not set")
+		}
+		netlifyClient := netlify.NewNetlifyClient(token, strings.Split(*t.netlifySitesStr, ","), commitTime)
+		netlifyClient.SetTarget(*t.deployTarget)
+		t.deployBudget = t.apiBudget.Child("Netlify API", *t.maxDeployCalls)
+		netlifyClient.SetBudget(t.deployBudget)
+		netlifyClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = netlifyClient
+		t.project = *t.netlifySitesStr
+
+		fmt.Fprintf(t.status, "Fetching Netlify %s deploys for %s from %s to %s...\n",
+			*t.deployTarget, t.project, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "argocd":
+		token := os.Getenv("ARGOCD_AUTH_TOKEN")
+		if token == "" {
+			log.Fatal("ARGOCD_AUTH_TOKEN environment variable not set")
+		}
+		argoCDClient := argocd.NewArgoCDClient(*t.argoCDServer, token, strings.Split(*t.argoCDAppsStr, ","), repoCommit)
+		t.deployBudget = t.apiBudget.Child("Argo CD API", *t.maxDeployCalls)
+		argoCDClient.SetBudget(t.deployBudget)
+		argoCDClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = argoCDClient
+		t.project = *t.argoCDServer + "/" + *t.argoCDAppsStr
+
+		fmt.Fprintf(t.status, "Fetching Argo CD syncs for %s from %s to %s...\n",
+			*t.argoCDAppsStr, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "spinnaker":
+		// Gate may sit behind a proxy that authenticates, so the token is optional
+		spinnakerClient := spinnaker.NewSpinnakerClient(*t.spinnakerGate, os.Getenv("SPINNAKER_TOKEN"), strings.Split(*t.spinnakerAppsStr, ","), repoCommit)
+		if *t.spinnakerPipelinesStr != "" {
+			spinnakerClient.SetPipelines(strings.Split(*t.spinnakerPipelinesStr, ","))
+		}
+		t.deployBudget = t.apiBudget.Child("Spinnaker API", *t.maxDeployCalls)
+		spinnakerClient.SetBudget(t.deployBudget)
+		spinnakerClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = spinnakerClient
+		t.project = *t.spinnakerGate + "/" + *t.spinnakerAppsStr
+		if *t.spinnakerPipelinesStr != "" {
+			t.project += "/" + *t.spinnakerPipelinesStr
+		}
+
+		fmt.Fprintf(t.status, "Fetching Spinnaker executions for %s from %s to %s...\n",
+			*t.spinnakerAppsStr, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	case "cloudrun":
+		// Revisions only carry the commit, which is in the services repository
+		commits := func(ctx context.Context, sha string) (string, time.Time, error) {
+			return repoCommit(ctx, "", "", sha)
+		}
+		cloudRunClient, err := cloudrun.NewCloudRunClient(context.Background(), *t.projectID, t.deployRegions, strings.Split(*t.cloudRunServicesStr, ","), commits)
+		if err != nil {
+			log.Fatalf("Error creating Cloud Run client: %v", err)
+		}
+		cloudRunClient.SetCommitKeys(strings.Split(*t.cloudRunCommitKeysStr, ","))
+		t.deployBudget = t.apiBudget.Child("Cloud Run API", *t.maxDeployCalls)
+		cloudRunClient.SetBudget(t.deployBudget)
+		cloudRunClient.SetRetryPolicy(t.retryFlags.Policy())
+		t.client = cloudRunClient
+		t.project = *t.projectID + "/" + *t.cloudRunServicesStr
+
+		fmt.Fprintf(t.status, "Fetching Cloud Run revisions for %s in %s from %s to %s...\n",
+			*t.cloudRunServicesStr, t.regionsDescription, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+	}
+	return closer
+}
+
+// fetchResults fetches and processes the releases in the date range
+func (t *tracker) fetchResults(ctx context.Context) []deploy.DeploymentMetric {
+	// Incrementally, only releases after the last run's watermark are fetched
+	var store *incremental.Store
+	var stored []deploy.DeploymentMetric
+	storeKey := *t.provider + ":" + t.project
+	switch *t.provider {
+	case "clouddeploy", "cloudrun":
+		storeKey += "/" + t.regionsDescription
+	case "vercel", "netlify":
+		storeKey += "/" + *t.deployTarget
+	}
+	fetchFrom := t.startDate
+	if *t.incrementalPath != "" {
+		var err error
+		store, err = incremental.Open(*t.incrementalPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		stored, fetchFrom, err = incremental.Load[deploy.DeploymentMetric](store, storeKey, t.startDate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(stored) > 0 {
+			fmt.Fprintf(t.status, "Using %d stored results; only fetching releases since the last run (%s)\n", len(stored), fetchFrom.Format("2006-01-02 15:04"))
+		}
+	}
+
+	var results []deploy.DeploymentMetric
+	if fetchFrom.Before(t.endDate) {
+		releases, err := t.client.ListReleases(ctx, fetchFrom, t.endDate)
+		if errors.Is(err, budget.ErrExhausted) {
+			log.Printf("Stopped fetching releases early: %v", err)
+		} else if err != nil {
+			log.Fatalf("Error fetching releases: %v", err)
+		}
+
+		fmt.Fprintf(t.status, "Found %d test environment releases\n", len(releases))
+
+		// Process deployments to gather results, counting releases as they're
+		// processed and keeping log messages off the progress line
+		var reporter *progress.Reporter
+		if !*t.quiet {
+			reporter = progress.New(os.Stderr, "releases", t.apiBudget)
+			log.SetOutput(reporter)
+		}
+		results = deploy.ProcessDeployments(ctx, t.client, releases, t.schedule, reporter)
+
+		// A partial run leaves the stored results as they were, to be fetched again next time
+		if store != nil && err == nil && !t.deployBudget.Exhausted() && !t.githubBudget.Exhausted() {
+			if err := incremental.Save(store, storeKey, fetchFrom, releaseWatermark(releases, results, t.endDate), incremental.Merge(stored, results, releaseID)); err != nil {
+				log.Fatal(err)
+			}
+			if err := store.Close(); err != nil {
+				log.Printf("Error saving results for the next incremental run: %v", err)
+			}
+		}
+	}
+	if store != nil {
+		results = startedBetween(incremental.Merge(stored, results, releaseID), t.startDate, t.endDate)
+	}
+	return results
+}
+
+// markDeployments flags rollbacks, hotfixes and prioritized PRs among results,
+// and times their rollouts, where the provider can
+func (t *tracker) markDeployments(ctx context.Context, results []deploy.DeploymentMetric) {
+	// Flag rollbacks, and the deployments they rolled back
+	if t.rollbackChecker != nil {
+		fmt.Fprintf(t.status, "Checking for rollback operations...\n")
+		if err := deploy.CheckRollbackOperations(ctx, t.rollbackChecker, results); err != nil {
+			log.Printf("Stopped checking for rollback operations early: %v", err)
+		}
+	}
+	deploy.MarkRollbacks(results)
+
+	// Time each target's rollout, to show where in the pipeline the latency accrues
+	if t.phaseTimer != nil {
+		fmt.Fprintf(t.status, "Timing rollout phases...\n")
+		if err := deploy.CheckRolloutPhases(ctx, t.phaseTimer, results); err != nil {
+			log.Printf("Stopped timing rollout phases early: %v", err)
+		}
+	}
+
+	// Flag deployments of hotfix and prioritized PRs, so the fast path can be checked
+	var hotfixLabels []string
+	if *t.hotfixLabelsStr != "" {
+		hotfixLabels = strings.Split(*t.hotfixLabelsStr, ",")
+	}
+	if len(hotfixLabels) > 0 || len(t.priorities) > 0 {
+		markLabels(ctx, t.githubClient, *t.githubOrg, *t.servicesRepo, hotfixLabels, t.priorities, results)
+	}
+}
+
+// joinReleaseHealth joins release health from Sentry to what each deployment
+// shipped, if -sentry-org is set
+func (t *tracker) joinReleaseHealth(ctx context.Context, results []deploy.DeploymentMetric) {
+	// Join release health from Sentry to what each deployment shipped
+	if *t.sentryOrg == "" {
+		return
+	}
+	token := os.Getenv("SENTRY_AUTH_TOKEN")
+	if token == "" {
+		log.Fatal("SENTRY_AUTH_TOKEN environment variable not set")
+	}
+	sentryClient := sentry.NewSentryClient(*t.sentryURL, token, *t.sentryOrg)
+	t.sentryBudget = t.apiBudget.Child("Sentry API", *t.maxSentryCalls)
+	sentryClient.SetBudget(t.sentryBudget)
+	sentryClient.SetRetryPolicy(t.retryFlags.Policy())
+
+	// A release is made from a commit, so can't be older than the oldest deployed one
+	releasesFrom := t.startDate
+	for _, result := range results {
+		if !result.CommitTime.IsZero() && result.CommitTime.Before(releasesFrom) {
+			releasesFrom = result.CommitTime
+		}
+	}
+	releases, err := sentryClient.FetchReleases(ctx, *t.sentryProject, releasesFrom, time.Now())
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching Sentry releases early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching Sentry releases: %v", err)
+	}
+	fmt.Fprintf(t.status, "Found %d Sentry releases for %s\n", len(releases), *t.sentryProject)
+	sentry.JoinReleases(results, releases)
+}
+
+// checkErrorRates flags deployments followed by a rise in the error rate as
+// failed changes, if -error-rate-source is set
+func (t *tracker) checkErrorRates(ctx context.Context, results []deploy.DeploymentMetric) {
+	// Flag deployments followed by a rise in the error rate as failed changes
+	if *t.errorRateSource == "" {
+		return
+	}
+	var source errorrate.Source
+	switch *t.errorRateSource {
+	case "datadog":
+		apiKey, appKey := os.Getenv("DD_API_KEY"), os.Getenv("DD_APP_KEY")
+		if apiKey == "" || appKey == "" {
+			log.Fatal("DD_API_KEY and DD_APP_KEY environment variables must be set")
+		}
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = errorrate.DefaultDatadogSite
+		}
+		source = errorrate.NewDatadogClient(site, apiKey, appKey, *t.errorRateQuery)
+	case "cloudmonitoring":
+		monitoringProject := *t.errorRateProject
+		if monitoringProject == "" {
+			monitoringProject = *t.projectID
+		}
+		if monitoringProject == "" {
+			log.Fatal("-error-rate-source cloudmonitoring needs -error-rate-project")
+		}
+		var err error
+		source, err = errorrate.NewCloudMonitoringClient(ctx, monitoringProject, *t.errorRateQuery)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
+	t.errorRateBudget = t.apiBudget.Child("Error rate API", *t.maxErrorRateCalls)
+	source.SetBudget(t.errorRateBudget)
+	source.SetRetryPolicy(t.retryFlags.Policy())
 
-	fmt.Printf("Found %d test environment releases\n", len(releases))
+	threshold := errorrate.DefaultThreshold
+	threshold.Increase = *t.errorRateIncrease
+	fmt.Fprintf(t.status, "Comparing error rates %v either side of each deployment...\n", *t.errorRateWindow)
+	if err := errorrate.CheckDeployments(ctx, source, results, *t.errorRateWindow, threshold); err != nil {
+		log.Printf("Stopped reading error rates early: %v", err)
+	}
+}
+
+// joinIncidents records the PagerDuty incidents that followed each deployment on
+// its service, if -pagerduty-services is set
+func (t *tracker) joinIncidents(ctx context.Context, results []deploy.DeploymentMetric) {
+	if *t.pagerDutyServices == "" {
+		return
+	}
+	token := os.Getenv("PAGERDUTY_API_KEY")
+	if token == "" {
+		log.Fatal("PAGERDUTY_API_KEY environment variable not set (needed for -pagerduty-services)")
+	}
+	pagerDutyClient := pagerduty.NewPagerDutyClient(token)
+	t.pagerDutyBudget = t.apiBudget.Child("PagerDuty API", *t.maxPagerDutyCalls)
+	pagerDutyClient.SetBudget(t.pagerDutyBudget)
+
+	// Deployments that went live after the range can still be followed by an incident
+	var err error
+	t.incidents, err = pagerDutyClient.FetchIncidents(ctx, strings.Split(*t.pagerDutyServices, ","), t.startDate, time.Now())
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching incidents early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching incidents: %v", err)
+	}
+	fmt.Fprintf(t.status, "Found %d PagerDuty incidents\n", len(t.incidents))
+	pagerduty.MarkIncidents(results, t.incidents, *t.incidentWindow, t.serviceNames)
+}
+
+// restores pairs the incidents opened in the date range with the deployments
+// that remediated them
+func (t *tracker) restores(results []deploy.DeploymentMetric) []pagerduty.Restore {
+	var opened []pagerduty.Incident
+	for _, incident := range t.incidents {
+		if !incident.CreatedAt.Before(t.startDate) && incident.CreatedAt.Before(t.endDate) {
+			opened = append(opened, incident)
+		}
+	}
+	return pagerduty.PairRestores(results, opened, t.serviceNames)
+}
+
+// addFields adds the fields from -hook and -column to each deployment, and leaves
+// out those -where rejects
+func (t *tracker) addFields(results []deploy.DeploymentMetric) []deploy.DeploymentMetric {
+	// Let a user-provided program add fields to each deployment
+	if *t.hookCommand != "" {
+		hook, err := hooks.New(*t.hookCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i := range results {
+			extra, err := hook.Run(export.DeploymentRecords(results[i : i+1])[0])
+			if err != nil {
+				log.Printf("Error running hook for release %s: %v", results[i].ReleaseID, err)
+				continue
+			}
+			results[i].Extra = extra
+		}
+	}
 
-	// Process deployments to gather results
-	results := deploy.ProcessDeployments(client, releases)
+	// Add the configured computed columns, and leave out deployments the filter rejects
+	if t.rules != nil {
+		kept := results[:0]
+		for _, result := range results {
+			record := export.DeploymentRecords([]deploy.DeploymentMetric{result})[0]
+			extra, keep, err := t.rules.Apply(export.Fields(record), result.Extra)
+			if err != nil {
+				log.Fatalf("Error applying -where/-column to release %s: %v", result.ReleaseID, err)
+			}
+			if keep {
+				result.Extra = extra
+				kept = append(kept, result)
+			}
+		}
+		results = kept
+	}
+	return results
+}
 
+// report prints the report on the date range
+func (t *tracker) report(ctx context.Context, results []deploy.DeploymentMetric) {
 	// Calculate PR deployment statistics
 	prStats := deploy.CalculatePRDeploymentStats(results)
 
+	// Work out the reporting periods, if the summary should be broken down by them
+	periods, err := t.periodFlags.Periods(t.startDate, t.endDate, func(team string) ([]period.Period, error) {
+		apiKey := os.Getenv("LINEAR_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("LINEAR_API_KEY environment variable not set")
+		}
+		linearClient := linear.NewCachedLinearClient(apiKey, t.cacheImpl)
+		defer linearClient.Close()
+		cycles, err := linearClient.FetchCycles(ctx, team, t.startDate, t.endDate)
+		if err != nil {
+			return nil, err
+		}
+		return linear.CyclePeriods(cycles), nil
+	})
+	if err != nil {
+		log.Fatalf("Error building report periods: %v", err)
+	}
+
 	// Print the results
-	printResults(results, prStats)
+	partial := t.deployBudget.Exhausted() || t.githubBudget.Exhausted() || t.sentryBudget.Exhausted() || t.errorRateBudget.Exhausted() || t.pagerDutyBudget.Exhausted()
+	if t.useMarkdown {
+		printMarkdown(os.Stdout, t.project, t.startDate, t.endDate, results, prStats, periods, partial)
+	} else {
+		if partial {
+			fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all releases were processed\n", t.apiBudget.Used())
+		}
+		if t.schedule != nil {
+			fmt.Printf("\nLatencies are in business hours (%s)\n", t.schedule)
+		}
+		printResults(results, prStats)
+		printPeriodStatistics(periods, results)
+		printRegionStatistics(results)
+		printHotfixStatistics(results)
+		printPriorityStatistics(results, t.priorities)
+		printRollbackStatistics(results)
+		printFailureStatistics(results)
+		printRolloutPhaseStatistics(results)
+		if *t.sentryOrg != "" {
+			printReleaseHealthStatistics(results)
+		}
+		if *t.errorRateSource != "" {
+			printErrorRateStatistics(results)
+		}
+		if *t.pagerDutyServices != "" {
+			printIncidentStatistics(periods, results, *t.incidentWindow)
+			printRestoreStatistics(t.restores(results))
+		}
+	}
+}
+
+// export writes the per-deployment export, if asked for
+func (t *tracker) export(results []deploy.DeploymentMetric) {
+	if t.format != "" {
+		if err := export.WriteFile(t.format, *t.outFile, export.DeploymentRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Fprintf(t.status, "\nWrote %d deployment records to %s\n", len(results), *t.outFile)
+	}
+}
+
+// pendingReleaseWindow is how long after it's created a release that hasn't
+// finished rolling out is taken to be still in progress, rather than failed
+const pendingReleaseWindow = 24 * time.Hour
+
+// releaseWatermark returns when the next incremental run should fetch releases
+// from: the creation of the oldest recent release that hadn't finished rolling
+// out, or end if there's none
+func releaseWatermark(releases []*deploy.Release, results []deploy.DeploymentMetric, end time.Time) time.Time {
+	finished := make(map[string]bool, len(results))
+	for _, result := range results {
+		finished[result.ReleaseName] = true
+	}
+	watermark := end
+	for _, release := range releases {
+		created := release.CreateTime
+		if !finished[release.Name] && end.Sub(created) < pendingReleaseWindow && created.Before(watermark) {
+			watermark = created
+		}
+	}
+	return watermark
+}
+
+// releaseID identifies a deployment's result, for merging incremental runs' results
+func releaseID(result deploy.DeploymentMetric) string {
+	return result.ReleaseName
+}
+
+// startedBetween returns the results for releases started in the date range
+func startedBetween(results []deploy.DeploymentMetric, start, end time.Time) []deploy.DeploymentMetric {
+	return slices.DeleteFunc(results, func(result deploy.DeploymentMetric) bool {
+		return result.ReleaseStartTime.Before(start) || result.ReleaseStartTime.After(end)
+	})
+}
+
+// markLabels flags deployments whose PR carries one of hotfixLabels, and gives
+// them their PR's priority level, looking each PR up once
+func markLabels(ctx context.Context, client *github.CachedGitHubClient, owner, repo string, hotfixLabels []string, priorities github.Priorities, results []deploy.DeploymentMetric) {
+	type labelled struct {
+		hotfix   bool
+		priority string
+	}
+	prs := make(map[string]labelled)
+	for i, result := range results {
+		if result.PRNumber == "" {
+			continue
+		}
+		labels, ok := prs[result.PRNumber]
+		if !ok {
+			number, err := strconv.Atoi(result.PRNumber)
+			if err != nil {
+				continue
+			}
+			pr, err := client.FetchPullRequest(ctx, owner, repo, number)
+			if errors.Is(err, budget.ErrExhausted) {
+				log.Printf("Stopped checking PR labels early: %v", err)
+				return
+			}
+			if err != nil {
+				log.Printf("Error fetching PR #%d: %v", number, err)
+			} else {
+				labels = labelled{hotfix: github.HasAnyLabel(pr, hotfixLabels), priority: priorities.Of(pr)}
+			}
+			prs[result.PRNumber] = labels
+		}
+		results[i].Hotfix = labels.hotfix
+		results[i].Priority = labels.priority
+	}
 }
 
 // printResults outputs the deployment analysis results in a readable format
@@ -111,8 +984,15 @@ func printResults(results []deploy.DeploymentMetric, prStats []deploy.PRDeployme
 	fmt.Println("\nSuccessful Deployments (Commit to Deploy Latency):")
 	fmt.Println("---------------------------------------------------")
 
+	multiRegion := len(deploymentsByRegion(results)) > 1
 	for _, result := range results {
+		if !result.DeploymentSuccessful {
+			continue // Listed with the failure statistics
+		}
 		fmt.Printf("Release: %s\n", result.ReleaseID)
+		if multiRegion {
+			fmt.Printf("  Region: %s\n", result.Region)
+		}
 		fmt.Printf("  Commit SHA: %s\n", result.CommitSHA)
 		if result.PRNumber != "" {
 			fmt.Printf("  PR Number: %s\n", result.PRNumber)
@@ -121,6 +1001,25 @@ func printResults(results []deploy.DeploymentMetric, prStats []deploy.PRDeployme
 		fmt.Printf("  Release Start: %s\n", result.ReleaseStartTime.Format("2006-01-02 15:04:05 MST"))
 		fmt.Printf("  Rollouts Completed: %s\n", result.ReleaseFinishTime.Format("2006-01-02 15:04:05 MST"))
 		fmt.Printf("  Commit-to-Deploy Latency: %v\n", result.CommitToDeployLatency.Truncate(time.Second))
+		if result.ErrorRateMeasured {
+			fmt.Printf("  Error Rate: %.4g before, %.4g after", result.ErrorRateBefore, result.ErrorRateAfter)
+			if result.ErrorRateRegressed {
+				fmt.Print(" (REGRESSION)")
+			}
+			fmt.Println()
+		}
+		if result.Rollback {
+			fmt.Println("  Rollback: to an earlier commit")
+		}
+		if result.RolledBack {
+			fmt.Printf("  Rolled Back: after %v\n", result.TimeToRollback.Truncate(time.Second))
+		}
+		if result.SentryRelease != "" {
+			fmt.Printf("  Sentry Release: %s (%s, %d new issues)\n", result.SentryRelease, crashFreeDescription(result), result.NewIssues)
+		}
+		for _, name := range slices.Sorted(maps.Keys(result.Extra)) {
+			fmt.Printf("  %s: %s\n", name, result.Extra[name])
+		}
 		fmt.Println()
 	}
 
@@ -128,27 +1027,6 @@ func printResults(results []deploy.DeploymentMetric, prStats []deploy.PRDeployme
 	printPRDeploymentStatistics(prStats)
 }
 
-// calculateMedian calculates the median of a slice of time.Duration
-func calculateMedian(durations []time.Duration) time.Duration {
-	n := len(durations)
-	if n == 0 {
-		return 0
-	}
-
-	// Sort the slice
-	slices.Sort(durations)
-
-	// If odd, return the middle element
-	if n%2 != 0 {
-		return durations[n/2]
-	}
-
-	// If even, return the average of the two middle elements
-	mid1 := durations[(n/2)-1]
-	mid2 := durations[n/2]
-	return (mid1 + mid2) / 2
-}
-
 // printDeploymentSummaryStatistics calculates and displays mean and median deployment latencies
 func printDeploymentSummaryStatistics(results []deploy.DeploymentMetric) {
 	var commitToDeployLatencies []time.Duration
@@ -170,7 +1048,7 @@ func printDeploymentSummaryStatistics(results []deploy.DeploymentMetric) {
 		meanLatency := totalCommitToDeployLatency / time.Duration(len(commitToDeployLatencies))
 
 		// Calculate median
-		medianLatency := calculateMedian(commitToDeployLatencies)
+		medianLatency := stats.MedianOf(commitToDeployLatencies)
 
 		fmt.Printf("Successful Deployments: %d\n", len(commitToDeployLatencies))
 		fmt.Println("Commit-to-Deploy Latency:")
@@ -238,3 +1116,382 @@ func printPRDeploymentStatistics(prStats []deploy.PRDeploymentStats) {
 	fmt.Printf("  PRs with multiple deployments: %d\n", totalPRsWithMultipleDeployments)
 	fmt.Printf("  Maximum deployments for a single PR: %d\n", maxDeployments)
 }
+
+// printPeriodStatistics displays deployment latency for releases started in each period
+func printPeriodStatistics(periods []period.Period, results []deploy.DeploymentMetric) {
+	if len(periods) == 0 {
+		return
+	}
+
+	fmt.Println("\nStatistics by Period:")
+	fmt.Println("---------------------")
+
+	groups := period.Group(periods, results, func(result deploy.DeploymentMetric) time.Time {
+		return result.ReleaseStartTime
+	})
+	for i, p := range periods {
+		var latencies []time.Duration
+		var total time.Duration
+		for _, result := range groups[i] {
+			if result.DeploymentSuccessful && result.CommitToDeployLatency > 0 {
+				latencies = append(latencies, result.CommitToDeployLatency)
+				total += result.CommitToDeployLatency
+			}
+		}
+
+		fmt.Printf("%s (%s to %s): %d deployments\n", p.Name, p.Start.Format("2006-01-02"), p.End.AddDate(0, 0, -1).Format("2006-01-02"), len(latencies))
+		if len(latencies) == 0 {
+			fmt.Println("  Commit-to-Deploy Latency: No data")
+			continue
+		}
+		mean := total / time.Duration(len(latencies))
+		fmt.Printf("  Commit-to-Deploy Latency: Mean %v, Median %v\n", mean.Truncate(time.Second), stats.MedianOf(latencies).Truncate(time.Second))
+	}
+}
+
+// deploymentsByRegion groups deployments by the Cloud Deploy or Cloud Run region
+// they were in
+func deploymentsByRegion(results []deploy.DeploymentMetric) map[string][]deploy.DeploymentMetric {
+	byRegion := make(map[string][]deploy.DeploymentMetric)
+	for _, result := range results {
+		if result.Region != "" {
+			byRegion[result.Region] = append(byRegion[result.Region], result)
+		}
+	}
+	return byRegion
+}
+
+// printRegionStatistics breaks deployments and their latency down by region, when
+// releases were fetched from more than one
+func printRegionStatistics(results []deploy.DeploymentMetric) {
+	byRegion := deploymentsByRegion(results)
+	if len(byRegion) < 2 {
+		return
+	}
+
+	fmt.Println("\nBy Region:")
+	fmt.Println("----------")
+	for _, region := range slices.Sorted(maps.Keys(byRegion)) {
+		var latencies []time.Duration
+		var failed int
+		for _, result := range byRegion[region] {
+			if !result.DeploymentSuccessful {
+				failed++
+			} else if result.CommitToDeployLatency > 0 {
+				latencies = append(latencies, result.CommitToDeployLatency)
+			}
+		}
+		fmt.Printf("%s: %d deployments, %d failed, median commit-to-deploy latency %v\n", region, len(byRegion[region])-failed, failed, stats.MedianOf(latencies).Truncate(time.Second))
+	}
+}
+
+// printHotfixStatistics compares deployment latency for hotfix PRs against everything
+// else, and reports what share of deployments were hotfixes
+func printHotfixStatistics(results []deploy.DeploymentMetric) {
+	var hotfixLatencies, otherLatencies []time.Duration
+	hotfixCount := 0
+	for _, result := range results {
+		if result.Hotfix {
+			hotfixCount++
+		}
+		if !result.DeploymentSuccessful || result.CommitToDeployLatency <= 0 {
+			continue
+		}
+		if result.Hotfix {
+			hotfixLatencies = append(hotfixLatencies, result.CommitToDeployLatency)
+		} else {
+			otherLatencies = append(otherLatencies, result.CommitToDeployLatency)
+		}
+	}
+	if hotfixCount == 0 {
+		return
+	}
+
+	fmt.Println("\nHotfix Deployments:")
+	fmt.Println("-------------------")
+	fmt.Printf("Hotfix Deployments: %d/%d (%.1f%%)\n", hotfixCount, len(results), float64(hotfixCount)/float64(len(results))*100)
+
+	for _, group := range []struct {
+		name      string
+		latencies []time.Duration
+	}{
+		{"Hotfixes", hotfixLatencies},
+		{"Other Deployments", otherLatencies},
+	} {
+		if len(group.latencies) == 0 {
+			fmt.Printf("%s Commit-to-Deploy Latency: No data\n", group.name)
+			continue
+		}
+		var total time.Duration
+		for _, d := range group.latencies {
+			total += d
+		}
+		mean := total / time.Duration(len(group.latencies))
+		fmt.Printf("%s Commit-to-Deploy Latency: Mean %v, Median %v\n", group.name, mean.Truncate(time.Second), stats.MedianOf(group.latencies).Truncate(time.Second))
+	}
+}
+
+// printPriorityStatistics compares commit-to-deploy latency across the priority
+// levels of the deployed PRs, most urgent first, to check urgent changes really
+// ship faster
+func printPriorityStatistics(results []deploy.DeploymentMetric, priorities github.Priorities) {
+	if len(priorities) == 0 {
+		return
+	}
+	latencies := make(map[string][]time.Duration)
+	counts := make(map[string]int)
+	for _, result := range results {
+		if !result.DeploymentSuccessful {
+			continue
+		}
+		level := result.Priority
+		if level == "" {
+			level = github.NoPriority
+		}
+		counts[level]++
+		if result.CommitToDeployLatency > 0 {
+			latencies[level] = append(latencies[level], result.CommitToDeployLatency)
+		}
+	}
+
+	fmt.Println("\nDeployments by Priority:")
+	fmt.Println("------------------------")
+	for _, level := range append(priorities.Levels(), github.NoPriority) {
+		if counts[level] == 0 {
+			continue
+		}
+		if len(latencies[level]) == 0 {
+			fmt.Printf("%s: %d deployments, Commit-to-Deploy Latency: No data\n", level, counts[level])
+			continue
+		}
+		var total time.Duration
+		for _, d := range latencies[level] {
+			total += d
+		}
+		mean := total / time.Duration(len(latencies[level]))
+		fmt.Printf("%s: %d deployments, Commit-to-Deploy Latency: Mean %v, Median %v\n", level, counts[level], mean.Truncate(time.Second), stats.MedianOf(latencies[level]).Truncate(time.Second))
+	}
+}
+
+// printRollbackStatistics reports how many deployments were rolled back, and how
+// long they were live first
+func printRollbackStatistics(results []deploy.DeploymentMetric) {
+	stats := deploy.SummarizeRollbacks(results)
+	if stats.RolledBack == 0 && stats.Rollbacks == 0 {
+		return
+	}
+
+	fmt.Println("\nRollbacks:")
+	fmt.Println("----------")
+	fmt.Printf("Rolled Back Deployments: %d/%d (%.1f%%)\n", stats.RolledBack, stats.Deployments, stats.Rate()*100)
+	fmt.Printf("Rollbacks to an Earlier Commit: %d\n", stats.Rollbacks)
+	if stats.RolledBack > 0 {
+		fmt.Printf("Mean Time to Rollback: %v\n", stats.MeanTimeToRollback.Truncate(time.Second))
+		fmt.Printf("Median Time to Rollback: %v\n", stats.MedianTimeToRollback.Truncate(time.Second))
+	}
+	for _, result := range results {
+		if result.RolledBack {
+			fmt.Printf("  %s (%s): rolled back after %v\n", result.ReleaseID, result.ReleaseFinishTime.Format("2006-01-02 15:04 MST"), result.TimeToRollback.Truncate(time.Second))
+		}
+	}
+}
+
+// printFailureStatistics reports the change failure rate from releases that failed
+// to deploy, overall and for each pipeline, and lists the failed releases
+func printFailureStatistics(results []deploy.DeploymentMetric) {
+	byPipeline, total := deploy.SummarizeFailures(results)
+	if total.Failed == 0 {
+		return
+	}
+
+	fmt.Println("\nFailed Deployments:")
+	fmt.Println("-------------------")
+	fmt.Printf("Change Failure Rate (failed deployments): %d/%d (%.1f%%)\n", total.Failed, total.Deployments, total.Rate()*100)
+	if len(byPipeline) > 1 {
+		for _, p := range byPipeline {
+			fmt.Printf("  %s: %d/%d (%.1f%%)\n", p.Pipeline, p.Failed, p.Deployments, p.Rate()*100)
+		}
+	}
+	fmt.Println("Failed Releases:")
+	for _, result := range results {
+		if result.DeploymentSuccessful {
+			continue
+		}
+		fmt.Printf("  %s (%s): %s", result.ReleaseID, result.ReleaseStartTime.Format("2006-01-02 15:04 MST"), result.FailureState)
+		if result.FailureMessage != "" {
+			fmt.Printf(": %s", result.FailureMessage)
+		}
+		fmt.Println()
+	}
+}
+
+// printRolloutPhaseStatistics breaks successful deployments' time down by phase:
+// rendering, then each target's rollout queueing, deploying and verifying
+func printRolloutPhaseStatistics(results []deploy.DeploymentMetric) {
+	renderTime, byTarget := deploy.SummarizeRolloutPhases(results)
+	if len(byTarget) == 0 {
+		return
+	}
+
+	fmt.Println("\nRollout Phases (median):")
+	fmt.Println("------------------------")
+	fmt.Printf("Render: %v\n", renderTime.Truncate(time.Second))
+	for _, target := range byTarget {
+		fmt.Printf("  %s (%d rollouts): queued %v, deploying %v, verifying %v\n", target.Target, target.Rollouts,
+			target.QueueTime.Truncate(time.Second), target.DeployTime.Truncate(time.Second), target.VerifyTime.Truncate(time.Second))
+	}
+}
+
+// crashFreeDescription describes a deployment's crash-free session rate, if its
+// release sent sessions
+func crashFreeDescription(result deploy.DeploymentMetric) string {
+	if !result.HasHealthData {
+		return "no session data"
+	}
+	return fmt.Sprintf("%.2f%% crash-free sessions", result.CrashFreeRate)
+}
+
+// printReleaseHealthStatistics summarizes the quality of what was deployed, from
+// the Sentry releases matched to deployments, and lists the least healthy ones
+func printReleaseHealthStatistics(results []deploy.DeploymentMetric) {
+	stats := sentry.SummarizeHealth(results)
+
+	fmt.Println("\nRelease Health:")
+	fmt.Println("---------------")
+	if stats.Matched == 0 {
+		fmt.Printf("No Sentry releases matched the %d successful deployments\n", stats.Deployments)
+		return
+	}
+	fmt.Printf("Deployments Matched to a Sentry Release: %d/%d\n", stats.Matched, stats.Deployments)
+	if stats.WithHealthData > 0 {
+		fmt.Printf("Median Crash-Free Sessions: %.2f%% (%d deployments with session data)\n", stats.MedianCrashFreeRate, stats.WithHealthData)
+	}
+	fmt.Printf("New Issues: %d\n", stats.NewIssues)
+	fmt.Printf("Deployments Introducing New Issues: %d/%d (%.1f%%)\n", stats.DeploymentsWithNewIssues, stats.Matched, float64(stats.DeploymentsWithNewIssues)/float64(stats.Matched)*100)
+
+	// The least healthy deployments first: fewest crash-free sessions, then most new issues
+	var matched []deploy.DeploymentMetric
+	for _, result := range results {
+		if result.DeploymentSuccessful && result.SentryRelease != "" && (result.HasHealthData || result.NewIssues > 0) {
+			matched = append(matched, result)
+		}
+	}
+	// Releases without session data sort as if none crashed
+	crashFree := func(result deploy.DeploymentMetric) float64 {
+		if !result.HasHealthData {
+			return 100
+		}
+		return result.CrashFreeRate
+	}
+	slices.SortStableFunc(matched, func(a, b deploy.DeploymentMetric) int {
+		if c := cmp.Compare(crashFree(a), crashFree(b)); c != 0 {
+			return c
+		}
+		return b.NewIssues - a.NewIssues
+	})
+	if len(matched) > 5 {
+		matched = matched[:5]
+	}
+	if len(matched) > 0 {
+		fmt.Println("Least Healthy Deployments:")
+		for _, result := range matched {
+			fmt.Printf("  %s (%s): %s, %d new issues\n", result.ReleaseID, result.SentryRelease, crashFreeDescription(result), result.NewIssues)
+		}
+	}
+}
+
+// printErrorRateStatistics reports the change failure rate: the share of
+// deployments whose error rate was compared that were followed by a regression
+func printErrorRateStatistics(results []deploy.DeploymentMetric) {
+	failed, measured := errorrate.ChangeFailureRate(results)
+
+	fmt.Println("\nError Rate Regressions:")
+	fmt.Println("-----------------------")
+	if measured == 0 {
+		fmt.Println("No deployments had their error rate compared")
+		return
+	}
+	fmt.Printf("Change Failure Rate (error rate regressions): %d/%d (%.1f%%)\n", failed, measured, float64(failed)/float64(measured)*100)
+	for _, result := range results {
+		if result.ErrorRateRegressed {
+			fmt.Printf("  %s (%s): %.4g before, %.4g after\n", result.ReleaseID, result.ReleaseFinishTime.Format("2006-01-02 15:04 MST"), result.ErrorRateBefore, result.ErrorRateAfter)
+		}
+	}
+}
+
+// parseServiceNames parses -pagerduty-service-names: comma-separated pipeline=service
+// pairs
+func parseServiceNames(s string) (map[string]string, error) {
+	names := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		pipeline, service, ok := strings.Cut(pair, "=")
+		if !ok || pipeline == "" || service == "" {
+			return nil, fmt.Errorf("invalid -pagerduty-service-names entry %q; expected pipeline=service", pair)
+		}
+		names[pipeline] = service
+	}
+	return names, nil
+}
+
+// printIncidentStatistics displays each service's risk score, the share of its
+// deployments followed by an incident, overall and in each period, and the
+// deployments that were
+func printIncidentStatistics(periods []period.Period, results []deploy.DeploymentMetric, window time.Duration) {
+	fmt.Println("\nDeployments Followed by Incidents:")
+	fmt.Println("----------------------------------")
+	risks := pagerduty.SummarizeRisk(results)
+	if len(risks) == 0 {
+		fmt.Println("No successful deployments to match incidents to")
+		return
+	}
+	fmt.Printf("Risk score: the share of a service's deployments followed by an incident within %v\n", window)
+	for _, risk := range risks {
+		fmt.Printf("  %s: %d/%d (%.1f%%)\n", risk.Service, risk.FollowedByIncident, risk.Deployments, risk.Score()*100)
+	}
+
+	if len(periods) > 0 {
+		fmt.Println("Risk score by period:")
+		groups := period.Group(periods, results, func(result deploy.DeploymentMetric) time.Time {
+			return result.ReleaseStartTime
+		})
+		for i, p := range periods {
+			fmt.Printf("  %s (%s to %s):\n", p.Name, p.Start.Format("2006-01-02"), p.End.AddDate(0, 0, -1).Format("2006-01-02"))
+			for _, risk := range pagerduty.SummarizeRisk(groups[i]) {
+				fmt.Printf("    %s: %d/%d (%.1f%%)\n", risk.Service, risk.FollowedByIncident, risk.Deployments, risk.Score()*100)
+			}
+		}
+	}
+
+	fmt.Println("Deployments followed by an incident:")
+	for _, result := range results {
+		if len(result.FollowingIncidents) > 0 {
+			fmt.Printf("  %s (%s): %s\n", result.ReleaseID, result.ReleaseFinishTime.Format("2006-01-02 15:04 MST"), strings.Join(result.FollowingIncidents, ", "))
+		}
+	}
+}
+
+// printRestoreStatistics displays each service's mean time to restore, from its
+// incidents to the deployments that remediated them
+func printRestoreStatistics(restores []pagerduty.Restore) {
+	fmt.Println("\nTime to Restore:")
+	fmt.Println("----------------")
+	summaries := pagerduty.SummarizeRestores(restores)
+	if len(summaries) == 0 {
+		fmt.Println("No incidents were opened and resolved in the date range")
+		return
+	}
+	for _, s := range summaries {
+		fmt.Printf("%s: %d resolved incidents, %d remediated by a deployment\n", s.Service, s.Incidents, s.Remediated)
+		if s.Remediated > 0 {
+			fmt.Printf("  Mean Time to Restore: %v, Median %v\n", s.MeanTimeToRestore.Truncate(time.Second), s.MedianTimeToRestore.Truncate(time.Second))
+		}
+	}
+	for _, restore := range restores {
+		if restore.Remediation != "" {
+			fmt.Printf("  %s: restored in %v by %s\n", restore.Incident, restore.TimeToRestore.Truncate(time.Second), restore.Remediation)
+		}
+	}
+}
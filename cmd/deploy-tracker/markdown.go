@@ -0,0 +1,149 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/errorrate"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// markdownTopN is how many deployments and PRs the "top" tables list
+const markdownTopN = 5
+
+// printMarkdown writes a compact Markdown summary, suitable for pasting into Slack or a GitHub discussion
+func printMarkdown(w io.Writer, project string, startDate, endDate time.Time, results []deploy.DeploymentMetric, prStats []deploy.PRDeploymentStats, periods []period.Period, partial bool) {
+	fmt.Fprintf(w, "### Deployment stats for %s (%s to %s)\n\n", project, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if partial {
+		fmt.Fprintf(w, "> **Partial results:** the API call budget ran out before every release was processed.\n\n")
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No deployment metrics found.")
+		return
+	}
+
+	var successful []deploy.DeploymentMetric
+	var latencies []time.Duration
+	var total time.Duration
+	for _, result := range results {
+		if result.DeploymentSuccessful && result.CommitToDeployLatency > 0 {
+			successful = append(successful, result)
+			latencies = append(latencies, result.CommitToDeployLatency)
+			total += result.CommitToDeployLatency
+		}
+	}
+
+	var totalPRDeployments, multipleDeployments int
+	for _, pr := range prStats {
+		totalPRDeployments += pr.DeploymentCount
+		if pr.DeploymentCount > 1 {
+			multipleDeployments++
+		}
+	}
+
+	mean, median := "-", "-"
+	if len(latencies) > 0 {
+		mean = markdown.Duration(total / time.Duration(len(latencies)))
+		median = markdown.Duration(stats.MedianOf(latencies))
+	}
+	summary := [][]string{
+		{"Successful deployments", fmt.Sprint(len(successful))},
+		{"Commit-to-deploy latency (mean)", mean},
+		{"Commit-to-deploy latency (median)", median},
+		{"PRs deployed", fmt.Sprint(len(prStats))},
+		{"PR deployments", fmt.Sprint(totalPRDeployments)},
+		{"PRs deployed more than once", fmt.Sprint(multipleDeployments)},
+	}
+	if failed, measured := errorrate.ChangeFailureRate(results); measured > 0 {
+		summary = append(summary, []string{"Change failure rate (error rate regressions)", fmt.Sprintf("%d/%d (%.1f%%)", failed, measured, float64(failed)/float64(measured)*100)})
+	}
+	if _, failures := deploy.SummarizeFailures(results); failures.Failed > 0 {
+		summary = append(summary, []string{"Change failure rate (failed deployments)", fmt.Sprintf("%d/%d (%.1f%%)", failures.Failed, failures.Deployments, failures.Rate()*100)})
+	}
+	if rollbacks := deploy.SummarizeRollbacks(results); rollbacks.RolledBack > 0 {
+		summary = append(summary,
+			[]string{"Rollback rate", fmt.Sprintf("%d/%d (%.1f%%)", rollbacks.RolledBack, rollbacks.Deployments, rollbacks.Rate()*100)},
+			[]string{"Time to rollback (median)", markdown.Duration(rollbacks.MedianTimeToRollback)},
+		)
+	}
+	markdown.Table(w, []string{"Metric", "Value"}, summary)
+
+	if len(periods) > 0 {
+		fmt.Fprintf(w, "\n**By period**\n\n")
+		groups := period.Group(periods, successful, func(result deploy.DeploymentMetric) time.Time {
+			return result.ReleaseStartTime
+		})
+		var rows [][]string
+		for i, p := range periods {
+			var periodLatencies []time.Duration
+			for _, result := range groups[i] {
+				periodLatencies = append(periodLatencies, result.CommitToDeployLatency)
+			}
+			rows = append(rows, []string{p.Name, fmt.Sprint(len(groups[i])), markdown.Duration(stats.MedianOf(periodLatencies))})
+		}
+		markdown.Table(w, []string{"Period", "Deployments", "Commit to deploy (median)"}, rows)
+	}
+
+	slices.SortFunc(successful, func(a, b deploy.DeploymentMetric) int {
+		return cmp.Compare(b.CommitToDeployLatency, a.CommitToDeployLatency)
+	})
+	if len(successful) > 0 {
+		fmt.Fprintf(w, "\n**Slowest deployments**\n\n")
+		var rows [][]string
+		for _, result := range successful[:min(markdownTopN, len(successful))] {
+			pr := "-"
+			if result.PRNumber != "" {
+				pr = "#" + result.PRNumber
+			}
+			rows = append(rows, []string{result.ReleaseID, pr, markdown.Duration(result.CommitToDeployLatency)})
+		}
+		markdown.Table(w, []string{"Release", "PR", "Commit to deploy"}, rows)
+	}
+
+	var failed [][]string
+	for _, result := range results {
+		if result.DeploymentSuccessful {
+			continue
+		}
+		reason := "-"
+		if result.FailureMessage != "" {
+			reason = result.FailureMessage
+		}
+		failed = append(failed, []string{result.ReleaseID, result.FailureState, reason})
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\n**Failed deployments**\n\n")
+		markdown.Table(w, []string{"Release", "State", "Reason"}, failed[:min(markdownTopN, len(failed))])
+	}
+
+	if renderTime, byTarget := deploy.SummarizeRolloutPhases(results); len(byTarget) > 0 {
+		fmt.Fprintf(w, "\n**Rollout phases** (median; render %s)\n\n", markdown.Duration(renderTime))
+		var rows [][]string
+		for _, target := range byTarget {
+			rows = append(rows, []string{target.Target, fmt.Sprint(target.Rollouts), markdown.Duration(target.QueueTime), markdown.Duration(target.DeployTime), markdown.Duration(target.VerifyTime)})
+		}
+		markdown.Table(w, []string{"Target", "Rollouts", "Queued", "Deploying", "Verifying"}, rows)
+	}
+
+	prStats = slices.Clone(prStats)
+	slices.SortStableFunc(prStats, func(a, b deploy.PRDeploymentStats) int {
+		return cmp.Compare(b.DeploymentCount, a.DeploymentCount)
+	})
+	if len(prStats) > 0 && prStats[0].DeploymentCount > 1 {
+		fmt.Fprintf(w, "\n**Most deployed PRs**\n\n")
+		var rows [][]string
+		for _, pr := range prStats[:min(markdownTopN, len(prStats))] {
+			if pr.DeploymentCount < 2 {
+				break
+			}
+			rows = append(rows, []string{"#" + pr.PRNumber, fmt.Sprint(pr.DeploymentCount), markdown.Duration(pr.FirstToLastDelta)})
+		}
+		markdown.Table(w, []string{"PR", "Deployments", "First commit to last deploy"}, rows)
+	}
+}
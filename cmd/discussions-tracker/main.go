@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+func main() {
+	// Define command line flags
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	outputFormat := flag.String("output", "", "Also export per-discussion metrics to -out-file in this format (csv, parquet)")
+	outFile := flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	maxGitHubCalls := flag.Int("max-github-calls", 0, "Stop gracefully with partial results after this many GitHub API calls (0 = unlimited)")
+	staleWhileRevalidate := flag.Bool("stale-while-revalidate", false, "Serve expired cache entries immediately and refresh them in the background")
+	configPath := config.RegisterFlag(flag.CommandLine)
+
+	// Parse flags
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*configPath, "discussions-tracker", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Check for repository argument
+	args := flag.Args()
+	if len(args) == 0 {
+		args = configRepos
+	}
+	if len(args) < 1 {
+		fmt.Println("Usage: discussions-tracker [flags] owner/repo")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 2 {
+		log.Fatal("Invalid repository format. Use 'owner/repo'")
+	}
+	owner := parts[0]
+	repo := parts[1]
+
+	// Validate export options before doing any work
+	format, err := export.ParseOutputFlags(*outputFormat, *outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	// Get GitHub credentials from environment
+	tokenSource, err := github.TokenSourceFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Create cache
+	cacheImpl, err := cache.NewDefaultCache()
+	if err != nil {
+		log.Fatalf("Error creating cache: %v", err)
+	}
+	defer cacheImpl.Close()
+
+	// Create a cached GitHub client
+	client := github.NewCachedGitHubClientFromTokenSource(tokenSource, cacheImpl)
+	client.SetStaleWhileRevalidate(*staleWhileRevalidate)
+	defer client.Close()
+
+	// Cap API usage so large scans can't exhaust the org's shared rate limit
+	apiBudget := budget.New("API", *maxAPICalls)
+	githubBudget := apiBudget.Child("GitHub API", *maxGitHubCalls)
+	client.SetBudget(githubBudget)
+
+	fmt.Printf("Fetching discussions for %s/%s from %s to %s...\n", owner, repo, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	discussions, err := client.FetchDiscussions(context.Background(), owner, repo, startDate, endDate)
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching discussions early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching discussions: %v", err)
+	}
+
+	fmt.Printf("Found %d discussions for %s/%s\n", len(discussions), owner, repo)
+
+	results := github.ProcessDiscussions(discussions)
+
+	// Print the results
+	if githubBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all discussions were fetched\n", apiBudget.Used())
+	}
+	printResults(results)
+
+	if format != "" {
+		if err := export.WriteFile(format, *outFile, export.DiscussionRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Printf("\nWrote %d discussion records to %s\n", len(results), *outFile)
+	}
+}
+
+// printResults outputs the analysis results in a readable format
+func printResults(results []github.DiscussionMetric) {
+	if len(results) == 0 {
+		fmt.Println("No discussions found")
+		return
+	}
+
+	fmt.Println("\nDiscussions With Maintainer Responses:")
+	fmt.Println("--------------------------------------")
+
+	respondedCount := 0
+	for _, result := range results {
+		if !result.HasResponse {
+			continue
+		}
+		respondedCount++
+		fmt.Printf("#%d: %s [%s]\n", result.Number, result.Title, result.Category)
+		fmt.Printf("  Time to First Response: %v (by %s)\n", result.TimeToFirstResponse.Truncate(time.Second), result.FirstResponder)
+		printResolution(result)
+		fmt.Println()
+	}
+	if respondedCount == 0 {
+		fmt.Println("  None found")
+	}
+
+	fmt.Println("\nDiscussions Awaiting a Maintainer Response:")
+	fmt.Println("-------------------------------------------")
+
+	awaitingCount := 0
+	for _, result := range results {
+		if result.HasResponse {
+			continue
+		}
+		awaitingCount++
+		fmt.Printf("#%d: %s [%s]\n", result.Number, result.Title, result.Category)
+		fmt.Printf("Author: %s\n", result.Author)
+		fmt.Printf("  Waiting for: %v\n", result.TimeSinceCreation.Truncate(time.Second))
+		printResolution(result)
+		fmt.Println()
+	}
+	if awaitingCount == 0 {
+		fmt.Println("  None found")
+	}
+
+	printSummaryStatistics(results)
+}
+
+// printResolution prints how a discussion was resolved, if it was
+func printResolution(result github.DiscussionMetric) {
+	if result.Answerable {
+		if result.Answered {
+			fmt.Printf("  Time to Marked Answer: %v\n", result.TimeToAnswer.Truncate(time.Second))
+		} else {
+			fmt.Printf("  Time to Marked Answer: Not yet answered\n")
+		}
+	}
+	if result.Closed {
+		fmt.Printf("  Time to Close: %v\n", result.TimeToClose.Truncate(time.Second))
+	}
+}
+
+// printSummaryStatistics calculates and displays mean and median response and answer times
+func printSummaryStatistics(results []github.DiscussionMetric) {
+	var responseTimes []time.Duration
+	var answerTimes []time.Duration
+	var totalResponseTime time.Duration
+	var totalAnswerTime time.Duration
+	answerableCount := 0
+
+	for _, result := range results {
+		if result.HasResponse {
+			responseTimes = append(responseTimes, result.TimeToFirstResponse)
+			totalResponseTime += result.TimeToFirstResponse
+		}
+		if result.Answerable {
+			answerableCount++
+			if result.Answered {
+				answerTimes = append(answerTimes, result.TimeToAnswer)
+				totalAnswerTime += result.TimeToAnswer
+			}
+		}
+	}
+
+	fmt.Println("\nSummary Statistics:")
+	fmt.Println("-----------------")
+
+	fmt.Printf("Maintainer Response Rate: %d/%d (%.1f%%)\n", len(responseTimes), len(results), float64(len(responseTimes))/float64(len(results))*100)
+	if len(responseTimes) > 0 {
+		meanResponseTime := totalResponseTime / time.Duration(len(responseTimes))
+		medianResponseTime := stats.MedianOf(responseTimes)
+
+		fmt.Println("Time to First Maintainer Response:")
+		fmt.Printf("  Mean: %v\n", meanResponseTime.Truncate(time.Second))
+		fmt.Printf("  Median: %v\n", medianResponseTime.Truncate(time.Second))
+	} else {
+		fmt.Println("Time to First Maintainer Response: No data")
+	}
+
+	if answerableCount > 0 {
+		fmt.Printf("Answered Questions: %d/%d (%.1f%%)\n", len(answerTimes), answerableCount, float64(len(answerTimes))/float64(answerableCount)*100)
+	}
+	if len(answerTimes) > 0 {
+		meanAnswerTime := totalAnswerTime / time.Duration(len(answerTimes))
+		medianAnswerTime := stats.MedianOf(answerTimes)
+
+		fmt.Println("Time to Marked Answer:")
+		fmt.Printf("  Mean: %v\n", meanAnswerTime.Truncate(time.Second))
+		fmt.Printf("  Median: %v\n", medianAnswerTime.Truncate(time.Second))
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/expr"
+	"github.com/reillywatson/statstracker/internal/hooks"
+	"github.com/reillywatson/statstracker/internal/launchdarkly"
+	"github.com/reillywatson/statstracker/internal/pagerduty"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+func main() {
+	// Define command line flags
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	project := flag.String("project", "default", "LaunchDarkly project key")
+	environmentsList := flag.String("environments", "", "Comma-separated environment keys, e.g. 'production', to count flag changes in (defaults to all of the project's environments)")
+	deploymentsFile := flag.String("deployments", "", "Deployments exported by deploy-tracker -output csv or parquet, to correlate flag changes with")
+	servicesList := flag.String("pagerduty-services", "", "Comma-separated PagerDuty service IDs whose incidents to correlate flag changes with (needs PAGERDUTY_API_KEY)")
+	window := flag.Duration("window", time.Hour, "How close to a flag change a deployment, or how soon after it an incident, counts as related")
+	outputFormat := flag.String("output", "", "Also export per-change metrics to -out-file in this format (csv, parquet)")
+	outFile := flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	maxLaunchDarklyCalls := flag.Int("max-launchdarkly-calls", 0, "Stop gracefully with partial results after this many LaunchDarkly API calls (0 = unlimited)")
+	maxPagerDutyCalls := flag.Int("max-pagerduty-calls", 0, "Stop fetching incidents after this many PagerDuty API calls (0 = unlimited)")
+	exprFlags := expr.RegisterFlags(flag.CommandLine)
+	retryFlags := retry.RegisterFlags(flag.CommandLine)
+	hookCommand := flag.String("hook", "", "Program to run on each flag change, given its export record as JSON on stdin, whose JSON output adds fields to the report and export")
+	configPath := config.RegisterFlag(flag.CommandLine)
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	if _, err := config.Apply(*configPath, "flag-tracker", flag.CommandLine); err != nil {
+		log.Fatal(err)
+	}
+
+	// Validate export options before doing any work
+	format, err := export.ParseOutputFlags(*outputFormat, *outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := exprFlags.Rules()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *window <= 0 {
+		log.Fatal("-window must be positive")
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	// Get the LaunchDarkly access token from environment
+	token := os.Getenv("LAUNCHDARKLY_ACCESS_TOKEN")
+	if token == "" {
+		fmt.Println("Usage: flag-tracker [flags]")
+		fmt.Println("Example: flag-tracker -project web -environments production -deployments deploys.csv")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		fmt.Println("\nRequired environment variables:")
+		fmt.Println("  LAUNCHDARKLY_ACCESS_TOKEN: LaunchDarkly access token that can read the audit log")
+		os.Exit(1)
+	}
+
+	// Deployments come from an earlier deploy-tracker run. Failed ones didn't
+	// ship anything, so they're left out.
+	var deployments []launchdarkly.Event
+	if *deploymentsFile != "" {
+		records, err := export.ReadFile[export.DeploymentRecord](*deploymentsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, record := range records {
+			if record.DeploymentSuccessful && !record.ReleaseFinishTime.IsZero() {
+				deployments = append(deployments, launchdarkly.Event{Name: record.ReleaseName, At: record.ReleaseFinishTime})
+			}
+		}
+		fmt.Printf("Read %d deployments from %s\n", len(deployments), *deploymentsFile)
+	}
+
+	// Cap API usage so large scans can't exhaust shared rate limits
+	apiBudget := budget.New("API", *maxAPICalls)
+	launchDarklyBudget := apiBudget.Child("LaunchDarkly API", *maxLaunchDarklyCalls)
+	pagerDutyBudget := apiBudget.Child("PagerDuty API", *maxPagerDutyCalls)
+
+	ctx := context.Background()
+
+	// Incidents opened up to a window after the range can still follow a change in it
+	var incidents []launchdarkly.Event
+	if *servicesList != "" {
+		pagerDutyKey := os.Getenv("PAGERDUTY_API_KEY")
+		if pagerDutyKey == "" {
+			log.Fatal("PAGERDUTY_API_KEY environment variable not set (needed for -pagerduty-services)")
+		}
+		pagerDutyClient := pagerduty.NewPagerDutyClient(pagerDutyKey)
+		pagerDutyClient.SetBudget(pagerDutyBudget)
+		fetched, err := pagerDutyClient.FetchIncidents(ctx, splitList(*servicesList), startDate, endDate.Add(*window))
+		if errors.Is(err, budget.ErrExhausted) {
+			log.Printf("Stopped fetching incidents early: %v", err)
+		} else if err != nil {
+			log.Fatalf("Error fetching incidents: %v", err)
+		}
+		for _, incident := range fetched {
+			incidents = append(incidents, launchdarkly.Event{Name: incident.String(), At: incident.CreatedAt})
+		}
+		fmt.Printf("Found %d incidents\n", len(incidents))
+	}
+
+	client := launchdarkly.NewLaunchDarklyClient(token, *project)
+	client.SetBudget(launchDarklyBudget)
+	client.SetRetryPolicy(retryFlags.Policy())
+
+	fmt.Printf("Fetching flag changes in project %s from %s to %s...\n", *project, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	changes, err := client.FetchFlagChanges(ctx, splitList(*environmentsList), startDate, endDate)
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching flag changes early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching flag changes: %v", err)
+	}
+
+	fmt.Printf("Found %d flag changes\n", len(changes))
+
+	results := launchdarkly.ProcessFlagChanges(changes, deployments, incidents, *window)
+
+	// Let a user-provided program add fields to each change, such as the flag's owning team
+	if *hookCommand != "" {
+		hook, err := hooks.New(*hookCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i := range results {
+			extra, err := hook.Run(export.FlagChangeRecords(results[i : i+1])[0])
+			if err != nil {
+				log.Printf("Error running hook for %s: %v", results[i].ID, err)
+				continue
+			}
+			results[i].Extra = extra
+		}
+	}
+
+	// Add the configured computed columns, and leave out changes the filter rejects
+	if rules != nil {
+		kept := results[:0]
+		for _, result := range results {
+			record := export.FlagChangeRecords([]launchdarkly.FlagChangeMetric{result})[0]
+			extra, keep, err := rules.Apply(export.Fields(record), result.Extra)
+			if err != nil {
+				log.Fatalf("Error applying -where/-column to %s: %v", result.ID, err)
+			}
+			if keep {
+				result.Extra = extra
+				kept = append(kept, result)
+			}
+		}
+		results = kept
+	}
+
+	// Print the results
+	if launchDarklyBudget.Exhausted() || pagerDutyBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all flag changes or incidents were fetched\n", apiBudget.Used())
+	}
+	printResults(results, *servicesList != "", startDate, endDate)
+	if *deploymentsFile != "" {
+		printDeploymentComparison(results, deployments, incidents, *servicesList != "", startDate, endDate, *window)
+	}
+
+	if format != "" {
+		if err := export.WriteFile(format, *outFile, export.FlagChangeRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Printf("\nWrote %d flag change records to %s\n", len(results), *outFile)
+	}
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// printResults outputs each environment's flag changes, and the changes followed
+// by incidents, in a readable format
+func printResults(results []launchdarkly.FlagChangeMetric, withIncidents bool, start, end time.Time) {
+	if len(results) == 0 {
+		fmt.Println("No flag changes found")
+		return
+	}
+
+	fmt.Println("\nFlag Changes by Environment:")
+	fmt.Println("----------------------------")
+
+	for _, s := range launchdarkly.SummarizeEnvironments(results, start, end) {
+		fmt.Printf("%s\n", s.Environment)
+		fmt.Printf("  Changes: %d to %d flags (%.1f per week)\n", s.Changes, s.FlagsChanged, s.ChangesPerWeek)
+		if s.ChangesNearDeployments > 0 {
+			fmt.Printf("  Changes Near a Deployment: %d\n", s.ChangesNearDeployments)
+		}
+		if withIncidents {
+			fmt.Printf("  Followed by an Incident: %d (%.1f%%)\n", s.ChangesFollowedByIncident, s.IncidentRate()*100)
+		}
+		fmt.Println()
+	}
+
+	var followed []launchdarkly.FlagChangeMetric
+	for _, result := range results {
+		if len(result.FollowingIncidents) > 0 {
+			followed = append(followed, result)
+		}
+	}
+	if len(followed) == 0 {
+		return
+	}
+	fmt.Println("Flag Changes Followed by Incidents:")
+	fmt.Println("-----------------------------------")
+	for _, result := range followed {
+		fmt.Printf("%s in %s: %s", result.Flag, result.Environment, result.Description)
+		if result.Member != "" {
+			fmt.Printf(" (by %s)", result.Member)
+		}
+		fmt.Printf(" at %s\n", result.ChangedAt.Format("2006-01-02 15:04 MST"))
+		for _, incident := range result.FollowingIncidents {
+			fmt.Printf("  Incident: %s\n", incident)
+		}
+		for _, name := range slices.Sorted(maps.Keys(result.Extra)) {
+			fmt.Printf("  %s: %s\n", name, result.Extra[name])
+		}
+	}
+	fmt.Println()
+}
+
+// printDeploymentComparison compares how often flags changed with how often code
+// was deployed, and how often each was followed by an incident
+func printDeploymentComparison(results []launchdarkly.FlagChangeMetric, deployments, incidents []launchdarkly.Event, withIncidents bool, start, end time.Time, window time.Duration) {
+	var deployed, deploymentsFollowed int
+	for _, d := range deployments {
+		if d.At.Before(start) || d.At.After(end) {
+			continue
+		}
+		deployed++
+		if len(launchdarkly.Between(incidents, d.At, d.At.Add(window))) > 0 {
+			deploymentsFollowed++
+		}
+	}
+	changesFollowed := 0
+	for _, result := range results {
+		if len(result.FollowingIncidents) > 0 {
+			changesFollowed++
+		}
+	}
+
+	fmt.Println("Flag Changes vs Deployments:")
+	fmt.Println("----------------------------")
+	fmt.Printf("Flag Changes: %d\n", len(results))
+	fmt.Printf("Deployments: %d\n", deployed)
+	if total := len(results) + deployed; total > 0 {
+		fmt.Printf("Share of Changes Made with Flags: %.1f%%\n", float64(len(results))/float64(total)*100)
+	}
+	if withIncidents {
+		if len(results) > 0 {
+			fmt.Printf("Flag Changes Followed by an Incident: %.1f%%\n", float64(changesFollowed)/float64(len(results))*100)
+		}
+		if deployed > 0 {
+			fmt.Printf("Deployments Followed by an Incident: %.1f%%\n", float64(deploymentsFollowed)/float64(deployed)*100)
+		}
+	}
+}
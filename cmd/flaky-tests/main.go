@@ -2,25 +2,59 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"slices"
+	"strings"
 
+	"github.com/reillywatson/statstracker/internal/budget"
 	"github.com/reillywatson/statstracker/internal/cache"
 	"github.com/reillywatson/statstracker/internal/circleci"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/expr"
+	"github.com/reillywatson/statstracker/internal/hooks"
+	"github.com/reillywatson/statstracker/internal/retry"
 )
 
 func main() {
 	// Define command line flags
+	outputFormat := flag.String("output", "", "Also export per-test metrics to -out-file in this format (csv, parquet)")
+	outFile := flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	maxCircleCICalls := flag.Int("max-circleci-calls", 0, "Stop gracefully with partial results after this many CircleCI API calls (0 = unlimited)")
+	exprFlags := expr.RegisterFlags(flag.CommandLine)
+	retryFlags := retry.RegisterFlags(flag.CommandLine)
+	hookCommand := flag.String("hook", "", "Program to run on each flaky test, given its export record as JSON on stdin, whose JSON output adds fields to the report and export")
+	staleWhileRevalidate := flag.Bool("stale-while-revalidate", false, "Serve expired cache entries immediately and refresh them in the background")
+	configPath := config.RegisterFlag(flag.CommandLine)
 	flag.Parse()
 
-	// Check for org and repo arguments
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*configPath, "flaky-tests", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Check for org and repo arguments, given separately or as org/repo
 	args := flag.Args()
+	if len(args) == 0 {
+		args = configRepos
+	}
+	if len(args) == 1 {
+		if org, repo, ok := strings.Cut(args[0], "/"); ok {
+			args = []string{org, repo}
+		}
+	}
 	if len(args) < 2 {
-		fmt.Println("Usage: flaky-tests <org> <repo>")
+		fmt.Println("Usage: flaky-tests [flags] <org> <repo>")
 		fmt.Println("Example: flaky-tests my-org my-repo")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
 		fmt.Println("\nRequired environment variables:")
 		fmt.Println("  CIRCLECI_TOKEN: CircleCI API token")
 		os.Exit(1)
@@ -29,6 +63,16 @@ func main() {
 	org := args[0]
 	repo := args[1]
 
+	// Validate export options before doing any work
+	format, err := export.ParseOutputFlags(*outputFormat, *outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := exprFlags.Rules()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Get CircleCI token from environment
 	token := os.Getenv("CIRCLECI_TOKEN")
 	if token == "" {
@@ -44,8 +88,15 @@ func main() {
 
 	// Create a cached CircleCI client
 	client := circleci.NewCachedCircleCIClient(token, cacheImpl)
+	client.SetStaleWhileRevalidate(*staleWhileRevalidate)
 	defer client.Close()
 
+	// Cap API usage so large scans can't exhaust shared rate limits
+	apiBudget := budget.New("API", *maxAPICalls)
+	circleBudget := apiBudget.Child("CircleCI API", *maxCircleCICalls)
+	client.SetBudget(circleBudget)
+	client.SetRetryPolicy(retryFlags.Policy())
+
 	ctx := context.Background()
 
 	// First verify we can access the project
@@ -58,7 +109,9 @@ func main() {
 	// Fetch flaky tests
 	fmt.Printf("Fetching flaky tests for %s/%s...\n", org, repo)
 	tests, err := client.FetchFlakyTests(ctx, org, repo)
-	if err != nil {
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching flaky tests early: %v", err)
+	} else if err != nil {
 		log.Fatalf("Error fetching flaky tests: %v", err)
 	}
 
@@ -67,8 +120,51 @@ func main() {
 	// Process flaky tests to gather metrics
 	results := circleci.ProcessFlakyTests(tests)
 
+	// Let a user-provided program add fields to each test, such as its owning team
+	if *hookCommand != "" {
+		hook, err := hooks.New(*hookCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i := range results {
+			extra, err := hook.Run(export.FlakyTestRecords(results[i : i+1])[0])
+			if err != nil {
+				log.Printf("Error running hook for %s: %v", results[i].TestName, err)
+				continue
+			}
+			results[i].Extra = extra
+		}
+	}
+
+	// Add the configured computed columns, and leave out tests the filter rejects
+	if rules != nil {
+		kept := results[:0]
+		for _, result := range results {
+			record := export.FlakyTestRecords([]circleci.FlakyTestMetric{result})[0]
+			extra, keep, err := rules.Apply(export.Fields(record), result.Extra)
+			if err != nil {
+				log.Fatalf("Error applying -where/-column to %s: %v", result.TestName, err)
+			}
+			if keep {
+				result.Extra = extra
+				kept = append(kept, result)
+			}
+		}
+		results = kept
+	}
+
 	// Print the results
+	if circleBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all flaky tests were fetched\n", apiBudget.Used())
+	}
 	printResults(results)
+
+	if format != "" {
+		if err := export.WriteFile(format, *outFile, export.FlakyTestRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Printf("\nWrote %d flaky test records to %s\n", len(results), *outFile)
+	}
 }
 
 // printResults outputs the flaky test analysis results in a readable format
@@ -90,6 +186,9 @@ func printResults(results []circleci.FlakyTestMetric) {
 		if result.LastOccurred != nil {
 			fmt.Printf("  Last Occurred: %s\n", result.LastOccurred.Format("2006-01-02 15:04:05 MST"))
 		}
+		for _, name := range slices.Sorted(maps.Keys(result.Extra)) {
+			fmt.Printf("  %s: %s\n", name, result.Extra[name])
+		}
 		fmt.Println()
 	}
 
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/forecast"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+func main() {
+	// Define command line flags
+	wipHistoryPath := flag.String("wip-history", "", "WIP history recorded by pr-tracker -wip -wip-history, to read weekly throughput from (required)")
+	remaining := flag.Int("remaining", 0, "Number of PRs still to be merged (required)")
+	trials := flag.Int("trials", 10000, "Number of simulated futures")
+	lastWeeks := flag.Int("weeks", 0, "Only draw throughput from the most recent this many complete weeks (0 = every week recorded)")
+	percentilesStr := flag.String("percentiles", "50,70,85,95", "Comma-separated confidence levels, as percentages of trials, to report completion dates for")
+	startDateStr := flag.String("start", "", "Date the remaining work starts from, in YYYY-MM-DD format (defaults to today)")
+	seed := flag.Uint64("seed", 0, "Seed for the simulation's random numbers, to reproduce a forecast (0 = a different one each run)")
+	reportFormat := flag.String("format", "text", "Console report format: text, or markdown for a compact summary to paste into Slack or GitHub")
+	configPath := config.RegisterFlag(flag.CommandLine)
+
+	// Parse flags
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*configPath, "forecast", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repoArgs := flag.Args()
+	if len(repoArgs) == 0 {
+		repoArgs = configRepos
+	}
+	if *wipHistoryPath == "" || *remaining <= 0 {
+		fmt.Println("Usage: forecast -wip-history FILE -remaining N [flags] [owner/repo...]")
+		fmt.Println("Forecasts when N more PRs will be merged by simulating weeks of the throughput recorded in a WIP history.")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	for _, arg := range repoArgs {
+		if parts := strings.Split(arg, "/"); len(parts) != 2 {
+			log.Fatalf("Invalid repository format %q. Use 'owner/repo'", arg)
+		}
+	}
+
+	useMarkdown, err := markdown.ParseFormat(*reportFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var percentiles []int
+	for _, s := range strings.Split(*percentilesStr, ",") {
+		p, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			log.Fatalf("Invalid percentile %q: %v", s, err)
+		}
+		percentiles = append(percentiles, p)
+	}
+
+	start := time.Now()
+	if *startDateStr != "" {
+		start, err = time.ParseInLocation("2006-01-02", *startDateStr, time.Local)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+	}
+
+	history, err := wip.OpenHistory(*wipHistoryPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	weeks := forecast.WeeklyThroughput(history.Snapshots(repoArgs))
+	if *lastWeeks > 0 && len(weeks) > *lastWeeks {
+		weeks = weeks[len(weeks)-*lastWeeks:]
+	}
+	if len(weeks) == 0 {
+		log.Fatalf("No complete weeks of merged counts in %s; record a daily snapshot with pr-tracker -wip -wip-history for at least a week first", *wipHistoryPath)
+	}
+
+	rngSeed := *seed
+	if rngSeed == 0 {
+		rngSeed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(rngSeed, rngSeed))
+	f, err := forecast.Simulate(forecast.Throughputs(weeks), *remaining, *trials, percentiles, start, rng)
+	if err != nil {
+		log.Fatalf("Error forecasting: %v", err)
+	}
+
+	scope := "every repository in the history"
+	if len(repoArgs) > 0 {
+		scope = strings.Join(repoArgs, ", ")
+	}
+	if useMarkdown {
+		printMarkdown(os.Stdout, scope, weeks, f)
+	} else {
+		printForecast(scope, weeks, f)
+	}
+}
+
+// throughputSummary describes the weeks a forecast draws from
+func throughputSummary(weeks []forecast.Week) string {
+	samples := forecast.Throughputs(weeks)
+	sorted := slices.Sorted(slices.Values(samples))
+	return fmt.Sprintf("%d weeks (%s to %s): min %d, median %d, max %d merged a week",
+		len(weeks), weeks[0].Period.Name, weeks[len(weeks)-1].Period.Name, sorted[0], sorted[len(sorted)/2], sorted[len(sorted)-1])
+}
+
+// printForecast outputs the completion date at each confidence level
+func printForecast(scope string, weeks []forecast.Week, f *forecast.Forecast) {
+	fmt.Printf("\nForecast for %d PRs in %s (%d trials):\n", f.Remaining, scope, f.Trials)
+	fmt.Println("------------------------------------------")
+	fmt.Printf("Throughput from %s\n", throughputSummary(weeks))
+	for _, band := range f.Bands {
+		fmt.Printf("  %d%% likely by %s (%d weeks)\n", band.Percentile, band.Date.Format("2006-01-02"), band.Weeks)
+	}
+	if f.Unlikely > 0 {
+		fmt.Printf("%d trials hadn't finished after %d weeks\n", f.Unlikely, forecast.MaxWeeks)
+	}
+}
+
+// printMarkdown writes the forecast as a compact Markdown summary
+func printMarkdown(w io.Writer, scope string, weeks []forecast.Week, f *forecast.Forecast) {
+	fmt.Fprintf(w, "### Forecast for %d PRs in %s\n\n", f.Remaining, scope)
+	fmt.Fprintf(w, "From %d trials drawing on throughput from %s.\n\n", f.Trials, throughputSummary(weeks))
+	var rows [][]string
+	for _, band := range f.Bands {
+		rows = append(rows, []string{fmt.Sprintf("%d%%", band.Percentile), band.Date.Format("2006-01-02"), fmt.Sprint(band.Weeks)})
+	}
+	markdown.Table(w, []string{"Confidence", "Done by", "Weeks"}, rows)
+	if f.Unlikely > 0 {
+		fmt.Fprintf(w, "\n> %d trials hadn't finished after %d weeks.\n", f.Unlikely, forecast.MaxWeeks)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/identity"
+	"github.com/reillywatson/statstracker/internal/linear"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+func main() {
+	// Define command line flags
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	team := flag.String("team", "", "Linear team key, e.g. ENG (required)")
+	outputFormat := flag.String("output", "", "Also export per-issue metrics to -out-file in this format (csv, parquet)")
+	outFile := flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	maxLinearCalls := flag.Int("max-linear-calls", 0, "Stop gracefully with partial results after this many Linear API calls (0 = unlimited)")
+	maxGitHubCalls := flag.Int("max-github-calls", 0, "Stop looking up linked PRs after this many GitHub API calls (0 = unlimited)")
+	identitiesFile := flag.String("identities", "", "YAML file mapping people's accounts across providers, so assignees are reported by the same names as other trackers use")
+	staleWhileRevalidate := flag.Bool("stale-while-revalidate", false, "Serve expired cache entries immediately and refresh them in the background")
+	configPath := config.RegisterFlag(flag.CommandLine)
+
+	// Parse flags
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	if _, err := config.Apply(*configPath, "linear-tracker", flag.CommandLine); err != nil {
+		log.Fatal(err)
+	}
+
+	if *team == "" {
+		fmt.Println("Usage: linear-tracker -team KEY [flags]")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	// Validate export options before doing any work
+	format, err := export.ParseOutputFlags(*outputFormat, *outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var people *identity.Directory
+	if *identitiesFile != "" {
+		people, err = identity.Load(*identitiesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	// Get Linear API key from environment
+	apiKey := os.Getenv("LINEAR_API_KEY")
+	if apiKey == "" {
+		log.Fatal("LINEAR_API_KEY environment variable not set")
+	}
+
+	// Create cache
+	cacheImpl, err := cache.NewDefaultCache()
+	if err != nil {
+		log.Fatalf("Error creating cache: %v", err)
+	}
+	defer cacheImpl.Close()
+
+	// Create a cached Linear client
+	client := linear.NewCachedLinearClient(apiKey, cacheImpl)
+	client.SetStaleWhileRevalidate(*staleWhileRevalidate)
+	defer client.Close()
+
+	// Cap API usage so large scans can't exhaust shared rate limits
+	apiBudget := budget.New("API", *maxAPICalls)
+	linearBudget := apiBudget.Child("Linear API", *maxLinearCalls)
+	githubBudget := apiBudget.Child("GitHub API", *maxGitHubCalls)
+	client.SetBudget(linearBudget)
+
+	fmt.Printf("Fetching issues completed by team %s from %s to %s...\n", *team, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	issues, err := client.FetchCompletedIssues(context.Background(), *team, startDate, endDate)
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching issues early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching issues: %v", err)
+	}
+
+	fmt.Printf("Found %d completed issues for team %s\n", len(issues), *team)
+
+	// Linked PRs' merge times come from GitHub, if we have credentials for it
+	var mergedAt linear.MergedAtFunc
+	if tokenSource, err := github.TokenSourceFromEnv(); err == nil {
+		githubClient := github.NewCachedGitHubClientFromTokenSource(tokenSource, cacheImpl)
+		githubClient.SetStaleWhileRevalidate(*staleWhileRevalidate)
+		githubClient.SetBudget(githubBudget)
+		defer githubClient.Close()
+		mergedAt = prMergedAt(context.Background(), githubClient)
+	} else if errors.Is(err, github.ErrNoCredentials) {
+		fmt.Println("GITHUB_TOKEN not set; skipping PR merge times")
+	} else {
+		log.Fatal(err)
+	}
+
+	results := linear.ProcessIssues(issues, mergedAt)
+	for i := range results {
+		results[i].Assignee = people.Resolve(identity.Linear, results[i].Assignee)
+	}
+
+	// Print the results
+	if linearBudget.Exhausted() || githubBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all issues or PRs were processed\n", apiBudget.Used())
+	}
+	printResults(results)
+
+	if format != "" {
+		if err := export.WriteFile(format, *outFile, export.LinearIssueRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Printf("\nWrote %d issue records to %s\n", len(results), *outFile)
+	}
+}
+
+// prMergedAt looks up linked PRs' merge times on GitHub
+func prMergedAt(ctx context.Context, client *github.CachedGitHubClient) linear.MergedAtFunc {
+	return func(prURL string) (time.Time, bool) {
+		owner, repo, number, ok := linear.ParseGitHubPRURL(prURL)
+		if !ok {
+			return time.Time{}, false
+		}
+		pr, err := client.FetchPullRequest(ctx, owner, repo, number)
+		if err != nil {
+			if !errors.Is(err, budget.ErrExhausted) {
+				log.Printf("Error fetching %s: %v", prURL, err)
+			}
+			return time.Time{}, false
+		}
+		return pr.MergedAt, !pr.MergedAt.IsZero()
+	}
+}
+
+// printResults outputs the analysis results in a readable format
+func printResults(results []linear.IssueMetric) {
+	if len(results) == 0 {
+		fmt.Println("No completed issues found")
+		return
+	}
+
+	fmt.Println("\nCompleted Issues:")
+	fmt.Println("-----------------")
+
+	for _, result := range results {
+		fmt.Printf("%s: %s\n", result.Identifier, result.Title)
+		if result.Assignee != "" {
+			fmt.Printf("  Assignee: %s\n", result.Assignee)
+		}
+		fmt.Printf("  Lead Time: %v\n", result.LeadTime.Truncate(time.Second))
+		if result.CycleTime > 0 {
+			fmt.Printf("  Cycle Time: %v\n", result.CycleTime.Truncate(time.Second))
+		}
+		switch len(result.PullRequests) {
+		case 0:
+			fmt.Printf("  Linked PRs: None\n")
+		default:
+			fmt.Printf("  Linked PRs: %d (first linked after %v)\n", len(result.PullRequests), result.TimeToFirstPR.Truncate(time.Second))
+		}
+		if result.MergeToDone > 0 {
+			fmt.Printf("  Merge to Done: %v\n", result.MergeToDone.Truncate(time.Second))
+		}
+		fmt.Println()
+	}
+
+	printSummaryStatistics(results)
+}
+
+// printSummaryStatistics calculates and displays mean and median planning and delivery times
+func printSummaryStatistics(results []linear.IssueMetric) {
+	var leadTimes, cycleTimes, firstPRTimes, mergeToDoneTimes []time.Duration
+	linkedCount := 0
+	for _, result := range results {
+		leadTimes = append(leadTimes, result.LeadTime)
+		if result.CycleTime > 0 {
+			cycleTimes = append(cycleTimes, result.CycleTime)
+		}
+		if len(result.PullRequests) > 0 {
+			linkedCount++
+		}
+		if result.TimeToFirstPR > 0 {
+			firstPRTimes = append(firstPRTimes, result.TimeToFirstPR)
+		}
+		if result.MergeToDone > 0 {
+			mergeToDoneTimes = append(mergeToDoneTimes, result.MergeToDone)
+		}
+	}
+
+	fmt.Println("\nSummary Statistics:")
+	fmt.Println("-----------------")
+	fmt.Printf("Issues with linked PRs: %d/%d (%.1f%%)\n", linkedCount, len(results), float64(linkedCount)/float64(len(results))*100)
+
+	for _, stat := range []struct {
+		name  string
+		times []time.Duration
+	}{
+		{"Lead Time (created to done)", leadTimes},
+		{"Cycle Time (started to done)", cycleTimes},
+		{"Time to First PR (started to first linked PR)", firstPRTimes},
+		{"Merge to Done (last PR merged to issue done)", mergeToDoneTimes},
+	} {
+		if len(stat.times) == 0 {
+			fmt.Printf("%s: No data\n", stat.name)
+			continue
+		}
+		var total time.Duration
+		for _, d := range stat.times {
+			total += d
+		}
+		mean := total / time.Duration(len(stat.times))
+
+		fmt.Printf("%s:\n", stat.name)
+		fmt.Printf("  Mean: %v\n", mean.Truncate(time.Second))
+		fmt.Printf("  Median: %v\n", stats.MedianOf(stat.times).Truncate(time.Second))
+	}
+}
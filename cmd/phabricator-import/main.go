@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/eventlog"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/phabricator"
+)
+
+func main() {
+	// Define command line flags
+	eventLogPath := flag.String("event-log", "", "Event log to append the imported revisions to, for recompute (required)")
+	owner := flag.String("owner", "phabricator", "Owner to record the repositories under, so recompute reports on them as owner/repository")
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to all history)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully after this many API calls (0 = unlimited)")
+
+	// Parse flags
+	flag.Parse()
+
+	repos := flag.Args()
+	if len(repos) < 1 || *eventLogPath == "" {
+		fmt.Println("Usage: phabricator-import -event-log FILE [flags] repository [repository...]")
+		fmt.Println("Imports Differential revisions into an event log, so recompute can report on review history from before a move to GitHub.")
+		fmt.Println("Repositories are named by their callsign or short name.")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	// Parse dates
+	var startDate time.Time
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	// Get Phabricator credentials from environment
+	phabricatorURL := os.Getenv("PHABRICATOR_URL")
+	if phabricatorURL == "" {
+		log.Fatal("PHABRICATOR_URL environment variable not set")
+	}
+	token := os.Getenv("PHABRICATOR_API_TOKEN")
+	if token == "" {
+		log.Fatal("PHABRICATOR_API_TOKEN environment variable not set")
+	}
+
+	events, err := eventlog.Open(*eventLogPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer events.Close()
+
+	client := phabricator.NewPhabricatorClient(phabricatorURL, token)
+	apiBudget := budget.New("API", *maxAPICalls)
+	client.SetBudget(apiBudget)
+
+	// Everything fetched is recorded just as pr-tracker -event-log records GitHub's
+	// data, so recompute reads it back the same way
+	recorder := github.NewRecordingClient(client, events)
+
+	// Ctrl-C stops importing; whatever was recorded by then stays in the log
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, repo := range repos {
+		fmt.Printf("Importing revisions for %s as %s/%s...\n", repo, *owner, repo)
+		prs, err := recorder.FetchPullRequests(ctx, *owner, repo, startDate, endDate)
+		if errors.Is(err, budget.ErrExhausted) || ctx.Err() != nil {
+			log.Fatalf("Stopped importing early, before %s's revisions were listed: %v", repo, err)
+		} else if err != nil {
+			log.Fatalf("Error fetching revisions for %s: %v", repo, err)
+		}
+
+		// Revisions have no base branch, so recompute asks for the required
+		// approvals of the empty branch; Differential doesn't count them
+		recorder.FetchRequiredApprovals(ctx, *owner, repo, "")
+
+		for i, pr := range prs {
+			if err := importRevision(ctx, recorder, *owner, repo, pr.Number); err != nil {
+				if errors.Is(err, budget.ErrExhausted) || ctx.Err() != nil {
+					log.Fatalf("Stopped importing early, after %d of %s's %d revisions: %v", i, repo, len(prs), err)
+				}
+				log.Fatalf("Error importing D%d: %v", pr.Number, err)
+			}
+		}
+		fmt.Printf("  Imported %d revisions from %s\n", len(prs), repo)
+	}
+
+	fmt.Printf("Import complete (%d API calls); recompute with -event-log %s\n", apiBudget.Used(), *eventLogPath)
+}
+
+// importRevision records everything recompute reads for a revision
+func importRevision(ctx context.Context, client github.GitHubClientInterface, owner, repo string, number int) error {
+	if _, err := client.FetchPullRequestReviews(ctx, owner, repo, number); err != nil {
+		return err
+	}
+	if _, err := client.FetchPullRequestCommits(ctx, owner, repo, number); err != nil {
+		return err
+	}
+	if _, err := client.FetchPullRequestComments(ctx, owner, repo, number); err != nil {
+		return err
+	}
+	if _, err := client.FetchPullRequestTimeline(ctx, owner, repo, number); err != nil {
+		return err
+	}
+	_, err := client.FetchPullRequestReviewRequests(ctx, owner, repo, number)
+	return err
+}
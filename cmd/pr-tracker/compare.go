@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/compare"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// reportComparison prints the comparison of -period-a with -period-b
+func (t *tracker) reportComparison(ctx context.Context, results, headline []github.PullRequestMetric) {
+	comparison := compare.Periods(t.periodA, t.periodB, headline, t.bootstrap)
+	if t.useMarkdown {
+		printComparisonMarkdown(os.Stdout, strings.Join(t.repoArgs, ", "), comparison, t.bootstrap, t.partial(ctx))
+		return
+	}
+	t.printNotes(ctx, results, headline)
+	printComparison(comparison, t.bootstrap)
+}
+
+// periodRange formats a period's dates, both included
+func periodRange(p period.Period) string {
+	return p.Start.Format("2006-01-02") + " to " + p.End.AddDate(0, 0, -1).Format("2006-01-02")
+}
+
+// comparisonChange formats how much a metric's median moved from A to B
+func comparisonChange(m compare.Metric) string {
+	change := m.Change()
+	if math.IsNaN(change) {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f%%", change*100)
+}
+
+// comparisonSignificance formats a metric's p-value and whether it's significant
+func comparisonSignificance(m compare.Metric) string {
+	if math.IsNaN(m.P) {
+		return "-"
+	}
+	if m.TooFew() {
+		return fmt.Sprintf("p = %.3f, too few PRs to tell", m.P)
+	}
+	if m.Significant() {
+		return fmt.Sprintf("p = %.3f, significant", m.P)
+	}
+	return fmt.Sprintf("p = %.3f, not significant", m.P)
+}
+
+// comparisonInterval formats the confidence interval of a period's median, to
+// follow it, or "" if it wasn't estimated
+func comparisonInterval(s compare.Sample, bootstrap *stats.Bootstrap) string {
+	if !s.HasInterval {
+		return ""
+	}
+	return fmt.Sprintf(" (%.0f%% CI %v to %v)", bootstrap.Confidence(), s.MedianLow.Truncate(time.Second), s.MedianHigh.Truncate(time.Second))
+}
+
+// printComparison outputs two periods' review times side by side, with whether
+// each difference is significant
+func printComparison(c compare.Comparison, bootstrap *stats.Bootstrap) {
+	fmt.Println("\nPeriod Comparison:")
+	fmt.Println("------------------")
+	fmt.Printf("A: %s, %d PRs\n", periodRange(c.A), c.PRsA)
+	fmt.Printf("B: %s, %d PRs\n", periodRange(c.B), c.PRsB)
+	if len(c.Metrics) == 0 {
+		fmt.Println("  No data")
+		return
+	}
+
+	for _, m := range c.Metrics {
+		fmt.Printf("%s:\n", m.Name)
+		for _, side := range []struct {
+			name   string
+			sample compare.Sample
+		}{{"A", m.A}, {"B", m.B}} {
+			if side.sample.Count == 0 {
+				fmt.Printf("  %s: No data\n", side.name)
+				continue
+			}
+			fmt.Printf("  %s: Median %v%s, Mean %v (%d PRs)\n", side.name, side.sample.Median.Truncate(time.Second), comparisonInterval(side.sample, bootstrap),
+				side.sample.Mean.Truncate(time.Second), side.sample.Count)
+		}
+		fmt.Printf("  Change in median: %s (%s)\n", comparisonChange(m), comparisonSignificance(m))
+	}
+	fmt.Printf("\nDifferences are tested with a two-sided Mann-Whitney U test; significant means p < %.2f. Metrics with fewer than %d PRs in a period are never called significant.\n", compare.Alpha, stats.MinSample)
+}
+
+// printComparisonMarkdown writes two periods' review times side by side as a Markdown table
+func printComparisonMarkdown(w io.Writer, repo string, c compare.Comparison, bootstrap *stats.Bootstrap, partial bool) {
+	fmt.Fprintf(w, "### PR review stats for %s: %s (A) vs %s (B)\n\n", repo, periodRange(c.A), periodRange(c.B))
+	if partial {
+		fmt.Fprintf(w, "> **Partial results:** the API call budget ran out, or the run was interrupted, before every PR was processed.\n\n")
+	}
+	if len(c.Metrics) == 0 {
+		fmt.Fprintln(w, "No pull requests found.")
+		return
+	}
+
+	median := func(s compare.Sample) string {
+		if s.Count == 0 {
+			return "-"
+		}
+		if s.HasInterval {
+			return fmt.Sprintf("%s (%s to %s, %d)", markdown.Duration(s.Median), markdown.Duration(s.MedianLow), markdown.Duration(s.MedianHigh), s.Count)
+		}
+		return fmt.Sprintf("%s (%d)", markdown.Duration(s.Median), s.Count)
+	}
+	var rows [][]string
+	for _, m := range c.Metrics {
+		significant := "no"
+		if m.Significant() {
+			significant = "**yes**"
+		} else if m.TooFew() {
+			significant = "too few PRs"
+		}
+		p := "-"
+		if !math.IsNaN(m.P) {
+			p = fmt.Sprintf("%.3f", m.P)
+		}
+		rows = append(rows, []string{m.Name, median(m.A), median(m.B), comparisonChange(m), p, significant})
+	}
+	markdown.Table(w, []string{"Metric", "A median (PRs)", "B median (PRs)", "Change", "p", "Significant"}, rows)
+	fmt.Fprintf(w, "\n%d PRs in A, %d in B. Mann-Whitney U test, significant at p < %.2f with at least %d PRs in each period.", c.PRsA, c.PRsB, compare.Alpha, stats.MinSample)
+	if bootstrap != nil {
+		fmt.Fprintf(w, " Ranges are %.0f%% confidence intervals of the medians.", bootstrap.Confidence())
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// interval formats the confidence interval of statistic over durations, to follow
+// the statistic, or "" if intervals aren't being estimated
+func interval(bootstrap *stats.Bootstrap, durations []time.Duration, statistic func([]float64) float64) string {
+	hours := make([]float64, len(durations))
+	for i, d := range durations {
+		hours[i] = d.Hours()
+	}
+	low, high, ok := bootstrap.Interval(hours, statistic)
+	if !ok {
+		return ""
+	}
+	toDuration := func(h float64) time.Duration {
+		return time.Duration(h * float64(time.Hour)).Truncate(time.Second)
+	}
+	return fmt.Sprintf(" (%.0f%% CI %v to %v)", bootstrap.Confidence(), toDuration(low), toDuration(high))
+}
+
+// tooFew notes when a group of PRs is too small to compare with others, or ""
+func tooFew(count int) string {
+	if count < stats.MinSample {
+		return " (too few to compare)"
+	}
+	return ""
+}
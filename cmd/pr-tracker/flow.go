@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/flow"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+// summarizeFlow puts WIP, throughput and cycle time side by side for each team
+// and week, for -flow
+func (t *tracker) summarizeFlow(ctx context.Context) []flow.Week {
+	if t.mergedPRs == nil {
+		log.Fatalf("-flow isn't supported with the %s provider", *t.provider)
+	}
+	// Throughput and cycle time come from the PRs merged in the date range,
+	// however long before it they were opened
+	var merged []github.PullRequestMetric
+	for _, r := range t.repos {
+		prs, err := t.mergedPRs.FetchMergedPullRequests(ctx, r.owner, r.repo, t.startDate, t.endDate)
+		if errors.Is(err, budget.ErrExhausted) || ctx.Err() != nil {
+			log.Printf("Stopped fetching merged pull requests early: %v", err)
+			break
+		} else if err != nil {
+			log.Fatalf("Error fetching merged pull requests for %s/%s: %v", r.owner, r.repo, err)
+		}
+		merged = append(merged, github.BasicMetrics(prs, r.owner, r.repo, t.opts)...)
+	}
+	resolveIdentities(merged, t.people)
+
+	var snapshots []wip.Snapshot
+	if *t.wipHistoryPath != "" {
+		history, err := wip.OpenHistory(*t.wipHistoryPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var repoNames []string
+		for _, r := range t.repos {
+			repoNames = append(repoNames, r.owner+"/"+r.repo)
+		}
+		snapshots = history.Snapshots(repoNames)
+	}
+	var teamOf func(author string) string
+	if t.people.HasTeams() {
+		teamOf = t.people.Team
+	}
+	return flow.Summarize(flowPeriods(t.startDate, t.endDate), merged, snapshots, teamOf)
+}
+
+// flowPeriods returns the weeks flow is reported over, with the first and last
+// cut down to the date range, so throughput in a partial week isn't spread over
+// days that weren't fetched
+func flowPeriods(startDate, endDate time.Time) []period.Period {
+	weeks := period.Weeks(startDate, endDate)
+	for i := range weeks {
+		if weeks[i].Start.Before(startDate) {
+			weeks[i].Start = startDate
+		}
+		if weeks[i].End.After(endDate) {
+			weeks[i].End = endDate
+		}
+	}
+	return weeks
+}
+
+// flowCheck describes whether a week's WIP agrees with what Little's Law predicts
+func flowCheck(week flow.Week) string {
+	deviation, ok := week.Deviation()
+	switch {
+	case !ok:
+		return "-"
+	case deviation > flow.DefaultTolerance:
+		return fmt.Sprintf("inconsistent (%.0f%% apart)", deviation*100)
+	default:
+		return "consistent"
+	}
+}
+
+// flowWIP formats a week's measured WIP, which needs a WIP history
+func flowWIP(week flow.Week) string {
+	if week.WIPDays == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", week.WIP)
+}
+
+// printFlowStatistics outputs WIP, throughput and cycle time per team per week,
+// and how well they agree with Little's Law
+func printFlowStatistics(weeks []flow.Week) {
+	if len(weeks) == 0 {
+		return
+	}
+
+	fmt.Println("\nFlow Metrics by Week (Little's Law):")
+	fmt.Println("------------------------------------")
+	for i, week := range weeks {
+		if i == 0 || week.Period.Name != weeks[i-1].Period.Name {
+			fmt.Printf("%s:\n", week.Period.Name)
+		}
+		fmt.Printf("  %s: WIP %s, throughput %d, cycle time %v, predicted WIP %.1f, %s\n",
+			week.Team, flowWIP(week), week.Throughput, week.CycleTime.Truncate(time.Second), week.PredictedWIP(), flowCheck(week))
+	}
+}
+
+// printFlowMarkdown writes WIP, throughput and cycle time per team per week as a Markdown table
+func printFlowMarkdown(w io.Writer, weeks []flow.Week) {
+	if len(weeks) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n**Flow by week**\n\n")
+	var rows [][]string
+	for _, week := range weeks {
+		rows = append(rows, []string{
+			week.Period.Name, week.Team, flowWIP(week), fmt.Sprint(week.Throughput),
+			markdown.Duration(week.CycleTime), fmt.Sprintf("%.1f", week.PredictedWIP()), flowCheck(week),
+		})
+	}
+	markdown.Table(w, []string{"Week", "Team", "WIP", "Throughput", "Cycle time", "Predicted WIP", "Little's Law"}, rows)
+}
@@ -1,120 +1,829 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"os"
+	"os/signal"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/businesshours"
 	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/codecommit"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/eventlog"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/expr"
+	"github.com/reillywatson/statstracker/internal/flow"
 	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/hooks"
+	"github.com/reillywatson/statstracker/internal/identity"
+	"github.com/reillywatson/statstracker/internal/incremental"
+	"github.com/reillywatson/statstracker/internal/linear"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/pagerduty"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/progress"
+	"github.com/reillywatson/statstracker/internal/retry"
+	"github.com/reillywatson/statstracker/internal/slo"
+	"github.com/reillywatson/statstracker/internal/stats"
 )
 
 func main() {
-	// Define command line flags
-	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
-	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
-	denyListStr := flag.String("exclude", "", "Comma-separated list of GitHub usernames to ignore")
-	tagsRepoStr := flag.String("tags-repo", "", "Tags repository in owner/repo format for checking tag commits")
-
-	// Parse flags
+	f := registerFlags()
 	flag.Parse()
+	t := newTracker(f)
+	closeClients := t.connect()
+	defer closeClients()
+
+	// Ctrl-C stops fetching, and the results so far are reported
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *t.wipMode {
+		t.reportWIP(ctx)
+		return
+	}
+	results, headline := t.fetchResults(ctx)
+	if t.comparing {
+		t.reportComparison(ctx, results, headline)
+	} else {
+		t.report(ctx, results, headline)
+	}
+	t.export(results)
+}
+
+// flags are pr-tracker's command line flags
+type flags struct {
+	startDateStr         *string
+	endDateStr           *string
+	periodAStr           *string
+	periodBStr           *string
+	confidence           *float64
+	denyListStr          *string
+	includeBots          *bool
+	botPatternsStr       *string
+	includeAuthorsStr    *string
+	identitiesFile       *string
+	baseBranchesStr      *string
+	onCallSchedulesStr   *string
+	tagsRepoStr          *string
+	requiredApprovals    *int
+	excludeDismissed     *bool
+	excludeComments      *bool
+	firstResponse        *bool
+	codeowners           *bool
+	byAuthor             *bool
+	reviewers            *bool
+	byChangeType         *bool
+	byLanguage           *bool
+	classify             *bool
+	bySize               *bool
+	codingTime           *bool
+	draftTime            *bool
+	reviewRequests       *bool
+	comments             *bool
+	excludeClassesStr    *string
+	hotfixLabelsStr      *string
+	priorities           github.Priorities
+	objectives           slo.Objectives
+	reposFile            *string
+	reportFormat         *string
+	outputFormat         *string
+	outFile              *string
+	reviewsOutFile       *string
+	maxAPICalls          *int
+	maxGitHubCalls       *int
+	concurrency          *int
+	fetchWindow          *time.Duration
+	periodFlags          *period.Flags
+	businessHoursFlags   *businesshours.Flags
+	staleWhileRevalidate *bool
+	exprFlags            *expr.Flags
+	retryFlags           *retry.Flags
+	hookCommand          *string
+	incrementalPath      *string
+	wipMode              *bool
+	wipHistoryPath       *string
+	cfdPath              *string
+	flowMode             *bool
+	eventLogPath         *string
+	provider             *string
+	quiet                *bool
+	configPath           *string
+}
+
+// registerFlags defines pr-tracker's flags on the command line
+func registerFlags() *flags {
+	f := &flags{}
+	f.startDateStr = flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	f.endDateStr = flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	f.periodAStr = flag.String("period-a", "", "Compare review times in two date ranges instead of reporting on -since to -until: the first, as YYYY-MM-DD..YYYY-MM-DD (used with -period-b)")
+	f.periodBStr = flag.String("period-b", "", "The date range to compare with -period-a, as YYYY-MM-DD..YYYY-MM-DD")
+	f.confidence = flag.Float64("confidence", 0, "Report bootstrap confidence intervals at this level, e.g. 95, next to means and medians (0 = don't)")
+	f.denyListStr = flag.String("exclude", "", "Comma-separated list of GitHub usernames to ignore")
+	f.includeBots = flag.Bool("include-bots", false, "Count PRs and reviews by bots, which are left out by default")
+	f.botPatternsStr = flag.String("bot-patterns", "", "Comma-separated glob patterns, e.g. '*-ci,deploy-*', for bot logins GitHub doesn't mark as bots")
+	f.includeAuthorsStr = flag.String("include-authors", "", "Comma-separated list of GitHub usernames to restrict PRs to, such as a team's members (reviews from anyone still count)")
+	f.identitiesFile = flag.String("identities", "", "YAML file mapping people's accounts across providers, so authors and reviewers are reported by name")
+	f.baseBranchesStr = flag.String("base", "", "Comma-separated base branches, e.g. 'main,release/*', to restrict PRs to; PRs into other branches are ignored")
+	f.onCallSchedulesStr = flag.String("oncall-schedules", "", "Comma-separated PagerDuty schedule IDs; -by-author and -reviewers note the weeks each person was on call (needs PAGERDUTY_API_KEY)")
+	f.tagsRepoStr = flag.String("tags-repo", "", "Tags repository in owner/repo format for checking tag commits")
+	f.requiredApprovals = flag.Int("required-approvals", 0, "Number of distinct approvals a PR needs (0 = read from the base branch's protection rules)")
+	f.excludeDismissed = flag.Bool("exclude-dismissed-approvals", false, "Don't count approvals that were later dismissed toward time to approval and required approvals")
+	f.excludeComments = flag.Bool("exclude-comment-reviews", false, "Don't count reviews that only left comments, without approving or requesting changes, as a PR's first review")
+	f.firstResponse = flag.Bool("first-response", false, "Also measure time to first response: until anyone but the author reviewed, or commented on the PR or its diff")
+	f.codeowners = flag.Bool("codeowners", false, "Measure time until the CODEOWNERS rules each PR triggers were satisfied, per owning team")
+	f.byAuthor = flag.Bool("by-author", false, "Also break review times down by PR author")
+	f.reviewers = flag.Bool("reviewers", false, "Also print a reviewer leaderboard: reviews given, median response time and approval rate per reviewer")
+	f.byChangeType = flag.Bool("by-change-type", false, "Also break PR counts and review times down by conventional-commit type (feat, fix, chore, ...) from PR titles")
+	f.byLanguage = flag.Bool("languages", false, "Classify PRs by the language most of their changes are in and break review times down by language")
+	f.classify = flag.Bool("classify", false, "Classify PRs as docs, test, config or code by the paths they change and break review times down by class")
+	f.bySize = flag.Bool("size", false, "Measure the lines and files each PR changes and break review times down by size (XS to XL)")
+	f.codingTime = flag.Bool("coding-time", false, "Measure coding time, from each PR's first commit until it was opened, as part of cycle time")
+	f.draftTime = flag.Bool("draft-time", false, "Measure review times from when PRs opened as drafts were marked ready for review, and report time in draft")
+	f.reviewRequests = flag.Bool("review-requests", false, "Measure each reviewer's response time from when their review was requested, rather than from when the PR was opened")
+	f.comments = flag.Bool("comments", false, "Count reviewers' inline comments per PR and per 100 lines changed, to spot rubber-stamp approvals; implies -size")
+	f.excludeClassesStr = flag.String("exclude-classes", "", "Comma-separated PR classes (docs, test, config, code) to leave out of the headline numbers; implies -classify")
+	f.hotfixLabelsStr = flag.String("hotfix-labels", "hotfix,emergency", "Comma-separated labels that mark PRs as hotfixes; their review times are reported separately (empty to disable)")
+	flag.Var(&f.priorities, "priority", "Priority level and the labels that put a PR at it, as level=label,label..., e.g. P0=sev0,urgent; review times are reported per level; give the most urgent first (repeatable)")
+	flag.Var(&f.objectives, "slo", "Delivery SLO to report error budgets for, as metric=threshold@target, e.g. first-review=8h@90% (metrics: first-review, approval, review-request; repeatable)")
+	f.reposFile = flag.String("repos-file", "", "File listing more repositories to report on together, one owner/repo per line")
+	f.reportFormat = flag.String("format", "text", "Console report format: text, or markdown for a compact summary to paste into Slack or GitHub")
+	f.outputFormat = flag.String("output", "", "Also export per-PR metrics to -out-file in this format (csv, parquet)")
+	f.outFile = flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	f.reviewsOutFile = flag.String("reviews-out-file", "", "Also export every review, one row each (PR, reviewer, state, submitted_at, latency), to this file: Parquet if it ends in .parquet, otherwise CSV")
+	f.maxAPICalls = flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	f.maxGitHubCalls = flag.Int("max-github-calls", 0, "Stop gracefully with partial results after this many GitHub API calls (0 = unlimited)")
+	f.concurrency = flag.Int("concurrency", 4, "Number of PRs whose reviews are fetched at once (1 fetches them one at a time)")
+	f.fetchWindow = flag.Duration("fetch-window", 0, "Fetch and process each repository's PRs this much of the date range at a time, e.g. 168h, to keep memory down on long scans of busy repositories (0 = the whole range at once)")
+	f.periodFlags = period.RegisterFlags(flag.CommandLine)
+	f.businessHoursFlags = businesshours.RegisterFlags(flag.CommandLine)
+	f.staleWhileRevalidate = flag.Bool("stale-while-revalidate", false, "Serve expired cache entries immediately and refresh them in the background")
+	f.exprFlags = expr.RegisterFlags(flag.CommandLine)
+	f.retryFlags = retry.RegisterFlags(flag.CommandLine)
+	f.hookCommand = flag.String("hook", "", "Program to run on each PR, given its export record as JSON on stdin, whose JSON output adds fields to the report and export")
+	f.incrementalPath = flag.String("incremental", "", "Keep results in this file and on later runs only fetch PRs created since the last run (or still open then), merging them with the stored results")
+	f.wipMode = flag.Bool("wip", false, "Instead of PRs created in the date range, list the PRs open now with their age, size, review state and SLA status (-since and -until are ignored)")
+	f.wipHistoryPath = flag.String("wip-history", "", "With -wip, record each repository's open PRs for the day in this file and report WIP over the days recorded; with -flow, read WIP from it")
+	f.cfdPath = flag.String("cfd", "", "With -wip and -wip-history, also write a cumulative flow diagram of the days recorded (PRs in draft, open, approved and merged each day) to this file: an HTML chart if it ends in .html, Parquet if .parquet, otherwise CSV")
+	f.flowMode = flag.Bool("flow", false, "Also report flow metrics per team per week: average WIP from the -wip-history snapshots, throughput and cycle time of the PRs merged, checked against Little's Law (teams come from -identities)")
+	f.eventLogPath = flag.String("event-log", "", "Append the raw GitHub data fetched to this file, so metrics can be recomputed later without re-fetching")
+	f.provider = flag.String("provider", "github", "Where the repositories are hosted: github, or codecommit for AWS CodeCommit, with repositories given as region/repository (needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	f.quiet = flag.Bool("quiet", false, "Don't print progress messages, such as the count of PRs processed so far")
+	f.configPath = config.RegisterFlag(flag.CommandLine)
+	return f
+}
+
+// repoRef is a repository to report on
+type repoRef struct{ owner, repo string }
 
-	// Check for repository argument
-	args := flag.Args()
-	if len(args) < 1 {
-		fmt.Println("Usage: pr-tracker [flags] owner/repo")
+// tracker is a pr-tracker run: its flags, what they were checked and parsed
+// into, and, once connected, the clients each mode fetches PRs with
+type tracker struct {
+	*flags
+
+	repoArgs           []string // The repositories as given, for report titles
+	repos              []repoRef
+	startDate, endDate time.Time
+	comparing          bool // Whether -period-a and -period-b were given
+	periodA, periodB   period.Period
+	people             *identity.Directory
+	format             export.Format
+	useMarkdown        bool
+	schedule           *businesshours.Schedule
+	rules              *expr.Rules
+	bootstrap          *stats.Bootstrap // nil unless -confidence is set
+	excludedClasses    []string
+	pagerDutyKey       string
+	status             io.Writer // Where progress messages go
+	opts               github.ProcessOptions
+
+	cacheImpl cache.Cache
+	apiBudget *budget.Budget
+	prBudget  *budget.Budget
+	prClient  github.GitHubClientInterface
+	openPRs   openPullRequestFetcher   // nil if the provider can't list the PRs open now
+	mergedPRs mergedPullRequestFetcher // nil if the provider can't search for merged PRs
+}
+
+// newTracker checks the parsed flags, and the config file, before any work is
+// done, exiting with a message if they're wrong
+func newTracker(f *flags) *tracker {
+	t := &tracker{flags: f}
+
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*t.configPath, "pr-tracker", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Collect repositories from the arguments and -repos-file
+	t.repoArgs = flag.Args()
+	if len(t.repoArgs) == 0 {
+		t.repoArgs = configRepos
+	}
+	if *t.reposFile != "" {
+		fileRepos, err := readRepoList(*t.reposFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t.repoArgs = append(t.repoArgs, fileRepos...)
+	}
+	if len(t.repoArgs) < 1 {
+		fmt.Println("Usage: pr-tracker [flags] owner/repo [owner/repo...]")
 		fmt.Println("Flags:")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	repoArg := args[0]
-	parts := strings.Split(repoArg, "/")
-	if len(parts) != 2 {
-		log.Fatal("Invalid repository format. Use 'owner/repo'")
+	for _, arg := range t.repoArgs {
+		parts := strings.Split(arg, "/")
+		if len(parts) != 2 {
+			log.Fatalf("Invalid repository format %q. Use 'owner/repo'", arg)
+		}
+		t.repos = append(t.repos, repoRef{parts[0], parts[1]})
 	}
-	owner := parts[0]
-	repo := parts[1]
 
-	// Parse tags repository if provided
-	var tagsOwner, tagsRepo string
-	if *tagsRepoStr != "" {
-		tagsParts := strings.Split(*tagsRepoStr, "/")
-		if len(tagsParts) != 2 {
-			log.Fatal("Invalid tags repository format. Use 'owner/repo'")
+	if *t.identitiesFile != "" {
+		t.people, err = identity.Load(*t.identitiesFile)
+		if err != nil {
+			log.Fatal(err)
 		}
-		tagsOwner = tagsParts[0]
-		tagsRepo = tagsParts[1]
 	}
 
-	denylist := strings.Split(*denyListStr, ",")
+	// Validate output options before doing any work
+	t.format, err = export.ParseOutputFlags(*t.outputFormat, *t.outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.useMarkdown, err = markdown.ParseFormat(*t.reportFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := t.periodFlags.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	t.schedule, err = t.businessHoursFlags.Schedule()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.rules, err = t.exprFlags.Rules()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, objective := range t.objectives {
+		switch objective.Metric {
+		case "first-review", "approval":
+		case "review-request":
+			*t.reviewRequests = true
+		default:
+			log.Fatalf("Unsupported SLO metric %q (supported: first-review, approval, review-request)", objective.Metric)
+		}
+	}
+	if *t.cfdPath != "" && (!*t.wipMode || *t.wipHistoryPath == "") {
+		log.Fatal("-cfd needs -wip and -wip-history")
+	}
+	if *t.reviewsOutFile != "" && *t.wipMode {
+		log.Fatal("-reviews-out-file can't be used with -wip")
+	}
+	if *t.confidence != 0 {
+		if t.bootstrap, err = stats.NewBootstrap(*t.confidence); err != nil {
+			log.Fatalf("Invalid -confidence: %v", err)
+		}
+	}
+	// Keep progress messages out of the Markdown so it can be piped straight into a post
+	t.status = os.Stdout
+	if t.useMarkdown {
+		t.status = os.Stderr
+	}
+	if *t.quiet {
+		t.status = io.Discard
+	}
+
+	t.parseDateRange()
+
+	t.pagerDutyKey = os.Getenv("PAGERDUTY_API_KEY")
+	if *t.onCallSchedulesStr != "" && t.pagerDutyKey == "" {
+		log.Fatal("PAGERDUTY_API_KEY environment variable not set (needed for -oncall-schedules)")
+	}
+
+	if *t.excludeClassesStr != "" {
+		for _, class := range strings.Split(*t.excludeClassesStr, ",") {
+			class = strings.TrimSpace(class)
+			if !slices.Contains([]string{github.ClassDocs, github.ClassTest, github.ClassConfig, github.ClassCode}, class) {
+				log.Fatalf("Invalid PR class %q. Use docs, test, config or code", class)
+			}
+			t.excludedClasses = append(t.excludedClasses, class)
+		}
+		*t.classify = true
+	}
+	t.opts = t.processOptions()
+	return t
+}
+
+// parseDateRange parses the date range PRs are fetched from: -since to -until,
+// or when comparing, what -period-a and -period-b cover
+func (t *tracker) parseDateRange() {
+	var err error
+	t.comparing = *t.periodAStr != "" || *t.periodBStr != ""
+	if t.comparing {
+		if *t.periodAStr == "" || *t.periodBStr == "" {
+			log.Fatal("-period-a and -period-b must be given together")
+		}
+		if *t.wipMode {
+			log.Fatal("-period-a and -period-b can't be used with -wip")
+		}
+		if t.periodA, err = period.ParseRange("A", *t.periodAStr); err != nil {
+			log.Fatalf("Invalid -period-a: %v", err)
+		}
+		if t.periodB, err = period.ParseRange("B", *t.periodBStr); err != nil {
+			log.Fatalf("Invalid -period-b: %v", err)
+		}
+		// A PR in both would make the periods look more alike than they are
+		if t.periodA.Start.Before(t.periodB.End) && t.periodB.Start.Before(t.periodA.End) {
+			log.Fatal("-period-a and -period-b overlap")
+		}
+	}
 
 	// Parse start date
-	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
-	if *startDateStr != "" {
-		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+	t.startDate = time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *t.startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *t.startDateStr)
 		if err != nil {
 			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
 		}
-		startDate = parsedDate
+		t.startDate = parsedDate
 	}
-	endDate := time.Now() // Default to now
-	if *endDateStr != "" {
-		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+	t.endDate = time.Now() // Default to now
+	if *t.endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *t.endDateStr)
 		if err != nil {
 			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
 		}
-		endDate = parsedDate
+		t.endDate = parsedDate
 	}
-	if startDate.After(endDate) {
+	if t.startDate.After(t.endDate) {
 		log.Fatal("Start date cannot be after end date")
 	}
+	// Comparing fetches from the start of the earlier period to the end of the later
+	if t.comparing {
+		t.startDate, t.endDate = t.periodA.Start, t.periodB.End
+		if t.periodB.Start.Before(t.startDate) {
+			t.startDate, t.endDate = t.periodB.Start, t.periodA.End
+		}
+	}
+}
 
-	// Get GitHub token from environment
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("GITHUB_TOKEN environment variable not set")
+// processOptions returns the options PRs are processed with
+func (t *tracker) processOptions() github.ProcessOptions {
+	// Parse tags repository if provided
+	var tagsOwner, tagsRepo string
+	if *t.tagsRepoStr != "" {
+		tagsParts := strings.Split(*t.tagsRepoStr, "/")
+		if len(tagsParts) != 2 {
+			log.Fatal("Invalid tags repository format. Use 'owner/repo'")
+		}
+		tagsOwner = tagsParts[0]
+		tagsRepo = tagsParts[1]
 	}
 
+	users := github.UserFilter{IncludeBots: *t.includeBots}
+	if *t.botPatternsStr != "" {
+		users.BotPatterns = strings.Split(*t.botPatternsStr, ",")
+	}
+	if *t.denyListStr != "" {
+		users.Exclude = strings.Split(*t.denyListStr, ",")
+	}
+	if *t.includeAuthorsStr != "" {
+		users.Authors = strings.Split(*t.includeAuthorsStr, ",")
+	}
+
+	var baseBranches []string
+	if *t.baseBranchesStr != "" {
+		baseBranches = strings.Split(*t.baseBranchesStr, ",")
+	}
+
+	var hotfixLabels []string
+	if *t.hotfixLabelsStr != "" {
+		hotfixLabels = strings.Split(*t.hotfixLabelsStr, ",")
+	}
+
+	opts := github.ProcessOptions{
+		Users:             users,
+		BaseBranches:      baseBranches,
+		TagsOwner:         tagsOwner,
+		TagsRepo:          tagsRepo,
+		RequiredApprovals: *t.requiredApprovals,
+		ExcludeDismissed:  *t.excludeDismissed,
+		ExcludeComments:   *t.excludeComments,
+		Codeowners:        *t.codeowners,
+		Languages:         *t.byLanguage,
+		Classify:          *t.classify,
+		Size:              *t.bySize || *t.comments,
+		Comments:          *t.comments,
+		FirstResponse:     *t.firstResponse,
+		CodingTime:        *t.codingTime,
+		DraftTime:         *t.draftTime,
+		ReviewRequests:    *t.reviewRequests,
+		BusinessHours:     t.schedule,
+		HotfixLabels:      hotfixLabels,
+		Priorities:        t.priorities,
+		Concurrency:       *t.concurrency,
+	}
+	if *t.hookCommand != "" {
+		hook, err := hooks.New(*t.hookCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Stages = append(opts.Stages, hookStage(hook))
+	}
+	return opts
+}
+
+// connect creates the cache and the client for wherever the repositories are
+// hosted, capped by the API budgets. The returned function closes them.
+func (t *tracker) connect() func() {
 	// Create cache
-	cacheImpl, err := cache.NewDefaultCache()
+	var err error
+	t.cacheImpl, err = cache.NewDefaultCache()
 	if err != nil {
 		log.Fatalf("Error creating cache: %v", err)
 	}
-	defer cacheImpl.Close()
+	closers := []io.Closer{t.cacheImpl}
+
+	// Cap API usage so large scans can't exhaust the org's shared rate limit
+	t.apiBudget = budget.New("API", *t.maxAPICalls)
+
+	// Create the client for wherever the repositories are hosted
+	switch *t.provider {
+	case "github":
+		tokenSource, err := github.TokenSourceFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		client := github.NewCachedGitHubClientFromTokenSource(tokenSource, t.cacheImpl)
+		client.SetStaleWhileRevalidate(*t.staleWhileRevalidate)
+		client.SetRetryPolicy(t.retryFlags.Policy())
+		closers = append(closers, client)
+		t.prBudget = t.apiBudget.Child("GitHub API", *t.maxGitHubCalls)
+		client.SetBudget(t.prBudget)
+		t.prClient = client
+		t.openPRs = client
+		t.mergedPRs = client
+	case "codecommit":
+		creds := codecommit.Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			log.Fatal("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables not set")
+		}
+		client := codecommit.NewCodeCommitClient(creds)
+		t.prBudget = t.apiBudget.Child("CodeCommit API", 0)
+		client.SetBudget(t.prBudget)
+		t.prClient = client
+	default:
+		log.Fatalf("Invalid provider %q. Use github or codecommit", *t.provider)
+	}
+
+	// Record what's fetched, if asked, separately from the cache, which expires
+	if *t.eventLogPath != "" {
+		events, err := eventlog.Open(*t.eventLogPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		closers = append(closers, events)
+		t.prClient = github.NewRecordingClient(t.prClient, events)
+	}
+
+	// Count PRs as they're processed, keeping log messages off the progress line
+	if !*t.quiet {
+		reporter := progress.New(os.Stderr, "PRs", t.apiBudget)
+		log.SetOutput(reporter)
+		t.opts.Progress = reporter
+	}
 
-	// Create a cached GitHub client
-	client := github.NewCachedGitHubClient(token, cacheImpl)
-	defer client.Close()
+	return func() {
+		for _, c := range slices.Backward(closers) {
+			c.Close()
+		}
+	}
+}
+
+// fetchResults fetches and processes each repository's PRs in the date range.
+// headline leaves out the PRs in -exclude-classes.
+func (t *tracker) fetchResults(ctx context.Context) (results, headline []github.PullRequestMetric) {
+	var store *incremental.Store
+	var err error
+	if *t.incrementalPath != "" {
+		store, err = incremental.Open(*t.incrementalPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Fetch and process each repository's pull requests, combining the results
+	for _, r := range t.repos {
+		// Incrementally, only PRs after the last run's watermark are fetched
+		key := r.owner + "/" + r.repo
+		var stored []github.PullRequestMetric
+		fetchFrom := t.startDate
+		if store != nil {
+			stored, fetchFrom, err = incremental.Load[github.PullRequestMetric](store, key, t.startDate)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		var repoResults []github.PullRequestMetric
+		if fetchFrom.Before(t.endDate) {
+			if len(stored) > 0 {
+				fmt.Fprintf(t.status, "Fetching PRs for %s/%s since the last run (%s), to add to %d stored results...\n", r.owner, r.repo, fetchFrom.Format("2006-01-02 15:04"), len(stored))
+			} else {
+				fmt.Fprintf(t.status, "Fetching PRs for %s/%s from %s to %s...\n", r.owner, r.repo, t.startDate.Format("2006-01-02"), t.endDate.Format("2006-01-02"))
+			}
+			// Only a window's PRs are held at once; incremental runs just need to
+			// know when the oldest still open was created
+			watermark := t.endDate
+			var err error
+			repoResults, err = github.ProcessPullRequestWindows(ctx, t.prClient, r.owner, r.repo, fetchFrom, t.endDate, *t.fetchWindow, t.opts, func(prs []*github.PullRequest) {
+				watermark = prWatermark(prs, watermark)
+				fmt.Fprintf(t.status, "Found %d pull requests for %s/%s\n", len(prs), r.owner, r.repo)
+			})
+			if errors.Is(err, budget.ErrExhausted) || ctx.Err() != nil {
+				log.Printf("Stopped fetching pull requests early: %v", err)
+			} else if err != nil {
+				log.Fatalf("Error fetching pull requests for %s/%s: %v", r.owner, r.repo, err)
+			}
 
-	// Fetch pull requests with start date
-	fmt.Printf("Fetching PRs for %s/%s from %s to %s...\n", owner, repo, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-	prs, err := client.FetchPullRequests(owner, repo, startDate, endDate)
+			// A partial run leaves the stored results as they were, to be fetched again next time
+			if store != nil && err == nil && !t.prBudget.Exhausted() && ctx.Err() == nil {
+				if err := incremental.Save(store, key, fetchFrom, watermark, incremental.Merge(stored, repoResults, prID)); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+		if store != nil {
+			repoResults = createdBetween(incremental.Merge(stored, repoResults, prID), t.startDate, t.endDate)
+		}
+
+		results = append(results, repoResults...)
+		if t.prBudget.Exhausted() || ctx.Err() != nil {
+			break
+		}
+	}
+	if store != nil {
+		if err := store.Close(); err != nil {
+			log.Printf("Error saving results for the next incremental run: %v", err)
+		}
+	}
+
+	resolveIdentities(results, t.people)
+
+	// Add the configured computed columns, and leave out PRs the filter rejects
+	if t.rules != nil {
+		kept := results[:0]
+		for _, result := range results {
+			record := export.PullRequestRecords([]github.PullRequestMetric{result})[0]
+			extra, keep, err := t.rules.Apply(export.Fields(record), result.Extra)
+			if err != nil {
+				log.Fatalf("Error applying -where/-column to PR #%d: %v", result.PRNumber, err)
+			}
+			if keep {
+				result.Extra = extra
+				kept = append(kept, result)
+			}
+		}
+		results = kept
+	}
+
+	// Trivial classes of PR are left out of the headline numbers, but still broken down by class
+	headline = slices.DeleteFunc(slices.Clone(results), func(result github.PullRequestMetric) bool {
+		return slices.Contains(t.excludedClasses, result.Class)
+	})
+	return results, headline
+}
+
+// report prints the report on the date range
+func (t *tracker) report(ctx context.Context, results, headline []github.PullRequestMetric) {
+	// Work out the reporting periods, if the summary should be broken down by them
+	periods, err := t.periodFlags.Periods(t.startDate, t.endDate, func(team string) ([]period.Period, error) {
+		apiKey := os.Getenv("LINEAR_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("LINEAR_API_KEY environment variable not set")
+		}
+		linearClient := linear.NewCachedLinearClient(apiKey, t.cacheImpl)
+		defer linearClient.Close()
+		cycles, err := linearClient.FetchCycles(ctx, team, t.startDate, t.endDate)
+		if err != nil {
+			return nil, err
+		}
+		return linear.CyclePeriods(cycles), nil
+	})
 	if err != nil {
-		log.Fatalf("Error fetching pull requests: %v", err)
+		log.Fatalf("Error building report periods: %v", err)
+	}
+
+	// Note when people were on call, to explain dips in their review and PR throughput
+	var onCall map[string][]string
+	if *t.onCallSchedulesStr != "" && !t.useMarkdown && (*t.byAuthor || *t.reviewers) {
+		pagerDutyClient := pagerduty.NewPagerDutyClient(t.pagerDutyKey)
+		pagerDutyClient.SetBudget(t.apiBudget.Child("PagerDuty API", 0))
+		shifts, err := pagerDutyClient.FetchShifts(ctx, strings.Split(*t.onCallSchedulesStr, ","), t.startDate, t.endDate)
+		if err != nil {
+			log.Printf("Not noting on-call weeks: %v", err)
+		} else {
+			weeks := periods
+			if len(weeks) == 0 {
+				weeks = period.Weeks(t.startDate, t.endDate)
+			}
+			onCall = onCallPeriods(shifts, t.people, weeks)
+		}
+	}
+
+	// Put WIP, throughput and cycle time side by side for each team and week
+	var flowWeeks []flow.Week
+	if *t.flowMode {
+		flowWeeks = t.summarizeFlow(ctx)
+	}
+
+	if t.useMarkdown {
+		printMarkdown(os.Stdout, strings.Join(t.repoArgs, ", "), t.startDate, t.endDate, headline, periods, t.partial(ctx))
+		printFlowMarkdown(os.Stdout, flowWeeks)
+		return
+	}
+	t.printNotes(ctx, results, headline)
+	printResults(headline, t.bootstrap)
+	printPeriodStatistics(periods, headline, t.bootstrap)
+	printHotfixStatistics(headline, t.bootstrap)
+	printSLOs(t.objectives, sloPeriods(periods, t.startDate, t.endDate), headline, t.schedule)
+	printFlowStatistics(flowWeeks)
+	if len(t.repos) > 1 {
+		printGroupStatistics("Review Times by Repository", github.SummarizeByRepository(headline), nil)
+	}
+	if *t.byAuthor {
+		printGroupStatistics("Review Times by Author", github.SummarizeByAuthor(headline), onCall)
+	}
+	if *t.byChangeType {
+		printGroupStatistics("Review Times by Change Type", github.SummarizeByChangeType(headline), nil)
+	}
+	if *t.byLanguage {
+		printGroupStatistics("Review Times by Language", github.SummarizeByLanguage(headline), nil)
+	}
+	if *t.classify {
+		printGroupStatistics("Review Times by PR Class", github.SummarizeByClass(results), nil)
+	}
+	if *t.comments {
+		printReviewDepth(github.SummarizeReviewDepth(headline))
 	}
+	if *t.bySize {
+		printGroupStatistics("Review Times by PR Size", github.SummarizeBySize(headline), nil)
+	}
+	if len(t.priorities) > 0 {
+		printGroupStatistics("Review Times by Priority", github.SummarizeByPriority(headline, t.priorities), nil)
+	}
+	if *t.reviewers {
+		printReviewerStatistics(github.SummarizeReviewers(headline), onCall)
+	}
+	if *t.reviewRequests {
+		printTeamStatistics(github.SummarizeTeams(headline))
+	}
+}
+
+// partial reports whether the run stopped before every PR was processed
+func (t *tracker) partial(ctx context.Context) bool {
+	return t.prBudget.Exhausted() || ctx.Err() != nil
+}
+
+// printNotes prints what a text report's reader should know before its numbers:
+// whether they're partial, and how they were measured
+func (t *tracker) printNotes(ctx context.Context, results, headline []github.PullRequestMetric) {
+	if t.prBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all PRs were processed\n", t.apiBudget.Used())
+	} else if ctx.Err() != nil {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - interrupted; not all PRs were processed\n")
+	}
+	if t.schedule != nil {
+		fmt.Printf("\nReview times are in business hours (%s)\n", t.schedule)
+	}
+	if len(t.excludedClasses) > 0 {
+		fmt.Printf("\nExcluding %d %s PRs from the headline numbers\n", len(results)-len(headline), strings.Join(t.excludedClasses, "/"))
+	}
+}
+
+// export writes the per-PR and per-review exports, if asked for
+func (t *tracker) export(results []github.PullRequestMetric) {
+	if t.format != "" {
+		if err := export.WriteFile(t.format, *t.outFile, export.PullRequestRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Fprintf(t.status, "\nWrote %d PR records to %s\n", len(results), *t.outFile)
+	}
+	if *t.reviewsOutFile != "" {
+		reviews := export.ReviewRecords(results)
+		if err := export.WriteFile(export.FormatOf(*t.reviewsOutFile), *t.reviewsOutFile, reviews); err != nil {
+			log.Fatalf("Error exporting reviews: %v", err)
+		}
+		fmt.Fprintf(t.status, "Wrote %d review records to %s\n", len(reviews), *t.reviewsOutFile)
+	}
+}
 
-	fmt.Printf("Found %d pull requests for %s/%s\n", len(prs), owner, repo)
+// openPullRequestFetcher lists the PRs open now, whenever they were created, and
+// counts those ever merged, for -wip
+type openPullRequestFetcher interface {
+	FetchOpenPullRequests(ctx context.Context, owner, repo string) ([]*github.PullRequest, error)
+	CountMergedPullRequests(ctx context.Context, owner, repo string) (int, error)
+}
 
-	// Process pull requests to gather results
-	results := github.ProcessPullRequests(client, prs, owner, repo, denylist, tagsOwner, tagsRepo)
+// mergedPullRequestFetcher lists the PRs merged in a date range, whenever they
+// were created, for -flow
+type mergedPullRequestFetcher interface {
+	FetchMergedPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error)
+}
+
+// prWatermark returns when the next incremental run should fetch PRs from: the
+// creation of the oldest PR still open, whose metrics may yet change, or end if
+// every PR is merged or closed
+func prWatermark(prs []*github.PullRequest, end time.Time) time.Time {
+	watermark := end
+	for _, pr := range prs {
+		if pr.State == "open" && pr.CreatedAt.Before(watermark) {
+			watermark = pr.CreatedAt
+		}
+	}
+	return watermark
+}
+
+// prID identifies a PR's result, for merging incremental runs' results
+func prID(result github.PullRequestMetric) string {
+	return fmt.Sprintf("%s#%d", result.Repository, result.PRNumber)
+}
 
-	// Print the results
-	printResults(results)
+// createdBetween returns the results for PRs created in the date range
+func createdBetween(results []github.PullRequestMetric, start, end time.Time) []github.PullRequestMetric {
+	return slices.DeleteFunc(results, func(result github.PullRequestMetric) bool {
+		return result.CreatedAt.Before(start) || result.CreatedAt.After(end)
+	})
 }
 
-// printResults outputs the analysis results in a readable format
-func printResults(results []github.PullRequestMetric) {
+// readRepoList reads one owner/repo per line, skipping blank lines and # comments
+func readRepoList(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository list: %w", err)
+	}
+	var repos []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			repos = append(repos, line)
+		}
+	}
+	return repos, nil
+}
+
+// spansRepositories reports whether results come from more than one repository
+func spansRepositories(results []github.PullRequestMetric) bool {
+	for _, result := range results {
+		if result.Repository != results[0].Repository {
+			return true
+		}
+	}
+	return false
+}
+
+// prRef formats a PR's number, qualified by its repository if qualify is set
+func prRef(result github.PullRequestMetric, qualify bool) string {
+	if qualify {
+		return fmt.Sprintf("%s#%d", result.Repository, result.PRNumber)
+	}
+	return fmt.Sprintf("#%d", result.PRNumber)
+}
+
+// printResults outputs the analysis results in a readable format, with confidence
+// intervals if bootstrap isn't nil
+func printResults(results []github.PullRequestMetric, bootstrap *stats.Bootstrap) {
 	// Output results
 	if len(results) == 0 {
 		fmt.Println("No pull requests found")
 		return
 	}
 
+	// PR numbers only identify a PR within its repository
+	qualify := spansRepositories(results)
+
 	// First, display PRs with reviews
 	fmt.Println("\nPull Requests With Reviews:")
 	fmt.Println("---------------------------")
@@ -123,9 +832,22 @@ func printResults(results []github.PullRequestMetric) {
 	for _, result := range results {
 		if result.HasReview {
 			reviewedPRsCount++
-			fmt.Printf("PR #%d: %s\n", result.PRNumber, result.PRTitle)
-			fmt.Printf("  Time to First Review: %v", result.TimeToFirstReview.Truncate(time.Second))
-			fmt.Printf(" (by %s - %s)\n", result.FirstReviewer, result.FirstReviewState)
+			fmt.Printf("PR %s: %s\n", prRef(result, qualify), result.PRTitle)
+			if result.CodingTime > 0 {
+				fmt.Printf("  Coding Time: %v\n", result.CodingTime.Truncate(time.Second))
+			}
+			if result.TimeInDraft > 0 {
+				fmt.Printf("  Time in Draft: %v\n", result.TimeInDraft.Truncate(time.Second))
+			}
+			if result.FirstReviewer != "" {
+				fmt.Printf("  Time to First Review: %v", result.TimeToFirstReview.Truncate(time.Second))
+				fmt.Printf(" (by %s - %s)\n", result.FirstReviewer, result.FirstReviewState)
+			} else {
+				fmt.Printf("  Time to First Review: Only comment reviews so far\n")
+			}
+			if result.FirstResponder != "" {
+				fmt.Printf("  Time to First Response: %v (by %s)\n", result.TimeToFirstResponse.Truncate(time.Second), result.FirstResponder)
+			}
 
 			if result.Approver != "" {
 				fmt.Printf("  Time to Approval: %v", result.TimeToApproval.Truncate(time.Second))
@@ -133,6 +855,56 @@ func printResults(results []github.PullRequestMetric) {
 			} else {
 				fmt.Printf("  Time to Approval: Not yet approved\n")
 			}
+			if result.ApprovalToMerge > 0 {
+				fmt.Printf("  Approval to Merge: %v\n", result.ApprovalToMerge.Truncate(time.Second))
+			}
+			if result.RequiredApprovals > 1 {
+				if result.TimeToRequiredApprovals > 0 {
+					fmt.Printf("  Time to %d Approvals: %v\n", result.RequiredApprovals, result.TimeToRequiredApprovals.Truncate(time.Second))
+				} else {
+					fmt.Printf("  Time to %d Approvals: Not yet reached\n", result.RequiredApprovals)
+				}
+			}
+			if result.DismissedApprovals > 0 {
+				if result.TimeToStandingApproval > 0 {
+					fmt.Printf("  Time to Final Standing Approval: %v", result.TimeToStandingApproval.Truncate(time.Second))
+				} else {
+					fmt.Printf("  Time to Final Standing Approval: None standing")
+				}
+				fmt.Printf(" (%d dismissed or stale)\n", result.DismissedApprovals)
+			}
+			if result.Dismissals > 0 {
+				fmt.Printf("  Dismissed Reviews: %d", result.Dismissals)
+				if result.OnlyApprovalDismissed {
+					fmt.Printf(" (including its only approval)")
+				}
+				fmt.Println()
+			}
+			if len(result.CodeownerApprovals) > 0 {
+				if result.TimeToCodeownerApproval > 0 {
+					fmt.Printf("  Time to Code Owner Approval: %v\n", result.TimeToCodeownerApproval.Truncate(time.Second))
+				} else {
+					fmt.Printf("  Time to Code Owner Approval: Not yet satisfied\n")
+				}
+				for _, oa := range result.CodeownerApprovals {
+					if oa.TimeToApproval > 0 {
+						fmt.Printf("    %s: %v\n", oa.Owner, oa.TimeToApproval.Truncate(time.Second))
+					} else {
+						fmt.Printf("    %s: Not yet approved\n", oa.Owner)
+					}
+				}
+			}
+			fmt.Printf("  Waiting on Reviewers: %v, on Author: %v\n", result.ReviewerWaitTime.Truncate(time.Second), result.AuthorWaitTime.Truncate(time.Second))
+			if result.ReviewRounds > 1 || result.TimeInChangesRequested > 0 {
+				fmt.Printf("  Review Rounds: %d (changes requested for %v)\n", result.ReviewRounds, result.TimeInChangesRequested.Truncate(time.Second))
+			}
+			if result.CommentsCounted {
+				fmt.Printf("  Review Comments: %d", result.ReviewComments)
+				if result.Size != "" {
+					fmt.Printf(" (%.1f per 100 lines changed)", result.ReviewDepth)
+				}
+				fmt.Println()
+			}
 			switch numDeploys := len(result.TagCommits); numDeploys {
 			case 0:
 				// do nothing
@@ -141,6 +913,7 @@ func printResults(results []github.PullRequestMetric) {
 			default:
 				fmt.Printf("  Deployed to test env %d times\n", numDeploys)
 			}
+			printExtra(result.Extra)
 			fmt.Println()
 		}
 	}
@@ -157,9 +930,12 @@ func printResults(results []github.PullRequestMetric) {
 	for _, result := range results {
 		if !result.HasReview {
 			awaitingReviewCount++
-			fmt.Printf("PR #%d: %s\n", result.PRNumber, result.PRTitle)
+			fmt.Printf("PR %s: %s\n", prRef(result, qualify), result.PRTitle)
 			fmt.Printf("Author: %s\n", result.Author)
 			fmt.Printf("  Waiting for: %v\n", result.TimeSinceCreation.Truncate(time.Second))
+			if result.FirstResponder != "" {
+				fmt.Printf("  Time to First Response: %v (by %s)\n", result.TimeToFirstResponse.Truncate(time.Second), result.FirstResponder)
+			}
 			switch numDeploys := len(result.TagCommits); numDeploys {
 			case 0:
 				// do nothing
@@ -168,6 +944,7 @@ func printResults(results []github.PullRequestMetric) {
 			default:
 				fmt.Printf("  Deployed to test env %d times\n", numDeploys)
 			}
+			printExtra(result.Extra)
 			fmt.Println()
 		}
 	}
@@ -176,43 +953,76 @@ func printResults(results []github.PullRequestMetric) {
 		fmt.Println("  None found")
 	}
 
-	printSummaryStatistics(results)
+	printSummaryStatistics(results, bootstrap)
+	printCodeownerStatistics(results)
 }
 
-// calculateMedian calculates the median of a slice of time.Duration
-func calculateMedian(durations []time.Duration) time.Duration {
-	n := len(durations)
-	if n == 0 {
-		return 0
-	}
-
-	// Sort the slice
-	slices.Sort(durations)
+// hookStage runs hook on each PR, passing it the PR's export record, and adds the
+// fields it returns to the PR's results
+func hookStage(hook *hooks.Hook) github.Stage {
+	return github.StageFunc(func(ctx context.Context, pr *github.PullRequestContext, metric *github.PullRequestMetric) error {
+		extra, err := hook.Run(export.PullRequestRecords([]github.PullRequestMetric{*metric})[0])
+		if err != nil {
+			return err
+		}
+		metric.Extra = extra
+		return nil
+	})
+}
 
-	// If odd, return the middle element
-	if n%2 != 0 {
-		return durations[n/2]
+// printExtra prints the fields a hook added, by name
+func printExtra(extra map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(extra)) {
+		fmt.Printf("  %s: %s\n", name, extra[name])
 	}
-
-	// If even, return the average of the two middle elements
-	mid1 := durations[(n/2)-1]
-	mid2 := durations[n/2]
-	return (mid1 + mid2) / 2
 }
 
 // printSummaryStatistics calculates and displays mean and median review times
-func printSummaryStatistics(results []github.PullRequestMetric) {
+func printSummaryStatistics(results []github.PullRequestMetric, bootstrap *stats.Bootstrap) {
 	// Collect all the time durations for each category
 	var firstReviewTimes []time.Duration
+	var firstResponseTimes []time.Duration
 	var approvalTimes []time.Duration
+	var approvalToMergeTimes []time.Duration
+	var codingTimes []time.Duration
+	var draftTimes []time.Duration
 	var waitingTimes []time.Duration
+	var reviewerWaitTimes []time.Duration
+	var requiredApprovalTimes []time.Duration
+	var standingApprovalTimes []time.Duration
+	var changesRequestedTimes []time.Duration
+	var reviewRounds []int
 
 	// Calculate totals for means
 	var totalReviewTime time.Duration
+	var totalResponseTime time.Duration
 	var totalApprovalTime time.Duration
+	var totalApprovalToMergeTime time.Duration
+	var totalCodingTime time.Duration
+	var totalDraftTime time.Duration
 	var totalWaitingTime time.Duration
+	var totalReviewerWaitTime time.Duration
+	var totalRequiredApprovalTime time.Duration
+	var totalStandingApprovalTime time.Duration
+	var totalChangesRequestedTime time.Duration
+	totalDismissedApprovals := 0
+	totalDismissals := 0
+	var approvalDismissedPRs []github.PullRequestMetric
 
 	for _, result := range results {
+		if result.CodingTime > 0 {
+			codingTimes = append(codingTimes, result.CodingTime)
+			totalCodingTime += result.CodingTime
+		}
+		if result.TimeInDraft > 0 {
+			draftTimes = append(draftTimes, result.TimeInDraft)
+			totalDraftTime += result.TimeInDraft
+		}
+		// A PR can be responded to without being reviewed, by a comment
+		if result.TimeToFirstResponse > 0 {
+			firstResponseTimes = append(firstResponseTimes, result.TimeToFirstResponse)
+			totalResponseTime += result.TimeToFirstResponse
+		}
 		if result.HasReview {
 			if result.TimeToFirstReview > 0 {
 				firstReviewTimes = append(firstReviewTimes, result.TimeToFirstReview)
@@ -223,6 +1033,37 @@ func printSummaryStatistics(results []github.PullRequestMetric) {
 				approvalTimes = append(approvalTimes, result.TimeToApproval)
 				totalApprovalTime += result.TimeToApproval
 			}
+
+			if result.ApprovalToMerge > 0 {
+				approvalToMergeTimes = append(approvalToMergeTimes, result.ApprovalToMerge)
+				totalApprovalToMergeTime += result.ApprovalToMerge
+			}
+
+			if result.RequiredApprovals > 1 && result.TimeToRequiredApprovals > 0 {
+				requiredApprovalTimes = append(requiredApprovalTimes, result.TimeToRequiredApprovals)
+				totalRequiredApprovalTime += result.TimeToRequiredApprovals
+			}
+
+			if result.TimeToStandingApproval > 0 {
+				standingApprovalTimes = append(standingApprovalTimes, result.TimeToStandingApproval)
+				totalStandingApprovalTime += result.TimeToStandingApproval
+			}
+			totalDismissedApprovals += result.DismissedApprovals
+			totalDismissals += result.Dismissals
+			if result.OnlyApprovalDismissed {
+				approvalDismissedPRs = append(approvalDismissedPRs, result)
+			}
+
+			if result.ReviewRounds > 0 {
+				reviewRounds = append(reviewRounds, result.ReviewRounds)
+			}
+			if result.TimeInChangesRequested > 0 {
+				changesRequestedTimes = append(changesRequestedTimes, result.TimeInChangesRequested)
+				totalChangesRequestedTime += result.TimeInChangesRequested
+			}
+
+			reviewerWaitTimes = append(reviewerWaitTimes, result.ReviewerWaitTime)
+			totalReviewerWaitTime += result.ReviewerWaitTime
 		} else {
 			// Track PRs with no reviews
 			waitingTimes = append(waitingTimes, result.TimeSinceCreation)
@@ -233,47 +1074,155 @@ func printSummaryStatistics(results []github.PullRequestMetric) {
 	fmt.Println("\nSummary Statistics:")
 	fmt.Println("-----------------")
 
+	// Coding time, from first commit to PR creation, only if it was measured
+	if len(codingTimes) > 0 {
+		meanCodingTime := totalCodingTime / time.Duration(len(codingTimes))
+		medianCodingTime := stats.MedianOf(codingTimes)
+
+		fmt.Println("Coding Time (first commit to PR opened):")
+		fmt.Printf("  Mean: %v%s\n", meanCodingTime.Truncate(time.Second), interval(bootstrap, codingTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianCodingTime.Truncate(time.Second), interval(bootstrap, codingTimes, stats.Median))
+	}
+
+	// Time in draft, over the PRs that were drafts at some point
+	if len(draftTimes) > 0 {
+		meanDraftTime := totalDraftTime / time.Duration(len(draftTimes))
+		medianDraftTime := stats.MedianOf(draftTimes)
+
+		fmt.Printf("Time in Draft (%d PRs):\n", len(draftTimes))
+		fmt.Printf("  Mean: %v%s\n", meanDraftTime.Truncate(time.Second), interval(bootstrap, draftTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianDraftTime.Truncate(time.Second), interval(bootstrap, draftTimes, stats.Median))
+	}
+
 	// Time to First Review statistics
 	if len(firstReviewTimes) > 0 {
 		// Calculate mean
 		meanReviewTime := totalReviewTime / time.Duration(len(firstReviewTimes))
 
 		// Calculate median
-		medianReviewTime := calculateMedian(firstReviewTimes)
+		medianReviewTime := stats.MedianOf(firstReviewTimes)
 
 		fmt.Println("Time to First Review:")
-		fmt.Printf("  Mean: %v\n", meanReviewTime.Truncate(time.Second))
-		fmt.Printf("  Median: %v\n", medianReviewTime.Truncate(time.Second))
+		fmt.Printf("  Mean: %v%s\n", meanReviewTime.Truncate(time.Second), interval(bootstrap, firstReviewTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianReviewTime.Truncate(time.Second), interval(bootstrap, firstReviewTimes, stats.Median))
 	} else {
 		fmt.Println("Time to First Review: No data")
 	}
 
+	// Time to first response, by review or comment, if measured
+	if len(firstResponseTimes) > 0 {
+		meanResponseTime := totalResponseTime / time.Duration(len(firstResponseTimes))
+		medianResponseTime := stats.MedianOf(firstResponseTimes)
+
+		fmt.Printf("Time to First Response (%d PRs):\n", len(firstResponseTimes))
+		fmt.Printf("  Mean: %v%s\n", meanResponseTime.Truncate(time.Second), interval(bootstrap, firstResponseTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianResponseTime.Truncate(time.Second), interval(bootstrap, firstResponseTimes, stats.Median))
+	}
+
 	// Time to Approval statistics
 	if len(approvalTimes) > 0 {
 		// Calculate mean
 		meanApprovalTime := totalApprovalTime / time.Duration(len(approvalTimes))
 
 		// Calculate median
-		medianApprovalTime := calculateMedian(approvalTimes)
+		medianApprovalTime := stats.MedianOf(approvalTimes)
 
 		fmt.Println("Time to Approval:")
-		fmt.Printf("  Mean: %v\n", meanApprovalTime.Truncate(time.Second))
-		fmt.Printf("  Median: %v\n", medianApprovalTime.Truncate(time.Second))
+		fmt.Printf("  Mean: %v%s\n", meanApprovalTime.Truncate(time.Second), interval(bootstrap, approvalTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianApprovalTime.Truncate(time.Second), interval(bootstrap, approvalTimes, stats.Median))
 	} else {
 		fmt.Println("Time to Approval: No data")
 	}
 
+	// Time from first approval to merge, where CI and merge queue delays show up
+	if len(approvalToMergeTimes) > 0 {
+		meanApprovalToMergeTime := totalApprovalToMergeTime / time.Duration(len(approvalToMergeTimes))
+		medianApprovalToMergeTime := stats.MedianOf(approvalToMergeTimes)
+
+		fmt.Println("Approval to Merge:")
+		fmt.Printf("  Mean: %v%s\n", meanApprovalToMergeTime.Truncate(time.Second), interval(bootstrap, approvalToMergeTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianApprovalToMergeTime.Truncate(time.Second), interval(bootstrap, approvalToMergeTimes, stats.Median))
+	}
+
+	// Time to final standing approval, ignoring approvals that were later dismissed or went stale
+	if len(standingApprovalTimes) > 0 {
+		meanStandingApprovalTime := totalStandingApprovalTime / time.Duration(len(standingApprovalTimes))
+		medianStandingApprovalTime := stats.MedianOf(standingApprovalTimes)
+
+		fmt.Println("Time to Final Standing Approval:")
+		fmt.Printf("  Mean: %v%s\n", meanStandingApprovalTime.Truncate(time.Second), interval(bootstrap, standingApprovalTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianStandingApprovalTime.Truncate(time.Second), interval(bootstrap, standingApprovalTimes, stats.Median))
+		fmt.Printf("  Dismissed or stale approvals: %d\n", totalDismissedApprovals)
+	}
+
+	// Dismissals, and the PRs left without an approval by them
+	if totalDismissals > 0 {
+		fmt.Printf("Dismissed Reviews: %d\n", totalDismissals)
+		if len(approvalDismissedPRs) > 0 {
+			fmt.Printf("  PRs whose only approval was dismissed (%d):\n", len(approvalDismissedPRs))
+			for _, result := range approvalDismissedPRs {
+				fmt.Printf("    %s#%d: %s\n", result.Repository, result.PRNumber, result.PRTitle)
+			}
+		}
+	}
+
+	// Time to required approvals statistics (only for PRs needing more than one)
+	if len(requiredApprovalTimes) > 0 {
+		meanRequiredApprovalTime := totalRequiredApprovalTime / time.Duration(len(requiredApprovalTimes))
+		medianRequiredApprovalTime := stats.MedianOf(requiredApprovalTimes)
+
+		fmt.Println("Time to Required Approvals (PRs needing more than one):")
+		fmt.Printf("  Mean: %v%s\n", meanRequiredApprovalTime.Truncate(time.Second), interval(bootstrap, requiredApprovalTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianRequiredApprovalTime.Truncate(time.Second), interval(bootstrap, requiredApprovalTimes, stats.Median))
+	}
+
+	// Review rounds, counting re-reviews after changes were requested and pushed
+	if len(reviewRounds) > 0 {
+		totalRounds, reReviewed := 0, 0
+		for _, rounds := range reviewRounds {
+			totalRounds += rounds
+			if rounds > 1 {
+				reReviewed++
+			}
+		}
+		slices.Sort(reviewRounds)
+		fmt.Println("Review Rounds:")
+		fmt.Printf("  Mean: %.1f\n", float64(totalRounds)/float64(len(reviewRounds)))
+		fmt.Printf("  Median: %d\n", reviewRounds[len(reviewRounds)/2])
+		fmt.Printf("  PRs needing more than one round: %d/%d (%.1f%%)\n", reReviewed, len(reviewRounds), float64(reReviewed)/float64(len(reviewRounds))*100)
+	}
+
+	// Time with changes requested, over the PRs that had changes requested
+	if len(changesRequestedTimes) > 0 {
+		meanChangesRequestedTime := totalChangesRequestedTime / time.Duration(len(changesRequestedTimes))
+		medianChangesRequestedTime := stats.MedianOf(changesRequestedTimes)
+
+		fmt.Printf("Time in Changes Requested (%d PRs):\n", len(changesRequestedTimes))
+		fmt.Printf("  Mean: %v%s\n", meanChangesRequestedTime.Truncate(time.Second), interval(bootstrap, changesRequestedTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianChangesRequestedTime.Truncate(time.Second), interval(bootstrap, changesRequestedTimes, stats.Median))
+	}
+
+	// Time waiting on reviewers, excluding time the author spent addressing feedback
+	if len(reviewerWaitTimes) > 0 {
+		meanReviewerWaitTime := totalReviewerWaitTime / time.Duration(len(reviewerWaitTimes))
+		medianReviewerWaitTime := stats.MedianOf(reviewerWaitTimes)
+
+		fmt.Println("Time Waiting on Reviewers (excluding author response time):")
+		fmt.Printf("  Mean: %v%s\n", meanReviewerWaitTime.Truncate(time.Second), interval(bootstrap, reviewerWaitTimes, stats.Mean))
+		fmt.Printf("  Median: %v%s\n", medianReviewerWaitTime.Truncate(time.Second), interval(bootstrap, reviewerWaitTimes, stats.Median))
+	}
+
 	// PRs awaiting review statistics
 	if len(waitingTimes) > 0 {
 		// Calculate mean
 		meanWaitingTime := totalWaitingTime / time.Duration(len(waitingTimes))
 
 		// Calculate median
-		medianWaitingTime := calculateMedian(waitingTimes)
+		medianWaitingTime := stats.MedianOf(waitingTimes)
 
 		fmt.Printf("PRs Awaiting Review: %d\n", len(waitingTimes))
-		fmt.Printf("  Mean wait time: %v\n", meanWaitingTime.Truncate(time.Second))
-		fmt.Printf("  Median wait time: %v\n", medianWaitingTime.Truncate(time.Second))
+		fmt.Printf("  Mean wait time: %v%s\n", meanWaitingTime.Truncate(time.Second), interval(bootstrap, waitingTimes, stats.Mean))
+		fmt.Printf("  Median wait time: %v%s\n", medianWaitingTime.Truncate(time.Second), interval(bootstrap, waitingTimes, stats.Median))
 	} else {
 		fmt.Println("PRs Awaiting Review: 0")
 	}
@@ -298,3 +1247,375 @@ func printSummaryStatistics(results []github.PullRequestMetric) {
 		}
 	}
 }
+
+// printCodeownerStatistics displays time to code owner approval overall and per owning
+// team. It prints nothing unless CODEOWNERS were checked and some PR triggered a rule.
+func printCodeownerStatistics(results []github.PullRequestMetric) {
+	var satisfiedTimes []time.Duration
+	var totalSatisfiedTime time.Duration
+	ownerTimes := make(map[string][]time.Duration)
+	ownerPending := make(map[string]int)
+
+	for _, result := range results {
+		if result.TimeToCodeownerApproval > 0 {
+			satisfiedTimes = append(satisfiedTimes, result.TimeToCodeownerApproval)
+			totalSatisfiedTime += result.TimeToCodeownerApproval
+		}
+		for _, oa := range result.CodeownerApprovals {
+			if oa.TimeToApproval > 0 {
+				ownerTimes[oa.Owner] = append(ownerTimes[oa.Owner], oa.TimeToApproval)
+			} else {
+				ownerPending[oa.Owner]++
+			}
+		}
+	}
+
+	if len(satisfiedTimes) == 0 && len(ownerTimes) == 0 && len(ownerPending) == 0 {
+		return
+	}
+
+	if len(satisfiedTimes) > 0 {
+		meanSatisfiedTime := totalSatisfiedTime / time.Duration(len(satisfiedTimes))
+		medianSatisfiedTime := stats.MedianOf(satisfiedTimes)
+
+		fmt.Println("Time to Code Owner Approval (all triggered rules satisfied):")
+		fmt.Printf("  Mean: %v\n", meanSatisfiedTime.Truncate(time.Second))
+		fmt.Printf("  Median: %v\n", medianSatisfiedTime.Truncate(time.Second))
+	}
+
+	var owners []string
+	for owner := range ownerTimes {
+		owners = append(owners, owner)
+	}
+	for owner := range ownerPending {
+		if _, ok := ownerTimes[owner]; !ok {
+			owners = append(owners, owner)
+		}
+	}
+	slices.Sort(owners)
+
+	fmt.Println("Time to Code Owner Approval by Owner:")
+	for _, owner := range owners {
+		times := ownerTimes[owner]
+		if len(times) == 0 {
+			fmt.Printf("  %s: No approvals (%d pending)\n", owner, ownerPending[owner])
+			continue
+		}
+		var total time.Duration
+		for _, d := range times {
+			total += d
+		}
+		mean := total / time.Duration(len(times))
+		fmt.Printf("  %s: Mean %v, Median %v (%d approved, %d pending)\n", owner, mean.Truncate(time.Second), stats.MedianOf(times).Truncate(time.Second), len(times), ownerPending[owner])
+	}
+}
+
+// printPeriodStatistics displays review times for PRs opened in each period
+func printPeriodStatistics(periods []period.Period, results []github.PullRequestMetric, bootstrap *stats.Bootstrap) {
+	if len(periods) == 0 {
+		return
+	}
+
+	fmt.Println("\nStatistics by Period:")
+	fmt.Println("---------------------")
+
+	groups := period.Group(periods, results, func(result github.PullRequestMetric) time.Time {
+		return result.CreatedAt
+	})
+	for i, p := range periods {
+		var firstReviewTimes, approvalTimes []time.Duration
+		for _, result := range groups[i] {
+			if result.TimeToFirstReview > 0 {
+				firstReviewTimes = append(firstReviewTimes, result.TimeToFirstReview)
+			}
+			if result.TimeToApproval > 0 {
+				approvalTimes = append(approvalTimes, result.TimeToApproval)
+			}
+		}
+
+		fmt.Printf("%s (%s to %s): %d PRs%s\n", p.Name, p.Start.Format("2006-01-02"), p.End.AddDate(0, 0, -1).Format("2006-01-02"), len(groups[i]), tooFew(len(groups[i])))
+		printMeanMedian("Time to First Review", firstReviewTimes, bootstrap)
+		printMeanMedian("Time to Approval", approvalTimes, bootstrap)
+	}
+}
+
+// printMeanMedian prints one indented line summarizing durations
+func printMeanMedian(name string, durations []time.Duration, bootstrap *stats.Bootstrap) {
+	if len(durations) == 0 {
+		fmt.Printf("  %s: No data\n", name)
+		return
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	mean := total / time.Duration(len(durations))
+	fmt.Printf("  %s: Mean %v%s, Median %v%s\n", name, mean.Truncate(time.Second), interval(bootstrap, durations, stats.Mean),
+		stats.MedianOf(durations).Truncate(time.Second), interval(bootstrap, durations, stats.Median))
+}
+
+// printGroupStatistics displays review times per group of PRs, longest-waiting first.
+// onCall, if given, lists the periods each group (a person) was on call.
+func printGroupStatistics(title string, stats []github.ReviewTimeStats, onCall map[string][]string) {
+	fmt.Printf("\n%s:\n", title)
+	fmt.Println(strings.Repeat("-", len(title)+1))
+
+	if len(stats) == 0 {
+		fmt.Println("  None found")
+		return
+	}
+
+	for _, group := range stats {
+		fmt.Printf("%s: %d PRs%s", group.Group, group.PRCount, tooFew(group.PRCount))
+		if group.AwaitingReviewCount > 0 {
+			fmt.Printf(" (%d awaiting review)", group.AwaitingReviewCount)
+		}
+		fmt.Println()
+		if group.MedianTimeToFirstReview > 0 {
+			fmt.Printf("  Time to First Review: Mean %v, Median %v\n", group.MeanTimeToFirstReview.Truncate(time.Second), group.MedianTimeToFirstReview.Truncate(time.Second))
+		} else {
+			fmt.Println("  Time to First Review: No data")
+		}
+		if group.MedianTimeToApproval > 0 {
+			fmt.Printf("  Time to Approval: Mean %v, Median %v\n", group.MeanTimeToApproval.Truncate(time.Second), group.MedianTimeToApproval.Truncate(time.Second))
+		} else {
+			fmt.Println("  Time to Approval: No data")
+		}
+		if weeks := onCall[group.Group]; len(weeks) > 0 {
+			fmt.Printf("  On Call: %s\n", strings.Join(weeks, ", "))
+		}
+	}
+}
+
+// printReviewerStatistics displays how much reviewing each reviewer did, busiest first
+func printReviewerStatistics(stats []github.ReviewerStats, onCall map[string][]string) {
+	fmt.Println("\nReviewer Leaderboard:")
+	fmt.Println("---------------------")
+
+	if len(stats) == 0 {
+		fmt.Println("  None found")
+		return
+	}
+
+	for _, reviewer := range stats {
+		fmt.Printf("%s: %d reviews of %d PRs\n", reviewer.Reviewer, reviewer.Reviews, reviewer.PRsReviewed)
+		fmt.Printf("  Median Response Time: %v\n", reviewer.MedianResponseTime.Truncate(time.Second))
+		fmt.Printf("  Approval Rate: %d/%d (%.1f%%)\n", reviewer.PRsApproved, reviewer.PRsReviewed, reviewer.ApprovalRate()*100)
+		if reviewer.RequestsReceived > 0 {
+			fmt.Printf("  Review Requests Answered: %d/%d\n", reviewer.RequestsAnswered, reviewer.RequestsReceived)
+			if reviewer.RequestsAnswered > 0 {
+				fmt.Printf("  Median Response Time from Request: %v\n", reviewer.MedianRequestResponseTime.Truncate(time.Second))
+			}
+		}
+		if weeks := onCall[reviewer.Reviewer]; len(weeks) > 0 {
+			fmt.Printf("  On Call: %s\n", strings.Join(weeks, ", "))
+		}
+	}
+}
+
+// printTeamStatistics displays how quickly each team answered requests for its
+// review, if any were made to teams
+func printTeamStatistics(stats []github.TeamStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Println("\nTeam Review Requests:")
+	fmt.Println("---------------------")
+
+	for _, team := range stats {
+		fmt.Printf("%s: %d/%d requests answered (%.1f%%)\n", team.Team, team.RequestsAnswered, team.RequestsReceived, team.AnswerRate()*100)
+		if team.RequestsAnswered > 0 {
+			fmt.Printf("  Median Response Time from Request: %v\n", team.MedianResponseTime.Truncate(time.Second))
+			fmt.Printf("  Answered By: %s\n", strings.Join(team.Responders, ", "))
+		}
+	}
+}
+
+// printReviewDepth displays how much reviewers had to say on the PRs they reviewed
+func printReviewDepth(stats github.ReviewDepthStats) {
+	fmt.Println("\nReview Depth:")
+	fmt.Println("-------------")
+
+	if stats.PRCount == 0 {
+		fmt.Println("  No reviewed PRs")
+		return
+	}
+
+	fmt.Printf("Review Comments: %d on %d reviewed PRs (median %.1f per PR)\n", stats.Comments, stats.PRCount, stats.MedianComments)
+	if stats.MeasuredPRCount > 0 {
+		fmt.Printf("Median Comments per 100 Lines Changed: %.1f\n", stats.MedianDepth)
+	}
+	if stats.ApprovedPRCount > 0 {
+		fmt.Printf("Approved Without Comments: %d/%d (%.1f%%)\n", stats.SilentApprovals, stats.ApprovedPRCount, float64(stats.SilentApprovals)/float64(stats.ApprovedPRCount)*100)
+	}
+}
+
+// printHotfixStatistics compares review times for hotfix PRs against everything
+// else, to check the fast path is fast and not overused
+func printHotfixStatistics(results []github.PullRequestMetric, bootstrap *stats.Bootstrap) {
+	var hotfixes, others []github.PullRequestMetric
+	for _, result := range results {
+		if result.Hotfix {
+			hotfixes = append(hotfixes, result)
+		} else {
+			others = append(others, result)
+		}
+	}
+	if len(hotfixes) == 0 {
+		return
+	}
+
+	fmt.Println("\nHotfix PRs:")
+	fmt.Println("-----------")
+	fmt.Printf("Hotfix PRs: %d/%d (%.1f%%)\n", len(hotfixes), len(results), float64(len(hotfixes))/float64(len(results))*100)
+
+	for _, group := range []struct {
+		name    string
+		results []github.PullRequestMetric
+	}{
+		{"Hotfixes", hotfixes},
+		{"Other PRs", others},
+	} {
+		var firstReviewTimes, approvalTimes []time.Duration
+		for _, result := range group.results {
+			if result.TimeToFirstReview > 0 {
+				firstReviewTimes = append(firstReviewTimes, result.TimeToFirstReview)
+			}
+			if result.TimeToApproval > 0 {
+				approvalTimes = append(approvalTimes, result.TimeToApproval)
+			}
+		}
+		fmt.Printf("%s: %d PRs%s\n", group.name, len(group.results), tooFew(len(group.results)))
+		printMeanMedian("Time to First Review", firstReviewTimes, bootstrap)
+		printMeanMedian("Time to Approval", approvalTimes, bootstrap)
+	}
+}
+
+// sloPeriods returns the periods SLOs are reported over: the -group-by periods if
+// there are any, or calendar months
+func sloPeriods(periods []period.Period, startDate, endDate time.Time) []period.Period {
+	if len(periods) > 0 {
+		return periods
+	}
+	return period.Months(startDate, endDate)
+}
+
+// sloSamples measures each PR against an SLO metric, or for review-request, each
+// request for a review. PRs still waiting count the time they've waited so far, in
+// business hours if review times are.
+func sloSamples(metric string, results []github.PullRequestMetric, schedule *businesshours.Schedule) []slo.Sample {
+	var samples []slo.Sample
+	for _, result := range results {
+		if metric == "review-request" {
+			// Unanswered requests already run until the PR was done with, or now
+			for _, request := range result.ReviewRequests {
+				samples = append(samples, slo.Sample{At: request.RequestedAt, Done: request.Responded, Elapsed: request.ResponseTime})
+			}
+			continue
+		}
+		sample := slo.Sample{At: result.CreatedAt}
+		switch metric {
+		case "first-review":
+			sample.Done, sample.Elapsed = result.HasReview, result.TimeToFirstReview
+		case "approval":
+			sample.Done, sample.Elapsed = result.Approver != "", result.TimeToApproval
+		}
+		if !sample.Done {
+			sample.Elapsed = result.TimeSinceCreation
+			if schedule != nil {
+				sample.Elapsed = schedule.Between(result.CreatedAt, time.Now())
+			}
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// printSLOs reports each objective's compliance, remaining error budget and burn rate per period
+func printSLOs(objectives slo.Objectives, periods []period.Period, results []github.PullRequestMetric, schedule *businesshours.Schedule) {
+	if len(objectives) == 0 {
+		return
+	}
+
+	fmt.Println("\nDelivery SLOs:")
+	fmt.Println("--------------")
+
+	for _, objective := range objectives {
+		of := "PRs"
+		if objective.Metric == "review-request" {
+			of = "review requests"
+		}
+		fmt.Printf("%s of %s:\n", objective, of)
+		printSLOResults(slo.Evaluate(objective, periods, sloSamples(objective.Metric, results, schedule)), "  ")
+
+		// Teams are held to review request SLOs on their own too
+		if objective.Metric == "review-request" {
+			for _, team := range github.SummarizeTeams(results) {
+				fmt.Printf("  %s:\n", team.Team)
+				printSLOResults(slo.Evaluate(objective, periods, teamSLOSamples(team.Team, results)), "    ")
+			}
+		}
+	}
+}
+
+// printSLOResults prints an objective's result for each period, indented by indent
+func printSLOResults(results []slo.Result, indent string) {
+	for _, result := range results {
+		if result.Total() == 0 {
+			fmt.Printf("%s%s: No data\n", indent, result.Period.Name)
+			continue
+		}
+		fmt.Printf("%s%s: %d/%d met (%.1f%%), %.0f%% of error budget remaining, burn rate %.2fx\n",
+			indent, result.Period.Name, result.Met, result.Total(), result.Compliance*100, result.BudgetRemaining*100, result.BurnRate)
+	}
+}
+
+// teamSLOSamples measures each request for a team's review against a review-request SLO
+func teamSLOSamples(team string, results []github.PullRequestMetric) []slo.Sample {
+	var samples []slo.Sample
+	for _, result := range results {
+		for _, request := range result.ReviewRequests {
+			if request.Team == team {
+				samples = append(samples, slo.Sample{At: request.RequestedAt, Done: request.Responded, Elapsed: request.ResponseTime})
+			}
+		}
+	}
+	return samples
+}
+
+// onCallPeriods returns the names of the periods each person was on call in.
+// PagerDuty users are matched to people by email through the identities file;
+// without an entry, they're reported under their email address.
+func onCallPeriods(shifts []pagerduty.Shift, people *identity.Directory, periods []period.Period) map[string][]string {
+	onCall := make(map[string][]string)
+	for _, p := range periods {
+		for _, shift := range shifts {
+			person := people.Resolve(identity.PagerDuty, shift.Email)
+			if shift.Overlaps(p) && !slices.Contains(onCall[person], p.Name) {
+				onCall[person] = append(onCall[person], p.Name)
+			}
+		}
+	}
+	return onCall
+}
+
+// resolveIdentities replaces the GitHub logins in results with people's names, so
+// someone with several accounts is reported as one person
+func resolveIdentities(results []github.PullRequestMetric, people *identity.Directory) {
+	if people == nil {
+		return
+	}
+	for i := range results {
+		results[i].Author = people.Resolve(identity.GitHub, results[i].Author)
+		results[i].FirstReviewer = people.Resolve(identity.GitHub, results[i].FirstReviewer)
+		results[i].FirstResponder = people.Resolve(identity.GitHub, results[i].FirstResponder)
+		results[i].Approver = people.Resolve(identity.GitHub, results[i].Approver)
+		for j := range results[i].Reviews {
+			results[i].Reviews[j].Reviewer = people.Resolve(identity.GitHub, results[i].Reviews[j].Reviewer)
+		}
+		for j := range results[i].ReviewRequests {
+			results[i].ReviewRequests[j].Reviewer = people.Resolve(identity.GitHub, results[i].ReviewRequests[j].Reviewer)
+		}
+	}
+}
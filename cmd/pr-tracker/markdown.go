@@ -0,0 +1,138 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// markdownTopN is how many PRs the "slowest" tables list
+const markdownTopN = 5
+
+// printMarkdown writes a compact Markdown summary, suitable for pasting into Slack or a GitHub discussion
+func printMarkdown(w io.Writer, repo string, startDate, endDate time.Time, results []github.PullRequestMetric, periods []period.Period, partial bool) {
+	fmt.Fprintf(w, "### PR review stats for %s (%s to %s)\n\n", repo, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if partial {
+		fmt.Fprintf(w, "> **Partial results:** the API call budget ran out, or the run was interrupted, before every PR was processed.\n\n")
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No pull requests found.")
+		return
+	}
+
+	qualify := spansRepositories(results)
+
+	var firstReviewTimes, approvalTimes, reviewerWaitTimes, waitingTimes []time.Duration
+	var reviewed, awaiting []github.PullRequestMetric
+	for _, result := range results {
+		if !result.HasReview {
+			waitingTimes = append(waitingTimes, result.TimeSinceCreation)
+			awaiting = append(awaiting, result)
+			continue
+		}
+		reviewed = append(reviewed, result)
+		if result.TimeToFirstReview > 0 {
+			firstReviewTimes = append(firstReviewTimes, result.TimeToFirstReview)
+		}
+		if result.TimeToApproval > 0 {
+			approvalTimes = append(approvalTimes, result.TimeToApproval)
+		}
+		reviewerWaitTimes = append(reviewerWaitTimes, result.ReviewerWaitTime)
+	}
+
+	markdown.Table(w, []string{"Metric", "Mean", "Median", "PRs"}, [][]string{
+		statsRow("Time to first review", firstReviewTimes),
+		statsRow("Time to approval", approvalTimes),
+		statsRow("Waiting on reviewers", reviewerWaitTimes),
+		statsRow("Awaiting review", waitingTimes),
+	})
+
+	if len(periods) > 0 {
+		fmt.Fprintf(w, "\n**By period**\n\n")
+		groups := period.Group(periods, results, func(result github.PullRequestMetric) time.Time {
+			return result.CreatedAt
+		})
+		var rows [][]string
+		for i, p := range periods {
+			var firstReviewTimes, approvalTimes []time.Duration
+			for _, result := range groups[i] {
+				if result.TimeToFirstReview > 0 {
+					firstReviewTimes = append(firstReviewTimes, result.TimeToFirstReview)
+				}
+				if result.TimeToApproval > 0 {
+					approvalTimes = append(approvalTimes, result.TimeToApproval)
+				}
+			}
+			rows = append(rows, []string{p.Name, fmt.Sprint(len(groups[i])) + tooFew(len(groups[i])), markdown.Duration(stats.MedianOf(firstReviewTimes)), markdown.Duration(stats.MedianOf(approvalTimes))})
+		}
+		markdown.Table(w, []string{"Period", "PRs", "First review (median)", "Approval (median)"}, rows)
+	}
+
+	if qualify {
+		fmt.Fprintf(w, "\n**By repository**\n\n")
+		var rows [][]string
+		for _, repo := range github.SummarizeByRepository(results) {
+			rows = append(rows, []string{repo.Group, fmt.Sprint(repo.PRCount), markdown.Duration(repo.MedianTimeToFirstReview), markdown.Duration(repo.MedianTimeToApproval)})
+		}
+		markdown.Table(w, []string{"Repository", "PRs", "First review (median)", "Approval (median)"}, rows)
+	}
+
+	// Slowest reviewed PRs, by time to approval (unapproved PRs count as slowest)
+	slices.SortFunc(reviewed, func(a, b github.PullRequestMetric) int {
+		return cmp.Compare(approvalSortKey(b), approvalSortKey(a))
+	})
+	if len(reviewed) > 0 {
+		fmt.Fprintf(w, "\n**Slowest to approval**\n\n")
+		var rows [][]string
+		for _, result := range reviewed[:min(markdownTopN, len(reviewed))] {
+			approval := "not yet approved"
+			if result.Approver != "" {
+				approval = markdown.Duration(result.TimeToApproval)
+			}
+			rows = append(rows, []string{prRef(result, qualify) + " " + result.PRTitle, result.Author, markdown.Duration(result.TimeToFirstReview), approval})
+		}
+		markdown.Table(w, []string{"PR", "Author", "First review", "Approval"}, rows)
+	}
+
+	// Longest-waiting PRs with no review at all
+	slices.SortFunc(awaiting, func(a, b github.PullRequestMetric) int {
+		return cmp.Compare(b.TimeSinceCreation, a.TimeSinceCreation)
+	})
+	if len(awaiting) > 0 {
+		fmt.Fprintf(w, "\n**Longest awaiting review**\n\n")
+		var rows [][]string
+		for _, result := range awaiting[:min(markdownTopN, len(awaiting))] {
+			rows = append(rows, []string{prRef(result, qualify) + " " + result.PRTitle, result.Author, markdown.Duration(result.TimeSinceCreation)})
+		}
+		markdown.Table(w, []string{"PR", "Author", "Waiting"}, rows)
+	}
+}
+
+// approvalSortKey orders PRs by time to approval, with unapproved PRs ordered by
+// how long they've been open, after every approved PR
+func approvalSortKey(result github.PullRequestMetric) time.Duration {
+	if result.Approver == "" {
+		return 1<<62 + result.TimeSinceCreation
+	}
+	return result.TimeToApproval
+}
+
+// statsRow formats a summary table row for a set of durations
+func statsRow(name string, durations []time.Duration) []string {
+	if len(durations) == 0 {
+		return []string{name, "-", "-", "0"}
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	mean := total / time.Duration(len(durations))
+	return []string{name, markdown.Duration(mean), markdown.Duration(stats.MedianOf(durations)), fmt.Sprint(len(durations))}
+}
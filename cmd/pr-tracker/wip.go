@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cfd"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/markdown"
+	"github.com/reillywatson/statstracker/internal/stats"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+// reportWIP reports on the PRs open now, for -wip
+func (t *tracker) reportWIP(ctx context.Context) {
+	if t.openPRs == nil {
+		log.Fatalf("-wip isn't supported with the %s provider", *t.provider)
+	}
+	// Search results say how big each PR is, so sizing costs nothing extra.
+	// Drafts are open too, and make up the cumulative flow's draft band.
+	t.opts.Size = true
+	t.opts.IncludeDrafts = true
+	var results []github.PullRequestMetric
+	var repoNames []string
+	merged := make(map[string]int)
+	for _, r := range t.repos {
+		repoNames = append(repoNames, r.owner+"/"+r.repo)
+		fmt.Fprintf(t.status, "Fetching open PRs for %s/%s...\n", r.owner, r.repo)
+		prs, err := t.openPRs.FetchOpenPullRequests(ctx, r.owner, r.repo)
+		if errors.Is(err, budget.ErrExhausted) || ctx.Err() != nil {
+			log.Printf("Stopped fetching pull requests early: %v", err)
+		} else if err != nil {
+			log.Fatalf("Error fetching open pull requests for %s/%s: %v", r.owner, r.repo, err)
+		}
+		fmt.Fprintf(t.status, "Found %d open pull requests for %s/%s\n", len(prs), r.owner, r.repo)
+		results = append(results, github.ProcessPullRequests(ctx, t.prClient, prs, r.owner, r.repo, t.opts)...)
+		if t.prBudget.Exhausted() || ctx.Err() != nil {
+			break
+		}
+		// The history's merged counts feed the cumulative flow diagram
+		if *t.wipHistoryPath != "" {
+			count, err := t.openPRs.CountMergedPullRequests(ctx, r.owner, r.repo)
+			if err != nil {
+				log.Printf("Error counting merged pull requests for %s/%s: %v", r.owner, r.repo, err)
+			} else {
+				merged[r.owner+"/"+r.repo] = count
+			}
+		}
+	}
+	resolveIdentities(results, t.people)
+
+	now := time.Now()
+	items := wip.Items(results, t.objectives, now, t.schedule)
+	for i := range items {
+		items[i].Team = t.people.Team(items[i].Author)
+	}
+	partial := t.partial(ctx)
+
+	// A partial snapshot would understate the day's WIP, so it isn't recorded
+	var history []wip.Snapshot
+	if *t.wipHistoryPath != "" {
+		h, err := wip.OpenHistory(*t.wipHistoryPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !partial {
+			h.Record(wip.Summarize(now, repoNames, items, merged))
+		}
+		history = h.Snapshots(repoNames)
+		if err := h.Close(); err != nil {
+			log.Printf("Error saving the WIP history: %v", err)
+		}
+	}
+
+	if t.useMarkdown {
+		printWIPMarkdown(os.Stdout, strings.Join(t.repoArgs, ", "), now, items, history, partial)
+	} else {
+		printWIP(items, history, partial)
+	}
+	if t.format != "" {
+		if err := export.WriteFile(t.format, *t.outFile, export.WIPRecords(items)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Fprintf(t.status, "\nWrote %d open PR records to %s\n", len(items), *t.outFile)
+	}
+	if *t.cfdPath != "" {
+		if err := writeCFD(*t.cfdPath, "Cumulative flow of "+strings.Join(t.repoArgs, ", "), history); err != nil {
+			log.Fatalf("Error writing the cumulative flow diagram: %v", err)
+		}
+		fmt.Fprintf(t.status, "Wrote the cumulative flow diagram to %s\n", *t.cfdPath)
+	}
+}
+
+// wipStates are the review states WIP is broken down by, most pressing first
+var wipStates = []string{wip.StateChangesRequested, wip.StateAwaitingReview, wip.StateCommented, wip.StateApproved}
+
+// wipDay is WIP on one day, summed across repositories
+type wipDay struct {
+	date             string
+	open, awaiting   int
+	breached         int
+	oldestAgeSeconds int64
+}
+
+// wipByDay sums the history's snapshots across repositories for each day, oldest first
+func wipByDay(history []wip.Snapshot) []wipDay {
+	var days []wipDay
+	for _, snapshot := range history {
+		if len(days) == 0 || days[len(days)-1].date != snapshot.Date {
+			days = append(days, wipDay{date: snapshot.Date})
+		}
+		day := &days[len(days)-1]
+		day.open += snapshot.Open
+		day.awaiting += snapshot.ByState[wip.StateAwaitingReview]
+		day.breached += snapshot.Breached
+		day.oldestAgeSeconds = max(day.oldestAgeSeconds, snapshot.OldestAgeSeconds)
+	}
+	return days
+}
+
+// printWIP outputs the open PRs, oldest first, then how WIP has changed over the history's days
+func printWIP(items []wip.Item, history []wip.Snapshot, partial bool) {
+	if partial {
+		fmt.Println("Note: the API call budget ran out, or the run was interrupted, so not every open PR is listed")
+	}
+	if len(items) == 0 {
+		fmt.Println("No open pull requests found")
+	} else {
+		qualify := itemsSpanRepositories(items)
+
+		fmt.Println("\nOpen Pull Requests (oldest first):")
+		fmt.Println("----------------------------------")
+		for _, item := range items {
+			fmt.Printf("PR %s: %s\n", itemRef(item, qualify), item.Title)
+			fmt.Printf("  Author: %s\n", item.Author)
+			fmt.Printf("  Age: %v\n", item.Age.Truncate(time.Second))
+			if item.Size != "" {
+				fmt.Printf("  Size: %s\n", item.Size)
+			}
+			fmt.Printf("  Review State: %s\n", item.ReviewState)
+			if item.SLA != "" {
+				fmt.Printf("  SLA: %s\n", slaStatus(item))
+			}
+		}
+
+		fmt.Println("\nWIP Summary:")
+		fmt.Println("------------")
+		fmt.Printf("Open PRs: %d\n", len(items))
+		byState := make(map[string]int)
+		var ages []time.Duration
+		breached := 0
+		for _, item := range items {
+			byState[item.ReviewState]++
+			ages = append(ages, item.Age)
+			if item.SLA == wip.SLABreached {
+				breached++
+			}
+		}
+		for _, state := range wipStates {
+			fmt.Printf("  %s: %d\n", state, byState[state])
+		}
+		fmt.Printf("Median Age: %v\n", stats.MedianOf(ages).Truncate(time.Second))
+		fmt.Printf("Oldest: %v\n", items[0].Age.Truncate(time.Second))
+		fmt.Printf("Over an SLO: %d\n", breached)
+	}
+
+	if days := wipByDay(history); len(days) > 0 {
+		fmt.Println("\nWIP Over Time:")
+		fmt.Println("--------------")
+		for _, day := range days {
+			fmt.Printf("%s: %d open, %d awaiting review, %d over an SLO, oldest %v\n",
+				day.date, day.open, day.awaiting, day.breached, (time.Duration(day.oldestAgeSeconds) * time.Second).Truncate(time.Second))
+		}
+	}
+}
+
+// printWIPMarkdown writes the WIP snapshot as a compact Markdown summary
+func printWIPMarkdown(w io.Writer, repo string, now time.Time, items []wip.Item, history []wip.Snapshot, partial bool) {
+	fmt.Fprintf(w, "### Open PRs in %s (%s)\n\n", repo, now.Format("2006-01-02"))
+	if partial {
+		fmt.Fprintf(w, "> **Partial results:** the API call budget ran out, or the run was interrupted, before every open PR was processed.\n\n")
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(w, "No open pull requests found.")
+	} else {
+		byState := make(map[string]int)
+		breached := 0
+		for _, item := range items {
+			byState[item.ReviewState]++
+			if item.SLA == wip.SLABreached {
+				breached++
+			}
+		}
+		headers := []string{"Open"}
+		row := []string{fmt.Sprint(len(items))}
+		for _, state := range wipStates {
+			headers = append(headers, capitalize(state))
+			row = append(row, fmt.Sprint(byState[state]))
+		}
+		markdown.Table(w, append(headers, "Over an SLO"), [][]string{append(row, fmt.Sprint(breached))})
+
+		qualify := itemsSpanRepositories(items)
+		fmt.Fprintf(w, "\n**Oldest open**\n\n")
+		var rows [][]string
+		for _, item := range items[:min(markdownTopN, len(items))] {
+			rows = append(rows, []string{itemRef(item, qualify) + " " + item.Title, item.Author, markdown.Duration(item.Age), item.ReviewState, slaStatus(item)})
+		}
+		markdown.Table(w, []string{"PR", "Author", "Age", "Review state", "SLA"}, rows)
+	}
+
+	if days := wipByDay(history); len(days) > 0 {
+		fmt.Fprintf(w, "\n**WIP over time**\n\n")
+		var rows [][]string
+		for _, day := range days {
+			rows = append(rows, []string{day.date, fmt.Sprint(day.open), fmt.Sprint(day.awaiting), fmt.Sprint(day.breached), markdown.Duration(time.Duration(day.oldestAgeSeconds) * time.Second)})
+		}
+		markdown.Table(w, []string{"Date", "Open", "Awaiting review", "Over an SLO", "Oldest"}, rows)
+	}
+}
+
+// slaStatus describes an open PR's SLA status, naming the SLOs it's over
+func slaStatus(item wip.Item) string {
+	if item.SLA == wip.SLABreached {
+		return fmt.Sprintf("%s (%s)", item.SLA, strings.Join(item.Breached, ", "))
+	}
+	if item.SLA == "" {
+		return "-"
+	}
+	return item.SLA
+}
+
+// itemsSpanRepositories reports whether the open PRs come from more than one repository
+func itemsSpanRepositories(items []wip.Item) bool {
+	for _, item := range items {
+		if item.Repository != items[0].Repository {
+			return true
+		}
+	}
+	return false
+}
+
+// itemRef identifies an open PR, qualified with its repository if qualify is set
+func itemRef(item wip.Item, qualify bool) string {
+	if qualify {
+		return fmt.Sprintf("%s#%d", item.Repository, item.Number)
+	}
+	return fmt.Sprintf("#%d", item.Number)
+}
+
+// capitalize upper-cases the first letter of s, for table headers
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// writeCFD writes the cumulative flow diagram of a WIP history to path: as an
+// HTML chart if it ends in .html, as Parquet if it ends in .parquet, and as CSV
+// otherwise
+func writeCFD(path, title string, history []wip.Snapshot) error {
+	days := cfd.Days(history)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		if err := cfd.WriteHTML(f, title, days); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	case ".parquet":
+		return export.WriteFile(export.FormatParquet, path, export.CFDRecords(days))
+	default:
+		return export.WriteFile(export.FormatCSV, path, export.CFDRecords(days))
+	}
+}
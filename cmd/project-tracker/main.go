@@ -0,0 +1,218 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+func main() {
+	// Define command line flags
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	isUser := flag.Bool("user", false, "The project belongs to a user rather than an organization")
+	excludeStatusStr := flag.String("exclude-status", "Done", "Comma-separated list of columns to leave out of the report, such as terminal columns")
+	outputFormat := flag.String("output", "", "Also export per-item, per-column times to -out-file in this format (csv, parquet)")
+	outFile := flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	maxGitHubCalls := flag.Int("max-github-calls", 0, "Stop gracefully with partial results after this many GitHub API calls (0 = unlimited)")
+	staleWhileRevalidate := flag.Bool("stale-while-revalidate", false, "Serve expired cache entries immediately and refresh them in the background")
+	configPath := config.RegisterFlag(flag.CommandLine)
+
+	// Parse flags
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*configPath, "project-tracker", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Check for project argument
+	args := flag.Args()
+	if len(args) == 0 {
+		args = configRepos
+	}
+	if len(args) < 1 {
+		fmt.Println("Usage: project-tracker [flags] owner/project-number")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 2 {
+		log.Fatal("Invalid project format. Use 'owner/project-number'")
+	}
+	owner := parts[0]
+	projectNumber, err := strconv.Atoi(parts[1])
+	if err != nil {
+		log.Fatalf("Invalid project number %q: %v", parts[1], err)
+	}
+
+	var excludedStatuses []string
+	if *excludeStatusStr != "" {
+		excludedStatuses = strings.Split(*excludeStatusStr, ",")
+	}
+
+	// Validate export options before doing any work
+	format, err := export.ParseOutputFlags(*outputFormat, *outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	// Get GitHub credentials from environment
+	tokenSource, err := github.TokenSourceFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Create cache
+	cacheImpl, err := cache.NewDefaultCache()
+	if err != nil {
+		log.Fatalf("Error creating cache: %v", err)
+	}
+	defer cacheImpl.Close()
+
+	// Create a cached GitHub client
+	client := github.NewCachedGitHubClientFromTokenSource(tokenSource, cacheImpl)
+	client.SetStaleWhileRevalidate(*staleWhileRevalidate)
+	defer client.Close()
+
+	// Cap API usage so large scans can't exhaust the org's shared rate limit
+	apiBudget := budget.New("API", *maxAPICalls)
+	githubBudget := apiBudget.Child("GitHub API", *maxGitHubCalls)
+	client.SetBudget(githubBudget)
+
+	fmt.Printf("Fetching items for project %s/%d...\n", owner, projectNumber)
+	items, err := client.FetchProjectItems(context.Background(), owner, projectNumber, *isUser)
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching project items early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching project items: %v", err)
+	}
+
+	fmt.Printf("Found %d items in project %s/%d\n", len(items), owner, projectNumber)
+
+	results := github.ProcessProjectItems(items, startDate, endDate)
+	for i := range results {
+		for _, status := range excludedStatuses {
+			delete(results[i].TimeInStatus, strings.TrimSpace(status))
+		}
+	}
+	results = slices.DeleteFunc(results, func(result github.ProjectItemMetric) bool {
+		return len(result.TimeInStatus) == 0
+	})
+
+	// Print the results
+	if githubBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all items were fetched\n", apiBudget.Used())
+	}
+	fmt.Printf("Time in column from %s to %s\n", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	printResults(results)
+
+	if format != "" {
+		records := export.ProjectItemStatusRecords(results)
+		if err := export.WriteFile(format, *outFile, records); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Printf("\nWrote %d item/column records to %s\n", len(records), *outFile)
+	}
+}
+
+// printResults outputs the analysis results in a readable format
+func printResults(results []github.ProjectItemMetric) {
+	if len(results) == 0 {
+		fmt.Println("No project items with status changes found")
+		return
+	}
+
+	fmt.Println("\nProject Items:")
+	fmt.Println("--------------")
+
+	for _, result := range results {
+		fmt.Printf("#%d: %s (%s)\n", result.Number, result.Title, result.Repository)
+		fmt.Printf("  Current Status: %s\n", result.CurrentStatus)
+		for _, status := range statusesByTime(result.TimeInStatus) {
+			fmt.Printf("  %s: %v\n", status, result.TimeInStatus[status].Truncate(time.Second))
+		}
+		fmt.Println()
+	}
+
+	printSummaryStatistics(results)
+}
+
+// statusesByTime returns the columns in timeInStatus, longest first
+func statusesByTime(timeInStatus map[string]time.Duration) []string {
+	statuses := make([]string, 0, len(timeInStatus))
+	for status := range timeInStatus {
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses, func(a, b string) int {
+		if c := cmp.Compare(timeInStatus[b], timeInStatus[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	return statuses
+}
+
+// printSummaryStatistics displays mean and median time in each column, across the items that spent time there
+func printSummaryStatistics(results []github.ProjectItemMetric) {
+	timesByStatus := make(map[string][]time.Duration)
+	totalByStatus := make(map[string]time.Duration)
+	for _, result := range results {
+		for status, d := range result.TimeInStatus {
+			timesByStatus[status] = append(timesByStatus[status], d)
+			totalByStatus[status] += d
+		}
+	}
+
+	fmt.Println("\nSummary Statistics:")
+	fmt.Println("-----------------")
+
+	for _, status := range statusesByTime(totalByStatus) {
+		times := timesByStatus[status]
+		mean := totalByStatus[status] / time.Duration(len(times))
+
+		fmt.Printf("%s (%d items):\n", status, len(times))
+		fmt.Printf("  Mean: %v\n", mean.Truncate(time.Second))
+		fmt.Printf("  Median: %v\n", stats.MedianOf(times).Truncate(time.Second))
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/businesshours"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/eventlog"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/github"
+)
+
+// definitions records how a set of derived results was computed, next to the results
+type definitions struct {
+	Version      string            `json:"version"`
+	RecomputedAt time.Time         `json:"recomputed_at"`
+	EventLog     string            `json:"event_log"`
+	Repositories []string          `json:"repositories"`
+	Since        string            `json:"since"`
+	Until        string            `json:"until"`
+	Settings     map[string]string `json:"settings"`
+}
+
+// scopeFlags choose which data is recomputed, and where to, rather than how metrics
+// are defined, so they're left out of the definitions version
+var scopeFlags = []string{"event-log", "since", "until", "output", "out-dir", "version", "config"}
+
+func main() {
+	// Define command line flags
+	eventLogPath := flag.String("event-log", "", "Event log written by pr-tracker -event-log to recompute metrics from (required)")
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	denyListStr := flag.String("exclude", "", "Comma-separated list of GitHub usernames to ignore")
+	includeBots := flag.Bool("include-bots", false, "Count PRs and reviews by bots, which are left out by default")
+	botPatternsStr := flag.String("bot-patterns", "", "Comma-separated glob patterns, e.g. '*-ci,deploy-*', for bot logins GitHub doesn't mark as bots")
+	includeAuthorsStr := flag.String("include-authors", "", "Comma-separated list of GitHub usernames to restrict PRs to (reviews from anyone still count)")
+	baseBranchesStr := flag.String("base", "", "Comma-separated base branches, e.g. 'main,release/*', to restrict PRs to")
+	tagsRepoStr := flag.String("tags-repo", "", "Tags repository in owner/repo format for checking tag commits")
+	requiredApprovals := flag.Int("required-approvals", 0, "Number of distinct approvals a PR needs (0 = read from the base branch's protection rules)")
+	excludeDismissed := flag.Bool("exclude-dismissed-approvals", false, "Don't count approvals that were later dismissed toward time to approval and required approvals")
+	excludeComments := flag.Bool("exclude-comment-reviews", false, "Don't count reviews that only left comments as a PR's first review")
+	firstResponse := flag.Bool("first-response", false, "Also measure time to first response, by review or by comment")
+	codeowners := flag.Bool("codeowners", false, "Measure time until the CODEOWNERS rules each PR triggers were satisfied")
+	byLanguage := flag.Bool("languages", false, "Classify PRs by the language most of their changes are in")
+	classify := flag.Bool("classify", false, "Classify PRs as docs, test, config or code by the paths they change")
+	bySize := flag.Bool("size", false, "Measure the lines and files each PR changes")
+	codingTime := flag.Bool("coding-time", false, "Measure coding time, from each PR's first commit until it was opened")
+	draftTime := flag.Bool("draft-time", false, "Measure review times from when PRs opened as drafts were marked ready for review")
+	reviewRequests := flag.Bool("review-requests", false, "Measure each reviewer's response time from when their review was requested")
+	comments := flag.Bool("comments", false, "Count reviewers' inline comments per PR and per 100 lines changed; implies -size")
+	hotfixLabelsStr := flag.String("hotfix-labels", "hotfix,emergency", "Comma-separated labels that mark PRs as hotfixes (empty to disable)")
+	var priorities github.Priorities
+	flag.Var(&priorities, "priority", "Priority level and the labels that put a PR at it, as level=label,label..., e.g. P0=sev0,urgent; give the most urgent first (repeatable)")
+	outputFormat := flag.String("output", "csv", "Format to write the recomputed per-PR metrics in (csv, parquet)")
+	outDir := flag.String("out-dir", "", "Directory to write recomputed results to, in a subdirectory per definitions version (required)")
+	version := flag.String("version", "", "Name for this version of the metric definitions (defaults to a hash of the settings, so unchanged settings reuse a version)")
+	businessHoursFlags := businesshours.RegisterFlags(flag.CommandLine)
+	configPath := config.RegisterFlag(flag.CommandLine)
+
+	// Parse flags
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*configPath, "recompute", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repoArgs := flag.Args()
+	if len(repoArgs) == 0 {
+		repoArgs = configRepos
+	}
+	if len(repoArgs) < 1 || *eventLogPath == "" || *outDir == "" {
+		fmt.Println("Usage: recompute -event-log FILE -out-dir DIR [flags] owner/repo [owner/repo...]")
+		fmt.Println("Recomputes PR metrics from a pr-tracker event log with the current definitions, without calling GitHub.")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	type repoRef struct{ owner, repo string }
+	var repos []repoRef
+	for _, arg := range repoArgs {
+		parts := strings.Split(arg, "/")
+		if len(parts) != 2 {
+			log.Fatalf("Invalid repository format %q. Use 'owner/repo'", arg)
+		}
+		repos = append(repos, repoRef{parts[0], parts[1]})
+	}
+
+	// Parse tags repository if provided
+	var tagsOwner, tagsRepo string
+	if *tagsRepoStr != "" {
+		tagsParts := strings.Split(*tagsRepoStr, "/")
+		if len(tagsParts) != 2 {
+			log.Fatal("Invalid tags repository format. Use 'owner/repo'")
+		}
+		tagsOwner = tagsParts[0]
+		tagsRepo = tagsParts[1]
+	}
+
+	users := github.UserFilter{IncludeBots: *includeBots}
+	if *botPatternsStr != "" {
+		users.BotPatterns = strings.Split(*botPatternsStr, ",")
+	}
+	if *denyListStr != "" {
+		users.Exclude = strings.Split(*denyListStr, ",")
+	}
+	if *includeAuthorsStr != "" {
+		users.Authors = strings.Split(*includeAuthorsStr, ",")
+	}
+
+	var baseBranches []string
+	if *baseBranchesStr != "" {
+		baseBranches = strings.Split(*baseBranchesStr, ",")
+	}
+
+	var hotfixLabels []string
+	if *hotfixLabelsStr != "" {
+		hotfixLabels = strings.Split(*hotfixLabelsStr, ",")
+	}
+
+	format, err := export.ParseFormat(*outputFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	schedule, err := businessHoursFlags.Schedule()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	defs := definitions{
+		Version:      *version,
+		EventLog:     *eventLogPath,
+		Repositories: repoArgs,
+		Since:        startDate.Format("2006-01-02"),
+		Until:        endDate.Format("2006-01-02"),
+		Settings:     definitionSettings(flag.CommandLine),
+	}
+	if defs.Version == "" {
+		defs.Version, err = definitionsVersion(defs.Settings)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	events, err := eventlog.Open(*eventLogPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer events.Close()
+	client := github.NewReplayClient(events)
+
+	opts := github.ProcessOptions{
+		Users:             users,
+		BaseBranches:      baseBranches,
+		TagsOwner:         tagsOwner,
+		TagsRepo:          tagsRepo,
+		RequiredApprovals: *requiredApprovals,
+		ExcludeDismissed:  *excludeDismissed,
+		ExcludeComments:   *excludeComments,
+		Codeowners:        *codeowners,
+		Languages:         *byLanguage,
+		Classify:          *classify,
+		Size:              *bySize || *comments,
+		Comments:          *comments,
+		FirstResponse:     *firstResponse,
+		CodingTime:        *codingTime,
+		DraftTime:         *draftTime,
+		ReviewRequests:    *reviewRequests,
+		BusinessHours:     schedule,
+		HotfixLabels:      hotfixLabels,
+		Priorities:        priorities,
+	}
+
+	// ProcessPullRequests reports anything the log is missing and carries on, just
+	// as if the API call had failed
+	var results []github.PullRequestMetric
+	for _, r := range repos {
+		prs, err := client.FetchPullRequests(context.Background(), r.owner, r.repo, startDate, endDate)
+		if err != nil {
+			log.Fatalf("Error reading pull requests for %s/%s: %v", r.owner, r.repo, err)
+		}
+		fmt.Printf("Found %d logged pull requests for %s/%s\n", len(prs), r.owner, r.repo)
+		results = append(results, github.ProcessPullRequests(context.Background(), client, prs, r.owner, r.repo, opts)...)
+	}
+
+	// Each version gets its own directory, so results from older definitions are kept
+	dir := filepath.Join(*outDir, defs.Version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Error creating output directory: %v", err)
+	}
+	outFile := filepath.Join(dir, "prs."+string(format))
+	if err := export.WriteFile(format, outFile, export.PullRequestRecords(results)); err != nil {
+		log.Fatalf("Error exporting results: %v", err)
+	}
+	reviews := export.ReviewRecords(results)
+	reviewsFile := filepath.Join(dir, "reviews."+string(format))
+	if err := export.WriteFile(format, reviewsFile, reviews); err != nil {
+		log.Fatalf("Error exporting reviews: %v", err)
+	}
+	defs.RecomputedAt = time.Now()
+	if err := writeDefinitions(filepath.Join(dir, "definitions.json"), defs); err != nil {
+		log.Fatalf("Error writing definitions: %v", err)
+	}
+
+	fmt.Printf("\nWrote %d PR records to %s and %d review records to %s (definitions version %s)\n", len(results), outFile, len(reviews), reviewsFile, defs.Version)
+}
+
+// definitionSettings returns the value of every flag that affects how metrics are
+// defined, whether set on the command line, from the config file or by default
+func definitionSettings(fs *flag.FlagSet) map[string]string {
+	settings := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(scopeFlags, f.Name) {
+			settings[f.Name] = f.Value.String()
+		}
+	})
+	return settings
+}
+
+// definitionsVersion derives a version from the settings, so the same definitions
+// always get the same version. A local holidays file's contents count, not just its
+// path; holidays fetched from a URL are only identified by the URL.
+func definitionsVersion(settings map[string]string) (string, error) {
+	// Maps are encoded with sorted keys, so the encoding is stable
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(encoded)
+	if path := settings["holidays"]; path != "" && !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		holidays, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read holidays file: %w", err)
+		}
+		h.Write(holidays)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// writeDefinitions writes defs to path as indented JSON
+func writeDefinitions(path string, defs definitions) error {
+	data, err := json.MarshalIndent(defs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/export"
+	"github.com/reillywatson/statstracker/internal/expr"
+	"github.com/reillywatson/statstracker/internal/hooks"
+	"github.com/reillywatson/statstracker/internal/retry"
+	"github.com/reillywatson/statstracker/internal/terraform"
+)
+
+func main() {
+	// Define command line flags
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	organization := flag.String("organization", "", "Terraform Cloud or Enterprise organization (required)")
+	workspacesList := flag.String("workspaces", "", "Comma-separated workspace names to report on (defaults to all of the organization's workspaces)")
+	hostname := flag.String("hostname", terraform.DefaultHostname, "Hostname of the Terraform Enterprise instance, for organizations not on Terraform Cloud")
+	outputFormat := flag.String("output", "", "Also export per-run metrics to -out-file in this format (csv, parquet)")
+	outFile := flag.String("out-file", "", "Path to write exported metrics to (used with -output)")
+	maxAPICalls := flag.Int("max-api-calls", 0, "Stop gracefully with partial results after this many API calls (0 = unlimited)")
+	maxTerraformCalls := flag.Int("max-terraform-calls", 0, "Stop gracefully with partial results after this many Terraform API calls (0 = unlimited)")
+	exprFlags := expr.RegisterFlags(flag.CommandLine)
+	retryFlags := retry.RegisterFlags(flag.CommandLine)
+	hookCommand := flag.String("hook", "", "Program to run on each run, given its export record as JSON on stdin, whose JSON output adds fields to the export")
+	configPath := config.RegisterFlag(flag.CommandLine)
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	if _, err := config.Apply(*configPath, "terraform-tracker", flag.CommandLine); err != nil {
+		log.Fatal(err)
+	}
+
+	if *organization == "" {
+		fmt.Println("Usage: terraform-tracker -organization ORG [flags]")
+		fmt.Println("Example: terraform-tracker -organization my-org -workspaces network,dns")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		fmt.Println("\nRequired environment variables:")
+		fmt.Println("  TFE_TOKEN: Terraform Cloud or Enterprise user or team API token")
+		os.Exit(1)
+	}
+
+	// Validate export options before doing any work
+	format, err := export.ParseOutputFlags(*outputFormat, *outFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := exprFlags.Rules()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	var workspaceNames []string
+	if *workspacesList != "" {
+		for _, name := range strings.Split(*workspacesList, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				workspaceNames = append(workspaceNames, name)
+			}
+		}
+	}
+
+	// Get the Terraform API token from environment
+	token := os.Getenv("TFE_TOKEN")
+	if token == "" {
+		log.Fatal("TFE_TOKEN environment variable not set")
+	}
+
+	client := terraform.NewTerraformClient(*hostname, token, *organization)
+
+	// Cap API usage so large scans can't exhaust shared rate limits
+	apiBudget := budget.New("API", *maxAPICalls)
+	terraformBudget := apiBudget.Child("Terraform API", *maxTerraformCalls)
+	client.SetBudget(terraformBudget)
+	client.SetRetryPolicy(retryFlags.Policy())
+
+	ctx := context.Background()
+
+	workspaces, err := client.FetchWorkspaces(ctx, workspaceNames)
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Stopped fetching workspaces early: %v", err)
+	} else if err != nil {
+		log.Fatalf("Error fetching workspaces: %v", err)
+	}
+
+	var runs []terraform.Run
+	for _, workspace := range workspaces {
+		if terraformBudget.Exhausted() {
+			break
+		}
+		fmt.Printf("Fetching runs for %s from %s to %s...\n", workspace.Name, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+		workspaceRuns, err := client.FetchRuns(ctx, workspace, startDate, endDate)
+		runs = append(runs, workspaceRuns...)
+		if errors.Is(err, budget.ErrExhausted) {
+			log.Printf("Stopped fetching runs early: %v", err)
+			break
+		} else if err != nil {
+			log.Fatalf("Error fetching runs: %v", err)
+		}
+	}
+
+	fmt.Printf("Found %d runs in %d workspaces\n", len(runs), len(workspaces))
+
+	results := terraform.ProcessRuns(runs)
+
+	// Let a user-provided program add fields to each run, such as its owning team
+	if *hookCommand != "" {
+		hook, err := hooks.New(*hookCommand)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i := range results {
+			extra, err := hook.Run(export.TerraformRunRecords(results[i : i+1])[0])
+			if err != nil {
+				log.Printf("Error running hook for %s: %v", results[i].RunID, err)
+				continue
+			}
+			results[i].Extra = extra
+		}
+	}
+
+	// Add the configured computed columns, and leave out runs the filter rejects
+	if rules != nil {
+		kept := results[:0]
+		for _, result := range results {
+			record := export.TerraformRunRecords([]terraform.RunMetric{result})[0]
+			extra, keep, err := rules.Apply(export.Fields(record), result.Extra)
+			if err != nil {
+				log.Fatalf("Error applying -where/-column to %s: %v", result.RunID, err)
+			}
+			if keep {
+				result.Extra = extra
+				kept = append(kept, result)
+			}
+		}
+		results = kept
+	}
+
+	// Print the results
+	if terraformBudget.Exhausted() {
+		fmt.Printf("\nWARNING: PARTIAL RESULTS - API call budget exhausted after %d calls; not all workspaces or runs were fetched\n", apiBudget.Used())
+	}
+	printResults(terraform.SummarizeWorkspaces(results, startDate, endDate))
+
+	if format != "" {
+		if err := export.WriteFile(format, *outFile, export.TerraformRunRecords(results)); err != nil {
+			log.Fatalf("Error exporting results: %v", err)
+		}
+		fmt.Printf("\nWrote %d run records to %s\n", len(results), *outFile)
+	}
+}
+
+// printResults outputs each workspace's run statistics in a readable format
+func printResults(stats []terraform.WorkspaceStats) {
+	if len(stats) == 0 {
+		fmt.Println("No runs found")
+		return
+	}
+
+	fmt.Println("\nWorkspaces:")
+	fmt.Println("-----------")
+
+	total := terraform.WorkspaceStats{}
+	for _, s := range stats {
+		fmt.Printf("%s\n", s.Workspace)
+		fmt.Printf("  Runs: %d (%d speculative plans)\n", s.Runs, s.SpeculativePlans)
+		fmt.Printf("  Applies: %d (%.1f per week)\n", s.Applies, s.AppliesPerWeek)
+		fmt.Printf("  Failure Rate: %.1f%% (%d of %d finished runs)\n", s.FailureRate()*100, s.Failures, s.Finished)
+		if s.MedianPlanDuration > 0 {
+			fmt.Printf("  Median Plan Duration: %v\n", s.MedianPlanDuration.Truncate(time.Second))
+		}
+		if s.MedianApplyDuration > 0 {
+			fmt.Printf("  Median Apply Duration: %v\n", s.MedianApplyDuration.Truncate(time.Second))
+		}
+		fmt.Println()
+
+		total.Runs += s.Runs
+		total.Applies += s.Applies
+		total.AppliesPerWeek += s.AppliesPerWeek
+		total.Failures += s.Failures
+		total.Finished += s.Finished
+	}
+
+	fmt.Println("Summary Statistics:")
+	fmt.Println("------------------")
+	fmt.Printf("Total Runs: %d\n", total.Runs)
+	fmt.Printf("Total Applies: %d (%.1f per week)\n", total.Applies, total.AppliesPerWeek)
+	fmt.Printf("Failure Rate: %.1f%% (%d of %d finished runs)\n", total.FailureRate()*100, total.Failures, total.Finished)
+}
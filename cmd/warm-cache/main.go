@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/config"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+func main() {
+	// Define command line flags
+	startDateStr := flag.String("since", "", "Start date in YYYY-MM-DD format (defaults to 30 days ago)")
+	endDateStr := flag.String("until", "", "End date in YYYY-MM-DD format (defaults to now)")
+	tagsRepoStr := flag.String("tags-repo", "", "Tags repository in owner/repo format; also warms the tag commits pr-tracker checks")
+	codeowners := flag.Bool("codeowners", false, "Also warm the PR files, CODEOWNERS and team data pr-tracker -codeowners reads")
+	concurrency := flag.Int("concurrency", 4, "Maximum number of concurrent API requests")
+	projectID := flag.String("project", "", "Google Cloud project ID; if set, also warms Cloud Deploy releases")
+	regions := deploy.NewRegions("us-east4")
+	flag.Var(regions, "region", "Google Cloud region, or comma-separated regions, to warm Cloud Deploy releases from (defaults to us-east4; repeatable)")
+	allRegions := flag.Bool("all-regions", false, "Warm Cloud Deploy releases from every region the project can use, instead of -region")
+	pipelineRegex := flag.String("pipeline-regex", "", "Warm releases from Cloud Deploy pipelines whose ID matches this regular expression, as deploy-tracker -pipeline-regex")
+	pipelineLabels := flag.String("pipeline-labels", "", "Comma-separated key=value labels Cloud Deploy pipelines must have, as deploy-tracker -pipeline-labels")
+	pipelineAnnotations := flag.String("pipeline-annotations", "", "Comma-separated key=value annotations Cloud Deploy pipelines must have, as deploy-tracker -pipeline-annotations")
+	retryFlags := retry.RegisterFlags(flag.CommandLine)
+	configPath := config.RegisterFlag(flag.CommandLine)
+
+	// Parse flags
+	flag.Parse()
+
+	// Fill in anything not given on the command line from the config file
+	configRepos, err := config.Apply(*configPath, "warm-cache", flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = configRepos
+	}
+	if len(args) < 1 && *projectID == "" {
+		fmt.Println("Usage: warm-cache [flags] owner/repo [owner/repo...]")
+		fmt.Println("Pre-fetches PRs, reviews, commits and releases into the cache so subsequent reports are fast.")
+		fmt.Println("Flags:")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if *concurrency < 1 {
+		log.Fatal("-concurrency must be at least 1")
+	}
+
+	type repoRef struct{ owner, repo string }
+	var repos []repoRef
+	for _, arg := range args {
+		parts := strings.Split(arg, "/")
+		if len(parts) != 2 {
+			log.Fatalf("Invalid repository format %q. Use 'owner/repo'", arg)
+		}
+		repos = append(repos, repoRef{parts[0], parts[1]})
+	}
+
+	var tagsOwner, tagsRepo string
+	if *tagsRepoStr != "" {
+		tagsParts := strings.Split(*tagsRepoStr, "/")
+		if len(tagsParts) != 2 {
+			log.Fatal("Invalid tags repository format. Use 'owner/repo'")
+		}
+		tagsOwner = tagsParts[0]
+		tagsRepo = tagsParts[1]
+	}
+
+	// Parse start date
+	startDate := time.Now().AddDate(0, 0, -30) // Default to 30 days ago
+	if *startDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *startDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		startDate = parsedDate
+	}
+	endDate := time.Now() // Default to now
+	if *endDateStr != "" {
+		parsedDate, err := time.Parse("2006-01-02", *endDateStr)
+		if err != nil {
+			log.Fatalf("Invalid date format. Please use YYYY-MM-DD: %v", err)
+		}
+		endDate = parsedDate
+	}
+	if startDate.After(endDate) {
+		log.Fatal("Start date cannot be after end date")
+	}
+
+	// Get GitHub credentials from environment
+	tokenSource, err := github.TokenSourceFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Cloud Deploy releases' GitHub lookups only take a personal access token
+	token := os.Getenv("GITHUB_TOKEN")
+	if *projectID != "" && token == "" {
+		log.Fatal("GITHUB_TOKEN environment variable not set (needed for -project)")
+	}
+
+	// Create cache
+	cacheImpl, err := cache.NewDefaultCache()
+	if err != nil {
+		log.Fatalf("Error creating cache: %v", err)
+	}
+	defer cacheImpl.Close()
+
+	client := github.NewCachedGitHubClientFromTokenSource(tokenSource, cacheImpl)
+	client.SetRetryPolicy(retryFlags.Policy())
+	defer client.Close()
+
+	// Ctrl-C stops warming; whatever was fetched by then stays cached
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Fetch every PR list first: they're cheap, and they tell us how much
+	// per-PR work there is before we spread it across workers.
+	prsByRepo := make([][]*github.PullRequest, len(repos))
+	for i, r := range repos {
+		fmt.Printf("Warming PRs for %s/%s from %s to %s...\n", r.owner, r.repo, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+		prs, err := client.FetchPullRequests(ctx, r.owner, r.repo, startDate, endDate)
+		if err != nil {
+			log.Fatalf("Error fetching pull requests for %s/%s: %v", r.owner, r.repo, err)
+		}
+		prsByRepo[i] = prs
+	}
+
+	// Run the same per-PR fetches pr-tracker makes (reviews and, with a tags
+	// repo, tag commits) so the cache keys line up exactly. Each worker gets
+	// its own shard of PRs; the metrics themselves are discarded.
+	for i, r := range repos {
+		shards := shard(prsByRepo[i], *concurrency)
+		forEach(len(shards), *concurrency, func(j int) {
+			github.ProcessPullRequests(ctx, client, shards[j], r.owner, r.repo, github.ProcessOptions{TagsOwner: tagsOwner, TagsRepo: tagsRepo, Codeowners: *codeowners})
+		})
+		fmt.Printf("  Warmed reviews for %d PRs in %s/%s\n", len(prsByRepo[i]), r.owner, r.repo)
+	}
+
+	if *projectID != "" {
+		pipelines, err := deploy.ParsePipelineSelector(*pipelineRegex, *pipelineLabels, *pipelineAnnotations)
+		if err != nil {
+			log.Fatal(err)
+		}
+		deployRegions := regions.List()
+		if *allRegions {
+			deployRegions = nil
+		}
+		warmReleases(ctx, token, *projectID, deployRegions, pipelines, startDate, endDate, *concurrency, retryFlags.Policy(), cacheImpl)
+	}
+
+	fmt.Println("Cache warm complete")
+}
+
+// warmReleases caches the rollout completion times deploy-tracker looks up for each release
+func warmReleases(ctx context.Context, token, projectID string, regions []string, pipelines deploy.PipelineSelector, startDate, endDate time.Time, concurrency int, retryPolicy retry.Policy, cacheImpl cache.Cache) {
+	client, err := deploy.NewCachedDeployClient(projectID, regions, token, "", "", "", cacheImpl)
+	if err != nil {
+		log.Fatalf("Error creating deploy client: %v", err)
+	}
+	client.SetPipelineSelector(pipelines)
+	client.SetRetryPolicy(retryPolicy)
+	defer client.Close()
+
+	fmt.Printf("Warming releases for project %s in %s...\n", projectID, regionsDescription(regions))
+	releases, err := client.ListReleases(ctx, startDate, endDate)
+	if err != nil {
+		log.Fatalf("Error fetching releases: %v", err)
+	}
+
+	forEach(len(releases), concurrency, func(i int) {
+		release := releases[i]
+		if _, err := client.GetRolloutCompletion(ctx, release); err != nil {
+			log.Printf("Error warming rollouts for release %s: %v", release.Name, err)
+		}
+	})
+	fmt.Printf("  Warmed %d releases\n", len(releases))
+}
+
+// regionsDescription names the regions releases are warmed from
+func regionsDescription(regions []string) string {
+	if len(regions) == 0 {
+		return "all regions"
+	}
+	return strings.Join(regions, ", ")
+}
+
+// shard splits items into at most n roughly equal, interleaved groups
+func shard[T any](items []T, n int) [][]T {
+	if n > len(items) {
+		n = len(items)
+	}
+	shards := make([][]T, n)
+	for i, item := range items {
+		shards[i%n] = append(shards[i%n], item)
+	}
+	return shards
+}
+
+// forEach calls fn for 0..n-1 using at most concurrency goroutines
+func forEach(n, concurrency int, fn func(i int)) {
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+}
@@ -0,0 +1,243 @@
+// Package argocd reads deployments from Argo CD's sync history, for teams that
+// deploy with GitOps rather than with Cloud Deploy. Each sync an Application
+// records is a release of the Git revision it synced to.
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// CommitFunc looks up the message of a commit in a GitHub repository and when it
+// was made. owner and repo are empty when the synced source isn't a GitHub
+// repository, for the caller to look in the services' repository instead.
+type CommitFunc func(ctx context.Context, owner, repo, sha string) (message string, committed time.Time, err error)
+
+// ArgoCDClient handles Argo CD API operations
+type ArgoCDClient struct {
+	httpClient *http.Client
+	token      string
+	apps       []string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+	commits    CommitFunc
+
+	// The syncs listed, for the other methods
+	mu    sync.Mutex
+	syncs map[string]argoSync
+}
+
+var _ deploy.DeployProvider = (*ArgoCDClient)(nil)
+
+// NewArgoCDClient creates a new client for the given Applications on the Argo CD
+// server at serverURL, authenticated with an API token. commits looks up the
+// commits that were synced.
+func NewArgoCDClient(serverURL, token string, apps []string, commits CommitFunc) *ArgoCDClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &ArgoCDClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:   token,
+		apps:    apps,
+		baseURL: strings.TrimSuffix(serverURL, "/"),
+		retry:   retrying,
+		commits: commits,
+		syncs:   make(map[string]argoSync),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *ArgoCDClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *ArgoCDClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// argoSync is an entry in an Application's history: a sync that succeeded
+type argoSync struct {
+	ID              int64        `json:"id"`
+	Revision        string       `json:"revision"`
+	Revisions       []string     `json:"revisions"` // For Applications with several sources
+	Source          argoSource   `json:"source"`
+	Sources         []argoSource `json:"sources"`
+	DeployStartedAt time.Time    `json:"deployStartedAt"`
+	DeployedAt      time.Time    `json:"deployedAt"`
+}
+
+type argoSource struct {
+	RepoURL string `json:"repoURL"`
+	Chart   string `json:"chart"` // Set for Helm repositories, whose revisions are chart versions
+}
+
+// ListReleases lists the Applications' syncs made in the date range, newest
+// first within each Application. Argo CD only keeps an Application's last few
+// syncs (its revisionHistoryLimit, 10 by default), so older ones can't be found.
+// If the API budget runs out part way through, the releases found so far are
+// returned along with the error.
+func (c *ArgoCDClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, app := range c.apps {
+		var application struct {
+			Status struct {
+				History []argoSync `json:"history"`
+			} `json:"status"`
+		}
+		if err := c.get(ctx, "/api/v1/applications/"+url.PathEscape(app), &application); err != nil {
+			return releases, fmt.Errorf("failed to get application %s: %w", app, err)
+		}
+
+		// History is oldest first
+		history := application.Status.History
+		for _, s := range slices.Backward(history) {
+			started := s.DeployStartedAt
+			if started.IsZero() {
+				started = s.DeployedAt
+			}
+			if started.Before(startDate) || started.After(endDate) {
+				continue
+			}
+			releases = append(releases, c.release(app, s, started))
+		}
+	}
+	return releases, nil
+}
+
+// release converts a sync to a deploy.Release, remembering it for ExtractSource
+// and GetRolloutCompletion
+func (c *ArgoCDClient) release(app string, s argoSync, started time.Time) *deploy.Release {
+	// The history ID comes last, so it's used as the release ID
+	name := fmt.Sprintf("applications/%s/history/%s-%d", app, app, s.ID)
+	c.mu.Lock()
+	c.syncs[name] = s
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        name,
+		CreateTime:  started,
+		Annotations: map[string]string{"app": app},
+	}
+}
+
+// shaPattern matches a Git commit SHA, as opposed to a Helm chart version
+var shaPattern = regexp.MustCompile(`^[a-f0-9]{40}$`)
+
+// ExtractSource returns the commit a sync deployed, the PR it merged if its
+// message names one, and when it was made. For an Application with several
+// sources, the first that's a Git repository is used.
+func (c *ArgoCDClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	s, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	revisions, sources := []string{s.Revision}, []argoSource{s.Source}
+	if len(s.Revisions) > 0 {
+		revisions, sources = s.Revisions, s.Sources
+	}
+	for i, revision := range revisions {
+		if i >= len(sources) || sources[i].Chart != "" || !shaPattern.MatchString(revision) {
+			continue
+		}
+		owner, repo := githubRepository(sources[i].RepoURL)
+		message, committed, err := c.commits(ctx, owner, repo, revision)
+		if err != nil {
+			return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", revision, err)
+		}
+		return revision, deploy.PRFromCommitMessage(message), committed, nil
+	}
+	return "", "", time.Time{}, fmt.Errorf("no Git commit found in the sync's revisions")
+}
+
+// GetRolloutCompletion returns when the sync finished
+func (c *ArgoCDClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	s, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.DeployedAt, nil
+}
+
+// listed returns the sync a release was converted from
+func (c *ArgoCDClient) listed(release *deploy.Release) (argoSync, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.syncs[release.Name]
+	if !ok {
+		return argoSync{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return s, nil
+}
+
+// githubRepoPattern matches the owner and name of a GitHub repository in an
+// HTTPS or SSH clone URL
+var githubRepoPattern = regexp.MustCompile(`^(?:https://|ssh://git@|git@)github\.com[/:]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// githubRepository returns the owner and name of the GitHub repository at repoURL,
+// or empty strings if it isn't one
+func githubRepository(repoURL string) (owner, repo string) {
+	matches := githubRepoPattern.FindStringSubmatch(repoURL)
+	if matches == nil {
+		return "", ""
+	}
+	return matches[1], matches[2]
+}
+
+// get makes an Argo CD API request and decodes the response into result
+func (c *ArgoCDClient) get(ctx context.Context, path string, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024 as Argo CD encodes times
+func at(hour int) string {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)
+}
+
+const (
+	shaOld  = "1111111111111111111111111111111111111111"
+	shaMain = "abc1234abc1234abc1234abc1234abc1234abc12"
+	shaOps  = "def5678def5678def5678def5678def5678def56"
+)
+
+func TestArgoCDClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the API token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/api/v1/applications/shop" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found", "code": 5, "message": "applications.argoproj.io \"missing\" not found"}`))
+			return
+		}
+		// Oldest first; the first sync is before the date range, and the last has a
+		// Helm chart as well as its manifests
+		fmt.Fprintf(w, `{"status": {"history": [
+			{"id": 1, "revision": %q, "source": {"repoURL": "https://github.com/someorg/shop.git"}, "deployStartedAt": %q, "deployedAt": %q},
+			{"id": 2, "revision": %q, "source": {"repoURL": "https://github.com/someorg/shop.git"}, "deployStartedAt": %q, "deployedAt": %q},
+			{"id": 3, "revisions": ["1.2.0", %q], "sources": [{"repoURL": "https://charts.example.com", "chart": "shop"}, {"repoURL": "git@github.com:someorg/shop-ops.git"}], "deployStartedAt": %q, "deployedAt": %q}
+		]}}`, shaOld, "2024-05-01T09:00:00Z", "2024-05-01T09:05:00Z", shaMain, at(9), at(10), shaOps, at(11), at(12))
+	}))
+	defer server.Close()
+
+	var looked []string
+	commits := func(ctx context.Context, owner, repo, sha string) (string, time.Time, error) {
+		looked = append(looked, owner+"/"+repo)
+		if sha == shaMain {
+			return "Add search (#42)", time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC), nil
+		}
+		return "Bump shop image", time.Date(2024, 6, 3, 10, 30, 0, 0, time.UTC), nil
+	}
+	client := NewArgoCDClient(server.URL+"/", "token", []string{"shop"}, commits)
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Newest first, leaving out the sync before the range
+	if len(releases) != 2 || releases[0].Name != "applications/shop/history/shop-3" || releases[1].Name != "applications/shop/history/shop-2" {
+		t.Fatalf("Expected syncs 3 and 2, got %v", releases)
+	}
+
+	sha, prNumber, commitTime, err := client.ExtractSource(context.Background(), releases[1])
+	if err != nil || sha != shaMain || prNumber != "42" || commitTime.Format(time.RFC3339) != at(8) {
+		t.Errorf("Expected PR #42's squashed commit, got %s %s %v (%v)", sha, prNumber, commitTime, err)
+	}
+	// The chart's version isn't a commit, so the manifests' is used
+	if sha, prNumber, _, err := client.ExtractSource(context.Background(), releases[0]); err != nil || sha != shaOps || prNumber != "" {
+		t.Errorf("Expected the ops repository's commit with no PR, got %s %q (%v)", sha, prNumber, err)
+	}
+	if len(looked) != 2 || looked[0] != "someorg/shop" || looked[1] != "someorg/shop-ops" {
+		t.Errorf("Expected commits to be looked up in each source's repository, got %v", looked)
+	}
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[1])
+	if err != nil || finish.Format(time.RFC3339) != at(10) {
+		t.Errorf("Expected the sync's finish time, got %v (%v)", finish, err)
+	}
+
+	missing := NewArgoCDClient(server.URL, "token", []string{"missing"}, commits)
+	if _, err := missing.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), `status 404: applications.argoproj.io "missing" not found`) {
+		t.Errorf("Expected Argo CD's error, got %v", err)
+	}
+}
+
+func TestGitHubRepository(t *testing.T) {
+	for repoURL, want := range map[string]string{
+		"https://github.com/someorg/shop.git":   "someorg/shop",
+		"https://github.com/someorg/shop":       "someorg/shop",
+		"git@github.com:someorg/shop.git":       "someorg/shop",
+		"ssh://git@github.com/someorg/shop.git": "someorg/shop",
+		"https://gitlab.com/someorg/shop.git":   "/",
+		"https://charts.example.com":            "/",
+	} {
+		if owner, repo := githubRepository(repoURL); owner+"/"+repo != want {
+			t.Errorf("githubRepository(%q) = %s/%s, want %s", repoURL, owner, repo, want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrExhausted is returned (wrapped) by API clients once a budget has been spent
+var ErrExhausted = errors.New("API call budget exhausted")
+
+// Budget caps the number of API calls a run may make. Budgets can be nested:
+// a per-provider budget created with Child also draws from its parent, so a
+// global --max-api-calls applies across providers. A nil *Budget is unlimited.
+type Budget struct {
+	name   string
+	limit  int // <= 0 means unlimited
+	parent *Budget
+
+	mu   sync.Mutex
+	used int
+}
+
+// New creates a top-level budget. A limit <= 0 means unlimited, which is still
+// useful for counting calls.
+func New(name string, limit int) *Budget {
+	return &Budget{name: name, limit: limit}
+}
+
+// Child creates a budget that also draws from b
+func (b *Budget) Child(name string, limit int) *Budget {
+	return &Budget{name: name, limit: limit, parent: b}
+}
+
+// Spend records one API call, or returns an error wrapping ErrExhausted if this
+// budget or any of its ancestors has no calls left. Nothing is recorded on error.
+func (b *Budget) Spend() error {
+	if b == nil {
+		return nil
+	}
+
+	// Lock the whole chain (child before parent) so the check and the
+	// increment are atomic across all levels.
+	var chain []*Budget
+	for cur := b; cur != nil; cur = cur.parent {
+		cur.mu.Lock()
+		chain = append(chain, cur)
+	}
+	defer func() {
+		for _, cur := range chain {
+			cur.mu.Unlock()
+		}
+	}()
+
+	for _, cur := range chain {
+		if cur.limit > 0 && cur.used >= cur.limit {
+			return fmt.Errorf("%s: %w (limit %d)", cur.name, ErrExhausted, cur.limit)
+		}
+	}
+	for _, cur := range chain {
+		cur.used++
+	}
+	return nil
+}
+
+// Used returns the number of calls recorded against b
+func (b *Budget) Used() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Exhausted reports whether b or any ancestor has reached its limit
+func (b *Budget) Exhausted() bool {
+	for cur := b; cur != nil; cur = cur.parent {
+		cur.mu.Lock()
+		exhausted := cur.limit > 0 && cur.used >= cur.limit
+		cur.mu.Unlock()
+		if exhausted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package budget
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBudget_Spend(t *testing.T) {
+	b := New("github", 2)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Spend(); err != nil {
+			t.Fatalf("Expected call %d to be within budget, got %v", i+1, err)
+		}
+	}
+
+	err := b.Spend()
+	if !errors.Is(err, ErrExhausted) {
+		t.Fatalf("Expected ErrExhausted, got %v", err)
+	}
+	if !b.Exhausted() {
+		t.Errorf("Expected budget to report exhausted")
+	}
+	if b.Used() != 2 {
+		t.Errorf("Expected 2 calls used, got %d", b.Used())
+	}
+}
+
+func TestBudget_ChildDrawsFromParent(t *testing.T) {
+	global := New("global", 3)
+	github := global.Child("github", 0)
+	deploy := global.Child("deploy", 1)
+
+	if err := deploy.Spend(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := deploy.Spend(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Expected per-provider budget to be exhausted, got %v", err)
+	}
+
+	// The failed deploy call must not have been charged to the global budget
+	if global.Used() != 1 {
+		t.Errorf("Expected 1 global call used, got %d", global.Used())
+	}
+
+	if err := github.Spend(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := github.Spend(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := github.Spend(); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Expected global budget to be exhausted, got %v", err)
+	}
+	if !github.Exhausted() {
+		t.Errorf("Expected child to report exhausted when its parent is")
+	}
+}
+
+func TestBudget_NilIsUnlimited(t *testing.T) {
+	var b *Budget
+	for i := 0; i < 10; i++ {
+		if err := b.Spend(); err != nil {
+			t.Fatalf("Expected nil budget to be unlimited, got %v", err)
+		}
+	}
+	if b.Exhausted() {
+		t.Errorf("Expected nil budget never to be exhausted")
+	}
+}
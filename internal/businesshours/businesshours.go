@@ -0,0 +1,143 @@
+// Package businesshours measures durations counting only working hours, so a PR
+// opened on Friday evening and reviewed Monday morning doesn't look like it waited
+// all weekend.
+package businesshours
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is a working day, Monday to Friday, in a timezone
+type Schedule struct {
+	Start    time.Duration // Start of the working day, as an offset from midnight
+	End      time.Duration // End of the working day, as an offset from midnight
+	Location *time.Location
+	Holidays Holidays // Days off, on top of weekends
+}
+
+// Parse builds a schedule from working hours in HH:MM-HH:MM format and an IANA
+// timezone name such as America/Toronto ("Local" for the system timezone)
+func Parse(hours, timezone string) (*Schedule, error) {
+	startStr, endStr, ok := strings.Cut(hours, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid business hours %q. Please use HH:MM-HH:MM", hours)
+	}
+	start, err := parseClock(startStr)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return nil, err
+	}
+	if end <= start {
+		return nil, fmt.Errorf("invalid business hours %q: the day must end after it starts", hours)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return &Schedule{Start: start, End: end, Location: loc}, nil
+}
+
+// parseClock parses an HH:MM time of day into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q. Please use HH:MM: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Between returns how much of from to to fell within working hours
+func (s *Schedule) Between(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+
+	from, to = from.In(s.Location), to.In(s.Location)
+	var total time.Duration
+	for day := midnight(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday || s.Holidays.Contains(day) {
+			continue
+		}
+		// Build the day's bounds from the clock so DST changes don't skew them
+		opening, closing := atClock(day, s.Start), atClock(day, s.End)
+		if opening.Before(from) {
+			opening = from
+		}
+		if closing.After(to) {
+			closing = to
+		}
+		if closing.After(opening) {
+			total += closing.Sub(opening)
+		}
+	}
+	return total
+}
+
+// String describes the schedule, e.g. "09:00-17:00 America/Toronto, Monday to Friday"
+func (s *Schedule) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	description := fmt.Sprintf("%s-%s %s, Monday to Friday", clock(s.Start), clock(s.End), s.Location)
+	if len(s.Holidays) > 0 {
+		description += fmt.Sprintf(", excluding %d holidays", len(s.Holidays))
+	}
+	return description
+}
+
+// atClock returns the time on day's date when the clock reads offset past
+// midnight. On a day DST starts or ends, that's an hour more or less than offset
+// after midnight.
+func atClock(day time.Time, offset time.Duration) time.Time {
+	hours, minutes := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hours, minutes, 0, 0, day.Location())
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Flags are the command-line flags that select a business-hours schedule
+type Flags struct {
+	Hours    *string
+	Timezone *string
+	Holidays *string
+}
+
+// RegisterFlags defines the business-hours flags shared by the trackers
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		Hours:    fs.String("business-hours", "", "Measure review times in working hours only, e.g. 09:00-17:00 (Monday to Friday; empty = wall-clock time)"),
+		Timezone: fs.String("timezone", "Local", "IANA timezone the -business-hours are in, e.g. America/Toronto"),
+		Holidays: fs.String("holidays", "", "Holidays to leave out of -business-hours: a file of YYYY-MM-DD dates, or an iCal file or http(s) URL"),
+	}
+}
+
+// Schedule returns the selected schedule, or nil if durations should be wall-clock time
+func (f *Flags) Schedule() (*Schedule, error) {
+	if *f.Hours == "" {
+		if *f.Holidays != "" {
+			return nil, errors.New("-holidays needs -business-hours")
+		}
+		return nil, nil
+	}
+	schedule, err := Parse(*f.Hours, *f.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if *f.Holidays != "" {
+		schedule.Holidays, err = LoadHolidays(*f.Holidays)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return schedule, nil
+}
@@ -0,0 +1,90 @@
+package businesshours
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse("09:00-17:30", "America/Toronto")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s.Start != 9*time.Hour || s.End != 17*time.Hour+30*time.Minute {
+		t.Errorf("Expected 9:00 to 17:30, got %v to %v", s.Start, s.End)
+	}
+	if s.String() != "09:00-17:30 America/Toronto, Monday to Friday" {
+		t.Errorf("Unexpected description %q", s.String())
+	}
+
+	for _, hours := range []string{"9-5", "17:00-09:00", "09:00"} {
+		if _, err := Parse(hours, "UTC"); err == nil {
+			t.Errorf("Expected an error parsing %q", hours)
+		}
+	}
+	if _, err := Parse("09:00-17:00", "Not/AZone"); err == nil {
+		t.Error("Expected an error for an unknown timezone")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	s, err := Parse("09:00-17:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(value string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     time.Duration
+	}{
+		{"within one day", "2024-03-06 10:00", "2024-03-06 12:30", 150 * time.Minute},
+		{"overnight", "2024-03-06 16:00", "2024-03-07 10:00", 2 * time.Hour},
+		// 2024-03-08 is a Friday
+		{"over a weekend", "2024-03-08 18:00", "2024-03-11 10:00", 1 * time.Hour},
+		{"entirely outside hours", "2024-03-09 10:00", "2024-03-10 12:00", 0},
+		{"backwards", "2024-03-06 12:00", "2024-03-06 10:00", 0},
+	}
+	for _, tt := range tests {
+		if got := s.Between(at(tt.from), at(tt.to)); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestBetweenAcrossDST(t *testing.T) {
+	s, err := Parse("09:00-17:00", "America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clocks went forward on Sunday 2024-03-10; Friday and Monday are still full 8-hour days
+	from := time.Date(2024, 3, 8, 9, 0, 0, 0, s.Location)
+	to := time.Date(2024, 3, 11, 17, 0, 0, 0, s.Location)
+	if got := s.Between(from, to); got != 16*time.Hour {
+		t.Errorf("Expected 16h, got %v", got)
+	}
+}
+
+func TestBetweenOnDSTTransitionDay(t *testing.T) {
+	s, err := Parse("09:00-17:00", "Africa/Cairo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clocks went forward from midnight to 01:00 on Friday 2023-04-28, so the day
+	// still opens at 09:00 by the clock, eight hours after it started
+	from := time.Date(2023, 4, 28, 9, 0, 0, 0, s.Location)
+	to := time.Date(2023, 4, 28, 10, 0, 0, 0, s.Location)
+	if got := s.Between(from, to); got != time.Hour {
+		t.Errorf("Expected 1h, got %v", got)
+	}
+	if got := s.Between(from.Add(-12*time.Hour), from.Add(12*time.Hour)); got != 8*time.Hour {
+		t.Errorf("Expected a full 8h day, got %v", got)
+	}
+}
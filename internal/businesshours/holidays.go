@@ -0,0 +1,204 @@
+package businesshours
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Holidays is a set of days off, keyed by YYYY-MM-DD
+type Holidays map[string]bool
+
+// Contains reports whether the calendar day t falls on is a holiday
+func (h Holidays) Contains(t time.Time) bool {
+	return h[t.Format("2006-01-02")]
+}
+
+// LoadHolidays reads holidays from a file or an http(s) URL. The source is either
+// an iCal calendar or a plain list of YYYY-MM-DD dates, one per line, with # comments.
+func LoadHolidays(source string) (Holidays, error) {
+	var content []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch holidays: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch holidays: %s returned %s", source, resp.Status)
+		}
+		content, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read holidays: %w", err)
+		}
+	} else {
+		var err error
+		content, err = os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read holidays: %w", err)
+		}
+	}
+
+	if strings.Contains(string(content), "BEGIN:VCALENDAR") {
+		return parseICal(string(content), time.Now().Year()+1)
+	}
+	return parseDateList(string(content))
+}
+
+// parseDateList parses one YYYY-MM-DD date per line
+func parseDateList(content string) (Holidays, error) {
+	holidays := make(Holidays)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday on line %d. Please use YYYY-MM-DD: %w", line, err)
+		}
+		holidays[day.Format("2006-01-02")] = true
+	}
+	return holidays, scanner.Err()
+}
+
+// parseICal reads the days each VEVENT in an iCal calendar covers. All-day events
+// end the day before DTEND, as the spec says; timed events cover every day they
+// touch. Plain yearly recurring events (the usual way to enter fixed-date holidays)
+// are expanded through lastYear, stopping at their UNTIL or COUNT; any other
+// recurrence rule, including yearly ones with BY* parts, only counts its first
+// occurrence.
+func parseICal(content string, lastYear int) (Holidays, error) {
+	// Long lines are folded onto continuation lines starting with whitespace
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\n ", "")
+	content = strings.ReplaceAll(content, "\n\t", "")
+
+	holidays := make(Holidays)
+	var inEvent bool
+	var start, end time.Time
+	var allDay bool
+	var rrule string
+	for _, line := range strings.Split(content, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		property, _, _ := strings.Cut(name, ";")
+
+		switch {
+		case property == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start, end, allDay, rrule = time.Time{}, time.Time{}, false, ""
+		case property == "END" && value == "VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("invalid iCal event with no DTSTART")
+			}
+			addEvent(holidays, start, end, allDay, rrule, lastYear)
+		case inEvent && property == "DTSTART":
+			var err error
+			start, allDay, err = parseICalDate(value)
+			if err != nil {
+				return nil, err
+			}
+		case inEvent && property == "DTEND":
+			var err error
+			end, _, err = parseICalDate(value)
+			if err != nil {
+				return nil, err
+			}
+		case inEvent && property == "RRULE":
+			rrule = value
+		}
+	}
+	return holidays, nil
+}
+
+// parseICalDate parses an iCal DATE (20241225) or DATE-TIME (20241225T090000Z) value
+// down to its day, reporting whether it was a DATE. The day is taken as written: a
+// TZID parameter or Z suffix is ignored, so a DATE-TIME near midnight in another
+// time zone can land on a neighbouring day.
+func parseICalDate(value string) (time.Time, bool, error) {
+	if len(value) < 8 {
+		return time.Time{}, false, fmt.Errorf("invalid iCal date %q", value)
+	}
+	day, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid iCal date %q: %w", value, err)
+	}
+	return day, len(value) == 8, nil
+}
+
+// addEvent marks the days an event covers, repeating plain yearly events through lastYear
+func addEvent(holidays Holidays, start, end time.Time, allDay bool, rrule string, lastYear int) {
+	// Work out how many days the event covers
+	days := 1
+	if !end.IsZero() {
+		days = int(end.Sub(start).Hours()/24 + 0.5)
+		if !allDay {
+			days++ // A timed event covers the day it ends on too
+		}
+		days = max(days, 1)
+	}
+
+	// Only plain yearly rules are expanded. Rules picking days with BY* parts, like
+	// Thanksgiving's BYMONTH=11;BYDAY=4TH, don't land on the same date each year
+	years, interval := 1, 1
+	var until time.Time
+	if rule := parseRRule(rrule); rule["FREQ"] == "YEARLY" && !hasByParts(rule) {
+		years = lastYear - start.Year() + 1
+		if n, err := strconv.Atoi(rule["INTERVAL"]); err == nil && n > 0 {
+			interval = n
+		}
+		if n, err := strconv.Atoi(rule["COUNT"]); err == nil {
+			years = min(years, n*interval)
+		}
+		if value, ok := rule["UNTIL"]; ok {
+			until, _, _ = parseICalDate(value)
+		}
+	}
+
+	for year := 0; year < years; year += interval {
+		first := start.AddDate(year, 0, 0)
+		if !until.IsZero() && first.After(until) {
+			break
+		}
+		for d := 0; d < days; d++ {
+			holidays[first.AddDate(0, 0, d).Format("2006-01-02")] = true
+		}
+	}
+}
+
+// parseRRule splits an RRULE value like FREQ=YEARLY;COUNT=5 into its parts
+func parseRRule(rrule string) map[string]string {
+	rule := make(map[string]string)
+	for _, part := range strings.Split(rrule, ";") {
+		if name, value, ok := strings.Cut(part, "="); ok {
+			rule[name] = value
+		}
+	}
+	return rule
+}
+
+// hasByParts reports whether a rule narrows its occurrences with BYDAY, BYMONTH
+// and the like
+func hasByParts(rule map[string]string) bool {
+	for name := range rule {
+		if strings.HasPrefix(name, "BY") {
+			return true
+		}
+	}
+	return false
+}
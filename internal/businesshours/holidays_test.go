@@ -0,0 +1,117 @@
+package businesshours
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadHolidaysDateList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.txt")
+	content := "# Company holidays\n2024-12-25\n2024-12-26 # Boxing Day\n\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	holidays, err := LoadHolidays(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(holidays) != 2 || !holidays["2024-12-25"] || !holidays["2024-12-26"] {
+		t.Errorf("Expected Christmas and Boxing Day, got %v", holidays)
+	}
+
+	if err := os.WriteFile(path, []byte("Dec 25\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHolidays(path); err == nil {
+		t.Error("Expected an error for an invalid date")
+	}
+}
+
+func TestParseICal(t *testing.T) {
+	content := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Winter shutdown\r\n" +
+		"DTSTART;VALUE=DATE:20241223\r\n" +
+		"DTEND;VALUE=DATE:20241228\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Canada Day\r\n" +
+		"DTSTART;VALUE=DATE:20230701\r\n" +
+		"RRULE:FREQ=YEARLY\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Offsite\r\n" +
+		"DTSTART:20240312T130000Z\r\n" +
+		"DTEND:20240313T170000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	holidays, err := parseICal(content, 2025)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"2024-12-23", "2024-12-24", "2024-12-25", "2024-12-26", "2024-12-27",
+		"2023-07-01", "2024-07-01", "2025-07-01",
+		"2024-03-12", "2024-03-13",
+	}
+	for _, day := range expected {
+		if !holidays[day] {
+			t.Errorf("Expected %s to be a holiday", day)
+		}
+	}
+	if len(holidays) != len(expected) {
+		t.Errorf("Expected %d holidays, got %d: %v", len(expected), len(holidays), holidays)
+	}
+}
+
+func TestParseICalRecurrence(t *testing.T) {
+	content := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Thanksgiving\r\n" +
+		"DTSTART;VALUE=DATE:20231123\r\n" +
+		"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Founders' Day\r\n" +
+		"DTSTART;VALUE=DATE:20220915\r\n" +
+		"RRULE:FREQ=YEARLY;UNTIL=20231231\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	holidays, err := parseICal(content, 2025)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The BYDAY rule only counts its first occurrence, rather than November 23rd
+	// every year, and the UNTIL rule stops after 2023
+	expected := []string{"2023-11-23", "2022-09-15", "2023-09-15"}
+	for _, day := range expected {
+		if !holidays[day] {
+			t.Errorf("Expected %s to be a holiday", day)
+		}
+	}
+	if len(holidays) != len(expected) {
+		t.Errorf("Expected %d holidays, got %d: %v", len(expected), len(holidays), holidays)
+	}
+}
+
+func TestBetweenSkipsHolidays(t *testing.T) {
+	s, err := Parse("09:00-17:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Holidays = Holidays{"2024-03-07": true}
+
+	// Wednesday 16:00 to Friday 10:00, with Thursday off
+	from := time.Date(2024, 3, 6, 16, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+	if got := s.Between(from, to); got != 2*time.Hour {
+		t.Errorf("Expected 2h, got %v", got)
+	}
+}
@@ -17,6 +17,10 @@ type Cache interface {
 	// Get retrieves a value from the cache
 	Get(key string, value interface{}) error
 
+	// GetStale retrieves a value from the cache even if it has expired,
+	// reporting whether the returned value is stale
+	GetStale(key string, value interface{}) (stale bool, err error)
+
 	// Set stores a value in the cache with an optional TTL
 	Set(key string, value interface{}, ttl time.Duration) error
 
@@ -59,12 +63,67 @@ func (b *CacheKeyBuilder) PRReviewsKey(owner, repo string, prNumber int) string
 	return b.buildKey("pr_reviews", owner, repo, prNumber)
 }
 
+func (b *CacheKeyBuilder) PRCommitsKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_commits", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) PRFilesKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_files", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) PRCommentsKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_comments", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) PRIssueCommentsKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_issue_comments", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) PRTimelineKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_timeline", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) PRReviewRequestsKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_review_requests", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) PRDismissalsKey(owner, repo string, prNumber int) string {
+	return b.buildKey("pr_dismissals", owner, repo, prNumber)
+}
+
+func (b *CacheKeyBuilder) CodeownersKey(owner, repo, ref string) string {
+	return b.buildKey("codeowners", owner, repo, ref)
+}
+
+func (b *CacheKeyBuilder) TeamMembersKey(org, team string) string {
+	return b.buildKey("team_members", org, team)
+}
+
+func (b *CacheKeyBuilder) RequiredApprovalsKey(owner, repo, branch string) string {
+	return b.buildKey("required_approvals", owner, repo, branch)
+}
+
 func (b *CacheKeyBuilder) PRsListKey(owner, repo string, startDate, endDate time.Time) string {
 	start := startDate.Format("2006-01-02")
 	end := endDate.Format("2006-01-02")
 	return b.buildKey("prs_list", owner, repo, start, end)
 }
 
+// PRsListPrefix is the prefix shared by PRsListKey's keys for a repository, whatever the dates
+func (b *CacheKeyBuilder) PRsListPrefix(owner, repo string) string {
+	return b.buildKey("prs_list", owner, repo) + ":"
+}
+
+func (b *CacheKeyBuilder) DiscussionsListKey(owner, repo string, startDate, endDate time.Time) string {
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+	return b.buildKey("discussions_list", owner, repo, start, end)
+}
+
+func (b *CacheKeyBuilder) ProjectItemsKey(owner string, number int) string {
+	return b.buildKey("project_items", owner, number)
+}
+
 func (b *CacheKeyBuilder) CommitsListKey(owner, repo string, startDate, endDate time.Time) string {
 	start := startDate.Format("2006-01-02")
 	end := endDate.Format("2006-01-02")
@@ -83,16 +142,42 @@ func (b *CacheKeyBuilder) RolloutsKey(projectID, region, releaseName string) str
 	return b.buildKey("rollouts", projectID, region, releaseName)
 }
 
+func (b *CacheKeyBuilder) RollbackKey(projectID, region, releaseName string) string {
+	return b.buildKey("rollback", projectID, region, releaseName)
+}
+
+func (b *CacheKeyBuilder) RolloutPhasesKey(projectID, region, releaseName string) string {
+	return b.buildKey("rollout_phases", projectID, region, releaseName)
+}
+
 func (b *CacheKeyBuilder) ReleasesListKey(projectID, region, pipeline string, startDate, endDate time.Time) string {
 	start := startDate.Format("2006-01-02")
 	end := endDate.Format("2006-01-02")
 	return b.buildKey("releases_list", projectID, region, pipeline, start, end)
 }
 
+func (b *CacheKeyBuilder) CompletedIssuesKey(team string, startDate, endDate time.Time) string {
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+	return b.buildKey("completed_issues", team, start, end)
+}
+
+func (b *CacheKeyBuilder) CyclesKey(team string, startDate, endDate time.Time) string {
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+	return b.buildKey("cycles", team, start, end)
+}
+
 func (b *CacheKeyBuilder) FlakyTestsKey(org, repo string) string {
 	return b.buildKey("flaky-tests", org, repo)
 }
 
+// PageKey is the key for what's kept of the response to a GET request, for
+// conditional requests: its validators and the data it held, converted
+func (b *CacheKeyBuilder) PageKey(url string) string {
+	return b.buildKey("page", url)
+}
+
 func (b *CacheKeyBuilder) buildKey(parts ...interface{}) string {
 	key := b.prefix
 	for _, part := range parts {
@@ -10,7 +10,9 @@ import (
 	"time"
 )
 
-// FileCache implements Cache interface using the filesystem
+// FileCache implements Cache interface using the filesystem. Writes are atomic
+// per key, so it is safe to share a cache directory between goroutines and
+// between processes (e.g. parallel CI jobs).
 type FileCache struct {
 	baseDir string
 }
@@ -41,33 +43,60 @@ func NewFileCacheWithDir(dir string) (*FileCache, error) {
 
 // Get retrieves a value from the cache
 func (c *FileCache) Get(key string, value interface{}) error {
+	entry, err := c.readEntry(key)
+	if err != nil {
+		return err
+	}
+
+	// Check if expired. The file is left in place rather than deleted: another
+	// process sharing the cache directory may already have replaced it with a
+	// fresh entry, and the caller's next Set will overwrite it anyway.
+	if entry.IsExpired() {
+		return ErrCacheMiss
+	}
+
+	return decodeEntry(entry, value)
+}
+
+// GetStale retrieves a value from the cache even if it has expired
+func (c *FileCache) GetStale(key string, value interface{}) (bool, error) {
+	entry, err := c.readEntry(key)
+	if err != nil {
+		return false, err
+	}
+
+	if err := decodeEntry(entry, value); err != nil {
+		return false, err
+	}
+
+	return entry.IsExpired(), nil
+}
+
+// readEntry loads the raw entry for a key without checking expiry
+func (c *FileCache) readEntry(key string) (*Entry, error) {
 	filename := c.keyToFilename(key)
 
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return ErrCacheMiss
+			return nil, ErrCacheMiss
 		}
-		return fmt.Errorf("failed to read cache file: %w", err)
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
 	}
 
 	var entry Entry
 	if err := json.Unmarshal(data, &entry); err != nil {
-		return fmt.Errorf("failed to unmarshal cache entry: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal cache entry: %w", err)
 	}
 
-	// Check if expired
-	if entry.IsExpired() {
-		// Clean up expired entry
-		_ = c.Delete(key)
-		return ErrCacheMiss
-	}
+	return &entry, nil
+}
 
-	// Unmarshal the actual data
+// decodeEntry unmarshals the actual data of an entry into value
+func decodeEntry(entry *Entry, value interface{}) error {
 	if err := json.Unmarshal(entry.Data, value); err != nil {
 		return fmt.Errorf("failed to unmarshal cached data: %w", err)
 	}
-
 	return nil
 }
 
@@ -105,13 +134,46 @@ func (c *FileCache) Set(key string, value interface{}, ttl time.Duration) error
 		return fmt.Errorf("failed to create cache subdirectory: %w", err)
 	}
 
-	if err := os.WriteFile(filename, entryData, 0644); err != nil {
+	if err := writeFileAtomic(filename, entryData); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it into place, so concurrent readers (including other processes
+// sharing the cache directory) see either the old entry or the new one, never
+// a partially written file. Concurrent writers to the same key are last-write-wins.
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".tmp-"+filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
 // Delete removes a value from the cache
 func (c *FileCache) Delete(key string) error {
 	filename := c.keyToFilename(key)
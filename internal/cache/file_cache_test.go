@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileCache_SetGet(t *testing.T) {
+	c, err := NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	if err := c.Set("key", []string{"a", "b"}, time.Hour); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	if err := c.Get("key", &got); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected [a b], got %v", got)
+	}
+
+	if err := c.Get("missing", &got); err != ErrCacheMiss {
+		t.Errorf("Expected ErrCacheMiss for missing key, got %v", err)
+	}
+}
+
+func TestFileCache_ExpiredEntryIsMiss(t *testing.T) {
+	c, err := NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	if err := c.Set("key", "value", time.Nanosecond); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	var got string
+	if err := c.Get("key", &got); err != ErrCacheMiss {
+		t.Errorf("Expected ErrCacheMiss for expired entry, got %v", err)
+	}
+}
+
+func TestFileCache_ConcurrentWritersSharingDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	// Separate instances over one directory stand in for separate processes
+	var caches []*FileCache
+	for i := 0; i < 4; i++ {
+		c, err := NewFileCacheWithDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		caches = append(caches, c)
+	}
+
+	// Large values make torn writes likely if writes aren't atomic
+	values := make([]string, len(caches))
+	for i := range values {
+		values[i] = strings.Repeat(fmt.Sprintf("%d", i), 256*1024)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 1000)
+	for i, c := range caches {
+		wg.Add(1)
+		go func(i int, c *FileCache) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := c.Set("shared", values[i], time.Hour); err != nil {
+					errs <- err
+					return
+				}
+				var got string
+				if err := c.Get("shared", &got); err != nil {
+					errs <- fmt.Errorf("read of shared key failed: %w", err)
+					return
+				}
+				if got != values[0] && got != values[1] && got != values[2] && got != values[3] {
+					errs <- fmt.Errorf("read a corrupted value of length %d", len(got))
+					return
+				}
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// No temporary files should be left behind
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", ".tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("Expected no leftover temp files, found %d", len(matches))
+	}
+	if _, err := os.Stat(caches[0].keyToFilename("shared")); err != nil {
+		t.Errorf("Expected cache file to exist: %v", err)
+	}
+}
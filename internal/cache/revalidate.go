@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"log"
+	"sync"
+)
+
+// Revalidator implements stale-while-revalidate lookups: expired entries are
+// returned immediately while a background refresh replaces them. A nil
+// *Revalidator disables the behaviour and falls back to plain Get.
+type Revalidator struct {
+	mu       sync.Mutex
+	inflight map[string]bool
+	wg       sync.WaitGroup
+}
+
+// NewRevalidator creates a Revalidator with no refreshes in flight
+func NewRevalidator() *Revalidator {
+	return &Revalidator{inflight: make(map[string]bool)}
+}
+
+// Get looks key up in c. A fresh entry behaves exactly like Cache.Get. A stale
+// entry is decoded into value and returned without error, and refresh is run in
+// the background (at most once per key at a time) to repopulate the cache.
+func (r *Revalidator) Get(c Cache, key string, value interface{}, refresh func() error) error {
+	if r == nil {
+		return c.Get(key, value)
+	}
+
+	stale, err := c.GetStale(key, value)
+	if err != nil {
+		return err
+	}
+	if stale {
+		r.revalidate(key, refresh)
+	}
+	return nil
+}
+
+// revalidate starts refresh in the background unless one is already running for key
+func (r *Revalidator) revalidate(key string, refresh func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inflight[key] {
+		return
+	}
+	r.inflight[key] = true
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := refresh(); err != nil {
+			log.Printf("Background refresh of %s failed: %v", key, err)
+		}
+		r.mu.Lock()
+		delete(r.inflight, key)
+		r.mu.Unlock()
+	}()
+}
+
+// Wait blocks until all background refreshes have finished
+func (r *Revalidator) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
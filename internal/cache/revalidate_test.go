@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRevalidator_ServesStaleAndRefreshes(t *testing.T) {
+	c, err := NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if err := c.Set("key", "old", time.Nanosecond); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	r := NewRevalidator()
+	var refreshes int32
+	refresh := func() error {
+		atomic.AddInt32(&refreshes, 1)
+		return c.Set("key", "new", time.Hour)
+	}
+
+	var got string
+	if err := r.Get(c, "key", &got, refresh); err != nil {
+		t.Fatalf("Expected stale hit, got %v", err)
+	}
+	if got != "old" {
+		t.Errorf("Expected stale value 'old', got '%s'", got)
+	}
+
+	r.Wait()
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Errorf("Expected 1 refresh, got %d", n)
+	}
+
+	if err := r.Get(c, "key", &got, refresh); err != nil {
+		t.Fatalf("Expected fresh hit, got %v", err)
+	}
+	if got != "new" {
+		t.Errorf("Expected refreshed value 'new', got '%s'", got)
+	}
+	r.Wait()
+	if n := atomic.LoadInt32(&refreshes); n != 1 {
+		t.Errorf("Expected no refresh for a fresh entry, got %d refreshes", n)
+	}
+}
+
+func TestRevalidator_NilFallsBackToGet(t *testing.T) {
+	c, err := NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if err := c.Set("key", "old", time.Nanosecond); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	var r *Revalidator
+	var got string
+	err = r.Get(c, "key", &got, func() error {
+		t.Error("Refresh should not be called without stale-while-revalidate")
+		return nil
+	})
+	if err != ErrCacheMiss {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	r.Wait()
+}
@@ -0,0 +1,190 @@
+// Package cfd builds cumulative flow diagrams from a WIP history: for each day,
+// how many PRs were in draft, open for review, approved and merged. Drawn as
+// stacked bands, a widening band is a stage where work is piling up, and the
+// slope of the merged band is throughput.
+package cfd
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+// Day is the number of PRs in each stage on one day, summed across repositories
+type Day struct {
+	Date     string // YYYY-MM-DD
+	Draft    int
+	Open     int // Ready for review, but not approved
+	Approved int
+	Merged   int // Merged since the first day charted
+}
+
+// Total is the height of the day's stack of bands
+func (d Day) Total() int {
+	return d.Draft + d.Open + d.Approved + d.Merged
+}
+
+// Days sums a WIP history's snapshots for each day, oldest first. Snapshots from
+// before drafts were recorded count every PR not approved as open. A repository's
+// merged count carries over days it wasn't counted, and only counts from its first
+// count on, so repositories added part way through don't make a jump.
+func Days(snapshots []wip.Snapshot) []Day {
+	firstMerged := make(map[string]int) // By repository
+	lastMerged := make(map[string]int)
+	var days []Day
+	for _, snapshot := range snapshots {
+		if len(days) == 0 || days[len(days)-1].Date != snapshot.Date {
+			days = append(days, Day{Date: snapshot.Date})
+		}
+		day := &days[len(days)-1]
+
+		if snapshot.ByStage != nil {
+			day.Draft += snapshot.ByStage[wip.StageDraft]
+			day.Open += snapshot.ByStage[wip.StageOpen]
+			day.Approved += snapshot.ByStage[wip.StageApproved]
+		} else {
+			approved := snapshot.ByState[wip.StateApproved]
+			day.Approved += approved
+			day.Open += snapshot.Open - approved
+		}
+
+		if snapshot.Merged > 0 {
+			if _, ok := firstMerged[snapshot.Repository]; !ok {
+				firstMerged[snapshot.Repository] = snapshot.Merged
+			}
+			lastMerged[snapshot.Repository] = snapshot.Merged
+		}
+		day.Merged = 0
+		for repo, merged := range lastMerged {
+			day.Merged += merged - firstMerged[repo]
+		}
+	}
+	return days
+}
+
+// Chart dimensions, in SVG user units
+const (
+	chartWidth  = 800
+	chartHeight = 400
+	chartMargin = 40
+)
+
+// band is one stage's area in the chart
+type band struct {
+	Name   string
+	Color  string
+	Points string // The SVG polygon's points
+	KeyX   int    // Where its entry in the key goes
+}
+
+// stages are the chart's bands from the bottom up: finished work at the bottom,
+// so the bands above it show what's still in progress
+var stages = []struct {
+	name  string
+	color string
+	count func(Day) int
+}{
+	{"Merged", "#6f42c1", func(d Day) int { return d.Merged }},
+	{"Approved", "#2da44e", func(d Day) int { return d.Approved }},
+	{"Open", "#0969da", func(d Day) int { return d.Open }},
+	{"Draft", "#8c959f", func(d Day) int { return d.Draft }},
+}
+
+var chartTemplate = template.Must(template.New("cfd").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-top: 2em; }
+th, td { border: 1px solid #d0d7de; padding: 0.2em 0.6em; text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Days}}<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" xmlns="http://www.w3.org/2000/svg" font-size="12">
+{{range .Bands}}<polygon points="{{.Points}}" fill="{{.Color}}"><title>{{.Name}}</title></polygon>
+{{end}}<line x1="{{.Left}}" y1="{{.Bottom}}" x2="{{.Right}}" y2="{{.Bottom}}" stroke="black"/>
+<line x1="{{.Left}}" y1="{{.Top}}" x2="{{.Left}}" y2="{{.Bottom}}" stroke="black"/>
+<text x="{{.Left}}" y="{{.Top}}" dx="-4" dy="4" text-anchor="end">{{.Max}}</text>
+<text x="{{.Left}}" y="{{.Bottom}}" dx="-4" dy="4" text-anchor="end">0</text>
+<text x="{{.Left}}" y="{{.Bottom}}" dy="16" text-anchor="start">{{.First}}</text>
+<text x="{{.Right}}" y="{{.Bottom}}" dy="16" text-anchor="end">{{.Last}}</text>
+{{range .Bands}}<rect x="{{.KeyX}}" y="8" width="12" height="12" fill="{{.Color}}"/><text x="{{.KeyX}}" y="18" dx="16">{{.Name}}</text>
+{{end}}</svg>
+<table>
+<tr><th>Date</th><th>Draft</th><th>Open</th><th>Approved</th><th>Merged</th></tr>
+{{range .Days}}<tr><td>{{.Date}}</td><td>{{.Draft}}</td><td>{{.Open}}</td><td>{{.Approved}}</td><td>{{.Merged}}</td></tr>
+{{end}}</table>
+{{else}}<p>No WIP snapshots recorded.</p>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes days as a self-contained HTML page, with the diagram drawn as
+// an inline SVG chart and the numbers in a table below it
+func WriteHTML(w io.Writer, title string, days []Day) error {
+	data := struct {
+		Title                    string
+		Days                     []Day
+		Bands                    []band
+		Width, Height            int
+		Left, Right, Top, Bottom int
+		Max                      int
+		First, Last              string
+	}{
+		Title:  title,
+		Days:   days,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Left:   chartMargin,
+		Right:  chartWidth - chartMargin,
+		Top:    chartMargin,
+		Bottom: chartHeight - chartMargin,
+	}
+	if len(days) > 0 {
+		data.First, data.Last = days[0].Date, days[len(days)-1].Date
+	}
+	for _, day := range days {
+		data.Max = max(data.Max, day.Total())
+	}
+
+	x := func(i int) float64 {
+		if len(days) < 2 {
+			return float64(data.Left)
+		}
+		return float64(data.Left) + float64(i)*float64(data.Right-data.Left)/float64(len(days)-1)
+	}
+	y := func(n int) float64 {
+		if data.Max == 0 {
+			return float64(data.Bottom)
+		}
+		return float64(data.Bottom) - float64(n)*float64(data.Bottom-data.Top)/float64(data.Max)
+	}
+
+	// Each band runs along its top edge left to right, then back along the
+	// top of the band below it
+	below := make([]int, len(days))
+	for s, stage := range stages {
+		above := make([]int, len(days))
+		var points []string
+		for i, day := range days {
+			above[i] = below[i] + stage.count(day)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(above[i])))
+		}
+		for i := len(days) - 1; i >= 0; i-- {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(below[i])))
+		}
+		data.Bands = append(data.Bands, band{Name: stage.name, Color: stage.color, Points: strings.Join(points, " "), KeyX: chartMargin + s*100})
+		below = above
+	}
+
+	if err := chartTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write cumulative flow diagram: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cfd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+func TestDays(t *testing.T) {
+	snapshots := []wip.Snapshot{
+		// From before stages and merges were recorded
+		{Date: "2024-06-10", Repository: "o/a", Open: 5, ByState: map[string]int{wip.StateApproved: 2}},
+		{Date: "2024-06-11", Repository: "o/a", Open: 4, ByStage: map[string]int{wip.StageDraft: 1, wip.StageOpen: 2, wip.StageApproved: 1}, Merged: 100},
+		{Date: "2024-06-11", Repository: "o/b", Open: 1, ByStage: map[string]int{wip.StageOpen: 1}, Merged: 7},
+		// o/b wasn't snapshotted on the 12th, so its merged count carries over
+		{Date: "2024-06-12", Repository: "o/a", Open: 3, ByStage: map[string]int{wip.StageOpen: 3}, Merged: 103},
+		{Date: "2024-06-13", Repository: "o/a", Open: 3, ByStage: map[string]int{wip.StageOpen: 3}, Merged: 104},
+		{Date: "2024-06-13", Repository: "o/b", Open: 0, ByStage: map[string]int{}, Merged: 9},
+	}
+
+	want := []Day{
+		{Date: "2024-06-10", Open: 3, Approved: 2},
+		{Date: "2024-06-11", Draft: 1, Open: 3, Approved: 1},
+		{Date: "2024-06-12", Open: 3, Merged: 3},
+		{Date: "2024-06-13", Open: 3, Merged: 6},
+	}
+	got := Days(snapshots)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d days, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Day %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+// reviewlessClient answers the only call ProcessPullRequests makes for PRs
+// nobody has reviewed
+type reviewlessClient struct {
+	github.GitHubClientInterface
+}
+
+func (reviewlessClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*github.Review, error) {
+	return nil, nil
+}
+
+// TestDays_Drafts follows open PRs from processing, the way -wip does, through
+// to the diagram, so the draft band can't be left empty by a filter on the way
+func TestDays_Drafts(t *testing.T) {
+	now := time.Now()
+	prs := []*github.PullRequest{
+		{Number: 1, User: github.User{Login: "alice"}, State: "open", CreatedAt: now.Add(-2 * time.Hour)},
+		{Number: 2, User: github.User{Login: "bob"}, State: "open", Draft: true, CreatedAt: now.Add(-time.Hour)},
+	}
+	opts := github.ProcessOptions{RequiredApprovals: 1, IncludeDrafts: true}
+	results := github.ProcessPullRequests(context.Background(), reviewlessClient{}, prs, "o", "r", opts)
+	snapshots := wip.Summarize(now, []string{"o/r"}, wip.Items(results, nil, now, nil), nil)
+
+	days := Days(snapshots)
+	if len(days) != 1 || days[0].Draft != 1 || days[0].Open != 1 {
+		t.Errorf("Expected 1 draft and 1 open PR, got %+v", days)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	days := []Day{
+		{Date: "2024-06-10", Draft: 1, Open: 2, Approved: 1},
+		{Date: "2024-06-11", Open: 2, Approved: 1, Merged: 4},
+	}
+	var b strings.Builder
+	if err := WriteHTML(&b, "Cumulative flow for o/<a>", days); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	if strings.Count(html, "<polygon") != 4 {
+		t.Errorf("Expected a band per stage, got:\n%s", html)
+	}
+	if !strings.Contains(html, "o/&lt;a&gt;") {
+		t.Errorf("Expected the title to be escaped, got:\n%s", html)
+	}
+	// The tallest day is 7 PRs high, so the merged band's top edge ends 4/7 of the way up
+	if !strings.Contains(html, `<polygon points="40.0,360.0 760.0,177.1 760.0,360.0 40.0,360.0"`) {
+		t.Errorf("Expected the merged band at the bottom, got:\n%s", html)
+	}
+
+	b.Reset()
+	if err := WriteHTML(&b, "Empty", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "No WIP snapshots recorded") {
+		t.Errorf("Expected an empty diagram to say so, got:\n%s", b.String())
+	}
+}
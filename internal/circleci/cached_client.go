@@ -4,7 +4,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/reillywatson/statstracker/internal/budget"
 	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/retry"
 )
 
 // CachedCircleCIClient wraps CircleCIClient with caching capabilities
@@ -12,6 +14,7 @@ type CachedCircleCIClient struct {
 	client *CircleCIClient
 	cache  cache.Cache
 	kb     *cache.CacheKeyBuilder
+	swr    *cache.Revalidator // nil unless stale-while-revalidate is enabled
 }
 
 // NewCachedCircleCIClient creates a new CircleCI client with caching
@@ -23,6 +26,27 @@ func NewCachedCircleCIClient(token string, cacheImpl cache.Cache) *CachedCircleC
 	}
 }
 
+// SetStaleWhileRevalidate toggles stale-while-revalidate mode, in which expired
+// cache entries are returned immediately and refreshed in the background.
+// Close waits for any outstanding refreshes.
+func (c *CachedCircleCIClient) SetStaleWhileRevalidate(enabled bool) {
+	if enabled {
+		c.swr = cache.NewRevalidator()
+	} else {
+		c.swr = nil
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Cache hits are free.
+func (c *CachedCircleCIClient) SetBudget(b *budget.Budget) {
+	c.client.SetBudget(b)
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are retried
+func (c *CachedCircleCIClient) SetRetryPolicy(p retry.Policy) {
+	c.client.SetRetryPolicy(p)
+}
+
 // FetchFlakyTests fetches flaky tests with caching
 func (c *CachedCircleCIClient) FetchFlakyTests(ctx context.Context, org, repo string) ([]FlakyTest, error) {
 	// Create cache key using the key builder
@@ -30,16 +54,28 @@ func (c *CachedCircleCIClient) FetchFlakyTests(ctx context.Context, org, repo st
 
 	// Try to get from cache
 	var cachedTests []FlakyTest
-	if err := c.cache.Get(key, &cachedTests); err == nil {
+	refresh := func() error {
+		// The refresh outlives this call, so it can't use the caller's context
+		_, err := c.fetchFlakyTests(context.Background(), org, repo)
+		return err
+	}
+	if err := c.swr.Get(c.cache, key, &cachedTests, refresh); err == nil {
 		return cachedTests, nil
 	} else if err != cache.ErrCacheMiss {
 		// Log non-miss errors but continue
 	}
 
 	// Not in cache or cache miss, fetch from API
+	return c.fetchFlakyTests(ctx, org, repo)
+}
+
+// fetchFlakyTests fetches flaky tests from the API and stores them in the cache
+func (c *CachedCircleCIClient) fetchFlakyTests(ctx context.Context, org, repo string) ([]FlakyTest, error) {
+	key := c.kb.FlakyTestsKey(org, repo)
 	tests, err := c.client.FetchFlakyTests(ctx, org, repo)
 	if err != nil {
-		return nil, err
+		// Pass along any partial results, but never cache them
+		return tests, err
 	}
 
 	// Store in cache with 1 hour TTL (flaky tests can change frequently)
@@ -57,5 +93,6 @@ func (c *CachedCircleCIClient) VerifyProjectAccess(ctx context.Context, org, rep
 
 // Close cleans up the client connections
 func (c *CachedCircleCIClient) Close() error {
+	c.swr.Wait()
 	return c.client.Close()
 }
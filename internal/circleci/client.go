@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
 )
 
 const (
@@ -18,20 +21,38 @@ type CircleCIClient struct {
 	httpClient *http.Client
 	token      string
 	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
 }
 
 // NewCircleCIClient creates a new CircleCI client
 func NewCircleCIClient(token string) *CircleCIClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
 	return &CircleCIClient{
 		httpClient: &http.Client{
-			Timeout: defaultTimeout,
+			Timeout:   defaultTimeout,
+			Transport: retrying,
 		},
 		token:   token,
 		baseURL: circleAPIBaseURL,
+		retry:   retrying,
 	}
 }
 
-// FetchFlakyTests fetches flaky tests for a given project
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *CircleCIClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *CircleCIClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// FetchFlakyTests fetches flaky tests for a given project. If the API budget runs
+// out part way through, the tests fetched so far are returned along with the error.
 func (c *CircleCIClient) FetchFlakyTests(ctx context.Context, org, repo string) ([]FlakyTest, error) {
 	projectSlug := fmt.Sprintf("gh/%s/%s", org, repo)
 
@@ -39,6 +60,9 @@ func (c *CircleCIClient) FetchFlakyTests(ctx context.Context, org, repo string)
 	nextPageToken := ""
 
 	for {
+		if err := c.budget.Spend(); err != nil {
+			return allTests, err
+		}
 		tests, token, err := c.fetchFlakyTestsPage(ctx, projectSlug, nextPageToken)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch flaky tests for project %s: %w", projectSlug, err)
@@ -112,6 +136,10 @@ func (c *CircleCIClient) VerifyProjectAccess(ctx context.Context, org, repo stri
 	projectSlug := fmt.Sprintf("gh/%s/%s", org, repo)
 	endpoint := fmt.Sprintf("%s/project/%s", c.baseURL, projectSlug)
 
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -28,5 +28,6 @@ type FlakyTestMetric struct {
 	TestName     string
 	ClassName    string
 	TimesFlaky   int
-	LastOccurred *time.Time // When the test was last flaky
+	LastOccurred *time.Time        // When the test was last flaky
+	Extra        map[string]string // Fields added by a hook program, if any
 }
@@ -0,0 +1,291 @@
+// Package cloudrun reads deployments from Cloud Run's revisions, for services
+// deployed straight to Cloud Run rather than through Cloud Deploy. Each revision
+// that became ready is a release, with the commit it was built from read from
+// its labels, annotations or image tag.
+package cloudrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	cloudRunAPIBaseURL = "https://run.googleapis.com"
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	defaultTimeout     = 30 * time.Second
+	pageSize           = 100
+)
+
+// DefaultCommitKey is the label Cloud Build's Cloud Run deploy step puts the
+// commit SHA in
+const DefaultCommitKey = "commit-sha"
+
+// CommitFunc looks up the message of a commit in the services' repository and
+// when it was made
+type CommitFunc func(ctx context.Context, sha string) (message string, committed time.Time, err error)
+
+// CloudRunClient handles Cloud Run Admin API operations
+type CloudRunClient struct {
+	httpClient *http.Client
+	project    string
+	regions    []string
+	services   []string
+	commitKeys []string // Labels and annotations that can hold the commit SHA, in order
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+	commits    CommitFunc
+
+	// The revisions listed, for the other methods
+	mu        sync.Mutex
+	revisions map[string]revision
+}
+
+var _ deploy.DeployProvider = (*CloudRunClient)(nil)
+
+// NewCloudRunClient creates a new client for the given services in each of a
+// Google Cloud project's regions, authenticated with Application Default
+// Credentials. commits looks up the commits that were deployed.
+func NewCloudRunClient(ctx context.Context, project string, regions, services []string, commits CommitFunc) (*CloudRunClient, error) {
+	authenticated, err := google.DefaultClient(ctx, cloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Google Cloud credentials: %w", err)
+	}
+	retrying := retry.NewTransport(authenticated.Transport, retry.DefaultPolicy)
+	return &CloudRunClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		project:    project,
+		regions:    regions,
+		services:   services,
+		commitKeys: []string{DefaultCommitKey},
+		baseURL:    cloudRunAPIBaseURL,
+		retry:      retrying,
+		commits:    commits,
+		revisions:  make(map[string]revision),
+	}, nil
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *CloudRunClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *CloudRunClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// SetCommitKeys sets the labels and annotations a revision's commit SHA is read
+// from, the first found winning (DefaultCommitKey unless set)
+func (c *CloudRunClient) SetCommitKeys(keys []string) {
+	c.commitKeys = keys
+}
+
+type revision struct {
+	Name        string            `json:"name"` // projects/<project>/locations/<region>/services/<service>/revisions/<revision>
+	CreateTime  time.Time         `json:"createTime"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Containers  []struct {
+		Image string `json:"image"`
+	} `json:"containers"`
+	Conditions []struct {
+		Type               string    `json:"type"`
+		State              string    `json:"state"`
+		LastTransitionTime time.Time `json:"lastTransitionTime"`
+	} `json:"conditions"`
+}
+
+// ready returns when the revision became ready to serve, if it did
+func (r revision) ready() (time.Time, bool) {
+	for _, condition := range r.Conditions {
+		if condition.Type == "Ready" && condition.State == "CONDITION_SUCCEEDED" {
+			return condition.LastTransitionTime, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ListReleases lists the services' revisions created in the date range that
+// became ready, newest first within each service and region. Revisions that
+// never became ready didn't deploy anything, so they're left out. If the API
+// budget runs out part way through, the releases found so far are returned along
+// with the error.
+func (c *CloudRunClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, region := range c.regions {
+		for _, service := range c.services {
+			// Revisions can't be filtered by date, so every page is read
+			var found []revision
+			query := url.Values{"pageSize": {strconv.Itoa(pageSize)}}
+			path := fmt.Sprintf("/v2/projects/%s/locations/%s/services/%s/revisions",
+				url.PathEscape(c.project), url.PathEscape(region), url.PathEscape(service))
+			for {
+				var page struct {
+					Revisions     []revision `json:"revisions"`
+					NextPageToken string     `json:"nextPageToken"`
+				}
+				if err := c.get(ctx, path, query, &page); err != nil {
+					return releases, fmt.Errorf("failed to list revisions of %s in %s: %w", service, region, err)
+				}
+				found = append(found, page.Revisions...)
+				if page.NextPageToken == "" {
+					break
+				}
+				query.Set("pageToken", page.NextPageToken)
+			}
+
+			slices.SortFunc(found, func(a, b revision) int {
+				return b.CreateTime.Compare(a.CreateTime)
+			})
+			for _, r := range found {
+				if _, ok := r.ready(); !ok || r.CreateTime.Before(startDate) || r.CreateTime.After(endDate) {
+					continue
+				}
+				releases = append(releases, c.release(service, r))
+			}
+		}
+	}
+	return releases, nil
+}
+
+// release converts a revision to a deploy.Release, remembering it for
+// ExtractSource and GetRolloutCompletion. Its resource name has the region, and
+// ends in the revision's name, which is used as the release ID.
+func (c *CloudRunClient) release(service string, r revision) *deploy.Release {
+	c.mu.Lock()
+	c.revisions[r.Name] = r
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        r.Name,
+		CreateTime:  r.CreateTime,
+		Annotations: map[string]string{"service": service},
+	}
+}
+
+// shaPattern matches a Git commit SHA, full or abbreviated
+var shaPattern = regexp.MustCompile(`^[a-f0-9]{7,40}$`)
+
+// fullSHAPattern matches a full Git commit SHA, as opposed to a version tag
+var fullSHAPattern = regexp.MustCompile(`^[a-f0-9]{40}$`)
+
+// ExtractSource returns the commit a revision was built from, the PR it merged if
+// its message names one, and when it was made. The commit is read from the
+// first of the commit keys found among the revision's labels, then its
+// annotations, and failing those from a container image tagged with a full SHA.
+func (c *CloudRunClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	r, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	sha := c.revisionCommit(r)
+	if sha == "" {
+		return "", "", time.Time{}, fmt.Errorf("no commit SHA found in the revision's labels, annotations or image (looked for %s)", strings.Join(c.commitKeys, ", "))
+	}
+	message, committed, err := c.commits(ctx, sha)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", sha, err)
+	}
+	return sha, deploy.PRFromCommitMessage(message), committed, nil
+}
+
+// revisionCommit finds the commit SHA a revision was built from, or ""
+func (c *CloudRunClient) revisionCommit(r revision) string {
+	for _, key := range c.commitKeys {
+		for _, values := range []map[string]string{r.Labels, r.Annotations} {
+			if sha := strings.ToLower(values[key]); shaPattern.MatchString(sha) {
+				return sha
+			}
+		}
+	}
+	for _, container := range r.Containers {
+		// An image reference ends in its tag, as in us-docker.pkg.dev/project/repo/shop:<sha>,
+		// unless it's pinned to a digest
+		image, _, _ := strings.Cut(container.Image, "@")
+		if i := strings.LastIndex(image, ":"); i >= 0 && fullSHAPattern.MatchString(image[i+1:]) {
+			return image[i+1:]
+		}
+	}
+	return ""
+}
+
+// GetRolloutCompletion returns when the revision became ready to serve
+func (c *CloudRunClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	r, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	readyAt, _ := r.ready()
+	return readyAt, nil
+}
+
+// listed returns the revision a release was converted from
+func (c *CloudRunClient) listed(release *deploy.Release) (revision, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.revisions[release.Name]
+	if !ok {
+		return revision{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return r, nil
+}
+
+// get makes a Cloud Run Admin API request and decodes the response into result
+func (c *CloudRunClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024 as the Cloud Run Admin API encodes times
+func at(hour int) string {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)
+}
+
+const (
+	shaLabel = "abc1234abc1234abc1234abc1234abc1234abc12"
+	shaImage = "def5678def5678def5678def5678def5678def56"
+)
+
+// newTestClient returns a client for the shop service in us-east4 reading from server
+func newTestClient(server *httptest.Server, commits CommitFunc) *CloudRunClient {
+	return &CloudRunClient{
+		httpClient: http.DefaultClient,
+		project:    "my-project",
+		regions:    []string{"us-east4"},
+		services:   []string{"shop"},
+		commitKeys: []string{DefaultCommitKey},
+		baseURL:    server.URL,
+		commits:    commits,
+		revisions:  make(map[string]revision),
+	}
+}
+
+func TestCloudRunClient(t *testing.T) {
+	const prefix = "projects/my-project/locations/us-east4/services/shop/revisions/"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/projects/my-project/locations/us-east4/services/shop/revisions" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": 404, "message": "Resource 'missing' of kind 'SERVICE' was not found.", "status": "NOT_FOUND"}}`))
+			return
+		}
+		ready := func(hour int) string {
+			return fmt.Sprintf(`"conditions": [{"type": "Ready", "state": "CONDITION_SUCCEEDED", "lastTransitionTime": %q}]`, at(hour))
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			// One labelled by Cloud Build, and one whose image is tagged with its commit
+			fmt.Fprintf(w, `{"revisions": [
+				{"name": "%sshop-00002-abc", "createTime": %q, "labels": {"commit-sha": %q}, %s},
+				{"name": "%sshop-00003-def", "createTime": %q, "containers": [{"image": "us-docker.pkg.dev/my-project/shop/shop:%s"}], %s}
+			], "nextPageToken": "2"}`, prefix, at(9), shaLabel, ready(10), prefix, at(11), shaImage, ready(12))
+			return
+		}
+		// One that never became ready, one before the date range, and one with no commit
+		fmt.Fprintf(w, `{"revisions": [
+			{"name": "%sshop-00004-ghi", "createTime": %q, "labels": {"commit-sha": %q},
+			 "conditions": [{"type": "Ready", "state": "CONDITION_FAILED", "lastTransitionTime": %q}]},
+			{"name": "%sshop-00001-xyz", "createTime": "2024-05-01T09:00:00Z", "labels": {"commit-sha": %q}, %s},
+			{"name": "%sshop-00005-jkl", "createTime": %q, "containers": [{"image": "us-docker.pkg.dev/my-project/shop/shop:v1.2.0"}], %s}
+		]}`, prefix, at(13), shaLabel, at(14), prefix, shaLabel, ready(10), prefix, at(15), ready(16))
+	}))
+	defer server.Close()
+
+	commits := func(ctx context.Context, sha string) (string, time.Time, error) {
+		if sha == shaLabel {
+			return "Add search (#42)", time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC), nil
+		}
+		return "Bump dependencies", time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC), nil
+	}
+	client := newTestClient(server, commits)
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Newest first, leaving out the revision that never became ready and the one before the range
+	var names []string
+	for _, release := range releases {
+		names = append(names, strings.TrimPrefix(release.Name, prefix))
+	}
+	if strings.Join(names, ",") != "shop-00005-jkl,shop-00003-def,shop-00002-abc" {
+		t.Fatalf("Expected revisions 5, 3 and 2, got %v", names)
+	}
+
+	sha, prNumber, commitTime, err := client.ExtractSource(context.Background(), releases[2])
+	if err != nil || sha != shaLabel || prNumber != "42" || commitTime.Format(time.RFC3339) != at(8) {
+		t.Errorf("Expected PR #42's squashed commit from the label, got %s %s %v (%v)", sha, prNumber, commitTime, err)
+	}
+	if sha, _, _, err := client.ExtractSource(context.Background(), releases[1]); err != nil || sha != shaImage {
+		t.Errorf("Expected the image's tag, got %s (%v)", sha, err)
+	}
+	if _, _, _, err := client.ExtractSource(context.Background(), releases[0]); err == nil || !strings.Contains(err.Error(), "no commit SHA found") {
+		t.Errorf("Expected an error for a revision with no commit, got %v", err)
+	}
+
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[2])
+	if err != nil || finish.Format(time.RFC3339) != at(10) {
+		t.Errorf("Expected when the revision became ready, got %v (%v)", finish, err)
+	}
+
+	client.services = []string{"missing"}
+	if _, err := client.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: Resource 'missing' of kind 'SERVICE' was not found.") {
+		t.Errorf("Expected Cloud Run's error, got %v", err)
+	}
+}
+
+func TestCloudRunClient_CommitKeys(t *testing.T) {
+	client := &CloudRunClient{commitKeys: []string{"git-commit", DefaultCommitKey}}
+	r := revision{
+		Labels:      map[string]string{"commit-sha": shaLabel},
+		Annotations: map[string]string{"git-commit": "ABC1234"},
+		Containers: []struct {
+			Image string `json:"image"`
+		}{{Image: "gcr.io/p/shop:" + shaImage + "@sha256:0123"}},
+	}
+	if sha := client.revisionCommit(r); sha != "abc1234" {
+		t.Errorf("Expected the first key's short SHA from the annotations, got %q", sha)
+	}
+	client.SetCommitKeys([]string{"other"})
+	if sha := client.revisionCommit(r); sha != shaImage {
+		t.Errorf("Expected the image's tag without its digest, got %q", sha)
+	}
+}
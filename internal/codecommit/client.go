@@ -0,0 +1,558 @@
+// Package codecommit reads pull requests from AWS CodeCommit, for teams whose
+// repositories live there. PRs, approvals, pushes, comments and changed files are
+// returned in the same form as GitHub's, so the PR metrics pipeline works on them
+// unchanged. Repositories are named region/repository, e.g. us-east-1/payments.
+package codecommit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/github"
+)
+
+const (
+	targetPrefix   = "CodeCommit_20150413."
+	defaultTimeout = 30 * time.Second
+	pageSize       = 100
+)
+
+// ErrUnsupported is returned for data CodeCommit doesn't have, such as CODEOWNERS
+// files and teams
+var ErrUnsupported = errors.New("not supported by CodeCommit")
+
+// CodeCommitClient handles CodeCommit API operations
+type CodeCommitClient struct {
+	httpClient *http.Client
+	creds      Credentials
+	baseURL    string         // empty means the region's endpoint
+	budget     *budget.Budget // nil means unlimited
+	now        func() time.Time
+
+	// PRs and their events, kept from listing for the calls that follow
+	mu     sync.Mutex
+	prs    map[int]*pullRequest
+	events map[int][]pullRequestEvent
+}
+
+var _ github.GitHubClientInterface = (*CodeCommitClient)(nil)
+
+// NewCodeCommitClient creates a new CodeCommit client authenticated with creds
+func NewCodeCommitClient(creds Credentials) *CodeCommitClient {
+	return &CodeCommitClient{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		creds:  creds,
+		now:    time.Now,
+		prs:    make(map[int]*pullRequest),
+		events: make(map[int][]pullRequestEvent),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *CodeCommitClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// epochTime is a timestamp as the AWS JSON protocol encodes it, in fractional
+// seconds since the epoch
+type epochTime struct {
+	time.Time
+}
+
+func (t *epochTime) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	whole, frac := math.Modf(seconds)
+	t.Time = time.Unix(int64(whole), int64(frac*1e9)).UTC()
+	return nil
+}
+
+type pullRequest struct {
+	Title             string    `json:"title"`
+	PullRequestStatus string    `json:"pullRequestStatus"`
+	AuthorARN         string    `json:"authorArn"`
+	CreationDate      epochTime `json:"creationDate"`
+	Targets           []struct {
+		RepositoryName       string `json:"repositoryName"`
+		SourceReference      string `json:"sourceReference"`
+		DestinationReference string `json:"destinationReference"`
+		SourceCommit         string `json:"sourceCommit"`
+		MergeBase            string `json:"mergeBase"`
+	} `json:"pullRequestTargets"`
+}
+
+type pullRequestEvent struct {
+	EventDate       epochTime `json:"eventDate"`
+	ActorARN        string    `json:"actorArn"`
+	CreatedMetadata *struct {
+		SourceCommitID string `json:"sourceCommitId"`
+	} `json:"pullRequestCreatedEventMetadata"`
+	StatusChangedMetadata *struct {
+		PullRequestStatus string `json:"pullRequestStatus"`
+	} `json:"pullRequestStatusChangedEventMetadata"`
+	SourceReferenceUpdatedMetadata *struct {
+		AfterCommitID string `json:"afterCommitId"`
+	} `json:"pullRequestSourceReferenceUpdatedEventMetadata"`
+	MergedStateChangedMetadata *struct {
+		MergeMetadata struct {
+			IsMerged bool `json:"isMerged"`
+		} `json:"mergeMetadata"`
+	} `json:"pullRequestMergedStateChangedEventMetadata"`
+	ApprovalStateChangedMetadata *struct {
+		ApprovalStatus string `json:"approvalStatus"`
+	} `json:"approvalStateChangedEventMetadata"`
+}
+
+// FetchPullRequests fetches PRs created in the date range, newest first. CodeCommit
+// can only list PR IDs, but they're numbered in the order PRs were created, so
+// listing stops at the first PR older than the range. If the API budget runs out
+// part way through, the PRs fetched so far are returned along with the error.
+func (c *CodeCommitClient) FetchPullRequests(ctx context.Context, region, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error) {
+	var ids []int
+	for _, status := range []string{"OPEN", "CLOSED"} {
+		input := map[string]interface{}{
+			"repositoryName":    repo,
+			"pullRequestStatus": status,
+			"maxResults":        pageSize,
+		}
+		for {
+			var resp struct {
+				PullRequestIDs []string `json:"pullRequestIds"`
+				NextToken      string   `json:"nextToken"`
+			}
+			if err := c.call(ctx, region, "ListPullRequests", input, &resp); err != nil {
+				return nil, fmt.Errorf("failed to list pull requests: %w", err)
+			}
+			for _, id := range resp.PullRequestIDs {
+				if n, err := strconv.Atoi(id); err == nil {
+					ids = append(ids, n)
+				}
+			}
+			if resp.NextToken == "" {
+				break
+			}
+			input["nextToken"] = resp.NextToken
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+
+	var prs []*github.PullRequest
+	for _, id := range ids {
+		pr, err := c.pullRequest(ctx, region, id)
+		if err != nil {
+			return prs, err
+		}
+		if pr.CreationDate.Before(startDate) {
+			break
+		}
+		if pr.CreationDate.After(endDate) {
+			continue
+		}
+		events, err := c.pullRequestEvents(ctx, region, id)
+		if err != nil {
+			return prs, err
+		}
+		prs = append(prs, toGitHubPullRequest(id, pr, events))
+	}
+	return prs, nil
+}
+
+// FetchPullRequestReviews returns the PR's approvals as reviews. An approval
+// that was later revoked is reported as dismissed, as GitHub does.
+func (c *CodeCommitClient) FetchPullRequestReviews(ctx context.Context, region, repo string, prNumber int) ([]*github.Review, error) {
+	events, err := c.pullRequestEvents(ctx, region, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	reviews, _ := approvals(events)
+	return reviews, nil
+}
+
+// FetchPullRequestDismissals returns a dismissal of an approval for each one that
+// was revoked
+func (c *CodeCommitClient) FetchPullRequestDismissals(ctx context.Context, region, repo string, prNumber int) ([]*github.DismissalEvent, error) {
+	events, err := c.pullRequestEvents(ctx, region, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	_, dismissals := approvals(events)
+	return dismissals, nil
+}
+
+// approvals converts a PR's approval events to reviews, numbered in the order
+// they were given, and the revoked ones to dismissals of those reviews
+func approvals(events []pullRequestEvent) ([]*github.Review, []*github.DismissalEvent) {
+	var reviews []*github.Review
+	var dismissals []*github.DismissalEvent
+	approved := make(map[string]*github.Review)
+	for _, event := range events {
+		if event.ApprovalStateChangedMetadata == nil {
+			continue
+		}
+		user := userFromARN(event.ActorARN)
+		switch event.ApprovalStateChangedMetadata.ApprovalStatus {
+		case "APPROVE":
+			review := &github.Review{
+				ID:          int64(len(reviews) + 1),
+				User:        user,
+				State:       "APPROVED",
+				SubmittedAt: event.EventDate.Time,
+			}
+			reviews = append(reviews, review)
+			approved[user.Login] = review
+		case "REVOKE":
+			if review := approved[user.Login]; review != nil {
+				review.State = "DISMISSED"
+				delete(approved, user.Login)
+				dismissals = append(dismissals, &github.DismissalEvent{
+					CreatedAt: event.EventDate.Time,
+					DismissedReview: github.DismissedReview{
+						State:    "approved",
+						ReviewID: review.ID,
+					},
+				})
+			}
+		}
+	}
+	return reviews, dismissals
+}
+
+// FetchPullRequestCommits returns a commit for each push to the PR's source
+// branch, at the time of the push, starting with the commit it was opened with.
+// Only each push's latest commit is known.
+func (c *CodeCommitClient) FetchPullRequestCommits(ctx context.Context, region, repo string, prNumber int) ([]*github.Commit, error) {
+	events, err := c.pullRequestEvents(ctx, region, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []*github.Commit
+	for _, event := range events {
+		var sha string
+		switch {
+		case event.CreatedMetadata != nil:
+			sha = event.CreatedMetadata.SourceCommitID
+		case event.SourceReferenceUpdatedMetadata != nil:
+			sha = event.SourceReferenceUpdatedMetadata.AfterCommitID
+		default:
+			continue
+		}
+		at := github.CommitSignature{Date: event.EventDate.Time}
+		commits = append(commits, &github.Commit{
+			SHA:    sha,
+			Commit: github.CommitDetail{Author: at, Committer: at},
+		})
+	}
+	return commits, nil
+}
+
+// FetchPullRequestFiles returns the files the PR changes. CodeCommit doesn't
+// count lines changed, so only the paths and statuses are filled in.
+func (c *CodeCommitClient) FetchPullRequestFiles(ctx context.Context, region, repo string, prNumber int) ([]*github.File, error) {
+	pr, err := c.pullRequest(ctx, region, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(pr.Targets) == 0 {
+		return nil, nil
+	}
+	target := pr.Targets[0]
+
+	var files []*github.File
+	input := map[string]interface{}{
+		"repositoryName":        target.RepositoryName,
+		"beforeCommitSpecifier": target.MergeBase,
+		"afterCommitSpecifier":  target.SourceCommit,
+		"MaxResults":            pageSize,
+	}
+	for {
+		var resp struct {
+			Differences []struct {
+				BeforeBlob *struct {
+					Path string `json:"path"`
+				} `json:"beforeBlob"`
+				AfterBlob *struct {
+					Path string `json:"path"`
+				} `json:"afterBlob"`
+				ChangeType string `json:"changeType"`
+			} `json:"differences"`
+			NextToken string `json:"NextToken"`
+		}
+		if err := c.call(ctx, region, "GetDifferences", input, &resp); err != nil {
+			return files, fmt.Errorf("failed to fetch pull request files: %w", err)
+		}
+		for _, diff := range resp.Differences {
+			file := &github.File{}
+			switch diff.ChangeType {
+			case "A":
+				file.Status = "added"
+			case "D":
+				file.Status = "removed"
+			default:
+				file.Status = "modified"
+			}
+			if diff.AfterBlob != nil {
+				file.Filename = diff.AfterBlob.Path
+			} else if diff.BeforeBlob != nil {
+				file.Filename = diff.BeforeBlob.Path
+			}
+			files = append(files, file)
+		}
+		if resp.NextToken == "" {
+			break
+		}
+		input["NextToken"] = resp.NextToken
+	}
+	return files, nil
+}
+
+// FetchPullRequestComments returns the comments on the PR, both on its code and
+// on the PR as a whole
+func (c *CodeCommitClient) FetchPullRequestComments(ctx context.Context, region, repo string, prNumber int) ([]*github.Comment, error) {
+	var comments []*github.Comment
+	input := map[string]interface{}{
+		"pullRequestId": strconv.Itoa(prNumber),
+		"maxResults":    pageSize,
+	}
+	for {
+		var resp struct {
+			Data []struct {
+				Comments []struct {
+					AuthorARN    string    `json:"authorArn"`
+					CreationDate epochTime `json:"creationDate"`
+					Deleted      bool      `json:"deleted"`
+				} `json:"comments"`
+			} `json:"commentsForPullRequestData"`
+			NextToken string `json:"nextToken"`
+		}
+		if err := c.call(ctx, region, "GetCommentsForPullRequest", input, &resp); err != nil {
+			return comments, fmt.Errorf("failed to fetch pull request comments: %w", err)
+		}
+		for _, data := range resp.Data {
+			for _, comment := range data.Comments {
+				if comment.Deleted {
+					continue
+				}
+				comments = append(comments, &github.Comment{
+					User:      userFromARN(comment.AuthorARN),
+					CreatedAt: comment.CreationDate.Time,
+				})
+			}
+		}
+		if resp.NextToken == "" {
+			break
+		}
+		input["nextToken"] = resp.NextToken
+	}
+	return comments, nil
+}
+
+// FetchPullRequestIssueComments returns no comments: comments on the PR as a whole
+// are returned by FetchPullRequestComments, along with those on its code
+func (c *CodeCommitClient) FetchPullRequestIssueComments(ctx context.Context, region, repo string, prNumber int) ([]*github.Comment, error) {
+	return nil, nil
+}
+
+// FetchPullRequestTimeline returns no events: CodeCommit has no draft PRs, which
+// are what the timeline is read for
+func (c *CodeCommitClient) FetchPullRequestTimeline(ctx context.Context, region, repo string, prNumber int) ([]*github.TimelineEvent, error) {
+	return nil, nil
+}
+
+// FetchPullRequestReviewRequests returns no events: CodeCommit PRs don't have
+// requested reviewers, only approval rules
+func (c *CodeCommitClient) FetchPullRequestReviewRequests(ctx context.Context, region, repo string, prNumber int) ([]*github.ReviewRequestEvent, error) {
+	return nil, nil
+}
+
+// FetchRequiredApprovals returns 0, for the default of one approval. CodeCommit's
+// approval rules apply to PRs rather than branches.
+func (c *CodeCommitClient) FetchRequiredApprovals(ctx context.Context, region, repo, branch string) (int, error) {
+	return 0, nil
+}
+
+func (c *CodeCommitClient) FetchCodeowners(ctx context.Context, region, repo, ref string) (string, error) {
+	return "", fmt.Errorf("CODEOWNERS: %w", ErrUnsupported)
+}
+
+func (c *CodeCommitClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	return nil, fmt.Errorf("teams: %w", ErrUnsupported)
+}
+
+func (c *CodeCommitClient) FetchCommits(ctx context.Context, region, repo string, since, until time.Time) ([]*github.Commit, error) {
+	return nil, fmt.Errorf("tag commits: %w", ErrUnsupported)
+}
+
+func (c *CodeCommitClient) FetchCommit(ctx context.Context, region, repo, sha string) (*github.Commit, error) {
+	return nil, fmt.Errorf("tag commits: %w", ErrUnsupported)
+}
+
+// pullRequest fetches a PR's details, once
+func (c *CodeCommitClient) pullRequest(ctx context.Context, region string, id int) (*pullRequest, error) {
+	c.mu.Lock()
+	pr, ok := c.prs[id]
+	c.mu.Unlock()
+	if ok {
+		return pr, nil
+	}
+
+	var resp struct {
+		PullRequest *pullRequest `json:"pullRequest"`
+	}
+	input := map[string]interface{}{"pullRequestId": strconv.Itoa(id)}
+	if err := c.call(ctx, region, "GetPullRequest", input, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request %d: %w", id, err)
+	}
+	if resp.PullRequest == nil {
+		return nil, fmt.Errorf("pull request %d not found", id)
+	}
+
+	c.mu.Lock()
+	c.prs[id] = resp.PullRequest
+	c.mu.Unlock()
+	return resp.PullRequest, nil
+}
+
+// pullRequestEvents fetches a PR's events, oldest first, once
+func (c *CodeCommitClient) pullRequestEvents(ctx context.Context, region string, id int) ([]pullRequestEvent, error) {
+	c.mu.Lock()
+	events, ok := c.events[id]
+	c.mu.Unlock()
+	if ok {
+		return events, nil
+	}
+
+	input := map[string]interface{}{
+		"pullRequestId": strconv.Itoa(id),
+		"maxResults":    pageSize,
+	}
+	for {
+		var resp struct {
+			Events    []pullRequestEvent `json:"pullRequestEvents"`
+			NextToken string             `json:"nextToken"`
+		}
+		if err := c.call(ctx, region, "DescribePullRequestEvents", input, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch events for pull request %d: %w", id, err)
+		}
+		events = append(events, resp.Events...)
+		if resp.NextToken == "" {
+			break
+		}
+		input["nextToken"] = resp.NextToken
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].EventDate.Before(events[j].EventDate.Time)
+	})
+
+	c.mu.Lock()
+	c.events[id] = events
+	c.mu.Unlock()
+	return events, nil
+}
+
+// toGitHubPullRequest converts a CodeCommit PR to GitHub's form. CodeCommit doesn't
+// record when PRs were merged or closed, so those times come from its events.
+func toGitHubPullRequest(id int, pr *pullRequest, events []pullRequestEvent) *github.PullRequest {
+	result := &github.PullRequest{
+		Number:    id,
+		Title:     pr.Title,
+		State:     strings.ToLower(pr.PullRequestStatus),
+		User:      userFromARN(pr.AuthorARN),
+		CreatedAt: pr.CreationDate.Time,
+	}
+	if len(pr.Targets) > 0 {
+		target := pr.Targets[0]
+		result.Base = github.Branch{Ref: strings.TrimPrefix(target.DestinationReference, "refs/heads/")}
+		result.Head = github.Branch{Ref: strings.TrimPrefix(target.SourceReference, "refs/heads/")}
+	}
+
+	for _, event := range events {
+		switch {
+		case event.MergedStateChangedMetadata != nil && event.MergedStateChangedMetadata.MergeMetadata.IsMerged:
+			result.MergedAt = event.EventDate.Time
+		case event.StatusChangedMetadata != nil && event.StatusChangedMetadata.PullRequestStatus == "CLOSED":
+			result.ClosedAt = event.EventDate.Time
+		}
+	}
+	if result.State == "open" {
+		result.ClosedAt = time.Time{} // closed, then reopened
+	}
+	return result
+}
+
+// userFromARN returns the user an IAM ARN names: the last part of its path, such
+// as alice in arn:aws:iam::123456789012:user/alice or in an assumed role's
+// arn:aws:sts::123456789012:assumed-role/Developer/alice
+func userFromARN(arn string) github.User {
+	login := arn
+	if i := strings.LastIndex(arn, "/"); i >= 0 {
+		login = arn[i+1:]
+	}
+	return github.User{Login: login}
+}
+
+// call makes a CodeCommit API request and decodes the response into result
+func (c *CodeCommitClient) call(ctx context.Context, region, action string, input, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	endpoint := c.baseURL
+	if endpoint == "" {
+		endpoint = "https://codecommit." + region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", targetPrefix+action)
+	sign(req, body, c.creds, region, "codecommit", c.now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Type != "" {
+			return fmt.Errorf("%s failed with status %d: %s: %s", action, resp.StatusCode, apiErr.Type, apiErr.Message)
+		}
+		return fmt.Errorf("%s failed with status %d", action, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
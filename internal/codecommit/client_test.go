@@ -0,0 +1,172 @@
+package codecommit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// day returns the epoch seconds of a day in March 2024, as the API encodes times
+func day(d int) float64 {
+	return float64(time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC).Unix())
+}
+
+func TestCodeCommitClient(t *testing.T) {
+	pullRequests := map[string]map[string]interface{}{
+		"12": {"title": "Too new", "pullRequestStatus": "OPEN", "authorArn": "arn:aws:iam::1:user/alice", "creationDate": day(30)},
+		"11": {"title": "Add widgets", "pullRequestStatus": "CLOSED", "authorArn": "arn:aws:sts::1:assumed-role/Dev/bob", "creationDate": day(10),
+			"pullRequestTargets": []map[string]interface{}{{
+				"repositoryName": "payments", "sourceReference": "refs/heads/widgets", "destinationReference": "refs/heads/main",
+				"sourceCommit": "c2", "mergeBase": "base",
+			}}},
+		"4": {"title": "Too old", "pullRequestStatus": "CLOSED", "authorArn": "arn:aws:iam::1:user/alice", "creationDate": day(1)},
+	}
+	events := []map[string]interface{}{
+		{"eventDate": day(10), "pullRequestCreatedEventMetadata": map[string]string{"sourceCommitId": "c1"}},
+		{"eventDate": day(11), "actorArn": "arn:aws:iam::1:user/carol", "approvalStateChangedEventMetadata": map[string]string{"approvalStatus": "APPROVE"}},
+		{"eventDate": day(12), "actorArn": "arn:aws:iam::1:user/carol", "approvalStateChangedEventMetadata": map[string]string{"approvalStatus": "REVOKE"}},
+		{"eventDate": day(12), "pullRequestSourceReferenceUpdatedEventMetadata": map[string]string{"afterCommitId": "c2"}},
+		{"eventDate": day(13), "actorArn": "arn:aws:iam::1:user/dave", "approvalStateChangedEventMetadata": map[string]string{"approvalStatus": "APPROVE"}},
+		{"eventDate": day(14), "pullRequestMergedStateChangedEventMetadata": map[string]interface{}{"mergeMetadata": map[string]bool{"isMerged": true}}},
+		{"eventDate": day(14), "pullRequestStatusChangedEventMetadata": map[string]string{"pullRequestStatus": "CLOSED"}},
+	}
+
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Expected a signed request, got Authorization %q", r.Header.Get("Authorization"))
+		}
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)
+		calls = append(calls, action)
+		var input map[string]interface{}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &input); err != nil {
+			t.Fatalf("Bad request body: %v", err)
+		}
+
+		var resp interface{}
+		switch action {
+		case "ListPullRequests":
+			if input["pullRequestStatus"] == "OPEN" {
+				resp = map[string]interface{}{"pullRequestIds": []string{"12"}}
+			} else {
+				resp = map[string]interface{}{"pullRequestIds": []string{"4", "11"}}
+			}
+		case "GetPullRequest":
+			resp = map[string]interface{}{"pullRequest": pullRequests[input["pullRequestId"].(string)]}
+		case "DescribePullRequestEvents":
+			// Two pages, to check paging
+			if input["nextToken"] == nil {
+				resp = map[string]interface{}{"pullRequestEvents": events[:3], "nextToken": "more"}
+			} else {
+				resp = map[string]interface{}{"pullRequestEvents": events[3:]}
+			}
+		case "GetDifferences":
+			if input["beforeCommitSpecifier"] != "base" || input["afterCommitSpecifier"] != "c2" {
+				t.Errorf("Expected the diff from the merge base to the source commit, got %v", input)
+			}
+			resp = map[string]interface{}{"differences": []map[string]interface{}{
+				{"afterBlob": map[string]string{"path": "widgets.go"}, "changeType": "A"},
+				{"beforeBlob": map[string]string{"path": "old.go"}, "changeType": "D"},
+			}}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type": "InvalidActionException", "message": "unexpected"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewCodeCommitClient(Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	client.baseURL = server.URL
+	ctx := context.Background()
+
+	prs, err := client.FetchPullRequests(ctx, "us-east-1", "payments", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(prs) != 1 {
+		t.Fatalf("Expected only PR 11 in range, got %d PRs", len(prs))
+	}
+	pr := prs[0]
+	if pr.Number != 11 || pr.State != "closed" || pr.User.Login != "bob" || pr.Base.Ref != "main" || pr.Head.Ref != "widgets" {
+		t.Errorf("Unexpected PR %v", pr)
+	}
+	if merged := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC); !pr.MergedAt.Equal(merged) || !pr.ClosedAt.Equal(merged) {
+		t.Errorf("Expected the PR merged and closed on March 14, got %v and %v", pr.MergedAt, pr.ClosedAt)
+	}
+	gets := 0
+	for _, call := range calls {
+		if call == "GetPullRequest" {
+			gets++
+		}
+	}
+	if gets != 3 {
+		t.Errorf("Expected listing to stop at the first PR older than the range, got calls %v", calls)
+	}
+
+	// Events are fetched once, when listing
+	calls = nil
+	reviews, err := client.FetchPullRequestReviews(ctx, "us-east-1", "payments", 11)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reviews) != 2 || reviews[0].User.Login != "carol" || reviews[0].State != "DISMISSED" ||
+		reviews[1].User.Login != "dave" || reviews[1].State != "APPROVED" {
+		t.Errorf("Expected carol's revoked approval and dave's approval, got %v", reviews)
+	}
+	dismissals, err := client.FetchPullRequestDismissals(ctx, "us-east-1", "payments", 11)
+	if err != nil || len(dismissals) != 1 || dismissals[0].DismissedReview.ReviewID != reviews[0].ID ||
+		dismissals[0].DismissedReview.State != "approved" {
+		t.Errorf("Expected carol's approval to have been dismissed, got %v (%v)", dismissals, err)
+	}
+	commits, err := client.FetchPullRequestCommits(ctx, "us-east-1", "payments", 11)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].SHA != "c1" || commits[1].SHA != "c2" ||
+		!commits[1].Commit.Committer.Date.Equal(time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a commit per push, got %v", commits)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no more calls for reviews and commits, got %v", calls)
+	}
+
+	files, err := client.FetchPullRequestFiles(ctx, "us-east-1", "payments", 11)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0].Filename != "widgets.go" || files[0].Status != "added" ||
+		files[1].Filename != "old.go" || files[1].Status != "removed" {
+		t.Errorf("Unexpected files %v", files)
+	}
+
+	_, err = client.FetchPullRequestComments(ctx, "us-east-1", "payments", 11)
+	if err == nil || !strings.Contains(err.Error(), "InvalidActionException: unexpected") {
+		t.Errorf("Expected the API's error, got %v", err)
+	}
+	if _, err := client.FetchCodeowners(ctx, "us-east-1", "payments", "main"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for CODEOWNERS, got %v", err)
+	}
+}
+
+func TestCodeCommitClient_Budget(t *testing.T) {
+	client := NewCodeCommitClient(Credentials{})
+	client.baseURL = "http://127.0.0.1:0" // never reached
+	b := budget.New("API", 1)
+	b.Spend()
+	client.SetBudget(b)
+
+	if _, err := client.FetchPullRequests(context.Background(), "us-east-1", "payments", time.Time{}, time.Now()); !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected budget.ErrExhausted, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Package compare puts two periods' PR review times side by side, such as the
+// quarters before and after a new review rota, and tests each metric with a
+// Mann-Whitney U test, so a change can be told apart from the week-to-week noise
+// review times always have. Metrics with too few PRs in either period to tell
+// are never reported as significant.
+package compare
+
+import (
+	"math"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// Alpha is the p-value below which a difference is reported as significant
+const Alpha = 0.05
+
+// Sample summarizes one period's durations for a metric
+type Sample struct {
+	Count  int
+	Mean   time.Duration
+	Median time.Duration
+
+	// The confidence interval of the median, if it was estimated
+	MedianLow, MedianHigh time.Duration
+	HasInterval           bool
+}
+
+// Metric is one metric in both periods
+type Metric struct {
+	Name string
+	A, B Sample
+	U    float64 // Mann-Whitney U of A against B
+	P    float64 // Two-sided p-value, NaN if either period has no data
+}
+
+// TooFew reports whether either period has too few PRs with the metric for a
+// difference between them to mean much
+func (m Metric) TooFew() bool {
+	return m.A.Count < stats.MinSample || m.B.Count < stats.MinSample
+}
+
+// Significant reports whether the periods' durations differ by more than chance
+// would explain. It's false when there are too few PRs to tell.
+func (m Metric) Significant() bool {
+	return !m.TooFew() && m.P < Alpha
+}
+
+// Change is how much the median moved from A to B, relative to A's, or NaN if
+// either period has no data
+func (m Metric) Change() float64 {
+	if m.A.Count == 0 || m.B.Count == 0 || m.A.Median == 0 {
+		return math.NaN()
+	}
+	return float64(m.B.Median-m.A.Median) / float64(m.A.Median)
+}
+
+// Comparison is the outcome of comparing two periods
+type Comparison struct {
+	A, B       period.Period
+	PRsA, PRsB int // PRs opened in each period
+	Metrics    []Metric
+}
+
+// metrics are the durations compared, each with the value a PR contributes, if
+// any. Review times only count for PRs that were reviewed, as in the summary.
+var metrics = []struct {
+	name  string
+	value func(github.PullRequestMetric) (time.Duration, bool)
+}{
+	{"Time to First Review", func(pr github.PullRequestMetric) (time.Duration, bool) {
+		return pr.TimeToFirstReview, pr.HasReview && pr.TimeToFirstReview > 0
+	}},
+	{"Time to First Response", func(pr github.PullRequestMetric) (time.Duration, bool) {
+		return pr.TimeToFirstResponse, pr.TimeToFirstResponse > 0
+	}},
+	{"Time to Approval", func(pr github.PullRequestMetric) (time.Duration, bool) {
+		return pr.TimeToApproval, pr.HasReview && pr.TimeToApproval > 0
+	}},
+	{"Approval to Merge", func(pr github.PullRequestMetric) (time.Duration, bool) {
+		return pr.ApprovalToMerge, pr.HasReview && pr.ApprovalToMerge > 0
+	}},
+	{"Waiting on Reviewers", func(pr github.PullRequestMetric) (time.Duration, bool) {
+		return pr.ReviewerWaitTime, pr.HasReview
+	}},
+	{"Open to Merge", func(pr github.PullRequestMetric) (time.Duration, bool) {
+		return pr.MergedAt.Sub(pr.CreatedAt), !pr.MergedAt.IsZero()
+	}},
+}
+
+// Periods compares the PRs opened in period a with those opened in period b.
+// Metrics that weren't measured in either period, such as time to first
+// response without -first-response, are left out. The medians' confidence
+// intervals are estimated with bootstrap, if it isn't nil.
+func Periods(a, b period.Period, results []github.PullRequestMetric, bootstrap *stats.Bootstrap) Comparison {
+	groups := period.Group([]period.Period{a, b}, results, func(result github.PullRequestMetric) time.Time {
+		return result.CreatedAt
+	})
+	c := Comparison{A: a, B: b, PRsA: len(groups[0]), PRsB: len(groups[1])}
+	for _, m := range metrics {
+		durationsA, durationsB := collect(groups[0], m.value), collect(groups[1], m.value)
+		if len(durationsA) == 0 && len(durationsB) == 0 {
+			continue
+		}
+		u, p := stats.MannWhitneyU(hours(durationsA), hours(durationsB))
+		c.Metrics = append(c.Metrics, Metric{
+			Name: m.name,
+			A:    summarize(durationsA, bootstrap),
+			B:    summarize(durationsB, bootstrap),
+			U:    u,
+			P:    p,
+		})
+	}
+	return c
+}
+
+// collect returns the durations the PRs contribute to a metric
+func collect(results []github.PullRequestMetric, value func(github.PullRequestMetric) (time.Duration, bool)) []time.Duration {
+	var durations []time.Duration
+	for _, result := range results {
+		if d, ok := value(result); ok {
+			durations = append(durations, d)
+		}
+	}
+	return durations
+}
+
+// hours converts durations to hours, for the significance test
+func hours(durations []time.Duration) []float64 {
+	values := make([]float64, len(durations))
+	for i, d := range durations {
+		values[i] = d.Hours()
+	}
+	return values
+}
+
+// fromHours converts a number of hours back to a duration
+func fromHours(h float64) time.Duration {
+	return time.Duration(h * float64(time.Hour))
+}
+
+// summarize returns the count, mean and median of durations, and the median's
+// confidence interval if bootstrap isn't nil
+func summarize(durations []time.Duration, bootstrap *stats.Bootstrap) Sample {
+	s := Sample{Count: len(durations)}
+	if len(durations) == 0 {
+		return s
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	s.Mean = total / time.Duration(len(durations))
+	s.Median = stats.MedianOf(durations)
+
+	if low, high, ok := bootstrap.Interval(hours(durations), stats.Median); ok {
+		s.MedianLow, s.MedianHigh = fromHours(low), fromHours(high)
+		s.HasInterval = true
+	}
+	return s
+}
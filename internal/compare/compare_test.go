@@ -0,0 +1,102 @@
+package compare
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+func TestPeriods(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 9, 0, 0, 0, time.UTC) }
+	a := period.Period{Name: "A", Start: day(1), End: day(11)}
+	b := period.Period{Name: "B", Start: day(11), End: day(21)}
+
+	// Ten PRs in each period: first reviews took 10 to 19 hours in A and 1 to 10
+	// in B, while approval times are the same
+	var results []github.PullRequestMetric
+	for i := range 10 {
+		results = append(results,
+			github.PullRequestMetric{CreatedAt: day(1 + i), HasReview: true, TimeToFirstReview: time.Duration(10+i) * time.Hour, TimeToApproval: time.Duration(20+i) * time.Hour},
+			github.PullRequestMetric{CreatedAt: day(11 + i), HasReview: true, TimeToFirstReview: time.Duration(1+i) * time.Hour, TimeToApproval: time.Duration(29-i) * time.Hour},
+		)
+	}
+	// Outside both periods, so not counted
+	results = append(results, github.PullRequestMetric{CreatedAt: day(25), HasReview: true, TimeToFirstReview: time.Hour})
+	// Awaiting review, so counted as a PR but in no review time
+	results = append(results, github.PullRequestMetric{CreatedAt: day(12), TimeSinceCreation: 48 * time.Hour})
+
+	c := Periods(a, b, results, nil)
+	if c.PRsA != 10 || c.PRsB != 11 {
+		t.Errorf("Expected 10 and 11 PRs, got %d and %d", c.PRsA, c.PRsB)
+	}
+	// Measured metrics only: first review, approval and waiting on reviewers
+	if len(c.Metrics) != 3 {
+		t.Fatalf("Expected 3 metrics, got %+v", c.Metrics)
+	}
+
+	firstReview := c.Metrics[0]
+	if firstReview.Name != "Time to First Review" || firstReview.A.Count != 10 || firstReview.B.Count != 10 {
+		t.Fatalf("Expected ten first reviews in each period, got %+v", firstReview)
+	}
+	if firstReview.A.Median != 14*time.Hour+30*time.Minute || firstReview.B.Median != 5*time.Hour+30*time.Minute {
+		t.Errorf("Expected medians of 14.5h and 5.5h, got %v and %v", firstReview.A.Median, firstReview.B.Median)
+	}
+	if !firstReview.Significant() || math.Abs(firstReview.Change()+9.0/14.5) > 0.001 {
+		t.Errorf("Expected a significant 62%% drop, got p %.4f and change %.3f", firstReview.P, firstReview.Change())
+	}
+
+	if firstReview.A.HasInterval {
+		t.Error("Expected no confidence intervals without a Bootstrap")
+	}
+
+	approval := c.Metrics[1]
+	if approval.Name != "Time to Approval" || approval.Significant() || approval.Change() != 0 {
+		t.Errorf("Expected no change in approval times, got %+v", approval)
+	}
+}
+
+func TestMetric_Change(t *testing.T) {
+	m := Metric{A: Sample{Count: 3, Median: 2 * time.Hour}, B: Sample{Count: 4, Median: 3 * time.Hour}}
+	if m.Change() != 0.5 {
+		t.Errorf("Expected a 50%% increase, got %v", m.Change())
+	}
+	if !m.TooFew() || m.Significant() {
+		t.Error("Expected too few PRs to tell, whatever the p-value")
+	}
+	m.B = Sample{}
+	if !math.IsNaN(m.Change()) || m.Significant() {
+		t.Errorf("Expected no change or significance without data in B, got %v", m.Change())
+	}
+}
+
+func TestPeriods_Intervals(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 9, 0, 0, 0, time.UTC) }
+	a := period.Period{Name: "A", Start: day(1), End: day(11)}
+	b := period.Period{Name: "B", Start: day(11), End: day(21)}
+
+	// Plenty of PRs in A, but only three in B
+	var results []github.PullRequestMetric
+	for i := range 10 {
+		results = append(results, github.PullRequestMetric{CreatedAt: day(1 + i), HasReview: true, TimeToFirstReview: time.Duration(10+i) * time.Hour})
+	}
+	for i := range 3 {
+		results = append(results, github.PullRequestMetric{CreatedAt: day(11 + i), HasReview: true, TimeToFirstReview: time.Duration(1+i) * time.Minute})
+	}
+
+	bootstrap, err := stats.NewBootstrap(95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstReview := Periods(a, b, results, bootstrap).Metrics[0]
+	if !firstReview.A.HasInterval || firstReview.A.MedianLow > firstReview.A.Median || firstReview.A.MedianHigh < firstReview.A.Median {
+		t.Errorf("Expected an interval around A's median, got %+v", firstReview.A)
+	}
+	// However different they look, three PRs aren't enough to call it
+	if !firstReview.TooFew() || firstReview.Significant() {
+		t.Errorf("Expected too few PRs in B to tell, got %+v", firstReview)
+	}
+}
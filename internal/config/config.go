@@ -0,0 +1,139 @@
+// Package config lets recurring reports keep their settings in a YAML file rather
+// than a long shell command. Settings are named after the commands' flags:
+//
+//	since: 2024-01-01
+//	exclude: [dependabot, renovate]
+//	pr-tracker:
+//	  repos: [myorg/api, myorg/web]
+//	  tags-repo: myorg/tags
+//	  slo: [first-review=8h@90%]
+//	deploy-tracker:
+//	  project: my-gcp-project
+//
+// Top-level settings are shared, and apply to every command with a flag of that
+// name. A section named after a command applies only to it, and overrides shared
+// settings. Flags given on the command line override the file.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reposKey lists the repositories to report on when none are given as arguments
+const reposKey = "repos"
+
+// RegisterFlag defines the -config flag
+func RegisterFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", "", "YAML file of settings, named after these flags, for recurring reports")
+}
+
+// Apply loads the settings in path for command into fs, leaving alone any flag set
+// on the command line, and returns the file's repositories. It does nothing if path
+// is empty. Call it straight after parsing flags.
+func Apply(path, command string, fs *flag.FlagSet) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var root map[string]yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	setOnCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { setOnCommandLine[f.Name] = true })
+
+	var repos []string
+	apply := func(settings map[string]yaml.Node, shared bool) error {
+		for name, node := range settings {
+			if node.Kind == yaml.MappingNode {
+				continue // another command's section
+			}
+			if name == reposKey {
+				values, err := nodeValues(name, &node)
+				if err != nil {
+					return err
+				}
+				repos = values
+				continue
+			}
+
+			f := fs.Lookup(name)
+			if f == nil {
+				if shared {
+					continue // meant for a different command
+				}
+				return fmt.Errorf("unknown setting %q for %s in %s", name, command, path)
+			}
+			if setOnCommandLine[name] {
+				continue
+			}
+			if err := setFlag(f, &node); err != nil {
+				return fmt.Errorf("invalid setting %q in %s: %w", name, path, err)
+			}
+		}
+		return nil
+	}
+
+	if err := apply(root, true); err != nil {
+		return nil, err
+	}
+	if section, ok := root[command]; ok {
+		var settings map[string]yaml.Node
+		if err := section.Decode(&settings); err != nil {
+			return nil, fmt.Errorf("invalid %s section in %s: %w", command, path, err)
+		}
+		if err := apply(settings, false); err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// setFlag sets a flag from a scalar or a list. Lists are passed to repeatable
+// flags one item at a time, and joined with commas for the rest, which take
+// comma-separated values.
+func setFlag(f *flag.Flag, node *yaml.Node) error {
+	values, err := nodeValues(f.Name, node)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Value.(flag.Getter); ok || node.Kind == yaml.ScalarNode {
+		return f.Value.Set(strings.Join(values, ","))
+	}
+	for _, value := range values {
+		if err := f.Value.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeValues returns a scalar's raw text, or a list's items. Raw text keeps values
+// such as dates exactly as written, for the flags to parse.
+func nodeValues(name string, node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		var values []string
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s must be a list of plain values", name)
+			}
+			values = append(values, item.Value)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%s must be a value or a list", name)
+	}
+}
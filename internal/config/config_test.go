@@ -0,0 +1,94 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// listValue is a repeatable flag, like slo.Objectives
+type listValue []string
+
+func (l *listValue) String() string     { return strings.Join(*l, ";") }
+func (l *listValue) Set(s string) error { *l = append(*l, s); return nil }
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "statstracker.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApply(t *testing.T) {
+	path := writeConfig(t, `
+since: 2024-01-01
+until: 2024-03-31
+exclude: [dependabot, renovate]
+project: shared-setting-for-another-command
+pr-tracker:
+  repos: [myorg/api, myorg/web]
+  until: 2024-02-29
+  required-approvals: 2
+  slo:
+    - first-review=8h@90%
+    - approval=24h@80%
+deploy-tracker:
+  project: my-gcp-project
+`)
+
+	fs := flag.NewFlagSet("pr-tracker", flag.ContinueOnError)
+	since := fs.String("since", "", "")
+	until := fs.String("until", "", "")
+	exclude := fs.String("exclude", "", "")
+	requiredApprovals := fs.Int("required-approvals", 0, "")
+	var slos listValue
+	fs.Var(&slos, "slo", "")
+	if err := fs.Parse([]string{"-since", "2024-02-01"}); err != nil {
+		t.Fatal(err)
+	}
+
+	repos, err := Apply(path, "pr-tracker", fs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if strings.Join(repos, " ") != "myorg/api myorg/web" {
+		t.Errorf("Expected the pr-tracker repos, got %v", repos)
+	}
+	if *since != "2024-02-01" {
+		t.Errorf("Expected the command line's -since to win, got %s", *since)
+	}
+	if *until != "2024-02-29" {
+		t.Errorf("Expected the pr-tracker section's until to override the shared one, got %s", *until)
+	}
+	if *exclude != "dependabot,renovate" {
+		t.Errorf("Expected exclude to be joined with commas, got %s", *exclude)
+	}
+	if *requiredApprovals != 2 {
+		t.Errorf("Expected 2 required approvals, got %d", *requiredApprovals)
+	}
+	if len(slos) != 2 || slos[0] != "first-review=8h@90%" {
+		t.Errorf("Expected each SLO to be set separately, got %v", slos)
+	}
+}
+
+func TestApplyRejectsUnknownCommandSettings(t *testing.T) {
+	path := writeConfig(t, "pr-tracker:\n  no-such-flag: true\n")
+
+	fs := flag.NewFlagSet("pr-tracker", flag.ContinueOnError)
+	if _, err := Apply(path, "pr-tracker", fs); err == nil {
+		t.Error("Expected an error for a setting pr-tracker has no flag for")
+	}
+}
+
+func TestApplyWithoutConfig(t *testing.T) {
+	fs := flag.NewFlagSet("pr-tracker", flag.ContinueOnError)
+	repos, err := Apply("", "pr-tracker", fs)
+	if err != nil || repos != nil {
+		t.Errorf("Expected nothing to happen without a config file, got %v, %v", repos, err)
+	}
+}
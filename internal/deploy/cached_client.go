@@ -1,11 +1,13 @@
 package deploy
 
 import (
+	"context"
 	"log"
 	"time"
 
-	"cloud.google.com/go/deploy/apiv1/deploypb"
+	"github.com/reillywatson/statstracker/internal/budget"
 	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/retry"
 )
 
 // CachedDeployClient wraps DeployClient with caching capabilities
@@ -13,11 +15,12 @@ type CachedDeployClient struct {
 	client *DeployClient
 	cache  cache.Cache
 	kb     *cache.CacheKeyBuilder
+	swr    *cache.Revalidator // nil unless stale-while-revalidate is enabled
 }
 
 // NewCachedDeployClient creates a new Deploy client with caching
-func NewCachedDeployClient(projectID, region, githubToken, githubOrg, tagsRepo, servicesRepo string, cacheImpl cache.Cache) (*CachedDeployClient, error) {
-	client, err := NewDeployClient(projectID, region, githubToken, githubOrg, tagsRepo, servicesRepo)
+func NewCachedDeployClient(projectID string, regions []string, githubToken, githubOrg, tagsRepo, servicesRepo string, cacheImpl cache.Cache) (*CachedDeployClient, error) {
+	client, err := NewDeployClient(projectID, regions, githubToken, githubOrg, tagsRepo, servicesRepo)
 	if err != nil {
 		return nil, err
 	}
@@ -29,19 +32,45 @@ func NewCachedDeployClient(projectID, region, githubToken, githubOrg, tagsRepo,
 	}, nil
 }
 
-// FetchTestEnvironmentReleases fetches releases with caching
-func (c *CachedDeployClient) FetchTestEnvironmentReleases(startDate, endDate time.Time) ([]*deploypb.Release, error) {
+// SetStaleWhileRevalidate toggles stale-while-revalidate mode, in which expired
+// cache entries are returned immediately and refreshed in the background.
+// Close waits for any outstanding refreshes.
+func (c *CachedDeployClient) SetStaleWhileRevalidate(enabled bool) {
+	if enabled {
+		c.swr = cache.NewRevalidator()
+	} else {
+		c.swr = nil
+	}
+}
+
+// SetBudgets limits the number of Cloud Deploy and GitHub API calls the client may make
+func (c *CachedDeployClient) SetBudgets(deployBudget, githubBudget *budget.Budget) {
+	c.client.SetBudgets(deployBudget, githubBudget)
+}
+
+// SetRetryPolicy sets how calls that fail with a server or network error are retried
+func (c *CachedDeployClient) SetRetryPolicy(p retry.Policy) {
+	c.client.SetRetryPolicy(p)
+}
+
+// SetPipelineSelector chooses which delivery pipelines' releases are fetched
+func (c *CachedDeployClient) SetPipelineSelector(s PipelineSelector) {
+	c.client.SetPipelineSelector(s)
+}
+
+// ListReleases fetches releases with caching
+func (c *CachedDeployClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*Release, error) {
 	// For release lists, we cache per-pipeline since that's how we fetch them
 	// We'll need to get pipelines first, then cache each pipeline's releases
-	releases, err := c.client.FetchTestEnvironmentReleases(startDate, endDate)
+	releases, err := c.client.ListReleases(ctx, startDate, endDate)
 	if err != nil {
-		return nil, err
+		return releases, err
 	}
 
 	// Cache individual releases if they're in a cacheable state
 	for _, release := range releases {
 		if c.isReleaseCacheable(release) {
-			releaseKey := c.kb.ReleaseKey(c.client.projectID, c.client.region, release.Name)
+			releaseKey := c.kb.ReleaseKey(c.client.projectID, releaseRegion(release.Name), release.Name)
 			if err := c.cache.Set(releaseKey, release, 24*time.Hour); err != nil {
 				log.Printf("Failed to cache release %s: %v", release.Name, err)
 			}
@@ -51,27 +80,37 @@ func (c *CachedDeployClient) FetchTestEnvironmentReleases(startDate, endDate tim
 	return releases, nil
 }
 
-// ExtractCommitSHAFromRelease extracts commit info with caching for GitHub API calls
-func (c *CachedDeployClient) ExtractCommitSHAFromRelease(release *deploypb.Release) (string, string, time.Time, error) {
+// ExtractSource extracts commit info with caching for GitHub API calls
+func (c *CachedDeployClient) ExtractSource(ctx context.Context, release *Release) (string, string, time.Time, error) {
 	// The actual implementation delegates to the wrapped client
 	// The GitHub API calls within this method will be cached if the DeployClient uses a cached GitHub client
-	return c.client.ExtractCommitSHAFromRelease(release)
+	return c.client.ExtractSource(ctx, release)
 }
 
-// GetReleaseFinishTime gets rollout completion time with caching
-func (c *CachedDeployClient) GetReleaseFinishTime(release *deploypb.Release) (time.Time, error) {
+// GetRolloutCompletion gets rollout completion time with caching
+func (c *CachedDeployClient) GetRolloutCompletion(ctx context.Context, release *Release) (time.Time, error) {
 	// Try to get rollouts from cache first
-	rolloutsKey := c.kb.RolloutsKey(c.client.projectID, c.client.region, release.Name)
+	rolloutsKey := c.kb.RolloutsKey(c.client.projectID, releaseRegion(release.Name), release.Name)
 
 	var cachedResult time.Time
-	if err := c.cache.Get(rolloutsKey, &cachedResult); err == nil {
+	refresh := func() error {
+		_, err := c.fetchRolloutCompletion(context.WithoutCancel(ctx), release)
+		return err
+	}
+	if err := c.swr.Get(c.cache, rolloutsKey, &cachedResult, refresh); err == nil {
 		return cachedResult, nil
 	} else if err != cache.ErrCacheMiss {
 		log.Printf("Cache error for rollouts: %v", err)
 	}
 
 	// Cache miss, fetch from API
-	finishTime, err := c.client.GetReleaseFinishTime(release)
+	return c.fetchRolloutCompletion(ctx, release)
+}
+
+// fetchRolloutCompletion gets rollout completion time from the API and stores it in the cache
+func (c *CachedDeployClient) fetchRolloutCompletion(ctx context.Context, release *Release) (time.Time, error) {
+	rolloutsKey := c.kb.RolloutsKey(c.client.projectID, releaseRegion(release.Name), release.Name)
+	finishTime, err := c.client.GetRolloutCompletion(ctx, release)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -86,19 +125,96 @@ func (c *CachedDeployClient) GetReleaseFinishTime(release *deploypb.Release) (ti
 	return finishTime, nil
 }
 
+// rollbackTTL is how long whether a release was rolled back is cached. It's
+// shorter than for the finish time, since a release can be rolled back any time.
+const rollbackTTL = time.Hour
+
+// GetReleaseRollbackTime gets when the release was rolled back, if it was, with caching
+func (c *CachedDeployClient) GetReleaseRollbackTime(ctx context.Context, release *Release) (time.Time, error) {
+	rollbackKey := c.kb.RollbackKey(c.client.projectID, releaseRegion(release.Name), release.Name)
+
+	var cachedResult time.Time
+	refresh := func() error {
+		_, err := c.fetchReleaseRollbackTime(context.WithoutCancel(ctx), release)
+		return err
+	}
+	if err := c.swr.Get(c.cache, rollbackKey, &cachedResult, refresh); err == nil {
+		return cachedResult, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for rollback: %v", err)
+	}
+
+	return c.fetchReleaseRollbackTime(ctx, release)
+}
+
+// fetchReleaseRollbackTime gets when the release was rolled back from the API and stores it in the cache
+func (c *CachedDeployClient) fetchReleaseRollbackTime(ctx context.Context, release *Release) (time.Time, error) {
+	rollbackKey := c.kb.RollbackKey(c.client.projectID, releaseRegion(release.Name), release.Name)
+	rolledBackAt, err := c.client.GetReleaseRollbackTime(ctx, release)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Once rolled back, always rolled back; until then it could happen any time
+	ttl := rollbackTTL
+	if !rolledBackAt.IsZero() {
+		ttl = 24 * time.Hour
+	}
+	if err := c.cache.Set(rollbackKey, rolledBackAt, ttl); err != nil {
+		log.Printf("Failed to cache rollback time: %v", err)
+	}
+
+	return rolledBackAt, nil
+}
+
+// GetRolloutPhases gets how long the release's rollouts spent in each phase, with caching
+func (c *CachedDeployClient) GetRolloutPhases(ctx context.Context, release *Release) ([]TargetRollout, error) {
+	phasesKey := c.kb.RolloutPhasesKey(c.client.projectID, releaseRegion(release.Name), release.Name)
+
+	var cachedResult []TargetRollout
+	refresh := func() error {
+		_, err := c.fetchRolloutPhases(context.WithoutCancel(ctx), release)
+		return err
+	}
+	if err := c.swr.Get(c.cache, phasesKey, &cachedResult, refresh); err == nil {
+		return cachedResult, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for rollout phases: %v", err)
+	}
+
+	return c.fetchRolloutPhases(ctx, release)
+}
+
+// fetchRolloutPhases gets the release's rollout phases from the API and stores them in the cache
+func (c *CachedDeployClient) fetchRolloutPhases(ctx context.Context, release *Release) ([]TargetRollout, error) {
+	phasesKey := c.kb.RolloutPhasesKey(c.client.projectID, releaseRegion(release.Name), release.Name)
+	rollouts, err := c.client.GetRolloutPhases(ctx, release)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only successful rollouts are timed, and they're finished
+	if err := c.cache.Set(phasesKey, rollouts, 24*time.Hour); err != nil {
+		log.Printf("Failed to cache rollout phases: %v", err)
+	}
+
+	return rollouts, nil
+}
+
 // isReleaseCacheable determines if a release is in a state that can be cached long-term
-func (c *CachedDeployClient) isReleaseCacheable(release *deploypb.Release) bool {
+func (c *CachedDeployClient) isReleaseCacheable(release *Release) bool {
 	if release == nil {
 		return false
 	}
 
 	// Cache if release has completed successfully or failed (final states)
-	return release.RenderState == deploypb.Release_SUCCEEDED ||
-		release.RenderState == deploypb.Release_FAILED
+	return release.RenderState == "SUCCEEDED" ||
+		release.RenderState == "FAILED"
 }
 
 // Close cleans up the client
 func (c *CachedDeployClient) Close() error {
+	c.swr.Wait()
 	defer c.cache.Close()
 	return c.client.Close()
 }
@@ -3,30 +3,42 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	deploy "cloud.google.com/go/deploy/apiv1"
 	"cloud.google.com/go/deploy/apiv1/deploypb"
-	"github.com/google/go-github/v39/github"
-	"golang.org/x/oauth2"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/retry"
 	"google.golang.org/api/iterator"
+	locationpb "google.golang.org/genproto/googleapis/cloud/location"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // DeployClient wraps Google Cloud Deploy operations
 type DeployClient struct {
 	deployClient *deploy.CloudDeployClient
-	githubClient *github.Client
+	githubClient *github.GitHubClient
 	projectID    string
-	region       string
-	githubOrg    string // GitHub organization name
-	tagsRepo     string // Repository containing deployment tags
-	servicesRepo string // Repository containing the actual service code
+	regions      []string       // Regions to list pipelines in; nil lists every region Cloud Deploy has
+	githubOrg    string         // GitHub organization name
+	tagsRepo     string         // Repository containing deployment tags
+	servicesRepo string         // Repository containing the actual service code
+	deployBudget *budget.Budget // Cloud Deploy API call budget, nil means unlimited
+	retryPolicy  retry.Policy
+	pipelines    PipelineSelector // Delivery pipelines whose releases are fetched
 }
 
-// NewDeployClient creates a new DeployClient with Application Default Credentials
-func NewDeployClient(projectID, region, githubToken, githubOrg, tagsRepo, servicesRepo string) (*DeployClient, error) {
+// NewDeployClient creates a new DeployClient with Application Default Credentials,
+// fetching releases from the delivery pipelines in the given regions, or in every
+// region the project can use if there are none
+func NewDeployClient(projectID string, regions []string, githubToken, githubOrg, tagsRepo, servicesRepo string) (*DeployClient, error) {
 	ctx := context.Background()
 
 	// Create Google Cloud Deploy client
@@ -35,19 +47,15 @@ func NewDeployClient(projectID, region, githubToken, githubOrg, tagsRepo, servic
 		return nil, fmt.Errorf("failed to create deploy client: %w", err)
 	}
 
-	// Create GitHub client
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: githubToken})
-	tc := oauth2.NewClient(ctx, ts)
-	githubClient := github.NewClient(tc)
-
 	return &DeployClient{
 		deployClient: deployClient,
-		githubClient: githubClient,
+		githubClient: github.NewGitHubClient(githubToken),
 		projectID:    projectID,
-		region:       region,
+		regions:      regions,
 		githubOrg:    githubOrg,
 		tagsRepo:     tagsRepo,
 		servicesRepo: servicesRepo,
+		retryPolicy:  retry.DefaultPolicy,
 	}, nil
 }
 
@@ -60,54 +68,106 @@ func (c *DeployClient) Close() error {
 	return nil
 }
 
-// FetchTestEnvironmentReleases gets successful releases from test environment delivery pipelines
-func (c *DeployClient) FetchTestEnvironmentReleases(startDate, endDate time.Time) ([]*deploypb.Release, error) {
-	ctx := context.Background()
+// SetBudgets limits the number of Cloud Deploy and GitHub API calls the client
+// may make. Once a budget is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *DeployClient) SetBudgets(deployBudget, githubBudget *budget.Budget) {
+	c.deployBudget = deployBudget
+	c.githubClient.SetBudget(githubBudget)
+}
 
-	// First, get all delivery pipelines that contain "test"
-	parent := fmt.Sprintf("projects/%s/locations/%s", c.projectID, c.region)
+// SetRetryPolicy sets how Cloud Deploy and GitHub calls that fail with a server or
+// network error are retried (retry.DefaultPolicy unless set)
+func (c *DeployClient) SetRetryPolicy(p retry.Policy) {
+	c.retryPolicy = p
+	c.githubClient.SetRetryPolicy(p)
+}
 
-	req := &deploypb.ListDeliveryPipelinesRequest{
-		Parent: parent,
+// SetPipelineSelector chooses which delivery pipelines' releases are fetched
+// (pipelines with "test" in their ID unless set)
+func (c *DeployClient) SetPipelineSelector(s PipelineSelector) {
+	c.pipelines = s
+}
+
+// retryOption retries a Cloud Deploy call, each page of a listing included,
+// according to the client's retry policy
+func (c *DeployClient) retryOption(op string) gax.CallOption {
+	return gax.WithRetry(func() gax.Retryer {
+		return retry.NewRetryer(op, c.retryPolicy, transientGRPCError)
+	})
+}
+
+// transientGRPCError reports whether a Cloud Deploy call failed because the
+// service was briefly unavailable or hit an internal error
+func transientGRPCError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Internal:
+		return true
 	}
+	return false
+}
 
-	pipelineIt := c.deployClient.ListDeliveryPipelines(ctx, req)
-	var testPipelines []string
+// ListReleases gets releases that rendered, or failed to, from the
+// delivery pipelines chosen with SetPipelineSelector, by default the test
+// environment's, in each of the client's regions. If the API budget runs out part
+// way through, the releases found so far are returned along with the error.
+func (c *DeployClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*Release, error) {
+	regions := c.regions
+	if len(regions) == 0 {
+		var err error
+		if regions, err = c.listRegions(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	for {
-		pipeline, err := pipelineIt.Next()
-		if err == iterator.Done {
-			break
+	// First, find the delivery pipelines to count in each region
+	var selected []string
+	for _, region := range regions {
+		req := &deploypb.ListDeliveryPipelinesRequest{
+			Parent: fmt.Sprintf("projects/%s/locations/%s", c.projectID, region),
 		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to list delivery pipelines: %w", err)
+
+		if err := c.deployBudget.Spend(); err != nil {
+			return nil, err
 		}
+		pipelineIt := c.deployClient.ListDeliveryPipelines(ctx, req, c.retryOption("ListDeliveryPipelines"))
+
+		for {
+			pipeline, err := pipelineIt.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list delivery pipelines in %s: %w", region, err)
+			}
 
-		// Check if pipeline name contains "test"
-		if strings.Contains(strings.ToLower(pipeline.Name), "test") {
-			testPipelines = append(testPipelines, pipeline.Name)
+			if c.pipelines.Matches(pipeline) {
+				selected = append(selected, pipeline.Name)
+			}
 		}
 	}
 	// print the names of the pipelines found
-	if len(testPipelines) == 0 {
-		return nil, fmt.Errorf("no test environment delivery pipelines found")
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no delivery pipelines with %s found in %s", c.pipelines, strings.Join(regions, ", "))
 	}
-	fmt.Printf("Found %d test environment delivery pipelines:\n", len(testPipelines))
-	for _, pipeline := range testPipelines {
+	fmt.Printf("Found %d delivery pipelines with %s in %s:\n", len(selected), c.pipelines, strings.Join(regions, ", "))
+	for _, pipeline := range selected {
 		fmt.Println(" -", pipeline)
 	}
 
-	var allReleases []*deploypb.Release
+	var allReleases []*Release
 
-	// For each test pipeline, get releases
-	for _, pipelineName := range testPipelines {
+	// For each pipeline, get releases
+	for _, pipelineName := range selected {
 		fmt.Printf("Checking releases for pipeline: %s\n", pipelineName)
 
 		releaseReq := &deploypb.ListReleasesRequest{
 			Parent: pipelineName,
 		}
 
-		releaseIt := c.deployClient.ListReleases(ctx, releaseReq)
+		if err := c.deployBudget.Spend(); err != nil {
+			return allReleases, err
+		}
+		releaseIt := c.deployClient.ListReleases(ctx, releaseReq, c.retryOption("ListReleases"))
 		releaseCount := 0
 		filteredReleaseCount := 0
 
@@ -129,24 +189,67 @@ func (c *DeployClient) FetchTestEnvironmentReleases(startDate, endDate time.Time
 				continue
 			}
 
-			// Only include successful releases (not failed or pending)
-			if release.RenderState == deploypb.Release_SUCCEEDED {
-				allReleases = append(allReleases, release)
+			// Include releases that rendered or failed to, but not ones still rendering
+			if release.RenderState == deploypb.Release_SUCCEEDED || release.RenderState == deploypb.Release_FAILED {
+				allReleases = append(allReleases, convertRelease(release))
 				filteredReleaseCount++
 			}
 		}
 
-		fmt.Printf("  Pipeline %s: found %d total releases, %d in date range and successful\n",
+		fmt.Printf("  Pipeline %s: found %d total releases, %d in date range and rendered\n",
 			pipelineName, releaseCount, filteredReleaseCount)
 	}
 
 	return allReleases, nil
 }
 
-// ExtractCommitSHAFromRelease extracts the commit SHA and PR number from a release
-func (c *DeployClient) ExtractCommitSHAFromRelease(release *deploypb.Release) (string, string, time.Time, error) {
-	ctx := context.Background()
+// listRegions returns the regions the project can use Cloud Deploy in
+func (c *DeployClient) listRegions(ctx context.Context) ([]string, error) {
+	if err := c.deployBudget.Spend(); err != nil {
+		return nil, err
+	}
+	req := &locationpb.ListLocationsRequest{Name: "projects/" + c.projectID}
+	it := c.deployClient.ListLocations(ctx, req, c.retryOption("ListLocations"))
+	var regions []string
+	for {
+		location, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Cloud Deploy regions: %w", err)
+		}
+		regions = append(regions, location.LocationId)
+	}
+	return regions, nil
+}
 
+// convertRelease converts a listed release to a Release
+func convertRelease(release *deploypb.Release) *Release {
+	converted := &Release{
+		Name:        release.Name,
+		CreateTime:  release.CreateTime.AsTime(),
+		Annotations: release.Annotations,
+		Labels:      release.Labels,
+		RenderState: release.RenderState.String(),
+		Abandoned:   release.Abandoned,
+	}
+	if release.RenderStartTime != nil && release.RenderEndTime != nil {
+		converted.RenderTime = release.RenderEndTime.AsTime().Sub(release.RenderStartTime.AsTime())
+	}
+	// Targets are rendered separately; the first to fail, by target, says why
+	for _, target := range slices.Sorted(maps.Keys(release.TargetRenders)) {
+		render := release.TargetRenders[target]
+		if render.RenderingState == deploypb.Release_TargetRender_FAILED {
+			converted.RenderFailure = fmt.Sprintf("%s: %s", target, render.FailureMessage)
+			break
+		}
+	}
+	return converted
+}
+
+// ExtractSource extracts the commit SHA and PR number from a release
+func (c *DeployClient) ExtractSource(ctx context.Context, release *Release) (string, string, time.Time, error) {
 	// Look for commit annotation in the release
 	var commitSHA string
 	if release.Annotations != nil {
@@ -167,7 +270,7 @@ func (c *DeployClient) ExtractCommitSHAFromRelease(release *deploypb.Release) (s
 	}
 
 	// Get the commit from tags repo
-	commit, _, err := c.githubClient.Repositories.GetCommit(ctx, c.githubOrg, c.tagsRepo, commitSHA, nil)
+	commit, err := c.githubClient.FetchCommit(ctx, c.githubOrg, c.tagsRepo, commitSHA)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("failed to get commit from %s: %w", c.tagsRepo, err)
 	}
@@ -187,12 +290,11 @@ func (c *DeployClient) ExtractCommitSHAFromRelease(release *deploypb.Release) (s
 	shaPattern2 := regexp.MustCompile(`\+\s*\w+:\s*\d{4}_\d{2}_\d{2}__\d{2}_\d{2}_\d{2}__[^_]+__([a-f0-9]{7,40})`)
 
 	for _, file := range files {
-		if file.Patch == nil {
+		if file.Patch == "" {
 			continue
 		}
 
-		patch := *file.Patch
-		lines := strings.Split(patch, "\n")
+		lines := strings.Split(file.Patch, "\n")
 
 		for _, line := range lines {
 			if strings.HasPrefix(line, "+") {
@@ -222,28 +324,33 @@ func (c *DeployClient) ExtractCommitSHAFromRelease(release *deploypb.Release) (s
 	}
 
 	// Get the commit from the services repo to get the commit time
-	serviceCommit, _, err := c.githubClient.Repositories.GetCommit(ctx, c.githubOrg, c.servicesRepo, appCommitSHA, nil)
+	serviceCommit, err := c.githubClient.FetchCommit(ctx, c.githubOrg, c.servicesRepo, appCommitSHA)
 	if err != nil {
 		return "", "", time.Time{}, fmt.Errorf("failed to get commit from services repo: %w", err)
 	}
 
-	commitTime := serviceCommit.GetCommit().GetCommitter().GetDate()
+	commitTime := serviceCommit.Commit.Committer.Date
 
 	return appCommitSHA, prNumber, commitTime, nil
 }
 
-// GetReleaseFinishTime returns the time when the last rollout for the release completed
-func (c *DeployClient) GetReleaseFinishTime(release *deploypb.Release) (time.Time, error) {
-	ctx := context.Background()
-
+// GetRolloutCompletion returns the time when the last rollout for the release
+// completed. Rollouts that rolled a target back to the release don't count: they
+// happen after it had gone live, often long after. If none of its rollouts
+// succeeded and one failed or was halted, the error is a *RolloutFailedError.
+func (c *DeployClient) GetRolloutCompletion(ctx context.Context, release *Release) (time.Time, error) {
 	// List all rollouts for this release
 	req := &deploypb.ListRolloutsRequest{
 		Parent: release.Name,
 	}
 
-	rolloutIt := c.deployClient.ListRollouts(ctx, req)
+	if err := c.deployBudget.Spend(); err != nil {
+		return time.Time{}, err
+	}
+	rolloutIt := c.deployClient.ListRollouts(ctx, req, c.retryOption("ListRollouts"))
 	var latestFinishTime time.Time
 	var foundCompletedRollout bool
+	var failed *RolloutFailedError
 
 	for {
 		rollout, err := rolloutIt.Next()
@@ -255,7 +362,7 @@ func (c *DeployClient) GetReleaseFinishTime(release *deploypb.Release) (time.Tim
 		}
 
 		// Only consider successful rollouts
-		if rollout.State == deploypb.Rollout_SUCCEEDED && rollout.DeployEndTime != nil {
+		if rollout.State == deploypb.Rollout_SUCCEEDED && rollout.DeployEndTime != nil && rollout.RollbackOfRollout == "" {
 			foundCompletedRollout = true
 			finishTime := rollout.DeployEndTime.AsTime()
 
@@ -264,11 +371,132 @@ func (c *DeployClient) GetReleaseFinishTime(release *deploypb.Release) (time.Tim
 				latestFinishTime = finishTime
 			}
 		}
+		if (rollout.State == deploypb.Rollout_FAILED || rollout.State == deploypb.Rollout_HALTED) && failed == nil {
+			failed = &RolloutFailedError{Release: release.Name, Rollout: rollout.Name, State: rollout.State.String(), Reason: rollout.FailureReason}
+		}
 	}
 
+	if !foundCompletedRollout && failed != nil {
+		return time.Time{}, failed
+	}
 	if !foundCompletedRollout {
 		return time.Time{}, fmt.Errorf("no completed rollouts found for release %s", release.Name)
 	}
 
 	return latestFinishTime, nil
 }
+
+// GetReleaseRollbackTime returns when the release was first rolled back by a
+// Cloud Deploy rollback operation, from the rollouts that rolled back its own, or
+// the zero time if it never was
+func (c *DeployClient) GetReleaseRollbackTime(ctx context.Context, release *Release) (time.Time, error) {
+	if err := c.deployBudget.Spend(); err != nil {
+		return time.Time{}, err
+	}
+	rolloutIt := c.deployClient.ListRollouts(ctx, &deploypb.ListRolloutsRequest{Parent: release.Name}, c.retryOption("ListRollouts"))
+	var rollbacks []string
+	for {
+		rollout, err := rolloutIt.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to list rollouts for release %s: %w", release.Name, err)
+		}
+		rollbacks = append(rollbacks, rollout.RolledBackByRollouts...)
+	}
+
+	// The rollback rollouts belong to the earlier release they rolled back to
+	var rolledBackAt time.Time
+	for _, name := range rollbacks {
+		if err := c.deployBudget.Spend(); err != nil {
+			return time.Time{}, err
+		}
+		rollout, err := c.deployClient.GetRollout(ctx, &deploypb.GetRolloutRequest{Name: name}, c.retryOption("GetRollout"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get rollback rollout %s: %w", name, err)
+		}
+		if rollout.State != deploypb.Rollout_SUCCEEDED || rollout.DeployEndTime == nil {
+			continue
+		}
+		if at := rollout.DeployEndTime.AsTime(); rolledBackAt.IsZero() || at.Before(rolledBackAt) {
+			rolledBackAt = at
+		}
+	}
+	return rolledBackAt, nil
+}
+
+// GetRolloutPhases returns how long the release's successful rollouts spent
+// queued, deploying and verifying on each target, from the rollouts and their job
+// runs. It costs one Cloud Deploy API call, plus one per target rolled out to.
+func (c *DeployClient) GetRolloutPhases(ctx context.Context, release *Release) ([]TargetRollout, error) {
+	if err := c.deployBudget.Spend(); err != nil {
+		return nil, err
+	}
+	rolloutIt := c.deployClient.ListRollouts(ctx, &deploypb.ListRolloutsRequest{Parent: release.Name}, c.retryOption("ListRollouts"))
+	// A target can be rolled out to more than once; the first success is when it went live
+	deployed := make(map[string]*deploypb.Rollout)
+	for {
+		rollout, err := rolloutIt.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list rollouts for release %s: %w", release.Name, err)
+		}
+		if rollout.State != deploypb.Rollout_SUCCEEDED || rollout.DeployEndTime == nil || rollout.RollbackOfRollout != "" {
+			continue
+		}
+		if first, ok := deployed[rollout.TargetId]; !ok || rollout.DeployEndTime.AsTime().Before(first.DeployEndTime.AsTime()) {
+			deployed[rollout.TargetId] = rollout
+		}
+	}
+
+	rollouts := make([]TargetRollout, 0, len(deployed))
+	for _, target := range slices.Sorted(maps.Keys(deployed)) {
+		rollout := deployed[target]
+		if err := c.deployBudget.Spend(); err != nil {
+			return nil, err
+		}
+		jobRunIt := c.deployClient.ListJobRuns(ctx, &deploypb.ListJobRunsRequest{Parent: rollout.Name}, c.retryOption("ListJobRuns"))
+		var jobRuns []*deploypb.JobRun
+		for {
+			jobRun, err := jobRunIt.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list job runs for rollout %s: %w", rollout.Name, err)
+			}
+			jobRuns = append(jobRuns, jobRun)
+		}
+		rollouts = append(rollouts, targetRollout(rollout, jobRuns))
+	}
+	return rollouts, nil
+}
+
+// targetRollout times a rollout's phases. Its job runs, one per job of each of its
+// phases (and each retry), say how long deploying and verifying took; without
+// them, all of the time it spent deploying counts as deploying.
+func targetRollout(rollout *deploypb.Rollout, jobRuns []*deploypb.JobRun) TargetRollout {
+	timed := TargetRollout{Target: rollout.TargetId}
+	if rollout.CreateTime != nil && rollout.DeployStartTime != nil {
+		timed.QueueTime = rollout.DeployStartTime.AsTime().Sub(rollout.CreateTime.AsTime())
+	}
+
+	for _, jobRun := range jobRuns {
+		if jobRun.StartTime == nil || jobRun.EndTime == nil {
+			continue
+		}
+		took := jobRun.EndTime.AsTime().Sub(jobRun.StartTime.AsTime())
+		if jobRun.JobId == "verify" {
+			timed.VerifyTime += took
+		} else {
+			timed.DeployTime += took
+		}
+	}
+	if timed.DeployTime == 0 && timed.VerifyTime == 0 && rollout.DeployStartTime != nil && rollout.DeployEndTime != nil {
+		timed.DeployTime = rollout.DeployEndTime.AsTime().Sub(rollout.DeployStartTime.AsTime())
+	}
+	return timed
+}
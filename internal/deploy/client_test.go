@@ -0,0 +1,57 @@
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/deploy/apiv1/deploypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestConvertRelease(t *testing.T) {
+	created := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	release := &deploypb.Release{
+		Name:                     "projects/p/locations/us-east4/deliveryPipelines/test-web/releases/rel-1",
+		Annotations:              map[string]string{"git-sha": "abc123"},
+		CreateTime:               timestamppb.New(created),
+		RenderState:              deploypb.Release_SUCCEEDED,
+		DeliveryPipelineSnapshot: &deploypb.DeliveryPipeline{Name: "test-web"},
+		TargetSnapshots:          []*deploypb.Target{{Name: "test"}},
+	}
+
+	converted := convertRelease(release)
+	if converted.Name != release.Name || converted.Annotations["git-sha"] != "abc123" || !converted.CreateTime.Equal(created) || converted.RenderState != "SUCCEEDED" {
+		t.Errorf("Expected the fields processing reads to be kept, got %+v", converted)
+	}
+}
+
+func TestTargetRollout(t *testing.T) {
+	created := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) *timestamppb.Timestamp {
+		return timestamppb.New(created.Add(time.Duration(minutes) * time.Minute))
+	}
+	rollout := &deploypb.Rollout{
+		TargetId:        "prod",
+		CreateTime:      at(0),
+		DeployStartTime: at(20),
+		DeployEndTime:   at(40),
+	}
+	jobRuns := []*deploypb.JobRun{
+		{JobId: "predeploy", StartTime: at(20), EndTime: at(22)},
+		{JobId: "deploy", StartTime: at(22), EndTime: at(30)},
+		{JobId: "verify", StartTime: at(30), EndTime: at(40)},
+		// Still running, so not counted
+		{JobId: "postdeploy", StartTime: at(40)},
+	}
+
+	got := targetRollout(rollout, jobRuns)
+	if got.Target != "prod" || got.QueueTime != 20*time.Minute || got.DeployTime != 10*time.Minute || got.VerifyTime != 10*time.Minute {
+		t.Errorf("Expected prod queued 20m, deploying 10m and verifying 10m, got %+v", got)
+	}
+
+	// Without job runs, all of the deploying time is deploying
+	got = targetRollout(rollout, nil)
+	if got.DeployTime != 20*time.Minute || got.VerifyTime != 0 {
+		t.Errorf("Expected 20m deploying and no verifying, got %+v", got)
+	}
+}
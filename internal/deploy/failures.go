@@ -0,0 +1,75 @@
+package deploy
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+)
+
+// Why a release didn't deploy, as DeploymentMetric.FailureState gives it. Failed
+// rollouts are given as ROLLOUT_ followed by the rollout's state.
+const (
+	FailureRender    = "RENDER_FAILED" // Rendering the release's manifests failed, so it was never rolled out
+	FailureAbandoned = "ABANDONED"     // The release was abandoned before any of its rollouts succeeded
+)
+
+// RolloutFailedError is returned by GetRolloutCompletion when none of a release's
+// rollouts succeeded and at least one of them failed
+type RolloutFailedError struct {
+	Release string // The release's resource name
+	Rollout string // The failed rollout's resource name
+	State   string // The rollout's state: FAILED or HALTED
+	Reason  string // Why it failed, if the provider said
+}
+
+func (e *RolloutFailedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("rollout %s of release %s %s", path.Base(e.Rollout), e.Release, e.State)
+	}
+	return fmt.Sprintf("rollout %s of release %s %s: %s", path.Base(e.Rollout), e.Release, e.State, e.Reason)
+}
+
+// PipelineFailures is how many of a delivery pipeline's (or app's, service's or
+// site's) releases deployed, and how many failed to
+type PipelineFailures struct {
+	Pipeline    string // The pipeline's ID, the last part of its resource name
+	Deployments int    // Releases that deployed or failed to
+	Failed      int
+}
+
+// Rate returns the share of the pipeline's releases that failed to deploy: its
+// change failure rate
+func (p PipelineFailures) Rate() float64 {
+	if p.Deployments == 0 {
+		return 0
+	}
+	return float64(p.Failed) / float64(p.Deployments)
+}
+
+// SummarizeFailures counts each pipeline's failed deployments, in pipeline order,
+// along with the totals across all of them
+func SummarizeFailures(results []DeploymentMetric) (byPipeline []PipelineFailures, total PipelineFailures) {
+	counts := make(map[string]*PipelineFailures)
+	for _, result := range results {
+		pipeline := path.Base(releasePipeline(result.ReleaseName))
+		p, ok := counts[pipeline]
+		if !ok {
+			p = &PipelineFailures{Pipeline: pipeline}
+			counts[pipeline] = p
+		}
+		p.Deployments++
+		total.Deployments++
+		if !result.DeploymentSuccessful {
+			p.Failed++
+			total.Failed++
+		}
+	}
+	for _, p := range counts {
+		byPipeline = append(byPipeline, *p)
+	}
+	slices.SortFunc(byPipeline, func(a, b PipelineFailures) int {
+		return cmp.Compare(a.Pipeline, b.Pipeline)
+	})
+	return byPipeline, total
+}
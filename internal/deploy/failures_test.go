@@ -0,0 +1,83 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingClient deploys each release an hour after it was created, unless it
+// says otherwise
+type failingClient map[string]error
+
+func (c failingClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*Release, error) {
+	return nil, nil
+}
+
+func (c failingClient) ExtractSource(ctx context.Context, release *Release) (string, string, time.Time, error) {
+	return "abc123", "", release.CreateTime.Add(-time.Hour), nil
+}
+
+func (c failingClient) GetRolloutCompletion(ctx context.Context, release *Release) (time.Time, error) {
+	if err := c[release.Name]; err != nil {
+		return time.Time{}, err
+	}
+	return release.CreateTime.Add(time.Hour), nil
+}
+
+func TestProcessDeploymentsFailures(t *testing.T) {
+	created := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	release := func(pipeline, id string) *Release {
+		return &Release{
+			Name:        "projects/p/locations/us-east4/deliveryPipelines/" + pipeline + "/releases/" + id,
+			CreateTime:  created,
+			RenderState: "SUCCEEDED",
+		}
+	}
+	deployed := release("test-web", "deployed")
+	renderFailed := release("test-web", "render-failed")
+	renderFailed.RenderState, renderFailed.RenderFailure = "FAILED", "test: skaffold render failed"
+	rolloutFailed := release("test-web", "rollout-failed")
+	abandoned := release("test-api", "abandoned")
+	abandoned.Abandoned = true
+	pending := release("test-api", "pending")
+
+	client := failingClient{
+		rolloutFailed.Name: &RolloutFailedError{Release: rolloutFailed.Name, Rollout: rolloutFailed.Name + "/rollouts/r1", State: "FAILED", Reason: "deploy job failed"},
+		abandoned.Name:     errors.New("no completed rollouts found"),
+		pending.Name:       errors.New("no completed rollouts found"),
+	}
+	results := ProcessDeployments(context.Background(), client, []*Release{deployed, renderFailed, rolloutFailed, abandoned, pending}, nil, nil)
+
+	want := map[string]struct{ state, message string }{
+		"deployed":       {"", ""},
+		"render-failed":  {FailureRender, "test: skaffold render failed"},
+		"rollout-failed": {"ROLLOUT_FAILED", "deploy job failed"},
+		"abandoned":      {FailureAbandoned, ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, leaving out the release still rolling out, got %d", len(want), len(results))
+	}
+	for _, result := range results {
+		w := want[result.ReleaseID]
+		if result.FailureState != w.state || result.FailureMessage != w.message || result.DeploymentSuccessful != (w.state == "") {
+			t.Errorf("Expected %s to have failure %q (%q), got %q (%q), successful %v", result.ReleaseID, w.state, w.message, result.FailureState, result.FailureMessage, result.DeploymentSuccessful)
+		}
+		if result.DeploymentSuccessful && result.CommitToDeployLatency != 2*time.Hour {
+			t.Errorf("Expected %s to take 2h from commit to deploy, got %v", result.ReleaseID, result.CommitToDeployLatency)
+		}
+		if !result.DeploymentSuccessful && (!result.ReleaseFinishTime.IsZero() || result.CommitToDeployLatency != 0) {
+			t.Errorf("Expected failed release %s not to have a finish time or latency", result.ReleaseID)
+		}
+	}
+
+	byPipeline, total := SummarizeFailures(results)
+	if total.Deployments != 4 || total.Failed != 3 || total.Rate() != 0.75 {
+		t.Errorf("Unexpected totals %+v", total)
+	}
+	if len(byPipeline) != 2 || byPipeline[0] != (PipelineFailures{Pipeline: "test-api", Deployments: 1, Failed: 1}) ||
+		byPipeline[1] != (PipelineFailures{Pipeline: "test-web", Deployments: 3, Failed: 2}) {
+		t.Errorf("Unexpected failures by pipeline %+v", byPipeline)
+	}
+}
@@ -1,25 +1,55 @@
 package deploy
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
+	"regexp"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/deploy/apiv1/deploypb"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/businesshours"
+	"github.com/reillywatson/statstracker/internal/progress"
 )
 
-// DeployClientInterface defines the interface for deploy operations
-type DeployClientInterface interface {
-	FetchTestEnvironmentReleases(startDate, endDate time.Time) ([]*deploypb.Release, error)
-	ExtractCommitSHAFromRelease(release *deploypb.Release) (string, string, time.Time, error)
-	GetReleaseFinishTime(release *deploypb.Release) (time.Time, error)
+// CommitTimeFunc looks up when a deployed commit was made, for providers whose
+// releases only name the commit, usually in the services' GitHub repository
+type CommitTimeFunc func(ctx context.Context, sha string) (time.Time, error)
+
+var (
+	// Squash merges on GitHub end the title with the PR, e.g. "Add search (#123)"
+	squashPattern = regexp.MustCompile(`\(#(\d+)\)$`)
+	// Merge commits start "Merge pull request #123 from ..."
+	mergePattern = regexp.MustCompile(`^Merge pull request #(\d+)\b`)
+)
+
+// PRFromCommitMessage reads the PR a commit merged from the first line of its
+// message, if it's the kind of message GitHub writes for a merged PR, for providers
+// that only know the deployed commit
+func PRFromCommitMessage(message string) string {
+	title, _, _ := strings.Cut(message, "\n")
+	title = strings.TrimSpace(title)
+	if matches := squashPattern.FindStringSubmatch(title); matches != nil {
+		return matches[1]
+	}
+	if matches := mergePattern.FindStringSubmatch(title); matches != nil {
+		return matches[1]
+	}
+	return ""
 }
 
-// ProcessDeployments analyzes releases and calculates commit-to-deploy latency
-func ProcessDeployments(client DeployClientInterface, releases []*deploypb.Release) []DeploymentMetric {
+// ProcessDeployments analyzes releases and calculates commit-to-deploy latency,
+// in business hours if schedule is set. Releases that failed to render, were
+// abandoned before they deployed or whose rollouts failed are included too, with
+// DeploymentSuccessful false and why they failed. Each release processed is
+// counted by reporter, if it's not nil.
+func ProcessDeployments(ctx context.Context, provider DeployProvider, releases []*Release, schedule *businesshours.Schedule, reporter *progress.Reporter) []DeploymentMetric {
 	var results []DeploymentMetric
 
+	reporter.Start(len(releases))
+	defer reporter.Finish()
+
 	for _, release := range releases {
 		// Extract release ID from the full name
 		// Format: projects/PROJECT/locations/REGION/deliveryPipelines/PIPELINE/releases/RELEASE_ID
@@ -30,49 +60,81 @@ func ProcessDeployments(client DeployClientInterface, releases []*deploypb.Relea
 		}
 
 		// Extract commit SHA and commit time
-		commitSHA, prNumber, commitTime, err := client.ExtractCommitSHAFromRelease(release)
+		commitSHA, prNumber, commitTime, err := provider.ExtractSource(ctx, release)
+		if errors.Is(err, budget.ErrExhausted) {
+			// Stop gracefully; the caller reports the results as partial
+			log.Printf("Stopping at release %s: %v", releaseID, err)
+			break
+		}
 		if err != nil {
 			log.Printf("Error extracting commit SHA for release %s: %v", releaseID, err)
+			reporter.Increment()
 			continue
 		}
-		fmt.Println("Release ID:", releaseID, "Commit SHA:", commitSHA, "PR Number:", prNumber, "Commit Time:", commitTime)
 
-		releaseStartTime := release.CreateTime.AsTime()
+		result := DeploymentMetric{
+			ReleaseID:        releaseID,
+			ReleaseName:      release.Name,
+			Region:           releaseRegion(release.Name),
+			CommitSHA:        commitSHA,
+			PRNumber:         prNumber,
+			CommitTime:       commitTime,
+			ReleaseStartTime: release.CreateTime,
+			RenderTime:       release.RenderTime,
+		}
+
+		// A release that failed to render was never rolled out
+		if release.RenderState == "FAILED" {
+			result.FailureState = FailureRender
+			result.FailureMessage = release.RenderFailure
+			results = append(results, result)
+			reporter.Increment()
+			continue
+		}
 
 		// Get release finish time (when the last rollout completed)
-		releaseFinishTime, err := client.GetReleaseFinishTime(release)
-		if err != nil {
+		releaseFinishTime, err := provider.GetRolloutCompletion(ctx, release)
+		if errors.Is(err, budget.ErrExhausted) {
+			log.Printf("Stopping at release %s: %v", releaseID, err)
+			break
+		}
+		var rolloutFailed *RolloutFailedError
+		switch {
+		case errors.As(err, &rolloutFailed):
+			result.FailureState = "ROLLOUT_" + rolloutFailed.State
+			result.FailureMessage = rolloutFailed.Reason
+		case err != nil && release.Abandoned:
+			result.FailureState = FailureAbandoned
+		case err != nil:
 			log.Printf("Error getting release finish time for release %s: %v", releaseID, err)
 			// Skip this release as it hasn't finished deploying
+			reporter.Increment()
 			continue
+		default:
+			result.ReleaseFinishTime = releaseFinishTime
+			result.DeploymentSuccessful = true
+
+			// Calculate commit-to-deploy latency
+			result.CommitToDeployLatency = releaseFinishTime.Sub(commitTime)
+			if schedule != nil {
+				result.CommitToDeployLatency = schedule.Between(commitTime, releaseFinishTime)
+			}
 		}
 
-		// Calculate commit-to-deploy latency
-		commitToDeployLatency := releaseFinishTime.Sub(commitTime)
-
-		results = append(results, DeploymentMetric{
-			ReleaseID:             releaseID,
-			ReleaseName:           release.Name,
-			CommitSHA:             commitSHA,
-			PRNumber:              prNumber,
-			CommitTime:            commitTime,
-			ReleaseStartTime:      releaseStartTime,
-			ReleaseFinishTime:     releaseFinishTime,
-			CommitToDeployLatency: commitToDeployLatency,
-			DeploymentSuccessful:  true,
-		})
+		results = append(results, result)
+		reporter.Increment()
 	}
 
 	return results
 }
 
-// CalculatePRDeploymentStats groups deployments by PR number and calculates statistics
+// CalculatePRDeploymentStats groups successful deployments by PR number and calculates statistics
 func CalculatePRDeploymentStats(deployments []DeploymentMetric) []PRDeploymentStats {
 	prMap := make(map[string][]DeploymentMetric)
 
 	// Group deployments by PR number
 	for _, deployment := range deployments {
-		if deployment.PRNumber != "" { // Only include PR deployments
+		if deployment.PRNumber != "" && deployment.DeploymentSuccessful { // Only include PR deployments
 			prMap[deployment.PRNumber] = append(prMap[deployment.PRNumber], deployment)
 		}
 	}
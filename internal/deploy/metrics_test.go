@@ -10,32 +10,42 @@ func TestCalculatePRDeploymentStats(t *testing.T) {
 	now := time.Now()
 	deployments := []DeploymentMetric{
 		{
-			ReleaseID:         "release-1",
-			CommitSHA:         "abc123",
-			PRNumber:          "123",
-			CommitTime:        now.Add(-2 * time.Hour),
-			ReleaseFinishTime: now.Add(-1 * time.Hour),
+			ReleaseID:            "release-1",
+			CommitSHA:            "abc123",
+			PRNumber:             "123",
+			CommitTime:           now.Add(-2 * time.Hour),
+			ReleaseFinishTime:    now.Add(-1 * time.Hour),
+			DeploymentSuccessful: true,
 		},
 		{
-			ReleaseID:         "release-2",
-			CommitSHA:         "def456",
-			PRNumber:          "123", // Same PR
-			CommitTime:        now.Add(-1 * time.Hour),
-			ReleaseFinishTime: now,
+			ReleaseID:            "release-2",
+			CommitSHA:            "def456",
+			PRNumber:             "123", // Same PR
+			CommitTime:           now.Add(-1 * time.Hour),
+			ReleaseFinishTime:    now,
+			DeploymentSuccessful: true,
 		},
 		{
-			ReleaseID:         "release-3",
-			CommitSHA:         "ghi789",
-			PRNumber:          "456", // Different PR
-			CommitTime:        now.Add(-30 * time.Minute),
-			ReleaseFinishTime: now.Add(-15 * time.Minute),
+			ReleaseID:            "release-3",
+			CommitSHA:            "ghi789",
+			PRNumber:             "456", // Different PR
+			CommitTime:           now.Add(-30 * time.Minute),
+			ReleaseFinishTime:    now.Add(-15 * time.Minute),
+			DeploymentSuccessful: true,
 		},
 		{
-			ReleaseID:         "release-4",
-			CommitSHA:         "jkl012",
-			PRNumber:          "", // Not a PR deployment
-			CommitTime:        now.Add(-45 * time.Minute),
-			ReleaseFinishTime: now.Add(-30 * time.Minute),
+			ReleaseID:            "release-4",
+			CommitSHA:            "jkl012",
+			PRNumber:             "", // Not a PR deployment
+			CommitTime:           now.Add(-45 * time.Minute),
+			ReleaseFinishTime:    now.Add(-30 * time.Minute),
+			DeploymentSuccessful: true,
+		}, {
+			ReleaseID:    "release-5",
+			CommitSHA:    "mno345",
+			PRNumber:     "456", // Failed to deploy, so not counted
+			CommitTime:   now.Add(-10 * time.Minute),
+			FailureState: FailureRender,
 		},
 	}
 
@@ -86,4 +96,11 @@ func TestCalculatePRDeploymentStats(t *testing.T) {
 	if pr123Stats.FirstToLastDelta != expectedDelta {
 		t.Errorf("Expected first to last delta %v, got %v", expectedDelta, pr123Stats.FirstToLastDelta)
 	}
+
+	// PR 456's failed deployment isn't counted
+	for _, pr := range stats {
+		if pr.PRNumber == "456" && pr.DeploymentCount != 1 {
+			t.Errorf("Expected PR 456 to have 1 successful deployment, got %d", pr.DeploymentCount)
+		}
+	}
 }
@@ -1,11 +1,32 @@
 package deploy
 
-import "time"
+import (
+	"path"
+	"time"
+)
+
+// Release is a release as the deploy clients return it: just the fields processing
+// reads, converted from the provider's own type as it's fetched. Cloud Deploy's
+// releases as listed carry snapshots of their pipeline and targets and the
+// rendered manifests' details, which are most of their size, and every release in
+// the range is held until it's been processed.
+type Release struct {
+	Name        string            // Resource name, .../deliveryPipelines/<pipeline>/releases/<id> for Cloud Deploy
+	CreateTime  time.Time         // When the release was created, which is when deploying it started
+	Annotations map[string]string // Cloud Deploy's name the commit the release deploys
+	Labels      map[string]string
+	RenderState string // SUCCEEDED, FAILED or IN_PROGRESS for Cloud Deploy, "" for other providers
+
+	Abandoned     bool          // Whether the release was abandoned, so it can't be rolled out any more
+	RenderFailure string        // Why rendering the release failed, if it did
+	RenderTime    time.Duration // How long rendering the release's manifests took, 0 if unknown
+}
 
 // DeploymentMetric represents the commit-to-deploy latency for a single deployment
 type DeploymentMetric struct {
 	ReleaseID             string
 	ReleaseName           string
+	Region                string // Cloud Deploy region the release's pipeline is in, "" for other providers
 	CommitSHA             string
 	PRNumber              string // PR number from pull-<number>_<SHA> format, empty if not a PR deployment
 	CommitTime            time.Time
@@ -13,6 +34,44 @@ type DeploymentMetric struct {
 	ReleaseFinishTime     time.Time // Time when the last rollout completed
 	CommitToDeployLatency time.Duration
 	DeploymentSuccessful  bool
+	Hotfix                bool              // Whether the deployed PR carries a hotfix label
+	Priority              string            // The deployed PR's priority level, "" if it has none
+	Extra                 map[string]string // Fields added by a hook program, if any
+
+	// Release health, from the Sentry release the deployment shipped, if matched
+	SentryRelease string  // The release's version, "" if none matched
+	HasHealthData bool    // Whether the release sent sessions, which CrashFreeRate needs
+	CrashFreeRate float64 // Percentage of the release's sessions that didn't crash
+	NewIssues     int     // Issues first seen in the release
+
+	// Error rate around the deployment going live, if measured
+	ErrorRateMeasured  bool    // Whether the error rate was read for the windows either side of the deployment
+	ErrorRateBefore    float64 // Mean error rate over the window before
+	ErrorRateAfter     float64 // Mean error rate over the window after
+	ErrorRateRegressed bool    // Whether the error rate rose significantly, making the deployment a failed change
+
+	// Incidents opened on the deployment's service soon after it went live, if read
+	FollowingIncidents []string // Each as "#<number> <title>"
+
+	// Rollbacks, to an earlier commit or by a Cloud Deploy rollback operation
+	Rollback       bool          // Whether the deployment rolled its pipeline back to an earlier commit
+	RolledBack     bool          // Whether the deployment was later rolled back
+	TimeToRollback time.Duration // How long the deployment was live before it was rolled back
+
+	// Failure, for releases that didn't deploy; DeploymentSuccessful is false for them
+	FailureState   string // FailureRender, FailureAbandoned, or ROLLOUT_ and the failed rollout's state, "" if it deployed
+	FailureMessage string // Why it failed, if the provider said
+
+	// Where the deployment's time went, for Cloud Deploy
+	RenderTime time.Duration   // How long rendering the release took
+	Rollouts   []TargetRollout // Each target's successful rollout, if they were timed
+}
+
+// Service returns what the deployment deployed, from its release name: a Cloud
+// Deploy delivery pipeline, or the app, service, site or application for other
+// providers
+func (m DeploymentMetric) Service() string {
+	return path.Base(releasePipeline(m.ReleaseName))
 }
 
 // PRDeploymentStats represents statistics for deployments of a specific PR
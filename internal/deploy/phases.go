@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// TargetRollout is how long a release's rollout to one target spent in each phase
+// after the release was rendered
+type TargetRollout struct {
+	Target     string
+	QueueTime  time.Duration // From the rollout being created to it starting to deploy, waiting for approval included
+	DeployTime time.Duration // Running the deploy job, along with any predeploy and postdeploy hooks
+	VerifyTime time.Duration // Running the verify job, 0 if the target doesn't verify deployments
+}
+
+// PhaseTimer looks up how long each of a release's successful rollouts spent in
+// each phase, as Cloud Deploy's rollouts and their job runs record
+type PhaseTimer interface {
+	GetRolloutPhases(ctx context.Context, release *Release) ([]TargetRollout, error)
+}
+
+// CheckRolloutPhases asks timer how long each successful deployment's rollouts
+// spent in each phase. It stops early, returning the error, if the timer's API
+// budget runs out.
+func CheckRolloutPhases(ctx context.Context, timer PhaseTimer, results []DeploymentMetric) error {
+	for i, result := range results {
+		if !result.DeploymentSuccessful || result.Rollouts != nil {
+			continue
+		}
+		rollouts, err := timer.GetRolloutPhases(ctx, &Release{Name: result.ReleaseName})
+		if errors.Is(err, budget.ErrExhausted) {
+			return err
+		}
+		if err != nil {
+			log.Printf("Error timing the rollouts of release %s: %v", result.ReleaseID, err)
+			continue
+		}
+		results[i].Rollouts = rollouts
+	}
+	return nil
+}
+
+// PhaseStats is how long a target's rollouts took in each phase, in the median
+type PhaseStats struct {
+	Target     string
+	Rollouts   int
+	QueueTime  time.Duration
+	DeployTime time.Duration
+	VerifyTime time.Duration
+}
+
+// SummarizeRolloutPhases returns the median time successful deployments took to
+// render, and how long their rollouts to each target took in each phase, in
+// target order
+func SummarizeRolloutPhases(results []DeploymentMetric) (renderTime time.Duration, byTarget []PhaseStats) {
+	var renderTimes []time.Duration
+	type phaseTimes struct{ queue, deploy, verify []time.Duration }
+	targets := make(map[string]*phaseTimes)
+	for _, result := range results {
+		if !result.DeploymentSuccessful {
+			continue
+		}
+		if result.RenderTime > 0 {
+			renderTimes = append(renderTimes, result.RenderTime)
+		}
+		for _, rollout := range result.Rollouts {
+			times, ok := targets[rollout.Target]
+			if !ok {
+				times = &phaseTimes{}
+				targets[rollout.Target] = times
+			}
+			times.queue = append(times.queue, rollout.QueueTime)
+			times.deploy = append(times.deploy, rollout.DeployTime)
+			times.verify = append(times.verify, rollout.VerifyTime)
+		}
+	}
+
+	for target, times := range targets {
+		byTarget = append(byTarget, PhaseStats{
+			Target:     target,
+			Rollouts:   len(times.queue),
+			QueueTime:  stats.MedianOf(times.queue),
+			DeployTime: stats.MedianOf(times.deploy),
+			VerifyTime: stats.MedianOf(times.verify),
+		})
+	}
+	slices.SortFunc(byTarget, func(a, b PhaseStats) int {
+		return cmp.Compare(a.Target, b.Target)
+	})
+	return stats.MedianOf(renderTimes), byTarget
+}
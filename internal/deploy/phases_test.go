@@ -0,0 +1,69 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+type phaseTimer map[string][]TargetRollout
+
+func (t phaseTimer) GetRolloutPhases(ctx context.Context, release *Release) ([]TargetRollout, error) {
+	if release.Name == "exhausted" {
+		return nil, budget.ErrExhausted
+	}
+	return t[release.Name], nil
+}
+
+func TestRolloutPhases(t *testing.T) {
+	results := []DeploymentMetric{
+		{ReleaseName: "rel-1", DeploymentSuccessful: true, RenderTime: 2 * time.Minute},
+		{ReleaseName: "rel-2", DeploymentSuccessful: true, RenderTime: 4 * time.Minute},
+		// Failed deployments aren't timed
+		{ReleaseName: "rel-3", RenderTime: time.Hour},
+	}
+	timer := phaseTimer{
+		"rel-1": {
+			{Target: "staging", QueueTime: time.Minute, DeployTime: 5 * time.Minute},
+			{Target: "prod", QueueTime: time.Hour, DeployTime: 10 * time.Minute, VerifyTime: 5 * time.Minute},
+		},
+		"rel-2": {
+			{Target: "staging", QueueTime: 3 * time.Minute, DeployTime: 7 * time.Minute},
+		},
+		"rel-3": {
+			{Target: "staging", QueueTime: time.Hour},
+		},
+	}
+
+	if err := CheckRolloutPhases(context.Background(), timer, results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results[0].Rollouts) != 2 || results[2].Rollouts != nil {
+		t.Errorf("Expected rel-1's two rollouts to be timed and rel-3's not, got %+v and %+v", results[0].Rollouts, results[2].Rollouts)
+	}
+
+	renderTime, byTarget := SummarizeRolloutPhases(results)
+	if renderTime != 3*time.Minute {
+		t.Errorf("Expected a median render time of 3m, got %v", renderTime)
+	}
+	want := []PhaseStats{
+		{Target: "prod", Rollouts: 1, QueueTime: time.Hour, DeployTime: 10 * time.Minute, VerifyTime: 5 * time.Minute},
+		{Target: "staging", Rollouts: 2, QueueTime: 2 * time.Minute, DeployTime: 6 * time.Minute},
+	}
+	if len(byTarget) != len(want) {
+		t.Fatalf("Expected %d targets, got %+v", len(want), byTarget)
+	}
+	for i := range want {
+		if byTarget[i] != want[i] {
+			t.Errorf("Target %d: expected %+v, got %+v", i, want[i], byTarget[i])
+		}
+	}
+
+	exhausted := []DeploymentMetric{{ReleaseName: "exhausted", DeploymentSuccessful: true}}
+	if err := CheckRolloutPhases(context.Background(), timer, exhausted); !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected the budget error, got %v", err)
+	}
+}
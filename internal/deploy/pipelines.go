@@ -0,0 +1,118 @@
+package deploy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/deploy/apiv1/deploypb"
+)
+
+// DefaultPipelineRegex matches the pipelines counted when no selection is made:
+// those with "test" anywhere in their ID
+var DefaultPipelineRegex = regexp.MustCompile(`(?i)test`)
+
+// PipelineSelector chooses the Cloud Deploy delivery pipelines whose releases count.
+// A pipeline must match everything that's set; if nothing is, pipelines whose ID
+// matches DefaultPipelineRegex are selected.
+type PipelineSelector struct {
+	Regex       *regexp.Regexp    // Matched against the pipeline's ID, e.g. qa-web, not its full resource name
+	Labels      map[string]string // Labels the pipeline must have; an empty value matches any value
+	Annotations map[string]string // Annotations the pipeline must have; an empty value matches any value
+}
+
+// ParsePipelineSelector builds a selector from a regular expression and
+// comma-separated key=value lists of labels and annotations, any of which may be
+// empty. A key without "=value" only needs to be present.
+func ParsePipelineSelector(regex, labels, annotations string) (PipelineSelector, error) {
+	var s PipelineSelector
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return s, fmt.Errorf("invalid pipeline regex: %w", err)
+		}
+		s.Regex = re
+	}
+	var err error
+	if s.Labels, err = parseKeyValues(labels); err != nil {
+		return s, fmt.Errorf("invalid pipeline labels: %w", err)
+	}
+	if s.Annotations, err = parseKeyValues(annotations); err != nil {
+		return s, fmt.Errorf("invalid pipeline annotations: %w", err)
+	}
+	return s, nil
+}
+
+// parseKeyValues parses a comma-separated list of key=value pairs, or bare keys
+func parseKeyValues(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if key == "" {
+			return nil, fmt.Errorf("missing key in %q", pair)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// Matches reports whether the selector chooses the pipeline
+func (s PipelineSelector) Matches(pipeline *deploypb.DeliveryPipeline) bool {
+	if re := s.regex(); re != nil && !re.MatchString(path.Base(pipeline.GetName())) {
+		return false
+	}
+	return hasAll(pipeline.GetLabels(), s.Labels) && hasAll(pipeline.GetAnnotations(), s.Annotations)
+}
+
+// regex returns the regular expression pipeline IDs must match, if any
+func (s PipelineSelector) regex() *regexp.Regexp {
+	if s.Regex == nil && len(s.Labels) == 0 && len(s.Annotations) == 0 {
+		return DefaultPipelineRegex
+	}
+	return s.Regex
+}
+
+// hasAll reports whether have has every key in want, with the wanted value if one is given
+func hasAll(have, want map[string]string) bool {
+	for key, value := range want {
+		got, ok := have[key]
+		if !ok || (value != "" && got != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the selector, for messages about what it matched
+func (s PipelineSelector) String() string {
+	var parts []string
+	if re := s.regex(); re != nil {
+		parts = append(parts, fmt.Sprintf("ID matching %s", re))
+	}
+	if len(s.Labels) > 0 {
+		parts = append(parts, "labels "+formatKeyValues(s.Labels))
+	}
+	if len(s.Annotations) > 0 {
+		parts = append(parts, "annotations "+formatKeyValues(s.Annotations))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// formatKeyValues formats m as ParsePipelineSelector takes it, in key order
+func formatKeyValues(m map[string]string) string {
+	var pairs []string
+	for key, value := range m {
+		if value == "" {
+			pairs = append(pairs, key)
+		} else {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
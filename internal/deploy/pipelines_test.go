@@ -0,0 +1,71 @@
+package deploy
+
+import (
+	"testing"
+
+	"cloud.google.com/go/deploy/apiv1/deploypb"
+)
+
+func pipeline(id string, labels, annotations map[string]string) *deploypb.DeliveryPipeline {
+	return &deploypb.DeliveryPipeline{
+		Name:        "projects/contest-prod/locations/us-east4/deliveryPipelines/" + id,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+func TestPipelineSelector_Default(t *testing.T) {
+	var s PipelineSelector
+	if !s.Matches(pipeline("web-Test", nil, nil)) {
+		t.Errorf("Expected the default selector to match a pipeline with test in its ID")
+	}
+	// Only the pipeline's ID counts, not the project it's in
+	if s.Matches(pipeline("web-prod", nil, nil)) {
+		t.Errorf("Expected the default selector not to match web-prod in a project named contest-prod")
+	}
+}
+
+func TestPipelineSelector_Regex(t *testing.T) {
+	s, err := ParsePipelineSelector(`^(test|qa)-`, "", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for id, want := range map[string]bool{"qa-web": true, "test-api": true, "contest-service": false, "web-test": false} {
+		if got := s.Matches(pipeline(id, nil, nil)); got != want {
+			t.Errorf("Expected %s to match %v, got %v", id, want, got)
+		}
+	}
+
+	if _, err := ParsePipelineSelector(`(`, "", ""); err == nil {
+		t.Errorf("Expected an error for an invalid regex")
+	}
+}
+
+func TestPipelineSelector_LabelsAndAnnotations(t *testing.T) {
+	s, err := ParsePipelineSelector("", "env=qa, team", "stats/include")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	matching := pipeline("contest-service", map[string]string{"env": "qa", "team": "web"}, map[string]string{"stats/include": "yes"})
+	if !s.Matches(matching) {
+		t.Errorf("Expected a pipeline with the labels and annotation to match, whatever its ID")
+	}
+	if s.Matches(pipeline("qa-web", map[string]string{"env": "prod", "team": "web"}, map[string]string{"stats/include": "yes"})) {
+		t.Errorf("Expected a pipeline with the wrong env label not to match")
+	}
+	if s.Matches(pipeline("qa-web", map[string]string{"env": "qa"}, map[string]string{"stats/include": "yes"})) {
+		t.Errorf("Expected a pipeline without a team label not to match")
+	}
+	if s.Matches(pipeline("qa-web", map[string]string{"env": "qa", "team": "web"}, nil)) {
+		t.Errorf("Expected a pipeline without the annotation not to match")
+	}
+
+	if got, want := s.String(), "labels env=qa,team and annotations stats/include"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if _, err := ParsePipelineSelector("", "=qa", ""); err == nil {
+		t.Errorf("Expected an error for a label without a key")
+	}
+}
@@ -0,0 +1,38 @@
+package deploy
+
+import (
+	"context"
+	"time"
+)
+
+// DeployProvider is a deployment backend: somewhere releases are made and rolled
+// out, which deploy-tracker can measure commit-to-deploy latency from. Google
+// Cloud Deploy (DeployClient) is the first; the others each have their own
+// package, such as internal/heroku. Whatever a backend calls its deployments,
+// they're returned as Releases, so deploy-tracker's commit-to-deploy latency and
+// deployment frequency work the same for every provider. Backend-specific
+// extras, such as Cloud Deploy's rollback operations and rollout phases, are
+// separate interfaces a provider can also implement (RollbackChecker,
+// PhaseTimer).
+//
+// Each method returns an error wrapping budget.ErrExhausted once the provider's
+// API budget is spent.
+type DeployProvider interface {
+	// ListReleases lists the releases created in the date range. If the budget
+	// runs out part way through, the releases found so far are returned along
+	// with the error.
+	ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*Release, error)
+
+	// GetRolloutCompletion returns when the release finished rolling out. If its
+	// rollout failed, the error is a *RolloutFailedError.
+	GetRolloutCompletion(ctx context.Context, release *Release) (time.Time, error)
+
+	// ExtractSource returns the commit the release deployed, the PR that commit
+	// merged if it's known ("" if not), and when the commit was made
+	ExtractSource(ctx context.Context, release *Release) (commitSHA, prNumber string, commitTime time.Time, err error)
+}
+
+var (
+	_ DeployProvider = (*DeployClient)(nil)
+	_ DeployProvider = (*CachedDeployClient)(nil)
+)
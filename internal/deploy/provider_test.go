@@ -0,0 +1,83 @@
+package deploy_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/argocd"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cloudrun"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/harness"
+	"github.com/reillywatson/statstracker/internal/heroku"
+	"github.com/reillywatson/statstracker/internal/netlify"
+	"github.com/reillywatson/statstracker/internal/render"
+	"github.com/reillywatson/statstracker/internal/spinnaker"
+	"github.com/reillywatson/statstracker/internal/vercel"
+)
+
+// budgetedProvider is a DeployProvider whose API calls are capped, as every
+// provider deploy-tracker supports is
+type budgetedProvider interface {
+	deploy.DeployProvider
+	SetBudget(b *budget.Budget)
+}
+
+// Every provider stops with ErrExhausted, before making a request, once its budget is spent
+func TestDeployProviders_Budget(t *testing.T) {
+	// Cloud Run finds credentials when it's created, though it never gets to use them here
+	credentials := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(credentials, []byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", credentials)
+
+	providers := []struct {
+		name string
+		new  func() (budgetedProvider, error)
+	}{
+		{"argocd", func() (budgetedProvider, error) {
+			return argocd.NewArgoCDClient("http://127.0.0.1:0", "token", []string{"shop"}, nil), nil
+		}},
+		{"cloudrun", func() (budgetedProvider, error) {
+			return cloudrun.NewCloudRunClient(context.Background(), "project", []string{"us-east4"}, []string{"shop"}, nil)
+		}},
+		{"harness", func() (budgetedProvider, error) {
+			return harness.NewHarnessClient("api-key", "acct", "eng", "shop", nil), nil
+		}},
+		{"heroku", func() (budgetedProvider, error) {
+			return heroku.NewHerokuClient("api-key", []string{"shop"}, nil), nil
+		}},
+		{"netlify", func() (budgetedProvider, error) {
+			return netlify.NewNetlifyClient("token", []string{"shop"}, nil), nil
+		}},
+		{"render", func() (budgetedProvider, error) {
+			return render.NewRenderClient("api-key", []string{"srv-web"}), nil
+		}},
+		{"spinnaker", func() (budgetedProvider, error) {
+			return spinnaker.NewSpinnakerClient("http://127.0.0.1:0", "", []string{"shop"}, nil), nil
+		}},
+		{"vercel", func() (budgetedProvider, error) {
+			return vercel.NewVercelClient("token", "", []string{"shop"}, nil), nil
+		}},
+	}
+
+	for _, p := range providers {
+		t.Run(p.name, func(t *testing.T) {
+			provider, err := p.new()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := budget.New("API", 1)
+			b.Spend()
+			provider.SetBudget(b)
+			if _, err := provider.ListReleases(context.Background(), time.Now().AddDate(0, 0, -1), time.Now()); !errors.Is(err, budget.ErrExhausted) {
+				t.Errorf("Expected ErrExhausted, got %v", err)
+			}
+		})
+	}
+}
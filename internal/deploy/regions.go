@@ -0,0 +1,54 @@
+package deploy
+
+import (
+	"strings"
+)
+
+// Regions is a repeatable command-line flag of Google Cloud regions, each of
+// which may also be a comma-separated list. The first region given replaces the
+// default rather than adding to it.
+type Regions struct {
+	list []string
+	set  bool
+}
+
+// NewRegions returns the flag holding regions until others are given
+func NewRegions(regions ...string) *Regions {
+	return &Regions{list: regions}
+}
+
+func (r *Regions) String() string {
+	if r == nil {
+		return ""
+	}
+	return strings.Join(r.list, ",")
+}
+
+func (r *Regions) Set(s string) error {
+	if !r.set {
+		r.list, r.set = nil, true
+	}
+	for _, region := range strings.Split(s, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			r.list = append(r.list, region)
+		}
+	}
+	return nil
+}
+
+// List returns the regions
+func (r *Regions) List() []string {
+	return r.list
+}
+
+// releaseRegion returns the region a Cloud Deploy resource is in, from its name,
+// e.g. projects/PROJECT/locations/REGION/deliveryPipelines/PIPELINE/releases/RELEASE_ID
+func releaseRegion(name string) string {
+	parts := strings.Split(name, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "locations" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package deploy
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestRegions(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	regions := NewRegions("us-east4")
+	fs.Var(regions, "region", "")
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := regions.List(); !slices.Equal(got, []string{"us-east4"}) {
+		t.Errorf("Expected the default region, got %v", got)
+	}
+
+	if err := fs.Parse([]string{"-region", "europe-west1", "-region", "us-central1, asia-east1"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"europe-west1", "us-central1", "asia-east1"}
+	if got := regions.List(); !slices.Equal(got, want) {
+		t.Errorf("Expected the given regions in place of the default, %v, got %v", want, got)
+	}
+	if got := regions.String(); got != "europe-west1,us-central1,asia-east1" {
+		t.Errorf("Expected the regions joined with commas, got %q", got)
+	}
+}
+
+func TestReleaseRegion(t *testing.T) {
+	if got := releaseRegion("projects/p/locations/europe-west1/deliveryPipelines/test-web/releases/rel-1"); got != "europe-west1" {
+		t.Errorf("Expected europe-west1, got %q", got)
+	}
+	if got := releaseRegion("shop-web/v42"); got != "" {
+		t.Errorf("Expected no region for another provider's release, got %q", got)
+	}
+}
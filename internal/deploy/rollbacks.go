@@ -0,0 +1,132 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// RollbackChecker looks up when a release was rolled back by an explicit rollback
+// operation, as Cloud Deploy's, returning the zero time if it wasn't
+type RollbackChecker interface {
+	GetReleaseRollbackTime(ctx context.Context, release *Release) (time.Time, error)
+}
+
+// CheckRollbackOperations asks checker whether each successful deployment was
+// rolled back, flagging those that were with how long they were live first. It
+// stops early, returning the error, if the checker's API budget runs out.
+func CheckRollbackOperations(ctx context.Context, checker RollbackChecker, results []DeploymentMetric) error {
+	for i, result := range results {
+		if !result.DeploymentSuccessful || result.ReleaseFinishTime.IsZero() {
+			continue
+		}
+		rolledBackAt, err := checker.GetReleaseRollbackTime(ctx, &Release{Name: result.ReleaseName})
+		if errors.Is(err, budget.ErrExhausted) {
+			return err
+		}
+		if err != nil {
+			log.Printf("Error checking whether release %s was rolled back: %v", result.ReleaseID, err)
+			continue
+		}
+		if !rolledBackAt.IsZero() {
+			markRolledBack(&results[i], rolledBackAt)
+		}
+	}
+	return nil
+}
+
+// MarkRollbacks flags deployments that took their pipeline (or app, service or
+// site) back to an earlier commit than the one it last deployed, and the
+// deployments they rolled back. This finds rollbacks done by redeploying an old
+// release, or making a new one of an old commit, which no provider records as a
+// rollback.
+func MarkRollbacks(results []DeploymentMetric) {
+	byPipeline := make(map[string][]int)
+	for i, result := range results {
+		if result.DeploymentSuccessful && !result.ReleaseFinishTime.IsZero() && !result.CommitTime.IsZero() {
+			pipeline := releasePipeline(result.ReleaseName)
+			byPipeline[pipeline] = append(byPipeline[pipeline], i)
+		}
+	}
+
+	for _, deployed := range byPipeline {
+		slices.SortStableFunc(deployed, func(a, b int) int {
+			return results[a].ReleaseFinishTime.Compare(results[b].ReleaseFinishTime)
+		})
+		for n := 1; n < len(deployed); n++ {
+			previous, current := &results[deployed[n-1]], &results[deployed[n]]
+			if current.CommitSHA != previous.CommitSHA && current.CommitTime.Before(previous.CommitTime) {
+				current.Rollback = true
+				markRolledBack(previous, current.ReleaseFinishTime)
+			}
+		}
+	}
+}
+
+// markRolledBack flags a deployment as rolled back at the given time, unless it
+// already was, by an explicit rollback operation
+func markRolledBack(result *DeploymentMetric, at time.Time) {
+	if result.RolledBack {
+		return
+	}
+	result.RolledBack = true
+	result.TimeToRollback = at.Sub(result.ReleaseFinishTime)
+}
+
+// releasePipeline returns what a release was deployed by, from its name without
+// the final collection and ID, e.g. a Cloud Deploy delivery pipeline's name from
+// projects/PROJECT/locations/REGION/deliveryPipelines/PIPELINE/releases/RELEASE_ID,
+// or a Heroku app's from apps/APP/releases/RELEASE
+func releasePipeline(name string) string {
+	return path.Dir(path.Dir(name))
+}
+
+// RollbackStats summarizes the deployments that were rolled back
+type RollbackStats struct {
+	Deployments          int           // Successful deployments
+	RolledBack           int           // Deployments that were later rolled back
+	Rollbacks            int           // Deployments that rolled back to an earlier commit
+	MeanTimeToRollback   time.Duration // How long rolled back deployments were live, on average
+	MedianTimeToRollback time.Duration
+}
+
+// Rate returns the share of successful deployments that were rolled back
+func (s RollbackStats) Rate() float64 {
+	if s.Deployments == 0 {
+		return 0
+	}
+	return float64(s.RolledBack) / float64(s.Deployments)
+}
+
+// SummarizeRollbacks counts the rolled back deployments MarkRollbacks and
+// CheckRollbackOperations flagged, and how long they were live
+func SummarizeRollbacks(results []DeploymentMetric) RollbackStats {
+	var summary RollbackStats
+	var times []time.Duration
+	var total time.Duration
+	for _, result := range results {
+		if !result.DeploymentSuccessful {
+			continue
+		}
+		summary.Deployments++
+		if result.Rollback {
+			summary.Rollbacks++
+		}
+		if result.RolledBack {
+			summary.RolledBack++
+			times = append(times, result.TimeToRollback)
+			total += result.TimeToRollback
+		}
+	}
+	if len(times) > 0 {
+		summary.MeanTimeToRollback = total / time.Duration(len(times))
+		summary.MedianTimeToRollback = stats.MedianOf(times)
+	}
+	return summary
+}
@@ -0,0 +1,98 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+var rollbackDay = time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+func deployment(pipeline, release, sha string, committedHour, liveHour int) DeploymentMetric {
+	return DeploymentMetric{
+		ReleaseID:            release,
+		ReleaseName:          "projects/p/locations/us-east4/deliveryPipelines/" + pipeline + "/releases/" + release,
+		CommitSHA:            sha,
+		CommitTime:           rollbackDay.Add(time.Duration(committedHour) * time.Hour),
+		ReleaseFinishTime:    rollbackDay.Add(time.Duration(liveHour) * time.Hour),
+		DeploymentSuccessful: true,
+	}
+}
+
+func TestMarkRollbacks(t *testing.T) {
+	results := []DeploymentMetric{
+		deployment("test-web", "rel-3", "ccc", 5, 9), // back to the first commit
+		deployment("test-web", "rel-1", "aaa", 1, 2),
+		deployment("test-web", "rel-2", "bbb", 3, 4),
+		deployment("test-web", "rel-4", "ddd", 8, 10), // a fix, rolling forward
+		// Another pipeline's deployments of older commits aren't rollbacks of test-web's
+		deployment("test-api", "api-1", "eee", 0, 6),
+	}
+	results[0].CommitSHA, results[0].CommitTime = "aaa", results[1].CommitTime
+
+	MarkRollbacks(results)
+
+	for _, result := range results {
+		wantRollback := result.ReleaseID == "rel-3"
+		wantRolledBack := result.ReleaseID == "rel-2"
+		if result.Rollback != wantRollback || result.RolledBack != wantRolledBack {
+			t.Errorf("Expected %s to have Rollback %v and RolledBack %v, got %v and %v", result.ReleaseID, wantRollback, wantRolledBack, result.Rollback, result.RolledBack)
+		}
+	}
+	if got := results[2].TimeToRollback; got != 5*time.Hour {
+		t.Errorf("Expected rel-2 to be live 5h before being rolled back, got %v", got)
+	}
+
+	stats := SummarizeRollbacks(results)
+	if stats.Deployments != 5 || stats.RolledBack != 1 || stats.Rollbacks != 1 || stats.MedianTimeToRollback != 5*time.Hour {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if got := stats.Rate(); got != 0.2 {
+		t.Errorf("Expected a rollback rate of 0.2, got %v", got)
+	}
+}
+
+type rollbackChecker map[string]time.Time
+
+func (c rollbackChecker) GetReleaseRollbackTime(ctx context.Context, release *Release) (time.Time, error) {
+	if release.Name == "exhausted" {
+		return time.Time{}, budget.ErrExhausted
+	}
+	return c[release.Name], nil
+}
+
+func TestCheckRollbackOperations(t *testing.T) {
+	results := []DeploymentMetric{
+		deployment("test-web", "rel-1", "aaa", 1, 2),
+		deployment("test-web", "rel-2", "bbb", 3, 4),
+	}
+	checker := rollbackChecker{results[1].ReleaseName: rollbackDay.Add(4*time.Hour + 30*time.Minute)}
+
+	if err := CheckRollbackOperations(context.Background(), checker, results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if results[0].RolledBack {
+		t.Errorf("Expected rel-1 not to be rolled back")
+	}
+	if !results[1].RolledBack || results[1].TimeToRollback != 30*time.Minute {
+		t.Errorf("Expected rel-2 to be rolled back after 30m, got %v after %v", results[1].RolledBack, results[1].TimeToRollback)
+	}
+
+	// Redeploying rel-1 afterwards is also seen as a rollback, but the rollback
+	// operation's time is kept
+	redeploy := results[0]
+	redeploy.ReleaseFinishTime = rollbackDay.Add(6 * time.Hour)
+	results = append(results, redeploy)
+	MarkRollbacks(results)
+	if !results[2].Rollback || results[1].TimeToRollback != 30*time.Minute {
+		t.Errorf("Expected the redeployment to be a rollback without changing rel-2's time to rollback, got %v", results[1].TimeToRollback)
+	}
+
+	exhausted := []DeploymentMetric{{ReleaseName: "exhausted", ReleaseFinishTime: rollbackDay, DeploymentSuccessful: true}}
+	if err := CheckRollbackOperations(context.Background(), checker, exhausted); !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected the budget error, got %v", err)
+	}
+}
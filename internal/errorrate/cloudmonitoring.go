@@ -0,0 +1,169 @@
+package errorrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	cloudMonitoringAPIBaseURL = "https://monitoring.googleapis.com"
+	monitoringReadScope       = "https://www.googleapis.com/auth/monitoring.read"
+	alignmentPeriod           = "60s"
+)
+
+// CloudMonitoringClient reads error rates from a Cloud Monitoring metric
+type CloudMonitoringClient struct {
+	httpClient *http.Client
+	project    string
+	filter     string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+}
+
+var _ Source = (*CloudMonitoringClient)(nil)
+
+// NewCloudMonitoringClient creates a new client for a Google Cloud project,
+// authenticated with Application Default Credentials, that reads the error rate
+// from the time series matching filter, such as
+// metric.type="logging.googleapis.com/user/http_errors". A counter is read as
+// its rate per second, and the matching series are added together.
+func NewCloudMonitoringClient(ctx context.Context, project, filter string) (*CloudMonitoringClient, error) {
+	authenticated, err := google.DefaultClient(ctx, monitoringReadScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Google Cloud credentials: %w", err)
+	}
+	retrying := retry.NewTransport(authenticated.Transport, retry.DefaultPolicy)
+	return &CloudMonitoringClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		project: project,
+		filter:  filter,
+		baseURL: cloudMonitoringAPIBaseURL,
+		retry:   retrying,
+	}, nil
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *CloudMonitoringClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *CloudMonitoringClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type timeSeries struct {
+	Points []struct {
+		Interval struct {
+			EndTime time.Time `json:"endTime"`
+		} `json:"interval"`
+		Value struct {
+			DoubleValue *float64 `json:"doubleValue"`
+			Int64Value  *string  `json:"int64Value"` // int64s are encoded as strings
+		} `json:"value"`
+	} `json:"points"`
+}
+
+// FetchErrorRate returns the matching time series' values over the time range,
+// aligned to the minute and added together, oldest first
+func (c *CloudMonitoringClient) FetchErrorRate(ctx context.Context, start, end time.Time) ([]Point, error) {
+	query := url.Values{
+		"filter":                         {c.filter},
+		"interval.startTime":             {start.UTC().Format(time.RFC3339)},
+		"interval.endTime":               {end.UTC().Format(time.RFC3339)},
+		"aggregation.alignmentPeriod":    {alignmentPeriod},
+		"aggregation.perSeriesAligner":   {"ALIGN_RATE"},
+		"aggregation.crossSeriesReducer": {"REDUCE_SUM"},
+	}
+
+	var series [][]Point
+	for {
+		var page struct {
+			TimeSeries    []timeSeries `json:"timeSeries"`
+			NextPageToken string       `json:"nextPageToken"`
+		}
+		if err := c.get(ctx, fmt.Sprintf("/v3/projects/%s/timeSeries", url.PathEscape(c.project)), query, &page); err != nil {
+			return nil, fmt.Errorf("failed to read time series: %w", err)
+		}
+		for _, ts := range page.TimeSeries {
+			var points []Point
+			for _, p := range ts.Points {
+				var value float64
+				switch {
+				case p.Value.DoubleValue != nil:
+					value = *p.Value.DoubleValue
+				case p.Value.Int64Value != nil:
+					n, err := strconv.ParseInt(*p.Value.Int64Value, 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("failed to decode point: %w", err)
+					}
+					value = float64(n)
+				default:
+					continue
+				}
+				points = append(points, Point{At: p.Interval.EndTime.UTC(), Value: value})
+			}
+			series = append(series, points)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+	return sumSeries(series), nil
+}
+
+// get makes a Cloud Monitoring API request and decodes the response into result
+func (c *CloudMonitoringClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package errorrate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCloudMonitoringClient_FetchErrorRate(t *testing.T) {
+	start := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/v3/projects/my-project/timeSeries" || query.Get("aggregation.perSeriesAligner") != "ALIGN_RATE" || query.Get("interval.startTime") != "2024-06-03T12:00:00Z" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		if query.Get("pageToken") == "" {
+			w.Write([]byte(`{"timeSeries": [{"points": [
+				{"interval": {"endTime": "2024-06-03T12:01:00Z"}, "value": {"doubleValue": 0.25}},
+				{"interval": {"endTime": "2024-06-03T12:00:00Z"}, "value": {"doubleValue": 0.5}}
+			]}], "nextPageToken": "2"}`))
+			return
+		}
+		w.Write([]byte(`{"timeSeries": [{"points": [
+			{"interval": {"endTime": "2024-06-03T12:01:00Z"}, "value": {"int64Value": "1"}}
+		]}]}`))
+	}))
+	defer server.Close()
+
+	client := &CloudMonitoringClient{httpClient: http.DefaultClient, project: "my-project", filter: `metric.type="errors"`, baseURL: server.URL}
+	points, err := client.FetchErrorRate(context.Background(), start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(points) != 2 || points[0].Value != 0.5 || points[1].Value != 1.25 {
+		t.Errorf("Expected the pages' series summed by minute, oldest first, got %v", points)
+	}
+}
@@ -0,0 +1,136 @@
+package errorrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	// DefaultDatadogSite is Datadog's US1 site
+	DefaultDatadogSite = "datadoghq.com"
+	defaultTimeout     = 30 * time.Second
+)
+
+// DatadogClient reads error rates from a Datadog metric query
+type DatadogClient struct {
+	httpClient *http.Client
+	apiKey     string
+	appKey     string
+	query      string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+}
+
+var _ Source = (*DatadogClient)(nil)
+
+// NewDatadogClient creates a new client for the Datadog site (such as
+// datadoghq.eu), authenticated with an API key and an application key, that reads
+// the error rate from query, such as
+// sum:trace.http.request.errors{service:web}.as_rate(). A query with several
+// series, such as one grouped by host, has them added together.
+func NewDatadogClient(site, apiKey, appKey, query string) *DatadogClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &DatadogClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		apiKey:  apiKey,
+		appKey:  appKey,
+		query:   query,
+		baseURL: "https://api." + site,
+		retry:   retrying,
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *DatadogClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *DatadogClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// FetchErrorRate returns the query's values over the time range, oldest first
+func (c *DatadogClient) FetchErrorRate(ctx context.Context, start, end time.Time) ([]Point, error) {
+	if err := c.budget.Spend(); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"query": {c.query},
+		"from":  {strconv.FormatInt(start.Unix(), 10)},
+		"to":    {strconv.FormatInt(end.Unix(), 10)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/query?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("DD-API-KEY", c.apiKey)
+	req.Header.Set("DD-APPLICATION-KEY", c.appKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, strings.Join(apiErr.Errors, "; "))
+		}
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Series []struct {
+			// Each point is [milliseconds since the epoch, value], with a null value
+			// where there was no data
+			PointList [][2]*float64 `json:"pointlist"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Status == "error" {
+		return nil, fmt.Errorf("query failed: %s", result.Error)
+	}
+
+	var series [][]Point
+	for _, s := range result.Series {
+		var points []Point
+		for _, p := range s.PointList {
+			if p[0] == nil || p[1] == nil {
+				continue
+			}
+			points = append(points, Point{At: time.UnixMilli(int64(*p[0])).UTC(), Value: *p[1]})
+		}
+		series = append(series, points)
+	}
+	return sumSeries(series), nil
+}
@@ -0,0 +1,49 @@
+package errorrate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDatadogClient_FetchErrorRate(t *testing.T) {
+	start := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	ms := func(minutes int) int64 { return start.Add(time.Duration(minutes) * time.Minute).UnixMilli() }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "api" || r.Header.Get("DD-APPLICATION-KEY") != "app" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["Forbidden"]}`))
+			return
+		}
+		query := r.URL.Query()
+		if r.URL.Path != "/api/v1/query" || query.Get("query") != "sum:errors{*} by {host}" || query.Get("from") != fmt.Sprint(start.Unix()) {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		// Two hosts' series, added together; missing values are skipped
+		fmt.Fprintf(w, `{"status": "ok", "series": [
+			{"pointlist": [[%d, 1.5], [%d, 2], [%d, null]]},
+			{"pointlist": [[%d, 0.5], [%d, 1]]}
+		]}`, ms(1), ms(0), ms(2), ms(0), ms(1))
+	}))
+	defer server.Close()
+
+	client := NewDatadogClient("example.com", "api", "app", "sum:errors{*} by {host}")
+	client.baseURL = server.URL
+	points, err := client.FetchErrorRate(context.Background(), start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(points) != 2 || !points[0].At.Equal(start) || points[0].Value != 2.5 || points[1].Value != 2.5 {
+		t.Errorf("Expected the series summed by minute, got %v", points)
+	}
+
+	denied := NewDatadogClient("example.com", "wrong", "app", "sum:errors{*}")
+	denied.baseURL = server.URL
+	if _, err := denied.FetchErrorRate(context.Background(), start, start.Add(time.Hour)); err == nil || !strings.Contains(err.Error(), "status 403: Forbidden") {
+		t.Errorf("Expected Datadog's error, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Package errorrate flags deployments followed by a significant rise in the error
+// rate, read from a monitoring system, as failed changes: a deployment that
+// breaks things often doesn't fail to deploy.
+package errorrate
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+)
+
+// Compare compares the mean error rate in the window before deployedAt with the
+// mean in the window after it. It returns false if either window has no points.
+func Compare(points []Point, deployedAt time.Time, window time.Duration, threshold Threshold) (Comparison, bool) {
+	var before, after []float64
+	for _, p := range points {
+		switch {
+		case p.At.Before(deployedAt) && !p.At.Before(deployedAt.Add(-window)):
+			before = append(before, p.Value)
+		case !p.At.Before(deployedAt) && !p.At.After(deployedAt.Add(window)):
+			after = append(after, p.Value)
+		}
+	}
+	if len(before) == 0 || len(after) == 0 {
+		return Comparison{}, false
+	}
+
+	c := Comparison{Before: mean(before), After: mean(after)}
+	c.Regressed = c.After > c.Before*(1+threshold.Increase) && c.After > c.Before+threshold.Sigmas*stddev(before)
+	return c, true
+}
+
+// CheckDeployments compares the error rate in the window before each successful
+// deployment went live with the window after, recording the result on the
+// deployment. Deployments whose window after hasn't finished yet are skipped. An
+// error reading one deployment's rates is logged and the rest are still checked,
+// unless it wraps budget.ErrExhausted, which stops checking and is returned.
+func CheckDeployments(ctx context.Context, source Source, results []deploy.DeploymentMetric, window time.Duration, threshold Threshold) error {
+	now := time.Now()
+	for i, result := range results {
+		liveAt := result.ReleaseFinishTime
+		if !result.DeploymentSuccessful || liveAt.IsZero() || liveAt.Add(window).After(now) {
+			continue
+		}
+		points, err := source.FetchErrorRate(ctx, liveAt.Add(-window), liveAt.Add(window))
+		if errors.Is(err, budget.ErrExhausted) {
+			return err
+		}
+		if err != nil {
+			log.Printf("Error reading the error rate around release %s: %v", result.ReleaseID, err)
+			continue
+		}
+		comparison, ok := Compare(points, liveAt, window, threshold)
+		if !ok {
+			continue
+		}
+		results[i].ErrorRateMeasured = true
+		results[i].ErrorRateBefore = comparison.Before
+		results[i].ErrorRateAfter = comparison.After
+		results[i].ErrorRateRegressed = comparison.Regressed
+	}
+	return nil
+}
+
+// ChangeFailureRate returns how many successful deployments had their error rate
+// compared, and how many of those were followed by a regression
+func ChangeFailureRate(results []deploy.DeploymentMetric) (failed, measured int) {
+	for _, result := range results {
+		if !result.DeploymentSuccessful || !result.ErrorRateMeasured {
+			continue
+		}
+		measured++
+		if result.ErrorRateRegressed {
+			failed++
+		}
+	}
+	return failed, measured
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// stddev returns the population standard deviation of values
+func stddev(values []float64) float64 {
+	m := mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// sumSeries adds series together point by point, by time, oldest first
+func sumSeries(series [][]Point) []Point {
+	sums := make(map[time.Time]float64)
+	for _, s := range series {
+		for _, p := range s {
+			sums[p.At] += p.Value
+		}
+	}
+	points := make([]Point, 0, len(sums))
+	for at, value := range sums {
+		points = append(points, Point{At: at, Value: value})
+	}
+	slices.SortFunc(points, func(a, b Point) int {
+		return a.At.Compare(b.At)
+	})
+	return points
+}
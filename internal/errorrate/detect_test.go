@@ -0,0 +1,122 @@
+package errorrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+// series returns a point a minute for the window either side of deployedAt,
+// with the rate before and after it
+func series(deployedAt time.Time, before, after []float64) []Point {
+	var points []Point
+	for i, v := range before {
+		points = append(points, Point{At: deployedAt.Add(time.Duration(i-len(before)) * time.Minute), Value: v})
+	}
+	for i, v := range after {
+		points = append(points, Point{At: deployedAt.Add(time.Duration(i) * time.Minute), Value: v})
+	}
+	return points
+}
+
+func TestCompare(t *testing.T) {
+	deployedAt := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		before    []float64
+		after     []float64
+		regressed bool
+	}{
+		{"steady", []float64{1, 1.2, 0.8, 1}, []float64{1.1, 0.9, 1, 1}, false},
+		{"doubled", []float64{1, 1.2, 0.8, 1}, []float64{2, 2.2, 1.8, 2}, true},
+		{"within the noise", []float64{1, 3, 0, 2}, []float64{3, 4, 2, 3}, false},
+		{"from no errors", []float64{0, 0, 0, 0}, []float64{0, 1, 0, 0}, true},
+		{"fell", []float64{2, 2, 2, 2}, []float64{1, 1, 1, 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := Compare(series(deployedAt, tt.before, tt.after), deployedAt, 4*time.Minute, DefaultThreshold)
+			if !ok {
+				t.Fatal("Expected a comparison")
+			}
+			if c.Regressed != tt.regressed {
+				t.Errorf("Expected regressed=%v, got %+v", tt.regressed, c)
+			}
+		})
+	}
+
+	// Points outside the windows are ignored, and an empty window can't be compared
+	points := series(deployedAt, []float64{50, 1}, nil)
+	if _, ok := Compare(points, deployedAt, time.Minute, DefaultThreshold); ok {
+		t.Error("Expected no comparison without points after the deployment")
+	}
+	points = series(deployedAt, []float64{50, 1}, []float64{1})
+	if c, ok := Compare(points, deployedAt, time.Minute, DefaultThreshold); !ok || c.Before != 1 || c.Regressed {
+		t.Errorf("Expected only the last minute before to count, got %+v", c)
+	}
+}
+
+type fakeSource struct {
+	points map[time.Time][]Point // by the start of the range asked for
+	calls  int
+	limit  int
+}
+
+func (s *fakeSource) FetchErrorRate(ctx context.Context, start, end time.Time) ([]Point, error) {
+	s.calls++
+	if s.limit > 0 && s.calls > s.limit {
+		return nil, budget.ErrExhausted
+	}
+	return s.points[start], nil
+}
+
+func (s *fakeSource) SetBudget(b *budget.Budget)    {}
+func (s *fakeSource) SetRetryPolicy(p retry.Policy) {}
+
+func TestCheckDeployments(t *testing.T) {
+	window := 4 * time.Minute
+	steadyAt := time.Now().Add(-3 * time.Hour)
+	brokenAt := time.Now().Add(-2 * time.Hour)
+	results := []deploy.DeploymentMetric{
+		{ReleaseID: "steady", DeploymentSuccessful: true, ReleaseFinishTime: steadyAt},
+		{ReleaseID: "broken", DeploymentSuccessful: true, ReleaseFinishTime: brokenAt},
+		{ReleaseID: "failed", DeploymentSuccessful: false, ReleaseFinishTime: brokenAt},
+		{ReleaseID: "just now", DeploymentSuccessful: true, ReleaseFinishTime: time.Now().Add(-time.Minute)},
+	}
+	source := &fakeSource{points: map[time.Time][]Point{
+		steadyAt.Add(-window): series(steadyAt, []float64{1, 1, 1, 1}, []float64{1, 1, 1, 1}),
+		brokenAt.Add(-window): series(brokenAt, []float64{1, 1, 1, 1}, []float64{5, 6, 5, 6}),
+	}}
+
+	if err := CheckDeployments(context.Background(), source, results, window, DefaultThreshold); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Neither the failed deployment nor the one whose window isn't over is read
+	if source.calls != 2 {
+		t.Errorf("Expected 2 deployments to be read, got %d", source.calls)
+	}
+	if !results[0].ErrorRateMeasured || results[0].ErrorRateRegressed {
+		t.Errorf("Expected the steady deployment to be measured and fine, got %+v", results[0])
+	}
+	if !results[1].ErrorRateMeasured || !results[1].ErrorRateRegressed || results[1].ErrorRateAfter != 5.5 {
+		t.Errorf("Expected the broken deployment to regress, got %+v", results[1])
+	}
+	if results[3].ErrorRateMeasured {
+		t.Error("Expected the deployment still in its window to be skipped")
+	}
+
+	if failed, measured := ChangeFailureRate(results); failed != 1 || measured != 2 {
+		t.Errorf("Expected 1 of 2 deployments to have failed, got %d of %d", failed, measured)
+	}
+
+	limited := &fakeSource{limit: 1}
+	if err := CheckDeployments(context.Background(), limited, results, window, DefaultThreshold); !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package errorrate
+
+import (
+	"context"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+// Point is an error rate at a moment: errors per second, or a fraction of
+// requests, depending on the metric queried
+type Point struct {
+	At    time.Time
+	Value float64
+}
+
+// Source is where error rates are read from, such as Datadog or Cloud Monitoring
+type Source interface {
+	// FetchErrorRate returns the error rate over the time range, oldest first
+	FetchErrorRate(ctx context.Context, start, end time.Time) ([]Point, error)
+	SetBudget(b *budget.Budget)
+	SetRetryPolicy(p retry.Policy)
+}
+
+// Comparison is a deployment's error rate before and after it went live
+type Comparison struct {
+	Before    float64 // Mean error rate over the window before the deployment
+	After     float64 // Mean error rate over the window after it
+	Regressed bool    // Whether the increase was significant
+}
+
+// Threshold decides what counts as a significant increase in the error rate
+type Threshold struct {
+	Increase float64 // Relative increase the mean must exceed, e.g. 0.5 for 50%
+	Sigmas   float64 // Standard deviations of the rate before that the mean must rise by, to see past noise
+}
+
+// DefaultThreshold flags a rate that rose by more than half, and by more than
+// three standard deviations of what it was before
+var DefaultThreshold = Threshold{Increase: 0.5, Sigmas: 3}
@@ -0,0 +1,120 @@
+// Package eventlog keeps an append-only log of the raw data fetched from APIs,
+// separate from the metrics derived from it, so metrics can be recomputed with new
+// definitions or settings without fetching everything again.
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotLogged is returned when the log has no event for a key
+var ErrNotLogged = errors.New("not in event log")
+
+// Event is one raw API response. Key identifies what was fetched, in the same form
+// as cache keys, e.g. github:pr_reviews:owner:repo:12.
+type Event struct {
+	At   time.Time       `json:"at"`
+	Key  string          `json:"key"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Log is an append-only file of events, one JSON object per line. It's safe for
+// concurrent use.
+type Log struct {
+	mu     sync.Mutex
+	file   *os.File
+	latest map[string]Event // by key
+}
+
+// Open opens the log at path, creating it if needed, and reads the events already in it
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+
+	l := &Log{file: file, latest: make(map[string]Event)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to parse event log %s line %d: %w", path, line, err)
+		}
+		l.latest[event.Key] = event
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read event log %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Append records data fetched for key. Data identical to the latest event for the
+// key isn't written again, so fetching the same thing on every run doesn't grow the log.
+func (l *Log) Append(key string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", key, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if latest, ok := l.latest[key]; ok && bytes.Equal(latest.Data, encoded) {
+		return nil
+	}
+	event := Event{At: time.Now(), Key: key, Data: encoded}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", key, err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event %s: %w", key, err)
+	}
+	l.latest[key] = event
+	return nil
+}
+
+// Latest decodes the most recent data logged for key into value, or returns
+// ErrNotLogged if there is none
+func (l *Log) Latest(key string, value interface{}) error {
+	l.mu.Lock()
+	event, ok := l.latest[key]
+	l.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s: %w", key, ErrNotLogged)
+	}
+	if err := json.Unmarshal(event.Data, value); err != nil {
+		return fmt.Errorf("failed to decode event %s: %w", key, err)
+	}
+	return nil
+}
+
+// LatestWithPrefix returns the most recent event for every key starting with prefix
+func (l *Log) LatestWithPrefix(prefix string) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []Event
+	for key, event := range l.latest {
+		if strings.HasPrefix(key, prefix) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,56 @@
+package eventlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, v := range []int{1, 1, 2} {
+		if err := l.Append("github:thing:a", v); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := l.Append("github:thing:b", "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := l.Append("linear:other", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	l.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 4 {
+		t.Errorf("Expected unchanged data not to be logged again (4 lines), got %d lines", lines)
+	}
+
+	// Reopening reads the existing events back
+	l, err = Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer l.Close()
+
+	var got int
+	if err := l.Latest("github:thing:a", &got); err != nil || got != 2 {
+		t.Errorf("Expected the latest value 2, got %d (%v)", got, err)
+	}
+	if err := l.Latest("github:missing", &got); !errors.Is(err, ErrNotLogged) {
+		t.Errorf("Expected ErrNotLogged, got %v", err)
+	}
+	if events := l.LatestWithPrefix("github:thing:"); len(events) != 2 {
+		t.Errorf("Expected 2 events with the prefix, got %d", len(events))
+	}
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/perfbudget"
+)
+
+// benchmarkSizes are the numbers of PRs benchmarks run with, from a busy repository's
+// month up to an organization's year
+var benchmarkSizes = []int{1_000, 10_000, 100_000}
+
+// Performance budgets per record. Raise one deliberately, in the change that needs
+// it, rather than to make a flaky run pass.
+var (
+	recordsBudget      = perfbudget.Budget{Time: 20 * time.Microsecond, Allocs: 8}
+	writeCSVBudget     = perfbudget.Budget{Time: 60 * time.Microsecond, Allocs: 24}
+	writeParquetBudget = perfbudget.Budget{Time: 40 * time.Microsecond, Allocs: 4}
+	readCSVBudget      = perfbudget.Budget{Time: 60 * time.Microsecond, Allocs: 60}
+	readParquetBudget  = perfbudget.Budget{Time: 60 * time.Microsecond, Allocs: 28}
+)
+
+// benchmarkResults returns n PR results with the fields a typical run sets
+func benchmarkResults(n int) []github.PullRequestMetric {
+	opened := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	results := make([]github.PullRequestMetric, n)
+	for i := range results {
+		results[i] = github.PullRequestMetric{
+			Repository:        "owner/repo",
+			PRNumber:          i + 1,
+			PRTitle:           fmt.Sprintf("feat: widget %d", i),
+			Author:            fmt.Sprintf("author%d", i%40),
+			CreatedAt:         opened.Add(-time.Duration(i) * time.Minute),
+			HasReview:         true,
+			FirstReviewer:     "reviewer1",
+			FirstReviewState:  "COMMENTED",
+			TimeToFirstReview: 90 * time.Minute,
+			Approver:          "reviewer2",
+			TimeToApproval:    3 * time.Hour,
+			ApprovalToMerge:   33 * time.Hour,
+			ChangeType:        "feat",
+			Additions:         205,
+			Deletions:         31,
+			ChangedFiles:      3,
+			Size:              github.SizeM,
+			Reviews: []github.ReviewMetric{
+				{Reviewer: "reviewer1", State: "COMMENTED", TimeToReview: 90 * time.Minute},
+				{Reviewer: "reviewer2", State: "APPROVED", TimeToReview: 3 * time.Hour},
+			},
+			Extra: map[string]string{"team": "widgets"},
+		}
+	}
+	return results
+}
+
+func BenchmarkPullRequestRecords(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			results := benchmarkResults(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				PullRequestRecords(results)
+			}
+		})
+	}
+}
+
+func BenchmarkWriteFile(b *testing.B) {
+	for _, format := range []Format{FormatCSV, FormatParquet} {
+		for _, n := range benchmarkSizes {
+			b.Run(fmt.Sprintf("%s/%d", format, n), func(b *testing.B) {
+				records := PullRequestRecords(benchmarkResults(n))
+				path := filepath.Join(b.TempDir(), "prs."+string(format))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := WriteFile(format, path, records); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	for _, format := range []Format{FormatCSV, FormatParquet} {
+		for _, n := range benchmarkSizes {
+			b.Run(fmt.Sprintf("%s/%d", format, n), func(b *testing.B) {
+				path := filepath.Join(b.TempDir(), "prs."+string(format))
+				if err := WriteFile(format, path, PullRequestRecords(benchmarkResults(n))); err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := ReadFile[PullRequestRecord](path); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPerformanceBudget_PullRequestRecords(t *testing.T) {
+	results := benchmarkResults(1_000)
+	perfbudget.Check(t, recordsBudget, len(results), func() {
+		PullRequestRecords(results)
+	})
+}
+
+func TestPerformanceBudget_WriteFile(t *testing.T) {
+	records := PullRequestRecords(benchmarkResults(1_000))
+	for format, budget := range map[Format]perfbudget.Budget{FormatCSV: writeCSVBudget, FormatParquet: writeParquetBudget} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prs."+string(format))
+			perfbudget.Check(t, budget, len(records), func() {
+				// The benchmark runs in a goroutine of its own, where t.Fatal can't be used
+				if err := WriteFile(format, path, records); err != nil {
+					t.Error(err)
+				}
+			})
+		})
+	}
+}
+
+func TestPerformanceBudget_ReadFile(t *testing.T) {
+	records := PullRequestRecords(benchmarkResults(1_000))
+	for format, budget := range map[Format]perfbudget.Budget{FormatCSV: readCSVBudget, FormatParquet: readParquetBudget} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prs."+string(format))
+			if err := WriteFile(format, path, records); err != nil {
+				t.Fatal(err)
+			}
+			perfbudget.Check(t, budget, len(records), func() {
+				if _, err := ReadFile[PullRequestRecord](path); err != nil {
+					t.Error(err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format identifies a file export format
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat validates an -output flag value
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatCSV, FormatParquet:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q (supported: csv, parquet)", s)
+	}
+}
+
+// ParseOutputFlags validates the -output/-out-file flag pair shared by the commands.
+// It returns an empty Format when no export was requested.
+func ParseOutputFlags(output, outFile string) (Format, error) {
+	if output == "" {
+		return "", nil
+	}
+	format, err := ParseFormat(output)
+	if err != nil {
+		return "", err
+	}
+	if outFile == "" {
+		return "", fmt.Errorf("-out-file is required when -output is set")
+	}
+	return format, nil
+}
+
+// FormatOf returns the format a file is in, going by its name: Parquet if it
+// ends in .parquet, otherwise CSV
+func FormatOf(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".parquet") {
+		return FormatParquet
+	}
+	return FormatCSV
+}
+
+// WriteFile writes records to path in the given format
+func WriteFile[T any](format Format, path string, records []T) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(path, records)
+	case FormatParquet:
+		return writeParquet(path, records)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// ReadFile reads back records written by WriteFile, as Parquet if path ends in
+// .parquet and as CSV otherwise, such as to correlate one tool's results with
+// another's. CSV columns the record type doesn't have are ignored, and fields the
+// file has no column for are left empty.
+func ReadFile[T any](path string) ([]T, error) {
+	if FormatOf(path) == FormatParquet {
+		records, err := parquet.ReadFile[T](path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parquet file %s: %w", path, err)
+		}
+		return records, nil
+	}
+	return readCSV[T](path)
+}
+
+// writeParquet writes records as a single Parquet file, with the schema derived from the record type
+func writeParquet[T any](path string, records []T) error {
+	if err := parquet.WriteFile(path, records); err != nil {
+		return fmt.Errorf("failed to write parquet file %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeCSV writes records as CSV with a header row. Columns follow the record's
+// field order and are named after its parquet tags, so both formats share a schema.
+// Timestamps are written as RFC 3339 and missing optional values as empty cells.
+func writeCSV[T any](path string, records []T) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create csv file %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close csv file %s: %w", path, closeErr)
+		}
+	}()
+
+	recordType := reflect.TypeOf((*T)(nil)).Elem()
+	w := csv.NewWriter(f)
+	if err := w.Write(csvHeader(recordType)); err != nil {
+		return fmt.Errorf("failed to write csv file %s: %w", path, err)
+	}
+	for _, record := range records {
+		if err := w.Write(csvRow(reflect.ValueOf(record))); err != nil {
+			return fmt.Errorf("failed to write csv file %s: %w", path, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write csv file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readCSV reads records from a CSV file written by writeCSV
+func readCSV[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open csv file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv file %s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	recordType := reflect.TypeOf((*T)(nil)).Elem()
+	fieldsByName := make(map[string]int)
+	for i, name := range csvHeader(recordType) {
+		fieldsByName[name] = i
+	}
+	records := make([]T, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		var record T
+		v := reflect.ValueOf(&record).Elem()
+		for i, name := range rows[0] {
+			field, ok := fieldsByName[name]
+			if !ok || i >= len(row) {
+				continue
+			}
+			if err := parseCSVCell(v.Field(field), row[i]); err != nil {
+				return nil, fmt.Errorf("failed to read csv file %s: line %d, column %s: %w", path, line+2, name, err)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// csvHeader returns the column names for a record type
+func csvHeader(t reflect.Type) []string {
+	header := make([]string, t.NumField())
+	for i := range header {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("parquet"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		header[i] = name
+	}
+	return header
+}
+
+// Fields returns a record's fields by column name, for expressions to use.
+// Timestamps are RFC 3339 strings, so they compare in time order, and missing
+// optional values are left out.
+func Fields(record any) map[string]any {
+	v := reflect.ValueOf(record)
+	header := csvHeader(v.Type())
+	fields := make(map[string]any, len(header))
+	for i, name := range header {
+		field := v.Field(i)
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		if _, ok := field.Interface().(time.Time); ok {
+			fields[name] = csvCell(field)
+		} else {
+			fields[name] = field.Interface()
+		}
+	}
+	return fields
+}
+
+// csvRow formats a record's fields as CSV cells
+func csvRow(v reflect.Value) []string {
+	row := make([]string, v.NumField())
+	for i := range row {
+		row[i] = csvCell(v.Field(i))
+	}
+	return row
+}
+
+func csvCell(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// parseCSVCell sets v from a cell formatted by csvCell. Empty cells leave v empty.
+func parseCSVCell(v reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if _, ok := v.Interface().(time.Time); ok {
+		t, err := time.Parse(time.RFC3339, cell)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		v.SetString(cell)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
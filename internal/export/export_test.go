@@ -0,0 +1,199 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/reillywatson/statstracker/internal/circleci"
+	"github.com/reillywatson/statstracker/internal/github"
+)
+
+func TestParseFormat(t *testing.T) {
+	if _, err := ParseFormat("parquet"); err != nil {
+		t.Errorf("Expected parquet to be a valid format, got %v", err)
+	}
+	if _, err := ParseFormat("csv"); err != nil {
+		t.Errorf("Expected csv to be a valid format, got %v", err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("Expected error for unsupported format, got none")
+	}
+	if FormatOf("reviews.PARQUET") != FormatParquet || FormatOf("reviews.csv") != FormatCSV || FormatOf("reviews") != FormatCSV {
+		t.Errorf("Expected Parquet files to be told apart by their extension")
+	}
+}
+
+func TestWriteFile_ParquetPullRequests(t *testing.T) {
+	results := []github.PullRequestMetric{
+		{
+			PRNumber:          42,
+			PRTitle:           "Add widgets",
+			Author:            "author",
+			HasReview:         true,
+			FirstReviewer:     "reviewer",
+			FirstReviewState:  "APPROVED",
+			TimeToFirstReview: 90 * time.Minute,
+			Approver:          "reviewer",
+			TimeToApproval:    90 * time.Minute,
+			TagCommits:        []github.TagCommit{{SHA: "abc123"}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "prs.parquet")
+	if err := WriteFile(FormatParquet, path, PullRequestRecords(results)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rows, err := parquet.ReadFile[PullRequestRecord](path)
+	if err != nil {
+		t.Fatalf("Failed to read back parquet file: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(rows))
+	}
+	if rows[0].PRNumber != 42 {
+		t.Errorf("Expected PR number 42, got %d", rows[0].PRNumber)
+	}
+	if rows[0].TimeToFirstReviewSeconds != 5400 {
+		t.Errorf("Expected 5400 seconds to first review, got %d", rows[0].TimeToFirstReviewSeconds)
+	}
+	if rows[0].TagCommitCount != 1 {
+		t.Errorf("Expected 1 tag commit, got %d", rows[0].TagCommitCount)
+	}
+}
+
+func TestWriteFile_ParquetFlakyTestsOptionalTime(t *testing.T) {
+	lastOccurred := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	results := []circleci.FlakyTestMetric{
+		{TestName: "TestA", TimesFlaky: 3, LastOccurred: &lastOccurred},
+		{TestName: "TestB", TimesFlaky: 1},
+	}
+
+	path := filepath.Join(t.TempDir(), "flaky.parquet")
+	if err := WriteFile(FormatParquet, path, FlakyTestRecords(results)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rows, err := parquet.ReadFile[FlakyTestRecord](path)
+	if err != nil {
+		t.Fatalf("Failed to read back parquet file: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].LastOccurred == nil || !rows[0].LastOccurred.Equal(lastOccurred) {
+		t.Errorf("Expected LastOccurred %v, got %v", lastOccurred, rows[0].LastOccurred)
+	}
+	if rows[1].LastOccurred != nil {
+		t.Errorf("Expected nil LastOccurred for second row, got %v", rows[1].LastOccurred)
+	}
+}
+
+func TestWriteFile_CSVFlakyTests(t *testing.T) {
+	lastOccurred := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	results := []circleci.FlakyTestMetric{
+		{TestName: "TestWidgets", ClassName: "widgets", TimesFlaky: 3, LastOccurred: &lastOccurred},
+		{TestName: "TestGadgets, again", ClassName: "gadgets", TimesFlaky: 1, Extra: map[string]string{"owner": "team-a"}},
+	}
+
+	path := filepath.Join(t.TempDir(), "flaky.csv")
+	if err := WriteFile(FormatCSV, path, FlakyTestRecords(results)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read back csv file: %v", err)
+	}
+	expected := "test_name,class_name,times_flaky,last_occurred,extra\n" +
+		"TestWidgets,widgets,3,2024-03-01T12:00:00Z,\n" +
+		"\"TestGadgets, again\",gadgets,1,,\"{\"\"owner\"\":\"\"team-a\"\"}\"\n"
+	if string(data) != expected {
+		t.Errorf("Expected csv:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestWriteFile_CSVReviews(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	results := []github.PullRequestMetric{
+		{Repository: "o/r", PRNumber: 42, Author: "author", CreatedAt: createdAt, Reviews: []github.ReviewMetric{
+			{Reviewer: "alice", State: "CHANGES_REQUESTED", SubmittedAt: createdAt.Add(time.Hour), TimeToReview: time.Hour},
+			{Reviewer: "bob", State: "APPROVED", SubmittedAt: createdAt.Add(3 * time.Hour), TimeToReview: 3 * time.Hour},
+		}},
+		{Repository: "o/r", PRNumber: 43, Author: "author", CreatedAt: createdAt},
+	}
+
+	path := filepath.Join(t.TempDir(), "reviews.csv")
+	if err := WriteFile(FormatCSV, path, ReviewRecords(results)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read back csv file: %v", err)
+	}
+	expected := "repository,pr_number,author,pr_created_at,reviewer,state,submitted_at,latency_seconds\n" +
+		"o/r,42,author,2024-03-01T09:00:00Z,alice,CHANGES_REQUESTED,2024-03-01T10:00:00Z,3600\n" +
+		"o/r,42,author,2024-03-01T09:00:00Z,bob,APPROVED,2024-03-01T12:00:00Z,10800\n"
+	if string(data) != expected {
+		t.Errorf("Expected one row per review:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	lastOccurred := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := FlakyTestRecords([]circleci.FlakyTestMetric{
+		{TestName: "TestWidgets", ClassName: "widgets", TimesFlaky: 3, LastOccurred: &lastOccurred},
+		{TestName: "TestGadgets, again", TimesFlaky: 1, Extra: map[string]string{"owner": "team-a"}},
+	})
+
+	for _, format := range []Format{FormatCSV, FormatParquet} {
+		path := filepath.Join(t.TempDir(), "flaky."+string(format))
+		if err := WriteFile(format, path, records); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		read, err := ReadFile[FlakyTestRecord](path)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", format, err)
+		}
+		if len(read) != 2 || read[0].TestName != "TestWidgets" || read[0].TimesFlaky != 3 || !read[0].LastOccurred.Equal(lastOccurred) {
+			t.Errorf("%s: expected the first record back, got %+v", format, read)
+		}
+		if len(read) == 2 && (read[1].TestName != "TestGadgets, again" || read[1].LastOccurred != nil || read[1].Extra != records[1].Extra) {
+			t.Errorf("%s: expected the second record back, got %+v", format, read[1])
+		}
+	}
+
+	// Columns the record doesn't have are ignored, and bad cells are reported
+	path := filepath.Join(t.TempDir(), "flaky.csv")
+	os.WriteFile(path, []byte("test_name,unknown,times_flaky\nTestWidgets,x,3\n"), 0o644)
+	if read, err := ReadFile[FlakyTestRecord](path); err != nil || len(read) != 1 || read[0].TimesFlaky != 3 {
+		t.Errorf("Expected the known columns to be read, got %+v (%v)", read, err)
+	}
+	os.WriteFile(path, []byte("test_name,times_flaky\nTestWidgets,often\n"), 0o644)
+	if _, err := ReadFile[FlakyTestRecord](path); err == nil {
+		t.Error("Expected an error for a malformed number")
+	}
+}
+
+func TestFields(t *testing.T) {
+	lastOccurred := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := FlakyTestRecords([]circleci.FlakyTestMetric{
+		{TestName: "TestWidgets", TimesFlaky: 3, LastOccurred: &lastOccurred},
+		{TestName: "TestGadgets", TimesFlaky: 1},
+	})
+
+	fields := Fields(records[0])
+	if fields["test_name"] != "TestWidgets" || fields["times_flaky"] != 3 {
+		t.Errorf("Expected the record's values by column name, got %v", fields)
+	}
+	if fields["last_occurred"] != "2024-03-01T12:00:00Z" {
+		t.Errorf("Expected last_occurred as RFC 3339, got %v", fields["last_occurred"])
+	}
+	if _, ok := Fields(records[1])["last_occurred"]; ok {
+		t.Error("Expected a missing last_occurred to be left out")
+	}
+}
@@ -0,0 +1,517 @@
+package export
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/cfd"
+	"github.com/reillywatson/statstracker/internal/circleci"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/launchdarkly"
+	"github.com/reillywatson/statstracker/internal/linear"
+	"github.com/reillywatson/statstracker/internal/terraform"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+// PullRequestRecord is the flattened, export-friendly form of a PullRequestMetric.
+// Durations are stored as whole seconds so they load cleanly into analytics tools.
+type PullRequestRecord struct {
+	Repository                     string    `parquet:"repository"`
+	PRNumber                       int       `parquet:"pr_number"`
+	PRTitle                        string    `parquet:"pr_title"`
+	Author                         string    `parquet:"author"`
+	CreatedAt                      time.Time `parquet:"created_at,timestamp(millisecond)"`
+	CodingTimeSeconds              int64     `parquet:"coding_time_seconds"`
+	TimeInDraftSeconds             int64     `parquet:"time_in_draft_seconds"`
+	HasReview                      bool      `parquet:"has_review"`
+	FirstReviewer                  string    `parquet:"first_reviewer"`
+	FirstReviewState               string    `parquet:"first_review_state"`
+	TimeToFirstReviewSeconds       int64     `parquet:"time_to_first_review_seconds"`
+	FirstResponder                 string    `parquet:"first_responder"`
+	TimeToFirstResponseSeconds     int64     `parquet:"time_to_first_response_seconds"`
+	Approver                       string    `parquet:"approver"`
+	TimeToApprovalSeconds          int64     `parquet:"time_to_approval_seconds"`
+	ApprovalToMergeSeconds         int64     `parquet:"approval_to_merge_seconds"`
+	TimeSinceCreationSeconds       int64     `parquet:"time_since_creation_seconds"`
+	TagCommitCount                 int       `parquet:"tag_commit_count"`
+	ReviewerWaitSeconds            int64     `parquet:"reviewer_wait_seconds"`
+	AuthorWaitSeconds              int64     `parquet:"author_wait_seconds"`
+	RequiredApprovals              int       `parquet:"required_approvals"`
+	TimeToRequiredApprovalsSeconds int64     `parquet:"time_to_required_approvals_seconds"`
+	TimeToStandingApprovalSeconds  int64     `parquet:"time_to_standing_approval_seconds"`
+	DismissedApprovals             int       `parquet:"dismissed_approvals"`
+	Dismissals                     int       `parquet:"dismissals"`
+	OnlyApprovalDismissed          bool      `parquet:"only_approval_dismissed"`
+	ReviewRounds                   int       `parquet:"review_rounds"`
+	TimeInChangesRequestedSeconds  int64     `parquet:"time_in_changes_requested_seconds"`
+	TimeToCodeownerApprovalSeconds int64     `parquet:"time_to_codeowner_approval_seconds"`
+	DominantLanguage               string    `parquet:"dominant_language"`
+	Class                          string    `parquet:"class"`
+	Hotfix                         bool      `parquet:"hotfix"`
+	Priority                       string    `parquet:"priority"`
+	ChangeType                     string    `parquet:"change_type"`
+	Additions                      int       `parquet:"additions"`
+	Deletions                      int       `parquet:"deletions"`
+	ChangedFiles                   int       `parquet:"changed_files"`
+	Size                           string    `parquet:"size"`
+	ReviewComments                 int       `parquet:"review_comments"`
+	ReviewDepth                    float64   `parquet:"review_depth"`
+	Extra                          string    `parquet:"extra"`
+}
+
+// DeploymentRecord is the flattened, export-friendly form of a DeploymentMetric
+type DeploymentRecord struct {
+	ReleaseID                    string    `parquet:"release_id"`
+	ReleaseName                  string    `parquet:"release_name"`
+	Region                       string    `parquet:"region"`
+	CommitSHA                    string    `parquet:"commit_sha"`
+	PRNumber                     string    `parquet:"pr_number"`
+	CommitTime                   time.Time `parquet:"commit_time,timestamp(millisecond)"`
+	ReleaseStartTime             time.Time `parquet:"release_start_time,timestamp(millisecond)"`
+	ReleaseFinishTime            time.Time `parquet:"release_finish_time,timestamp(millisecond)"`
+	CommitToDeployLatencySeconds int64     `parquet:"commit_to_deploy_latency_seconds"`
+	DeploymentSuccessful         bool      `parquet:"deployment_successful"`
+	Hotfix                       bool      `parquet:"hotfix"`
+	Priority                     string    `parquet:"priority"`
+	SentryRelease                string    `parquet:"sentry_release"`
+	CrashFreeRate                *float64  `parquet:"crash_free_rate,optional"`
+	NewIssues                    int       `parquet:"new_issues"`
+	ErrorRateBefore              *float64  `parquet:"error_rate_before,optional"`
+	ErrorRateAfter               *float64  `parquet:"error_rate_after,optional"`
+	ErrorRateRegressed           bool      `parquet:"error_rate_regressed"`
+	FollowingIncidents           int       `parquet:"following_incidents"`
+	Rollback                     bool      `parquet:"rollback"`
+	RolledBack                   bool      `parquet:"rolled_back"`
+	TimeToRollbackSeconds        *float64  `parquet:"time_to_rollback_seconds,optional"`
+	FailureState                 string    `parquet:"failure_state"`
+	FailureMessage               string    `parquet:"failure_message"`
+	RenderTimeSeconds            *float64  `parquet:"render_time_seconds,optional"`
+	QueueTimeSeconds             *float64  `parquet:"queue_time_seconds,optional"`  // Across the release's targets
+	DeployTimeSeconds            *float64  `parquet:"deploy_time_seconds,optional"` // Across the release's targets
+	VerifyTimeSeconds            *float64  `parquet:"verify_time_seconds,optional"` // Across the release's targets
+	Extra                        string    `parquet:"extra"`
+}
+
+// FlakyTestRecord is the flattened, export-friendly form of a FlakyTestMetric
+type FlakyTestRecord struct {
+	TestName     string     `parquet:"test_name"`
+	ClassName    string     `parquet:"class_name"`
+	TimesFlaky   int        `parquet:"times_flaky"`
+	LastOccurred *time.Time `parquet:"last_occurred,optional"`
+	Extra        string     `parquet:"extra"`
+}
+
+// DiscussionRecord is the flattened, export-friendly form of a DiscussionMetric
+type DiscussionRecord struct {
+	Number                     int    `parquet:"number"`
+	Title                      string `parquet:"title"`
+	Author                     string `parquet:"author"`
+	Category                   string `parquet:"category"`
+	HasResponse                bool   `parquet:"has_response"`
+	FirstResponder             string `parquet:"first_responder"`
+	TimeToFirstResponseSeconds int64  `parquet:"time_to_first_response_seconds"`
+	Answerable                 bool   `parquet:"answerable"`
+	Answered                   bool   `parquet:"answered"`
+	TimeToAnswerSeconds        int64  `parquet:"time_to_answer_seconds"`
+	Closed                     bool   `parquet:"closed"`
+	TimeToCloseSeconds         int64  `parquet:"time_to_close_seconds"`
+	TimeSinceCreationSeconds   int64  `parquet:"time_since_creation_seconds"`
+}
+
+// ProjectItemStatusRecord is one row per project item and column, since
+// time-in-column doesn't flatten into a fixed set of fields
+type ProjectItemStatusRecord struct {
+	Number        int    `parquet:"number"`
+	Title         string `parquet:"title"`
+	Repository    string `parquet:"repository"`
+	CurrentStatus string `parquet:"current_status"`
+	Status        string `parquet:"status"`
+	Seconds       int64  `parquet:"seconds"`
+}
+
+// LinearIssueRecord is the flattened, export-friendly form of a linear.IssueMetric
+type LinearIssueRecord struct {
+	Identifier           string `parquet:"identifier"`
+	Title                string `parquet:"title"`
+	Assignee             string `parquet:"assignee"`
+	LeadTimeSeconds      int64  `parquet:"lead_time_seconds"`
+	CycleTimeSeconds     int64  `parquet:"cycle_time_seconds"`
+	PullRequestCount     int    `parquet:"pull_request_count"`
+	TimeToFirstPRSeconds int64  `parquet:"time_to_first_pr_seconds"`
+	MergeToDoneSeconds   int64  `parquet:"merge_to_done_seconds"`
+}
+
+// FlagChangeRecord is the flattened, export-friendly form of a launchdarkly.FlagChangeMetric
+type FlagChangeRecord struct {
+	ID                 string    `parquet:"id"`
+	Flag               string    `parquet:"flag"`
+	Environment        string    `parquet:"environment"`
+	Action             string    `parquet:"action"`
+	Description        string    `parquet:"description"`
+	Member             string    `parquet:"member"`
+	ChangedAt          time.Time `parquet:"changed_at,timestamp(millisecond)"`
+	NearbyDeployments  int       `parquet:"nearby_deployments"`
+	FollowingIncidents int       `parquet:"following_incidents"`
+	Extra              string    `parquet:"extra"`
+}
+
+// TerraformRunRecord is the flattened, export-friendly form of a terraform.RunMetric
+type TerraformRunRecord struct {
+	Workspace            string    `parquet:"workspace"`
+	RunID                string    `parquet:"run_id"`
+	Status               string    `parquet:"status"`
+	Message              string    `parquet:"message"`
+	CommitSHA            string    `parquet:"commit_sha"`
+	CreatedAt            time.Time `parquet:"created_at,timestamp(millisecond)"`
+	PlanOnly             bool      `parquet:"plan_only"`
+	PlanDurationSeconds  int64     `parquet:"plan_duration_seconds"`
+	ApplyDurationSeconds int64     `parquet:"apply_duration_seconds"`
+	Applied              bool      `parquet:"applied"`
+	Failed               bool      `parquet:"failed"`
+	Extra                string    `parquet:"extra"`
+}
+
+// PullRequestRecords converts PR metrics into export records
+func PullRequestRecords(results []github.PullRequestMetric) []PullRequestRecord {
+	records := make([]PullRequestRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, PullRequestRecord{
+			Repository:                     result.Repository,
+			PRNumber:                       result.PRNumber,
+			PRTitle:                        result.PRTitle,
+			Author:                         result.Author,
+			CreatedAt:                      result.CreatedAt,
+			CodingTimeSeconds:              seconds(result.CodingTime),
+			TimeInDraftSeconds:             seconds(result.TimeInDraft),
+			HasReview:                      result.HasReview,
+			FirstReviewer:                  result.FirstReviewer,
+			FirstReviewState:               result.FirstReviewState,
+			TimeToFirstReviewSeconds:       seconds(result.TimeToFirstReview),
+			FirstResponder:                 result.FirstResponder,
+			TimeToFirstResponseSeconds:     seconds(result.TimeToFirstResponse),
+			Approver:                       result.Approver,
+			TimeToApprovalSeconds:          seconds(result.TimeToApproval),
+			ApprovalToMergeSeconds:         seconds(result.ApprovalToMerge),
+			TimeSinceCreationSeconds:       seconds(result.TimeSinceCreation),
+			TagCommitCount:                 len(result.TagCommits),
+			ReviewerWaitSeconds:            seconds(result.ReviewerWaitTime),
+			AuthorWaitSeconds:              seconds(result.AuthorWaitTime),
+			RequiredApprovals:              result.RequiredApprovals,
+			TimeToRequiredApprovalsSeconds: seconds(result.TimeToRequiredApprovals),
+			TimeToStandingApprovalSeconds:  seconds(result.TimeToStandingApproval),
+			DismissedApprovals:             result.DismissedApprovals,
+			Dismissals:                     result.Dismissals,
+			OnlyApprovalDismissed:          result.OnlyApprovalDismissed,
+			ReviewRounds:                   result.ReviewRounds,
+			TimeInChangesRequestedSeconds:  seconds(result.TimeInChangesRequested),
+			TimeToCodeownerApprovalSeconds: seconds(result.TimeToCodeownerApproval),
+			DominantLanguage:               result.DominantLanguage,
+			Class:                          result.Class,
+			Hotfix:                         result.Hotfix,
+			Priority:                       result.Priority,
+			ChangeType:                     result.ChangeType,
+			Additions:                      result.Additions,
+			Deletions:                      result.Deletions,
+			ChangedFiles:                   result.ChangedFiles,
+			Size:                           result.Size,
+			ReviewComments:                 result.ReviewComments,
+			ReviewDepth:                    result.ReviewDepth,
+			Extra:                          extraJSON(result.Extra),
+		})
+	}
+	return records
+}
+
+// DeploymentRecords converts deployment metrics into export records
+func DeploymentRecords(results []deploy.DeploymentMetric) []DeploymentRecord {
+	records := make([]DeploymentRecord, 0, len(results))
+	for _, result := range results {
+		var queue, deploying, verifying time.Duration
+		for _, rollout := range result.Rollouts {
+			queue += rollout.QueueTime
+			deploying += rollout.DeployTime
+			verifying += rollout.VerifyTime
+		}
+		timed := len(result.Rollouts) > 0
+		records = append(records, DeploymentRecord{
+			ReleaseID:                    result.ReleaseID,
+			ReleaseName:                  result.ReleaseName,
+			Region:                       result.Region,
+			CommitSHA:                    result.CommitSHA,
+			PRNumber:                     result.PRNumber,
+			CommitTime:                   result.CommitTime,
+			ReleaseStartTime:             result.ReleaseStartTime,
+			ReleaseFinishTime:            result.ReleaseFinishTime,
+			CommitToDeployLatencySeconds: seconds(result.CommitToDeployLatency),
+			DeploymentSuccessful:         result.DeploymentSuccessful,
+			Hotfix:                       result.Hotfix,
+			Priority:                     result.Priority,
+			SentryRelease:                result.SentryRelease,
+			CrashFreeRate:                crashFreeRate(result),
+			NewIssues:                    result.NewIssues,
+			ErrorRateBefore:              measured(result.ErrorRateMeasured, result.ErrorRateBefore),
+			ErrorRateAfter:               measured(result.ErrorRateMeasured, result.ErrorRateAfter),
+			ErrorRateRegressed:           result.ErrorRateRegressed,
+			FollowingIncidents:           len(result.FollowingIncidents),
+			Rollback:                     result.Rollback,
+			RolledBack:                   result.RolledBack,
+			TimeToRollbackSeconds:        measured(result.RolledBack, result.TimeToRollback.Seconds()),
+			FailureState:                 result.FailureState,
+			FailureMessage:               result.FailureMessage,
+			RenderTimeSeconds:            measured(result.RenderTime > 0, result.RenderTime.Seconds()),
+			QueueTimeSeconds:             measured(timed, queue.Seconds()),
+			DeployTimeSeconds:            measured(timed, deploying.Seconds()),
+			VerifyTimeSeconds:            measured(timed, verifying.Seconds()),
+			Extra:                        extraJSON(result.Extra),
+		})
+	}
+	return records
+}
+
+// crashFreeRate returns a deployment's crash-free session rate, or nil if its
+// release had no session data, which isn't the same as none crashing
+func crashFreeRate(result deploy.DeploymentMetric) *float64 {
+	return measured(result.HasHealthData, result.CrashFreeRate)
+}
+
+// measured returns value if it was measured, or nil, so a value that wasn't
+// measured exports as empty rather than 0
+func measured(ok bool, value float64) *float64 {
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// FlakyTestRecords converts flaky test metrics into export records
+func FlakyTestRecords(results []circleci.FlakyTestMetric) []FlakyTestRecord {
+	records := make([]FlakyTestRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, FlakyTestRecord{
+			TestName:     result.TestName,
+			ClassName:    result.ClassName,
+			TimesFlaky:   result.TimesFlaky,
+			LastOccurred: result.LastOccurred,
+			Extra:        extraJSON(result.Extra),
+		})
+	}
+	return records
+}
+
+// DiscussionRecords converts discussion metrics into export records
+func DiscussionRecords(results []github.DiscussionMetric) []DiscussionRecord {
+	records := make([]DiscussionRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, DiscussionRecord{
+			Number:                     result.Number,
+			Title:                      result.Title,
+			Author:                     result.Author,
+			Category:                   result.Category,
+			HasResponse:                result.HasResponse,
+			FirstResponder:             result.FirstResponder,
+			TimeToFirstResponseSeconds: seconds(result.TimeToFirstResponse),
+			Answerable:                 result.Answerable,
+			Answered:                   result.Answered,
+			TimeToAnswerSeconds:        seconds(result.TimeToAnswer),
+			Closed:                     result.Closed,
+			TimeToCloseSeconds:         seconds(result.TimeToClose),
+			TimeSinceCreationSeconds:   seconds(result.TimeSinceCreation),
+		})
+	}
+	return records
+}
+
+// ProjectItemStatusRecords converts project item metrics into export records, ordered by item then column
+func ProjectItemStatusRecords(results []github.ProjectItemMetric) []ProjectItemStatusRecord {
+	var records []ProjectItemStatusRecord
+	for _, result := range results {
+		statuses := make([]string, 0, len(result.TimeInStatus))
+		for status := range result.TimeInStatus {
+			statuses = append(statuses, status)
+		}
+		slices.Sort(statuses)
+
+		for _, status := range statuses {
+			records = append(records, ProjectItemStatusRecord{
+				Number:        result.Number,
+				Title:         result.Title,
+				Repository:    result.Repository,
+				CurrentStatus: result.CurrentStatus,
+				Status:        status,
+				Seconds:       seconds(result.TimeInStatus[status]),
+			})
+		}
+	}
+	return records
+}
+
+// LinearIssueRecords converts Linear issue metrics into export records
+func LinearIssueRecords(results []linear.IssueMetric) []LinearIssueRecord {
+	records := make([]LinearIssueRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, LinearIssueRecord{
+			Identifier:           result.Identifier,
+			Title:                result.Title,
+			Assignee:             result.Assignee,
+			LeadTimeSeconds:      seconds(result.LeadTime),
+			CycleTimeSeconds:     seconds(result.CycleTime),
+			PullRequestCount:     len(result.PullRequests),
+			TimeToFirstPRSeconds: seconds(result.TimeToFirstPR),
+			MergeToDoneSeconds:   seconds(result.MergeToDone),
+		})
+	}
+	return records
+}
+
+// FlagChangeRecords converts LaunchDarkly flag change metrics into export records
+func FlagChangeRecords(results []launchdarkly.FlagChangeMetric) []FlagChangeRecord {
+	records := make([]FlagChangeRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, FlagChangeRecord{
+			ID:                 result.ID,
+			Flag:               result.Flag,
+			Environment:        result.Environment,
+			Action:             result.Action,
+			Description:        result.Description,
+			Member:             result.Member,
+			ChangedAt:          result.ChangedAt,
+			NearbyDeployments:  len(result.NearbyDeployments),
+			FollowingIncidents: len(result.FollowingIncidents),
+			Extra:              extraJSON(result.Extra),
+		})
+	}
+	return records
+}
+
+// TerraformRunRecords converts Terraform run metrics into export records
+func TerraformRunRecords(results []terraform.RunMetric) []TerraformRunRecord {
+	records := make([]TerraformRunRecord, 0, len(results))
+	for _, result := range results {
+		records = append(records, TerraformRunRecord{
+			Workspace:            result.Workspace,
+			RunID:                result.RunID,
+			Status:               result.Status,
+			Message:              result.Message,
+			CommitSHA:            result.CommitSHA,
+			CreatedAt:            result.CreatedAt,
+			PlanOnly:             result.PlanOnly,
+			PlanDurationSeconds:  seconds(result.PlanDuration),
+			ApplyDurationSeconds: seconds(result.ApplyDuration),
+			Applied:              result.Applied,
+			Failed:               result.Failed,
+			Extra:                extraJSON(result.Extra),
+		})
+	}
+	return records
+}
+
+// ReviewRecord is one review of a PR, for analysis beyond the per-PR metrics
+type ReviewRecord struct {
+	Repository     string    `parquet:"repository"`
+	PRNumber       int       `parquet:"pr_number"`
+	Author         string    `parquet:"author"` // The PR's author
+	PRCreatedAt    time.Time `parquet:"pr_created_at,timestamp(millisecond)"`
+	Reviewer       string    `parquet:"reviewer"`
+	State          string    `parquet:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	SubmittedAt    time.Time `parquet:"submitted_at,timestamp(millisecond)"`
+	LatencySeconds int64     `parquet:"latency_seconds"` // From when the PR was opened, or left draft, to the review
+}
+
+// ReviewRecords flattens every review of the PRs into export records, in
+// submission order within each PR
+func ReviewRecords(results []github.PullRequestMetric) []ReviewRecord {
+	var records []ReviewRecord
+	for _, result := range results {
+		for _, review := range result.Reviews {
+			records = append(records, ReviewRecord{
+				Repository:     result.Repository,
+				PRNumber:       result.PRNumber,
+				Author:         result.Author,
+				PRCreatedAt:    result.CreatedAt,
+				Reviewer:       review.Reviewer,
+				State:          review.State,
+				SubmittedAt:    review.SubmittedAt,
+				LatencySeconds: seconds(review.TimeToReview),
+			})
+		}
+	}
+	return records
+}
+
+// WIPRecord is the flattened, export-friendly form of an open PR in a WIP snapshot
+type WIPRecord struct {
+	Repository   string    `parquet:"repository"`
+	PRNumber     int       `parquet:"pr_number"`
+	Title        string    `parquet:"title"`
+	Author       string    `parquet:"author"`
+	CreatedAt    time.Time `parquet:"created_at,timestamp(millisecond)"`
+	AgeSeconds   int64     `parquet:"age_seconds"`
+	Size         string    `parquet:"size"`
+	ReviewState  string    `parquet:"review_state"`
+	SLAStatus    string    `parquet:"sla_status"`
+	BreachedSLOs string    `parquet:"breached_slos"` // Comma-separated SLO metrics
+}
+
+// WIPRecords converts a WIP snapshot's items into export records
+func WIPRecords(items []wip.Item) []WIPRecord {
+	records := make([]WIPRecord, 0, len(items))
+	for _, item := range items {
+		records = append(records, WIPRecord{
+			Repository:   item.Repository,
+			PRNumber:     item.Number,
+			Title:        item.Title,
+			Author:       item.Author,
+			CreatedAt:    item.CreatedAt,
+			AgeSeconds:   seconds(item.Age),
+			Size:         item.Size,
+			ReviewState:  item.ReviewState,
+			SLAStatus:    item.SLA,
+			BreachedSLOs: strings.Join(item.Breached, ","),
+		})
+	}
+	return records
+}
+
+// CFDRecord is one day of a cumulative flow diagram
+type CFDRecord struct {
+	Date     string `parquet:"date"` // YYYY-MM-DD
+	Draft    int    `parquet:"draft"`
+	Open     int    `parquet:"open"`
+	Approved int    `parquet:"approved"`
+	Merged   int    `parquet:"merged"` // Merged since the first day
+}
+
+// CFDRecords converts a cumulative flow diagram's days into export records
+func CFDRecords(days []cfd.Day) []CFDRecord {
+	records := make([]CFDRecord, 0, len(days))
+	for _, day := range days {
+		records = append(records, CFDRecord{
+			Date:     day.Date,
+			Draft:    day.Draft,
+			Open:     day.Open,
+			Approved: day.Approved,
+			Merged:   day.Merged,
+		})
+	}
+	return records
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+// extraJSON encodes fields added by hooks as a JSON object, or "" if there are none,
+// since the export columns are fixed but hooks can add any fields
+func extraJSON(extra map[string]string) string {
+	if len(extra) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(extra)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
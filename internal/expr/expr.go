@@ -0,0 +1,436 @@
+// Package expr is a small expression language for filtering the records a report
+// covers and computing extra columns from them, so a team can tailor a recurring
+// report in its config file rather than post-processing the export:
+//
+//	pr-tracker:
+//	  where: 'class == "code" && !hotfix'
+//	  column:
+//	    - review_hours=hours(time_to_first_review_seconds)
+//	    - big=additions + deletions > 500
+//
+// Expressions use Go's syntax for literals and operators (+ - * / %, comparisons,
+// && || !, and parentheses), and refer to a record's fields by their export column
+// names. Numbers are float64s, times are RFC 3339 strings, and a few functions are
+// built in; see funcs.
+package expr
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"maps"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed expression
+type Expr struct {
+	src  string
+	node ast.Expr
+}
+
+// Parse parses an expression
+func Parse(src string) (*Expr, error) {
+	node, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	return &Expr{src: src, node: node}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression with the given fields
+func (e *Expr) Eval(fields map[string]any) (any, error) {
+	v, err := eval(e.node, fields)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating %q: %w", e.src, err)
+	}
+	return v, nil
+}
+
+// funcs are the functions expressions can call
+var funcs = map[string]func(args []any) (any, error){
+	"contains":  stringFunc2(strings.Contains),
+	"hasPrefix": stringFunc2(strings.HasPrefix),
+	"hasSuffix": stringFunc2(strings.HasSuffix),
+	"lower":     stringFunc1(strings.ToLower),
+	"upper":     stringFunc1(strings.ToUpper),
+	"hours":     numberFunc1(func(seconds float64) float64 { return seconds / 3600 }),
+	"days":      numberFunc1(func(seconds float64) float64 { return seconds / 86400 }),
+	"round":     numberFunc1(math.Round),
+	// cond(c, a, b) is a if c is true, and b otherwise
+	"cond": func(args []any) (any, error) {
+		if len(args) != 3 {
+			return nil, errors.New("expected 3 arguments")
+		}
+		c, ok := args[0].(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a bool condition, got %s", typeName(args[0]))
+		}
+		if c {
+			return args[1], nil
+		}
+		return args[2], nil
+	},
+}
+
+func eval(node ast.Expr, fields map[string]any) (any, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return eval(n.X, fields)
+
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.INT, token.FLOAT:
+			return strconv.ParseFloat(n.Value, 64)
+		case token.STRING:
+			return strconv.Unquote(n.Value)
+		}
+		return nil, fmt.Errorf("unsupported literal %s", n.Value)
+
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		v, ok := fields[n.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", n.Name)
+		}
+		return normalize(v), nil
+
+	case *ast.UnaryExpr:
+		x, err := eval(n.X, fields)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n.Op == token.NOT && isBool(x):
+			return !x.(bool), nil
+		case n.Op == token.SUB && isNumber(x):
+			return -x.(float64), nil
+		}
+		return nil, fmt.Errorf("can't apply %s to %s", n.Op, typeName(x))
+
+	case *ast.BinaryExpr:
+		return evalBinary(n, fields)
+
+	case *ast.CallExpr:
+		name, ok := n.Fun.(*ast.Ident)
+		if !ok || funcs[name.Name] == nil {
+			return nil, fmt.Errorf("unknown function %s", exprString(n.Fun))
+		}
+		args := make([]any, len(n.Args))
+		for i, arg := range n.Args {
+			v, err := eval(arg, fields)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		v, err := funcs[name.Name](args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name.Name, err)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported expression %s", exprString(node))
+}
+
+func evalBinary(n *ast.BinaryExpr, fields map[string]any) (any, error) {
+	x, err := eval(n.X, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || only evaluate their right side if they need to
+	if n.Op == token.LAND || n.Op == token.LOR {
+		if !isBool(x) {
+			return nil, fmt.Errorf("can't apply %s to %s", n.Op, typeName(x))
+		}
+		if x.(bool) == (n.Op == token.LOR) {
+			return x, nil
+		}
+		y, err := eval(n.Y, fields)
+		if err != nil {
+			return nil, err
+		}
+		if !isBool(y) {
+			return nil, fmt.Errorf("can't apply %s to %s", n.Op, typeName(y))
+		}
+		return y, nil
+	}
+
+	y, err := eval(n.Y, fields)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Op {
+	case token.EQL:
+		return x == y, nil
+	case token.NEQ:
+		return x != y, nil
+	}
+
+	switch x := x.(type) {
+	case float64:
+		if y, ok := y.(float64); ok {
+			switch n.Op {
+			case token.ADD:
+				return x + y, nil
+			case token.SUB:
+				return x - y, nil
+			case token.MUL:
+				return x * y, nil
+			case token.QUO:
+				return x / y, nil
+			case token.REM:
+				return math.Mod(x, y), nil
+			case token.LSS:
+				return x < y, nil
+			case token.LEQ:
+				return x <= y, nil
+			case token.GTR:
+				return x > y, nil
+			case token.GEQ:
+				return x >= y, nil
+			}
+		}
+	case string:
+		if y, ok := y.(string); ok {
+			switch n.Op {
+			case token.ADD:
+				return x + y, nil
+			case token.LSS:
+				return x < y, nil
+			case token.LEQ:
+				return x <= y, nil
+			case token.GTR:
+				return x > y, nil
+			case token.GEQ:
+				return x >= y, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't apply %s to %s and %s", n.Op, typeName(x), typeName(y))
+}
+
+// normalize converts a field's value to one of the types expressions work with:
+// float64, string or bool
+func normalize(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String:
+		return rv.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func isBool(v any) bool {
+	_, ok := v.(bool)
+	return ok
+}
+
+func isNumber(v any) bool {
+	_, ok := v.(float64)
+	return ok
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func exprString(node ast.Expr) string {
+	var b strings.Builder
+	if err := format.Node(&b, token.NewFileSet(), node); err != nil {
+		return fmt.Sprintf("%T", node)
+	}
+	return b.String()
+}
+
+func stringFunc1(f func(string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if len(args) != 1 || !isString(args[0]) {
+			return nil, errors.New("expected 1 string argument")
+		}
+		return f(args[0].(string)), nil
+	}
+}
+
+func stringFunc2(f func(string, string) bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if len(args) != 2 || !isString(args[0]) || !isString(args[1]) {
+			return nil, errors.New("expected 2 string arguments")
+		}
+		return f(args[0].(string), args[1].(string)), nil
+	}
+}
+
+func numberFunc1(f func(float64) float64) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		if len(args) != 1 || !isNumber(args[0]) {
+			return nil, errors.New("expected 1 number argument")
+		}
+		return f(args[0].(float64)), nil
+	}
+}
+
+func isString(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+// Format formats a value for a report or export column
+func Format(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// Column is a computed column: a name and the expression computing its value
+type Column struct {
+	Name string
+	Expr *Expr
+}
+
+// ParseColumn parses a name=expression column spec
+func ParseColumn(spec string) (Column, error) {
+	name, src, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !token.IsIdentifier(name) {
+		return Column{}, fmt.Errorf("invalid column %q, expected name=expression", spec)
+	}
+	e, err := Parse(src)
+	if err != nil {
+		return Column{}, err
+	}
+	return Column{Name: name, Expr: e}, nil
+}
+
+// Columns is a repeatable command-line flag of computed columns
+type Columns []Column
+
+func (c *Columns) String() string {
+	var specs []string
+	for _, column := range *c {
+		specs = append(specs, column.Name+"="+column.Expr.String())
+	}
+	return strings.Join(specs, ", ")
+}
+
+// Set parses and adds a column
+func (c *Columns) Set(spec string) error {
+	column, err := ParseColumn(spec)
+	if err != nil {
+		return err
+	}
+	*c = append(*c, column)
+	return nil
+}
+
+// Rules are the filter and computed columns applied to a report's records
+type Rules struct {
+	Where   *Expr // nil keeps every record
+	Columns Columns
+}
+
+// Apply evaluates the rules on a record with the given fields, plus the extra fields
+// hooks added to it. It returns the extra fields with the computed columns added,
+// formatted, and whether the record passes the filter. Columns are computed in
+// order, before filtering, so each can use the ones before it and the filter can
+// use them all.
+func (r *Rules) Apply(fields map[string]any, extra map[string]string) (map[string]string, bool, error) {
+	vars := make(map[string]any, len(fields)+len(extra)+len(r.Columns))
+	for name, value := range extra {
+		vars[name] = value
+	}
+	for name, value := range fields {
+		vars[name] = value
+	}
+
+	extra = maps.Clone(extra)
+	for _, column := range r.Columns {
+		v, err := column.Expr.Eval(vars)
+		if err != nil {
+			return nil, false, fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		vars[column.Name] = v
+		if extra == nil {
+			extra = make(map[string]string, len(r.Columns))
+		}
+		extra[column.Name] = Format(v)
+	}
+
+	if r.Where == nil {
+		return extra, true, nil
+	}
+	v, err := r.Where.Eval(vars)
+	if err != nil {
+		return nil, false, err
+	}
+	keep, ok := v.(bool)
+	if !ok {
+		return nil, false, fmt.Errorf("filter %q is a %s, not a bool", r.Where, typeName(v))
+	}
+	return extra, keep, nil
+}
+
+// Flags holds the values of the expression flags
+type Flags struct {
+	Where   *string
+	Columns *Columns
+}
+
+// RegisterFlags defines the expression flags shared by the trackers
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		Where:   fs.String("where", "", "Expression records must match to be reported or exported, e.g. 'class == \"code\" && !hotfix'"),
+		Columns: &Columns{},
+	}
+	fs.Var(f.Columns, "column", "Computed column to add to the report and export, as name=expression, e.g. review_hours=hours(time_to_first_review_seconds) (repeatable)")
+	return f
+}
+
+// Rules returns the selected rules, or nil if there are none
+func (f *Flags) Rules() (*Rules, error) {
+	if *f.Where == "" && len(*f.Columns) == 0 {
+		return nil, nil
+	}
+	rules := &Rules{Columns: *f.Columns}
+	if *f.Where != "" {
+		where, err := Parse(*f.Where)
+		if err != nil {
+			return nil, err
+		}
+		rules.Where = where
+	}
+	return rules, nil
+}
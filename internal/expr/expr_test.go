@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	fields := map[string]any{
+		"additions":                    400,
+		"deletions":                    int64(200),
+		"class":                        "code",
+		"hotfix":                       false,
+		"review_depth":                 1.5,
+		"time_to_first_review_seconds": int64(5400),
+		"created_at":                   "2024-03-01T09:00:00Z",
+	}
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"additions + deletions", 600.0},
+		{"additions + deletions > 500", true},
+		{"(additions - deletions) * 2 / 4", 100.0},
+		{"additions % 300", 100.0},
+		{"-review_depth", -1.5},
+		{`class == "code" && !hotfix`, true},
+		{`class != "code" || hotfix`, false},
+		{`class + "/" + upper(class)`, "code/CODE"},
+		{"hours(time_to_first_review_seconds)", 1.5},
+		{"round(days(time_to_first_review_seconds) * 100)", 6.0},
+		{`created_at >= "2024-03-01"`, true},
+		{`contains(class, "od") && hasPrefix(class, "c") && hasSuffix(lower("X.GO"), ".go")`, true},
+		{`cond(additions > 100, "big", "small")`, "big"},
+		// The right side isn't evaluated, so its unknown field doesn't matter
+		{"hotfix && missing > 1", false},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		got, err := e.Eval(fields)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	fields := map[string]any{"additions": 10, "class": "code"}
+	tests := []struct {
+		expr, want string
+	}{
+		{"missing > 1", `unknown field "missing"`},
+		{`additions + class`, "can't apply + to number and string"},
+		{"!additions", "can't apply ! to number"},
+		{"additions && true", "can't apply && to number"},
+		{"exec(class)", "unknown function exec"},
+		{"upper(additions)", "upper: expected 1 string argument"},
+		{"class[0]", "unsupported expression class[0]"},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if _, err := e.Eval(fields); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Eval(%q) error = %v, want it to contain %q", tt.expr, err, tt.want)
+		}
+	}
+
+	if _, err := Parse("additions +"); err == nil {
+		t.Error("Expected an error parsing an incomplete expression")
+	}
+}
+
+func TestRules_Apply(t *testing.T) {
+	var columns Columns
+	for _, spec := range []string{"size=additions + deletions", "big=size > 100"} {
+		if err := columns.Set(spec); err != nil {
+			t.Fatalf("Set(%q): %v", spec, err)
+		}
+	}
+	where, err := Parse(`big || team == "infra"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := &Rules{Where: where, Columns: columns}
+
+	extra, keep, err := rules.Apply(map[string]any{"additions": 150, "deletions": 0}, map[string]string{"team": "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keep {
+		t.Error("Expected a big change to pass the filter")
+	}
+	want := map[string]string{"team": "web", "size": "150", "big": "true"}
+	if len(extra) != len(want) {
+		t.Errorf("extra = %v, want %v", extra, want)
+	}
+	for name, value := range want {
+		if extra[name] != value {
+			t.Errorf("extra[%q] = %q, want %q", name, extra[name], value)
+		}
+	}
+
+	// Hook fields can be filtered on too
+	if _, keep, err := rules.Apply(map[string]any{"additions": 1, "deletions": 1}, map[string]string{"team": "infra"}); err != nil || !keep {
+		t.Errorf("Apply() = %v, %v, want the infra team's change kept", keep, err)
+	}
+	if _, keep, err := rules.Apply(map[string]any{"additions": 1, "deletions": 1}, map[string]string{"team": "web"}); err != nil || keep {
+		t.Errorf("Apply() = %v, %v, want a small web change dropped", keep, err)
+	}
+
+	notBool, _ := Parse("additions")
+	if _, _, err := (&Rules{Where: notBool}).Apply(map[string]any{"additions": 1}, nil); err == nil {
+		t.Error("Expected an error for a filter that isn't a bool")
+	}
+}
+
+func TestParseColumn(t *testing.T) {
+	for _, spec := range []string{"no-equals", "=additions", "bad name=additions", "x=additions +"} {
+		if _, err := ParseColumn(spec); err == nil {
+			t.Errorf("ParseColumn(%q): expected an error", spec)
+		}
+	}
+	column, err := ParseColumn("review_hours = hours(time_to_first_review_seconds)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if column.Name != "review_hours" {
+		t.Errorf("Name = %q, want review_hours", column.Name)
+	}
+}
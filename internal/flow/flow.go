@@ -0,0 +1,186 @@
+// Package flow puts the three standard flow metrics side by side, per team per
+// week: WIP (how many PRs were open, on average), throughput (how many were
+// merged) and cycle time (how long those took from being opened to merging).
+// Little's Law says that over a stable period average WIP is throughput times
+// average cycle time, so a week where they disagree is one where WIP was
+// growing or shrinking, work was abandoned rather than merged, or the WIP
+// history has gaps.
+package flow
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+const (
+	AllTeams = "all"  // The team of a Week covering everyone
+	NoTeam   = "none" // The team of a Week covering authors who aren't on one
+)
+
+// DefaultTolerance is how far apart measured WIP and the WIP Little's Law
+// predicts can be, relative to the larger, for a week to count as consistent
+const DefaultTolerance = 0.25
+
+// Week is one team's flow over one week
+type Week struct {
+	Period     period.Period
+	Team       string        // AllTeams, a team, or NoTeam
+	WIP        float64       // Mean PRs open on the days with a WIP snapshot
+	WIPDays    int           // Days in the week with a WIP snapshot, 0 if WIP wasn't measured
+	Throughput int           // PRs merged in the week
+	CycleTime  time.Duration // Mean time from opening to merging of the PRs merged
+}
+
+// PredictedWIP is the average WIP Little's Law predicts from the week's
+// throughput and cycle time
+func (w Week) PredictedWIP() float64 {
+	days := w.Period.End.Sub(w.Period.Start).Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+	return float64(w.Throughput) / days * w.CycleTime.Hours() / 24
+}
+
+// Deviation is how far apart measured WIP and PredictedWIP are, relative to the
+// larger of the two. ok is false if the week's WIP wasn't measured or nothing
+// was merged, when there's nothing to check.
+func (w Week) Deviation() (deviation float64, ok bool) {
+	if w.WIPDays == 0 || w.Throughput == 0 {
+		return 0, false
+	}
+	predicted := w.PredictedWIP()
+	larger := max(w.WIP, predicted)
+	if larger == 0 {
+		return 0, true
+	}
+	return math.Abs(w.WIP-predicted) / larger, true
+}
+
+// Summarize works out each team's flow in each week. Throughput and cycle time
+// come from the merged PRs among results, and WIP from the daily snapshots of a
+// WIP history. teamOf returns a PR author's team, or ""; if it's nil, only
+// AllTeams is reported. Weeks are returned in order, each with AllTeams first,
+// then the teams in name order and NoTeam last.
+func Summarize(weeks []period.Period, results []github.PullRequestMetric, snapshots []wip.Snapshot, teamOf func(author string) string) []Week {
+	// The teams a merged PR counts towards
+	teamsOf := func(author string) []string {
+		if teamOf == nil {
+			return []string{AllTeams}
+		}
+		if t := teamOf(author); t != "" {
+			return []string{AllTeams, t}
+		}
+		return []string{AllTeams, NoTeam}
+	}
+
+	// Open PRs each day, summed across repositories
+	type day struct {
+		date time.Time
+		open map[string]int // By team, AllTeams included
+	}
+	var days []*day
+	for _, snapshot := range snapshots {
+		if len(days) == 0 || days[len(days)-1].date.Format("2006-01-02") != snapshot.Date {
+			date, err := time.ParseInLocation("2006-01-02", snapshot.Date, time.Local)
+			if err != nil {
+				continue
+			}
+			days = append(days, &day{date: date, open: make(map[string]int)})
+		}
+		d := days[len(days)-1]
+		d.open[AllTeams] += snapshot.Open
+		if teamOf != nil {
+			onTeams := 0
+			for t, open := range snapshot.ByTeam {
+				d.open[t] += open
+				onTeams += open
+			}
+			if snapshot.Open > onTeams {
+				d.open[NoTeam] += snapshot.Open - onTeams
+			}
+		}
+	}
+
+	// Every team anything was seen for
+	teams := map[string]bool{AllTeams: true}
+	for _, d := range days {
+		for t := range d.open {
+			teams[t] = true
+		}
+	}
+	for _, result := range results {
+		if !result.MergedAt.IsZero() {
+			for _, t := range teamsOf(result.Author) {
+				teams[t] = true
+			}
+		}
+	}
+	order := make([]string, 0, len(teams))
+	for t := range teams {
+		order = append(order, t)
+	}
+	slices.SortFunc(order, func(a, b string) int {
+		return cmp.Or(cmp.Compare(teamRank(a), teamRank(b)), cmp.Compare(a, b))
+	})
+
+	var summary []Week
+	for _, p := range weeks {
+		byTeam := make(map[string]*Week)
+		for _, t := range order {
+			byTeam[t] = &Week{Period: p, Team: t}
+		}
+
+		cycleTimes := make(map[string]time.Duration)
+		for _, result := range results {
+			if result.MergedAt.IsZero() || !p.Contains(result.MergedAt) {
+				continue
+			}
+			for _, t := range teamsOf(result.Author) {
+				byTeam[t].Throughput++
+				cycleTimes[t] += result.MergedAt.Sub(result.CreatedAt)
+			}
+		}
+
+		wipTotals := make(map[string]int)
+		wipDays := 0
+		for _, d := range days {
+			if !p.Contains(d.date) {
+				continue
+			}
+			wipDays++
+			for t, open := range d.open {
+				wipTotals[t] += open
+			}
+		}
+
+		for _, t := range order {
+			w := byTeam[t]
+			if w.Throughput > 0 {
+				w.CycleTime = cycleTimes[t] / time.Duration(w.Throughput)
+			}
+			if wipDays > 0 {
+				w.WIPDays = wipDays
+				w.WIP = float64(wipTotals[t]) / float64(wipDays)
+			}
+			summary = append(summary, *w)
+		}
+	}
+	return summary
+}
+
+// teamRank orders AllTeams first and NoTeam last, with the teams between
+func teamRank(team string) int {
+	switch team {
+	case AllTeams:
+		return 0
+	case NoTeam:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,79 @@
+package flow
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+func TestSummarize(t *testing.T) {
+	monday := time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local)
+	week := period.Period{Name: "2024-W23", Start: monday, End: monday.AddDate(0, 0, 7)}
+	at := func(day, hour int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	}
+
+	results := []github.PullRequestMetric{
+		// Two of alice's PRs merged, after a day and after three days
+		{Author: "alice", CreatedAt: at(0, 9), MergedAt: at(1, 9)},
+		{Author: "alice", CreatedAt: at(0, 9), MergedAt: at(3, 9)},
+		// One of carol's, after two days; she isn't on a team
+		{Author: "carol", CreatedAt: at(1, 9), MergedAt: at(3, 9)},
+		// Still open, and merged the week after
+		{Author: "alice", CreatedAt: at(2, 9)},
+		{Author: "alice", CreatedAt: at(2, 9), MergedAt: at(8, 9)},
+	}
+	snapshots := []wip.Snapshot{
+		{Date: "2024-06-03", Repository: "o/a", Open: 2, ByTeam: map[string]int{"payments": 2}},
+		{Date: "2024-06-03", Repository: "o/b", Open: 1},
+		{Date: "2024-06-04", Repository: "o/a", Open: 1, ByTeam: map[string]int{"payments": 1}},
+		{Date: "2024-06-04", Repository: "o/b", Open: 0},
+	}
+	teamOf := func(author string) string {
+		if author == "alice" {
+			return "payments"
+		}
+		return ""
+	}
+
+	weeks := Summarize([]period.Period{week}, results, snapshots, teamOf)
+	if len(weeks) != 3 || weeks[0].Team != AllTeams || weeks[1].Team != "payments" || weeks[2].Team != NoTeam {
+		t.Fatalf("Expected all, payments and none, got %+v", weeks)
+	}
+
+	all := weeks[0]
+	if all.Throughput != 3 || all.CycleTime != 48*time.Hour {
+		t.Errorf("Expected 3 PRs merged in 2 days on average, got %d in %v", all.Throughput, all.CycleTime)
+	}
+	if all.WIPDays != 2 || all.WIP != 2 {
+		t.Errorf("Expected 2 PRs open on average over 2 days, got %v over %d", all.WIP, all.WIPDays)
+	}
+	// 3 PRs a week is 3/7 a day, times 2 days
+	if predicted := all.PredictedWIP(); math.Abs(predicted-6.0/7) > 1e-9 {
+		t.Errorf("Expected 6/7 PRs of predicted WIP, got %v", predicted)
+	}
+	if deviation, ok := all.Deviation(); !ok || math.Abs(deviation-(2-6.0/7)/2) > 1e-9 {
+		t.Errorf("Expected measured and predicted WIP to be 57%% apart, got %v (%v)", deviation, ok)
+	}
+
+	payments, none := weeks[1], weeks[2]
+	if payments.Throughput != 2 || payments.CycleTime != 48*time.Hour || payments.WIP != 1.5 {
+		t.Errorf("Unexpected payments flow %+v", payments)
+	}
+	if none.Throughput != 1 || none.CycleTime != 48*time.Hour || none.WIP != 0.5 {
+		t.Errorf("Unexpected flow for authors on no team %+v", none)
+	}
+
+	// Without teams, and without a WIP history, there's just the week's throughput
+	weeks = Summarize([]period.Period{week}, results, nil, nil)
+	if len(weeks) != 1 || weeks[0].Team != AllTeams || weeks[0].Throughput != 3 || weeks[0].WIPDays != 0 {
+		t.Fatalf("Expected everyone's throughput alone, got %+v", weeks)
+	}
+	if _, ok := weeks[0].Deviation(); ok {
+		t.Error("Expected no consistency check without WIP")
+	}
+}
@@ -0,0 +1,168 @@
+// Package forecast answers "when will these N PRs be merged?" with a Monte Carlo
+// simulation: it replays weeks of throughput drawn at random from the weeks a
+// WIP history recorded until the remaining work is done, many times over, and
+// reports the completion dates reached by given fractions of the trials. Unlike
+// dividing by average throughput, this keeps the history's week-to-week spread,
+// so the bands widen when throughput is erratic.
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/period"
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+// MaxWeeks caps a trial, so a history of mostly empty weeks can't run forever
+const MaxWeeks = 520
+
+// ErrNoThroughput is returned when the history has no complete week in which
+// anything was merged, so there's nothing to forecast from
+var ErrNoThroughput = errors.New("no weeks with merges recorded")
+
+// Week is the number of PRs merged in one calendar week
+type Week struct {
+	Period period.Period
+	Merged int
+}
+
+// WeeklyThroughput works out how many PRs were merged in each calendar week from
+// a WIP history's merged counts, summed across repositories, oldest first. A
+// repository's count on a day is the last one recorded on or before it, and a
+// week is only included if every repository with counts has one from before the
+// week began and another from its last day or later, so a week the history only
+// partly covers isn't mistaken for a slow one.
+func WeeklyThroughput(snapshots []wip.Snapshot) []Week {
+	type count struct {
+		date   time.Time
+		merged int
+	}
+	byRepo := make(map[string][]count) // Oldest first
+	var first, last time.Time
+	for _, snapshot := range snapshots {
+		if snapshot.Merged == 0 {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", snapshot.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		byRepo[snapshot.Repository] = append(byRepo[snapshot.Repository], count{date, snapshot.Merged})
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+	if len(byRepo) == 0 {
+		return nil
+	}
+
+	// The count recorded on or before a day, if there is one
+	mergedBy := func(counts []count, day time.Time) (int, bool) {
+		i, found := slices.BinarySearchFunc(counts, day, func(c count, t time.Time) int {
+			return c.date.Compare(t)
+		})
+		if found {
+			return counts[i].merged, true
+		}
+		if i == 0 {
+			return 0, false
+		}
+		return counts[i-1].merged, true
+	}
+
+	var weeks []Week
+	for _, p := range period.Weeks(first, last.AddDate(0, 0, 1)) {
+		lastDay := p.End.AddDate(0, 0, -1)
+		dayBefore := p.Start.AddDate(0, 0, -1)
+		week := Week{Period: p}
+		complete := true
+		for _, counts := range byRepo {
+			before, ok := mergedBy(counts, dayBefore)
+			if !ok || counts[len(counts)-1].date.Before(lastDay) {
+				complete = false
+				break
+			}
+			after, _ := mergedBy(counts, lastDay)
+			week.Merged += max(after-before, 0)
+		}
+		if complete {
+			weeks = append(weeks, week)
+		}
+	}
+	return weeks
+}
+
+// Band is when a fraction of the trials had finished
+type Band struct {
+	Percentile int // Percent of trials finished by Date
+	Weeks      int // Weeks from the start
+	Date       time.Time
+}
+
+// Forecast is the outcome of a simulation
+type Forecast struct {
+	Remaining int
+	Trials    int
+	Bands     []Band // In the order the percentiles were given
+	Unlikely  int    // Trials that hadn't finished after MaxWeeks
+}
+
+// Simulate forecasts when remaining PRs will have been merged, starting from start,
+// by drawing a week's throughput at random from samples until they're done, trials
+// times over. Each band is the number of whole weeks that the given percentile of
+// trials finished within.
+func Simulate(samples []int, remaining, trials int, percentiles []int, start time.Time, rng *rand.Rand) (*Forecast, error) {
+	if remaining < 0 {
+		return nil, fmt.Errorf("remaining work can't be negative, got %d", remaining)
+	}
+	if trials <= 0 {
+		return nil, fmt.Errorf("trials must be positive, got %d", trials)
+	}
+	for _, p := range percentiles {
+		if p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentiles must be between 1 and 100, got %d", p)
+		}
+	}
+	if !slices.ContainsFunc(samples, func(n int) bool { return n > 0 }) {
+		return nil, ErrNoThroughput
+	}
+
+	f := &Forecast{Remaining: remaining, Trials: trials}
+	outcomes := make([]int, trials)
+	for i := range outcomes {
+		weeks, done := 0, 0
+		for done < remaining && weeks < MaxWeeks {
+			done += samples[rng.IntN(len(samples))]
+			weeks++
+		}
+		if done < remaining {
+			f.Unlikely++
+		}
+		outcomes[i] = weeks
+	}
+	slices.Sort(outcomes)
+
+	for _, p := range percentiles {
+		// The smallest number of weeks at least p% of trials finished within
+		i := (p*trials+99)/100 - 1
+		weeks := outcomes[i]
+		f.Bands = append(f.Bands, Band{Percentile: p, Weeks: weeks, Date: start.AddDate(0, 0, 7*weeks)})
+	}
+	return f, nil
+}
+
+// Throughputs returns the merged counts of weeks, to draw from
+func Throughputs(weeks []Week) []int {
+	samples := make([]int, 0, len(weeks))
+	for _, w := range weeks {
+		samples = append(samples, w.Merged)
+	}
+	return samples
+}
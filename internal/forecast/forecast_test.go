@@ -0,0 +1,86 @@
+package forecast
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/wip"
+)
+
+func TestWeeklyThroughput(t *testing.T) {
+	snapshots := []wip.Snapshot{
+		// Counted before merges were, so ignored
+		{Date: "2024-05-30", Repository: "o/a", Open: 3},
+		// Sunday before 2024-W23, then daily through 2024-W24 with gaps
+		{Date: "2024-06-02", Repository: "o/a", Merged: 100},
+		{Date: "2024-06-02", Repository: "o/b", Merged: 50},
+		{Date: "2024-06-05", Repository: "o/a", Merged: 104},
+		{Date: "2024-06-09", Repository: "o/a", Merged: 110},
+		{Date: "2024-06-09", Repository: "o/b", Merged: 52},
+		// o/b's last count is on Wednesday of 2024-W24, so that week isn't complete
+		{Date: "2024-06-12", Repository: "o/b", Merged: 53},
+		{Date: "2024-06-16", Repository: "o/a", Merged: 111},
+	}
+
+	weeks := WeeklyThroughput(snapshots)
+	if len(weeks) != 1 {
+		t.Fatalf("Expected only 2024-W23 to be complete, got %+v", weeks)
+	}
+	if weeks[0].Period.Name != "2024-W23" || weeks[0].Merged != 12 {
+		t.Errorf("Expected 12 merged in 2024-W23, got %+v", weeks[0])
+	}
+
+	if weeks := WeeklyThroughput(snapshots[:1]); len(weeks) != 0 {
+		t.Errorf("Expected no weeks without merged counts, got %+v", weeks)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	start := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	// Steady throughput always takes the same time
+	f, err := Simulate([]int{5}, 20, 1000, []int{50, 95}, start, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, band := range f.Bands {
+		if band.Weeks != 4 || !band.Date.Equal(start.AddDate(0, 0, 28)) {
+			t.Errorf("Expected 4 weeks at the %dth percentile, got %+v", band.Percentile, band)
+		}
+	}
+
+	// Erratic throughput spreads the bands out, later for higher confidence
+	f, err = Simulate([]int{0, 2, 10}, 30, 5000, []int{50, 70, 85, 95}, start, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weeks := make([]int, len(f.Bands))
+	for i, band := range f.Bands {
+		weeks[i] = band.Weeks
+	}
+	if !slices.IsSorted(weeks) || weeks[0] == weeks[len(weeks)-1] {
+		t.Errorf("Expected bands to widen with confidence, got %v", weeks)
+	}
+	// 30 PRs at 4 a week on average is around 7.5 weeks
+	if weeks[0] < 5 || weeks[0] > 10 {
+		t.Errorf("Expected the median around 7 or 8 weeks, got %d", weeks[0])
+	}
+
+	if _, err := Simulate([]int{0, 0}, 10, 100, []int{50, 70, 85, 95}, start, rng); !errors.Is(err, ErrNoThroughput) {
+		t.Errorf("Expected ErrNoThroughput without merges, got %v", err)
+	}
+	if _, err := Simulate([]int{1}, 10, 100, []int{101}, start, rng); err == nil {
+		t.Error("Expected an error for a percentile over 100")
+	}
+}
+
+func TestThroughputs(t *testing.T) {
+	weeks := []Week{{Merged: 1}, {Merged: 2}, {Merged: 3}}
+	if got := Throughputs(weeks); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Expected every week's merged count, got %v", got)
+	}
+}
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// appAPIURL is where installation tokens are requested
+const appAPIURL = "https://api.github.com"
+
+// tokenRefreshMargin is how long before an installation token expires that it's
+// replaced, so a request made just before expiry doesn't fail part way through
+const tokenRefreshMargin = 5 * time.Minute
+
+// ErrNoCredentials is returned by TokenSourceFromEnv when neither a token nor a
+// GitHub App is configured
+var ErrNoCredentials = errors.New("GITHUB_TOKEN environment variable not set (or GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PRIVATE_KEY_FILE for a GitHub App)")
+
+// TokenSourceFromEnv returns the credentials to call GitHub with. If GITHUB_APP_ID
+// is set, the tools authenticate as that GitHub App's installation, with the
+// installation from GITHUB_APP_INSTALLATION_ID and the App's private key read from
+// GITHUB_APP_PRIVATE_KEY_FILE (or given directly in GITHUB_APP_PRIVATE_KEY).
+// Otherwise GITHUB_TOKEN is used.
+func TokenSourceFromEnv() (oauth2.TokenSource, error) {
+	appIDStr := os.Getenv("GITHUB_APP_ID")
+	if appIDStr == "" {
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			return nil, ErrNoCredentials
+		}
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), nil
+	}
+
+	appID, err := strconv.ParseInt(appIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_ID %q: %w", appIDStr, err)
+	}
+	installationIDStr := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	if installationIDStr == "" {
+		return nil, errors.New("GITHUB_APP_INSTALLATION_ID environment variable not set (needed with GITHUB_APP_ID)")
+	}
+	installationID, err := strconv.ParseInt(installationIDStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID %q: %w", installationIDStr, err)
+	}
+	privateKey := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if path := os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"); path != "" {
+		if privateKey, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+	}
+	if len(privateKey) == 0 {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY_FILE environment variable not set (needed with GITHUB_APP_ID)")
+	}
+	return NewAppTokenSource(appID, installationID, privateKey)
+}
+
+// NewAppTokenSource returns a token source that authenticates as a GitHub App's
+// installation. privateKey is the App's PEM-encoded private key, as downloaded from
+// the App's settings. Installation tokens last an hour; a new one is requested
+// shortly before the current one expires, so long runs keep working.
+func NewAppTokenSource(appID, installationID int64, privateKey []byte) (oauth2.TokenSource, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	ts := &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		baseURL:        appAPIURL,
+		httpClient:     http.DefaultClient,
+		now:            time.Now,
+	}
+	return oauth2.ReuseTokenSource(nil, ts), nil
+}
+
+// appTokenSource requests installation tokens, each time it's asked for one.
+// oauth2.ReuseTokenSource keeps the current token until it's about to expire.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	baseURL        string
+	httpClient     *http.Client
+	now            func() time.Time
+}
+
+// Token exchanges a JWT signed with the App's private key for an installation token
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt()
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.baseURL, s.installationID)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create installation token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("installation token request for app %d failed with status %d", s.appID, resp.StatusCode)
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode installation token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: result.Token,
+		TokenType:   "token",
+		Expiry:      result.ExpiresAt.Add(-tokenRefreshMargin),
+	}, nil
+}
+
+// jwt returns a JSON Web Token identifying the App, signed with RS256. GitHub
+// accepts them for at most 10 minutes; the issue time is backdated a minute to
+// allow for clock drift.
+func (s *appTokenSource) jwt() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey parses a PEM-encoded RSA private key. GitHub issues PKCS #1
+// keys, but PKCS #8 is accepted too, for keys that have been converted.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
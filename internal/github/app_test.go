@@ -0,0 +1,116 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	requests := 0
+	expiresIn := time.Hour
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		// The JWT must be signed with the App's key and name the App
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Fatalf("Expected a JWT, got Authorization %q", r.Header.Get("Authorization"))
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("Bad JWT signature: %v", err)
+		}
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			t.Fatalf("Bad JWT claims: %v", err)
+		}
+		if claims.Iss != "7" || claims.Iat > now.Unix() || claims.Exp-claims.Iat > 10*60 {
+			t.Errorf("Unexpected JWT claims %+v", claims)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, requests, time.Now().Add(expiresIn).Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	ts := oauth2.ReuseTokenSource(nil, &appTokenSource{
+		appID:          7,
+		installationID: 42,
+		key:            key,
+		baseURL:        server.URL,
+		httpClient:     server.Client(),
+		now:            func() time.Time { return now },
+	})
+
+	token, err := ts.Token()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if token.AccessToken != "ghs_1" {
+		t.Errorf("Expected the installation token, got %q", token.AccessToken)
+	}
+	if token, _ := ts.Token(); token.AccessToken != "ghs_1" || requests != 1 {
+		t.Errorf("Expected the token to be reused until it nears expiry, got %q after %d requests", token.AccessToken, requests)
+	}
+
+	// A token about to expire is replaced before it's used again
+	expiresIn = tokenRefreshMargin / 2
+	ts = oauth2.ReuseTokenSource(nil, &appTokenSource{appID: 7, installationID: 42, key: key, baseURL: server.URL, httpClient: server.Client(), now: func() time.Time { return now }})
+	ts.Token()
+	if token, _ := ts.Token(); token.AccessToken != "ghs_3" {
+		t.Errorf("Expected a new token once the old one neared expiry, got %q", token.AccessToken)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, block := range map[string]*pem.Block{
+		"PKCS1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"PKCS8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := parsePrivateKey(pem.EncodeToMemory(block))
+		if err != nil || !parsed.Equal(key) {
+			t.Errorf("%s: expected the key back, got error %v", name, err)
+		}
+	}
+	if _, err := parsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("Expected an error for a key that isn't PEM-encoded")
+	}
+}
@@ -0,0 +1,148 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/perfbudget"
+)
+
+// benchmarkSizes are the numbers of PRs benchmarks run with, from a busy repository's
+// month up to an organization's year
+var benchmarkSizes = []int{1_000, 10_000, 100_000}
+
+// Performance budgets per PR. Raise one deliberately, in the change that needs it,
+// rather than to make a flaky run pass.
+var (
+	processBudget   = perfbudget.Budget{Time: 100 * time.Microsecond, Allocs: 36}
+	summarizeBudget = perfbudget.Budget{Time: 10 * time.Microsecond, Allocs: 1}
+)
+
+// benchmarkClient returns a client that gives every PR the same two reviews, commits,
+// files and comments, so processing does the work of a typical reviewed PR
+func benchmarkClient() *MockGitHubClient {
+	reviewedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	review := func(login, state string, at time.Time) *Review {
+		return &Review{User: User{Login: login}, State: state, SubmittedAt: at}
+	}
+	commit := func(at time.Time) *Commit {
+		return &Commit{Commit: CommitDetail{
+			Author:    CommitSignature{Date: at},
+			Committer: CommitSignature{Date: at},
+		}}
+	}
+	file := func(name string, additions, deletions int) *File {
+		return &File{Filename: name, Additions: additions, Deletions: deletions, Changes: additions + deletions}
+	}
+	return &MockGitHubClient{
+		reviews: []*Review{
+			review("reviewer1", "COMMENTED", reviewedAt),
+			review("reviewer2", "APPROVED", reviewedAt.Add(2*time.Hour)),
+		},
+		prCommits: []*Commit{commit(reviewedAt.Add(-48 * time.Hour)), commit(reviewedAt.Add(time.Hour))},
+		files:     []*File{file("internal/widget.go", 120, 30), file("internal/widget_test.go", 80, 0), file("README.md", 5, 1)},
+		comments: []*Comment{
+			{User: User{Login: "reviewer1"}, CreatedAt: reviewedAt},
+			{User: User{Login: "reviewer2"}, CreatedAt: reviewedAt},
+		},
+		issueComments: []*Comment{{User: User{Login: "reviewer1"}, CreatedAt: reviewedAt}},
+	}
+}
+
+// benchmarkPullRequests returns n PRs by a few dozen authors, all opened in the day
+// before benchmarkClient's reviews. Most are merged; the rest are still open.
+func benchmarkPullRequests(n int) []*PullRequest {
+	opened := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	prs := make([]*PullRequest, n)
+	for i := range prs {
+		createdAt := opened.Add(-time.Duration(i%1000) * time.Minute)
+		pr := &PullRequest{
+			Number:    i + 1,
+			Title:     fmt.Sprintf("feat: widget %d", i),
+			User:      User{Login: fmt.Sprintf("author%d", i%40)},
+			State:     "open",
+			CreatedAt: createdAt,
+			Base:      Branch{Ref: "main"},
+			Head:      Branch{Ref: fmt.Sprintf("widget-%d", i)},
+		}
+		if i%5 != 0 {
+			mergedAt := createdAt.Add(36 * time.Hour)
+			pr.State = "closed"
+			pr.MergedAt = mergedAt
+		}
+		prs[i] = pr
+	}
+	return prs
+}
+
+// benchmarkOptions turns on the stages that don't need anything more from the client
+var benchmarkOptions = ProcessOptions{
+	RequiredApprovals: 1,
+	Languages:         true,
+	Classify:          true,
+	Size:              true,
+	CodingTime:        true,
+	Comments:          true,
+	FirstResponse:     true,
+}
+
+// quietLog discards log output while a benchmark runs, as processing logs per PR
+// when anything is missing
+func quietLog(tb testing.TB) {
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func BenchmarkProcessPullRequests(b *testing.B) {
+	quietLog(b)
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			client := benchmarkClient()
+			prs := benchmarkPullRequests(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ProcessPullRequests(context.Background(), client, prs, "owner", "repo", benchmarkOptions)
+			}
+		})
+	}
+}
+
+func BenchmarkSummarizeByAuthor(b *testing.B) {
+	quietLog(b)
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			results := ProcessPullRequests(context.Background(), benchmarkClient(), benchmarkPullRequests(n), "owner", "repo", benchmarkOptions)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				SummarizeByAuthor(results)
+			}
+		})
+	}
+}
+
+func TestPerformanceBudget_ProcessPullRequests(t *testing.T) {
+	quietLog(t)
+	client := benchmarkClient()
+	prs := benchmarkPullRequests(1_000)
+	if results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", benchmarkOptions); len(results) != len(prs) {
+		t.Fatalf("Expected %d results, got %d", len(prs), len(results))
+	}
+	perfbudget.Check(t, processBudget, len(prs), func() {
+		ProcessPullRequests(context.Background(), client, prs, "owner", "repo", benchmarkOptions)
+	})
+}
+
+func TestPerformanceBudget_SummarizeByAuthor(t *testing.T) {
+	quietLog(t)
+	results := ProcessPullRequests(context.Background(), benchmarkClient(), benchmarkPullRequests(1_000), "owner", "repo", benchmarkOptions)
+	perfbudget.Check(t, summarizeBudget, len(results), func() {
+		SummarizeByAuthor(results)
+	})
+}
@@ -1,11 +1,14 @@
 package github
 
 import (
+	"context"
 	"log"
 	"time"
 
-	"github.com/google/go-github/v39/github"
+	"github.com/reillywatson/statstracker/internal/budget"
 	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/retry"
+	"golang.org/x/oauth2"
 )
 
 // CachedGitHubClient wraps GitHubClient with caching capabilities
@@ -13,32 +16,92 @@ type CachedGitHubClient struct {
 	client *GitHubClient
 	cache  cache.Cache
 	kb     *cache.CacheKeyBuilder
+	swr    *cache.Revalidator // nil unless stale-while-revalidate is enabled
 }
 
 // NewCachedGitHubClient creates a new GitHub client with caching
 func NewCachedGitHubClient(token string, cacheImpl cache.Cache) *CachedGitHubClient {
+	return NewCachedGitHubClientFromTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}), cacheImpl)
+}
+
+// NewCachedGitHubClientFromTokenSource creates a caching client that authenticates
+// with tokens from ts
+func NewCachedGitHubClientFromTokenSource(ts oauth2.TokenSource, cacheImpl cache.Cache) *CachedGitHubClient {
+	client := NewGitHubClientFromTokenSource(ts)
+	client.setResponseCache(cacheImpl)
 	return &CachedGitHubClient{
-		client: NewGitHubClient(token),
+		client: client,
 		cache:  cacheImpl,
 		kb:     cache.NewCacheKeyBuilder("github"),
 	}
 }
 
+// SetStaleWhileRevalidate toggles stale-while-revalidate mode, in which expired
+// cache entries are returned immediately and refreshed in the background.
+// Refreshes aren't cancelled along with the request that started them; Close
+// waits for any outstanding ones.
+func (c *CachedGitHubClient) SetStaleWhileRevalidate(enabled bool) {
+	if enabled {
+		c.swr = cache.NewRevalidator()
+	} else {
+		c.swr = nil
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Cache hits are free.
+func (c *CachedGitHubClient) SetBudget(b *budget.Budget) {
+	c.client.SetBudget(b)
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are retried
+func (c *CachedGitHubClient) SetRetryPolicy(p retry.Policy) {
+	c.client.SetRetryPolicy(p)
+}
+
 // FetchPullRequests fetches pull requests with caching
-func (c *CachedGitHubClient) FetchPullRequests(owner, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error) {
+func (c *CachedGitHubClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
 	// Try to get from cache first
 	cacheKey := c.kb.PRsListKey(owner, repo, startDate, endDate)
-	var cachedPRs []*github.PullRequest
-	if err := c.cache.Get(cacheKey, &cachedPRs); err == nil {
+	var cachedPRs []*PullRequest
+	refresh := func() error {
+		_, err := c.fetchPullRequests(context.WithoutCancel(ctx), owner, repo, startDate, endDate)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedPRs, refresh); err == nil {
 		return cachedPRs, nil
 	} else if err != cache.ErrCacheMiss {
 		log.Printf("Cache error for PRs list: %v", err)
 	}
 
 	// Cache miss, fetch from API
-	prs, err := c.client.FetchPullRequests(owner, repo, startDate, endDate)
+	return c.fetchPullRequests(ctx, owner, repo, startDate, endDate)
+}
+
+// FetchOpenPullRequests fetches the PRs open now. They're never cached: a
+// snapshot of what's open has to be current.
+func (c *CachedGitHubClient) FetchOpenPullRequests(ctx context.Context, owner, repo string) ([]*PullRequest, error) {
+	return c.client.FetchOpenPullRequests(ctx, owner, repo)
+}
+
+// FetchMergedPullRequests fetches the PRs merged in the date range. They're never
+// cached, as the cache holds PRs by when they were created.
+func (c *CachedGitHubClient) FetchMergedPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	return c.client.FetchMergedPullRequests(ctx, owner, repo, startDate, endDate)
+}
+
+// CountMergedPullRequests counts the PRs ever merged in a repository. The count
+// is never cached, since it's only useful if it's current.
+func (c *CachedGitHubClient) CountMergedPullRequests(ctx context.Context, owner, repo string) (int, error) {
+	return c.client.CountMergedPullRequests(ctx, owner, repo)
+}
+
+// fetchPullRequests fetches pull requests from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	cacheKey := c.kb.PRsListKey(owner, repo, startDate, endDate)
+	prs, err := c.client.FetchPullRequests(ctx, owner, repo, startDate, endDate)
 	if err != nil {
-		return nil, err
+		// Pass along any partial results, but never cache them
+		return prs, err
 	}
 
 	// Cache the result - use longer TTL for historical data, shorter for recent data
@@ -50,9 +113,9 @@ func (c *CachedGitHubClient) FetchPullRequests(owner, repo string, startDate, en
 	// Also cache individual PRs if they're in a cacheable state
 	for _, pr := range prs {
 		if c.isPRCacheable(pr) {
-			prKey := c.kb.PRKey(owner, repo, pr.GetNumber())
+			prKey := c.kb.PRKey(owner, repo, pr.Number)
 			if err := c.cache.Set(prKey, pr, 24*time.Hour); err != nil {
-				log.Printf("Failed to cache individual PR #%d: %v", pr.GetNumber(), err)
+				log.Printf("Failed to cache individual PR #%d: %v", pr.Number, err)
 			}
 		}
 	}
@@ -60,54 +123,485 @@ func (c *CachedGitHubClient) FetchPullRequests(owner, repo string, startDate, en
 	return prs, nil
 }
 
+// FetchDiscussions fetches discussions with caching
+func (c *CachedGitHubClient) FetchDiscussions(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]Discussion, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.DiscussionsListKey(owner, repo, startDate, endDate)
+	var cachedDiscussions []Discussion
+	refresh := func() error {
+		_, err := c.fetchDiscussions(context.WithoutCancel(ctx), owner, repo, startDate, endDate)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedDiscussions, refresh); err == nil {
+		return cachedDiscussions, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for discussions list: %v", err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchDiscussions(ctx, owner, repo, startDate, endDate)
+}
+
+// fetchDiscussions fetches discussions from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchDiscussions(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]Discussion, error) {
+	cacheKey := c.kb.DiscussionsListKey(owner, repo, startDate, endDate)
+	discussions, err := c.client.FetchDiscussions(ctx, owner, repo, startDate, endDate)
+	if err != nil {
+		// Pass along any partial results, but never cache them
+		return discussions, err
+	}
+
+	// Discussions get new replies like open PRs do, so share the PR list TTLs
+	if err := c.cache.Set(cacheKey, discussions, c.calculatePRListTTL(endDate)); err != nil {
+		log.Printf("Failed to cache discussions list: %v", err)
+	}
+
+	return discussions, nil
+}
+
+// FetchProjectItems fetches a project board's items with caching
+func (c *CachedGitHubClient) FetchProjectItems(ctx context.Context, owner string, number int, isUser bool) ([]ProjectItem, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.ProjectItemsKey(owner, number)
+	var cachedItems []ProjectItem
+	refresh := func() error {
+		_, err := c.fetchProjectItems(context.WithoutCancel(ctx), owner, number, isUser)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedItems, refresh); err == nil {
+		return cachedItems, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for project items: %v", err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchProjectItems(ctx, owner, number, isUser)
+}
+
+// fetchProjectItems fetches a project board's items from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchProjectItems(ctx context.Context, owner string, number int, isUser bool) ([]ProjectItem, error) {
+	cacheKey := c.kb.ProjectItemsKey(owner, number)
+	items, err := c.client.FetchProjectItems(ctx, owner, number, isUser)
+	if err != nil {
+		// Pass along any partial results, but never cache them
+		return items, err
+	}
+
+	// Boards change constantly, so only cache briefly
+	if err := c.cache.Set(cacheKey, items, 1*time.Hour); err != nil {
+		log.Printf("Failed to cache project items: %v", err)
+	}
+
+	return items, nil
+}
+
+// FetchPullRequest fetches a single PR with caching
+func (c *CachedGitHubClient) FetchPullRequest(ctx context.Context, owner, repo string, prNumber int) (*PullRequest, error) {
+	cacheKey := c.kb.PRKey(owner, repo, prNumber)
+
+	var pr *PullRequest
+	if err := c.cache.Get(cacheKey, &pr); err == nil {
+		return pr, nil
+	}
+
+	// Cache miss, fetch from API
+	pr, err := c.client.FetchPullRequest(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs won't change, so they can be cached for longer
+	ttl := 1 * time.Hour
+	if c.isPRCacheable(pr) {
+		ttl = 24 * time.Hour
+	}
+	if err := c.cache.Set(cacheKey, pr, ttl); err != nil {
+		log.Printf("Failed to cache PR #%d: %v", prNumber, err)
+	}
+
+	return pr, nil
+}
+
 // FetchPullRequestReviews fetches PR reviews with caching
-func (c *CachedGitHubClient) FetchPullRequestReviews(owner, repo string, prNumber int) ([]*github.PullRequestReview, error) {
+func (c *CachedGitHubClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
 	// Try to get from cache first
 	cacheKey := c.kb.PRReviewsKey(owner, repo, prNumber)
-	var cachedReviews []*github.PullRequestReview
-	if err := c.cache.Get(cacheKey, &cachedReviews); err == nil {
+	var cachedReviews []*Review
+	refresh := func() error {
+		_, err := c.fetchPullRequestReviews(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedReviews, refresh); err == nil {
 		return cachedReviews, nil
 	} else if err != cache.ErrCacheMiss {
 		log.Printf("Cache error for PR #%d reviews: %v", prNumber, err)
 	}
 
 	// Cache miss, fetch from API
-	reviews, err := c.client.FetchPullRequestReviews(owner, repo, prNumber)
+	return c.fetchPullRequestReviews(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestReviews fetches PR reviews from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
+	cacheKey := c.kb.PRReviewsKey(owner, repo, prNumber)
+	reviews, err := c.client.FetchPullRequestReviews(ctx, owner, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if PR is in a cacheable state
-	var pr *github.PullRequest
-	prKey := c.kb.PRKey(owner, repo, prNumber)
-	if err := c.cache.Get(prKey, &pr); err == nil && c.isPRCacheable(pr) {
-		// PR is cacheable, cache reviews with longer TTL
-		if err := c.cache.Set(cacheKey, reviews, 24*time.Hour); err != nil {
-			log.Printf("Failed to cache PR #%d reviews: %v", prNumber, err)
-		}
-	} else {
-		// PR might still be active, cache with shorter TTL
-		if err := c.cache.Set(cacheKey, reviews, 1*time.Hour); err != nil {
-			log.Printf("Failed to cache PR #%d reviews: %v", prNumber, err)
-		}
+	// Closed PRs won't get new reviews, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, reviews, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d reviews: %v", prNumber, err)
 	}
 
 	return reviews, nil
 }
 
+// FetchPullRequestCommits fetches a PR's commits with caching
+func (c *CachedGitHubClient) FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRCommitsKey(owner, repo, prNumber)
+	var cachedCommits []*Commit
+	refresh := func() error {
+		_, err := c.fetchPullRequestCommits(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedCommits, refresh); err == nil {
+		return cachedCommits, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d commits: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestCommits(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestCommits fetches a PR's commits from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error) {
+	cacheKey := c.kb.PRCommitsKey(owner, repo, prNumber)
+	commits, err := c.client.FetchPullRequestCommits(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs won't get new commits, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, commits, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d commits: %v", prNumber, err)
+	}
+
+	return commits, nil
+}
+
+// FetchPullRequestFiles fetches the files a PR changes with caching
+func (c *CachedGitHubClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRFilesKey(owner, repo, prNumber)
+	var cachedFiles []*File
+	refresh := func() error {
+		_, err := c.fetchPullRequestFiles(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedFiles, refresh); err == nil {
+		return cachedFiles, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d files: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestFiles(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestFiles fetches the files a PR changes from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	cacheKey := c.kb.PRFilesKey(owner, repo, prNumber)
+	files, err := c.client.FetchPullRequestFiles(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs won't change, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, files, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d files: %v", prNumber, err)
+	}
+
+	return files, nil
+}
+
+// FetchPullRequestComments fetches a PR's review comments with caching
+func (c *CachedGitHubClient) FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRCommentsKey(owner, repo, prNumber)
+	var cachedComments []*Comment
+	refresh := func() error {
+		_, err := c.fetchPullRequestComments(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedComments, refresh); err == nil {
+		return cachedComments, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d comments: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestComments(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestComments fetches a PR's review comments from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	cacheKey := c.kb.PRCommentsKey(owner, repo, prNumber)
+	comments, err := c.client.FetchPullRequestComments(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs rarely get new comments, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, comments, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d comments: %v", prNumber, err)
+	}
+
+	return comments, nil
+}
+
+// FetchPullRequestIssueComments fetches a PR's conversation comments with caching
+func (c *CachedGitHubClient) FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRIssueCommentsKey(owner, repo, prNumber)
+	var cachedComments []*Comment
+	refresh := func() error {
+		_, err := c.fetchPullRequestIssueComments(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedComments, refresh); err == nil {
+		return cachedComments, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d issue comments: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestIssueComments(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestIssueComments fetches a PR's conversation comments from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	cacheKey := c.kb.PRIssueCommentsKey(owner, repo, prNumber)
+	comments, err := c.client.FetchPullRequestIssueComments(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs rarely get new comments, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, comments, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d issue comments: %v", prNumber, err)
+	}
+
+	return comments, nil
+}
+
+// FetchPullRequestTimeline fetches a PR's timeline events with caching
+func (c *CachedGitHubClient) FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRTimelineKey(owner, repo, prNumber)
+	var cachedEvents []*TimelineEvent
+	refresh := func() error {
+		_, err := c.fetchPullRequestTimeline(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedEvents, refresh); err == nil {
+		return cachedEvents, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d timeline: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestTimeline(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestTimeline fetches a PR's timeline events from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error) {
+	cacheKey := c.kb.PRTimelineKey(owner, repo, prNumber)
+	events, err := c.client.FetchPullRequestTimeline(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs rarely get new events, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, events, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d timeline: %v", prNumber, err)
+	}
+
+	return events, nil
+}
+
+// FetchPullRequestReviewRequests fetches a PR's review request events with caching
+func (c *CachedGitHubClient) FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRReviewRequestsKey(owner, repo, prNumber)
+	var cachedEvents []*ReviewRequestEvent
+	refresh := func() error {
+		_, err := c.fetchPullRequestReviewRequests(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedEvents, refresh); err == nil {
+		return cachedEvents, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d review requests: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestReviewRequests(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestReviewRequests fetches a PR's review request events from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error) {
+	cacheKey := c.kb.PRReviewRequestsKey(owner, repo, prNumber)
+	events, err := c.client.FetchPullRequestReviewRequests(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs rarely get new requests, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, events, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d review requests: %v", prNumber, err)
+	}
+
+	return events, nil
+}
+
+// FetchPullRequestDismissals fetches a PR's review dismissal events with caching
+func (c *CachedGitHubClient) FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error) {
+	// Try to get from cache first
+	cacheKey := c.kb.PRDismissalsKey(owner, repo, prNumber)
+	var cachedEvents []*DismissalEvent
+	refresh := func() error {
+		_, err := c.fetchPullRequestDismissals(context.WithoutCancel(ctx), owner, repo, prNumber)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedEvents, refresh); err == nil {
+		return cachedEvents, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for PR #%d dismissals: %v", prNumber, err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchPullRequestDismissals(ctx, owner, repo, prNumber)
+}
+
+// fetchPullRequestDismissals fetches a PR's review dismissal events from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error) {
+	cacheKey := c.kb.PRDismissalsKey(owner, repo, prNumber)
+	events, err := c.client.FetchPullRequestDismissals(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed PRs rarely get new dismissals, so they can be cached for longer
+	if err := c.cache.Set(cacheKey, events, c.prDataTTL(owner, repo, prNumber)); err != nil {
+		log.Printf("Failed to cache PR #%d dismissals: %v", prNumber, err)
+	}
+
+	return events, nil
+}
+
+// prDataTTL returns the TTL for per-PR data: long if the PR is known to be closed, short otherwise
+func (c *CachedGitHubClient) prDataTTL(owner, repo string, prNumber int) time.Duration {
+	var pr *PullRequest
+	if err := c.cache.Get(c.kb.PRKey(owner, repo, prNumber), &pr); err == nil && c.isPRCacheable(pr) {
+		return 24 * time.Hour
+	}
+	return 1 * time.Hour
+}
+
+// FetchRequiredApprovals fetches a branch's required approval count with caching
+func (c *CachedGitHubClient) FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	cacheKey := c.kb.RequiredApprovalsKey(owner, repo, branch)
+
+	var required int
+	if err := c.cache.Get(cacheKey, &required); err == nil {
+		return required, nil
+	}
+
+	// Cache miss, fetch from API
+	required, err := c.client.FetchRequiredApprovals(ctx, owner, repo, branch)
+	if err != nil {
+		return 0, err
+	}
+
+	// Protection rules rarely change
+	if err := c.cache.Set(cacheKey, required, 24*time.Hour); err != nil {
+		log.Printf("Failed to cache required approvals for %s: %v", branch, err)
+	}
+
+	return required, nil
+}
+
+// FetchCodeowners fetches the CODEOWNERS file at ref with caching
+func (c *CachedGitHubClient) FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error) {
+	cacheKey := c.kb.CodeownersKey(owner, repo, ref)
+
+	var content string
+	if err := c.cache.Get(cacheKey, &content); err == nil {
+		return content, nil
+	}
+
+	// Cache miss, fetch from API
+	content, err := c.client.FetchCodeowners(ctx, owner, repo, ref)
+	if err != nil {
+		return "", err
+	}
+
+	// Ownership rules rarely change
+	if err := c.cache.Set(cacheKey, content, 24*time.Hour); err != nil {
+		log.Printf("Failed to cache CODEOWNERS for %s: %v", ref, err)
+	}
+
+	return content, nil
+}
+
+// FetchTeamMembers fetches a team's members with caching
+func (c *CachedGitHubClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	cacheKey := c.kb.TeamMembersKey(org, team)
+
+	var members []string
+	if err := c.cache.Get(cacheKey, &members); err == nil {
+		return members, nil
+	}
+
+	// Cache miss, fetch from API
+	members, err := c.client.FetchTeamMembers(ctx, org, team)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.cache.Set(cacheKey, members, 24*time.Hour); err != nil {
+		log.Printf("Failed to cache members of %s/%s: %v", org, team, err)
+	}
+
+	return members, nil
+}
+
 // FetchCommits fetches commits with caching
-func (c *CachedGitHubClient) FetchCommits(owner, repo string, since, until time.Time) ([]*github.RepositoryCommit, error) {
+func (c *CachedGitHubClient) FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error) {
 	// Try to get from cache first
 	cacheKey := c.kb.CommitsListKey(owner, repo, since, until)
-	var cachedCommits []*github.RepositoryCommit
-	if err := c.cache.Get(cacheKey, &cachedCommits); err == nil {
+	var cachedCommits []*Commit
+	refresh := func() error {
+		_, err := c.fetchCommits(context.WithoutCancel(ctx), owner, repo, since, until)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &cachedCommits, refresh); err == nil {
 		return cachedCommits, nil
 	} else if err != cache.ErrCacheMiss {
 		log.Printf("Cache error for commits list: %v", err)
 	}
 
 	// Cache miss, fetch from API
-	commits, err := c.client.FetchCommits(owner, repo, since, until)
+	return c.fetchCommits(ctx, owner, repo, since, until)
+}
+
+// fetchCommits fetches commits from the API and stores them in the cache
+func (c *CachedGitHubClient) fetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error) {
+	cacheKey := c.kb.CommitsListKey(owner, repo, since, until)
+	commits, err := c.client.FetchCommits(ctx, owner, repo, since, until)
 	if err != nil {
 		return nil, err
 	}
@@ -122,16 +616,26 @@ func (c *CachedGitHubClient) FetchCommits(owner, repo string, since, until time.
 }
 
 // FetchCommit fetches a single commit with caching
-func (c *CachedGitHubClient) FetchCommit(owner, repo, sha string) (*github.RepositoryCommit, error) {
+func (c *CachedGitHubClient) FetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
 	cacheKey := c.kb.CommitKey(owner, repo, sha)
 
-	var commit *github.RepositoryCommit
-	if err := c.cache.Get(cacheKey, &commit); err == nil {
+	var commit *Commit
+	refresh := func() error {
+		_, err := c.fetchCommit(context.WithoutCancel(ctx), owner, repo, sha)
+		return err
+	}
+	if err := c.swr.Get(c.cache, cacheKey, &commit, refresh); err == nil {
 		return commit, nil
 	}
 
 	// Cache miss, fetch from API
-	commit, err := c.client.FetchCommit(owner, repo, sha)
+	return c.fetchCommit(ctx, owner, repo, sha)
+}
+
+// fetchCommit fetches a single commit from the API and stores it in the cache
+func (c *CachedGitHubClient) fetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
+	cacheKey := c.kb.CommitKey(owner, repo, sha)
+	commit, err := c.client.FetchCommit(ctx, owner, repo, sha)
 	if err != nil {
 		return nil, err
 	}
@@ -145,13 +649,13 @@ func (c *CachedGitHubClient) FetchCommit(owner, repo, sha string) (*github.Repos
 }
 
 // isPRCacheable determines if a PR is in a state that can be cached long-term
-func (c *CachedGitHubClient) isPRCacheable(pr *github.PullRequest) bool {
+func (c *CachedGitHubClient) isPRCacheable(pr *PullRequest) bool {
 	if pr == nil {
 		return false
 	}
 
 	// Cache if PR is closed (merged or not)
-	return pr.GetState() == "closed"
+	return pr.State == "closed"
 }
 
 // calculatePRListTTL calculates TTL for PR list cache based on how recent the data is
@@ -180,7 +684,8 @@ func (c *CachedGitHubClient) calculateCommitListTTL(endDate time.Time) time.Dura
 	return 1 * time.Hour
 }
 
-// Close cleans up the client
+// Close waits for background refreshes and cleans up the client
 func (c *CachedGitHubClient) Close() error {
+	c.swr.Wait()
 	return c.cache.Close()
 }
@@ -0,0 +1,32 @@
+package github
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conventionalTitle matches a conventional-commit prefix such as "feat:", "fix(api):" or "refactor!:"
+var conventionalTitle = regexp.MustCompile(`^\s*([a-zA-Z]+)(?:\([^)]*\))?!?:`)
+
+// changeTypes are the conventional-commit types PRs are broken down by
+var changeTypes = map[string]bool{
+	"feat": true, "fix": true, "chore": true, "refactor": true, "docs": true, "test": true,
+	"perf": true, "build": true, "ci": true, "style": true, "revert": true,
+}
+
+// ParseChangeType returns the conventional-commit type of a PR title (or squash
+// commit message), such as "feat" or "fix", or "" if it doesn't have a recognized one
+func ParseChangeType(title string) string {
+	m := conventionalTitle.FindStringSubmatch(title)
+	if m == nil {
+		return ""
+	}
+	changeType := strings.ToLower(m[1])
+	if changeType == "feature" {
+		changeType = "feat"
+	}
+	if !changeTypes[changeType] {
+		return ""
+	}
+	return changeType
+}
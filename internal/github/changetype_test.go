@@ -0,0 +1,21 @@
+package github
+
+import "testing"
+
+func TestParseChangeType(t *testing.T) {
+	tests := map[string]string{
+		"feat: add leaderboard":            "feat",
+		"fix(api): handle empty page":      "fix",
+		"refactor!: drop old flags":        "refactor",
+		"Chore: bump deps":                 "chore",
+		"feature(ui): dark mode":           "feat",
+		"Add leaderboard":                  "",
+		"WIP: something":                   "",
+		"Revert \"feat: add leaderboard\"": "",
+	}
+	for title, want := range tests {
+		if got := ParseChangeType(title); got != want {
+			t.Errorf("ParseChangeType(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
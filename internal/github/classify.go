@@ -0,0 +1,70 @@
+package github
+
+import (
+	"path"
+	"strings"
+)
+
+// PR classes, from least to most significant. A PR takes the most significant
+// class of any file it changes, so docs-only means every file is documentation.
+const (
+	ClassDocs   = "docs"
+	ClassConfig = "config"
+	ClassTest   = "test"
+	ClassCode   = "code"
+)
+
+var classRank = map[string]int{ClassDocs: 0, ClassConfig: 1, ClassTest: 2, ClassCode: 3}
+
+// configExtensions are extensions of files that configure rather than implement
+var configExtensions = map[string]bool{
+	".yaml": true, ".yml": true, ".json": true, ".toml": true, ".ini": true,
+	".cfg": true, ".conf": true, ".env": true, ".properties": true, ".lock": true,
+}
+
+// configFilenames are specially named configuration and dependency files
+var configFilenames = map[string]bool{
+	"Dockerfile": true, "Makefile": true, "go.mod": true, "go.sum": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true,
+	"requirements.txt": true, "Gemfile": true, "Gemfile.lock": true, "CODEOWNERS": true,
+}
+
+// docsExtensions are extensions of documentation files
+var docsExtensions = map[string]bool{
+	".md": true, ".rst": true, ".adoc": true, ".txt": true,
+}
+
+// fileClass classifies a changed file by its path
+func fileClass(filename string) string {
+	base := path.Base(filename)
+	ext := strings.ToLower(path.Ext(base))
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	dirs := "/" + path.Dir(filename) + "/"
+
+	switch {
+	case strings.Contains(dirs, "/test/") || strings.Contains(dirs, "/tests/") ||
+		strings.Contains(dirs, "/__tests__/") || strings.Contains(dirs, "/testdata/") ||
+		strings.HasSuffix(stem, "_test") || strings.HasPrefix(stem, "test_") ||
+		strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec"):
+		return ClassTest
+	case configFilenames[base] || configExtensions[ext] || strings.HasPrefix(filename, ".github/") ||
+		stem == "": // stem is empty for dotfiles such as .gitignore
+		return ClassConfig
+	case docsExtensions[ext] || strings.HasPrefix(filename, "docs/") ||
+		strings.HasPrefix(base, "LICENSE") || strings.HasPrefix(base, "README") || strings.HasPrefix(base, "CHANGELOG"):
+		return ClassDocs
+	default:
+		return ClassCode
+	}
+}
+
+// classifyFiles returns the most significant class of any of files, or "" if there are none
+func classifyFiles(files []*File) string {
+	var class string
+	for _, file := range files {
+		if c := fileClass(file.Filename); class == "" || classRank[c] > classRank[class] {
+			class = c
+		}
+	}
+	return class
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestFileClass(t *testing.T) {
+	tests := map[string]string{
+		"internal/github/metrics.go":      ClassCode,
+		"internal/github/metrics_test.go": ClassTest,
+		"web/src/App.spec.ts":             ClassTest,
+		"tests/test_api.py":               ClassTest,
+		"config/app.yaml":                 ClassConfig,
+		".github/workflows/ci.yml":        ClassConfig,
+		".gitignore":                      ClassConfig,
+		"go.mod":                          ClassConfig,
+		"README.md":                       ClassDocs,
+		"docs/architecture.png":           ClassDocs,
+		"LICENSE":                         ClassDocs,
+	}
+	for filename, want := range tests {
+		if got := fileClass(filename); got != want {
+			t.Errorf("fileClass(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestClassifyFiles(t *testing.T) {
+	files := func(names ...string) []*File {
+		var result []*File
+		for _, name := range names {
+			result = append(result, &File{Filename: name})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		files []*File
+		want  string
+	}{
+		{"docs only", files("README.md", "docs/setup.md"), ClassDocs},
+		{"config and docs", files("README.md", "config.yaml"), ClassConfig},
+		{"tests and docs", files("README.md", "foo_test.go"), ClassTest},
+		{"any code", files("README.md", "foo_test.go", "foo.go"), ClassCode},
+		{"no files", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := classifyFiles(tt.files); got != tt.want {
+			t.Errorf("%s: classifyFiles() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
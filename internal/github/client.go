@@ -2,96 +2,382 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
-	"github.com/google/go-github/v39/github"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/retry"
 	"golang.org/x/oauth2"
 )
 
 // GitHubClientInterface defines the interface for GitHub operations
 type GitHubClientInterface interface {
-	FetchPullRequests(owner, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error)
-	FetchPullRequestReviews(owner, repo string, prNumber int) ([]*github.PullRequestReview, error)
-	FetchCommits(owner, repo string, since, until time.Time) ([]*github.RepositoryCommit, error)
-	FetchCommit(owner, repo, sha string) (*github.RepositoryCommit, error)
+	FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error)
+	FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error)
+	FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error)
+	FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error)
+	FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error)
+	FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error)
+	FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error)
+	FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error)
+	FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error)
+	FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error)
+	FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error)
+	FetchTeamMembers(ctx context.Context, org, team string) ([]string, error)
+	FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error)
+	FetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error)
 }
 
 type GitHubClient struct {
-	client *github.Client
+	api        restAPI
+	httpClient *http.Client   // authenticated client, for the GraphQL API
+	budget     *budget.Budget // nil means unlimited
+
+	pages *pageStore // Kept for conditional requests
+	retry *retry.Transport
 }
 
 func NewGitHubClient(token string) *GitHubClient {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
+	return NewGitHubClientFromTokenSource(oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
-	)
+	))
+}
+
+// NewGitHubClientFromTokenSource creates a client that authenticates with tokens
+// from ts, such as a GitHub App's installation tokens (see NewAppTokenSource)
+func NewGitHubClientFromTokenSource(ts oauth2.TokenSource) *GitHubClient {
+	ctx := context.Background()
 	tc := oauth2.NewClient(ctx, ts)
+	retrying := retry.NewTransport(tc.Transport, retry.DefaultPolicy)
+	pages := newPageStore()
+	tc.Transport = newConditionalTransport(newRateLimitTransport(retrying), pages)
 
 	return &GitHubClient{
-		client: github.NewClient(tc),
+		api:        newGoGitHub(tc, pages),
+		httpClient: tc,
+		pages:      pages,
+		retry:      retrying,
 	}
 }
 
-func (c *GitHubClient) FetchPullRequests(owner, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error) {
-	ctx := context.Background()
-	var allPRs []*github.PullRequest
-	opts := &github.PullRequestListOptions{
-		State:       "all",
-		ListOptions: github.ListOptions{PerPage: 100},
+// setResponseCache keeps pages in c, so requests for data that was fetched before
+// are made conditional and cost nothing against the rate limit if it hasn't changed
+func (c *GitHubClient) setResponseCache(responses cache.Cache) {
+	c.pages.cache = responses
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *GitHubClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *GitHubClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// FetchPullRequest fetches a single pull request
+func (c *GitHubClient) FetchPullRequest(ctx context.Context, owner, repo string, prNumber int) (*PullRequest, error) {
+	if err := c.budget.Spend(); err != nil {
+		return nil, err
+	}
+	pr, err := c.api.GetPullRequest(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request #%d: %w", prNumber, err)
 	}
 
+	return pr, nil
+}
+
+func (c *GitHubClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
+	var allReviews []*Review
+	page := 1
+
 	for {
-		prs, resp, err := c.client.PullRequests.List(ctx, owner, repo, opts)
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		reviews, next, err := c.api.ListReviews(ctx, owner, repo, prNumber, page)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch pull requests: %w", err)
+			return nil, fmt.Errorf("failed to fetch pull request reviews: %w", err)
 		}
 
-		for _, pr := range prs {
-			if !pr.GetCreatedAt().Before(startDate) && !pr.GetCreatedAt().After(endDate) {
-				allPRs = append(allPRs, pr)
-			}
+		allReviews = append(allReviews, reviews...)
+
+		if next == 0 {
+			break
 		}
+		page = next
+	}
 
-		// Break if we've processed all pages or found PRs older than our start date
-		if resp.NextPage == 0 {
+	return allReviews, nil
+}
+
+// FetchPullRequestCommits fetches the commits on a PR's branch (GitHub returns at most 250)
+func (c *GitHubClient) FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error) {
+	var allCommits []*Commit
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		commits, next, err := c.api.ListPullRequestCommits(ctx, owner, repo, prNumber, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request commits: %w", err)
+		}
+
+		allCommits = append(allCommits, commits...)
+
+		if next == 0 {
 			break
 		}
+		page = next
+	}
+
+	return allCommits, nil
+}
+
+// FetchPullRequestFiles fetches the files a PR changes (GitHub returns at most 3000)
+func (c *GitHubClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	var allFiles []*File
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		files, next, err := c.api.ListPullRequestFiles(ctx, owner, repo, prNumber, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request files: %w", err)
+		}
+
+		allFiles = append(allFiles, files...)
+
+		if next == 0 {
+			break
+		}
+		page = next
+	}
+
+	return allFiles, nil
+}
+
+// FetchPullRequestComments fetches the inline review comments on a PR's diff
+func (c *GitHubClient) FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	var allComments []*Comment
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		comments, next, err := c.api.ListReviewComments(ctx, owner, repo, prNumber, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request comments: %w", err)
+		}
+
+		allComments = append(allComments, comments...)
+
+		if next == 0 {
+			break
+		}
+		page = next
+	}
+
+	return allComments, nil
+}
+
+// FetchPullRequestIssueComments fetches the comments on a PR's conversation, as
+// opposed to those on its diff
+func (c *GitHubClient) FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	var allComments []*Comment
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		comments, next, err := c.api.ListIssueComments(ctx, owner, repo, prNumber, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request issue comments: %w", err)
+		}
+
+		allComments = append(allComments, comments...)
 
-		// Check the last PR on the page - if it's older than our start date, we can stop
-		lastPR := prs[len(prs)-1]
-		if lastPR.GetCreatedAt().Before(startDate) {
+		if next == 0 {
 			break
 		}
-		opts.Page = resp.NextPage
+		page = next
 	}
 
-	return allPRs, nil
+	return allComments, nil
 }
 
-func (c *GitHubClient) FetchPullRequestReviews(owner, repo string, prNumber int) ([]*github.PullRequestReview, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// FetchPullRequestTimeline fetches a PR's timeline events, such as when it was
+// marked ready for review
+func (c *GitHubClient) FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error) {
+	var allEvents []*TimelineEvent
+	page := 1
 
-	reviews, _, err := c.client.PullRequests.ListReviews(ctx, owner, repo, prNumber, nil)
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		events, next, err := c.api.ListTimeline(ctx, owner, repo, prNumber, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request timeline: %w", err)
+		}
+
+		allEvents = append(allEvents, events...)
+
+		if next == 0 {
+			break
+		}
+		page = next
+	}
+
+	return allEvents, nil
+}
+
+// FetchPullRequestReviewRequests fetches the events for reviews being requested
+// from, or no longer requested from, people or teams on a PR
+func (c *GitHubClient) FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error) {
+	var requests []*ReviewRequestEvent
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		// go-github's events don't have the requested team, so they're decoded here
+		var events []*ReviewRequestEvent
+		next, err := c.api.Get(ctx, fmt.Sprintf("repos/%s/%s/issues/%d/events?per_page=100&page=%d", owner, repo, prNumber, page), &events)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request events: %w", err)
+		}
+
+		for _, e := range events {
+			if e.Event == "review_requested" || e.Event == "review_request_removed" {
+				requests = append(requests, e)
+			}
+		}
+
+		if next == 0 {
+			break
+		}
+		page = next
+	}
+
+	return requests, nil
+}
+
+// FetchPullRequestDismissals fetches the events for reviews being dismissed on a
+// PR, which say what each dismissed review was before it was dismissed
+func (c *GitHubClient) FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error) {
+	var allDismissals []*DismissalEvent
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		dismissals, next, err := c.api.ListDismissals(ctx, owner, repo, prNumber, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch pull request events: %w", err)
+		}
+
+		allDismissals = append(allDismissals, dismissals...)
+
+		if next == 0 {
+			break
+		}
+		page = next
+	}
+
+	return allDismissals, nil
+}
+
+// FetchRequiredApprovals returns the number of approving reviews the branch's
+// protection rules require, or 0 if the branch isn't protected or doesn't require reviews
+func (c *GitHubClient) FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	if err := c.budget.Spend(); err != nil {
+		return 0, err
+	}
+	required, err := c.api.RequiredApprovals(ctx, owner, repo, branch)
+	if errors.Is(err, errNotFound) {
+		// Branch not protected
+		return 0, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pull request reviews: %w", err)
+		return 0, fmt.Errorf("failed to fetch branch protection for %s: %w", branch, err)
 	}
 
-	return reviews, nil
+	return required, nil
 }
 
-func (c *GitHubClient) FetchCommits(owner, repo string, since, until time.Time) ([]*github.RepositoryCommit, error) {
-	ctx := context.Background()
-	var allCommits []*github.RepositoryCommit
-	opts := &github.CommitsListOptions{
-		Since:       since,
-		Until:       until,
-		ListOptions: github.ListOptions{PerPage: 100},
+// codeownersPaths are the locations GitHub reads a CODEOWNERS file from, in order of precedence
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// FetchCodeowners returns the contents of the repository's CODEOWNERS file at ref,
+// or an empty string if it doesn't have one
+func (c *GitHubClient) FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error) {
+	for _, path := range codeownersPaths {
+		if err := c.budget.Spend(); err != nil {
+			return "", err
+		}
+		content, err := c.api.FileContents(ctx, owner, repo, path, ref)
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", path, err)
+		}
+		return content, nil
+	}
+
+	return "", nil
+}
+
+// FetchTeamMembers returns the logins of a team's members
+func (c *GitHubClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	var members []string
+	page := 1
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		logins, next, err := c.api.ListTeamMembers(ctx, org, team, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch members of %s/%s: %w", org, team, err)
+		}
+
+		members = append(members, logins...)
+
+		if next == 0 {
+			break
+		}
+		page = next
 	}
 
+	return members, nil
+}
+
+func (c *GitHubClient) FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error) {
+	var allCommits []*Commit
+	page := 1
+
 	for {
-		commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
+		if err := c.budget.Spend(); err != nil {
+			return nil, err
+		}
+		commits, next, err := c.api.ListCommits(ctx, owner, repo, since, until, page)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch commits: %w", err)
 		}
@@ -99,20 +385,21 @@ func (c *GitHubClient) FetchCommits(owner, repo string, since, until time.Time)
 		allCommits = append(allCommits, commits...)
 
 		// Break if we've processed all pages
-		if resp.NextPage == 0 {
+		if next == 0 {
 			break
 		}
-		opts.Page = resp.NextPage
+		page = next
 	}
 
 	return allCommits, nil
 }
 
 // FetchCommit fetches a single commit with its diff
-func (c *GitHubClient) FetchCommit(owner, repo, sha string) (*github.RepositoryCommit, error) {
-	ctx := context.Background()
-
-	commit, _, err := c.client.Repositories.GetCommit(ctx, owner, repo, sha, nil)
+func (c *GitHubClient) FetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
+	if err := c.budget.Spend(); err != nil {
+		return nil, err
+	}
+	commit, err := c.api.GetCommit(ctx, owner, repo, sha)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch commit %s: %w", sha, err)
 	}
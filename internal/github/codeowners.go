@@ -0,0 +1,262 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// codeownersRule is a single CODEOWNERS line: files matching the pattern need an
+// approval from any one of the owners
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeowners is a parsed CODEOWNERS file
+type codeowners struct {
+	rules []codeownersRule
+}
+
+// parseCodeowners parses a CODEOWNERS file. Lines with invalid patterns are skipped,
+// as GitHub does.
+func parseCodeowners(content string) *codeowners {
+	co := &codeowners{}
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		pattern, err := codeownersPattern(fields[0])
+		if err != nil {
+			log.Printf("Skipping CODEOWNERS pattern %q: %v", fields[0], err)
+			continue
+		}
+		co.rules = append(co.rules, codeownersRule{pattern: pattern, owners: fields[1:]})
+	}
+	return co
+}
+
+// owners returns the owners of path. As in GitHub, the last matching rule wins,
+// and a rule with no owners leaves the path unowned.
+func (co *codeowners) owners(path string) []string {
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].pattern.MatchString(path) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// codeownersPattern converts a gitignore-style CODEOWNERS pattern into a regexp.
+// Patterns containing a slash (other than a trailing one) are relative to the
+// repository root; others match at any depth. A pattern naming a directory also
+// matches everything beneath it, but one ending in a wildcard, like docs/*, only
+// matches what the wildcard does: the directory's direct children.
+func codeownersPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") || strings.Contains(pattern, "[") {
+		return nil, fmt.Errorf("negation and character ranges are not supported")
+	}
+
+	directory := strings.HasSuffix(pattern, "/") || !strings.HasSuffix(pattern, "*")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			re.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+	if directory {
+		re.WriteString("(?:/.*)?")
+	}
+	re.WriteString("$")
+
+	return regexp.Compile(re.String())
+}
+
+// codeownersResolver works out which CODEOWNERS rules PRs trigger and when their
+// owners approved. CODEOWNERS files and team memberships are looked up once per run.
+type codeownersResolver struct {
+	client      GitHubClientInterface
+	owner, repo string
+
+	files   map[string]*codeowners // by base branch; nil if the branch has no CODEOWNERS
+	members map[string][]string    // by "@org/team"
+}
+
+func newCodeownersResolver(client GitHubClientInterface, owner, repo string) *codeownersResolver {
+	return &codeownersResolver{
+		client:  client,
+		owner:   owner,
+		repo:    repo,
+		files:   make(map[string]*codeowners),
+		members: make(map[string][]string),
+	}
+}
+
+// approvals returns, for each owner whose approval the PR needs, how long after
+// readyAt it took them to approve, along with the time until every triggered rule
+// had an owner's approval (0 if that never happened). Like the other times, these
+// are measured with opts.elapsed, so an approval given while the PR was a draft
+// counts as 0. Rules are read from the base
+// branch, since that's what GitHub enforces. The PR's files are only asked for if
+// the branch has a CODEOWNERS file.
+func (r *codeownersResolver) approvals(ctx context.Context, pr *PullRequest, readyAt time.Time, opts ProcessOptions, approvals []reviewEvent, prFiles func(context.Context) ([]*File, error)) ([]OwnerApproval, time.Duration, error) {
+	co, err := r.codeowners(ctx, pr.Base.Ref)
+	if err != nil || co == nil {
+		return nil, 0, err
+	}
+
+	files, err := prFiles(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Each distinct owner list is one requirement: any of its owners can satisfy it
+	var requirements [][]string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		owners := co.owners(file.Filename)
+		key := strings.Join(owners, " ")
+		if len(owners) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		requirements = append(requirements, owners)
+	}
+
+	// When each owner first approved
+	approvedAt := make(map[string]time.Time)
+	var ownerApprovals []OwnerApproval
+	for _, owners := range requirements {
+		for _, owner := range owners {
+			if _, ok := approvedAt[owner]; ok {
+				continue
+			}
+			at, err := r.firstApproval(ctx, owner, approvals)
+			if err != nil {
+				return nil, 0, err
+			}
+			approvedAt[owner] = at
+
+			var timeToApproval time.Duration
+			if !at.IsZero() {
+				timeToApproval = opts.elapsed(readyAt, at)
+			}
+			ownerApprovals = append(ownerApprovals, OwnerApproval{Owner: owner, TimeToApproval: timeToApproval})
+		}
+	}
+
+	// Every requirement is satisfied once its earliest-approving owner has approved
+	var allSatisfied time.Time
+	for _, owners := range requirements {
+		var satisfied time.Time
+		for _, owner := range owners {
+			if at := approvedAt[owner]; !at.IsZero() && (satisfied.IsZero() || at.Before(satisfied)) {
+				satisfied = at
+			}
+		}
+		if satisfied.IsZero() {
+			return ownerApprovals, 0, nil
+		}
+		if satisfied.After(allSatisfied) {
+			allSatisfied = satisfied
+		}
+	}
+	if allSatisfied.IsZero() {
+		return ownerApprovals, 0, nil
+	}
+	return ownerApprovals, opts.elapsed(readyAt, allSatisfied), nil
+}
+
+// codeowners returns the parsed CODEOWNERS file for a branch, or nil if there isn't one
+func (r *codeownersResolver) codeowners(ctx context.Context, branch string) (*codeowners, error) {
+	if co, ok := r.files[branch]; ok {
+		return co, nil
+	}
+
+	content, err := r.client.FetchCodeowners(ctx, r.owner, r.repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	var co *codeowners
+	if content != "" {
+		co = parseCodeowners(content)
+	}
+	r.files[branch] = co
+	return co, nil
+}
+
+// firstApproval returns when owner (a @user or @org/team) first approved, or the zero
+// time if they haven't. Owners given as email addresses can't be matched to reviewers
+// and are never considered to have approved.
+func (r *codeownersResolver) firstApproval(ctx context.Context, owner string, approvals []reviewEvent) (time.Time, error) {
+	if !strings.HasPrefix(owner, "@") {
+		return time.Time{}, nil
+	}
+
+	approvers := []string{strings.TrimPrefix(owner, "@")}
+	if org, team, ok := strings.Cut(approvers[0], "/"); ok {
+		members, err := r.teamMembers(ctx, owner, org, team)
+		if err != nil {
+			return time.Time{}, err
+		}
+		approvers = members
+	}
+
+	var first time.Time
+	for _, a := range approvals {
+		for _, approver := range approvers {
+			if strings.EqualFold(a.reviewer, approver) && (first.IsZero() || a.at.Before(first)) {
+				first = a.at
+			}
+		}
+	}
+	return first, nil
+}
+
+// teamMembers returns a team's members. Listing members needs the read:org scope;
+// without it the team is treated as having no members rather than failing the run.
+func (r *codeownersResolver) teamMembers(ctx context.Context, owner, org, team string) ([]string, error) {
+	if members, ok := r.members[owner]; ok {
+		return members, nil
+	}
+
+	members, err := r.client.FetchTeamMembers(ctx, org, team)
+	if errors.Is(err, budget.ErrExhausted) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("Could not list members of %s, treating its approvals as missing: %v", owner, err)
+	}
+	r.members[owner] = members
+	return members, nil
+}
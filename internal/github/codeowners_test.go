@@ -0,0 +1,71 @@
+package github
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCodeowners_Owners(t *testing.T) {
+	co := parseCodeowners(`
+# Default owners
+*                @org/everyone
+
+/server/         @org/backend
+docs/            @org/docs   # anywhere in the tree
+*.md             @writer
+/apps/**/config  @org/ops
+/vendor/
+`)
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"main.go", []string{"@org/everyone"}},
+		{"server/api.go", []string{"@org/backend"}},
+		{"server/handlers/users.go", []string{"@org/backend"}},
+		{"cmd/server/main.go", []string{"@org/everyone"}},
+		{"docs/index.html", []string{"@org/docs"}},
+		{"server/docs/index.html", []string{"@org/docs"}},
+		{"server/README.md", []string{"@writer"}},
+		{"apps/web/config/prod.yaml", []string{"@org/ops"}},
+		{"apps/config", []string{"@org/ops"}},
+		{"vendor/lib/lib.go", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := co.owners(tt.path); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected owners %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCodeownersPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"docs/*", "docs/a.md", true},
+		{"docs/*", "docs/x/b.md", false},
+		{"docs/", "docs/x/b.md", true},
+		{"/docs", "docs/x/b.md", true},
+		{"docs/**", "docs/x/b.md", true},
+		{"*.md", "docs/x/b.md", true},
+		{"/docs/*.md", "docs/x/b.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			re, err := codeownersPattern(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := re.MatchString(tt.path); got != tt.matches {
+				t.Errorf("Expected match %v, got %v", tt.matches, got)
+			}
+		})
+	}
+}
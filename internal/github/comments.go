@@ -0,0 +1,64 @@
+package github
+
+import "github.com/reillywatson/statstracker/internal/stats"
+
+// ReviewDepthStats summarizes how much reviewers had to say on the PRs they
+// reviewed, to tell substantive review from rubber-stamp approvals
+type ReviewDepthStats struct {
+	PRCount         int     // Reviewed PRs whose comments were counted
+	Comments        int     // Review comments across those PRs
+	MedianComments  float64 // Median review comments per PR
+	MeasuredPRCount int     // Of those, PRs whose size was measured
+	MedianDepth     float64 // Median review comments per 100 lines changed, over the measured PRs
+	ApprovedPRCount int     // Of those, PRs that were approved
+	SilentApprovals int     // Approved PRs without a single review comment
+}
+
+// countReviewComments counts the inline review comments on a PR left by reviewers
+// that count under users, leaving out the author's own replies
+func countReviewComments(pr *PullRequest, comments []*Comment, users UserFilter) int {
+	count := 0
+	for _, comment := range comments {
+		if comment.User.Login == pr.User.Login || !users.IncludesReviewer(comment.User) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// reviewDepth returns review comments per 100 lines changed, or 0 if no lines changed
+func reviewDepth(comments, linesChanged int) float64 {
+	if linesChanged == 0 {
+		return 0
+	}
+	return float64(comments) / float64(linesChanged) * 100
+}
+
+// SummarizeReviewDepth summarizes review comments across reviewed PRs. PRs whose
+// comments weren't counted, or which haven't been reviewed, are left out.
+func SummarizeReviewDepth(results []PullRequestMetric) ReviewDepthStats {
+	var summary ReviewDepthStats
+	var counts, depths []float64
+	for _, result := range results {
+		if !result.CommentsCounted || !result.HasReview {
+			continue
+		}
+		summary.PRCount++
+		summary.Comments += result.ReviewComments
+		counts = append(counts, float64(result.ReviewComments))
+		if result.Size != "" {
+			summary.MeasuredPRCount++
+			depths = append(depths, result.ReviewDepth)
+		}
+		if result.TimeToApproval > 0 {
+			summary.ApprovedPRCount++
+			if result.ReviewComments == 0 {
+				summary.SilentApprovals++
+			}
+		}
+	}
+	summary.MedianComments = stats.MedianOf(counts)
+	summary.MedianDepth = stats.MedianOf(depths)
+	return summary
+}
@@ -0,0 +1,43 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountReviewComments(t *testing.T) {
+	pr := &PullRequest{User: user("author")}
+	comments := []*Comment{
+		{User: user("reviewer")},
+		{User: user("author")}, // the author's reply
+		{User: user("reviewer")},
+		{User: user("lint-bot[bot]")},
+		{User: user("mallory")},
+	}
+
+	if got := countReviewComments(pr, comments, UserFilter{Exclude: []string{"mallory"}}); got != 2 {
+		t.Errorf("Expected 2 review comments, got %d", got)
+	}
+}
+
+func TestSummarizeReviewDepth(t *testing.T) {
+	results := []PullRequestMetric{
+		{CommentsCounted: true, HasReview: true, TimeToApproval: time.Hour, ReviewComments: 0, Size: SizeM, ReviewDepth: 0},
+		{CommentsCounted: true, HasReview: true, TimeToApproval: time.Hour, ReviewComments: 4, Size: SizeS, ReviewDepth: 10},
+		{CommentsCounted: true, HasReview: true, ReviewComments: 6},
+		{CommentsCounted: true},                      // not reviewed yet
+		{HasReview: true, TimeToApproval: time.Hour}, // comments not counted
+	}
+
+	stats := SummarizeReviewDepth(results)
+
+	if stats.PRCount != 3 || stats.Comments != 10 || stats.MedianComments != 4 {
+		t.Errorf("Expected 3 PRs with 10 comments and a median of 4, got %+v", stats)
+	}
+	if stats.MeasuredPRCount != 2 || stats.MedianDepth != 5 {
+		t.Errorf("Expected a median depth of 5 over 2 measured PRs, got %+v", stats)
+	}
+	if stats.ApprovedPRCount != 2 || stats.SilentApprovals != 1 {
+		t.Errorf("Expected 1 of 2 approvals to be silent, got %+v", stats)
+	}
+}
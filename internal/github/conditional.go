@@ -0,0 +1,72 @@
+package github
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/cache"
+)
+
+// conditionalPageTTL is how long pages are kept for conditional requests. They're
+// only useful once the data cached from them expires, which for historical data
+// takes a day.
+const conditionalPageTTL = 30 * 24 * time.Hour
+
+// validators are what a request is made conditional on: the ETag or Last-Modified
+// date of the response last seen for the same URL
+type validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// storedPage is what's kept of a GET response for the next request for the same
+// URL: its validators, and what it held already converted to the package's types.
+// The response itself isn't kept, as its JSON has every field GitHub sends.
+type storedPage[T any] struct {
+	validators
+	Next  int `json:"next,omitempty"` // The next page, for listings
+	Value T   `json:"value"`
+}
+
+// pageStore keeps pages for conditional requests
+type pageStore struct {
+	cache cache.Cache // nil keeps nothing, and makes requests unconditional
+	kb    *cache.CacheKeyBuilder
+}
+
+func newPageStore() *pageStore {
+	return &pageStore{kb: cache.NewCacheKeyBuilder("github")}
+}
+
+// conditionalTransport makes GET requests conditional on the page last stored for
+// the same URL, using its ETag or Last-Modified date. When nothing has changed
+// GitHub answers 304 Not Modified, which doesn't count against the rate limit, and
+// goGitHub returns the stored page in its place (see conditional). So refreshing
+// expired cache entries for data that hasn't changed, like a merged PR's reviews,
+// costs almost nothing. GraphQL requests, such as the PR search, are POSTs and
+// never conditional.
+type conditionalTransport struct {
+	base  http.RoundTripper
+	pages *pageStore
+}
+
+func newConditionalTransport(base http.RoundTripper, pages *pageStore) *conditionalTransport {
+	return &conditionalTransport{base: base, pages: pages}
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.pages.cache == nil || req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	var stored validators
+	if err := t.pages.cache.Get(t.pages.kb.PageKey(req.URL.String()), &stored); err == nil {
+		req = req.Clone(req.Context())
+		if stored.ETag != "" {
+			req.Header.Set("If-None-Match", stored.ETag)
+		} else if stored.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stored.LastModified)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
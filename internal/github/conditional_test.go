@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/reillywatson/statstracker/internal/cache"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	etag := `"v1"`
+	body := `[{"id": 1, "user": {"login": "bob"}, "state": "APPROVED", "body": "Looks good to me", "html_url": "https://github.com/o/r/pull/1#pullrequestreview-1"}]`
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	responses, err := cache.NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := newPageStore()
+	pages.cache = responses
+	api := newGoGitHub(&http.Client{Transport: newConditionalTransport(http.DefaultTransport, pages)}, pages)
+	api.client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	list := func() ([]*Review, int) {
+		t.Helper()
+		reviews, next, err := api.ListReviews(context.Background(), "o", "r", 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reviews, next
+	}
+
+	// The first request is unconditional, and what's kept of it is just the reviews as converted
+	if reviews, next := list(); len(reviews) != 1 || reviews[0].User.Login != "bob" || next != 2 {
+		t.Errorf("Expected bob's review and a next page, got %+v, next page %d", reviews, next)
+	}
+	var stored storedPage[json.RawMessage]
+	if err := responses.Get(pages.kb.PageKey(server.URL+"/repos/o/r/pulls/1/reviews?page=1&per_page=100"), &stored); err != nil {
+		t.Fatalf("Expected the page to be stored: %v", err)
+	}
+	if stored.ETag != etag || stored.Next != 2 || strings.Contains(string(stored.Value), "Looks good") || strings.Contains(string(stored.Value), "html_url") {
+		t.Errorf("Expected the ETag, next page and converted reviews to be stored, got %+v: %s", stored, stored.Value)
+	}
+
+	// Unchanged data comes back as the stored page
+	if reviews, next := list(); len(reviews) != 1 || reviews[0].State != "APPROVED" || next != 2 {
+		t.Errorf("Expected the stored page for a 304, got %+v, next page %d", reviews, next)
+	}
+
+	// Changed data replaces the stored page
+	etag = `"v2"`
+	body = `[{"id": 2, "user": {"login": "carol"}, "state": "CHANGES_REQUESTED"}]`
+	if reviews, _ := list(); len(reviews) != 1 || reviews[0].User.Login != "carol" {
+		t.Errorf("Expected the changed page, got %+v", reviews)
+	}
+	if reviews, _ := list(); len(reviews) != 1 || reviews[0].User.Login != "carol" {
+		t.Errorf("Expected the changed page to be stored, got %+v", reviews)
+	}
+
+	want := []string{"", `"v1"`, `"v1"`, `"v2"`}
+	if len(conditional) != len(want) {
+		t.Fatalf("Expected %d requests, got %d", len(want), len(conditional))
+	}
+	for i := range want {
+		if conditional[i] != want[i] {
+			t.Errorf("Expected request %d to have If-None-Match %q, got %q", i, want[i], conditional[i])
+		}
+	}
+}
+
+func TestConditionalRequests_Get(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-Modified-Since") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", "Mon, 10 Jun 2024 12:00:00 GMT")
+		io.WriteString(w, `[{"event": "review_requested", "node_id": "abc"}]`)
+	}))
+	defer server.Close()
+
+	responses, err := cache.NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := newPageStore()
+	pages.cache = responses
+	api := newGoGitHub(&http.Client{Transport: newConditionalTransport(http.DefaultTransport, pages)}, pages)
+	api.client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	for i := 0; i < 2; i++ {
+		var events []struct {
+			Event string `json:"event"`
+		}
+		if _, err := api.Get(context.Background(), "repos/o/r/issues/1/events", &events); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Event != "review_requested" {
+			t.Errorf("Request %d: expected the event, got %+v", i, events)
+		}
+	}
+	if len(conditional) != 2 || conditional[0] != "" || conditional[1] == "" {
+		t.Errorf("Expected the second request to be conditional, got If-Modified-Since %q", conditional)
+	}
+}
+
+// A 304 for a page that's no longer stored is passed on as go-github's error
+func TestConditional_Expired(t *testing.T) {
+	responses, err := cache.NewFileCacheWithDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := newPageStore()
+	pages.cache = responses
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/o/r/pulls/1/reviews", nil)
+	resp := &github.Response{Response: &http.Response{StatusCode: http.StatusNotModified, Request: req}}
+	notModified := &github.ErrorResponse{Response: resp.Response}
+
+	_, _, err = conditional(pages, func() ([]*Review, *github.Response, error) {
+		return nil, resp, notModified
+	})
+	if err != notModified {
+		t.Errorf("Expected the 304 error, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package github
+
+import "github.com/google/go-github/v39/github"
+
+// convertAll converts each of a listing's items from the API's type
+func convertAll[T, M any](items []*T, convert func(*T) *M) []*M {
+	if items == nil {
+		return nil
+	}
+	converted := make([]*M, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, convert(item))
+	}
+	return converted
+}
+
+// convertPullRequest converts a PR, from a listing or fetched on its own
+func convertPullRequest(pr *github.PullRequest) *PullRequest {
+	converted := &PullRequest{
+		Number:       pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		State:        pr.GetState(),
+		Draft:        pr.GetDraft(),
+		User:         convertUser(pr.GetUser()),
+		Base:         Branch{Ref: pr.GetBase().GetRef()},
+		Head:         Branch{Ref: pr.GetHead().GetRef()},
+		CreatedAt:    pr.GetCreatedAt(),
+		ClosedAt:     pr.GetClosedAt(),
+		MergedAt:     pr.GetMergedAt(),
+		Additions:    pr.GetAdditions(),
+		Deletions:    pr.GetDeletions(),
+		ChangedFiles: pr.GetChangedFiles(),
+	}
+	for _, label := range pr.Labels {
+		converted.Labels = append(converted.Labels, Label{Name: label.GetName()})
+	}
+	return converted
+}
+
+// convertUser converts an account; a missing one, such as a deleted user's, has an empty login
+func convertUser(user *github.User) User {
+	return User{Login: user.GetLogin(), Type: user.GetType()}
+}
+
+// convertReview converts a review
+func convertReview(review *github.PullRequestReview) *Review {
+	return &Review{
+		ID:          review.GetID(),
+		User:        convertUser(review.GetUser()),
+		State:       review.GetState(),
+		SubmittedAt: review.GetSubmittedAt(),
+	}
+}
+
+// convertCommit converts a commit, with its files if they were listed
+func convertCommit(commit *github.RepositoryCommit) *Commit {
+	return &Commit{
+		SHA: commit.GetSHA(),
+		Commit: CommitDetail{
+			Message:   commit.GetCommit().GetMessage(),
+			Author:    convertSignature(commit.GetCommit().GetAuthor()),
+			Committer: convertSignature(commit.GetCommit().GetCommitter()),
+		},
+		Files: convertAll(commit.Files, convertFile),
+	}
+}
+
+// convertSignature converts a commit's author or committer
+func convertSignature(author *github.CommitAuthor) CommitSignature {
+	return CommitSignature{Name: author.GetName(), Date: author.GetDate()}
+}
+
+// convertFile converts a changed file
+func convertFile(file *github.CommitFile) *File {
+	return &File{
+		Filename:  file.GetFilename(),
+		Status:    file.GetStatus(),
+		Additions: file.GetAdditions(),
+		Deletions: file.GetDeletions(),
+		Changes:   file.GetChanges(),
+		Patch:     file.GetPatch(),
+	}
+}
+
+// convertReviewComment converts a comment on a PR's diff
+func convertReviewComment(comment *github.PullRequestComment) *Comment {
+	return &Comment{User: convertUser(comment.GetUser()), CreatedAt: comment.GetCreatedAt()}
+}
+
+// convertIssueComment converts a comment in a PR's conversation
+func convertIssueComment(comment *github.IssueComment) *Comment {
+	return &Comment{User: convertUser(comment.GetUser()), CreatedAt: comment.GetCreatedAt()}
+}
+
+// convertTimelineEvent converts a timeline event
+func convertTimelineEvent(event *github.Timeline) *TimelineEvent {
+	return &TimelineEvent{Event: event.GetEvent(), CreatedAt: event.GetCreatedAt()}
+}
+
+// convertDismissal converts a review_dismissed event
+func convertDismissal(event *github.IssueEvent) *DismissalEvent {
+	return &DismissalEvent{
+		DismissedReview: DismissedReview{
+			ReviewID: event.GetDismissedReview().GetReviewID(),
+			State:    event.GetDismissedReview().GetState(),
+		},
+		CreatedAt: event.GetCreatedAt(),
+	}
+}
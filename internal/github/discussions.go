@@ -0,0 +1,186 @@
+package github
+
+import (
+	"context"
+	"time"
+)
+
+// discussionsQuery pages through a repository's discussions, newest first. Only the
+// first 100 comments are fetched, which is plenty to find the first maintainer reply.
+const discussionsQuery = `
+query($owner: String!, $repo: String!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    discussions(first: 50, after: $cursor, orderBy: {field: CREATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number
+        title
+        url
+        createdAt
+        answerChosenAt
+        closedAt
+        author { login }
+        category { name isAnswerable }
+        comments(first: 100) {
+          nodes {
+            createdAt
+            authorAssociation
+            author { login }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphQLActor struct {
+	Login string `json:"login"`
+}
+
+type discussionsResponse struct {
+	Repository struct {
+		Discussions struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []struct {
+				Number         int           `json:"number"`
+				Title          string        `json:"title"`
+				URL            string        `json:"url"`
+				CreatedAt      time.Time     `json:"createdAt"`
+				AnswerChosenAt *time.Time    `json:"answerChosenAt"`
+				ClosedAt       *time.Time    `json:"closedAt"`
+				Author         *graphQLActor `json:"author"` // nil for deleted users
+				Category       struct {
+					Name         string `json:"name"`
+					IsAnswerable bool   `json:"isAnswerable"`
+				} `json:"category"`
+				Comments struct {
+					Nodes []struct {
+						CreatedAt         time.Time     `json:"createdAt"`
+						AuthorAssociation string        `json:"authorAssociation"`
+						Author            *graphQLActor `json:"author"`
+					} `json:"nodes"`
+				} `json:"comments"`
+			} `json:"nodes"`
+		} `json:"discussions"`
+	} `json:"repository"`
+}
+
+// FetchDiscussions fetches discussions created in the date range. If the API budget
+// runs out part way through, the discussions fetched so far are returned along with the error.
+func (c *GitHubClient) FetchDiscussions(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]Discussion, error) {
+	var allDiscussions []Discussion
+	variables := map[string]interface{}{
+		"owner":  owner,
+		"repo":   repo,
+		"cursor": nil,
+	}
+
+	for {
+		var resp discussionsResponse
+		if err := c.graphQL(ctx, discussionsQuery, variables, &resp); err != nil {
+			return allDiscussions, err
+		}
+
+		page := resp.Repository.Discussions
+		reachedStart := false
+		for _, node := range page.Nodes {
+			if node.CreatedAt.Before(startDate) {
+				reachedStart = true
+				continue
+			}
+			if node.CreatedAt.After(endDate) {
+				continue
+			}
+
+			d := Discussion{
+				Number:         node.Number,
+				Title:          node.Title,
+				URL:            node.URL,
+				Author:         node.Author.login(),
+				Category:       node.Category.Name,
+				Answerable:     node.Category.IsAnswerable,
+				CreatedAt:      node.CreatedAt,
+				AnswerChosenAt: node.AnswerChosenAt,
+				ClosedAt:       node.ClosedAt,
+			}
+			for _, comment := range node.Comments.Nodes {
+				d.Comments = append(d.Comments, DiscussionComment{
+					Author:            comment.Author.login(),
+					AuthorAssociation: comment.AuthorAssociation,
+					CreatedAt:         comment.CreatedAt,
+				})
+			}
+			allDiscussions = append(allDiscussions, d)
+		}
+
+		// Discussions are newest first, so stop once we're past the start date
+		if !page.PageInfo.HasNextPage || reachedStart {
+			break
+		}
+		variables["cursor"] = page.PageInfo.EndCursor
+	}
+
+	return allDiscussions, nil
+}
+
+func (a *graphQLActor) login() string {
+	if a == nil {
+		return ""
+	}
+	return a.Login
+}
+
+// maintainerAssociations are the author associations whose replies count as a maintainer response
+var maintainerAssociations = map[string]bool{
+	"OWNER":        true,
+	"MEMBER":       true,
+	"COLLABORATOR": true,
+}
+
+// ProcessDiscussions measures how quickly maintainers responded to and resolved each discussion
+func ProcessDiscussions(discussions []Discussion) []DiscussionMetric {
+	var results []DiscussionMetric
+
+	for _, d := range discussions {
+		metric := DiscussionMetric{
+			Number:            d.Number,
+			Title:             d.Title,
+			Author:            d.Author,
+			Category:          d.Category,
+			Answerable:        d.Answerable,
+			TimeSinceCreation: time.Since(d.CreatedAt),
+		}
+
+		// Find the first reply from a maintainer other than the discussion's author
+		var firstResponse *DiscussionComment
+		for i, comment := range d.Comments {
+			if !maintainerAssociations[comment.AuthorAssociation] || comment.Author == d.Author {
+				continue
+			}
+			if firstResponse == nil || comment.CreatedAt.Before(firstResponse.CreatedAt) {
+				firstResponse = &d.Comments[i]
+			}
+		}
+		if firstResponse != nil {
+			metric.HasResponse = true
+			metric.TimeToFirstResponse = firstResponse.CreatedAt.Sub(d.CreatedAt)
+			metric.FirstResponder = firstResponse.Author
+		}
+
+		if d.AnswerChosenAt != nil {
+			metric.Answered = true
+			metric.TimeToAnswer = d.AnswerChosenAt.Sub(d.CreatedAt)
+		}
+		if d.ClosedAt != nil {
+			metric.Closed = true
+			metric.TimeToClose = d.ClosedAt.Sub(d.CreatedAt)
+		}
+
+		results = append(results, metric)
+	}
+
+	return results
+}
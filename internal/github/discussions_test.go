@@ -0,0 +1,64 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcessDiscussions(t *testing.T) {
+	createdAt := time.Now().Add(-48 * time.Hour)
+	answeredAt := createdAt.Add(6 * time.Hour)
+
+	discussions := []Discussion{
+		{
+			Number:         1,
+			Title:          "How do I configure the cache?",
+			Author:         "asker",
+			Category:       "Q&A",
+			Answerable:     true,
+			CreatedAt:      createdAt,
+			AnswerChosenAt: &answeredAt,
+			Comments: []DiscussionComment{
+				// Community replies and the author's own follow-ups don't count
+				{Author: "helper", AuthorAssociation: "CONTRIBUTOR", CreatedAt: createdAt.Add(1 * time.Hour)},
+				{Author: "asker", AuthorAssociation: "NONE", CreatedAt: createdAt.Add(2 * time.Hour)},
+				{Author: "maintainer", AuthorAssociation: "MEMBER", CreatedAt: createdAt.Add(3 * time.Hour)},
+			},
+		},
+		{
+			Number:    2,
+			Title:     "Idea: dark mode",
+			Author:    "fan",
+			Category:  "Ideas",
+			CreatedAt: createdAt,
+			Comments: []DiscussionComment{
+				{Author: "someone", AuthorAssociation: "NONE", CreatedAt: createdAt.Add(1 * time.Hour)},
+			},
+		},
+	}
+
+	results := ProcessDiscussions(discussions)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	answered := results[0]
+	if !answered.HasResponse || answered.FirstResponder != "maintainer" {
+		t.Errorf("Expected a response from maintainer, got %+v", answered)
+	}
+	if answered.TimeToFirstResponse != 3*time.Hour {
+		t.Errorf("Expected TimeToFirstResponse 3h, got %v", answered.TimeToFirstResponse)
+	}
+	if !answered.Answered || answered.TimeToAnswer != 6*time.Hour {
+		t.Errorf("Expected an answer after 6h, got answered=%v after %v", answered.Answered, answered.TimeToAnswer)
+	}
+
+	unanswered := results[1]
+	if unanswered.HasResponse {
+		t.Errorf("Expected no maintainer response, got one from %s", unanswered.FirstResponder)
+	}
+	if unanswered.Answered || unanswered.Answerable {
+		t.Errorf("Expected an unanswerable, unanswered discussion, got %+v", unanswered)
+	}
+}
@@ -0,0 +1,48 @@
+package github
+
+import (
+	"slices"
+	"time"
+)
+
+// draftTime returns when a PR was first ready for review and how long it spent as
+// a draft, from its ready_for_review and convert_to_draft timeline events. A PR
+// whose first such event is ready_for_review was opened as a draft.
+func draftTime(pr *PullRequest, timeline []*TimelineEvent, opts ProcessOptions) (readyAt time.Time, inDraft time.Duration) {
+	var events []*TimelineEvent
+	for _, event := range timeline {
+		if e := event.Event; e == "ready_for_review" || e == "convert_to_draft" {
+			events = append(events, event)
+		}
+	}
+	slices.SortStableFunc(events, func(a, b *TimelineEvent) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	readyAt = pr.CreatedAt
+	draft := len(events) > 0 && events[0].Event == "ready_for_review"
+	since := pr.CreatedAt
+	for i, event := range events {
+		switch {
+		case event.Event == "convert_to_draft" && !draft:
+			draft = true
+			since = event.CreatedAt
+		case event.Event == "ready_for_review" && draft:
+			draft = false
+			inDraft += opts.elapsed(since, event.CreatedAt)
+			if i == 0 {
+				readyAt = event.CreatedAt
+			}
+		}
+	}
+
+	// Still a draft: count the time up to when it was closed, or now
+	if draft {
+		end := time.Now()
+		if !pr.ClosedAt.IsZero() {
+			end = pr.ClosedAt
+		}
+		inDraft += opts.elapsed(since, end)
+	}
+	return readyAt, inDraft
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func timelineEvent(event string, at time.Time) *TimelineEvent {
+	return &TimelineEvent{Event: event, CreatedAt: at}
+}
+
+func TestDraftTime(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	pr := &PullRequest{CreatedAt: createdAt}
+
+	tests := []struct {
+		name        string
+		timeline    []*TimelineEvent
+		wantReadyAt time.Time
+		wantInDraft time.Duration
+	}{
+		{
+			name:        "never a draft",
+			timeline:    []*TimelineEvent{timelineEvent("labeled", createdAt.Add(time.Hour))},
+			wantReadyAt: createdAt,
+		},
+		{
+			name:        "opened as a draft",
+			timeline:    []*TimelineEvent{timelineEvent("ready_for_review", createdAt.Add(5*time.Hour))},
+			wantReadyAt: createdAt.Add(5 * time.Hour),
+			wantInDraft: 5 * time.Hour,
+		},
+		{
+			name: "converted back to draft after review",
+			timeline: []*TimelineEvent{
+				timelineEvent("ready_for_review", createdAt.Add(2*time.Hour)),
+				timelineEvent("convert_to_draft", createdAt.Add(4*time.Hour)),
+				timelineEvent("ready_for_review", createdAt.Add(7*time.Hour)),
+			},
+			wantReadyAt: createdAt.Add(2 * time.Hour),
+			wantInDraft: 5 * time.Hour,
+		},
+		{
+			name:        "converted to draft after opening",
+			timeline:    []*TimelineEvent{timelineEvent("convert_to_draft", createdAt.Add(time.Hour)), timelineEvent("ready_for_review", createdAt.Add(3*time.Hour))},
+			wantReadyAt: createdAt,
+			wantInDraft: 2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		readyAt, inDraft := draftTime(pr, tt.timeline, ProcessOptions{})
+		if !readyAt.Equal(tt.wantReadyAt) || inDraft != tt.wantInDraft {
+			t.Errorf("%s: got ready at %v after %v in draft, want %v after %v", tt.name, readyAt, inDraft, tt.wantReadyAt, tt.wantInDraft)
+		}
+	}
+}
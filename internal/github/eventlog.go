@@ -0,0 +1,249 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/eventlog"
+)
+
+// RecordingClient records everything another client fetches in an event log, so
+// metrics can later be recomputed from the log with ReplayClient. Events are keyed
+// like cache entries.
+type RecordingClient struct {
+	client GitHubClientInterface
+	events *eventlog.Log
+	kb     *cache.CacheKeyBuilder
+}
+
+// NewRecordingClient wraps client, recording what it fetches in events
+func NewRecordingClient(client GitHubClientInterface, events *eventlog.Log) *RecordingClient {
+	return &RecordingClient{client: client, events: events, kb: cache.NewCacheKeyBuilder("github")}
+}
+
+// record logs a successful fetch. Failing to log doesn't fail the fetch.
+func (c *RecordingClient) record(key string, data interface{}, err error) {
+	if err != nil {
+		return
+	}
+	if err := c.events.Append(key, data); err != nil {
+		log.Printf("Failed to record event: %v", err)
+	}
+}
+
+func (c *RecordingClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	prs, err := c.client.FetchPullRequests(ctx, owner, repo, startDate, endDate)
+	c.record(c.kb.PRsListKey(owner, repo, startDate, endDate), prs, err)
+	return prs, err
+}
+
+func (c *RecordingClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
+	reviews, err := c.client.FetchPullRequestReviews(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRReviewsKey(owner, repo, prNumber), reviews, err)
+	return reviews, err
+}
+
+func (c *RecordingClient) FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error) {
+	commits, err := c.client.FetchPullRequestCommits(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRCommitsKey(owner, repo, prNumber), commits, err)
+	return commits, err
+}
+
+func (c *RecordingClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	files, err := c.client.FetchPullRequestFiles(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRFilesKey(owner, repo, prNumber), files, err)
+	return files, err
+}
+
+func (c *RecordingClient) FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	comments, err := c.client.FetchPullRequestComments(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRCommentsKey(owner, repo, prNumber), comments, err)
+	return comments, err
+}
+
+func (c *RecordingClient) FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	comments, err := c.client.FetchPullRequestIssueComments(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRIssueCommentsKey(owner, repo, prNumber), comments, err)
+	return comments, err
+}
+
+func (c *RecordingClient) FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error) {
+	events, err := c.client.FetchPullRequestTimeline(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRTimelineKey(owner, repo, prNumber), events, err)
+	return events, err
+}
+
+func (c *RecordingClient) FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error) {
+	events, err := c.client.FetchPullRequestReviewRequests(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRReviewRequestsKey(owner, repo, prNumber), events, err)
+	return events, err
+}
+
+func (c *RecordingClient) FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error) {
+	events, err := c.client.FetchPullRequestDismissals(ctx, owner, repo, prNumber)
+	c.record(c.kb.PRDismissalsKey(owner, repo, prNumber), events, err)
+	return events, err
+}
+
+func (c *RecordingClient) FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	n, err := c.client.FetchRequiredApprovals(ctx, owner, repo, branch)
+	c.record(c.kb.RequiredApprovalsKey(owner, repo, branch), n, err)
+	return n, err
+}
+
+func (c *RecordingClient) FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error) {
+	content, err := c.client.FetchCodeowners(ctx, owner, repo, ref)
+	c.record(c.kb.CodeownersKey(owner, repo, ref), content, err)
+	return content, err
+}
+
+func (c *RecordingClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	members, err := c.client.FetchTeamMembers(ctx, org, team)
+	c.record(c.kb.TeamMembersKey(org, team), members, err)
+	return members, err
+}
+
+func (c *RecordingClient) FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error) {
+	commits, err := c.client.FetchCommits(ctx, owner, repo, since, until)
+	c.record(c.kb.CommitsListKey(owner, repo, since, until), commits, err)
+	return commits, err
+}
+
+func (c *RecordingClient) FetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
+	commit, err := c.client.FetchCommit(ctx, owner, repo, sha)
+	c.record(c.kb.CommitKey(owner, repo, sha), commit, err)
+	return commit, err
+}
+
+// ReplayClient serves data recorded by a RecordingClient, without calling the API.
+// Anything that wasn't recorded fails with an error wrapping eventlog.ErrNotLogged.
+type ReplayClient struct {
+	events *eventlog.Log
+	kb     *cache.CacheKeyBuilder
+}
+
+// NewReplayClient creates a client that replays events
+func NewReplayClient(events *eventlog.Log) *ReplayClient {
+	return &ReplayClient{events: events, kb: cache.NewCacheKeyBuilder("github")}
+}
+
+// FetchPullRequests returns the recorded PRs created in the date range. Every
+// recorded listing of the repository is searched, so the range doesn't need to
+// match a recorded run's exactly.
+func (c *ReplayClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	listings := c.events.LatestWithPrefix(c.kb.PRsListPrefix(owner, repo))
+	if len(listings) == 0 {
+		return nil, fmt.Errorf("%s/%s pull requests: %w", owner, repo, eventlog.ErrNotLogged)
+	}
+
+	// Apply listings oldest first, so each PR ends up in its latest recorded state
+	slices.SortFunc(listings, func(a, b eventlog.Event) int {
+		return a.At.Compare(b.At)
+	})
+	byNumber := make(map[int]*PullRequest)
+	for _, listing := range listings {
+		var prs []*PullRequest
+		if err := json.Unmarshal(listing.Data, &prs); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %w", listing.Key, err)
+		}
+		for _, pr := range prs {
+			if pr.CreatedAt.Before(startDate) || pr.CreatedAt.After(endDate) {
+				continue
+			}
+			byNumber[pr.Number] = pr
+		}
+	}
+
+	// Newest first, as the API lists them
+	prs := make([]*PullRequest, 0, len(byNumber))
+	for _, pr := range byNumber {
+		prs = append(prs, pr)
+	}
+	slices.SortFunc(prs, func(a, b *PullRequest) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return prs, nil
+}
+
+func (c *ReplayClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
+	var reviews []*Review
+	err := c.events.Latest(c.kb.PRReviewsKey(owner, repo, prNumber), &reviews)
+	return reviews, err
+}
+
+func (c *ReplayClient) FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error) {
+	var commits []*Commit
+	err := c.events.Latest(c.kb.PRCommitsKey(owner, repo, prNumber), &commits)
+	return commits, err
+}
+
+func (c *ReplayClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	var files []*File
+	err := c.events.Latest(c.kb.PRFilesKey(owner, repo, prNumber), &files)
+	return files, err
+}
+
+func (c *ReplayClient) FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	var comments []*Comment
+	err := c.events.Latest(c.kb.PRCommentsKey(owner, repo, prNumber), &comments)
+	return comments, err
+}
+
+func (c *ReplayClient) FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	var comments []*Comment
+	err := c.events.Latest(c.kb.PRIssueCommentsKey(owner, repo, prNumber), &comments)
+	return comments, err
+}
+
+func (c *ReplayClient) FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error) {
+	var events []*TimelineEvent
+	err := c.events.Latest(c.kb.PRTimelineKey(owner, repo, prNumber), &events)
+	return events, err
+}
+
+func (c *ReplayClient) FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error) {
+	var events []*ReviewRequestEvent
+	err := c.events.Latest(c.kb.PRReviewRequestsKey(owner, repo, prNumber), &events)
+	return events, err
+}
+
+func (c *ReplayClient) FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error) {
+	var events []*DismissalEvent
+	err := c.events.Latest(c.kb.PRDismissalsKey(owner, repo, prNumber), &events)
+	return events, err
+}
+
+func (c *ReplayClient) FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	var n int
+	err := c.events.Latest(c.kb.RequiredApprovalsKey(owner, repo, branch), &n)
+	return n, err
+}
+
+func (c *ReplayClient) FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error) {
+	var content string
+	err := c.events.Latest(c.kb.CodeownersKey(owner, repo, ref), &content)
+	return content, err
+}
+
+func (c *ReplayClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	var members []string
+	err := c.events.Latest(c.kb.TeamMembersKey(org, team), &members)
+	return members, err
+}
+
+func (c *ReplayClient) FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error) {
+	var commits []*Commit
+	err := c.events.Latest(c.kb.CommitsListKey(owner, repo, since, until), &commits)
+	return commits, err
+}
+
+func (c *ReplayClient) FetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
+	var commit *Commit
+	err := c.events.Latest(c.kb.CommitKey(owner, repo, sha), &commit)
+	return commit, err
+}
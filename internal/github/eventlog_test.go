@@ -0,0 +1,84 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/cache"
+	"github.com/reillywatson/statstracker/internal/eventlog"
+)
+
+func TestReplayClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	events, err := eventlog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := start.Add(24 * time.Hour)
+	merged := created.Add(5 * time.Hour)
+	reviewed := created.Add(2 * time.Hour)
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "Recorded PR",
+		User:      User{Login: "author"},
+		State:     "closed",
+		CreatedAt: created,
+		MergedAt:  merged,
+	}
+	mock := &MockGitHubClient{
+		reviews: []*Review{{
+			User:        User{Login: "reviewer"},
+			State:       "APPROVED",
+			SubmittedAt: reviewed,
+		}},
+	}
+
+	// Record a run: the PR listing and everything processing it fetches
+	kb := cache.NewCacheKeyBuilder("github")
+	if err := events.Append(kb.PRsListKey("owner", "repo", start, start.AddDate(0, 0, 7)), []*PullRequest{pr}); err != nil {
+		t.Fatal(err)
+	}
+	recorded := ProcessPullRequests(context.Background(), NewRecordingClient(mock, events), []*PullRequest{pr}, "owner", "repo", ProcessOptions{})
+	if err := events.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay it from a fresh read of the log, over a different date range
+	events, err = eventlog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Close()
+	replay := NewReplayClient(events)
+
+	prs, err := replay.FetchPullRequests(context.Background(), "owner", "repo", start, start.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 || prs[0].Number != 1 {
+		t.Fatalf("Expected the recorded PR, got %v", prs)
+	}
+	if prs, _ := replay.FetchPullRequests(context.Background(), "owner", "repo", start.AddDate(0, 0, 2), start.AddDate(0, 0, 7)); len(prs) != 0 {
+		t.Errorf("Expected no PRs created in the range, got %d", len(prs))
+	}
+
+	replayed := ProcessPullRequests(context.Background(), replay, prs, "owner", "repo", ProcessOptions{})
+	if len(replayed) != 1 || len(recorded) != 1 {
+		t.Fatalf("Expected 1 result each, got %d recorded and %d replayed", len(recorded), len(replayed))
+	}
+	if replayed[0].FirstReviewer != "reviewer" || replayed[0].TimeToFirstReview != recorded[0].TimeToFirstReview {
+		t.Errorf("Expected replay to match the recorded run, got %+v, want %+v", replayed[0], recorded[0])
+	}
+
+	if _, err := replay.FetchPullRequestFiles(context.Background(), "owner", "repo", 1); !errors.Is(err, eventlog.ErrNotLogged) {
+		t.Errorf("Expected ErrNotLogged for unrecorded files, got %v", err)
+	}
+	if _, err := replay.FetchPullRequests(context.Background(), "owner", "other", start, start.AddDate(0, 0, 7)); !errors.Is(err, eventlog.ErrNotLogged) {
+		t.Errorf("Expected ErrNotLogged for an unrecorded repository, got %v", err)
+	}
+}
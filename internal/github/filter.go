@@ -0,0 +1,68 @@
+package github
+
+import (
+	"path"
+	"strings"
+)
+
+// UserFilter decides whose PRs and reviews count towards metrics. Logins are
+// matched case-insensitively, as GitHub treats them. Bots are left out unless
+// IncludeBots is set.
+type UserFilter struct {
+	Exclude     []string // Users whose PRs and reviews are ignored
+	Authors     []string // If set, only PRs opened by these users are counted
+	BotPatterns []string // Glob patterns, such as *-ci, for bot logins GitHub doesn't mark as bots
+	IncludeBots bool     // Count PRs and reviews by bots
+}
+
+// IncludesAuthor reports whether PRs opened by user count
+func (f UserFilter) IncludesAuthor(user User) bool {
+	if !f.IncludesReviewer(user) {
+		return false
+	}
+	return len(f.Authors) == 0 || containsLogin(f.Authors, user.Login)
+}
+
+// IncludesReviewer reports whether reviews by user count. The author allowlist
+// doesn't apply here, so reviews from outside a team still count on its PRs.
+func (f UserFilter) IncludesReviewer(user User) bool {
+	if containsLogin(f.Exclude, user.Login) {
+		return false
+	}
+	return f.IncludeBots || !f.IsBot(user)
+}
+
+// IsBot reports whether user is a bot: an app account (whose logins end in [bot]),
+// a user GitHub types as a bot, or a login matching one of the bot patterns
+func (f UserFilter) IsBot(user User) bool {
+	login := strings.ToLower(user.Login)
+	if strings.HasSuffix(login, "[bot]") || user.Type == "Bot" {
+		return true
+	}
+	for _, pattern := range f.BotPatterns {
+		if ok, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), login); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLogin(logins []string, login string) bool {
+	for _, l := range logins {
+		if strings.EqualFold(strings.TrimSpace(l), login) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesBranch reports whether branch matches any of the glob patterns. As in
+// path.Match, * doesn't match a slash, so release/* matches release/1.2 but not main.
+func matchesBranch(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.TrimSpace(pattern), branch); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package github
+
+import (
+	"testing"
+)
+
+func user(login string) User {
+	return User{Login: login}
+}
+
+func TestUserFilter(t *testing.T) {
+	f := UserFilter{
+		Exclude: []string{"mallory", "carol"},
+		Authors: []string{"alice", "Bob", "carol"},
+	}
+
+	tests := []struct {
+		login            string
+		author, reviewer bool
+	}{
+		{"alice", true, true},
+		{"bob", true, true},     // matched case-insensitively
+		{"carol", false, false}, // excluding wins over the allowlist
+		{"dave", false, true},   // not on the team, but their reviews count
+		{"mallory", false, false},
+	}
+	for _, tt := range tests {
+		if got := f.IncludesAuthor(user(tt.login)); got != tt.author {
+			t.Errorf("IncludesAuthor(%q) = %v, want %v", tt.login, got, tt.author)
+		}
+		if got := f.IncludesReviewer(user(tt.login)); got != tt.reviewer {
+			t.Errorf("IncludesReviewer(%q) = %v, want %v", tt.login, got, tt.reviewer)
+		}
+	}
+
+	if !(UserFilter{}).IncludesAuthor(user("anyone")) {
+		t.Error("Expected an empty filter to include every human author")
+	}
+}
+
+func TestUserFilterBots(t *testing.T) {
+	f := UserFilter{BotPatterns: []string{"*-ci", "renovate*"}}
+
+	bots := []User{
+		user("dependabot[bot]"),
+		{Login: "some-machine-user", Type: "Bot"},
+		user("deploy-ci"),
+		user("Renovate-Approve"),
+	}
+	for _, bot := range bots {
+		if !f.IsBot(bot) {
+			t.Errorf("Expected %s to be detected as a bot", bot.Login)
+		}
+		if f.IncludesAuthor(bot) || f.IncludesReviewer(bot) {
+			t.Errorf("Expected %s to be excluded by default", bot.Login)
+		}
+	}
+	if f.IsBot(user("alice")) {
+		t.Error("Expected alice not to be a bot")
+	}
+
+	f.IncludeBots = true
+	if !f.IncludesAuthor(user("dependabot[bot]")) {
+		t.Error("Expected bots to be included with IncludeBots")
+	}
+}
+
+func TestMatchesBranch(t *testing.T) {
+	patterns := []string{"main", " release/*"}
+
+	tests := []struct {
+		branch string
+		want   bool
+	}{
+		{"main", true},
+		{"release/1.2", true},
+		{"release/1.2/hotfix", false},
+		{"feature/big-rewrite", false},
+		{"mainline", false},
+	}
+	for _, tt := range tests {
+		if got := matchesBranch(patterns, tt.branch); got != tt.want {
+			t.Errorf("matchesBranch(%q) = %v, want %v", tt.branch, got, tt.want)
+		}
+	}
+}
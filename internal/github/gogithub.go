@@ -0,0 +1,277 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// goGitHub implements restAPI with go-github
+type goGitHub struct {
+	client *github.Client
+	pages  *pageStore // Pages kept for conditional requests, made by the client's transport
+}
+
+func newGoGitHub(httpClient *http.Client, pages *pageStore) *goGitHub {
+	return &goGitHub{client: github.NewClient(httpClient), pages: pages}
+}
+
+// listOptions asks for a page of a listing, as many items to a page as GitHub allows
+func listOptions(page int) github.ListOptions {
+	return github.ListOptions{Page: page, PerPage: 100}
+}
+
+// notFound wraps err in errNotFound if the API said what was asked for doesn't exist
+func notFound(resp *github.Response, err error) error {
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %v", errNotFound, err)
+	}
+	return err
+}
+
+// conditional runs get, a go-github call with its result converted to the
+// package's types. If GitHub said nothing has changed since the page stored for
+// the call's URL (see conditionalTransport), the stored page is returned instead,
+// with its next page; otherwise a successful result is stored with the response's
+// validators.
+func conditional[T any](pages *pageStore, get func() (T, *github.Response, error)) (T, *github.Response, error) {
+	value, resp, err := get()
+	if pages == nil || pages.cache == nil || resp == nil || resp.Response == nil || resp.Request == nil {
+		return value, resp, err
+	}
+	key := pages.kb.PageKey(resp.Request.URL.String())
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		var stored storedPage[T]
+		if pages.cache.Get(key, &stored) != nil {
+			return value, resp, err // Expired since the request was made
+		}
+		resp.NextPage = stored.Next
+		return stored.Value, resp, nil
+
+	case err == nil && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		stored := storedPage[T]{
+			validators: validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")},
+			Next:       resp.NextPage,
+			Value:      value,
+		}
+		if err := pages.cache.Set(key, stored, conditionalPageTTL); err != nil {
+			log.Printf("Failed to store page for conditional requests: %v", err)
+		}
+	}
+	return value, resp, err
+}
+
+func (g *goGitHub) GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error) {
+	pr, _, err := conditional(g.pages, func() (*PullRequest, *github.Response, error) {
+		pr, resp, err := g.client.PullRequests.Get(ctx, owner, repo, number)
+		if err != nil {
+			return nil, resp, err
+		}
+		return convertPullRequest(pr), resp, nil
+	})
+	return pr, err
+}
+
+func (g *goGitHub) ListReviews(ctx context.Context, owner, repo string, number, page int) ([]*Review, int, error) {
+	opts := listOptions(page)
+	reviews, resp, err := conditional(g.pages, func() ([]*Review, *github.Response, error) {
+		reviews, resp, err := g.client.PullRequests.ListReviews(ctx, owner, repo, number, &opts)
+		return convertAll(reviews, convertReview), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, resp.NextPage, nil
+}
+
+func (g *goGitHub) ListPullRequestCommits(ctx context.Context, owner, repo string, number, page int) ([]*Commit, int, error) {
+	opts := listOptions(page)
+	commits, resp, err := conditional(g.pages, func() ([]*Commit, *github.Response, error) {
+		commits, resp, err := g.client.PullRequests.ListCommits(ctx, owner, repo, number, &opts)
+		return convertAll(commits, convertCommit), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return commits, resp.NextPage, nil
+}
+
+func (g *goGitHub) ListPullRequestFiles(ctx context.Context, owner, repo string, number, page int) ([]*File, int, error) {
+	opts := listOptions(page)
+	files, resp, err := conditional(g.pages, func() ([]*File, *github.Response, error) {
+		files, resp, err := g.client.PullRequests.ListFiles(ctx, owner, repo, number, &opts)
+		return convertAll(files, convertFile), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return files, resp.NextPage, nil
+}
+
+func (g *goGitHub) ListReviewComments(ctx context.Context, owner, repo string, number, page int) ([]*Comment, int, error) {
+	opts := &github.PullRequestListCommentsOptions{ListOptions: listOptions(page)}
+	comments, resp, err := conditional(g.pages, func() ([]*Comment, *github.Response, error) {
+		comments, resp, err := g.client.PullRequests.ListComments(ctx, owner, repo, number, opts)
+		return convertAll(comments, convertReviewComment), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, resp.NextPage, nil
+}
+
+func (g *goGitHub) ListIssueComments(ctx context.Context, owner, repo string, number, page int) ([]*Comment, int, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: listOptions(page)}
+	comments, resp, err := conditional(g.pages, func() ([]*Comment, *github.Response, error) {
+		comments, resp, err := g.client.Issues.ListComments(ctx, owner, repo, number, opts)
+		return convertAll(comments, convertIssueComment), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, resp.NextPage, nil
+}
+
+func (g *goGitHub) ListTimeline(ctx context.Context, owner, repo string, number, page int) ([]*TimelineEvent, int, error) {
+	opts := listOptions(page)
+	events, resp, err := conditional(g.pages, func() ([]*TimelineEvent, *github.Response, error) {
+		events, resp, err := g.client.Issues.ListIssueTimeline(ctx, owner, repo, number, &opts)
+		return convertAll(events, convertTimelineEvent), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, resp.NextPage, nil
+}
+
+// ListDismissals lists a page of the PR's events, keeping just the review dismissals
+func (g *goGitHub) ListDismissals(ctx context.Context, owner, repo string, number, page int) ([]*DismissalEvent, int, error) {
+	opts := listOptions(page)
+	dismissals, resp, err := conditional(g.pages, func() ([]*DismissalEvent, *github.Response, error) {
+		events, resp, err := g.client.Issues.ListIssueEvents(ctx, owner, repo, number, &opts)
+		if err != nil {
+			return nil, resp, err
+		}
+		var dismissals []*DismissalEvent
+		for _, e := range events {
+			if e.GetEvent() == "review_dismissed" {
+				dismissals = append(dismissals, convertDismissal(e))
+			}
+		}
+		return dismissals, resp, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return dismissals, resp.NextPage, nil
+}
+
+func (g *goGitHub) RequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	required, resp, err := conditional(g.pages, func() (int, *github.Response, error) {
+		protection, resp, err := g.client.Repositories.GetBranchProtection(ctx, owner, repo, branch)
+		if err != nil {
+			return 0, resp, err
+		}
+		if protection.GetRequiredPullRequestReviews() == nil {
+			return 0, resp, nil
+		}
+		return protection.GetRequiredPullRequestReviews().RequiredApprovingReviewCount, resp, nil
+	})
+	if err != nil {
+		return 0, notFound(resp, err)
+	}
+	return required, nil
+}
+
+func (g *goGitHub) FileContents(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	content, resp, err := conditional(g.pages, func() (string, *github.Response, error) {
+		file, _, resp, err := g.client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+		if err != nil {
+			return "", resp, err
+		}
+		if file == nil {
+			return "", resp, fmt.Errorf("%w: %s is a directory", errNotFound, path)
+		}
+		content, err := file.GetContent()
+		return content, resp, err
+	})
+	if err != nil {
+		return "", notFound(resp, err)
+	}
+	return content, nil
+}
+
+func (g *goGitHub) ListTeamMembers(ctx context.Context, org, team string, page int) ([]string, int, error) {
+	opts := &github.TeamListTeamMembersOptions{ListOptions: listOptions(page)}
+	members, resp, err := conditional(g.pages, func() ([]string, *github.Response, error) {
+		users, resp, err := g.client.Teams.ListTeamMembersBySlug(ctx, org, team, opts)
+		if err != nil {
+			return nil, resp, err
+		}
+		var members []string
+		for _, user := range users {
+			members = append(members, user.GetLogin())
+		}
+		return members, resp, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return members, resp.NextPage, nil
+}
+
+func (g *goGitHub) ListCommits(ctx context.Context, owner, repo string, since, until time.Time, page int) ([]*Commit, int, error) {
+	opts := &github.CommitsListOptions{Since: since, Until: until, ListOptions: listOptions(page)}
+	commits, resp, err := conditional(g.pages, func() ([]*Commit, *github.Response, error) {
+		commits, resp, err := g.client.Repositories.ListCommits(ctx, owner, repo, opts)
+		return convertAll(commits, convertCommit), resp, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return commits, resp.NextPage, nil
+}
+
+func (g *goGitHub) GetCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
+	commit, _, err := conditional(g.pages, func() (*Commit, *github.Response, error) {
+		commit, resp, err := g.client.Repositories.GetCommit(ctx, owner, repo, sha, nil)
+		if err != nil {
+			return nil, resp, err
+		}
+		return convertCommit(commit), resp, nil
+	})
+	return commit, err
+}
+
+// Get keeps v as it was decoded for conditional requests, which holds just the
+// fields its type has
+func (g *goGitHub) Get(ctx context.Context, path string, v interface{}) (int, error) {
+	req, err := g.client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	data, resp, err := conditional(g.pages, func() (json.RawMessage, *github.Response, error) {
+		resp, err := g.client.Do(ctx, req, v)
+		if err != nil {
+			return nil, resp, err
+		}
+		data, err := json.Marshal(v)
+		return data, resp, err
+	})
+	if err != nil {
+		return 0, notFound(resp, err)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		if err := json.Unmarshal(data, v); err != nil {
+			return 0, fmt.Errorf("failed to decode stored page: %w", err)
+		}
+	}
+	return resp.NextPage, nil
+}
@@ -0,0 +1,87 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HasAnyLabel reports whether pr carries any of labels, ignoring case
+func HasAnyLabel(pr *PullRequest, labels []string) bool {
+	for _, label := range pr.Labels {
+		for _, want := range labels {
+			if strings.EqualFold(label.Name, strings.TrimSpace(want)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Priority is a priority level, such as P0, and the labels that put a PR at it
+type Priority struct {
+	Level  string
+	Labels []string
+}
+
+// ParsePriority parses a priority level from level=label,label..., e.g. P0=sev0,urgent
+func ParsePriority(spec string) (Priority, error) {
+	level, labels, ok := strings.Cut(spec, "=")
+	level = strings.TrimSpace(level)
+	if !ok || level == "" || strings.TrimSpace(labels) == "" {
+		return Priority{}, fmt.Errorf("invalid priority %q. Use level=label,label..., e.g. P0=sev0,urgent", spec)
+	}
+	var p Priority
+	p.Level = level
+	for _, label := range strings.Split(labels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			p.Labels = append(p.Labels, label)
+		}
+	}
+	return p, nil
+}
+
+// String gives the priority as it's parsed, e.g. P0=sev0,urgent
+func (p Priority) String() string {
+	return p.Level + "=" + strings.Join(p.Labels, ",")
+}
+
+// Priorities are priority levels, most urgent first. It's a flag.Value, so each
+// level can be given with a repeated flag, e.g. -priority P0=sev0 -priority P1=sev1.
+type Priorities []Priority
+
+func (p *Priorities) String() string {
+	var specs []string
+	for _, priority := range *p {
+		specs = append(specs, priority.String())
+	}
+	return strings.Join(specs, "; ")
+}
+
+// Set parses and adds a priority level, less urgent than those before it
+func (p *Priorities) Set(spec string) error {
+	priority, err := ParsePriority(spec)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, priority)
+	return nil
+}
+
+// Levels returns the priority levels, most urgent first
+func (p Priorities) Levels() []string {
+	levels := make([]string, 0, len(p))
+	for _, priority := range p {
+		levels = append(levels, priority.Level)
+	}
+	return levels
+}
+
+// Of returns the most urgent level any of pr's labels put it at, or "" if none do
+func (p Priorities) Of(pr *PullRequest) string {
+	for _, priority := range p {
+		if HasAnyLabel(pr, priority.Labels) {
+			return priority.Level
+		}
+	}
+	return ""
+}
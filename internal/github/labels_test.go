@@ -0,0 +1,60 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestHasAnyLabel(t *testing.T) {
+	pr := &PullRequest{
+		Labels: []Label{{Name: "Hotfix"}, {Name: "backend"}},
+	}
+
+	if !HasAnyLabel(pr, []string{"hotfix", "emergency"}) {
+		t.Error("Expected a case-insensitive match on Hotfix")
+	}
+	if HasAnyLabel(pr, []string{"emergency"}) {
+		t.Error("Expected no match for emergency")
+	}
+	if HasAnyLabel(pr, nil) {
+		t.Error("Expected no match for no labels")
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	var priorities Priorities
+	for _, spec := range []string{"P0=sev0, urgent", "P1=sev1", "P2=sev2,low-priority"} {
+		if err := priorities.Set(spec); err != nil {
+			t.Fatalf("Unexpected error for %q: %v", spec, err)
+		}
+	}
+	if got := priorities.String(); got != "P0=sev0,urgent; P1=sev1; P2=sev2,low-priority" {
+		t.Errorf("Unexpected String(): %q", got)
+	}
+
+	tests := []struct {
+		labels []string
+		want   string
+	}{
+		{[]string{"Urgent"}, "P0"},
+		{[]string{"low-priority", "backend"}, "P2"},
+		// The most urgent level wins
+		{[]string{"sev2", "sev1"}, "P1"},
+		{[]string{"backend"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		pr := &PullRequest{}
+		for _, name := range tt.labels {
+			pr.Labels = append(pr.Labels, Label{Name: name})
+		}
+		if got := priorities.Of(pr); got != tt.want {
+			t.Errorf("Priority of a PR labelled %v: expected %q, got %q", tt.labels, tt.want, got)
+		}
+	}
+
+	for _, spec := range []string{"P0", "=sev0", "P0="} {
+		if _, err := ParsePriority(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package github
+
+import (
+	"path"
+	"strings"
+)
+
+// languagesByExtension maps file extensions to the language they're classified as
+var languagesByExtension = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".scala": "Scala",
+	".rs":    "Rust",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".swift": "Swift",
+	".m":     "Objective-C",
+	".php":   "PHP",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".vue":   "Vue",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".sql":   "SQL",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".tf":    "Terraform",
+	".hcl":   "Terraform",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".json":  "JSON",
+	".proto": "Protobuf",
+	".md":    "Markdown",
+}
+
+// languagesByFilename maps extensionless (or specially named) files to their language
+var languagesByFilename = map[string]string{
+	"Dockerfile": "Docker",
+	"Makefile":   "Make",
+	"go.mod":     "Go",
+	"go.sum":     "Go",
+}
+
+// fileLanguage classifies a changed file by its name, returning "Other" if it isn't recognized
+func fileLanguage(filename string) string {
+	base := path.Base(filename)
+	if lang, ok := languagesByFilename[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "Dockerfile.") {
+		return "Docker"
+	}
+	if lang, ok := languagesByExtension[strings.ToLower(path.Ext(base))]; ok {
+		return lang
+	}
+	return "Other"
+}
+
+// dominantLanguage returns the language with the most changed lines across files,
+// or "" if there are no files. Ties go to the alphabetically first language.
+func dominantLanguage(files []*File) string {
+	changes := make(map[string]int)
+	for _, file := range files {
+		// Count renamed or binary files with no line changes as one line, so they aren't ignored
+		changes[fileLanguage(file.Filename)] += max(file.Changes, 1)
+	}
+
+	var dominant string
+	for lang, n := range changes {
+		if dominant == "" || n > changes[dominant] || (n == changes[dominant] && lang < dominant) {
+			dominant = lang
+		}
+	}
+	return dominant
+}
@@ -0,0 +1,38 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestFileLanguage(t *testing.T) {
+	tests := map[string]string{
+		"main.go":                   "Go",
+		"web/src/App.tsx":           "TypeScript",
+		"infra/modules/vpc/main.tf": "Terraform",
+		"deploy/Dockerfile":         "Docker",
+		"Dockerfile.dev":            "Docker",
+		".github/workflows/ci.yml":  "YAML",
+		"LICENSE":                   "Other",
+		"README.MD":                 "Markdown",
+	}
+	for filename, want := range tests {
+		if got := fileLanguage(filename); got != want {
+			t.Errorf("fileLanguage(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestDominantLanguage(t *testing.T) {
+	files := []*File{
+		{Filename: "main.go", Changes: 10},
+		{Filename: "util.go", Changes: 5},
+		{Filename: "infra/main.tf", Changes: 40},
+		{Filename: "logo.png"},
+	}
+	if got := dominantLanguage(files); got != "Terraform" {
+		t.Errorf("Expected Terraform to dominate, got %q", got)
+	}
+	if got := dominantLanguage(nil); got != "" {
+		t.Errorf("Expected no language for no files, got %q", got)
+	}
+}
@@ -1,6 +1,8 @@
 package github
 
 import (
+	"context"
+	"errors"
 	"log"
 	"regexp"
 	"slices"
@@ -8,36 +10,176 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/go-github/v39/github"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/businesshours"
+	"github.com/reillywatson/statstracker/internal/progress"
 )
 
-// ProcessPullRequests analyzes the pull requests and returns results
-func ProcessPullRequests(client GitHubClientInterface, prs []*github.PullRequest, owner, repo string, denylist []string, tagsOwner, tagsRepo string) []PullRequestMetric {
-	var results []PullRequestMetric
+// ProcessOptions controls which PRs and reviews ProcessPullRequests considers and which extra metrics it gathers
+type ProcessOptions struct {
+	Users             UserFilter // Whose PRs and reviews count
+	BaseBranches      []string   // If set, only PRs targeting a base branch matching one of these globs, such as release/*, count
+	TagsOwner         string     // Tags repository to check for tag commits; skipped if empty
+	TagsRepo          string
+	RequiredApprovals int        // Approvals a PR needs; 0 reads it from the base branch's protection rules
+	ExcludeDismissed  bool       // Don't count approvals that were later dismissed toward time to approval or required approvals
+	ExcludeComments   bool       // Don't count reviews that only commented as a PR's first review
+	Codeowners        bool       // Measure time until the CODEOWNERS rules each PR triggers were satisfied
+	Languages         bool       // Classify each PR by the language with the most changed lines
+	Classify          bool       // Classify each PR as docs, test, config or code by the paths it changes
+	Size              bool       // Measure the lines and files each PR changes
+	CodingTime        bool       // Measure time from each PR's first commit until it was opened
+	DraftTime         bool       // Measure review times from when each PR left draft, and report time in draft
+	ReviewRequests    bool       // Measure each reviewer's response time from when their review was requested
+	Comments          bool       // Count reviewers' inline comments, and with Size, comments per 100 lines changed
+	FirstResponse     bool       // Measure time until anyone but the author responded, by review or by comment
+	HotfixLabels      []string   // Labels that mark a PR as a hotfix taking the fast path
+	Priorities        Priorities // Priority levels, and the labels that put a PR at each
+	Concurrency       int        // PRs whose reviews are fetched at once; 0 or 1 fetches them one at a time
+	IncludeDrafts     bool       // Report on PRs that are still drafts too, as an inventory of open PRs should
+	Stages            []Stage    // Extra stages to run on each PR, after the ones the options above turn on
+
+	// BusinessHours, if set, measures time to first review, time to approval and
+	// reviewer response times in working hours only
+	BusinessHours *businesshours.Schedule
+
+	// Progress, if set, counts the PRs processed
+	Progress *progress.Reporter
+}
 
-	// Process each PR
+// elapsed returns the time from start to end, in business hours if a schedule is
+// set. It's 0 if end is before start, as for a review given while a PR was a draft.
+func (opts ProcessOptions) elapsed(start, end time.Time) time.Duration {
+	if end.Before(start) {
+		return 0
+	}
+	if opts.BusinessHours != nil {
+		return opts.BusinessHours.Between(start, end)
+	}
+	return end.Sub(start)
+}
+
+// includePullRequest reports whether a PR is reported on at all: open or merged,
+// not a draft (unless IncludeDrafts is set), into a matching base branch and by
+// an included author
+func includePullRequest(pr *PullRequest, opts ProcessOptions) bool {
+	// Skip draft PRs
+	if pr.Draft && !opts.IncludeDrafts {
+		return false
+	}
+
+	// Skip closed PRs that weren't merged
+	if pr.State == "closed" && pr.MergedAt.IsZero() {
+		return false
+	}
+
+	// Skip PRs into other branches, such as long-lived feature branches
+	if len(opts.BaseBranches) > 0 && !matchesBranch(opts.BaseBranches, pr.Base.Ref) {
+		return false
+	}
+
+	return opts.Users.IncludesAuthor(pr.User)
+}
+
+// BasicMetrics returns just who opened each PR and when it was opened and merged,
+// for the PRs ProcessPullRequests would include. It makes no API calls, so it
+// suits counting PRs without timing their reviews.
+func BasicMetrics(prs []*PullRequest, owner, repo string, opts ProcessOptions) []PullRequestMetric {
+	var results []PullRequestMetric
 	for _, pr := range prs {
-		// Skip draft PRs
-		if pr.GetDraft() {
+		if !includePullRequest(pr, opts) {
 			continue
 		}
+		results = append(results, PullRequestMetric{
+			Repository: owner + "/" + repo,
+			PRTitle:    pr.Title,
+			PRNumber:   pr.Number,
+			Author:     pr.User.Login,
+			CreatedAt:  pr.CreatedAt,
+			MergedAt:   pr.MergedAt,
+			Draft:      pr.Draft,
+		})
+	}
+	return results
+}
 
-		// Skip closed PRs that weren't merged
-		if pr.GetState() == "closed" && pr.GetMergedAt().IsZero() {
-			continue
+// ProcessPullRequests analyzes the pull requests and returns results. If the API
+// budget runs out or ctx is cancelled part way through, the results so far are returned.
+func ProcessPullRequests(ctx context.Context, client GitHubClientInterface, prs []*PullRequest, owner, repo string, opts ProcessOptions) []PullRequestMetric {
+	var results []PullRequestMetric
+
+	// Required approvals are looked up once per base branch
+	requiredApprovalsByBranch := make(map[string]int)
+
+	stages := pullRequestStages(client, owner, repo, opts)
+
+	// Reviews are fetched ahead of processing, several at a time if asked
+	var included []*PullRequest
+	for _, pr := range prs {
+		if includePullRequest(pr, opts) {
+			included = append(included, pr)
 		}
+	}
+	fetcher := fetchReviews(ctx, client, included, owner, repo, opts.Concurrency)
+	defer fetcher.stop()
 
-		prAuthorLogin := pr.GetUser().GetLogin()
-		if slices.Contains(denylist, prAuthorLogin) {
-			continue
+	opts.Progress.Start(len(included))
+	defer opts.Progress.Finish()
+
+	// Process each PR
+	for i, pr := range included {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Stopping at PR #%d: %v", pr.Number, err)
+			break
 		}
+		prAuthorLogin := pr.User.Login
 
-		reviews, err := client.FetchPullRequestReviews(owner, repo, pr.GetNumber())
+		reviews, err := fetcher.reviews(i)
+		if stopProcessing(ctx, err) {
+			// Stop gracefully; the caller reports the results as partial
+			log.Printf("Stopping at PR #%d: %v", pr.Number, err)
+			break
+		}
 		if err != nil {
-			log.Printf("Error fetching reviews for PR #%d: %v", pr.GetNumber(), err)
+			log.Printf("Error fetching reviews for PR #%d: %v", pr.Number, err)
+			opts.Progress.Increment()
 			continue
 		}
 
+		// Review times run from when the PR was ready for review, which for PRs
+		// opened as drafts is when they left draft
+		readyAt := pr.CreatedAt
+		var timeInDraft time.Duration
+		if opts.DraftTime {
+			timeline, err := client.FetchPullRequestTimeline(ctx, owner, repo, pr.Number)
+			if stopProcessing(ctx, err) {
+				log.Printf("Stopping at PR #%d: %v", pr.Number, err)
+				break
+			}
+			if err != nil {
+				log.Printf("Error fetching timeline for PR #%d: %v", pr.Number, err)
+			} else {
+				readyAt, timeInDraft = draftTime(pr, timeline, opts)
+			}
+		}
+
+		// A dismissed review's state only says it was dismissed; what it was before,
+		// which decides whether it counts as an approval, is in the PR's events
+		dismissedStates := make(map[int64]string) // by review ID
+		if slices.ContainsFunc(reviews, func(r *Review) bool { return r.State == "DISMISSED" }) {
+			dismissals, err := client.FetchPullRequestDismissals(ctx, owner, repo, pr.Number)
+			if stopProcessing(ctx, err) {
+				log.Printf("Stopping at PR #%d: %v", pr.Number, err)
+				break
+			}
+			if err != nil {
+				log.Printf("Error fetching dismissals for PR #%d: %v", pr.Number, err)
+			}
+			for _, d := range dismissals {
+				dismissedStates[d.DismissedReview.ReviewID] = strings.ToUpper(d.DismissedReview.State)
+			}
+		}
+
 		// Track first review and first approval separately
 		var firstReviewTime *time.Time
 		var firstReviewer string
@@ -47,31 +189,41 @@ func ProcessPullRequests(client GitHubClientInterface, prs []*github.PullRequest
 		var approver string
 
 		var validReviewFound bool
+		var validReviews []reviewEvent
+		var approvals []reviewEvent
 
 		for _, review := range reviews {
-			submittedAt := review.GetSubmittedAt()
-			reviewerUser := review.GetUser().GetLogin()
-			reviewState := review.GetState()
+			submittedAt := review.SubmittedAt
+			reviewerUser := review.User.Login
+			reviewState := review.State
 
 			// Skip empty, pending reviews, or self-reviews
 			if reviewState == "PENDING" || reviewerUser == prAuthorLogin {
 				continue
 			}
-			if slices.Contains(denylist, reviewerUser) {
+			if !opts.Users.IncludesReviewer(review.User) {
 				continue
 			}
 
+			var dismissed string
+			if reviewState == "DISMISSED" {
+				dismissed = dismissedStates[review.ID]
+			}
+
 			validReviewFound = true
+			validReviews = append(validReviews, reviewEvent{reviewer: reviewerUser, state: reviewState, at: submittedAt, dismissed: dismissed})
 
-			// Check for first review (of any kind)
-			if firstReviewTime == nil || submittedAt.Before(*firstReviewTime) {
+			// Check for first review, of any kind unless comments are left out
+			commentOnly := reviewState == "COMMENTED" || dismissed == "COMMENTED"
+			if (!commentOnly || !opts.ExcludeComments) && (firstReviewTime == nil || submittedAt.Before(*firstReviewTime)) {
 				firstReviewTime = &submittedAt
 				firstReviewer = reviewerUser
 				firstReviewState = reviewState
 			}
 
-			// Check specifically for approvals
-			if reviewState == "APPROVED" {
+			// Check specifically for approvals, which were given even if later dismissed
+			if reviewState == "APPROVED" || (dismissed == "APPROVED" && !opts.ExcludeDismissed) {
+				approvals = append(approvals, reviewEvent{reviewer: reviewerUser, state: "APPROVED", at: submittedAt})
 				if firstApprovalTime == nil || submittedAt.Before(*firstApprovalTime) {
 					firstApprovalTime = &submittedAt
 					approver = reviewerUser
@@ -82,73 +234,517 @@ func ProcessPullRequests(client GitHubClientInterface, prs []*github.PullRequest
 		// Calculate time to first review
 		var timeToFirstReview time.Duration
 		if firstReviewTime != nil {
-			timeToFirstReview = firstReviewTime.Sub(pr.GetCreatedAt())
+			timeToFirstReview = opts.elapsed(readyAt, *firstReviewTime)
 		}
 
 		// Calculate time to first approval
 		var timeToApproval time.Duration
 		if firstApprovalTime != nil {
-			timeToApproval = firstApprovalTime.Sub(pr.GetCreatedAt())
+			timeToApproval = opts.elapsed(readyAt, *firstApprovalTime)
 		}
 
-		// Calculate time since PR was created (for PRs without reviews)
-		timeSinceCreation := time.Since(pr.GetCreatedAt())
+		// Calculate time from first approval to merge, which CI and merge queues add
+		var approvalToMerge time.Duration
+		if firstApprovalTime != nil && pr.MergedAt.After(*firstApprovalTime) {
+			approvalToMerge = opts.elapsed(*firstApprovalTime, pr.MergedAt)
+		}
 
-		// Check if PR has associated tag commits (only if tags repo is specified)
-		var tagCommits []TagCommit
-		if tagsOwner != "" && tagsRepo != "" {
-			tagCommits = checkPRTagCommits(client, pr, tagsOwner, tagsRepo)
+		// Calculate time until the PR had as many distinct approvals as its base branch requires
+		requiredApprovals := opts.RequiredApprovals
+		if requiredApprovals <= 0 {
+			requiredApprovals = lookupRequiredApprovals(ctx, client, owner, repo, pr.Base.Ref, requiredApprovalsByBranch)
+		}
+		// Like the times from elapsed, these are 0 for a PR approved while it was a draft
+		var timeToRequiredApprovals time.Duration
+		if approvedAt, ok := nthDistinctApproval(approvals, requiredApprovals); ok {
+			timeToRequiredApprovals = max(approvedAt.Sub(readyAt), 0)
 		}
 
-		// Always add the PR to results, but mark whether it has reviews
-		results = append(results, PullRequestMetric{
-			PRTitle:           pr.GetTitle(),
-			PRNumber:          pr.GetNumber(),
+		// Split the time until approval (or merge, or now) into time the ball was in
+		// the reviewers' court and time it was in the author's
+		waitEnd := time.Now()
+		if firstApprovalTime != nil {
+			waitEnd = *firstApprovalTime
+		} else if !pr.MergedAt.IsZero() {
+			waitEnd = pr.MergedAt
+		}
+		// Push times are only needed when a review handed the PR back to the author,
+		// when an approval may have gone stale, or to measure coding time
+		var pushes []time.Time
+		var codingTime time.Duration
+		pushesKnown := true
+		if handsBackToAuthor(validReviews) || len(approvals) > 0 || opts.CodingTime {
+			commits, err := client.FetchPullRequestCommits(ctx, owner, repo, pr.Number)
+			if stopProcessing(ctx, err) {
+				log.Printf("Stopping at PR #%d: %v", pr.Number, err)
+				break
+			}
+			if err != nil {
+				log.Printf("Error fetching commits for PR #%d: %v", pr.Number, err)
+				pushesKnown = false
+			} else {
+				pushes = commitTimes(commits)
+				if first, ok := firstAuthored(commits); ok && opts.CodingTime && first.Before(pr.CreatedAt) {
+					codingTime = opts.elapsed(first, pr.CreatedAt)
+				}
+			}
+		}
+
+		var reviewerWaitTime, authorWaitTime time.Duration
+		if !handsBackToAuthor(validReviews) {
+			reviewerWaitTime = max(waitEnd.Sub(readyAt), 0)
+		} else if pushesKnown {
+			reviewerWaitTime, authorWaitTime = splitWaitTime(readyAt, waitEnd, validReviews, pushes)
+		}
+
+		// The first approval overstates readiness if it was later dismissed or made
+		// stale by a push, so also track the approval the PR ended up with
+		var timeToStandingApproval time.Duration
+		var dismissedApprovals int
+		if pushesKnown {
+			if approvedAt, ok := finalStandingApproval(validReviews, pushes); ok {
+				timeToStandingApproval = max(approvedAt.Sub(readyAt), 0)
+			}
+			dismissedApprovals = countDismissedApprovals(validReviews, pushes)
+		}
+
+		// Count the changes requested → new commits → re-review cycles, and how long
+		// reviewers had changes requested, which holds the PR up until they approve
+		var rounds int
+		if pushesKnown {
+			rounds = reviewRounds(validReviews, pushes)
+		}
+		changesRequestedEnd := time.Now()
+		if !pr.MergedAt.IsZero() {
+			changesRequestedEnd = pr.MergedAt
+		} else if !pr.ClosedAt.IsZero() {
+			changesRequestedEnd = pr.ClosedAt
+		}
+		timeInChangesRequested := changesRequestedTime(validReviews, changesRequestedEnd, opts)
+
+		// Calculate time since PR was ready for review (for PRs without reviews)
+		timeSinceCreation := time.Since(readyAt)
+
+		// The review metrics every PR gets
+		metric := PullRequestMetric{
+			Repository:        owner + "/" + repo,
+			PRTitle:           pr.Title,
+			PRNumber:          pr.Number,
 			Author:            prAuthorLogin,
+			CreatedAt:         pr.CreatedAt,
+			MergedAt:          pr.MergedAt,
+			Draft:             pr.Draft,
 			TimeToFirstReview: timeToFirstReview,
 			FirstReviewer:     firstReviewer,
 			FirstReviewState:  firstReviewState,
 			TimeToApproval:    timeToApproval,
+			ApprovalToMerge:   approvalToMerge,
+			CodingTime:        codingTime,
+			TimeInDraft:       timeInDraft,
 			Approver:          approver,
 			HasReview:         validReviewFound,
 			TimeSinceCreation: timeSinceCreation,
-			TagCommits:        tagCommits,
-		})
+			ReviewerWaitTime:  reviewerWaitTime,
+			AuthorWaitTime:    authorWaitTime,
+
+			RequiredApprovals:       requiredApprovals,
+			TimeToRequiredApprovals: timeToRequiredApprovals,
+
+			TimeToStandingApproval: timeToStandingApproval,
+			DismissedApprovals:     dismissedApprovals,
+			Dismissals:             countDismissals(validReviews),
+			OnlyApprovalDismissed:  allApprovalsDismissed(validReviews),
+
+			ReviewRounds:           rounds,
+			TimeInChangesRequested: timeInChangesRequested,
+
+			Hotfix:     HasAnyLabel(pr, opts.HotfixLabels),
+			Priority:   opts.Priorities.Of(pr),
+			ChangeType: ParseChangeType(pr.Title),
+
+			Reviews: prReviews(readyAt, validReviews, opts),
+		}
+
+		// Then the stages fill in everything else asked for
+		prCtx := &PullRequestContext{
+			PR:        pr,
+			Owner:     owner,
+			Repo:      repo,
+			ReadyAt:   readyAt,
+			Client:    client,
+			Options:   opts,
+			reviews:   validReviews,
+			approvals: approvals,
+		}
+		if err := enrich(ctx, stages, prCtx, &metric); stopProcessing(ctx, err) {
+			log.Printf("Stopping at PR #%d: %v", pr.Number, err)
+			break
+		}
+
+		// Always add the PR to results, but mark whether it has reviews
+		results = append(results, metric)
+		opts.Progress.Increment()
 	}
 
 	return results
 }
 
+// stopProcessing reports whether err means processing can't go on: the API budget
+// has run out, or the run was cancelled
+func stopProcessing(ctx context.Context, err error) bool {
+	return errors.Is(err, budget.ErrExhausted) || (err != nil && ctx.Err() != nil)
+}
+
+// enrich runs the stages on a PR's metric, logging any errors. It stops at, and
+// returns, an error wrapping budget.ErrExhausted or from ctx being cancelled.
+func enrich(ctx context.Context, stages []Stage, pr *PullRequestContext, metric *PullRequestMetric) error {
+	var logged []error
+	for _, stage := range stages {
+		err := stage.Enrich(ctx, pr, metric)
+		if stopProcessing(ctx, err) {
+			return err
+		}
+		// Stages sharing data, such as the PR's files, share its errors too
+		if err != nil && !slices.Contains(logged, err) {
+			log.Printf("Error processing PR #%d: %v", pr.PR.Number, err)
+			logged = append(logged, err)
+		}
+	}
+	return nil
+}
+
+// lookupRequiredApprovals returns the number of approvals the branch's protection
+// rules require, memoized in seen. Reading protection rules needs admin access, so
+// any failure (or an unprotected branch) falls back to a single approval.
+func lookupRequiredApprovals(ctx context.Context, client GitHubClientInterface, owner, repo, branch string, seen map[string]int) int {
+	if n, ok := seen[branch]; ok {
+		return n
+	}
+
+	n, err := client.FetchRequiredApprovals(ctx, owner, repo, branch)
+	if err != nil {
+		log.Printf("Could not read branch protection for %s, assuming 1 required approval: %v", branch, err)
+	}
+	if n <= 0 {
+		n = 1
+	}
+	seen[branch] = n
+	return n
+}
+
+// nthDistinctApproval returns when the nth distinct reviewer first approved
+func nthDistinctApproval(approvals []reviewEvent, n int) (time.Time, bool) {
+	sorted := slices.Clone(approvals)
+	slices.SortStableFunc(sorted, func(a, b reviewEvent) int {
+		return a.at.Compare(b.at)
+	})
+
+	approvers := make(map[string]bool)
+	for _, a := range sorted {
+		if approvers[a.reviewer] {
+			continue
+		}
+		approvers[a.reviewer] = true
+		if len(approvers) == n {
+			return a.at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// lastPush returns the latest push time, or the zero time if there were none
+func lastPush(pushes []time.Time) time.Time {
+	var last time.Time
+	for _, p := range pushes {
+		if p.After(last) {
+			last = p
+		}
+	}
+	return last
+}
+
+// finalStandingApproval returns when the PR gained the approval it ended up with:
+// the earliest approval made after the last push by a reviewer who hasn't since
+// requested changes. Approvals followed by a push are stale and don't count.
+func finalStandingApproval(reviews []reviewEvent, pushes []time.Time) (time.Time, bool) {
+	sorted := slices.Clone(reviews)
+	slices.SortStableFunc(sorted, func(a, b reviewEvent) int {
+		return a.at.Compare(b.at)
+	})
+
+	last := lastPush(pushes)
+	standing := make(map[string]time.Time)
+	for _, r := range sorted {
+		switch r.state {
+		case "APPROVED":
+			if r.at.Before(last) {
+				continue
+			}
+			if _, ok := standing[r.reviewer]; !ok {
+				standing[r.reviewer] = r.at
+			}
+		case "CHANGES_REQUESTED":
+			delete(standing, r.reviewer)
+		}
+	}
+
+	var earliest time.Time
+	for _, at := range standing {
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// countDismissedApprovals counts approvals that no longer stand: dismissed
+// approvals, and approvals followed by a push. Dismissed reviews whose original
+// state couldn't be looked up are counted too; in practice dismissals are almost
+// always of approvals.
+func countDismissedApprovals(reviews []reviewEvent, pushes []time.Time) int {
+	last := lastPush(pushes)
+	count := 0
+	for _, r := range reviews {
+		if (r.state == "DISMISSED" && (r.dismissed == "" || r.dismissed == "APPROVED")) || (r.state == "APPROVED" && r.at.Before(last)) {
+			count++
+		}
+	}
+	return count
+}
+
+// countDismissals counts the reviews that were dismissed, whatever they were
+func countDismissals(reviews []reviewEvent) int {
+	count := 0
+	for _, r := range reviews {
+		if r.state == "DISMISSED" {
+			count++
+		}
+	}
+	return count
+}
+
+// allApprovalsDismissed reports whether a PR was approved, but every approval it
+// received (usually its only one) was later dismissed, leaving it with none
+func allApprovalsDismissed(reviews []reviewEvent) bool {
+	dismissed := false
+	for _, r := range reviews {
+		switch {
+		case r.state == "APPROVED":
+			return false
+		case r.dismissed == "APPROVED":
+			dismissed = true
+		}
+	}
+	return dismissed
+}
+
+// prReviews converts a PR's reviews to their exported form, in submission order
+func prReviews(readyAt time.Time, reviews []reviewEvent, opts ProcessOptions) []ReviewMetric {
+	sorted := slices.Clone(reviews)
+	slices.SortStableFunc(sorted, func(a, b reviewEvent) int {
+		return a.at.Compare(b.at)
+	})
+
+	var result []ReviewMetric
+	for _, r := range sorted {
+		result = append(result, ReviewMetric{Reviewer: r.reviewer, State: r.state, SubmittedAt: r.at, TimeToReview: opts.elapsed(readyAt, r.at)})
+	}
+	return result
+}
+
+// reviewEvent is a submitted review that counts towards PR metrics
+type reviewEvent struct {
+	reviewer  string
+	state     string
+	at        time.Time
+	dismissed string // For a DISMISSED review, what it was: APPROVED, CHANGES_REQUESTED or COMMENTED; "" if unknown
+}
+
+// handsBackToAuthor reports whether a review outcome puts the ball in the author's
+// court: anything other than an approval means the author is expected to respond.
+func handsBackToAuthor(reviews []reviewEvent) bool {
+	for _, r := range reviews {
+		if r.state == "CHANGES_REQUESTED" || r.state == "COMMENTED" {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewRounds counts a PR's review rounds. The first review starts the first round,
+// and each review after the author pushed in response to a request for changes
+// starts another. It's 0 if the PR hasn't been reviewed.
+func reviewRounds(reviews []reviewEvent, pushes []time.Time) int {
+	type event struct {
+		at     time.Time
+		review *reviewEvent
+	}
+	var events []event
+	for i := range reviews {
+		events = append(events, event{at: reviews[i].at, review: &reviews[i]})
+	}
+	for _, p := range pushes {
+		events = append(events, event{at: p})
+	}
+	slices.SortStableFunc(events, func(a, b event) int {
+		return a.at.Compare(b.at)
+	})
+
+	rounds := 0
+	changesRequested, pushedSince := false, false
+	for _, e := range events {
+		if e.review == nil {
+			pushedSince = changesRequested
+			continue
+		}
+		if rounds == 0 || pushedSince {
+			rounds++
+			changesRequested, pushedSince = false, false
+		}
+		if e.review.state == "CHANGES_REQUESTED" {
+			changesRequested = true
+		}
+	}
+	return rounds
+}
+
+// changesRequestedTime returns how long, up to end, at least one reviewer had
+// changes requested on the PR. A reviewer's request stands until they approve;
+// later comments don't lift it.
+func changesRequestedTime(reviews []reviewEvent, end time.Time, opts ProcessOptions) time.Duration {
+	sorted := slices.Clone(reviews)
+	slices.SortStableFunc(sorted, func(a, b reviewEvent) int {
+		return a.at.Compare(b.at)
+	})
+
+	requesting := make(map[string]bool)
+	var total time.Duration
+	var since time.Time
+	for _, r := range sorted {
+		if r.at.After(end) {
+			break
+		}
+		wasBlocked := len(requesting) > 0
+		switch r.state {
+		case "CHANGES_REQUESTED":
+			requesting[r.reviewer] = true
+		case "APPROVED":
+			delete(requesting, r.reviewer)
+		}
+		if !wasBlocked && len(requesting) > 0 {
+			since = r.at
+		} else if wasBlocked && len(requesting) == 0 {
+			total += opts.elapsed(since, r.at)
+		}
+	}
+	if len(requesting) > 0 && end.After(since) {
+		total += opts.elapsed(since, end)
+	}
+	return total
+}
+
+// commitTimes returns when each commit was pushed, approximated by its committer date
+func commitTimes(commits []*Commit) []time.Time {
+	times := make([]time.Time, 0, len(commits))
+	for _, commit := range commits {
+		times = append(times, commit.Commit.Committer.Date)
+	}
+	return times
+}
+
+// firstAuthored returns when the earliest of commits was authored. Author dates
+// survive rebases and amends, unlike committer dates, so they show when work began.
+func firstAuthored(commits []*Commit) (time.Time, bool) {
+	var first time.Time
+	for _, commit := range commits {
+		at := commit.Commit.Author.Date
+		if !at.IsZero() && (first.IsZero() || at.Before(first)) {
+			first = at
+		}
+	}
+	return first, !first.IsZero()
+}
+
+// splitWaitTime divides the period from start to end into time spent waiting on
+// reviewers and time spent waiting on the author. The PR starts in the reviewers'
+// court; a review requesting changes or leaving comments hands it to the author,
+// and the author's next push hands it back.
+func splitWaitTime(start, end time.Time, reviews []reviewEvent, pushes []time.Time) (reviewerWait, authorWait time.Duration) {
+	type handoff struct {
+		at       time.Time
+		toAuthor bool
+	}
+	var handoffs []handoff
+	for _, r := range reviews {
+		if r.state == "CHANGES_REQUESTED" || r.state == "COMMENTED" {
+			handoffs = append(handoffs, handoff{at: r.at, toAuthor: true})
+		}
+	}
+	for _, p := range pushes {
+		handoffs = append(handoffs, handoff{at: p, toAuthor: false})
+	}
+	slices.SortStableFunc(handoffs, func(a, b handoff) int {
+		return a.at.Compare(b.at)
+	})
+
+	withAuthor := false
+	last := start
+	for _, h := range handoffs {
+		if h.at.Before(start) {
+			continue // e.g. commits made before the PR was opened
+		}
+		if h.at.After(end) {
+			break
+		}
+		if h.toAuthor == withAuthor {
+			continue // ball is already in that court
+		}
+		if withAuthor {
+			authorWait += h.at.Sub(last)
+		} else {
+			reviewerWait += h.at.Sub(last)
+		}
+		withAuthor = h.toAuthor
+		last = h.at
+	}
+
+	if end.After(last) {
+		if withAuthor {
+			authorWait += end.Sub(last)
+		} else {
+			reviewerWait += end.Sub(last)
+		}
+	}
+	return reviewerWait, authorWait
+}
+
 // checkPRTagCommits checks if a PR has associated commits in the tags repository
 // This function looks for commits in the tags repo that either:
 // 1. Reference the PR number directly (pattern: pull-<number>_<sha>)
 // 2. Have a branch name that matches the PR's head branch
 // Returns all matching tag commits
-func checkPRTagCommits(client GitHubClientInterface, pr *github.PullRequest, tagsOwner, tagsRepo string) []TagCommit {
-	prNumber := pr.GetNumber()
-	prBranch := ""
-	if pr.GetHead() != nil {
-		prBranch = pr.GetHead().GetRef()
-	}
+func checkPRTagCommits(ctx context.Context, client GitHubClientInterface, pr *PullRequest, tagsOwner, tagsRepo string) []TagCommit {
+	prNumber := pr.Number
+	prBranch := pr.Head.Ref
 
 	// Fetch commits from tags repo during PR timeframe (creation to close/merge)
-	startTime := pr.GetCreatedAt()
+	startTime := pr.CreatedAt
 	endTime := time.Now()
 
-	// Handle closed/merged times properly - GetMergedAt() and GetClosedAt() return time.Time, not *time.Time
-	if pr.GetState() == "closed" {
+	// Closed PRs end the window when they were merged, or failing that closed; the times are zero if unset
+	if pr.State == "closed" {
 		// For closed PRs, use the merge time if available, otherwise closed time
-		if !pr.GetMergedAt().IsZero() {
-			endTime = pr.GetMergedAt()
-		} else if !pr.GetClosedAt().IsZero() {
-			endTime = pr.GetClosedAt()
+		if !pr.MergedAt.IsZero() {
+			endTime = pr.MergedAt
+		} else if !pr.ClosedAt.IsZero() {
+			endTime = pr.ClosedAt
 		} else {
 			// If no close time available, extend the search window beyond creation
-			endTime = pr.GetCreatedAt().Add(30 * 24 * time.Hour) // 30 days after creation
+			endTime = pr.CreatedAt.Add(30 * 24 * time.Hour) // 30 days after creation
 		}
 	}
 
-	commits, err := client.FetchCommits(tagsOwner, tagsRepo, startTime, endTime)
+	commits, err := client.FetchCommits(ctx, tagsOwner, tagsRepo, startTime, endTime)
 	if err != nil {
 		log.Printf("Error fetching commits from tags repo for PR #%d: %v", prNumber, err)
 		return []TagCommit{}
@@ -158,9 +754,12 @@ func checkPRTagCommits(client GitHubClientInterface, pr *github.PullRequest, tag
 
 	for _, commit := range commits {
 		// Fetch the full commit with diff to analyze
-		fullCommit, err := client.FetchCommit(tagsOwner, tagsRepo, commit.GetSHA())
+		fullCommit, err := client.FetchCommit(ctx, tagsOwner, tagsRepo, commit.SHA)
+		if stopProcessing(ctx, err) {
+			break
+		}
 		if err != nil {
-			log.Printf("Error fetching commit %s from tags repo: %v", commit.GetSHA(), err)
+			log.Printf("Error fetching commit %s from tags repo: %v", commit.SHA, err)
 			continue
 		}
 
@@ -178,7 +777,7 @@ func checkPRTagCommits(client GitHubClientInterface, pr *github.PullRequest, tag
 // 1. Direct PR reference: pull-<pr number>_<SHA>
 // 2. Branch reference: YYYY_MM_DD__HH_MM_SS__<BRANCHNAME>__<SHA>
 // Returns a TagCommit if a match is found, nil otherwise
-func analyzeCommitDiffForPRReference(commit *github.RepositoryCommit, prNumber int, prBranch string) *TagCommit {
+func analyzeCommitDiffForPRReference(commit *Commit, prNumber int, prBranch string) *TagCommit {
 	files := commit.Files
 	if len(files) == 0 {
 		return nil
@@ -195,11 +794,11 @@ func analyzeCommitDiffForPRReference(commit *github.RepositoryCommit, prNumber i
 	}
 
 	for _, file := range files {
-		if file.Patch == nil {
+		if file.Patch == "" {
 			continue
 		}
 
-		patch := *file.Patch
+		patch := file.Patch
 		lines := strings.Split(patch, "\n")
 
 		for _, line := range lines {
@@ -207,10 +806,10 @@ func analyzeCommitDiffForPRReference(commit *github.RepositoryCommit, prNumber i
 				// Check for direct PR reference
 				if matches := prPattern.FindStringSubmatch(line); matches != nil {
 					return &TagCommit{
-						SHA:     commit.GetSHA(),
-						Message: commit.GetCommit().GetMessage(),
-						Date:    commit.GetCommit().GetAuthor().GetDate(),
-						Author:  commit.GetCommit().GetAuthor().GetName(),
+						SHA:     commit.SHA,
+						Message: commit.Commit.Message,
+						Date:    commit.Commit.Author.Date,
+						Author:  commit.Commit.Author.Name,
 					}
 				}
 
@@ -218,10 +817,10 @@ func analyzeCommitDiffForPRReference(commit *github.RepositoryCommit, prNumber i
 				if branchPattern != nil {
 					if matches := branchPattern.FindStringSubmatch(line); matches != nil {
 						return &TagCommit{
-							SHA:     commit.GetSHA(),
-							Message: commit.GetCommit().GetMessage(),
-							Date:    commit.GetCommit().GetAuthor().GetDate(),
-							Author:  commit.GetCommit().GetAuthor().GetName(),
+							SHA:     commit.SHA,
+							Message: commit.Commit.Message,
+							Date:    commit.Commit.Author.Date,
+							Author:  commit.Commit.Author.Name,
 						}
 					}
 				}
@@ -1,34 +1,90 @@
 package github
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
-	"github.com/google/go-github/v39/github"
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/businesshours"
 )
 
 // MockGitHubClient implements GitHubClientInterface for testing
 type MockGitHubClient struct {
-	reviews []*github.PullRequestReview
-	commits []*github.RepositoryCommit
-	commit  *github.RepositoryCommit
-	err     error
+	reviews     []*Review
+	prCommits   []*Commit
+	commits     []*Commit
+	commit      *Commit
+	err         error
+	reviewCalls int
+
+	requiredApprovals int
+	files             []*File
+	comments          []*Comment
+	issueComments     []*Comment
+	timeline          []*TimelineEvent
+	reviewRequests    []*ReviewRequestEvent
+	dismissals        []*DismissalEvent
+	codeowners        string
+	teamMembers       map[string][]string
 }
 
-func (m *MockGitHubClient) FetchPullRequests(owner, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error) {
+func (m *MockGitHubClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
 	// Not used in ProcessPullRequests tests since PRs are passed as parameter
 	return nil, nil
 }
 
-func (m *MockGitHubClient) FetchPullRequestReviews(owner, repo string, prNumber int) ([]*github.PullRequestReview, error) {
+func (m *MockGitHubClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
+	m.reviewCalls++
 	return m.reviews, m.err
 }
 
-func (m *MockGitHubClient) FetchCommits(owner, repo string, since, until time.Time) ([]*github.RepositoryCommit, error) {
+func (m *MockGitHubClient) FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*Commit, error) {
+	return m.prCommits, m.err
+}
+
+func (m *MockGitHubClient) FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	return m.comments, m.err
+}
+
+func (m *MockGitHubClient) FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*Comment, error) {
+	return m.issueComments, m.err
+}
+
+func (m *MockGitHubClient) FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*TimelineEvent, error) {
+	return m.timeline, m.err
+}
+
+func (m *MockGitHubClient) FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*ReviewRequestEvent, error) {
+	return m.reviewRequests, m.err
+}
+
+func (m *MockGitHubClient) FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*DismissalEvent, error) {
+	return m.dismissals, m.err
+}
+
+func (m *MockGitHubClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	return m.files, m.err
+}
+
+func (m *MockGitHubClient) FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error) {
+	return m.codeowners, m.err
+}
+
+func (m *MockGitHubClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	return m.teamMembers[org+"/"+team], m.err
+}
+
+func (m *MockGitHubClient) FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	return m.requiredApprovals, nil
+}
+
+func (m *MockGitHubClient) FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*Commit, error) {
 	return m.commits, m.err
 }
 
-func (m *MockGitHubClient) FetchCommit(owner, repo, sha string) (*github.RepositoryCommit, error) {
+func (m *MockGitHubClient) FetchCommit(ctx context.Context, owner, repo, sha string) (*Commit, error) {
 	return m.commit, m.err
 }
 
@@ -36,37 +92,60 @@ func TestProcessPullRequests_SkipDraftPRs(t *testing.T) {
 	client := &MockGitHubClient{}
 
 	draft := true
-	user := &github.User{Login: github.String("author")}
-	pr := &github.PullRequest{
-		Number: github.Int(1),
-		Title:  github.String("Draft PR"),
+	user := User{Login: "author"}
+	pr := &PullRequest{
+		Number: 1,
+		Title:  "Draft PR",
 		User:   user,
-		Draft:  &draft,
-		State:  github.String("open"),
+		Draft:  draft,
+		State:  "open",
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 0 {
 		t.Errorf("Expected 0 results for draft PR, got %d", len(results))
 	}
+
+	results = ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{IncludeDrafts: true})
+	if len(results) != 1 || !results[0].Draft {
+		t.Errorf("Expected the draft PR with IncludeDrafts, got %+v", results)
+	}
+}
+
+func TestBasicMetrics(t *testing.T) {
+	created := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	merged := created.Add(48 * time.Hour)
+	prs := []*PullRequest{
+		{Number: 1, Title: "Merged", User: User{Login: "author"}, State: "closed", CreatedAt: created, MergedAt: merged},
+		{Number: 2, Title: "Closed", User: User{Login: "author"}, State: "closed", CreatedAt: created},
+		{Number: 3, Title: "Someone else's", User: User{Login: "other"}, State: "closed", CreatedAt: created, MergedAt: merged},
+	}
+
+	results := BasicMetrics(prs, "owner", "repo", ProcessOptions{Users: UserFilter{Exclude: []string{"other"}}})
+	if len(results) != 1 {
+		t.Fatalf("Expected just the merged PR by an included author, got %+v", results)
+	}
+	got := results[0]
+	if got.Repository != "owner/repo" || got.PRNumber != 1 || got.Author != "author" || !got.CreatedAt.Equal(created) || !got.MergedAt.Equal(merged) {
+		t.Errorf("Unexpected result %+v", got)
+	}
 }
 
 func TestProcessPullRequests_SkipClosedUnmergedPRs(t *testing.T) {
 	client := &MockGitHubClient{}
 
-	user := &github.User{Login: github.String("author")}
-	pr := &github.PullRequest{
-		Number:   github.Int(1),
-		Title:    github.String("Closed PR"),
-		User:     user,
-		State:    github.String("closed"),
-		MergedAt: nil, // Not merged
+	user := User{Login: "author"}
+	pr := &PullRequest{
+		Number: 1,
+		Title:  "Closed PR",
+		User:   user,
+		State:  "closed",
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 0 {
 		t.Errorf("Expected 0 results for closed unmerged PR, got %d", len(results))
@@ -76,17 +155,17 @@ func TestProcessPullRequests_SkipClosedUnmergedPRs(t *testing.T) {
 func TestProcessPullRequests_SkipDenylistedAuthors(t *testing.T) {
 	client := &MockGitHubClient{}
 
-	user := &github.User{Login: github.String("denylisted-author")}
-	pr := &github.PullRequest{
-		Number: github.Int(1),
-		Title:  github.String("PR from denylisted author"),
+	user := User{Login: "denylisted-author"}
+	pr := &PullRequest{
+		Number: 1,
+		Title:  "PR from denylisted author",
 		User:   user,
-		State:  github.String("open"),
+		State:  "open",
 	}
 
-	prs := []*github.PullRequest{pr}
+	prs := []*PullRequest{pr}
 	denylist := []string{"denylisted-author"}
-	results := ProcessPullRequests(client, prs, "owner", "repo", denylist, "", "")
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{Users: UserFilter{Exclude: denylist}})
 
 	if len(results) != 0 {
 		t.Errorf("Expected 0 results for denylisted author, got %d", len(results))
@@ -95,27 +174,30 @@ func TestProcessPullRequests_SkipDenylistedAuthors(t *testing.T) {
 
 func TestProcessPullRequests_BasicPRWithoutReviews(t *testing.T) {
 	client := &MockGitHubClient{
-		reviews: []*github.PullRequestReview{}, // No reviews
+		reviews: []*Review{}, // No reviews
 	}
 
-	user := &github.User{Login: github.String("author")}
+	user := User{Login: "author"}
 	createdAt := time.Now().Add(-2 * time.Hour)
-	pr := &github.PullRequest{
-		Number:    github.Int(1),
-		Title:     github.String("Basic PR"),
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "Basic PR",
 		User:      user,
-		State:     github.String("open"),
-		CreatedAt: &createdAt,
+		State:     "open",
+		CreatedAt: createdAt,
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 result, got %d", len(results))
 	}
 
 	result := results[0]
+	if result.Repository != "owner/repo" {
+		t.Errorf("Expected repository 'owner/repo', got '%s'", result.Repository)
+	}
 	if result.PRNumber != 1 {
 		t.Errorf("Expected PR number 1, got %d", result.PRNumber)
 	}
@@ -138,30 +220,30 @@ func TestProcessPullRequests_BasicPRWithoutReviews(t *testing.T) {
 
 func TestProcessPullRequests_PRWithApprovalReview(t *testing.T) {
 	reviewTime := time.Now().Add(-1 * time.Hour)
-	reviewer := &github.User{Login: github.String("reviewer")}
+	reviewer := User{Login: "reviewer"}
 
 	client := &MockGitHubClient{
-		reviews: []*github.PullRequestReview{
+		reviews: []*Review{
 			{
 				User:        reviewer,
-				State:       github.String("APPROVED"),
-				SubmittedAt: &reviewTime,
+				State:       "APPROVED",
+				SubmittedAt: reviewTime,
 			},
 		},
 	}
 
-	user := &github.User{Login: github.String("author")}
+	user := User{Login: "author"}
 	createdAt := time.Now().Add(-2 * time.Hour)
-	pr := &github.PullRequest{
-		Number:    github.Int(1),
-		Title:     github.String("PR with approval"),
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with approval",
 		User:      user,
-		State:     github.String("open"),
-		CreatedAt: &createdAt,
+		State:     "open",
+		CreatedAt: createdAt,
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 result, got %d", len(results))
@@ -190,40 +272,204 @@ func TestProcessPullRequests_PRWithApprovalReview(t *testing.T) {
 	}
 }
 
+func TestProcessPullRequests_ApprovalToMerge(t *testing.T) {
+	now := time.Now()
+	createdAt := now.Add(-5 * time.Hour)
+	approvedAt := now.Add(-4 * time.Hour)
+	mergedAt := now.Add(-1 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "reviewer"}, State: "APPROVED", SubmittedAt: approvedAt},
+		},
+	}
+	prs := []*PullRequest{
+		{Number: 1, Title: "Merged", User: User{Login: "author"}, State: "closed", CreatedAt: createdAt, MergedAt: mergedAt},
+		{Number: 2, Title: "Still open", User: User{Login: "author"}, State: "open", CreatedAt: createdAt},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{RequiredApprovals: 1})
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].ApprovalToMerge != 3*time.Hour {
+		t.Errorf("Expected ApprovalToMerge of 3h, got %v", results[0].ApprovalToMerge)
+	}
+	if results[1].ApprovalToMerge != 0 {
+		t.Errorf("Expected no ApprovalToMerge for an unmerged PR, got %v", results[1].ApprovalToMerge)
+	}
+}
+
+func TestProcessPullRequests_CodingTime(t *testing.T) {
+	now := time.Now()
+	createdAt := now.Add(-2 * time.Hour)
+	firstAuthoredAt := now.Add(-30 * time.Hour)
+	rebasedAt := now.Add(-3 * time.Hour)
+	secondAuthoredAt := now.Add(-10 * time.Hour)
+
+	client := &MockGitHubClient{
+		prCommits: []*Commit{
+			// Rebased just before opening, but first written more than a day earlier
+			{Commit: CommitDetail{Author: CommitSignature{Date: firstAuthoredAt}, Committer: CommitSignature{Date: rebasedAt}}},
+			{Commit: CommitDetail{Author: CommitSignature{Date: secondAuthoredAt}, Committer: CommitSignature{Date: rebasedAt}}},
+		},
+	}
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "Feature",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, CodingTime: true})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].CodingTime != 28*time.Hour {
+		t.Errorf("Expected CodingTime of 28h, got %v", results[0].CodingTime)
+	}
+
+	results = ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1})
+
+	if results[0].CodingTime != 0 {
+		t.Errorf("Expected no CodingTime unless asked for, got %v", results[0].CodingTime)
+	}
+}
+
+func TestProcessPullRequests_DraftTime(t *testing.T) {
+	now := time.Now()
+	createdAt := now.Add(-10 * time.Hour)
+	readyAt := now.Add(-4 * time.Hour)
+	reviewTime := now.Add(-3 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "reviewer"}, State: "APPROVED", SubmittedAt: reviewTime},
+		},
+		timeline: []*TimelineEvent{
+			{Event: "ready_for_review", CreatedAt: readyAt},
+		},
+		files:      []*File{{Filename: "main.go"}},
+		codeowners: "* @reviewer\n",
+	}
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "Opened as a draft",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+		Base:      Branch{Ref: "main"},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, DraftTime: true})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].TimeInDraft != 6*time.Hour {
+		t.Errorf("Expected TimeInDraft of 6h, got %v", results[0].TimeInDraft)
+	}
+	if results[0].TimeToFirstReview != time.Hour || results[0].TimeToApproval != time.Hour {
+		t.Errorf("Expected review times of 1h from leaving draft, got %v and %v", results[0].TimeToFirstReview, results[0].TimeToApproval)
+	}
+
+	// Approved while still a draft: nothing was waited for after leaving draft
+	client.reviews[0].SubmittedAt = now.Add(-8 * time.Hour)
+	results = ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, DraftTime: true, Codeowners: true})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	got := results[0]
+	if len(got.CodeownerApprovals) != 1 {
+		t.Fatalf("Expected 1 owner approval, got %v", got.CodeownerApprovals)
+	}
+	for name, d := range map[string]time.Duration{
+		"TimeToApproval":          got.TimeToApproval,
+		"TimeToRequiredApprovals": got.TimeToRequiredApprovals,
+		"TimeToStandingApproval":  got.TimeToStandingApproval,
+		"ReviewerWaitTime":        got.ReviewerWaitTime,
+		"TimeToCodeownerApproval": got.TimeToCodeownerApproval,
+		"owner's TimeToApproval":  got.CodeownerApprovals[0].TimeToApproval,
+	} {
+		if d != 0 {
+			t.Errorf("Expected %s of 0 for a PR approved while a draft, got %v", name, d)
+		}
+	}
+}
+
+func TestProcessPullRequests_BusinessHours(t *testing.T) {
+	schedule, err := businesshours.Parse("09:00-17:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Opened Friday evening, reviewed Monday morning
+	createdAt := time.Date(2024, 3, 8, 18, 0, 0, 0, time.UTC)
+	reviewTime := time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "reviewer"}, State: "APPROVED", SubmittedAt: reviewTime},
+		},
+	}
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR opened Friday evening",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, BusinessHours: schedule})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].TimeToFirstReview != 1*time.Hour {
+		t.Errorf("Expected TimeToFirstReview to be 1h of business hours, got %v", results[0].TimeToFirstReview)
+	}
+	if results[0].TimeToApproval != 1*time.Hour {
+		t.Errorf("Expected TimeToApproval to be 1h of business hours, got %v", results[0].TimeToApproval)
+	}
+}
+
 func TestProcessPullRequests_PRWithMultipleReviews(t *testing.T) {
 	firstReviewTime := time.Now().Add(-90 * time.Minute)
 	secondReviewTime := time.Now().Add(-30 * time.Minute)
 
-	reviewer1 := &github.User{Login: github.String("reviewer1")}
-	reviewer2 := &github.User{Login: github.String("reviewer2")}
+	reviewer1 := User{Login: "reviewer1"}
+	reviewer2 := User{Login: "reviewer2"}
 
 	client := &MockGitHubClient{
-		reviews: []*github.PullRequestReview{
+		reviews: []*Review{
 			{
 				User:        reviewer2,
-				State:       github.String("APPROVED"),
-				SubmittedAt: &secondReviewTime,
+				State:       "APPROVED",
+				SubmittedAt: secondReviewTime,
 			},
 			{
 				User:        reviewer1,
-				State:       github.String("CHANGES_REQUESTED"),
-				SubmittedAt: &firstReviewTime,
+				State:       "CHANGES_REQUESTED",
+				SubmittedAt: firstReviewTime,
 			},
 		},
 	}
 
-	user := &github.User{Login: github.String("author")}
+	user := User{Login: "author"}
 	createdAt := time.Now().Add(-2 * time.Hour)
-	pr := &github.PullRequest{
-		Number:    github.Int(1),
-		Title:     github.String("PR with multiple reviews"),
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with multiple reviews",
 		User:      user,
-		State:     github.String("open"),
-		CreatedAt: &createdAt,
+		State:     "open",
+		CreatedAt: createdAt,
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 result, got %d", len(results))
@@ -256,33 +502,39 @@ func TestProcessPullRequests_PRWithMultipleReviews(t *testing.T) {
 	if result.TimeToApproval != expectedTimeToApproval {
 		t.Errorf("Expected TimeToApproval to be %v, got %v", expectedTimeToApproval, result.TimeToApproval)
 	}
+
+	// Every review is kept, in submission order, with when it was submitted
+	if len(result.Reviews) != 2 || result.Reviews[0].Reviewer != "reviewer1" || !result.Reviews[0].SubmittedAt.Equal(firstReviewTime) ||
+		result.Reviews[1].State != "APPROVED" || result.Reviews[1].TimeToReview != expectedTimeToApproval {
+		t.Errorf("Expected both reviews in submission order, got %+v", result.Reviews)
+	}
 }
 
 func TestProcessPullRequests_SkipSelfReviews(t *testing.T) {
 	reviewTime := time.Now().Add(-1 * time.Hour)
-	author := &github.User{Login: github.String("author")}
+	author := User{Login: "author"}
 
 	client := &MockGitHubClient{
-		reviews: []*github.PullRequestReview{
+		reviews: []*Review{
 			{
 				User:        author, // Self-review
-				State:       github.String("APPROVED"),
-				SubmittedAt: &reviewTime,
+				State:       "APPROVED",
+				SubmittedAt: reviewTime,
 			},
 		},
 	}
 
 	createdAt := time.Now().Add(-2 * time.Hour)
-	pr := &github.PullRequest{
-		Number:    github.Int(1),
-		Title:     github.String("PR with self review"),
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with self review",
 		User:      author,
-		State:     github.String("open"),
-		CreatedAt: &createdAt,
+		State:     "open",
+		CreatedAt: createdAt,
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 result, got %d", len(results))
@@ -299,31 +551,31 @@ func TestProcessPullRequests_SkipSelfReviews(t *testing.T) {
 
 func TestProcessPullRequests_SkipDenylistedReviewers(t *testing.T) {
 	reviewTime := time.Now().Add(-1 * time.Hour)
-	reviewer := &github.User{Login: github.String("denylisted-reviewer")}
+	reviewer := User{Login: "denylisted-reviewer"}
 
 	client := &MockGitHubClient{
-		reviews: []*github.PullRequestReview{
+		reviews: []*Review{
 			{
 				User:        reviewer,
-				State:       github.String("APPROVED"),
-				SubmittedAt: &reviewTime,
+				State:       "APPROVED",
+				SubmittedAt: reviewTime,
 			},
 		},
 	}
 
-	author := &github.User{Login: github.String("author")}
+	author := User{Login: "author"}
 	createdAt := time.Now().Add(-2 * time.Hour)
-	pr := &github.PullRequest{
-		Number:    github.Int(1),
-		Title:     github.String("PR with denylisted reviewer"),
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with denylisted reviewer",
 		User:      author,
-		State:     github.String("open"),
-		CreatedAt: &createdAt,
+		State:     "open",
+		CreatedAt: createdAt,
 	}
 
-	prs := []*github.PullRequest{pr}
+	prs := []*PullRequest{pr}
 	denylist := []string{"denylisted-reviewer"}
-	results := ProcessPullRequests(client, prs, "owner", "repo", denylist, "", "")
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{Users: UserFilter{Exclude: denylist}})
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 result, got %d", len(results))
@@ -338,32 +590,90 @@ func TestProcessPullRequests_SkipDenylistedReviewers(t *testing.T) {
 	}
 }
 
+func TestProcessPullRequests_SkipBots(t *testing.T) {
+	reviewTime := time.Now().Add(-1 * time.Hour)
+	createdAt := time.Now().Add(-2 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "review-bot[bot]"}, State: "APPROVED", SubmittedAt: reviewTime},
+		},
+	}
+	prs := []*PullRequest{
+		{Number: 1, Title: "Bump deps", User: User{Login: "dependabot[bot]"}, State: "open", CreatedAt: createdAt},
+		{Number: 2, Title: "Human PR", User: User{Login: "author"}, State: "open", CreatedAt: createdAt},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
+
+	if len(results) != 1 || results[0].PRNumber != 2 {
+		t.Fatalf("Expected only the human PR, got %v", results)
+	}
+	if results[0].HasReview {
+		t.Errorf("Expected the bot's review to be ignored")
+	}
+
+	results = ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{Users: UserFilter{IncludeBots: true}})
+
+	if len(results) != 2 || !results[1].HasReview {
+		t.Errorf("Expected bots' PRs and reviews to count with IncludeBots, got %v", results)
+	}
+}
+
+func TestProcessPullRequests_BaseBranches(t *testing.T) {
+	createdAt := time.Now().Add(-2 * time.Hour)
+	author := User{Login: "author"}
+	pr := func(number int, base string) *PullRequest {
+		return &PullRequest{
+			Number:    number,
+			Title:     "PR into " + base,
+			User:      author,
+			State:     "open",
+			CreatedAt: createdAt,
+			Base:      Branch{Ref: base},
+		}
+	}
+	prs := []*PullRequest{pr(1, "main"), pr(2, "feature/rewrite"), pr(3, "release/2.0")}
+
+	results := ProcessPullRequests(context.Background(), &MockGitHubClient{}, prs, "owner", "repo", ProcessOptions{BaseBranches: []string{"main", "release/*"}})
+
+	if len(results) != 2 || results[0].PRNumber != 1 || results[1].PRNumber != 3 {
+		t.Errorf("Expected only the PRs into main and release branches, got %v", results)
+	}
+
+	results = ProcessPullRequests(context.Background(), &MockGitHubClient{}, prs, "owner", "repo", ProcessOptions{})
+
+	if len(results) != 3 {
+		t.Errorf("Expected every PR without base branch filtering, got %d", len(results))
+	}
+}
+
 func TestProcessPullRequests_SkipPendingReviews(t *testing.T) {
 	reviewTime := time.Now().Add(-1 * time.Hour)
-	reviewer := &github.User{Login: github.String("reviewer")}
+	reviewer := User{Login: "reviewer"}
 
 	client := &MockGitHubClient{
-		reviews: []*github.PullRequestReview{
+		reviews: []*Review{
 			{
 				User:        reviewer,
-				State:       github.String("PENDING"),
-				SubmittedAt: &reviewTime,
+				State:       "PENDING",
+				SubmittedAt: reviewTime,
 			},
 		},
 	}
 
-	author := &github.User{Login: github.String("author")}
+	author := User{Login: "author"}
 	createdAt := time.Now().Add(-2 * time.Hour)
-	pr := &github.PullRequest{
-		Number:    github.Int(1),
-		Title:     github.String("PR with pending review"),
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with pending review",
 		User:      author,
-		State:     github.String("open"),
-		CreatedAt: &createdAt,
+		State:     "open",
+		CreatedAt: createdAt,
 	}
 
-	prs := []*github.PullRequest{pr}
-	results := ProcessPullRequests(client, prs, "owner", "repo", []string{}, "", "")
+	prs := []*PullRequest{pr}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
 
 	if len(results) != 1 {
 		t.Fatalf("Expected 1 result, got %d", len(results))
@@ -378,6 +688,529 @@ func TestProcessPullRequests_SkipPendingReviews(t *testing.T) {
 	}
 }
 
+func TestProcessPullRequests_StopsWhenBudgetExhausted(t *testing.T) {
+	client := &MockGitHubClient{
+		err: fmt.Errorf("github: %w", budget.ErrExhausted),
+	}
+
+	user := User{Login: "author"}
+	createdAt := time.Now().Add(-2 * time.Hour)
+	prs := []*PullRequest{
+		{Number: 1, User: user, State: "open", CreatedAt: createdAt},
+		{Number: 2, User: user, State: "open", CreatedAt: createdAt},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{})
+
+	if len(results) != 0 {
+		t.Errorf("Expected processing to stop with 0 results, got %d", len(results))
+	}
+	if client.reviewCalls != 1 {
+		t.Errorf("Expected 1 review fetch before stopping, got %d", client.reviewCalls)
+	}
+}
+
+func TestProcessPullRequests_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	user := User{Login: "author"}
+	createdAt := time.Now().Add(-2 * time.Hour)
+	prs := []*PullRequest{
+		{Number: 1, User: user, State: "open", CreatedAt: createdAt},
+	}
+
+	results := ProcessPullRequests(ctx, &MockGitHubClient{}, prs, "owner", "repo", ProcessOptions{})
+
+	if len(results) != 0 {
+		t.Errorf("Expected processing to stop with 0 results, got %d", len(results))
+	}
+}
+
+func TestProcessPullRequests_ReviewerAndAuthorWaitTime(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	changesRequestedAt := createdAt.Add(1 * time.Hour)
+	pushedAt := createdAt.Add(3 * time.Hour)
+	approvedAt := createdAt.Add(4 * time.Hour)
+
+	reviewer := User{Login: "reviewer"}
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: reviewer, State: "APPROVED", SubmittedAt: approvedAt},
+			{User: reviewer, State: "CHANGES_REQUESTED", SubmittedAt: changesRequestedAt},
+		},
+		prCommits: []*Commit{
+			{Commit: CommitDetail{Committer: CommitSignature{Date: pushedAt}}},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with a round of feedback",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	// Reviewers: creation -> changes requested (1h), push -> approval (1h)
+	if results[0].ReviewerWaitTime != 2*time.Hour {
+		t.Errorf("Expected ReviewerWaitTime 2h, got %v", results[0].ReviewerWaitTime)
+	}
+	// Author: changes requested -> push (2h)
+	if results[0].AuthorWaitTime != 2*time.Hour {
+		t.Errorf("Expected AuthorWaitTime 2h, got %v", results[0].AuthorWaitTime)
+	}
+}
+
+func TestProcessPullRequests_TimeToRequiredApprovals(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	firstApproval := createdAt.Add(1 * time.Hour)
+	repeatApproval := createdAt.Add(2 * time.Hour)
+	secondApprover := createdAt.Add(5 * time.Hour)
+
+	reviewer1 := User{Login: "reviewer1"}
+	reviewer2 := User{Login: "reviewer2"}
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: reviewer1, State: "APPROVED", SubmittedAt: firstApproval},
+			{User: reviewer1, State: "APPROVED", SubmittedAt: repeatApproval},
+			{User: reviewer2, State: "APPROVED", SubmittedAt: secondApprover},
+		},
+		requiredApprovals: 2, // from branch protection
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+		Base:      Branch{Ref: "main"},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].RequiredApprovals != 2 {
+		t.Errorf("Expected 2 required approvals from branch protection, got %d", results[0].RequiredApprovals)
+	}
+	if results[0].TimeToApproval != 1*time.Hour {
+		t.Errorf("Expected TimeToApproval 1h, got %v", results[0].TimeToApproval)
+	}
+	// A repeat approval from the same reviewer doesn't count twice
+	if results[0].TimeToRequiredApprovals != 5*time.Hour {
+		t.Errorf("Expected TimeToRequiredApprovals 5h, got %v", results[0].TimeToRequiredApprovals)
+	}
+
+	// An explicit requirement overrides branch protection
+	results = ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 3})
+	if results[0].RequiredApprovals != 3 {
+		t.Errorf("Expected 3 required approvals, got %d", results[0].RequiredApprovals)
+	}
+	if results[0].TimeToRequiredApprovals != 0 {
+		t.Errorf("Expected TimeToRequiredApprovals 0 when not reached, got %v", results[0].TimeToRequiredApprovals)
+	}
+}
+
+func TestProcessPullRequests_DismissedAndStaleApprovals(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	firstApprovedAt := createdAt.Add(1 * time.Hour)
+	dismissedAt := createdAt.Add(2 * time.Hour)
+	pushedAt := createdAt.Add(3 * time.Hour)
+	finalApprovedAt := createdAt.Add(5 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "alice"}, State: "APPROVED", SubmittedAt: firstApprovedAt},
+			{User: User{Login: "bob"}, State: "DISMISSED", SubmittedAt: dismissedAt},
+			{User: User{Login: "bob"}, State: "APPROVED", SubmittedAt: finalApprovedAt},
+		},
+		prCommits: []*Commit{
+			{Commit: CommitDetail{Committer: CommitSignature{Date: pushedAt}}},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR approved before a push",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].TimeToApproval != 1*time.Hour {
+		t.Errorf("Expected TimeToApproval 1h, got %v", results[0].TimeToApproval)
+	}
+	// alice's approval went stale with the push, so bob's later approval is the one that stands
+	if results[0].TimeToStandingApproval != 5*time.Hour {
+		t.Errorf("Expected TimeToStandingApproval 5h, got %v", results[0].TimeToStandingApproval)
+	}
+	// alice's stale approval and bob's dismissed review
+	if results[0].DismissedApprovals != 2 {
+		t.Errorf("Expected 2 dismissed approvals, got %d", results[0].DismissedApprovals)
+	}
+}
+
+func TestProcessPullRequests_DismissedReviews(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	changesRequestedAt := createdAt.Add(1 * time.Hour)
+	approvedAt := createdAt.Add(2 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{ID: 1, User: User{Login: "alice"}, State: "DISMISSED", SubmittedAt: changesRequestedAt},
+			{ID: 2, User: User{Login: "bob"}, State: "DISMISSED", SubmittedAt: approvedAt},
+		},
+		dismissals: []*DismissalEvent{
+			{DismissedReview: DismissedReview{ReviewID: 1, State: "changes_requested"}},
+			{DismissedReview: DismissedReview{ReviewID: 2, State: "approved"}},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR whose approval was dismissed",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	result := results[0]
+	if result.Dismissals != 2 {
+		t.Errorf("Expected 2 dismissals, got %d", result.Dismissals)
+	}
+	// Only bob's review was an approval
+	if result.DismissedApprovals != 1 {
+		t.Errorf("Expected 1 dismissed approval, got %d", result.DismissedApprovals)
+	}
+	if !result.OnlyApprovalDismissed {
+		t.Error("Expected the PR's only approval to have been dismissed")
+	}
+	// The dismissed approval was still given, so it counts unless excluded
+	if result.TimeToApproval != 2*time.Hour || result.Approver != "bob" || result.TimeToRequiredApprovals != 2*time.Hour {
+		t.Errorf("Expected bob's approval after 2h, got %v by %q", result.TimeToApproval, result.Approver)
+	}
+	if result.TimeToStandingApproval != 0 {
+		t.Errorf("Expected no standing approval, got %v", result.TimeToStandingApproval)
+	}
+
+	results = ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, ExcludeDismissed: true})
+	if results[0].TimeToApproval != 0 || results[0].Approver != "" || results[0].TimeToRequiredApprovals != 0 {
+		t.Errorf("Expected the dismissed approval to be excluded, got %v by %q", results[0].TimeToApproval, results[0].Approver)
+	}
+	if !results[0].OnlyApprovalDismissed {
+		t.Error("Expected the PR to still be reported as having its only approval dismissed")
+	}
+}
+
+func TestProcessPullRequests_CodeownerApprovals(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	backendApprovedAt := createdAt.Add(2 * time.Hour)
+	docsApprovedAt := createdAt.Add(5 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "alice"}, State: "APPROVED", SubmittedAt: backendApprovedAt},
+			{User: User{Login: "dave"}, State: "APPROVED", SubmittedAt: docsApprovedAt},
+		},
+		files: []*File{
+			{Filename: "server/api.go"},
+			{Filename: "docs/api.md"},
+		},
+		codeowners: "* @org/everyone\n/server/ @org/backend\n*.md @dave @erin\n",
+		teamMembers: map[string][]string{
+			"org/backend": {"alice", "bob"},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR touching two owners' code",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+		Base:      Branch{Ref: "main"},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, Codeowners: true})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].TimeToCodeownerApproval != 5*time.Hour {
+		t.Errorf("Expected TimeToCodeownerApproval 5h, got %v", results[0].TimeToCodeownerApproval)
+	}
+
+	expected := []OwnerApproval{
+		{Owner: "@org/backend", TimeToApproval: 2 * time.Hour},
+		{Owner: "@dave", TimeToApproval: 5 * time.Hour},
+		{Owner: "@erin"},
+	}
+	if len(results[0].CodeownerApprovals) != len(expected) {
+		t.Fatalf("Expected %d owner approvals, got %v", len(expected), results[0].CodeownerApprovals)
+	}
+	for i, want := range expected {
+		if got := results[0].CodeownerApprovals[i]; got != want {
+			t.Errorf("Owner approval %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestProcessPullRequests_LanguageAndClass(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+
+	client := &MockGitHubClient{
+		files: []*File{
+			{Filename: "server/api.go", Changes: 5},
+			{Filename: "server/api_test.go", Changes: 50},
+			{Filename: "README.md", Changes: 2},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "PR with code, tests and docs",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, Languages: true, Classify: true})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].DominantLanguage != "Go" {
+		t.Errorf("Expected DominantLanguage Go, got %q", results[0].DominantLanguage)
+	}
+	if results[0].Class != ClassCode {
+		t.Errorf("Expected Class %q, got %q", ClassCode, results[0].Class)
+	}
+}
+
+func TestProcessPullRequests_Size(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+
+	client := &MockGitHubClient{
+		files: []*File{
+			{Filename: "server/api.go", Additions: 40, Deletions: 10},
+			{Filename: "server/api_test.go", Additions: 60},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "Medium PR",
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, Size: true})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Additions != 100 || results[0].Deletions != 10 || results[0].ChangedFiles != 2 {
+		t.Errorf("Expected 100 additions, 10 deletions and 2 files, got %+v", results[0])
+	}
+	if results[0].Size != SizeM {
+		t.Errorf("Expected Size %q, got %q", SizeM, results[0].Size)
+	}
+}
+
+func TestProcessPullRequests_ReviewComments(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	author := User{Login: "author"}
+	reviewer := User{Login: "reviewer"}
+
+	client := &MockGitHubClient{
+		files: []*File{
+			{Filename: "server/api.go", Additions: 150, Deletions: 50},
+		},
+		comments: []*Comment{
+			{User: reviewer},
+			{User: author},
+			{User: reviewer},
+		},
+	}
+
+	pr := &PullRequest{
+		Number:    1,
+		Title:     "Reviewed PR",
+		User:      author,
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, Size: true, Comments: true})
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if !results[0].CommentsCounted || results[0].ReviewComments != 2 {
+		t.Errorf("Expected 2 review comments from the reviewer, got %d", results[0].ReviewComments)
+	}
+	if results[0].ReviewDepth != 1 {
+		t.Errorf("Expected 1 comment per 100 lines, got %v", results[0].ReviewDepth)
+	}
+}
+
+func TestProcessPullRequests_ExcludeComments(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	commentedAt := createdAt.Add(1 * time.Hour)
+	approvedAt := createdAt.Add(3 * time.Hour)
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "commenter"}, State: "COMMENTED", SubmittedAt: commentedAt},
+			{User: User{Login: "approver"}, State: "APPROVED", SubmittedAt: approvedAt},
+		},
+	}
+	pr := &PullRequest{
+		Number:    1,
+		User:      User{Login: "author"},
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1})
+	if results[0].FirstReviewer != "commenter" || results[0].TimeToFirstReview != time.Hour {
+		t.Errorf("Expected the comment to be the first review, got %s after %v", results[0].FirstReviewer, results[0].TimeToFirstReview)
+	}
+
+	results = ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, ExcludeComments: true})
+	if results[0].FirstReviewer != "approver" || results[0].TimeToFirstReview != 3*time.Hour {
+		t.Errorf("Expected the approval to be the first review, got %s after %v", results[0].FirstReviewer, results[0].TimeToFirstReview)
+	}
+	if !results[0].HasReview || len(results[0].Reviews) != 2 {
+		t.Errorf("Expected the comment review to still be listed, got %v", results[0].Reviews)
+	}
+}
+
+func TestProcessPullRequests_FirstResponse(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Hour)
+	draftCommentAt := createdAt.Add(-1 * time.Hour)
+	authorCommentAt := createdAt.Add(30 * time.Minute)
+	issueCommentAt := createdAt.Add(2 * time.Hour)
+	reviewCommentAt := createdAt.Add(4 * time.Hour)
+	reviewedAt := createdAt.Add(5 * time.Hour)
+	author := User{Login: "author"}
+
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "reviewer"}, State: "APPROVED", SubmittedAt: reviewedAt},
+		},
+		comments: []*Comment{
+			{User: User{Login: "reviewer"}, CreatedAt: reviewCommentAt},
+		},
+		issueComments: []*Comment{
+			{User: User{Login: "early"}, CreatedAt: draftCommentAt},
+			{User: author, CreatedAt: authorCommentAt},
+			{User: User{Login: "teammate"}, CreatedAt: issueCommentAt},
+		},
+	}
+	pr := &PullRequest{
+		Number:    1,
+		User:      author,
+		State:     "open",
+		CreatedAt: createdAt,
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, FirstResponse: true})
+
+	if results[0].FirstResponder != "teammate" || results[0].TimeToFirstResponse != 2*time.Hour {
+		t.Errorf("Expected teammate's comment to be the first response, got %s after %v", results[0].FirstResponder, results[0].TimeToFirstResponse)
+	}
+	if results[0].TimeToFirstReview != 5*time.Hour {
+		t.Errorf("Expected time to first review to be unaffected, got %v", results[0].TimeToFirstReview)
+	}
+}
+
+func TestSplitWaitTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+
+	reviews := []reviewEvent{
+		{state: "COMMENTED", at: start.Add(1 * time.Hour)},
+		{state: "CHANGES_REQUESTED", at: start.Add(2 * time.Hour)}, // already with author
+		{state: "COMMENTED", at: start.Add(6 * time.Hour)},
+	}
+	pushes := []time.Time{
+		start.Add(-1 * time.Hour), // before the PR was opened
+		start.Add(4 * time.Hour),
+		start.Add(5 * time.Hour), // already with reviewers
+	}
+
+	reviewerWait, authorWait := splitWaitTime(start, end, reviews, pushes)
+
+	// Reviewers: 0-1h, 4h-6h; author: 1h-4h, 6h-10h
+	if reviewerWait != 3*time.Hour {
+		t.Errorf("Expected reviewer wait 3h, got %v", reviewerWait)
+	}
+	if authorWait != 7*time.Hour {
+		t.Errorf("Expected author wait 7h, got %v", authorWait)
+	}
+}
+
+func TestReviewRounds(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	reviews := []reviewEvent{
+		{reviewer: "alice", state: "CHANGES_REQUESTED", at: start.Add(1 * time.Hour)},
+		{reviewer: "bob", state: "COMMENTED", at: start.Add(2 * time.Hour)}, // same round, no push yet
+		{reviewer: "alice", state: "CHANGES_REQUESTED", at: start.Add(4 * time.Hour)},
+		{reviewer: "alice", state: "APPROVED", at: start.Add(8 * time.Hour)},
+	}
+	pushes := []time.Time{
+		start.Add(-1 * time.Hour), // before any review
+		start.Add(3 * time.Hour),
+		start.Add(6 * time.Hour),
+	}
+
+	if got := reviewRounds(reviews, pushes); got != 3 {
+		t.Errorf("Expected 3 review rounds, got %d", got)
+	}
+	if got := reviewRounds(reviews[1:2], pushes); got != 1 {
+		t.Errorf("Expected a comment followed by pushes to be 1 round, got %d", got)
+	}
+	if got := reviewRounds(nil, pushes); got != 0 {
+		t.Errorf("Expected 0 rounds without reviews, got %d", got)
+	}
+}
+
+func TestChangesRequestedTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	reviews := []reviewEvent{
+		{reviewer: "alice", state: "CHANGES_REQUESTED", at: start.Add(1 * time.Hour)},
+		{reviewer: "bob", state: "CHANGES_REQUESTED", at: start.Add(2 * time.Hour)},
+		{reviewer: "alice", state: "APPROVED", at: start.Add(3 * time.Hour)},
+		{reviewer: "bob", state: "COMMENTED", at: start.Add(4 * time.Hour)}, // doesn't lift bob's request
+		{reviewer: "bob", state: "APPROVED", at: start.Add(5 * time.Hour)},
+		{reviewer: "carol", state: "CHANGES_REQUESTED", at: start.Add(7 * time.Hour)},
+	}
+
+	// 1h-5h, then 7h until the end at 10h
+	if got := changesRequestedTime(reviews, start.Add(10*time.Hour), ProcessOptions{}); got != 7*time.Hour {
+		t.Errorf("Expected 7h with changes requested, got %v", got)
+	}
+}
+
 func TestAnalyzeCommitDiffForPRReference(t *testing.T) {
 	// Test PR number pattern
 	prNumber := 123
@@ -393,18 +1226,18 @@ func TestAnalyzeCommitDiffForPRReference(t *testing.T) {
 +app2: pull-123_abc123def456
  app3: 1f2e3d4c`
 
-	commit := &github.RepositoryCommit{
-		SHA: &sha,
-		Files: []*github.CommitFile{
+	commit := &Commit{
+		SHA: sha,
+		Files: []*File{
 			{
-				Patch: &patchContent,
+				Patch: patchContent,
 			},
 		},
-		Commit: &github.Commit{
-			Message: &commitMessage,
-			Author: &github.CommitAuthor{
-				Name: &authorName,
-				Date: &authorDate,
+		Commit: CommitDetail{
+			Message: commitMessage,
+			Author: CommitSignature{
+				Name: authorName,
+				Date: authorDate,
 			},
 		},
 	}
@@ -430,18 +1263,18 @@ func TestAnalyzeCommitDiffForPRReference(t *testing.T) {
 +app2: 2024_01_15__14_30_45__feature-branch__abc123def456
  app3: 1f2e3d4c`
 
-	branchCommit := &github.RepositoryCommit{
-		SHA: &sha,
-		Files: []*github.CommitFile{
+	branchCommit := &Commit{
+		SHA: sha,
+		Files: []*File{
 			{
-				Patch: &branchPatchContent,
+				Patch: branchPatchContent,
 			},
 		},
-		Commit: &github.Commit{
-			Message: &commitMessage,
-			Author: &github.CommitAuthor{
-				Name: &authorName,
-				Date: &authorDate,
+		Commit: CommitDetail{
+			Message: commitMessage,
+			Author: CommitSignature{
+				Name: authorName,
+				Date: authorDate,
 			},
 		},
 	}
@@ -464,18 +1297,18 @@ func TestAnalyzeCommitDiffForPRReference(t *testing.T) {
 +app2: pull-999_abc123def456
  app3: 1f2e3d4c`
 
-	noMatchCommit := &github.RepositoryCommit{
-		SHA: &sha,
-		Files: []*github.CommitFile{
+	noMatchCommit := &Commit{
+		SHA: sha,
+		Files: []*File{
 			{
-				Patch: &noMatchPatchContent,
+				Patch: noMatchPatchContent,
 			},
 		},
-		Commit: &github.Commit{
-			Message: &commitMessage,
-			Author: &github.CommitAuthor{
-				Name: &authorName,
-				Date: &authorDate,
+		Commit: CommitDetail{
+			Message: commitMessage,
+			Author: CommitSignature{
+				Name: authorName,
+				Date: authorDate,
 			},
 		},
 	}
@@ -496,13 +1329,13 @@ func TestCheckPRTagCommits(t *testing.T) {
 	authorDate := time.Now()
 	commitMessage := "Test commit"
 
-	pr := &github.PullRequest{
-		Number:    &prNumber,
-		CreatedAt: &createdAt,
-		MergedAt:  &mergedAt,
-		State:     github.String("closed"),
-		Head: &github.PullRequestBranch{
-			Ref: &prBranch,
+	pr := &PullRequest{
+		Number:    prNumber,
+		CreatedAt: createdAt,
+		MergedAt:  mergedAt,
+		State:     "closed",
+		Head: Branch{
+			Ref: prBranch,
 		},
 	}
 
@@ -513,34 +1346,34 @@ func TestCheckPRTagCommits(t *testing.T) {
 +app2: pull-123_abc123def456
  app3: 1f2e3d4c`
 
-	mockCommit := &github.RepositoryCommit{
-		SHA: &sha,
-		Files: []*github.CommitFile{
+	mockCommit := &Commit{
+		SHA: sha,
+		Files: []*File{
 			{
-				Patch: &patchContent,
+				Patch: patchContent,
 			},
 		},
-		Commit: &github.Commit{
-			Message: &commitMessage,
-			Author: &github.CommitAuthor{
-				Name: &authorName,
-				Date: &authorDate,
+		Commit: CommitDetail{
+			Message: commitMessage,
+			Author: CommitSignature{
+				Name: authorName,
+				Date: authorDate,
 			},
 		},
 	}
 
-	listCommit := &github.RepositoryCommit{
-		SHA: &sha,
+	listCommit := &Commit{
+		SHA: sha,
 	}
 
 	// Create mock client
 	client := &MockGitHubClient{
-		commits: []*github.RepositoryCommit{listCommit},
+		commits: []*Commit{listCommit},
 		commit:  mockCommit,
 		err:     nil,
 	}
 
-	result := checkPRTagCommits(client, pr, "org", "tags-repo")
+	result := checkPRTagCommits(context.Background(), client, pr, "org", "tags-repo")
 	if len(result) != 1 {
 		t.Errorf("Expected to find 1 tag commit for PR, but found %d", len(result))
 	} else {
@@ -558,11 +1391,11 @@ func TestCheckPRTagCommits(t *testing.T) {
 
 	// Test with no matching commits
 	clientNoMatch := &MockGitHubClient{
-		commits: []*github.RepositoryCommit{},
+		commits: []*Commit{},
 		err:     nil,
 	}
 
-	resultNoMatch := checkPRTagCommits(clientNoMatch, pr, "org", "tags-repo")
+	resultNoMatch := checkPRTagCommits(context.Background(), clientNoMatch, pr, "org", "tags-repo")
 	if len(resultNoMatch) != 0 {
 		t.Errorf("Expected not to find tag commits for PR, but found %d", len(resultNoMatch))
 	}
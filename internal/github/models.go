@@ -2,23 +2,112 @@ package github
 
 import "time"
 
-// PullRequest represents a GitHub pull request.
+// The types below are what the GitHub clients return: just the fields processing
+// reads, converted from the API's types as they're fetched, so that caches and
+// event logs hold no more than they need and the rest of the code doesn't depend
+// on go-github's structs. Their JSON matches the REST API's for those fields, so
+// entries cached and events logged in the API's form still decode.
+
+// PullRequest is a GitHub pull request
 type PullRequest struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	IsDraft   bool      `json:"is_draft"`
-	Author    string    `json:"author"`
-	Reviews   []Review  `json:"reviews"`
+	Number       int       `json:"number"`
+	Title        string    `json:"title"`
+	State        string    `json:"state"` // open or closed
+	Draft        bool      `json:"draft"`
+	User         User      `json:"user"` // The author
+	Labels       []Label   `json:"labels,omitempty"`
+	Base         Branch    `json:"base"`
+	Head         Branch    `json:"head"`
+	CreatedAt    time.Time `json:"created_at"`
+	ClosedAt     time.Time `json:"closed_at"` // Zero if the PR is open
+	MergedAt     time.Time `json:"merged_at"` // Zero if the PR wasn't merged
+	Additions    int       `json:"additions"`
+	Deletions    int       `json:"deletions"`
+	ChangedFiles int       `json:"changed_files"` // 0 if the PR came from a REST listing, which doesn't include its size
+}
+
+// User is a GitHub account
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type,omitempty"` // User, Bot or Organization
+}
+
+// Team is a GitHub team
+type Team struct {
+	Slug string `json:"slug"`
+}
+
+// Label is a label on an issue or PR
+type Label struct {
+	Name string `json:"name"`
 }
 
-// Review represents a review on a pull request.
+// Branch is one end of a PR: the branch it merges into, or from
+type Branch struct {
+	Ref string `json:"ref"`
+}
+
+// Review is a review submitted on a PR
 type Review struct {
-	ID     int       `json:"id"`
-	User   string    `json:"user"`
-	Status string    `json:"status"`
-	Date   time.Time `json:"date"`
+	ID          int64     `json:"id"`
+	User        User      `json:"user"`
+	State       string    `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// Commit is a commit on a PR's branch or in a repository. Files are only listed
+// for a commit fetched on its own.
+type Commit struct {
+	SHA    string       `json:"sha"`
+	Commit CommitDetail `json:"commit"`
+	Files  []*File      `json:"files,omitempty"`
+}
+
+// CommitDetail is the git commit itself
+type CommitDetail struct {
+	Message   string          `json:"message"`
+	Author    CommitSignature `json:"author"`
+	Committer CommitSignature `json:"committer"`
+}
+
+// CommitSignature is who authored or committed a commit, and when
+type CommitSignature struct {
+	Name string    `json:"name"`
+	Date time.Time `json:"date"`
+}
+
+// File is a file changed by a PR or a commit
+type File struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"` // added, removed, modified, renamed, ...
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+	Patch     string `json:"patch,omitempty"` // "" for binary files, and files too big to diff
+}
+
+// Comment is a comment on a PR, on its diff or in its conversation
+type Comment struct {
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TimelineEvent is an event on a PR's timeline, such as it being marked ready for review
+type TimelineEvent struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DismissalEvent is a review being dismissed on a PR
+type DismissalEvent struct {
+	DismissedReview DismissedReview `json:"dismissed_review"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// DismissedReview is the review a DismissalEvent dismissed, and what it was before
+type DismissedReview struct {
+	ReviewID int64  `json:"review_id"`
+	State    string `json:"state"` // approved, changes_requested or commented
 }
 
 // TagCommit represents a commit in the tags repository that references a PR
@@ -29,17 +118,158 @@ type TagCommit struct {
 	Author  string    // The commit author
 }
 
+// OwnerApproval records how long a CODEOWNERS owner whose approval a PR needed took to approve it
+type OwnerApproval struct {
+	Owner          string        // @user or @org/team from CODEOWNERS
+	TimeToApproval time.Duration // 0 if the owner hasn't approved
+}
+
+// ReviewMetric is a single review a PR received, excluding self-reviews and excluded reviewers
+type ReviewMetric struct {
+	Reviewer     string
+	State        string        // APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	SubmittedAt  time.Time     // When the review was submitted
+	TimeToReview time.Duration // Time from PR creation to the review (in business hours, if measured in them)
+}
+
+// ReviewRequestEvent is a review being requested from, or no longer requested
+// from, a person or a team on a PR, as GitHub lists it among the PR's events
+type ReviewRequestEvent struct {
+	Event             string    `json:"event"` // review_requested or review_request_removed
+	RequestedReviewer *User     `json:"requested_reviewer,omitempty"`
+	RequestedTeam     *Team     `json:"requested_team,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ReviewRequestMetric is one request for a reviewer's review, and how long they took to respond
+type ReviewRequestMetric struct {
+	Reviewer     string        // Who was asked, or for a team, the member who responded first
+	Team         string        // The org/team asked, if the request was to a team
+	RequestedAt  time.Time     // When the review was requested, or when the PR left draft if that was later
+	Responded    bool          // Whether the reviewer, or a member of the team, reviewed after the request
+	ResponseTime time.Duration // Time from the request until the review, or until the PR was merged or closed (or now) without one
+}
+
 // PullRequestMetric represents the analysis results for a single PR
 type PullRequestMetric struct {
+	Repository        string // owner/repo
 	PRTitle           string
 	PRNumber          int
 	Author            string
+	CreatedAt         time.Time
+	MergedAt          time.Time     // When the PR was merged, zero if it hasn't been
+	Draft             bool          // Whether the PR is still a draft
+	CodingTime        time.Duration // First commit until the PR was opened, 0 if not measured
+	TimeInDraft       time.Duration // Time the PR spent as a draft, 0 if not measured; review times start once it left draft
 	TimeToFirstReview time.Duration
 	FirstReviewer     string
 	FirstReviewState  string
 	TimeToApproval    time.Duration
 	Approver          string
+	ApprovalToMerge   time.Duration // First approval until merge, 0 if not approved and merged
 	HasReview         bool          // Flag to indicate if PR has at least one review
 	TimeSinceCreation time.Duration // How long the PR has been open without review
 	TagCommits        []TagCommit   // All tag commits that reference this PR
+	ReviewerWaitTime  time.Duration // Time until approval that the PR was waiting on reviewers
+	AuthorWaitTime    time.Duration // Time until approval that the PR was waiting on the author to address feedback
+
+	TimeToFirstResponse time.Duration // Time until anyone but the author reviewed or commented, 0 if not measured or no one has
+	FirstResponder      string        // Who responded first, "" if not measured or no one has
+
+	RequiredApprovals       int           // Distinct approvals the PR's base branch requires
+	TimeToRequiredApprovals time.Duration // Time until the required number of distinct approvals, 0 if not reached
+
+	TimeToStandingApproval time.Duration // Time until the approval the PR ended with (not later dismissed or made stale by a push), 0 if none
+	DismissedApprovals     int           // Approvals that were dismissed or invalidated by a later push
+	Dismissals             int           // Reviews that were dismissed, whatever they were
+	OnlyApprovalDismissed  bool          // Whether the PR was approved, but every approval it received was later dismissed
+
+	ReviewRounds           int           // Review rounds: the first review, plus one per re-review after changes were requested and pushed; 0 if not reviewed
+	TimeInChangesRequested time.Duration // Time some reviewer had changes requested on the PR
+
+	CodeownerApprovals      []OwnerApproval // Owners of the CODEOWNERS rules the PR triggers
+	TimeToCodeownerApproval time.Duration   // Time until every triggered rule had an owner's approval, 0 if not reached
+
+	DominantLanguage string // Language with the most changed lines, "" if not measured
+	Class            string // ClassDocs, ClassTest, ClassConfig or ClassCode, "" if not classified
+	Hotfix           bool   // Whether the PR carries one of the hotfix labels
+	Priority         string // The most urgent priority level the PR's labels put it at, "" if none
+	ChangeType       string // Conventional-commit type from the title (feat, fix, chore, ...), "" if none
+
+	Additions    int    // Lines added, 0 if size wasn't measured
+	Deletions    int    // Lines deleted, 0 if size wasn't measured
+	ChangedFiles int    // Files changed, 0 if size wasn't measured
+	Size         string // SizeXS to SizeXL by lines changed, "" if not measured
+
+	CommentsCounted bool    // Whether review comments were fetched
+	ReviewComments  int     // Inline review comments from reviewers
+	ReviewDepth     float64 // Review comments per 100 lines changed, 0 if size wasn't measured
+
+	Reviews        []ReviewMetric        // Every review the PR received, in submission order
+	ReviewRequests []ReviewRequestMetric // Every request for an individual's review, in request order, if measured
+
+	Extra map[string]string // Fields added by a hook program, if any
+}
+
+// Discussion is a GitHub Discussion with the comments needed to measure responsiveness
+type Discussion struct {
+	Number         int
+	Title          string
+	URL            string
+	Author         string
+	Category       string
+	Answerable     bool // Whether the category supports marking an answer (e.g. Q&A)
+	CreatedAt      time.Time
+	AnswerChosenAt *time.Time // nil if no answer has been marked
+	ClosedAt       *time.Time // nil if still open
+	Comments       []DiscussionComment
+}
+
+// DiscussionComment is a top-level comment on a discussion
+type DiscussionComment struct {
+	Author            string
+	AuthorAssociation string // OWNER, MEMBER, COLLABORATOR, CONTRIBUTOR, NONE, ...
+	CreatedAt         time.Time
+}
+
+// DiscussionMetric represents the responsiveness results for a single discussion
+type DiscussionMetric struct {
+	Number              int
+	Title               string
+	Author              string
+	Category            string
+	HasResponse         bool          // Whether a maintainer has replied
+	TimeToFirstResponse time.Duration // Time until the first maintainer reply
+	FirstResponder      string
+	Answerable          bool
+	Answered            bool
+	TimeToAnswer        time.Duration // Time until a reply was marked as the answer
+	Closed              bool
+	TimeToClose         time.Duration
+	TimeSinceCreation   time.Duration
+}
+
+// ProjectItem is an issue or PR on a GitHub Projects (v2) board, with the history of its Status column
+type ProjectItem struct {
+	Number        int
+	Title         string
+	Repository    string // owner/repo
+	CurrentStatus string
+	StatusChanges []StatusChange // Oldest first
+}
+
+// StatusChange is a move between columns of a project board
+type StatusChange struct {
+	From string // Empty when the item first gets a status
+	To   string // Empty when the status is cleared
+	At   time.Time
+}
+
+// ProjectItemMetric represents how long a project item spent in each column during the report period
+type ProjectItemMetric struct {
+	Number        int
+	Title         string
+	Repository    string
+	CurrentStatus string
+	TimeInStatus  map[string]time.Duration
 }
@@ -0,0 +1,71 @@
+package github
+
+import (
+	"context"
+)
+
+// reviewFetcher fetches PRs' reviews in the background ahead of processing, a
+// bounded number at a time, so a large window isn't fetched one PR at a time. A
+// PR holds its slot until the next PR's reviews are asked for, so no more than
+// the limit are ever fetched ahead, and little budget is wasted if processing
+// stops early.
+type reviewFetcher struct {
+	results []chan fetchedReviews
+	slots   chan struct{}
+	done    chan struct{}
+}
+
+type fetchedReviews struct {
+	reviews []*Review
+	err     error
+}
+
+// fetchReviews starts fetching the reviews of prs, up to concurrency at a time
+// (at least one). Call stop once done with the fetcher.
+func fetchReviews(ctx context.Context, client GitHubClientInterface, prs []*PullRequest, owner, repo string, concurrency int) *reviewFetcher {
+	f := &reviewFetcher{
+		results: make([]chan fetchedReviews, len(prs)),
+		slots:   make(chan struct{}, max(concurrency, 1)),
+		done:    make(chan struct{}),
+	}
+	for i := range f.results {
+		f.results[i] = make(chan fetchedReviews, 1)
+	}
+
+	go func() {
+		for i, pr := range prs {
+			select {
+			case f.slots <- struct{}{}:
+			case <-f.done:
+				return
+			}
+			// Both cases may have been ready; don't start another fetch after stop
+			select {
+			case <-f.done:
+				return
+			default:
+			}
+
+			go func(i int, pr *PullRequest) {
+				reviews, err := client.FetchPullRequestReviews(ctx, owner, repo, pr.Number)
+				f.results[i] <- fetchedReviews{reviews: reviews, err: err}
+			}(i, pr)
+		}
+	}()
+	return f
+}
+
+// reviews waits for the reviews of the ith PR. PRs must be asked for in order.
+func (f *reviewFetcher) reviews(i int) ([]*Review, error) {
+	// The previous PR is done with, so its slot can go to the next fetch
+	if i > 0 {
+		<-f.slots
+	}
+	result := <-f.results[i]
+	return result.reviews, result.err
+}
+
+// stop stops starting new fetches. Fetches already under way finish in the background.
+func (f *reviewFetcher) stop() {
+	close(f.done)
+}
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// slowReviewsClient returns one review per PR, from a reviewer named after the PR
+// number, after a delay, and tracks how many fetches run at once
+type slowReviewsClient struct {
+	MockGitHubClient
+	reviewedAt time.Time
+	err        error // returned for PRs numbered above failAfter, if set
+	failAfter  int
+
+	mu                sync.Mutex
+	inFlight, maxSeen int
+}
+
+func (c *slowReviewsClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*Review, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.maxSeen = max(c.maxSeen, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	if c.err != nil && prNumber > c.failAfter {
+		return nil, c.err
+	}
+	return []*Review{{
+		User:        User{Login: fmt.Sprintf("reviewer%d", prNumber)},
+		State:       "APPROVED",
+		SubmittedAt: c.reviewedAt,
+	}}, nil
+}
+
+func TestProcessPullRequests_Concurrency(t *testing.T) {
+	createdAt := time.Now().Add(-2 * time.Hour)
+	author := User{Login: "author"}
+	var prs []*PullRequest
+	for n := 1; n <= 20; n++ {
+		prs = append(prs, &PullRequest{Number: n, User: author, State: "open", CreatedAt: createdAt})
+	}
+	// Drafts are skipped without fetching their reviews
+	prs[3].Draft = true
+
+	client := &slowReviewsClient{reviewedAt: createdAt.Add(time.Hour)}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{Concurrency: 4})
+
+	if len(results) != 19 {
+		t.Fatalf("Expected 19 results, got %d", len(results))
+	}
+	for i, result := range results {
+		n := i + 1
+		if n >= 4 {
+			n++
+		}
+		if result.PRNumber != n || result.FirstReviewer != fmt.Sprintf("reviewer%d", n) {
+			t.Errorf("Expected result %d to be PR #%d reviewed by reviewer%d, got PR #%d reviewed by %s", i, n, n, result.PRNumber, result.FirstReviewer)
+		}
+	}
+	if client.maxSeen < 2 || client.maxSeen > 4 {
+		t.Errorf("Expected between 2 and 4 fetches at once, got %d", client.maxSeen)
+	}
+}
+
+func TestProcessPullRequests_ConcurrencyStopsWhenBudgetExhausted(t *testing.T) {
+	createdAt := time.Now().Add(-2 * time.Hour)
+	author := User{Login: "author"}
+	var prs []*PullRequest
+	for n := 1; n <= 20; n++ {
+		prs = append(prs, &PullRequest{Number: n, User: author, State: "open", CreatedAt: createdAt})
+	}
+
+	client := &slowReviewsClient{
+		reviewedAt: createdAt.Add(time.Hour),
+		err:        fmt.Errorf("github: %w", budget.ErrExhausted),
+		failAfter:  5,
+	}
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{Concurrency: 4})
+
+	if len(results) != 5 {
+		t.Fatalf("Expected processing to stop after 5 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if result.PRNumber != i+1 {
+			t.Errorf("Expected result %d to be PR #%d, got #%d", i, i+1, result.PRNumber)
+		}
+	}
+}
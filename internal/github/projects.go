@@ -0,0 +1,197 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// projectItemsQuery pages through a Projects (v2) board's items along with their
+// status change events. %s is the owner field, "organization" or "user". Only the
+// first 100 status changes per item are fetched.
+const projectItemsQuery = `
+query($owner: String!, $number: Int!, $cursor: String) {
+  %s(login: $owner) {
+    projectV2(number: $number) {
+      id
+      items(first: 50, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          status: fieldValueByName(name: "Status") {
+            ... on ProjectV2ItemFieldSingleSelectValue { name }
+          }
+          content {
+            ... on Issue {
+              number
+              title
+              repository { nameWithOwner }
+              timelineItems(first: 100, itemTypes: [PROJECT_V2_ITEM_STATUS_CHANGED_EVENT]) {
+                nodes { ...statusChange }
+              }
+            }
+            ... on PullRequest {
+              number
+              title
+              repository { nameWithOwner }
+              timelineItems(first: 100, itemTypes: [PROJECT_V2_ITEM_STATUS_CHANGED_EVENT]) {
+                nodes { ...statusChange }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+
+fragment statusChange on ProjectV2ItemStatusChangedEvent {
+  createdAt
+  previousStatus
+  status
+  project { id }
+}`
+
+type projectItemsResponse struct {
+	Owner struct {
+		ProjectV2 struct {
+			ID    string `json:"id"`
+			Items struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					Status *struct {
+						Name string `json:"name"`
+					} `json:"status"`
+					Content *struct {
+						Number     int    `json:"number"`
+						Title      string `json:"title"`
+						Repository struct {
+							NameWithOwner string `json:"nameWithOwner"`
+						} `json:"repository"`
+						TimelineItems struct {
+							Nodes []struct {
+								CreatedAt      time.Time `json:"createdAt"`
+								PreviousStatus string    `json:"previousStatus"`
+								Status         string    `json:"status"`
+								Project        struct {
+									ID string `json:"id"`
+								} `json:"project"`
+							} `json:"nodes"`
+						} `json:"timelineItems"`
+					} `json:"content"` // nil for draft issues, which have no timeline
+				} `json:"nodes"`
+			} `json:"items"`
+		} `json:"projectV2"`
+	} `json:"owner"`
+}
+
+// FetchProjectItems fetches the issues and PRs on a Projects (v2) board with their
+// status history. owner is an organization, or a user if isUser is set. If the API
+// budget runs out part way through, the items fetched so far are returned along with the error.
+func (c *GitHubClient) FetchProjectItems(ctx context.Context, owner string, number int, isUser bool) ([]ProjectItem, error) {
+	ownerField := "organization"
+	if isUser {
+		ownerField = "user"
+	}
+	// Alias the owner field so the response decodes the same way for orgs and users
+	query := fmt.Sprintf(projectItemsQuery, "owner: "+ownerField)
+
+	var allItems []ProjectItem
+	variables := map[string]interface{}{
+		"owner":  owner,
+		"number": number,
+		"cursor": nil,
+	}
+
+	for {
+		var resp projectItemsResponse
+		if err := c.graphQL(ctx, query, variables, &resp); err != nil {
+			return allItems, err
+		}
+
+		page := resp.Owner.ProjectV2.Items
+		for _, node := range page.Nodes {
+			if node.Content == nil || node.Content.Number == 0 {
+				continue
+			}
+
+			item := ProjectItem{
+				Number:     node.Content.Number,
+				Title:      node.Content.Title,
+				Repository: node.Content.Repository.NameWithOwner,
+			}
+			if node.Status != nil {
+				item.CurrentStatus = node.Status.Name
+			}
+			for _, event := range node.Content.TimelineItems.Nodes {
+				// The timeline includes status changes on every board the item is on
+				if event.Project.ID != resp.Owner.ProjectV2.ID {
+					continue
+				}
+				item.StatusChanges = append(item.StatusChanges, StatusChange{
+					From: event.PreviousStatus,
+					To:   event.Status,
+					At:   event.CreatedAt,
+				})
+			}
+			allItems = append(allItems, item)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = page.PageInfo.EndCursor
+	}
+
+	return allItems, nil
+}
+
+// ProcessProjectItems measures how long each item spent in each column between
+// startDate and endDate. An item's time in a column runs from the status change
+// that moved it there until the next one (or endDate). Time before an item's first
+// recorded status change isn't attributed to any column. Items with no time in the
+// period are skipped.
+func ProcessProjectItems(items []ProjectItem, startDate, endDate time.Time) []ProjectItemMetric {
+	var results []ProjectItemMetric
+
+	for _, item := range items {
+		changes := slices.Clone(item.StatusChanges)
+		slices.SortStableFunc(changes, func(a, b StatusChange) int {
+			return a.At.Compare(b.At)
+		})
+
+		timeInStatus := make(map[string]time.Duration)
+		for i, change := range changes {
+			if change.To == "" {
+				continue
+			}
+			from := change.At
+			until := endDate
+			if i+1 < len(changes) && changes[i+1].At.Before(endDate) {
+				until = changes[i+1].At
+			}
+			if from.Before(startDate) {
+				from = startDate
+			}
+			if until.After(from) {
+				timeInStatus[change.To] += until.Sub(from)
+			}
+		}
+
+		if len(timeInStatus) == 0 {
+			continue
+		}
+		results = append(results, ProjectItemMetric{
+			Number:        item.Number,
+			Title:         item.Title,
+			Repository:    item.Repository,
+			CurrentStatus: item.CurrentStatus,
+			TimeInStatus:  timeInStatus,
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcessProjectItems(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	items := []ProjectItem{
+		{
+			Number:        1,
+			Title:         "Moved into the period already in progress",
+			CurrentStatus: "Done",
+			StatusChanges: []StatusChange{
+				{From: "In Review", To: "Done", At: start.Add(48 * time.Hour)},
+				{From: "Todo", To: "In Progress", At: start.Add(-24 * time.Hour)},
+				{From: "In Progress", To: "In Review", At: start.Add(24 * time.Hour)},
+			},
+		},
+		{
+			Number:        2,
+			Title:         "Sitting in Done for the whole period",
+			CurrentStatus: "Done",
+			StatusChanges: []StatusChange{
+				{To: "In Progress", At: start.Add(-72 * time.Hour)},
+				{From: "In Progress", To: "Done", At: start.Add(-48 * time.Hour)},
+			},
+		},
+	}
+
+	results := ProcessProjectItems(items, start, end)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	expected := map[string]time.Duration{
+		"In Progress": 24 * time.Hour, // clipped to the period start
+		"In Review":   24 * time.Hour,
+		"Done":        end.Sub(start.Add(48 * time.Hour)),
+	}
+	for status, want := range expected {
+		if got := results[0].TimeInStatus[status]; got != want {
+			t.Errorf("Expected %v in %s, got %v", want, status, got)
+		}
+	}
+
+	if got := results[1].TimeInStatus["Done"]; got != end.Sub(start) {
+		t.Errorf("Expected item 2 to be in Done for the whole period, got %v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRateLimitRetries bounds how many times one request waits out a rate limit
+// before its error is passed on
+const maxRateLimitRetries = 10
+
+// secondaryRateLimitWait is how long to back off from a secondary rate limit that
+// doesn't say when to retry, as GitHub's docs recommend
+const secondaryRateLimitWait = time.Minute
+
+// rateLimitTransport waits out GitHub rate limits instead of failing: when a
+// request is rejected for exceeding the primary rate limit it sleeps until the
+// limit resets, and when it trips a secondary (abuse) limit it backs off for as
+// long as GitHub asks, then retries the request. Long waits log their progress,
+// so a large run doesn't look hung.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	// For tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{base: base, now: time.Now, sleep: sleepWithProgress}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		wait, reason := rateLimitWait(resp, t.now())
+		if wait == 0 || attempt > maxRateLimitRetries {
+			return resp, nil
+		}
+
+		// Retrying needs a fresh copy of the body, if there was one
+		retry := req
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			retry = req.Clone(req.Context())
+			retry.Body = body
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		log.Printf("Hit GitHub's %s; waiting %v before retrying %s", reason, wait.Round(time.Second), req.URL.Path)
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		req = retry
+	}
+}
+
+// rateLimitWait returns how long to wait before retrying a request that got resp,
+// and which limit it hit, or 0 if it wasn't rate limited
+func rateLimitWait(resp *http.Response, now time.Time) (time.Duration, string) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, ""
+	}
+
+	// Secondary limits usually say how long to back off
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return max(time.Duration(seconds)*time.Second, time.Second), "secondary rate limit"
+		}
+	}
+
+	// The primary limit is spent until the reset time. A second's slack allows for
+	// clock skew.
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now)+time.Second, time.Second), "rate limit"
+		}
+	}
+
+	// Secondary limits without Retry-After only say so in the message. Other 403s
+	// are permission errors, which retrying won't fix.
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err == nil && bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit")) {
+		return secondaryRateLimitWait, "secondary rate limit"
+	}
+	return 0, ""
+}
+
+// sleepWithProgress sleeps for d, logging how much longer there is to go every
+// minute, or returns early with ctx's error if it's cancelled
+func sleepWithProgress(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(d)
+	for {
+		select {
+		case <-timer.C:
+			return nil
+		case <-ticker.C:
+			log.Printf("Still waiting for the GitHub rate limit to reset, %v to go", time.Until(deadline).Round(time.Second))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limited  func(w http.ResponseWriter)
+		wantWait time.Duration // 0 means the limited response is returned as is
+	}{
+		{
+			name: "primary limit waits until reset",
+			limited: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", fmt.Sprint(now.Add(30*time.Second).Unix()))
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantWait: 31 * time.Second,
+		},
+		{
+			name: "secondary limit honours Retry-After",
+			limited: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "5")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantWait: 5 * time.Second,
+		},
+		{
+			name: "secondary limit without Retry-After backs off a minute",
+			limited: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+			},
+			wantWait: time.Minute,
+		},
+		{
+			name: "permission errors aren't retried",
+			limited: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "4999")
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, `{"message": "Resource not accessible by integration"}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 1 {
+					tt.limited(w)
+					return
+				}
+				io.WriteString(w, "ok")
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			transport := &rateLimitTransport{
+				base: http.DefaultTransport,
+				now:  func() time.Time { return now },
+				sleep: func(ctx context.Context, d time.Duration) error {
+					waits = append(waits, d)
+					return nil
+				},
+			}
+			client := &http.Client{Transport: transport}
+
+			// A body, as GraphQL queries have, must be sent again on retry
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"query": "q"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if tt.wantWait == 0 {
+				if len(waits) != 0 || resp.StatusCode != http.StatusForbidden {
+					t.Errorf("Expected the 403 back without waiting, got status %d after waits %v", resp.StatusCode, waits)
+				}
+				if !strings.Contains(string(body), "not accessible") {
+					t.Errorf("Expected the error body to be passed on, got %q", body)
+				}
+				return
+			}
+			if len(waits) != 1 || waits[0] != tt.wantWait {
+				t.Errorf("Expected one wait of %v, got %v", tt.wantWait, waits)
+			}
+			if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+				t.Errorf("Expected the retry to succeed, got status %d: %q", resp.StatusCode, body)
+			}
+			if len(bodies) != 2 || bodies[1] != `{"query": "q"}` {
+				t.Errorf("Expected the request body to be sent again, got %q", bodies)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport_GivesUpEventually(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := &rateLimitTransport{
+		base:  http.DefaultTransport,
+		now:   time.Now,
+		sleep: func(ctx context.Context, d time.Duration) error { return nil },
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected the rate limit error to be passed on, got status %d", resp.StatusCode)
+	}
+	if requests != maxRateLimitRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", maxRateLimitRetries+1, requests)
+	}
+}
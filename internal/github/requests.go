@@ -0,0 +1,184 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// reviewRequests pairs each request for a review in a PR's events with the next
+// review answering it, to measure how long reviewers took to respond from when
+// they were asked rather than from when the PR was opened. A request to a person
+// is answered by their review; one to a team in org, by the first review from any
+// of its members, who are looked up with members. Requests made while the PR was
+// a draft count from when it was ready for review. A request withdrawn before it
+// was answered isn't counted, and one still waiting runs until the PR was merged
+// or closed, or now. Teams whose members can't be listed are skipped.
+func reviewRequests(pr *PullRequest, org string, readyAt time.Time, requested []*ReviewRequestEvent, reviews []reviewEvent, members func(team string) ([]string, bool), opts ProcessOptions) []ReviewRequestMetric {
+	type event struct {
+		at       time.Time
+		asked    string // for requests, the person asked, or "@team"
+		reviewer string // for reviews
+		kind     string // review_requested, review_request_removed or reviewed
+	}
+	var events []event
+	teamMembers := make(map[string][]string) // by "@team"
+	for _, e := range requested {
+		var asked string
+		switch {
+		case e.RequestedReviewer != nil:
+			if !opts.Users.IncludesReviewer(*e.RequestedReviewer) || e.RequestedReviewer.Login == pr.User.Login {
+				continue
+			}
+			asked = e.RequestedReviewer.Login
+		case e.RequestedTeam != nil:
+			asked = "@" + e.RequestedTeam.Slug
+			if _, ok := teamMembers[asked]; !ok {
+				m, ok := members(e.RequestedTeam.Slug)
+				if !ok {
+					continue
+				}
+				teamMembers[asked] = m
+			}
+		default:
+			continue
+		}
+		events = append(events, event{at: e.CreatedAt, asked: asked, kind: e.Event})
+	}
+	for _, r := range reviews {
+		events = append(events, event{at: r.at, reviewer: r.reviewer, kind: "reviewed"})
+	}
+	// A review submitted at the same moment as a request answers it
+	slices.SortStableFunc(events, func(a, b event) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return boolCompare(a.kind == "reviewed", b.kind == "reviewed")
+	})
+
+	// answers reports whether a review by reviewer answers a request to asked
+	answers := func(reviewer, asked string) bool {
+		if team, ok := teamMembers[asked]; ok {
+			return slices.ContainsFunc(team, func(member string) bool {
+				return strings.EqualFold(member, reviewer)
+			})
+		}
+		return reviewer == asked
+	}
+
+	var requests []ReviewRequestMetric
+	pending := make(map[string]int) // person or team to their unanswered request's index in requests
+	withdrawn := make(map[int]bool)
+	for _, e := range events {
+		i, waiting := pending[e.asked]
+		switch e.kind {
+		case "review_requested":
+			if waiting {
+				continue // asked again before responding; the first request still counts
+			}
+			requestedAt := e.at
+			if requestedAt.Before(readyAt) {
+				requestedAt = readyAt
+			}
+			request := ReviewRequestMetric{Reviewer: e.asked, RequestedAt: requestedAt}
+			if team, ok := strings.CutPrefix(e.asked, "@"); ok {
+				request = ReviewRequestMetric{Team: org + "/" + team, RequestedAt: requestedAt}
+			}
+			pending[e.asked] = len(requests)
+			requests = append(requests, request)
+		case "review_request_removed":
+			if waiting {
+				withdrawn[i] = true
+				delete(pending, e.asked)
+			}
+		case "reviewed":
+			for asked, i := range pending {
+				if !answers(e.reviewer, asked) {
+					continue
+				}
+				requests[i].Reviewer = e.reviewer
+				requests[i].Responded = true
+				requests[i].ResponseTime = opts.elapsed(requests[i].RequestedAt, e.at)
+				delete(pending, asked)
+			}
+		}
+	}
+
+	// Requests never answered have waited until the PR was done with, or now
+	end := time.Now()
+	if !pr.MergedAt.IsZero() {
+		end = pr.MergedAt
+	} else if !pr.ClosedAt.IsZero() {
+		end = pr.ClosedAt
+	}
+	for _, i := range pending {
+		requests[i].ResponseTime = opts.elapsed(requests[i].RequestedAt, end)
+	}
+
+	var kept []ReviewRequestMetric
+	for i, request := range requests {
+		if !withdrawn[i] {
+			kept = append(kept, request)
+		}
+	}
+	return kept
+}
+
+// reviewRequestsStage measures how long each requested reviewer or team took to
+// respond. Team memberships are looked up once per run.
+type reviewRequestsStage struct {
+	members map[string][]string // by org/team; nil if they couldn't be listed
+}
+
+func newReviewRequestsStage() *reviewRequestsStage {
+	return &reviewRequestsStage{members: make(map[string][]string)}
+}
+
+func (s *reviewRequestsStage) Enrich(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	events, err := pr.Client.FetchPullRequestReviewRequests(ctx, pr.Owner, pr.Repo, pr.PR.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch review requests: %w", err)
+	}
+
+	// Teams belong to the repository's organization
+	var membersErr error
+	members := func(team string) ([]string, bool) {
+		key := pr.Owner + "/" + team
+		if m, ok := s.members[key]; ok {
+			return m, m != nil
+		}
+		m, err := pr.Client.FetchTeamMembers(ctx, pr.Owner, team)
+		if errors.Is(err, budget.ErrExhausted) {
+			membersErr = err
+			return nil, false
+		}
+		if err != nil {
+			// Listing members needs the read:org scope
+			log.Printf("Could not list members of %s, leaving out its review requests: %v", key, err)
+		} else if m == nil {
+			m = []string{}
+		}
+		s.members[key] = m
+		return m, m != nil
+	}
+	metric.ReviewRequests = reviewRequests(pr.PR, pr.Owner, pr.ReadyAt, events, pr.reviews, members, pr.Options)
+	return membersErr
+}
+
+// boolCompare orders false before true
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
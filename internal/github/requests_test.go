@@ -0,0 +1,157 @@
+package github
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func requestEvent(event, reviewer string, at time.Time) *ReviewRequestEvent {
+	return &ReviewRequestEvent{Event: event, RequestedReviewer: &User{Login: reviewer}, CreatedAt: at}
+}
+
+func teamRequestEvent(event, team string, at time.Time) *ReviewRequestEvent {
+	return &ReviewRequestEvent{Event: event, RequestedTeam: &Team{Slug: team}, CreatedAt: at}
+}
+
+// noTeams is the members lookup for tests without team requests
+func noTeams(team string) ([]string, bool) {
+	return nil, false
+}
+
+func TestReviewRequests(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	mergedAt := createdAt.Add(48 * time.Hour)
+	pr := &PullRequest{
+		CreatedAt: createdAt,
+		MergedAt:  mergedAt,
+		User:      User{Login: "alice"},
+	}
+
+	requested := []*ReviewRequestEvent{
+		requestEvent("review_requested", "bob", createdAt),
+		requestEvent("review_requested", "carol", createdAt),
+		// Asked again before reviewing; the first request still counts
+		requestEvent("review_requested", "bob", createdAt.Add(2*time.Hour)),
+		// Dave was asked later in the PR's life, and answered quickly
+		requestEvent("review_requested", "dave", createdAt.Add(20*time.Hour)),
+		// Erin was asked and then removed
+		requestEvent("review_requested", "erin", createdAt.Add(time.Hour)),
+		requestEvent("review_request_removed", "erin", createdAt.Add(2*time.Hour)),
+		// Carol was asked again after her first review
+		requestEvent("review_requested", "carol", createdAt.Add(6*time.Hour)),
+	}
+	reviews := []reviewEvent{
+		{reviewer: "carol", state: "CHANGES_REQUESTED", at: createdAt.Add(4 * time.Hour)},
+		{reviewer: "bob", state: "COMMENTED", at: createdAt.Add(5 * time.Hour)},
+		{reviewer: "dave", state: "APPROVED", at: createdAt.Add(21 * time.Hour)},
+		// Erin reviewed anyway, without a request outstanding
+		{reviewer: "erin", state: "COMMENTED", at: createdAt.Add(3 * time.Hour)},
+	}
+
+	got := reviewRequests(pr, "owner", createdAt, requested, reviews, noTeams, ProcessOptions{})
+	want := []ReviewRequestMetric{
+		{Reviewer: "bob", RequestedAt: createdAt, Responded: true, ResponseTime: 5 * time.Hour},
+		{Reviewer: "carol", RequestedAt: createdAt, Responded: true, ResponseTime: 4 * time.Hour},
+		{Reviewer: "carol", RequestedAt: createdAt.Add(6 * time.Hour), ResponseTime: 42 * time.Hour},
+		{Reviewer: "dave", RequestedAt: createdAt.Add(20 * time.Hour), Responded: true, ResponseTime: time.Hour},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d requests, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i].Reviewer != want[i].Reviewer || !got[i].RequestedAt.Equal(want[i].RequestedAt) ||
+			got[i].Responded != want[i].Responded || got[i].ResponseTime != want[i].ResponseTime {
+			t.Errorf("Request %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestReviewRequests_DuringDraft(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	readyAt := createdAt.Add(3 * time.Hour)
+	pr := &PullRequest{CreatedAt: createdAt, User: User{Login: "alice"}}
+
+	requested := []*ReviewRequestEvent{requestEvent("review_requested", "bob", createdAt)}
+	reviews := []reviewEvent{{reviewer: "bob", state: "APPROVED", at: readyAt.Add(time.Hour)}}
+
+	got := reviewRequests(pr, "owner", readyAt, requested, reviews, noTeams, ProcessOptions{})
+	if len(got) != 1 || !got[0].RequestedAt.Equal(readyAt) || got[0].ResponseTime != time.Hour {
+		t.Errorf("Expected a request counted from when the PR left draft, answered after 1h, got %+v", got)
+	}
+}
+
+func TestReviewRequests_Teams(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	pr := &PullRequest{CreatedAt: createdAt, User: User{Login: "alice"}}
+
+	requested := []*ReviewRequestEvent{
+		teamRequestEvent("review_requested", "backend", createdAt),
+		teamRequestEvent("review_requested", "frontend", createdAt),
+		// Nobody can tell who's in the secret team, so its request isn't counted
+		teamRequestEvent("review_requested", "secret", createdAt),
+		requestEvent("review_requested", "bob", createdAt),
+	}
+	reviews := []reviewEvent{
+		// Bob answers both his own request and the backend team's
+		{reviewer: "bob", state: "COMMENTED", at: createdAt.Add(2 * time.Hour)},
+		{reviewer: "carol", state: "APPROVED", at: createdAt.Add(3 * time.Hour)},
+	}
+	members := func(team string) ([]string, bool) {
+		switch team {
+		case "backend":
+			return []string{"Bob", "carol"}, true
+		case "frontend":
+			return []string{"dave"}, true
+		}
+		return nil, false
+	}
+
+	got := reviewRequests(pr, "owner", createdAt, requested, reviews, members, ProcessOptions{})
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 requests, got %+v", got)
+	}
+	if got[0].Team != "owner/backend" || got[0].Reviewer != "bob" || !got[0].Responded || got[0].ResponseTime != 2*time.Hour {
+		t.Errorf("Expected the backend team's request answered by bob in 2h, got %+v", got[0])
+	}
+	if got[1].Team != "owner/frontend" || got[1].Reviewer != "" || got[1].Responded {
+		t.Errorf("Expected the frontend team's request unanswered, got %+v", got[1])
+	}
+	if got[2].Team != "" || got[2].Reviewer != "bob" || !got[2].Responded {
+		t.Errorf("Expected bob's own request answered, got %+v", got[2])
+	}
+}
+
+func TestProcessPullRequests_ReviewRequests(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	reviewedAt := createdAt.Add(30 * time.Hour)
+	pr := &PullRequest{
+		Number:    1,
+		CreatedAt: createdAt,
+		User:      User{Login: "alice"},
+	}
+	client := &MockGitHubClient{
+		reviews: []*Review{
+			{User: User{Login: "bob"}, State: "APPROVED", SubmittedAt: reviewedAt},
+		},
+		reviewRequests: []*ReviewRequestEvent{
+			requestEvent("review_requested", "bob", createdAt.Add(24*time.Hour)),
+			teamRequestEvent("review_requested", "backend", createdAt.Add(26*time.Hour)),
+		},
+		teamMembers: map[string][]string{"owner/backend": {"bob"}},
+	}
+
+	results := ProcessPullRequests(context.Background(), client, []*PullRequest{pr}, "owner", "repo", ProcessOptions{RequiredApprovals: 1, ReviewRequests: true})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	// Bob took 30h from the PR being opened, but only 6h from being asked, and
+	// answered for his team 4h after it was
+	requests := results[0].ReviewRequests
+	if len(requests) != 2 || !requests[0].Responded || requests[0].ResponseTime != 6*time.Hour {
+		t.Fatalf("Expected bob to have answered his request in 6h, got %+v", requests)
+	}
+	if requests[1].Team != "owner/backend" || requests[1].Reviewer != "bob" || requests[1].ResponseTime != 4*time.Hour {
+		t.Errorf("Expected bob to have answered the backend team's request in 4h, got %+v", requests[1])
+	}
+}
@@ -0,0 +1,58 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// firstResponseStage measures the time until someone other than the author
+// responded to the PR at all, whether by review, by a comment on its diff or by a
+// comment on its conversation. A question answered in the conversation is a
+// response even though it isn't a review.
+func firstResponseStage(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	reviewComments, err := pr.Client.FetchPullRequestComments(ctx, pr.Owner, pr.Repo, pr.PR.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch review comments: %w", err)
+	}
+	issueComments, err := pr.Client.FetchPullRequestIssueComments(ctx, pr.Owner, pr.Repo, pr.PR.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch issue comments: %w", err)
+	}
+	if at, responder, ok := firstResponse(pr.PR, pr.ReadyAt, pr.reviews, reviewComments, issueComments, pr.Options.Users); ok {
+		metric.TimeToFirstResponse = pr.Options.elapsed(pr.ReadyAt, at)
+		metric.FirstResponder = responder
+	}
+	return nil
+}
+
+// firstResponse returns when, and by whom, the PR was first responded to once it
+// was ready for review. Reviews have already been filtered by users; comments by
+// the author, or by users left out, don't count.
+func firstResponse(pr *PullRequest, readyAt time.Time, reviews []reviewEvent, reviewComments []*Comment, issueComments []*Comment, users UserFilter) (time.Time, string, bool) {
+	var first time.Time
+	var responder string
+	consider := func(at time.Time, who string) {
+		if at.Before(readyAt) {
+			return
+		}
+		if responder == "" || at.Before(first) {
+			first, responder = at, who
+		}
+	}
+
+	for _, r := range reviews {
+		consider(r.at, r.reviewer)
+	}
+	for _, c := range reviewComments {
+		if c.User.Login != pr.User.Login && users.IncludesReviewer(c.User) {
+			consider(c.CreatedAt, c.User.Login)
+		}
+	}
+	for _, c := range issueComments {
+		if c.User.Login != pr.User.Login && users.IncludesReviewer(c.User) {
+			consider(c.CreatedAt, c.User.Login)
+		}
+	}
+	return first, responder, responder != ""
+}
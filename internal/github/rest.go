@@ -0,0 +1,44 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// restAPI is the part of GitHub's REST API the client uses, in the package's own
+// types. Its go-github implementation, goGitHub, and the converters it uses are
+// the only code that depends on go-github, so upgrading or replacing go-github
+// means changing those and nothing else: the client keeps the budget, pagination
+// and error messages, and the metrics code and its tests only ever see the
+// package's types.
+//
+// Listing methods fetch one page, counting from 1, and return the next page, or 0
+// after the last one.
+type restAPI interface {
+	GetPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequest, error)
+	ListReviews(ctx context.Context, owner, repo string, number, page int) ([]*Review, int, error)
+	ListPullRequestCommits(ctx context.Context, owner, repo string, number, page int) ([]*Commit, int, error)
+	ListPullRequestFiles(ctx context.Context, owner, repo string, number, page int) ([]*File, int, error)
+	ListReviewComments(ctx context.Context, owner, repo string, number, page int) ([]*Comment, int, error)
+	ListIssueComments(ctx context.Context, owner, repo string, number, page int) ([]*Comment, int, error)
+	ListTimeline(ctx context.Context, owner, repo string, number, page int) ([]*TimelineEvent, int, error)
+	ListDismissals(ctx context.Context, owner, repo string, number, page int) ([]*DismissalEvent, int, error)
+
+	// RequiredApprovals returns how many approving reviews the branch's protection
+	// rules require, or errNotFound if the branch isn't protected
+	RequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error)
+	// FileContents returns a file's contents at ref, or errNotFound if there's no
+	// file at path
+	FileContents(ctx context.Context, owner, repo, path, ref string) (string, error)
+	ListTeamMembers(ctx context.Context, org, team string, page int) ([]string, int, error)
+	ListCommits(ctx context.Context, owner, repo string, since, until time.Time, page int) ([]*Commit, int, error)
+	GetCommit(ctx context.Context, owner, repo, sha string) (*Commit, error)
+
+	// Get decodes the JSON at an API path, such as repos/o/r/issues/1/events?page=2,
+	// into v, for endpoints and fields go-github doesn't support
+	Get(ctx context.Context, path string, v interface{}) (int, error)
+}
+
+// errNotFound is returned by restAPI methods for something that doesn't exist
+var errNotFound = errors.New("not found")
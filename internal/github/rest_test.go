@@ -0,0 +1,102 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// fakeREST serves commits and files from memory, a page at a time. Methods the
+// tests don't use panic through the nil embedded interface.
+type fakeREST struct {
+	restAPI
+	commits   [][]*Commit       // pages of commits
+	files     map[string]string // contents by path; missing paths are errNotFound
+	protected map[string]int    // required approvals by branch; missing branches aren't protected
+	pages     []int             // pages asked for, in order
+}
+
+func (f *fakeREST) ListPullRequestCommits(ctx context.Context, owner, repo string, number, page int) ([]*Commit, int, error) {
+	f.pages = append(f.pages, page)
+	if page > len(f.commits) {
+		return nil, 0, fmt.Errorf("no page %d", page)
+	}
+	next := page + 1
+	if next > len(f.commits) {
+		next = 0
+	}
+	return f.commits[page-1], next, nil
+}
+
+func (f *fakeREST) FileContents(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errNotFound, path)
+	}
+	return content, nil
+}
+
+func (f *fakeREST) RequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	required, ok := f.protected[branch]
+	if !ok {
+		return 0, errNotFound
+	}
+	return required, nil
+}
+
+func TestGitHubClient_Paginates(t *testing.T) {
+	api := &fakeREST{commits: [][]*Commit{
+		{{SHA: "a"}, {SHA: "b"}},
+		{{SHA: "c"}},
+	}}
+	client := &GitHubClient{api: api}
+
+	commits, err := client.FetchPullRequestCommits(context.Background(), "owner", "repo", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(commits) != 3 || commits[2].SHA != "c" {
+		t.Errorf("Expected commits a, b and c, got %+v", commits)
+	}
+	if len(api.pages) != 2 || api.pages[0] != 1 || api.pages[1] != 2 {
+		t.Errorf("Expected pages 1 and 2 to be fetched, got %v", api.pages)
+	}
+}
+
+func TestGitHubClient_SpendsBudgetPerPage(t *testing.T) {
+	api := &fakeREST{commits: [][]*Commit{{{SHA: "a"}}, {{SHA: "b"}}}}
+	client := &GitHubClient{api: api}
+	b := budget.New("github", 1)
+	client.SetBudget(b)
+
+	if _, err := client.FetchPullRequestCommits(context.Background(), "owner", "repo", 1); err == nil {
+		t.Fatal("Expected the budget to run out on the second page")
+	}
+	if len(api.pages) != 1 {
+		t.Errorf("Expected only the first page to be fetched, got %v", api.pages)
+	}
+}
+
+func TestGitHubClient_NotFound(t *testing.T) {
+	api := &fakeREST{
+		files:     map[string]string{"CODEOWNERS": "* @owners"},
+		protected: map[string]int{"main": 2},
+	}
+	client := &GitHubClient{api: api}
+	ctx := context.Background()
+
+	// .github/CODEOWNERS is missing, so the next location is used
+	codeowners, err := client.FetchCodeowners(ctx, "owner", "repo", "main")
+	if err != nil || codeowners != "* @owners" {
+		t.Errorf("Expected the root CODEOWNERS file, got %q, %v", codeowners, err)
+	}
+
+	if required, err := client.FetchRequiredApprovals(ctx, "owner", "repo", "main"); err != nil || required != 2 {
+		t.Errorf("Expected 2 required approvals on main, got %d, %v", required, err)
+	}
+	if required, err := client.FetchRequiredApprovals(ctx, "owner", "repo", "feature"); err != nil || required != 0 {
+		t.Errorf("Expected no required approvals on an unprotected branch, got %d, %v", required, err)
+	}
+}
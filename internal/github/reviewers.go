@@ -0,0 +1,163 @@
+package github
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// ReviewerStats summarizes one reviewer's review activity across PRs
+type ReviewerStats struct {
+	Reviewer           string
+	Reviews            int           // Reviews submitted, including repeat reviews of the same PR
+	PRsReviewed        int           // Distinct PRs reviewed
+	PRsApproved        int           // Distinct PRs the reviewer approved
+	MedianResponseTime time.Duration // Median time from PR creation to the reviewer's first review of it
+
+	// With ProcessOptions.ReviewRequests, how the reviewer answered requests for their review
+	RequestsReceived          int
+	RequestsAnswered          int
+	MedianRequestResponseTime time.Duration // Median time from a request to the reviewer's review, of those answered
+}
+
+// ApprovalRate is the fraction of the PRs the reviewer reviewed that they approved
+func (s ReviewerStats) ApprovalRate() float64 {
+	if s.PRsReviewed == 0 {
+		return 0
+	}
+	return float64(s.PRsApproved) / float64(s.PRsReviewed)
+}
+
+// SummarizeReviewers aggregates review activity per reviewer, busiest reviewers first,
+// to show how review load is spread across the team
+func SummarizeReviewers(results []PullRequestMetric) []ReviewerStats {
+	byReviewer := make(map[string]*ReviewerStats)
+	responseTimes := make(map[string][]time.Duration)
+	requestResponseTimes := make(map[string][]time.Duration)
+	reviewerStats := func(reviewer string) *ReviewerStats {
+		stats, ok := byReviewer[reviewer]
+		if !ok {
+			stats = &ReviewerStats{Reviewer: reviewer}
+			byReviewer[reviewer] = stats
+		}
+		return stats
+	}
+
+	for _, result := range results {
+		approved := make(map[string]bool)
+		seen := make(map[string]bool)
+		for _, review := range result.Reviews {
+			stats := reviewerStats(review.Reviewer)
+			stats.Reviews++
+
+			// Reviews are in submission order, so the first one seen is the response
+			if !seen[review.Reviewer] {
+				seen[review.Reviewer] = true
+				stats.PRsReviewed++
+				responseTimes[review.Reviewer] = append(responseTimes[review.Reviewer], review.TimeToReview)
+			}
+			if review.State == "APPROVED" && !approved[review.Reviewer] {
+				approved[review.Reviewer] = true
+				stats.PRsApproved++
+			}
+		}
+
+		// Reviewers who were asked but never reviewed are listed too. Requests
+		// to teams are the team's, not the member's who answered.
+		for _, request := range result.ReviewRequests {
+			if request.Team != "" {
+				continue
+			}
+			stats := reviewerStats(request.Reviewer)
+			stats.RequestsReceived++
+			if request.Responded {
+				stats.RequestsAnswered++
+				requestResponseTimes[request.Reviewer] = append(requestResponseTimes[request.Reviewer], request.ResponseTime)
+			}
+		}
+	}
+
+	var summaries []ReviewerStats
+	for reviewer, s := range byReviewer {
+		s.MedianResponseTime = stats.MedianOf(responseTimes[reviewer])
+		s.MedianRequestResponseTime = stats.MedianOf(requestResponseTimes[reviewer])
+		summaries = append(summaries, *s)
+	}
+
+	slices.SortFunc(summaries, func(a, b ReviewerStats) int {
+		if c := cmp.Compare(b.Reviews, a.Reviews); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Reviewer, b.Reviewer)
+	})
+	return summaries
+}
+
+// TeamStats summarizes how a team answered requests for its review across PRs
+type TeamStats struct {
+	Team               string // org/team
+	RequestsReceived   int
+	RequestsAnswered   int
+	MedianResponseTime time.Duration // Median time from a request to the first review by a member, of those answered
+	Responders         []string      // Members who answered for the team, most often first
+}
+
+// AnswerRate is the fraction of the team's review requests that a member answered
+func (s TeamStats) AnswerRate() float64 {
+	if s.RequestsReceived == 0 {
+		return 0
+	}
+	return float64(s.RequestsAnswered) / float64(s.RequestsReceived)
+}
+
+// SummarizeTeams aggregates the review requests made to teams per team, with
+// ProcessOptions.ReviewRequests, to hold teams to a review SLA. Teams are listed
+// by name.
+func SummarizeTeams(results []PullRequestMetric) []TeamStats {
+	byTeam := make(map[string]*TeamStats)
+	responseTimes := make(map[string][]time.Duration)
+	answered := make(map[string]map[string]int) // by team, then member
+
+	for _, result := range results {
+		for _, request := range result.ReviewRequests {
+			if request.Team == "" {
+				continue
+			}
+			stats, ok := byTeam[request.Team]
+			if !ok {
+				stats = &TeamStats{Team: request.Team}
+				byTeam[request.Team] = stats
+				answered[request.Team] = make(map[string]int)
+			}
+			stats.RequestsReceived++
+			if request.Responded {
+				stats.RequestsAnswered++
+				responseTimes[request.Team] = append(responseTimes[request.Team], request.ResponseTime)
+				answered[request.Team][request.Reviewer]++
+			}
+		}
+	}
+
+	var summaries []TeamStats
+	for team, s := range byTeam {
+		s.MedianResponseTime = stats.MedianOf(responseTimes[team])
+		counts := answered[team]
+		for member := range counts {
+			s.Responders = append(s.Responders, member)
+		}
+		slices.SortFunc(s.Responders, func(a, b string) int {
+			if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+				return c
+			}
+			return cmp.Compare(a, b)
+		})
+		summaries = append(summaries, *s)
+	}
+
+	slices.SortFunc(summaries, func(a, b TeamStats) int {
+		return cmp.Compare(a.Team, b.Team)
+	})
+	return summaries
+}
@@ -0,0 +1,123 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeReviewers(t *testing.T) {
+	results := []PullRequestMetric{
+		{
+			PRNumber: 1,
+			Reviews: []ReviewMetric{
+				{Reviewer: "carol", State: "CHANGES_REQUESTED", TimeToReview: 1 * time.Hour},
+				{Reviewer: "dave", State: "COMMENTED", TimeToReview: 2 * time.Hour},
+				{Reviewer: "carol", State: "APPROVED", TimeToReview: 5 * time.Hour},
+			},
+		},
+		{
+			PRNumber: 2,
+			Reviews: []ReviewMetric{
+				{Reviewer: "carol", State: "COMMENTED", TimeToReview: 3 * time.Hour},
+			},
+		},
+	}
+
+	stats := SummarizeReviewers(results)
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 reviewers, got %d", len(stats))
+	}
+
+	carol := stats[0]
+	if carol.Reviewer != "carol" || carol.Reviews != 3 || carol.PRsReviewed != 2 || carol.PRsApproved != 1 {
+		t.Errorf("Expected carol with 3 reviews of 2 PRs, 1 approved, got %+v", carol)
+	}
+	// First responses were after 1h and 3h
+	if carol.MedianResponseTime != 2*time.Hour {
+		t.Errorf("Expected carol's median response time to be 2h, got %v", carol.MedianResponseTime)
+	}
+	if carol.ApprovalRate() != 0.5 {
+		t.Errorf("Expected carol's approval rate to be 0.5, got %v", carol.ApprovalRate())
+	}
+
+	dave := stats[1]
+	if dave.Reviewer != "dave" || dave.PRsApproved != 0 || dave.ApprovalRate() != 0 {
+		t.Errorf("Expected dave with no approvals, got %+v", dave)
+	}
+}
+
+func TestSummarizeReviewers_ReviewRequests(t *testing.T) {
+	results := []PullRequestMetric{
+		{
+			PRNumber: 1,
+			Reviews:  []ReviewMetric{{Reviewer: "carol", State: "APPROVED", TimeToReview: 9 * time.Hour}},
+			ReviewRequests: []ReviewRequestMetric{
+				{Reviewer: "carol", Responded: true, ResponseTime: 1 * time.Hour},
+				{Reviewer: "dave", ResponseTime: 30 * time.Hour},
+			},
+		},
+		{
+			PRNumber:       2,
+			Reviews:        []ReviewMetric{{Reviewer: "carol", State: "COMMENTED", TimeToReview: 5 * time.Hour}},
+			ReviewRequests: []ReviewRequestMetric{{Reviewer: "carol", Responded: true, ResponseTime: 3 * time.Hour}},
+		},
+	}
+
+	stats := SummarizeReviewers(results)
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 reviewers, got %d", len(stats))
+	}
+	carol := stats[0]
+	if carol.RequestsReceived != 2 || carol.RequestsAnswered != 2 || carol.MedianRequestResponseTime != 2*time.Hour {
+		t.Errorf("Expected carol to have answered 2/2 requests in a median 2h, got %+v", carol)
+	}
+	// Dave never reviewed, but is listed for the request he didn't answer
+	dave := stats[1]
+	if dave.Reviewer != "dave" || dave.Reviews != 0 || dave.RequestsReceived != 1 || dave.RequestsAnswered != 0 {
+		t.Errorf("Expected dave with 1 unanswered request, got %+v", dave)
+	}
+}
+
+func TestSummarizeTeams(t *testing.T) {
+	results := []PullRequestMetric{
+		{
+			PRNumber: 1,
+			ReviewRequests: []ReviewRequestMetric{
+				{Reviewer: "carol", Team: "org/backend", Responded: true, ResponseTime: 1 * time.Hour},
+				{Reviewer: "carol", Responded: true, ResponseTime: 1 * time.Hour},
+				{Team: "org/frontend", ResponseTime: 30 * time.Hour},
+			},
+		},
+		{
+			PRNumber: 2,
+			ReviewRequests: []ReviewRequestMetric{
+				{Reviewer: "dave", Team: "org/backend", Responded: true, ResponseTime: 5 * time.Hour},
+				{Reviewer: "carol", Team: "org/backend", Responded: true, ResponseTime: 3 * time.Hour},
+			},
+		},
+	}
+
+	stats := SummarizeTeams(results)
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 teams, got %+v", stats)
+	}
+	backend := stats[0]
+	if backend.Team != "org/backend" || backend.RequestsReceived != 3 || backend.RequestsAnswered != 3 || backend.MedianResponseTime != 3*time.Hour {
+		t.Errorf("Expected backend to have answered 3/3 requests in a median 3h, got %+v", backend)
+	}
+	if len(backend.Responders) != 2 || backend.Responders[0] != "carol" || backend.Responders[1] != "dave" {
+		t.Errorf("Expected carol to have answered for backend most often, then dave, got %v", backend.Responders)
+	}
+	frontend := stats[1]
+	if frontend.Team != "org/frontend" || frontend.RequestsAnswered != 0 || frontend.AnswerRate() != 0 {
+		t.Errorf("Expected frontend with no answered requests, got %+v", frontend)
+	}
+
+	// Carol's own request counts for her; the ones she answered for the team don't
+	if reviewers := SummarizeReviewers(results); len(reviewers) != 1 || reviewers[0].RequestsReceived != 1 {
+		t.Errorf("Expected carol with only her own request, got %+v", reviewers)
+	}
+}
@@ -0,0 +1,213 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxSearchResults is the most results GitHub's search returns for one query,
+// however many pages are asked for
+const maxSearchResults = 1000
+
+// pullRequestSearchQuery searches for PRs, 100 at a time, with the fields
+// processing needs. The search API takes the same qualifiers as GitHub's search
+// box, so the date range is part of the query rather than filtered client-side.
+const pullRequestSearchQuery = `
+query($query: String!, $cursor: String) {
+  search(query: $query, type: ISSUE, first: 100, after: $cursor) {
+    issueCount
+    pageInfo { hasNextPage endCursor }
+    nodes {
+      ... on PullRequest {
+        number
+        title
+        url
+        state
+        isDraft
+        createdAt
+        closedAt
+        mergedAt
+        baseRefName
+        headRefName
+        additions
+        deletions
+        changedFiles
+        author { __typename login }
+        labels(first: 100) { nodes { name } }
+      }
+    }
+  }
+}`
+
+type pullRequestSearchResponse struct {
+	Search struct {
+		IssueCount int `json:"issueCount"`
+		PageInfo   struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []pullRequestNode `json:"nodes"`
+	} `json:"search"`
+}
+
+type pullRequestNode struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	State        string     `json:"state"`
+	IsDraft      bool       `json:"isDraft"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ClosedAt     *time.Time `json:"closedAt"`
+	MergedAt     *time.Time `json:"mergedAt"`
+	BaseRefName  string     `json:"baseRefName"`
+	HeadRefName  string     `json:"headRefName"`
+	Additions    int        `json:"additions"`
+	Deletions    int        `json:"deletions"`
+	ChangedFiles int        `json:"changedFiles"`
+	Author       *struct {
+		Typename string `json:"__typename"`
+		Login    string `json:"login"`
+	} `json:"author"` // nil for deleted users
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+}
+
+// FetchPullRequests fetches PRs created in the date range, newest first. It uses
+// the search API, so only the PRs in the range are fetched, however much history
+// the repository has. If the API budget runs out part way through, the PRs fetched
+// so far are returned along with the error.
+func (c *GitHubClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	return c.searchPullRequests(ctx, owner, repo, "", "created", startDate, endDate, nil)
+}
+
+// githubLaunch is before any PR on GitHub was created
+var githubLaunch = time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// FetchOpenPullRequests fetches the PRs open now, however long ago they were
+// created, newest first
+func (c *GitHubClient) FetchOpenPullRequests(ctx context.Context, owner, repo string) ([]*PullRequest, error) {
+	return c.searchPullRequests(ctx, owner, repo, "is:open", "created", githubLaunch, time.Now(), nil)
+}
+
+// FetchMergedPullRequests fetches the PRs merged in the date range, however long
+// before it they were created, newest first
+func (c *GitHubClient) FetchMergedPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	return c.searchPullRequests(ctx, owner, repo, "is:merged", "merged", startDate, endDate, nil)
+}
+
+// pullRequestCountQuery counts the PRs a search matches, without listing any
+const pullRequestCountQuery = `
+query($query: String!) {
+  search(query: $query, type: ISSUE, first: 1) {
+    issueCount
+  }
+}`
+
+// CountMergedPullRequests counts the PRs ever merged in a repository. It's one
+// API call however many there are, since the search API reports how many PRs
+// match without them being listed.
+func (c *GitHubClient) CountMergedPullRequests(ctx context.Context, owner, repo string) (int, error) {
+	variables := map[string]interface{}{
+		"query": fmt.Sprintf("repo:%s/%s is:pr is:merged", owner, repo),
+	}
+	var resp pullRequestSearchResponse
+	if err := c.graphQL(ctx, pullRequestCountQuery, variables, &resp); err != nil {
+		return 0, fmt.Errorf("failed to count merged pull requests: %w", err)
+	}
+	return resp.Search.IssueCount, nil
+}
+
+// searchPullRequests appends the PRs whose dates (created or merged) are in the
+// date range, and matching any extra search qualifiers, to prs. Search results
+// stop at maxSearchResults, so busier ranges are split in two and each half
+// searched separately, newer half first.
+func (c *GitHubClient) searchPullRequests(ctx context.Context, owner, repo, qualifiers, dates string, startDate, endDate time.Time, prs []*PullRequest) ([]*PullRequest, error) {
+	variables := map[string]interface{}{
+		"query":  pullRequestSearch(owner, repo, qualifiers, dates, startDate, endDate),
+		"cursor": nil,
+	}
+	for {
+		var resp pullRequestSearchResponse
+		if err := c.graphQL(ctx, pullRequestSearchQuery, variables, &resp); err != nil {
+			return prs, fmt.Errorf("failed to search pull requests: %w", err)
+		}
+
+		page := resp.Search
+		if page.IssueCount > maxSearchResults && endDate.Sub(startDate) > time.Second {
+			mid := startDate.Add(endDate.Sub(startDate) / 2).Truncate(time.Second)
+			prs, err := c.searchPullRequests(ctx, owner, repo, qualifiers, dates, mid.Add(time.Second), endDate, prs)
+			if err != nil {
+				return prs, err
+			}
+			return c.searchPullRequests(ctx, owner, repo, qualifiers, dates, startDate, mid, prs)
+		}
+
+		for _, node := range page.Nodes {
+			if node.Number == 0 {
+				continue // not a PR
+			}
+			prs = append(prs, node.pullRequest())
+		}
+
+		if !page.PageInfo.HasNextPage {
+			return prs, nil
+		}
+		variables["cursor"] = page.PageInfo.EndCursor
+	}
+}
+
+// pullRequestSearch returns the search query for a repository's PRs whose dates
+// (created or merged) are in the date range and matching qualifiers, if any,
+// newest first. Both ends of a range are inclusive.
+func pullRequestSearch(owner, repo, qualifiers, dates string, startDate, endDate time.Time) string {
+	const layout = "2006-01-02T15:04:05Z"
+	if qualifiers != "" {
+		qualifiers += " "
+	}
+	return fmt.Sprintf("repo:%s/%s is:pr %s%s:%s..%s sort:created-desc",
+		owner, repo, qualifiers, dates, startDate.UTC().Format(layout), endDate.UTC().Format(layout))
+}
+
+// pullRequest converts a search result to the form of a PR the rest of
+// processing works with, which follows the REST API's
+func (n *pullRequestNode) pullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number:       n.Number,
+		Title:        n.Title,
+		State:        "closed",
+		Draft:        n.IsDraft,
+		CreatedAt:    n.CreatedAt,
+		Base:         Branch{Ref: n.BaseRefName},
+		Head:         Branch{Ref: n.HeadRefName},
+		Additions:    n.Additions,
+		Deletions:    n.Deletions,
+		ChangedFiles: n.ChangedFiles,
+	}
+	if n.ClosedAt != nil {
+		pr.ClosedAt = *n.ClosedAt
+	}
+	if n.MergedAt != nil {
+		pr.MergedAt = *n.MergedAt
+	}
+	if n.State == "OPEN" {
+		pr.State = "open"
+	}
+	if n.Author != nil {
+		// The REST API names apps' accounts with a [bot] suffix, which the GraphQL
+		// API leaves off, and exclude lists and identities are written with it
+		login := n.Author.Login
+		if n.Author.Typename == "Bot" && !strings.HasSuffix(login, "[bot]") {
+			login += "[bot]"
+		}
+		pr.User = User{Login: login, Type: n.Author.Typename}
+	}
+	for _, label := range n.Labels.Nodes {
+		pr.Labels = append(pr.Labels, Label{Name: label.Name})
+	}
+	return pr
+}
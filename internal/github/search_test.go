@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// graphQLHandlerTransport serves GraphQL requests from handler, whatever their URL
+type graphQLHandlerTransport struct {
+	handler http.HandlerFunc
+}
+
+func (t graphQLHandlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler(rec, req)
+	return rec.Result(), nil
+}
+
+func TestFetchPullRequests_Search(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	mid := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	var queries []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				Query  string  `json:"query"`
+				Cursor *string `json:"cursor"`
+			} `json:"variables"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("Bad GraphQL request: %v", err)
+		}
+		query := req.Variables.Query
+		queries = append(queries, query)
+
+		var resp string
+		switch {
+		case query == pullRequestSearch("owner", "repo", "", "created", start, end):
+			// Too many results for one search, so the range has to be split
+			resp = `{"issueCount": 1500, "pageInfo": {"hasNextPage": true, "endCursor": "x"}, "nodes": []}`
+		case query == pullRequestSearch("owner", "repo", "", "created", mid.Add(time.Second), end) && req.Variables.Cursor == nil:
+			resp = `{"issueCount": 800, "pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+				{"number": 3, "title": "Newest", "state": "OPEN", "createdAt": "2024-03-30T00:00:00Z", "baseRefName": "main",
+				 "author": {"__typename": "User", "login": "octocat"}, "labels": {"nodes": [{"name": "hotfix"}]}}]}`
+		case query == pullRequestSearch("owner", "repo", "", "created", mid.Add(time.Second), end):
+			resp = `{"issueCount": 800, "pageInfo": {"hasNextPage": false}, "nodes": [
+				{"number": 2, "title": "Merged", "state": "MERGED", "createdAt": "2024-03-20T00:00:00Z",
+				 "mergedAt": "2024-03-21T00:00:00Z", "closedAt": "2024-03-21T00:00:00Z", "baseRefName": "main", "headRefName": "fix",
+				 "additions": 10, "deletions": 2, "changedFiles": 1, "author": {"__typename": "Bot", "login": "dependabot"}}]}`
+		case query == pullRequestSearch("owner", "repo", "", "created", start, mid):
+			resp = `{"issueCount": 700, "pageInfo": {"hasNextPage": false}, "nodes": [
+				{"number": 1, "title": "Oldest", "state": "CLOSED", "createdAt": "2024-03-02T00:00:00Z", "closedAt": "2024-03-03T00:00:00Z"}]}`
+		default:
+			t.Errorf("Unexpected search %q", query)
+		}
+		w.Write([]byte(`{"data": {"search": ` + resp + `}}`))
+	}
+	client := &GitHubClient{httpClient: &http.Client{Transport: graphQLHandlerTransport{handler}}}
+
+	prs, err := client.FetchPullRequests(context.Background(), "owner", "repo", start, end)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(queries) != 4 {
+		t.Errorf("Expected 4 searches, got %d: %q", len(queries), queries)
+	}
+	if len(prs) != 3 || prs[0].Number != 3 || prs[1].Number != 2 || prs[2].Number != 1 {
+		t.Fatalf("Expected PRs 3, 2 and 1, newest first, got %v", prs)
+	}
+
+	newest, merged, closed := prs[0], prs[1], prs[2]
+	if newest.State != "open" || newest.User.Login != "octocat" || !HasAnyLabel(newest, []string{"hotfix"}) {
+		t.Errorf("Unexpected open PR %v", newest)
+	}
+	if merged.State != "closed" || merged.MergedAt.IsZero() || merged.Base.Ref != "main" || merged.Head.Ref != "fix" {
+		t.Errorf("Unexpected merged PR %v", merged)
+	}
+	if merged.ChangedFiles != 1 || merged.Additions != 10 || merged.Deletions != 2 {
+		t.Errorf("Expected the merged PR's size, got %v", merged)
+	}
+	if merged.User.Login != "dependabot[bot]" || !(UserFilter{}).IsBot(merged.User) {
+		t.Errorf("Expected the merged PR's author to be the dependabot[bot] app, got %v", merged.User)
+	}
+	if closed.State != "closed" || !closed.MergedAt.IsZero() || closed.User.Login != "" {
+		t.Errorf("Unexpected closed PR %v", closed)
+	}
+}
+
+func TestPullRequestSearch(t *testing.T) {
+	toronto := time.FixedZone("EST", -5*60*60)
+	got := pullRequestSearch("owner", "repo", "", "created", time.Date(2024, 1, 1, 0, 0, 0, 0, toronto), time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC))
+	want := "repo:owner/repo is:pr created:2024-01-01T05:00:00Z..2024-01-31T12:30:00Z sort:created-desc"
+	if got != want {
+		t.Errorf("pullRequestSearch() = %q, want %q", got, want)
+	}
+
+	got = pullRequestSearch("owner", "repo", "is:open", "created", time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC))
+	want = "repo:owner/repo is:pr is:open created:2008-01-01T00:00:00Z..2024-01-31T12:30:00Z sort:created-desc"
+	if got != want {
+		t.Errorf("pullRequestSearch() = %q, want %q", got, want)
+	}
+
+	got = pullRequestSearch("owner", "repo", "is:merged", "merged", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC))
+	want = "repo:owner/repo is:pr is:merged merged:2024-01-01T00:00:00Z..2024-01-08T00:00:00Z sort:created-desc"
+	if got != want {
+		t.Errorf("pullRequestSearch() = %q, want %q", got, want)
+	}
+}
+
+func TestCountMergedPullRequests(t *testing.T) {
+	var query string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				Query string `json:"query"`
+			} `json:"variables"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("Bad GraphQL request: %v", err)
+		}
+		query = req.Variables.Query
+		w.Write([]byte(`{"data": {"search": {"issueCount": 1234}}}`))
+	}
+	client := &GitHubClient{httpClient: &http.Client{Transport: graphQLHandlerTransport{handler}}}
+
+	count, err := client.CountMergedPullRequests(context.Background(), "owner", "repo")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if count != 1234 {
+		t.Errorf("Expected 1234 merged PRs, got %d", count)
+	}
+	if want := "repo:owner/repo is:pr is:merged"; query != want {
+		t.Errorf("Expected the search %q, got %q", want, query)
+	}
+}
@@ -0,0 +1,42 @@
+package github
+
+// PR size buckets by lines changed (additions plus deletions), smallest first
+const (
+	SizeXS = "XS" // Under 10 lines
+	SizeS  = "S"  // Under 50 lines
+	SizeM  = "M"  // Under 250 lines
+	SizeL  = "L"  // Under 1000 lines
+	SizeXL = "XL" // 1000 lines or more
+)
+
+var sizeRank = map[string]int{SizeXS: 0, SizeS: 1, SizeM: 2, SizeL: 3, SizeXL: 4}
+
+// sizeBucket returns the size bucket for a PR changing lines lines
+func sizeBucket(lines int) string {
+	switch {
+	case lines < 10:
+		return SizeXS
+	case lines < 50:
+		return SizeS
+	case lines < 250:
+		return SizeM
+	case lines < 1000:
+		return SizeL
+	default:
+		return SizeXL
+	}
+}
+
+// prSize returns the lines added and deleted and the files changed by a PR. PRs
+// fetched individually carry these counts; PRs from a listing don't, so they're
+// added up from the PR's files instead.
+func prSize(pr *PullRequest, files []*File) (additions, deletions, changedFiles int) {
+	if pr.ChangedFiles > 0 {
+		return pr.Additions, pr.Deletions, pr.ChangedFiles
+	}
+	for _, file := range files {
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+	return additions, deletions, len(files)
+}
@@ -0,0 +1,44 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestSizeBucket(t *testing.T) {
+	tests := []struct {
+		lines int
+		want  string
+	}{
+		{0, SizeXS},
+		{9, SizeXS},
+		{10, SizeS},
+		{249, SizeM},
+		{250, SizeL},
+		{999, SizeL},
+		{5000, SizeXL},
+	}
+	for _, tt := range tests {
+		if got := sizeBucket(tt.lines); got != tt.want {
+			t.Errorf("sizeBucket(%d) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestPRSize(t *testing.T) {
+	files := []*File{
+		{Filename: "a.go", Additions: 10, Deletions: 2},
+		{Filename: "b.go", Additions: 5},
+	}
+
+	additions, deletions, changedFiles := prSize(&PullRequest{}, files)
+	if additions != 15 || deletions != 2 || changedFiles != 2 {
+		t.Errorf("Expected 15 additions, 2 deletions and 2 files from the file list, got %d, %d and %d", additions, deletions, changedFiles)
+	}
+
+	// Counts on the PR itself win, since the file list is capped
+	pr := &PullRequest{Additions: 4000, Deletions: 100, ChangedFiles: 3500}
+	additions, deletions, changedFiles = prSize(pr, files)
+	if additions != 4000 || deletions != 100 || changedFiles != 3500 {
+		t.Errorf("Expected the PR's own counts, got %d, %d and %d", additions, deletions, changedFiles)
+	}
+}
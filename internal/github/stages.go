@@ -0,0 +1,141 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stage adds metrics to a PR's result once its review metrics are known. Stages
+// run in order, so a stage can build on fields set by earlier ones, as review depth
+// builds on size. A stage that can't get what it needs sets what it can and returns
+// the error, which is logged; an error wrapping budget.ErrExhausted stops processing.
+type Stage interface {
+	Enrich(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error
+}
+
+// StageFunc adapts a function to a Stage
+type StageFunc func(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error
+
+func (f StageFunc) Enrich(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	return f(ctx, pr, metric)
+}
+
+// PullRequestContext is what stages can use about the PR being processed. Data
+// fetched for one stage, such as the PR's files, is shared with later ones.
+type PullRequestContext struct {
+	PR          *PullRequest
+	Owner, Repo string
+	ReadyAt     time.Time // When the PR was ready for review, which review times run from
+	Client      GitHubClientInterface
+	Options     ProcessOptions
+
+	reviews      []reviewEvent
+	approvals    []reviewEvent
+	files        []*File
+	filesErr     error
+	filesFetched bool
+}
+
+// Files returns the files the PR changes, fetching them the first time they're asked for
+func (c *PullRequestContext) Files(ctx context.Context) ([]*File, error) {
+	if !c.filesFetched {
+		c.files, c.filesErr = c.Client.FetchPullRequestFiles(ctx, c.Owner, c.Repo, c.PR.Number)
+		c.filesFetched = true
+	}
+	return c.files, c.filesErr
+}
+
+// pullRequestStages returns the stages opts turns on, in the order they run,
+// followed by opts.Stages. Stages with per-run state, like the CODEOWNERS files
+// already read, are created fresh for each run.
+func pullRequestStages(client GitHubClientInterface, owner, repo string, opts ProcessOptions) []Stage {
+	var stages []Stage
+	if opts.Codeowners {
+		stages = append(stages, &codeownersStage{resolver: newCodeownersResolver(client, owner, repo)})
+	}
+	if opts.Languages {
+		stages = append(stages, StageFunc(languageStage))
+	}
+	if opts.Classify {
+		stages = append(stages, StageFunc(classifyStage))
+	}
+	if opts.Size {
+		stages = append(stages, StageFunc(sizeStage))
+	}
+	if opts.Comments {
+		stages = append(stages, StageFunc(commentsStage))
+	}
+	if opts.FirstResponse {
+		stages = append(stages, StageFunc(firstResponseStage))
+	}
+	if opts.ReviewRequests {
+		stages = append(stages, newReviewRequestsStage())
+	}
+	if opts.TagsOwner != "" && opts.TagsRepo != "" {
+		stages = append(stages, StageFunc(tagCommitsStage))
+	}
+	return append(stages, opts.Stages...)
+}
+
+// codeownersStage measures the time until the owners of the code the PR touches approved
+type codeownersStage struct {
+	resolver *codeownersResolver
+}
+
+func (s *codeownersStage) Enrich(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	var err error
+	metric.CodeownerApprovals, metric.TimeToCodeownerApproval, err = s.resolver.approvals(ctx, pr.PR, pr.ReadyAt, pr.Options, pr.approvals, pr.Files)
+	if err != nil {
+		return fmt.Errorf("failed to check CODEOWNERS: %w", err)
+	}
+	return nil
+}
+
+// languageStage classifies the PR by the language most of its changes are in
+func languageStage(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	files, err := pr.Files(ctx)
+	metric.DominantLanguage = dominantLanguage(files)
+	return err
+}
+
+// classifyStage classifies the PR by the kind of files it changes
+func classifyStage(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	files, err := pr.Files(ctx)
+	metric.Class = classifyFiles(files)
+	return err
+}
+
+// sizeStage measures the lines and files the PR changes. Listed PRs don't carry
+// their size, so that needs the files.
+func sizeStage(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	var files []*File
+	var err error
+	if pr.PR.ChangedFiles == 0 {
+		files, err = pr.Files(ctx)
+	}
+	metric.Additions, metric.Deletions, metric.ChangedFiles = prSize(pr.PR, files)
+	metric.Size = sizeBucket(metric.Additions + metric.Deletions)
+	return err
+}
+
+// commentsStage counts what reviewers had to say, to tell substantive review from
+// rubber stamps. Review depth needs the size stage to have run first.
+func commentsStage(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	comments, err := pr.Client.FetchPullRequestComments(ctx, pr.Owner, pr.Repo, pr.PR.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch review comments: %w", err)
+	}
+	metric.CommentsCounted = true
+	metric.ReviewComments = countReviewComments(pr.PR, comments, pr.Options.Users)
+	if pr.Options.Size {
+		metric.ReviewDepth = reviewDepth(metric.ReviewComments, metric.Additions+metric.Deletions)
+	}
+	return nil
+}
+
+// tagCommitsStage finds the PR's commits in the tags repository
+func tagCommitsStage(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+	metric.TagCommits = checkPRTagCommits(ctx, pr.Client, pr.PR, pr.Options.TagsOwner, pr.Options.TagsRepo)
+	return nil
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// countingFilesClient counts how often a PR's files are fetched
+type countingFilesClient struct {
+	MockGitHubClient
+	fileCalls int
+}
+
+func (c *countingFilesClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*File, error) {
+	c.fileCalls++
+	return c.MockGitHubClient.FetchPullRequestFiles(ctx, owner, repo, prNumber)
+}
+
+func TestProcessPullRequests_Stages(t *testing.T) {
+	createdAt := time.Now().Add(-2 * time.Hour)
+	author := User{Login: "author"}
+	prs := []*PullRequest{
+		{Number: 1, User: author, State: "open", CreatedAt: createdAt},
+		{Number: 2, User: author, State: "open", CreatedAt: createdAt},
+	}
+	client := &countingFilesClient{MockGitHubClient: MockGitHubClient{
+		files: []*File{
+			{Filename: "main.go", Additions: 30, Deletions: 10, Changes: 40},
+		},
+	}}
+
+	// A custom stage runs after the built-in ones and can use their results and data
+	var seen []string
+	custom := StageFunc(func(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+		files, err := pr.Files(ctx)
+		if err != nil {
+			return err
+		}
+		seen = append(seen, fmt.Sprintf("#%d %s %s %d files", pr.PR.Number, metric.DominantLanguage, metric.Size, len(files)))
+		return nil
+	})
+	results := ProcessPullRequests(context.Background(), client, prs, "owner", "repo", ProcessOptions{
+		Languages: true,
+		Size:      true,
+		Stages:    []Stage{custom},
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	want := []string{"#1 Go S 1 files", "#2 Go S 1 files"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("Expected the custom stage to see %v, got %v", want, seen)
+	}
+	if client.fileCalls != 2 {
+		t.Errorf("Expected each PR's files to be fetched once, got %d fetches", client.fileCalls)
+	}
+}
+
+func TestProcessPullRequests_StageExhaustsBudget(t *testing.T) {
+	createdAt := time.Now().Add(-2 * time.Hour)
+	author := User{Login: "author"}
+	prs := []*PullRequest{
+		{Number: 1, User: author, State: "open", CreatedAt: createdAt},
+		{Number: 2, User: author, State: "open", CreatedAt: createdAt},
+	}
+
+	calls := 0
+	stage := StageFunc(func(ctx context.Context, pr *PullRequestContext, metric *PullRequestMetric) error {
+		calls++
+		if pr.PR.Number == 2 {
+			return fmt.Errorf("lookup: %w", budget.ErrExhausted)
+		}
+		return fmt.Errorf("lookup failed")
+	})
+	results := ProcessPullRequests(context.Background(), &MockGitHubClient{}, prs, "owner", "repo", ProcessOptions{Stages: []Stage{stage}})
+
+	// Other errors are only logged, but running out of budget stops processing
+	if len(results) != 1 || results[0].PRNumber != 1 {
+		t.Errorf("Expected only PR #1 in the results, got %d results", len(results))
+	}
+	if calls != 2 {
+		t.Errorf("Expected the stage to run twice, got %d", calls)
+	}
+}
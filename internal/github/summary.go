@@ -0,0 +1,163 @@
+package github
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// ReviewTimeStats summarizes review times for a group of PRs, such as one author's
+type ReviewTimeStats struct {
+	Group                   string
+	PRCount                 int
+	AwaitingReviewCount     int // PRs with no review yet
+	MeanTimeToFirstReview   time.Duration
+	MedianTimeToFirstReview time.Duration
+	MeanTimeToApproval      time.Duration
+	MedianTimeToApproval    time.Duration
+}
+
+// SummarizeByAuthor groups PR metrics by author, slowest median time to first
+// review first, so the contributors waiting longest are at the top
+func SummarizeByAuthor(results []PullRequestMetric) []ReviewTimeStats {
+	return summarizeBy(results, func(pr PullRequestMetric) string { return pr.Author })
+}
+
+// SummarizeByLanguage groups PR metrics by their dominant language, slowest median
+// time to first review first. PRs whose language wasn't measured are left out.
+func SummarizeByLanguage(results []PullRequestMetric) []ReviewTimeStats {
+	var measured []PullRequestMetric
+	for _, result := range results {
+		if result.DominantLanguage != "" {
+			measured = append(measured, result)
+		}
+	}
+	return summarizeBy(measured, func(pr PullRequestMetric) string { return pr.DominantLanguage })
+}
+
+// SummarizeByClass groups PR metrics by class (docs, test, config or code), slowest
+// median time to first review first. Unclassified PRs are left out.
+func SummarizeByClass(results []PullRequestMetric) []ReviewTimeStats {
+	var classified []PullRequestMetric
+	for _, result := range results {
+		if result.Class != "" {
+			classified = append(classified, result)
+		}
+	}
+	return summarizeBy(classified, func(pr PullRequestMetric) string { return pr.Class })
+}
+
+// SummarizeByChangeType groups PR metrics by conventional-commit type, slowest median
+// time to first review first. PRs without a recognized type are grouped as "other".
+func SummarizeByChangeType(results []PullRequestMetric) []ReviewTimeStats {
+	return summarizeBy(results, func(pr PullRequestMetric) string {
+		if pr.ChangeType == "" {
+			return "other"
+		}
+		return pr.ChangeType
+	})
+}
+
+// SummarizeByRepository groups PR metrics by repository, slowest median time to
+// first review first, for subtotals when reporting on several repositories at once
+func SummarizeByRepository(results []PullRequestMetric) []ReviewTimeStats {
+	return summarizeBy(results, func(pr PullRequestMetric) string { return pr.Repository })
+}
+
+// SummarizeBySize groups PR metrics by size bucket, smallest first, to show how
+// review latency grows with PR size. PRs whose size wasn't measured are left out.
+func SummarizeBySize(results []PullRequestMetric) []ReviewTimeStats {
+	var measured []PullRequestMetric
+	for _, result := range results {
+		if result.Size != "" {
+			measured = append(measured, result)
+		}
+	}
+	stats := summarizeBy(measured, func(pr PullRequestMetric) string { return pr.Size })
+	slices.SortFunc(stats, func(a, b ReviewTimeStats) int {
+		return cmp.Compare(sizeRank[a.Group], sizeRank[b.Group])
+	})
+	return stats
+}
+
+// NoPriority is the group SummarizeByPriority puts PRs without a priority level in
+const NoPriority = "none"
+
+// SummarizeByPriority groups PR metrics by priority level, most urgent first, to
+// check urgent changes really are reviewed faster. PRs without a level come last.
+func SummarizeByPriority(results []PullRequestMetric, priorities Priorities) []ReviewTimeStats {
+	stats := summarizeBy(results, func(pr PullRequestMetric) string {
+		if pr.Priority == "" {
+			return NoPriority
+		}
+		return pr.Priority
+	})
+	rank := func(group string) int {
+		if i := slices.Index(priorities.Levels(), group); i >= 0 {
+			return i
+		}
+		return len(priorities)
+	}
+	slices.SortStableFunc(stats, func(a, b ReviewTimeStats) int {
+		return cmp.Compare(rank(a.Group), rank(b.Group))
+	})
+	return stats
+}
+
+// summarizeBy groups PR metrics by key, slowest median time to first review first
+func summarizeBy(results []PullRequestMetric, key func(PullRequestMetric) string) []ReviewTimeStats {
+	byGroup := make(map[string][]PullRequestMetric)
+	for _, result := range results {
+		byGroup[key(result)] = append(byGroup[key(result)], result)
+	}
+
+	var summaries []ReviewTimeStats
+	for group, prs := range byGroup {
+		var firstReviewTimes, approvalTimes []time.Duration
+		awaiting := 0
+		for _, pr := range prs {
+			if !pr.HasReview {
+				awaiting++
+				continue
+			}
+			if pr.TimeToFirstReview > 0 {
+				firstReviewTimes = append(firstReviewTimes, pr.TimeToFirstReview)
+			}
+			if pr.TimeToApproval > 0 {
+				approvalTimes = append(approvalTimes, pr.TimeToApproval)
+			}
+		}
+
+		summaries = append(summaries, ReviewTimeStats{
+			Group:                   group,
+			PRCount:                 len(prs),
+			AwaitingReviewCount:     awaiting,
+			MeanTimeToFirstReview:   meanDuration(firstReviewTimes),
+			MedianTimeToFirstReview: stats.MedianOf(firstReviewTimes),
+			MeanTimeToApproval:      meanDuration(approvalTimes),
+			MedianTimeToApproval:    stats.MedianOf(approvalTimes),
+		})
+	}
+
+	slices.SortFunc(summaries, func(a, b ReviewTimeStats) int {
+		if c := cmp.Compare(b.MedianTimeToFirstReview, a.MedianTimeToFirstReview); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Group, b.Group)
+	})
+	return summaries
+}
+
+// meanDuration returns the mean of durations, or 0 if there are none
+func meanDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
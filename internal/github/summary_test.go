@@ -0,0 +1,120 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeByAuthor(t *testing.T) {
+	results := []PullRequestMetric{
+		{Author: "alice", HasReview: true, TimeToFirstReview: 1 * time.Hour, TimeToApproval: 2 * time.Hour},
+		{Author: "alice", HasReview: true, TimeToFirstReview: 3 * time.Hour},
+		{Author: "bob", HasReview: true, TimeToFirstReview: 10 * time.Hour, TimeToApproval: 12 * time.Hour},
+		{Author: "bob"},
+	}
+
+	stats := SummarizeByAuthor(results)
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 authors, got %d", len(stats))
+	}
+
+	// bob has waited longest, so comes first
+	bob := stats[0]
+	if bob.Group != "bob" || bob.PRCount != 2 || bob.AwaitingReviewCount != 1 {
+		t.Errorf("Expected bob with 2 PRs, 1 awaiting review, got %+v", bob)
+	}
+	if bob.MedianTimeToFirstReview != 10*time.Hour {
+		t.Errorf("Expected bob's median time to first review to be 10h, got %v", bob.MedianTimeToFirstReview)
+	}
+
+	alice := stats[1]
+	if alice.MeanTimeToFirstReview != 2*time.Hour || alice.MedianTimeToFirstReview != 2*time.Hour {
+		t.Errorf("Expected alice's mean and median time to first review to be 2h, got %v and %v", alice.MeanTimeToFirstReview, alice.MedianTimeToFirstReview)
+	}
+	if alice.MedianTimeToApproval != 2*time.Hour {
+		t.Errorf("Expected alice's median time to approval to be 2h, got %v", alice.MedianTimeToApproval)
+	}
+}
+
+func TestSummarizeByLanguage(t *testing.T) {
+	results := []PullRequestMetric{
+		{Author: "alice", DominantLanguage: "Go", HasReview: true, TimeToFirstReview: 1 * time.Hour},
+		{Author: "alice", DominantLanguage: "Terraform"},
+		{Author: "bob", DominantLanguage: "Terraform", HasReview: true, TimeToFirstReview: 20 * time.Hour},
+		{Author: "bob"}, // language not measured
+	}
+
+	stats := SummarizeByLanguage(results)
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 languages, got %d", len(stats))
+	}
+	if stats[0].Group != "Terraform" || stats[0].PRCount != 2 || stats[0].AwaitingReviewCount != 1 {
+		t.Errorf("Expected Terraform first with 2 PRs, 1 awaiting review, got %+v", stats[0])
+	}
+	if stats[1].Group != "Go" || stats[1].MedianTimeToFirstReview != 1*time.Hour {
+		t.Errorf("Expected Go second with a 1h median time to first review, got %+v", stats[1])
+	}
+}
+
+func TestSummarizeByChangeType(t *testing.T) {
+	results := []PullRequestMetric{
+		{ChangeType: "feat", HasReview: true, TimeToFirstReview: 4 * time.Hour},
+		{ChangeType: "fix", HasReview: true, TimeToFirstReview: 1 * time.Hour},
+		{ChangeType: "fix", HasReview: true, TimeToFirstReview: 3 * time.Hour},
+		{},
+	}
+
+	stats := SummarizeByChangeType(results)
+
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 change types, got %d", len(stats))
+	}
+	if stats[0].Group != "feat" || stats[1].Group != "fix" || stats[2].Group != "other" {
+		t.Errorf("Expected feat, fix, other, got %s, %s, %s", stats[0].Group, stats[1].Group, stats[2].Group)
+	}
+	if stats[1].PRCount != 2 || stats[1].MedianTimeToFirstReview != 2*time.Hour {
+		t.Errorf("Expected 2 fix PRs with a 2h median time to first review, got %+v", stats[1])
+	}
+}
+
+func TestSummarizeBySize(t *testing.T) {
+	results := []PullRequestMetric{
+		{Size: SizeL, HasReview: true, TimeToFirstReview: 9 * time.Hour},
+		{Size: SizeXS, HasReview: true, TimeToFirstReview: 1 * time.Hour},
+		{Size: SizeM, HasReview: true, TimeToFirstReview: 12 * time.Hour},
+		{},
+	}
+
+	stats := SummarizeBySize(results)
+
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 size buckets, got %d", len(stats))
+	}
+	if stats[0].Group != SizeXS || stats[1].Group != SizeM || stats[2].Group != SizeL {
+		t.Errorf("Expected buckets smallest first, got %s, %s, %s", stats[0].Group, stats[1].Group, stats[2].Group)
+	}
+}
+
+func TestSummarizeByPriority(t *testing.T) {
+	priorities := Priorities{{Level: "P0", Labels: []string{"sev0"}}, {Level: "P1", Labels: []string{"sev1"}}}
+	results := []PullRequestMetric{
+		{HasReview: true, TimeToFirstReview: 9 * time.Hour},
+		{Priority: "P1", HasReview: true, TimeToFirstReview: 4 * time.Hour},
+		{Priority: "P0", HasReview: true, TimeToFirstReview: 1 * time.Hour},
+		{Priority: "P0", HasReview: true, TimeToFirstReview: 3 * time.Hour},
+	}
+
+	stats := SummarizeByPriority(results, priorities)
+
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 priority groups, got %d", len(stats))
+	}
+	if stats[0].Group != "P0" || stats[1].Group != "P1" || stats[2].Group != NoPriority {
+		t.Errorf("Expected groups most urgent first, got %s, %s, %s", stats[0].Group, stats[1].Group, stats[2].Group)
+	}
+	if stats[0].PRCount != 2 || stats[0].MedianTimeToFirstReview != 2*time.Hour {
+		t.Errorf("Expected 2 P0 PRs with a median of 2h, got %+v", stats[0])
+	}
+}
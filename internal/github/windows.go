@@ -0,0 +1,43 @@
+package github
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessPullRequestWindows fetches and processes the PRs created in the date range
+// one window of time at a time, newest first, so that only one window's PRs as the
+// API returns them are held in memory at once; just their metrics are kept. A
+// year-long scan of a busy repository otherwise holds every PR, and everything
+// fetched alongside them, until the last is processed. A window of 0 fetches the
+// whole range at once.
+//
+// fetched, if not nil, is called with each window's PRs before they're processed,
+// for counting them or noting which are still open. If fetching fails, as when the
+// API budget runs out, or ctx is cancelled, the results so far are returned along
+// with the error.
+func ProcessPullRequestWindows(ctx context.Context, client GitHubClientInterface, owner, repo string, startDate, endDate time.Time, window time.Duration, opts ProcessOptions, fetched func(prs []*PullRequest)) ([]PullRequestMetric, error) {
+	var results []PullRequestMetric
+	for end := endDate; !end.Before(startDate); {
+		// Searches match whole seconds at both ends, so windows start on a second
+		// and the next one ends the second before
+		start := startDate
+		if s := end.Add(-window).Truncate(time.Second); window > 0 && s.After(startDate) {
+			start = s
+		}
+
+		prs, err := client.FetchPullRequests(ctx, owner, repo, start, end)
+		if fetched != nil {
+			fetched(prs)
+		}
+		results = append(results, ProcessPullRequests(ctx, client, prs, owner, repo, opts)...)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return results, err
+		}
+		end = start.Add(-time.Second)
+	}
+	return results, nil
+}
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// windowClient lists PRs from a fixed set by the date range asked for, recording
+// each range
+type windowClient struct {
+	MockGitHubClient
+	prs    []*PullRequest
+	ranges [][2]time.Time
+	err    error // returned from the second listing onwards
+}
+
+func (c *windowClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*PullRequest, error) {
+	c.ranges = append(c.ranges, [2]time.Time{startDate, endDate})
+	if c.err != nil && len(c.ranges) > 1 {
+		return nil, c.err
+	}
+	var prs []*PullRequest
+	for _, pr := range c.prs {
+		if !pr.CreatedAt.Before(startDate) && !pr.CreatedAt.After(endDate) {
+			prs = append(prs, pr)
+		}
+	}
+	return prs, nil
+}
+
+func TestProcessPullRequestWindows(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 20, 12, 0, 0, 500, time.UTC)
+	client := &windowClient{}
+	for i, day := range []int{0, 6, 7, 8, 19} {
+		createdAt := start.AddDate(0, 0, day)
+		client.prs = append(client.prs, &PullRequest{
+			Number:    i + 1,
+			User:      User{Login: "author"},
+			State:     "open",
+			CreatedAt: createdAt,
+		})
+	}
+
+	var fetched int
+	results, err := ProcessPullRequestWindows(context.Background(), client, "owner", "repo", start, end, 7*24*time.Hour, ProcessOptions{}, func(prs []*PullRequest) {
+		fetched += len(prs)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if fetched != 5 || len(results) != 5 {
+		t.Fatalf("Expected every PR fetched and processed once, got %d fetched and %d results", fetched, len(results))
+	}
+
+	// Newest window first, each starting on a second and ending the second before the next
+	want := [][2]time.Time{
+		{time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC), end},
+		{time.Date(2024, 1, 6, 11, 59, 59, 0, time.UTC), time.Date(2024, 1, 13, 11, 59, 59, 0, time.UTC)},
+		{start, time.Date(2024, 1, 6, 11, 59, 58, 0, time.UTC)},
+	}
+	if len(client.ranges) != len(want) {
+		t.Fatalf("Expected %d windows, got %v", len(want), client.ranges)
+	}
+	for i := range want {
+		if !client.ranges[i][0].Equal(want[i][0]) || !client.ranges[i][1].Equal(want[i][1]) {
+			t.Errorf("Expected window %d to be %v, got %v", i, want[i], client.ranges[i])
+		}
+	}
+
+	// No window fetches the whole range at once
+	client.ranges = nil
+	if results, _ := ProcessPullRequestWindows(context.Background(), client, "owner", "repo", start, end, 0, ProcessOptions{}, nil); len(results) != 5 || len(client.ranges) != 1 {
+		t.Errorf("Expected one listing of all 5 PRs without a window, got %d listings and %d results", len(client.ranges), len(results))
+	}
+}
+
+func TestProcessPullRequestWindows_StopsOnError(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := start.AddDate(0, 0, 28)
+	client := &windowClient{
+		prs: []*PullRequest{{Number: 1, User: User{Login: "author"}, State: "open", CreatedAt: createdAt}},
+		err: budget.ErrExhausted,
+	}
+
+	results, err := ProcessPullRequestWindows(context.Background(), client, "owner", "repo", start, start.AddDate(0, 0, 30), 7*24*time.Hour, ProcessOptions{}, nil)
+	if !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected the budget error, got %v", err)
+	}
+	if len(client.ranges) != 2 {
+		t.Errorf("Expected fetching to stop at the failed window, got %d windows", len(client.ranges))
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected the first window's result to be kept, got %d results", len(results))
+	}
+}
@@ -0,0 +1,314 @@
+// Package harness reads deployments from Harness pipeline executions, for teams
+// that deploy with Harness rather than Cloud Deploy. A successful execution that
+// deployed several services is a release of each.
+package harness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	harnessAPIBaseURL = "https://app.harness.io"
+	defaultTimeout    = 30 * time.Second
+	pageSize          = 100
+)
+
+// HarnessClient handles Harness API operations
+type HarnessClient struct {
+	httpClient *http.Client
+	apiKey     string
+	accountID  string
+	orgID      string
+	projectID  string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+
+	environments []string // environment identifiers to count deployments to; empty means all
+	commitTime   deploy.CommitTimeFunc
+
+	// Finish times of the releases listed, for GetRolloutCompletion
+	mu       sync.Mutex
+	finished map[string]time.Time
+}
+
+var _ deploy.DeployProvider = (*HarnessClient)(nil)
+
+// NewHarnessClient creates a new client for a Harness project, authenticated with
+// an API key. commitTime looks up when deployed commits were made, usually in the
+// services' GitHub repository.
+func NewHarnessClient(apiKey, accountID, orgID, projectID string, commitTime deploy.CommitTimeFunc) *HarnessClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &HarnessClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		apiKey:     apiKey,
+		accountID:  accountID,
+		orgID:      orgID,
+		projectID:  projectID,
+		baseURL:    harnessAPIBaseURL,
+		retry:      retrying,
+		commitTime: commitTime,
+		finished:   make(map[string]time.Time),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *HarnessClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *HarnessClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// SetEnvironments restricts deployments to those to the given environments, such
+// as the test environment, by identifier
+func (c *HarnessClient) SetEnvironments(environments []string) {
+	c.environments = environments
+}
+
+type execution struct {
+	PlanExecutionID    string `json:"planExecutionId"`
+	PipelineIdentifier string `json:"pipelineIdentifier"`
+	Status             string `json:"status"`
+	StartTs            int64  `json:"startTs"` // milliseconds since the epoch
+	EndTs              int64  `json:"endTs"`
+	ModuleInfo         struct {
+		CD *struct {
+			EnvIdentifiers  []string `json:"envIdentifiers"`
+			ServiceInfoList []struct {
+				Identifier string `json:"identifier"`
+				Artifacts  struct {
+					Primary *struct {
+						Tag string `json:"tag"`
+					} `json:"primary"`
+				} `json:"artifacts"`
+			} `json:"serviceInfoList"`
+		} `json:"cd"`
+		CI *struct {
+			CIExecutionInfoDTO *struct {
+				Branch *struct {
+					Commits []struct {
+						ID string `json:"id"`
+					} `json:"commits"`
+				} `json:"branch"`
+				PullRequest *struct {
+					ID      int `json:"id"`
+					Commits []struct {
+						ID string `json:"id"`
+					} `json:"commits"`
+				} `json:"pullRequest"`
+			} `json:"ciExecutionInfoDTO"`
+		} `json:"ci"`
+	} `json:"moduleInfo"`
+}
+
+// ListReleases lists the services deployed by successful pipeline
+// executions that started in the date range, as releases, newest first. Only
+// deployments to the environments set with SetEnvironments are included, if any
+// were. If the API budget runs out part way through, the releases found so far are
+// returned along with the error.
+func (c *HarnessClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	body := map[string]interface{}{
+		"filterType": "PipelineExecution",
+		"status":     []string{"Success"},
+		"timeRange": map[string]int64{
+			"startTime": startDate.UnixMilli(),
+			"endTime":   endDate.UnixMilli(),
+		},
+	}
+	for page := 0; ; page++ {
+		var resp struct {
+			Content []execution `json:"content"`
+			Last    bool        `json:"last"`
+		}
+		query := url.Values{"page": {strconv.Itoa(page)}, "size": {strconv.Itoa(pageSize)}}
+		if err := c.call(ctx, "/pipeline/api/pipelines/execution/summary", query, body, &resp); err != nil {
+			return releases, fmt.Errorf("failed to list pipeline executions: %w", err)
+		}
+		for _, exec := range resp.Content {
+			releases = append(releases, c.releases(exec)...)
+		}
+		if resp.Last || len(resp.Content) == 0 {
+			break
+		}
+	}
+	return releases, nil
+}
+
+// releases converts an execution to a release per service it deployed, with the
+// deployed commit and PR in the release's annotations
+func (c *HarnessClient) releases(exec execution) []*deploy.Release {
+	cd := exec.ModuleInfo.CD
+	if exec.Status != "Success" || cd == nil || exec.EndTs == 0 {
+		return nil
+	}
+	if len(c.environments) > 0 && !slices.ContainsFunc(cd.EnvIdentifiers, func(env string) bool {
+		return slices.Contains(c.environments, env)
+	}) {
+		return nil
+	}
+
+	var releases []*deploy.Release
+	for _, service := range cd.ServiceInfoList {
+		sha, prNumber := "", ""
+		if service.Artifacts.Primary != nil {
+			sha, prNumber = parseTag(service.Artifacts.Primary.Tag)
+		}
+		if sha == "" {
+			sha, prNumber = ciCommit(exec)
+		}
+
+		// The execution ID comes last, so it's used as the release ID
+		name := fmt.Sprintf("accounts/%s/orgs/%s/projects/%s/pipelines/%s/services/%s/executions/%s",
+			c.accountID, c.orgID, c.projectID, exec.PipelineIdentifier, service.Identifier, exec.PlanExecutionID)
+		release := &deploy.Release{
+			Name:        name,
+			CreateTime:  time.UnixMilli(exec.StartTs),
+			Annotations: map[string]string{"service": service.Identifier},
+		}
+		if sha != "" {
+			release.Annotations["git-sha"] = sha
+		}
+		if prNumber != "" {
+			release.Annotations["pr-number"] = prNumber
+		}
+		releases = append(releases, release)
+
+		c.mu.Lock()
+		c.finished[name] = time.UnixMilli(exec.EndTs)
+		c.mu.Unlock()
+	}
+	return releases
+}
+
+var (
+	// pull-<PR number>_<SHA>, as the tags repository uses for Cloud Deploy
+	prTagPattern = regexp.MustCompile(`^pull-(\d+)_([a-f0-9]{7,40})$`)
+	// Any tag ending in a SHA, e.g. main-5c0119f or a bare SHA
+	shaTagPattern = regexp.MustCompile(`(?:^|[^a-f0-9])([a-f0-9]{7,40})$`)
+)
+
+// parseTag reads the commit SHA, and the PR number if there is one, from an
+// artifact's tag
+func parseTag(tag string) (sha, prNumber string) {
+	if matches := prTagPattern.FindStringSubmatch(tag); matches != nil {
+		return matches[2], matches[1]
+	}
+	if matches := shaTagPattern.FindStringSubmatch(tag); matches != nil {
+		return matches[1], ""
+	}
+	return "", ""
+}
+
+// ciCommit returns the commit, and PR, a pipeline's CI stage built, if it had one
+func ciCommit(exec execution) (sha, prNumber string) {
+	if exec.ModuleInfo.CI == nil || exec.ModuleInfo.CI.CIExecutionInfoDTO == nil {
+		return "", ""
+	}
+	info := exec.ModuleInfo.CI.CIExecutionInfoDTO
+	if pr := info.PullRequest; pr != nil && len(pr.Commits) > 0 {
+		return pr.Commits[0].ID, strconv.Itoa(pr.ID)
+	}
+	if branch := info.Branch; branch != nil && len(branch.Commits) > 0 {
+		return branch.Commits[0].ID, ""
+	}
+	return "", ""
+}
+
+// ExtractSource returns the commit and PR number a release deployed,
+// and when the commit was made
+func (c *HarnessClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	sha := release.Annotations["git-sha"]
+	if sha == "" {
+		return "", "", time.Time{}, fmt.Errorf("no commit SHA found in the artifact tag or CI stage")
+	}
+	commitTime, err := c.commitTime(ctx, sha)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", sha, err)
+	}
+	return sha, release.Annotations["pr-number"], commitTime, nil
+}
+
+// GetRolloutCompletion returns when the execution that deployed the release finished
+func (c *HarnessClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	finished, ok := c.finished[release.Name]
+	if !ok {
+		return time.Time{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return finished, nil
+}
+
+// call makes a Harness API request and decodes the response's data into result
+func (c *HarnessClient) call(ctx context.Context, path string, query url.Values, body, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	query.Set("accountIdentifier", c.accountID)
+	query.Set("orgIdentifier", c.orgID)
+	query.Set("projectIdentifier", c.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path+"?"+query.Encode(), bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	var envelope struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(data, &envelope) == nil && envelope.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, envelope.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
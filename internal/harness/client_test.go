@@ -0,0 +1,142 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns the epoch milliseconds of a time on June 3rd 2024, as Harness encodes times
+func at(hour int) int64 {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestHarnessClient(t *testing.T) {
+	pages := []string{
+		fmt.Sprintf(`{"content": [
+			{"planExecutionId": "exec2", "pipelineIdentifier": "deploy", "status": "Success", "startTs": %d, "endTs": %d,
+				"moduleInfo": {"cd": {"envIdentifiers": ["test"], "serviceInfoList": [
+					{"identifier": "api", "artifacts": {"primary": {"tag": "pull-42_abc1234"}}},
+					{"identifier": "worker", "artifacts": {"primary": {"tag": "latest"}}}
+				]}, "ci": {"ciExecutionInfoDTO": {"branch": {"commits": [{"id": "def5678"}]}}}}},
+			{"planExecutionId": "exec1", "pipelineIdentifier": "deploy", "status": "Success", "startTs": %d, "endTs": %d,
+				"moduleInfo": {"cd": {"envIdentifiers": ["prod"], "serviceInfoList": [
+					{"identifier": "api", "artifacts": {"primary": {"tag": "main-0123abcd"}}}
+				]}}}
+		], "last": false}`, at(10), at(11), at(8), at(9)),
+		fmt.Sprintf(`{"content": [
+			{"planExecutionId": "exec0", "pipelineIdentifier": "build", "status": "Success", "startTs": %d, "endTs": %d,
+				"moduleInfo": {"ci": {}}}
+		], "last": true}`, at(6), at(7)),
+	}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pipeline/api/pipelines/execution/summary" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "api-key" {
+			t.Errorf("Expected the API key, got %q", r.Header.Get("x-api-key"))
+		}
+		query := r.URL.Query()
+		if query.Get("accountIdentifier") != "acct" || query.Get("orgIdentifier") != "eng" || query.Get("projectIdentifier") != "shop" {
+			t.Errorf("Unexpected identifiers %v", query)
+		}
+		var body struct {
+			TimeRange struct {
+				StartTime int64 `json:"startTime"`
+			} `json:"timeRange"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TimeRange.StartTime != at(0) {
+			t.Errorf("Expected the time range to start at midnight, got %v (%v)", body.TimeRange.StartTime, err)
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		calls++
+		w.Write([]byte(`{"status": "SUCCESS", "data": ` + pages[page] + `}`))
+	}))
+	defer server.Close()
+
+	commitTimes := map[string]time.Time{
+		"abc1234": time.Date(2024, 6, 3, 9, 30, 0, 0, time.UTC),
+		"def5678": time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC),
+	}
+	client := NewHarnessClient("api-key", "acct", "eng", "shop", func(ctx context.Context, sha string) (time.Time, error) {
+		commitTime, ok := commitTimes[sha]
+		if !ok {
+			return time.Time{}, errors.New("no such commit")
+		}
+		return commitTime, nil
+	})
+	client.baseURL = server.URL
+	client.SetEnvironments([]string{"test"})
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 pages to be fetched, got %d", calls)
+	}
+	// The production deployment and the build-only pipeline are left out
+	if len(releases) != 2 {
+		t.Fatalf("Expected a release per service deployed to test, got %d", len(releases))
+	}
+	api, worker := releases[0], releases[1]
+	if !strings.HasSuffix(api.Name, "/services/api/executions/exec2") || !api.CreateTime.Equal(time.UnixMilli(at(10))) {
+		t.Errorf("Unexpected release %v", api)
+	}
+
+	sha, prNumber, commitTime, err := client.ExtractSource(context.Background(), api)
+	if err != nil || sha != "abc1234" || prNumber != "42" || !commitTime.Equal(commitTimes["abc1234"]) {
+		t.Errorf("Expected PR #42's commit from the artifact tag, got %s %s %v (%v)", sha, prNumber, commitTime, err)
+	}
+	// The worker's tag has no SHA, so the commit comes from the CI stage
+	sha, prNumber, _, err = client.ExtractSource(context.Background(), worker)
+	if err != nil || sha != "def5678" || prNumber != "" {
+		t.Errorf("Expected the CI stage's commit, got %s %s (%v)", sha, prNumber, err)
+	}
+
+	finish, err := client.GetRolloutCompletion(context.Background(), api)
+	if err != nil || !finish.Equal(time.UnixMilli(at(11))) {
+		t.Errorf("Expected the execution's end time, got %v (%v)", finish, err)
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag, sha, prNumber string
+	}{
+		{"pull-42_abc1234", "abc1234", "42"},
+		{"main-0123abcd", "0123abcd", ""},
+		{"0123456789abcdef0123456789abcdef01234567", "0123456789abcdef0123456789abcdef01234567", ""},
+		{"latest", "", ""},
+		{"v1.2.3", "", ""},
+	}
+	for _, tt := range tests {
+		sha, prNumber := parseTag(tt.tag)
+		if sha != tt.sha || prNumber != tt.prNumber {
+			t.Errorf("parseTag(%q) = %q, %q, want %q, %q", tt.tag, sha, prNumber, tt.sha, tt.prNumber)
+		}
+	}
+}
+
+func TestHarnessClient_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"status": "ERROR", "code": "INVALID_TOKEN", "message": "Token is not valid."}`))
+	}))
+	defer server.Close()
+
+	client := NewHarnessClient("bad-key", "acct", "eng", "shop", nil)
+	client.baseURL = server.URL
+	_, err := client.ListReleases(context.Background(), time.Now().AddDate(0, 0, -1), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "status 401: Token is not valid.") {
+		t.Errorf("Expected Harness's error, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package heroku reads deployments from Heroku's release history, for teams that
+// deploy to Heroku rather than with Cloud Deploy. Only successful releases that
+// deployed code count; config var changes and add-on attachments are releases
+// on Heroku too, but don't ship a commit.
+package heroku
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	herokuAPIBaseURL = "https://api.heroku.com"
+	defaultTimeout   = 30 * time.Second
+	pageSize         = 100
+)
+
+// HerokuClient handles Heroku Platform API operations
+type HerokuClient struct {
+	httpClient *http.Client
+	apiKey     string
+	apps       []string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+	commitTime deploy.CommitTimeFunc
+
+	// Finish times and commits of the releases listed, for the other methods
+	mu       sync.Mutex
+	releases map[string]herokuRelease
+}
+
+var _ deploy.DeployProvider = (*HerokuClient)(nil)
+
+// NewHerokuClient creates a new client for the given apps, authenticated with an
+// API key. commitTime looks up when deployed commits were made, usually in the
+// services' GitHub repository.
+func NewHerokuClient(apiKey string, apps []string, commitTime deploy.CommitTimeFunc) *HerokuClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &HerokuClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		apiKey:     apiKey,
+		apps:       apps,
+		baseURL:    herokuAPIBaseURL,
+		retry:      retrying,
+		commitTime: commitTime,
+		releases:   make(map[string]herokuRelease),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *HerokuClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *HerokuClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type herokuRelease struct {
+	ID          string    `json:"id"`
+	Version     int       `json:"version"`
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Slug        *struct {
+		ID string `json:"id"`
+	} `json:"slug"`
+}
+
+// ListReleases lists the apps' successful code deployments made in
+// the date range, newest first within each app. Releases that only changed config
+// or add-ons, which deploy no new slug, are left out. If the API budget runs out
+// part way through, the releases found so far are returned along with the error.
+func (c *HerokuClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, app := range c.apps {
+		// Releases are paged with the Range header, newest first
+		pageRange := fmt.Sprintf("version ..; order=desc, max=%d", pageSize)
+	pages:
+		for pageRange != "" {
+			var page []herokuRelease
+			next, err := c.get(ctx, "/apps/"+url.PathEscape(app)+"/releases", pageRange, &page)
+			if err != nil {
+				return releases, fmt.Errorf("failed to list releases for %s: %w", app, err)
+			}
+			for _, r := range page {
+				if r.CreatedAt.Before(startDate) {
+					break pages
+				}
+				if r.CreatedAt.After(endDate) || r.Status != "succeeded" || r.Slug == nil {
+					continue
+				}
+				releases = append(releases, c.release(app, r))
+			}
+			pageRange = next
+		}
+	}
+	return releases, nil
+}
+
+// release converts a Heroku release to a deploy.Release, remembering it for
+// ExtractSource and GetRolloutCompletion
+func (c *HerokuClient) release(app string, r herokuRelease) *deploy.Release {
+	// The version comes last, so it's used as the release ID
+	name := fmt.Sprintf("apps/%s/releases/%s-v%d", app, app, r.Version)
+	c.mu.Lock()
+	c.releases[name] = r
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        name,
+		CreateTime:  r.CreatedAt,
+		Annotations: map[string]string{"app": app},
+	}
+}
+
+// deployPattern matches the description Heroku gives releases that deploy a commit
+var deployPattern = regexp.MustCompile(`^Deploy ([a-f0-9]{7,40})\b`)
+
+// ExtractSource returns the commit a release deployed and when it was
+// made. Heroku doesn't know the PR, so the PR number is always empty. The commit is
+// read from the release's description where it's there, or else from its slug.
+func (c *HerokuClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	r, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	var sha string
+	if matches := deployPattern.FindStringSubmatch(r.Description); matches != nil {
+		sha = matches[1]
+	} else {
+		var slug struct {
+			Commit string `json:"commit"`
+		}
+		if _, err := c.get(ctx, "/apps/"+url.PathEscape(release.Annotations["app"])+"/slugs/"+r.Slug.ID, "", &slug); err != nil {
+			return "", "", time.Time{}, fmt.Errorf("failed to fetch slug: %w", err)
+		}
+		sha = slug.Commit
+	}
+	if sha == "" {
+		return "", "", time.Time{}, fmt.Errorf("no commit SHA found in the release or its slug")
+	}
+
+	commitTime, err := c.commitTime(ctx, sha)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", sha, err)
+	}
+	return sha, "", commitTime, nil
+}
+
+// GetRolloutCompletion returns when the release went live, after its release
+// phase, if it had one
+func (c *HerokuClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	r, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.UpdatedAt, nil
+}
+
+// listed returns the Heroku release a release was converted from
+func (c *HerokuClient) listed(release *deploy.Release) (herokuRelease, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.releases[release.Name]
+	if !ok {
+		return herokuRelease{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return r, nil
+}
+
+// get makes a Heroku API request, asking for pageRange if it's set, and decodes
+// the response into result. It returns the range of the next page, if there is one.
+func (c *HerokuClient) get(ctx context.Context, path, pageRange string, result interface{}) (string, error) {
+	if err := c.budget.Spend(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/vnd.heroku+json; version=3")
+	if pageRange != "" {
+		req.Header.Set("Range", pageRange)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	// A 206 means there are more pages
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		var apiErr struct {
+			ID      string `json:"id"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		return resp.Header.Get("Next-Range"), nil
+	}
+	return "", nil
+}
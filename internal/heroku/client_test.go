@@ -0,0 +1,95 @@
+package heroku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024 as Heroku encodes times
+func at(hour int) string {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)
+}
+
+func TestHerokuClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-key" || !strings.Contains(r.Header.Get("Accept"), "version=3") {
+			t.Errorf("Unexpected headers %v", r.Header)
+		}
+		switch r.URL.Path {
+		case "/apps/shop/releases":
+			// The first page ends with a config change; the second runs back past the date range
+			if r.Header.Get("Range") == "version ..; order=desc, max=100" {
+				w.Header().Set("Next-Range", "version ]40..; order=desc, max=100")
+				w.WriteHeader(http.StatusPartialContent)
+				fmt.Fprintf(w, `[
+					{"id": "r43", "version": 43, "status": "succeeded", "description": "Deploy abc1234", "created_at": %q, "updated_at": %q, "slug": {"id": "slug-43"}},
+					{"id": "r42", "version": 42, "status": "failed", "description": "Deploy fff0000", "created_at": %q, "updated_at": %q, "slug": {"id": "slug-42"}},
+					{"id": "r41", "version": 41, "status": "succeeded", "description": "Set FEATURE config vars", "created_at": %q, "updated_at": %q, "slug": null}
+				]`, at(12), at(13), at(10), at(10), at(9), at(9))
+				return
+			}
+			if r.Header.Get("Range") != "version ]40..; order=desc, max=100" {
+				t.Errorf("Expected the next range, got %q", r.Header.Get("Range"))
+			}
+			fmt.Fprintf(w, `[
+				{"id": "r40", "version": 40, "status": "succeeded", "description": "Promote from shop-staging", "created_at": %q, "updated_at": %q, "slug": {"id": "slug-40"}},
+				{"id": "r39", "version": 39, "status": "succeeded", "description": "Deploy 9999999", "created_at": "2024-05-01T00:00:00Z", "updated_at": "2024-05-01T00:00:00Z", "slug": {"id": "slug-39"}}
+			]`, at(8), at(8))
+		case "/apps/shop/slugs/slug-40":
+			w.Write([]byte(`{"id": "slug-40", "commit": "def5678"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id": "not_found", "message": "Couldn't find that app."}`))
+		}
+	}))
+	defer server.Close()
+
+	commitTimes := map[string]time.Time{
+		"abc1234": time.Date(2024, 6, 3, 11, 0, 0, 0, time.UTC),
+		"def5678": time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC),
+	}
+	client := NewHerokuClient("api-key", []string{"shop"}, func(ctx context.Context, sha string) (time.Time, error) {
+		commitTime, ok := commitTimes[sha]
+		if !ok {
+			return time.Time{}, errors.New("no such commit")
+		}
+		return commitTime, nil
+	})
+	client.baseURL = server.URL
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The failed release, the config change and the release before the range are left out
+	if len(releases) != 2 || releases[0].Name != "apps/shop/releases/shop-v43" || releases[1].Name != "apps/shop/releases/shop-v40" {
+		t.Fatalf("Expected v43 and v40, got %v", releases)
+	}
+
+	sha, prNumber, commitTime, err := client.ExtractSource(context.Background(), releases[0])
+	if err != nil || sha != "abc1234" || prNumber != "" || !commitTime.Equal(commitTimes["abc1234"]) {
+		t.Errorf("Expected the commit from the description, got %s %s %v (%v)", sha, prNumber, commitTime, err)
+	}
+	// A promotion's description doesn't name the commit, so it's read from the slug
+	sha, _, _, err = client.ExtractSource(context.Background(), releases[1])
+	if err != nil || sha != "def5678" {
+		t.Errorf("Expected the commit from the slug, got %s (%v)", sha, err)
+	}
+
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[0])
+	if err != nil || finish.Format(time.RFC3339) != at(13) {
+		t.Errorf("Expected the release's update time, got %v (%v)", finish, err)
+	}
+
+	missing := NewHerokuClient("api-key", []string{"missing"}, nil)
+	missing.baseURL = server.URL
+	if _, err := missing.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: Couldn't find that app.") {
+		t.Errorf("Expected Heroku's error, got %v", err)
+	}
+}
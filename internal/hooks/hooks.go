@@ -0,0 +1,74 @@
+// Package hooks runs user-provided programs that add fields to records, such as
+// a team looked up in an internal service or a risk score, without changing the
+// trackers themselves.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// timeout bounds each run of a hook, so a stuck program can't hang the report
+const timeout = 30 * time.Second
+
+// Hook is an external program run once per record. It's given the record as a JSON
+// object on stdin, in the same form as exported (e.g. {"pr_number": 12, ...}), and
+// writes a JSON object of extra fields to stdout. Values that aren't strings are
+// kept as their JSON text.
+type Hook struct {
+	name string
+	args []string
+}
+
+// New creates a hook from a command line, such as "python3 enrich.py". Arguments
+// are split on spaces; there's no shell quoting.
+func New(command string) (*Hook, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("hook command is empty")
+	}
+	return &Hook{name: fields[0], args: fields[1:]}, nil
+}
+
+// Run runs the hook on record and returns the fields it added
+func (h *Hook) Run(record interface{}) (map[string]string, error) {
+	input, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record for hook: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.name, h.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("hook %s failed: %w: %s", h.name, err, msg)
+		}
+		return nil, fmt.Errorf("hook %s failed: %w", h.name, err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(stdout.Bytes(), &raw); err != nil {
+		return nil, fmt.Errorf("hook %s didn't write a JSON object: %w", h.name, err)
+	}
+	fields := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			fields[name] = s
+		} else {
+			fields[name] = string(value)
+		}
+	}
+	return fields, nil
+}
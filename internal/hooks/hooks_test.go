@@ -0,0 +1,69 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeScript writes a shell script to a temporary file and returns a command running it
+func writeScript(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return "sh " + path
+}
+
+func TestHook(t *testing.T) {
+	// Adds fields, echoing the record it was given back as a string
+	hook, err := New(writeScript(t, `read -r record
+escaped=$(printf '%s' "$record" | sed 's/"/\\"/g')
+printf '{"team": "infra", "score": 0.5, "tags": ["a","b"], "input": "%s"}\n' "$escaped"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := hook.Run(map[string]interface{}{"pr_number": 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"team":  "infra",
+		"score": "0.5",
+		"input": `{"pr_number":12}`,
+		"tags":  `["a","b"]`,
+	}
+	if len(fields) != len(want) {
+		t.Errorf("Expected %d fields, got %v", len(want), fields)
+	}
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("Expected %s = %s, got %s", name, value, fields[name])
+		}
+	}
+}
+
+func TestHook_Errors(t *testing.T) {
+	if _, err := New("  "); err == nil {
+		t.Error("Expected an error for an empty command")
+	}
+
+	for _, tt := range []struct {
+		command string
+		want    string
+	}{
+		{writeScript(t, "echo 'no such team' >&2\nexit 1\n"), "no such team"},
+		{"echo not json", "didn't write a JSON object"},
+	} {
+		hook, err := New(tt.command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := hook.Run(struct{}{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected %q to fail with %q, got %v", tt.command, tt.want, err)
+		}
+	}
+}
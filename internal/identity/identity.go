@@ -0,0 +1,116 @@
+// Package identity unifies the accounts one person has on different providers, so
+// per-person rollups aren't split across a GitHub login, a Linear name and so on.
+package identity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Providers whose accounts can be mapped to people
+const (
+	GitHub    = "github"
+	GitLab    = "gitlab"
+	Jira      = "jira"
+	PagerDuty = "pagerduty"
+	Linear    = "linear"
+)
+
+// Person is one human and their account on each provider
+type Person struct {
+	Name      string `yaml:"name"`
+	GitHub    string `yaml:"github"`
+	GitLab    string `yaml:"gitlab"`
+	Jira      string `yaml:"jira"`
+	PagerDuty string `yaml:"pagerduty"`
+	Linear    string `yaml:"linear"`
+	Team      string `yaml:"team"` // The team they're on, for per-team rollups; optional
+}
+
+// Directory resolves provider accounts to people. A nil Directory resolves every
+// account to itself.
+type Directory struct {
+	byAccount map[string]string // "provider:account" (lowercased) to name
+	teams     map[string]string // Name to team, for people with one
+}
+
+// Load reads a directory from a YAML file listing people:
+//
+//	people:
+//	  - name: Alice Smith
+//	    github: asmith
+//	    linear: Alice
+//	    pagerduty: alice@example.com
+//	    team: payments
+func Load(path string) (*Directory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identities: %w", err)
+	}
+	var file struct {
+		People []Person `yaml:"people"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse identities %s: %w", path, err)
+	}
+	return New(file.People)
+}
+
+// New builds a directory from people. An account can only belong to one person.
+func New(people []Person) (*Directory, error) {
+	d := &Directory{byAccount: make(map[string]string), teams: make(map[string]string)}
+	for _, p := range people {
+		if p.Name == "" {
+			return nil, fmt.Errorf("every person needs a name")
+		}
+		if p.Team != "" {
+			d.teams[p.Name] = p.Team
+		}
+		for provider, account := range map[string]string{
+			GitHub: p.GitHub, GitLab: p.GitLab, Jira: p.Jira, PagerDuty: p.PagerDuty, Linear: p.Linear,
+		} {
+			if account == "" {
+				continue
+			}
+			key := accountKey(provider, account)
+			if other, ok := d.byAccount[key]; ok && other != p.Name {
+				return nil, fmt.Errorf("%s account %q belongs to both %s and %s", provider, account, other, p.Name)
+			}
+			d.byAccount[key] = p.Name
+		}
+	}
+	return d, nil
+}
+
+// Resolve returns the name of the person with account on provider, or the account
+// itself if it isn't mapped. Accounts are matched case-insensitively.
+func (d *Directory) Resolve(provider, account string) string {
+	if d == nil || account == "" {
+		return account
+	}
+	if name, ok := d.byAccount[accountKey(provider, account)]; ok {
+		return name
+	}
+	return account
+}
+
+// Team returns the team of the person with the given name, as Resolve returns
+// it, or "" if they don't have one or aren't listed
+func (d *Directory) Team(name string) string {
+	if d == nil {
+		return ""
+	}
+	return d.teams[name]
+}
+
+// HasTeams reports whether anyone in the directory is on a team
+func (d *Directory) HasTeams() bool {
+	return d != nil && len(d.teams) > 0
+}
+
+func accountKey(provider, account string) string {
+	return provider + ":" + strings.ToLower(account)
+}
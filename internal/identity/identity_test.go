@@ -0,0 +1,71 @@
+package identity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAndResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identities.yaml")
+	content := `
+people:
+  - name: Alice Smith
+    github: asmith
+    linear: Alice
+    pagerduty: alice@example.com
+    team: payments
+  - name: Bob Jones
+    github: bjones-work
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		provider, account, want string
+	}{
+		{GitHub, "asmith", "Alice Smith"},
+		{GitHub, "ASmith", "Alice Smith"},
+		{Linear, "Alice", "Alice Smith"},
+		{PagerDuty, "alice@example.com", "Alice Smith"},
+		{GitHub, "bjones-work", "Bob Jones"},
+		{Linear, "asmith", "asmith"}, // a GitHub login isn't a Linear name
+		{GitHub, "carol", "carol"},
+	}
+	for _, tt := range tests {
+		if got := d.Resolve(tt.provider, tt.account); got != tt.want {
+			t.Errorf("Resolve(%s, %s) = %q, want %q", tt.provider, tt.account, got, tt.want)
+		}
+	}
+
+	if got := d.Team("Alice Smith"); got != "payments" {
+		t.Errorf("Expected Alice to be on payments, got %q", got)
+	}
+	if got := d.Team("Bob Jones"); got != "" {
+		t.Errorf("Expected Bob to have no team, got %q", got)
+	}
+
+	var none *Directory
+	if got := none.Resolve(GitHub, "asmith"); got != "asmith" {
+		t.Errorf("Expected a nil directory to resolve accounts to themselves, got %q", got)
+	}
+	if got := none.Team("Alice Smith"); got != "" {
+		t.Errorf("Expected a nil directory to know no teams, got %q", got)
+	}
+}
+
+func TestNewRejectsSharedAccounts(t *testing.T) {
+	_, err := New([]Person{
+		{Name: "Alice Smith", GitHub: "shared"},
+		{Name: "Bob Jones", GitHub: "shared"},
+	})
+	if err == nil {
+		t.Error("Expected an error for an account mapped to two people")
+	}
+}
@@ -0,0 +1,119 @@
+// Package incremental lets trackers fetch only what's new since their last run.
+// A Store keeps, for each repository or pipeline, the results of earlier runs
+// along with a watermark: results for items started before it are final, so the
+// next run only fetches from the watermark onwards and merges what it finds with
+// what's stored. Items that could still change, such as open PRs, hold the
+// watermark back so that they're fetched again.
+package incremental
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// state is what's stored for one repository or pipeline
+type state struct {
+	Since     time.Time       `json:"since"`     // Start of the window the results cover
+	Watermark time.Time       `json:"watermark"` // Results for items started before this are final
+	Results   json.RawMessage `json:"results"`
+}
+
+// Store holds each repository's or pipeline's results and watermark, in a JSON file
+type Store struct {
+	path   string
+	states map[string]state
+}
+
+// Open reads the store at path. A missing file is an empty store, as on a first run.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, states: make(map[string]state)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read incremental state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.states); err != nil {
+		return nil, fmt.Errorf("failed to parse incremental state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Load returns the results stored for key and when to fetch from to bring them up
+// to date for a report starting at start: the watermark, unless the stored results
+// don't go back as far as start, in which case nothing is returned and everything
+// from start is fetched again.
+func Load[T any](s *Store, key string, start time.Time) ([]T, time.Time, error) {
+	st, ok := s.states[key]
+	if !ok || st.Since.After(start) {
+		return nil, start, nil
+	}
+	var results []T
+	if err := json.Unmarshal(st.Results, &results); err != nil {
+		return nil, start, fmt.Errorf("failed to parse incremental state for %s: %w", key, err)
+	}
+	if st.Watermark.After(start) {
+		return results, st.Watermark, nil
+	}
+	return results, start, nil
+}
+
+// Save replaces the results stored for key, and its watermark. The results are
+// those Load returned merged with the ones fetched from since, so they cover from
+// the earlier of since and the stored results' start. The store is written out by
+// Close.
+func Save[T any](s *Store, key string, since, watermark time.Time, results []T) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("failed to encode results for %s: %w", key, err)
+	}
+	if st, ok := s.states[key]; ok && st.Since.Before(since) {
+		since = st.Since
+	}
+	s.states[key] = state{Since: since, Watermark: watermark, Results: data}
+	return nil
+}
+
+// Merge returns the stored results with fresh ones added, replacing any stored
+// result with the same id as a fresh one
+func Merge[T any](stored, fresh []T, id func(T) string) []T {
+	replaced := make(map[string]bool, len(fresh))
+	for _, result := range fresh {
+		replaced[id(result)] = true
+	}
+	merged := slices.DeleteFunc(slices.Clone(stored), func(result T) bool {
+		return replaced[id(result)]
+	})
+	return append(merged, fresh...)
+}
+
+// Close writes the store out, replacing the file atomically so an interrupted
+// write can't lose earlier runs' results
+func (s *Store) Close() error {
+	data, err := json.Marshal(s.states)
+	if err != nil {
+		return fmt.Errorf("failed to encode incremental state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write incremental state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write incremental state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write incremental state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write incremental state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package incremental
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type result struct {
+	ID    string
+	Value int
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// A first run fetches everything from the start of its report
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, from, err := Load[result](s, "owner/repo", day(1))
+	if err != nil || stored != nil || !from.Equal(day(1)) {
+		t.Fatalf("Expected nothing stored on a first run, got %v from %v (%v)", stored, from, err)
+	}
+	if err := Save(s, "owner/repo", day(1), day(10), []result{{"a", 1}, {"b", 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next run picks up from the watermark, with the results so far
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, from, err = Load[result](s, "owner/repo", day(3))
+	if err != nil || len(stored) != 2 || !from.Equal(day(10)) {
+		t.Fatalf("Expected 2 results to fetch on from the watermark, got %v from %v (%v)", stored, from, err)
+	}
+	merged := Merge(stored, []result{{"b", 3}, {"c", 4}}, func(r result) string { return r.ID })
+	if len(merged) != 3 || merged[0] != (result{"a", 1}) || merged[1] != (result{"b", 3}) || merged[2] != (result{"c", 4}) {
+		t.Errorf("Expected b replaced and c added, got %v", merged)
+	}
+	if err := Save(s, "owner/repo", from, day(12), merged); err != nil {
+		t.Fatal(err)
+	}
+	// The merged results still cover the first run's window
+	if stored, from, _ := Load[result](s, "owner/repo", day(1)); len(stored) != 3 || !from.Equal(day(12)) {
+		t.Errorf("Expected the merged results from the new watermark, got %v from %v", stored, from)
+	}
+
+	// A report starting before the stored results fetches everything again
+	if stored, from, _ := Load[result](s, "owner/repo", day(1).AddDate(0, 0, -7)); stored != nil || !from.Equal(day(1).AddDate(0, 0, -7)) {
+		t.Errorf("Expected a full fetch for an earlier report, got %v from %v", stored, from)
+	}
+	// Other keys are separate
+	if stored, _, _ := Load[result](s, "owner/other", day(1)); stored != nil {
+		t.Errorf("Expected nothing stored for another repository, got %v", stored)
+	}
+}
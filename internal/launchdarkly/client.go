@@ -0,0 +1,205 @@
+// Package launchdarkly reads feature flag changes from LaunchDarkly's audit log.
+// Turning a flag on, or widening its rollout, ships a change to users as surely
+// as a deployment does, so flag changes are counted per environment and
+// correlated with deployments and incidents.
+package launchdarkly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	launchDarklyAPIBaseURL = "https://app.launchdarkly.com"
+	defaultTimeout         = 30 * time.Second
+	pageSize               = 20 // The most the audit log returns at once
+)
+
+// LaunchDarklyClient handles LaunchDarkly API operations
+type LaunchDarklyClient struct {
+	httpClient *http.Client
+	token      string
+	project    string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+}
+
+// NewLaunchDarklyClient creates a new client for a project, by key, authenticated
+// with an access token that can read the audit log
+func NewLaunchDarklyClient(token, project string) *LaunchDarklyClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &LaunchDarklyClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:   token,
+		project: project,
+		baseURL: launchDarklyAPIBaseURL,
+		retry:   retrying,
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *LaunchDarklyClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *LaunchDarklyClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type auditLogEntry struct {
+	ID        string `json:"_id"`
+	Date      int64  `json:"date"` // Milliseconds since the epoch
+	Kind      string `json:"kind"`
+	TitleVerb string `json:"titleVerb"`
+	Member    *struct {
+		Email string `json:"email"`
+	} `json:"member"`
+	Accesses []struct {
+		Action   string `json:"action"`
+		Resource string `json:"resource"`
+	} `json:"accesses"`
+}
+
+// FetchFlagChanges lists the changes made to flags' settings in the given
+// environments, or in every environment if none are given, in the date range,
+// newest first. Changes to a flag as a whole, such as creating it, aren't made in
+// an environment and are left out. If the API budget runs out part way through,
+// the changes found so far are returned along with the error.
+func (c *LaunchDarklyClient) FetchFlagChanges(ctx context.Context, environments []string, startDate, endDate time.Time) ([]FlagChange, error) {
+	if len(environments) == 0 {
+		environments = []string{"*"}
+	}
+
+	var changes []FlagChange
+	for _, env := range environments {
+		query := url.Values{
+			"spec":   {fmt.Sprintf("proj/%s:env/%s:flag/*", c.project, env)},
+			"after":  {strconv.FormatInt(startDate.UnixMilli(), 10)},
+			"before": {strconv.FormatInt(endDate.UnixMilli(), 10)},
+			"limit":  {strconv.Itoa(pageSize)},
+		}
+		path := "/api/v2/auditlog?" + query.Encode()
+		for path != "" {
+			var page struct {
+				Items []auditLogEntry `json:"items"`
+				Links struct {
+					Next *struct {
+						Href string `json:"href"`
+					} `json:"next"`
+				} `json:"_links"`
+			}
+			if err := c.get(ctx, path, &page); err != nil {
+				return changes, fmt.Errorf("failed to list flag changes in %s: %w", env, err)
+			}
+			for _, entry := range page.Items {
+				if change, ok := flagChange(entry); ok {
+					changes = append(changes, change)
+				}
+			}
+			// The link to the next page carries the query on
+			path = ""
+			if page.Links.Next != nil && len(page.Items) == pageSize {
+				path = page.Links.Next.Href
+			}
+		}
+	}
+	return changes, nil
+}
+
+// flagChange converts an audit log entry to a flag change, if it was one made in
+// an environment
+func flagChange(entry auditLogEntry) (FlagChange, bool) {
+	if entry.Kind != "flag" || len(entry.Accesses) == 0 {
+		return FlagChange{}, false
+	}
+	access := entry.Accesses[0]
+	resource := parseResource(access.Resource)
+	if resource["env"] == "" || resource["flag"] == "" {
+		return FlagChange{}, false
+	}
+	change := FlagChange{
+		ID:          entry.ID,
+		Flag:        resource["flag"],
+		Environment: resource["env"],
+		Action:      access.Action,
+		Description: entry.TitleVerb,
+		At:          time.UnixMilli(entry.Date).UTC(),
+	}
+	if entry.Member != nil {
+		change.Member = entry.Member.Email
+	}
+	return change, true
+}
+
+// parseResource splits a resource name such as
+// proj/default:env/production;tag1:flag/new-checkout into its keys by type,
+// leaving out tags
+func parseResource(resource string) map[string]string {
+	keys := make(map[string]string)
+	for _, part := range strings.Split(resource, ":") {
+		kind, key, ok := strings.Cut(part, "/")
+		if !ok {
+			continue
+		}
+		key, _, _ = strings.Cut(key, ";")
+		keys[kind] = key
+	}
+	return keys
+}
+
+// get makes a LaunchDarkly API request and decodes the response into result
+func (c *LaunchDarklyClient) get(ctx context.Context, path string, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	// Access tokens are sent as they are, without a scheme
+	req.Header.Set("Authorization", c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
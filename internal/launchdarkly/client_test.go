@@ -0,0 +1,95 @@
+package launchdarkly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+// at returns a time on June 3rd 2024, in the milliseconds LaunchDarkly uses
+func at(hour int) int64 {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestLaunchDarklyClient_FetchFlagChanges(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "api-token" {
+			t.Errorf("Expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		query := r.URL.Query()
+		if query.Get("spec") != "proj/web:env/production:flag/*" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"code": "forbidden", "message": "Access to the requested resource was denied"}`))
+			return
+		}
+		requests = append(requests, query.Get("before"))
+		if query.Get("before") == fmt.Sprint(at(8)) {
+			// The second, last page
+			fmt.Fprintf(w, `{"items": [
+				{"_id": "a3", "date": %d, "kind": "flag", "titleVerb": "turned off the flag", "accesses": [{"action": "updateOn", "resource": "proj/web:env/production;critical:flag/new-checkout"}]}
+			], "_links": {}}`, at(7))
+			return
+		}
+		var items []string
+		for i := 0; i < pageSize; i++ {
+			switch i {
+			case 0:
+				items = append(items, fmt.Sprintf(`{"_id": "a1", "date": %d, "kind": "flag", "titleVerb": "changed the targeting rules", "member": {"email": "alice@example.com"},
+					"accesses": [{"action": "updateRules", "resource": "proj/web:env/production:flag/new-checkout"}]}`, at(10)))
+			case 1:
+				// Creating a flag isn't a change in an environment
+				items = append(items, fmt.Sprintf(`{"_id": "a2", "date": %d, "kind": "flag", "accesses": [{"action": "createFlag", "resource": "proj/web:flag/dark-mode"}]}`, at(9)))
+			default:
+				items = append(items, fmt.Sprintf(`{"_id": "m%d", "date": %d, "kind": "member", "accesses": [{"action": "updateRole", "resource": "member/x"}]}`, i, at(9)))
+			}
+		}
+		fmt.Fprintf(w, `{"items": [%s], "_links": {"next": {"href": "/api/v2/auditlog?before=%d&limit=20&spec=proj/web:env/production:flag/*"}}}`, strings.Join(items, ","), at(8))
+	}))
+	defer server.Close()
+
+	client := NewLaunchDarklyClient("api-token", "web")
+	client.baseURL = server.URL
+
+	changes, err := client.FetchFlagChanges(context.Background(), []string{"production"}, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected two pages, got %v", requests)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 flag changes, got %+v", changes)
+	}
+	want := FlagChange{ID: "a1", Flag: "new-checkout", Environment: "production", Action: "updateRules", Description: "changed the targeting rules", Member: "alice@example.com", At: time.UnixMilli(at(10)).UTC()}
+	if changes[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, changes[0])
+	}
+	// Tags on the environment aren't part of its key
+	if changes[1].ID != "a3" || changes[1].Environment != "production" || changes[1].Member != "" {
+		t.Errorf("Unexpected change %+v", changes[1])
+	}
+
+	_, err = client.FetchFlagChanges(context.Background(), []string{"staging"}, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err == nil || !strings.Contains(err.Error(), "API returned status 403: Access to the requested resource was denied") {
+		t.Errorf("Expected the API's error, got %v", err)
+	}
+}
+
+func TestLaunchDarklyClient_Budget(t *testing.T) {
+	client := NewLaunchDarklyClient("api-token", "web")
+	client.SetBudget(budget.New("LaunchDarkly API", 1))
+	client.budget.Spend()
+
+	_, err := client.FetchFlagChanges(context.Background(), nil, time.Now().AddDate(0, 0, -7), time.Now())
+	if !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package launchdarkly
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// ProcessFlagChanges notes, for each flag change, the deployments made within
+// window of it either side, since a flag is often turned on to release what a
+// deployment shipped, and the incidents opened within window after it
+func ProcessFlagChanges(changes []FlagChange, deployments, incidents []Event, window time.Duration) []FlagChangeMetric {
+	var results []FlagChangeMetric
+
+	for _, change := range changes {
+		results = append(results, FlagChangeMetric{
+			ID:                 change.ID,
+			Flag:               change.Flag,
+			Environment:        change.Environment,
+			Action:             change.Action,
+			Description:        change.Description,
+			Member:             change.Member,
+			ChangedAt:          change.At,
+			NearbyDeployments:  Between(deployments, change.At.Add(-window), change.At.Add(window)),
+			FollowingIncidents: Between(incidents, change.At, change.At.Add(window)),
+		})
+	}
+
+	return results
+}
+
+// Between returns the names of the events from start to end, inclusive, in time order
+func Between(events []Event, start, end time.Time) []string {
+	sorted := slices.Clone(events)
+	slices.SortStableFunc(sorted, func(a, b Event) int {
+		return a.At.Compare(b.At)
+	})
+
+	var names []string
+	for _, e := range sorted {
+		if !e.At.Before(start) && !e.At.After(end) {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+// SummarizeEnvironments aggregates flag changes per environment, by name, with
+// change frequency measured over the weeks from start to end
+func SummarizeEnvironments(results []FlagChangeMetric, start, end time.Time) []EnvironmentStats {
+	byEnvironment := make(map[string]*EnvironmentStats)
+	flags := make(map[string]map[string]bool)
+
+	for _, result := range results {
+		stats, ok := byEnvironment[result.Environment]
+		if !ok {
+			stats = &EnvironmentStats{Environment: result.Environment}
+			byEnvironment[result.Environment] = stats
+			flags[result.Environment] = make(map[string]bool)
+		}
+		stats.Changes++
+		flags[result.Environment][result.Flag] = true
+		if len(result.NearbyDeployments) > 0 {
+			stats.ChangesNearDeployments++
+		}
+		if len(result.FollowingIncidents) > 0 {
+			stats.ChangesFollowedByIncident++
+		}
+	}
+
+	weeks := end.Sub(start).Hours() / (24 * 7)
+	var stats []EnvironmentStats
+	for env, s := range byEnvironment {
+		s.FlagsChanged = len(flags[env])
+		if weeks > 0 {
+			s.ChangesPerWeek = float64(s.Changes) / weeks
+		}
+		stats = append(stats, *s)
+	}
+
+	slices.SortFunc(stats, func(a, b EnvironmentStats) int {
+		return cmp.Compare(a.Environment, b.Environment)
+	})
+	return stats
+}
@@ -0,0 +1,53 @@
+package launchdarkly
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProcessFlagChanges(t *testing.T) {
+	base := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	changes := []FlagChange{
+		{ID: "a1", Flag: "new-checkout", Environment: "production", Action: "updateOn", At: base},
+		{ID: "a2", Flag: "new-checkout", Environment: "production", Action: "updateFallthrough", At: base.Add(6 * time.Hour)},
+		{ID: "a3", Flag: "dark-mode", Environment: "production", Action: "updateOn", At: base.Add(24 * time.Hour)},
+		{ID: "a4", Flag: "dark-mode", Environment: "staging", Action: "updateOn", At: base.Add(-time.Hour)},
+	}
+	deployments := []Event{
+		{Name: "rel-2", At: base.Add(30 * time.Minute)},
+		{Name: "rel-1", At: base.Add(-20 * time.Minute)},
+	}
+	incidents := []Event{
+		// Opened before the change, so not caused by it
+		{Name: "#11 Checkout errors", At: base.Add(5*time.Hour + 30*time.Minute)},
+		{Name: "#12 Checkout errors", At: base.Add(6*time.Hour + 45*time.Minute)},
+	}
+
+	results := ProcessFlagChanges(changes, deployments, incidents, time.Hour)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	if !slices.Equal(results[0].NearbyDeployments, []string{"rel-1", "rel-2"}) || len(results[0].FollowingIncidents) != 0 {
+		t.Errorf("Expected both deployments near the first change and no incidents, got %+v", results[0])
+	}
+	if len(results[1].NearbyDeployments) != 0 || !slices.Equal(results[1].FollowingIncidents, []string{"#12 Checkout errors"}) {
+		t.Errorf("Expected the second change to be followed by incident #12, got %+v", results[1])
+	}
+
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	stats := SummarizeEnvironments(results, start, start.AddDate(0, 0, 14))
+	if len(stats) != 2 || stats[0].Environment != "production" || stats[1].Environment != "staging" {
+		t.Fatalf("Expected production and staging, got %+v", stats)
+	}
+	production := stats[0]
+	if production.Changes != 3 || production.FlagsChanged != 2 || production.ChangesNearDeployments != 1 || production.ChangesFollowedByIncident != 1 {
+		t.Errorf("Unexpected production stats %+v", production)
+	}
+	if production.ChangesPerWeek != 1.5 {
+		t.Errorf("Expected 1.5 changes a week, got %v", production.ChangesPerWeek)
+	}
+	if rate := production.IncidentRate(); rate < 0.33 || rate > 0.34 {
+		t.Errorf("Expected a third of changes to be followed by an incident, got %v", rate)
+	}
+}
@@ -0,0 +1,54 @@
+package launchdarkly
+
+import "time"
+
+// FlagChange is a change to a feature flag's settings in one environment, from
+// LaunchDarkly's audit log: turning it on or off, or changing who it's served to
+type FlagChange struct {
+	ID          string // The audit log entry's ID
+	Flag        string // The flag's key
+	Environment string // The environment's key, e.g. production
+	Action      string // What changed, e.g. updateOn, updateRules or updateFallthrough
+	Description string // e.g. "turned on the flag"
+	Member      string // Who made the change, by email, or "" for changes made through the API
+	At          time.Time
+}
+
+// Event is a deployment or incident to correlate flag changes with
+type Event struct {
+	Name string
+	At   time.Time
+}
+
+// FlagChangeMetric is a flag change and what happened around it
+type FlagChangeMetric struct {
+	ID                 string
+	Flag               string
+	Environment        string
+	Action             string
+	Description        string
+	Member             string
+	ChangedAt          time.Time
+	NearbyDeployments  []string          // Deployments within the correlation window either side of the change
+	FollowingIncidents []string          // Incidents opened within the correlation window after the change
+	Extra              map[string]string // Fields added by a hook program, if any
+}
+
+// EnvironmentStats summarizes one environment's flag changes over a date range
+type EnvironmentStats struct {
+	Environment               string
+	Changes                   int
+	FlagsChanged              int // Distinct flags changed
+	ChangesPerWeek            float64
+	ChangesNearDeployments    int // Changes made around a deployment, which may have been part of a rollout
+	ChangesFollowedByIncident int
+}
+
+// IncidentRate is the fraction of changes followed by an incident, like a
+// deployment change failure rate
+func (s EnvironmentStats) IncidentRate() float64 {
+	if s.Changes == 0 {
+		return 0
+	}
+	return float64(s.ChangesFollowedByIncident) / float64(s.Changes)
+}
@@ -0,0 +1,113 @@
+package linear
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/cache"
+)
+
+// CachedLinearClient wraps LinearClient with caching capabilities
+type CachedLinearClient struct {
+	client *LinearClient
+	cache  cache.Cache
+	kb     *cache.CacheKeyBuilder
+	swr    *cache.Revalidator // nil unless stale-while-revalidate is enabled
+}
+
+// NewCachedLinearClient creates a new Linear client with caching
+func NewCachedLinearClient(apiKey string, cacheImpl cache.Cache) *CachedLinearClient {
+	return &CachedLinearClient{
+		client: NewLinearClient(apiKey),
+		cache:  cacheImpl,
+		kb:     cache.NewCacheKeyBuilder("linear"),
+	}
+}
+
+// SetStaleWhileRevalidate toggles stale-while-revalidate mode, in which expired
+// cache entries are returned immediately and refreshed in the background.
+// Close waits for any outstanding refreshes.
+func (c *CachedLinearClient) SetStaleWhileRevalidate(enabled bool) {
+	if enabled {
+		c.swr = cache.NewRevalidator()
+	} else {
+		c.swr = nil
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Cache hits are free.
+func (c *CachedLinearClient) SetBudget(b *budget.Budget) {
+	c.client.SetBudget(b)
+}
+
+// FetchCompletedIssues fetches completed issues with caching
+func (c *CachedLinearClient) FetchCompletedIssues(ctx context.Context, teamKey string, startDate, endDate time.Time) ([]Issue, error) {
+	// Try to get from cache first
+	key := c.kb.CompletedIssuesKey(teamKey, startDate, endDate)
+	var cachedIssues []Issue
+	refresh := func() error {
+		// The refresh outlives this call, so it can't use the caller's context
+		_, err := c.fetchCompletedIssues(context.Background(), teamKey, startDate, endDate)
+		return err
+	}
+	if err := c.swr.Get(c.cache, key, &cachedIssues, refresh); err == nil {
+		return cachedIssues, nil
+	} else if err != cache.ErrCacheMiss {
+		log.Printf("Cache error for completed issues: %v", err)
+	}
+
+	// Cache miss, fetch from API
+	return c.fetchCompletedIssues(ctx, teamKey, startDate, endDate)
+}
+
+// fetchCompletedIssues fetches completed issues from the API and stores them in the cache
+func (c *CachedLinearClient) fetchCompletedIssues(ctx context.Context, teamKey string, startDate, endDate time.Time) ([]Issue, error) {
+	key := c.kb.CompletedIssuesKey(teamKey, startDate, endDate)
+	issues, err := c.client.FetchCompletedIssues(ctx, teamKey, startDate, endDate)
+	if err != nil {
+		// Pass along any partial results, but never cache them
+		return issues, err
+	}
+
+	// Completed issues rarely change, but recent ranges may gain more of them
+	ttl := 1 * time.Hour
+	if time.Since(endDate) > 7*24*time.Hour {
+		ttl = 24 * time.Hour
+	}
+	if err := c.cache.Set(key, issues, ttl); err != nil {
+		log.Printf("Failed to cache completed issues: %v", err)
+	}
+
+	return issues, nil
+}
+
+// FetchCycles fetches a team's cycles with caching
+func (c *CachedLinearClient) FetchCycles(ctx context.Context, teamKey string, startDate, endDate time.Time) ([]Cycle, error) {
+	key := c.kb.CyclesKey(teamKey, startDate, endDate)
+
+	var cycles []Cycle
+	if err := c.cache.Get(key, &cycles); err == nil {
+		return cycles, nil
+	}
+
+	// Cache miss, fetch from API
+	cycles, err := c.client.FetchCycles(ctx, teamKey, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cycles are planned well ahead and rarely move
+	if err := c.cache.Set(key, cycles, 24*time.Hour); err != nil {
+		log.Printf("Failed to cache cycles: %v", err)
+	}
+
+	return cycles, nil
+}
+
+// Close waits for background refreshes and cleans up the client
+func (c *CachedLinearClient) Close() error {
+	c.swr.Wait()
+	return c.client.Close()
+}
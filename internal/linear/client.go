@@ -0,0 +1,229 @@
+package linear
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+const (
+	linearAPIURL   = "https://api.linear.app/graphql"
+	defaultTimeout = 30 * time.Second
+)
+
+// issuesQuery pages through a team's issues completed in a date range
+const issuesQuery = `
+query($team: String!, $since: DateTimeOrDuration!, $until: DateTimeOrDuration!, $cursor: String) {
+  issues(
+    first: 50
+    after: $cursor
+    filter: { team: { key: { eq: $team } }, completedAt: { gte: $since, lte: $until } }
+  ) {
+    pageInfo { hasNextPage endCursor }
+    nodes {
+      identifier
+      title
+      url
+      createdAt
+      startedAt
+      completedAt
+      assignee { name }
+      attachments { nodes { url sourceType createdAt } }
+    }
+  }
+}`
+
+// cyclesQuery fetches a team's cycles that overlap a date range
+const cyclesQuery = `
+query($team: String!, $since: DateTimeOrDuration!, $until: DateTimeOrDuration!) {
+  cycles(
+    first: 100
+    filter: { team: { key: { eq: $team } }, endsAt: { gte: $since }, startsAt: { lte: $until } }
+  ) {
+    nodes { number name startsAt endsAt }
+  }
+}`
+
+// LinearClient handles Linear API operations
+type LinearClient struct {
+	httpClient *http.Client
+	apiKey     string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+}
+
+// NewLinearClient creates a new Linear client authenticated with a personal API key
+func NewLinearClient(apiKey string) *LinearClient {
+	return &LinearClient{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		apiKey:  apiKey,
+		baseURL: linearAPIURL,
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *LinearClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+type issuesResponse struct {
+	Issues struct {
+		PageInfo struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []struct {
+			Identifier  string     `json:"identifier"`
+			Title       string     `json:"title"`
+			URL         string     `json:"url"`
+			CreatedAt   time.Time  `json:"createdAt"`
+			StartedAt   *time.Time `json:"startedAt"`
+			CompletedAt *time.Time `json:"completedAt"`
+			Assignee    *struct {
+				Name string `json:"name"`
+			} `json:"assignee"`
+			Attachments struct {
+				Nodes []Attachment `json:"nodes"`
+			} `json:"attachments"`
+		} `json:"nodes"`
+	} `json:"issues"`
+}
+
+// FetchCompletedIssues fetches a team's issues completed in the date range. If the
+// API budget runs out part way through, the issues fetched so far are returned along with the error.
+func (c *LinearClient) FetchCompletedIssues(ctx context.Context, teamKey string, startDate, endDate time.Time) ([]Issue, error) {
+	var allIssues []Issue
+	variables := map[string]interface{}{
+		"team":   teamKey,
+		"since":  startDate.Format(time.RFC3339),
+		"until":  endDate.Format(time.RFC3339),
+		"cursor": nil,
+	}
+
+	for {
+		if err := c.budget.Spend(); err != nil {
+			return allIssues, err
+		}
+		var resp issuesResponse
+		if err := c.query(ctx, issuesQuery, variables, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch issues for team %s: %w", teamKey, err)
+		}
+
+		for _, node := range resp.Issues.Nodes {
+			issue := Issue{
+				Identifier:  node.Identifier,
+				Title:       node.Title,
+				URL:         node.URL,
+				CreatedAt:   node.CreatedAt,
+				StartedAt:   node.StartedAt,
+				CompletedAt: node.CompletedAt,
+				Attachments: node.Attachments.Nodes,
+			}
+			if node.Assignee != nil {
+				issue.Assignee = node.Assignee.Name
+			}
+			allIssues = append(allIssues, issue)
+		}
+
+		if !resp.Issues.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = resp.Issues.PageInfo.EndCursor
+	}
+
+	return allIssues, nil
+}
+
+// FetchCycles fetches a team's cycles (sprints) that overlap the date range, in start order
+func (c *LinearClient) FetchCycles(ctx context.Context, teamKey string, startDate, endDate time.Time) ([]Cycle, error) {
+	if err := c.budget.Spend(); err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Cycles struct {
+			Nodes []Cycle `json:"nodes"`
+		} `json:"cycles"`
+	}
+	variables := map[string]interface{}{
+		"team":  teamKey,
+		"since": startDate.Format(time.RFC3339),
+		"until": endDate.Format(time.RFC3339),
+	}
+	if err := c.query(ctx, cyclesQuery, variables, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch cycles for team %s: %w", teamKey, err)
+	}
+
+	cycles := resp.Cycles.Nodes
+	slices.SortFunc(cycles, func(a, b Cycle) int {
+		return a.StartsAt.Compare(b.StartsAt)
+	})
+	return cycles, nil
+}
+
+// query runs a GraphQL query and decodes its data into result
+func (c *LinearClient) query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Personal API keys are sent as-is, without a Bearer prefix
+	req.Header.Set("Authorization", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		var messages []string
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("query failed: %s", strings.Join(messages, "; "))
+	}
+
+	if err := json.Unmarshal(response.Data, result); err != nil {
+		return fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return nil
+}
+
+// Close cleans up the client (no-op for HTTP client)
+func (c *LinearClient) Close() error {
+	return nil
+}
@@ -0,0 +1,90 @@
+package linear
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+func TestLinearClient_FetchCompletedIssues(t *testing.T) {
+	pages := []string{
+		`{"data":{"issues":{"pageInfo":{"hasNextPage":true,"endCursor":"c1"},"nodes":[
+			{"identifier":"ENG-1","title":"First","createdAt":"2024-03-01T00:00:00Z","startedAt":"2024-03-02T00:00:00Z","completedAt":"2024-03-05T00:00:00Z",
+			 "assignee":{"name":"Alice"},"attachments":{"nodes":[{"url":"https://github.com/org/repo/pull/7","sourceType":"github","createdAt":"2024-03-03T00:00:00Z"}]}}]}}}`,
+		`{"data":{"issues":{"pageInfo":{"hasNextPage":false},"nodes":[
+			{"identifier":"ENG-2","title":"Second","createdAt":"2024-03-01T00:00:00Z","completedAt":"2024-03-04T00:00:00Z","assignee":null,"attachments":{"nodes":[]}}]}}}`,
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "test-key" {
+			t.Errorf("Expected Authorization header to be 'test-key', got '%s'", auth)
+		}
+
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if body.Variables["team"] != "ENG" {
+			t.Errorf("Expected team ENG, got %v", body.Variables["team"])
+		}
+		if requests == 1 && body.Variables["cursor"] != "c1" {
+			t.Errorf("Expected cursor c1 on the second request, got %v", body.Variables["cursor"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(pages[requests]))
+		requests++
+	}))
+	defer server.Close()
+
+	client := NewLinearClient("test-key")
+	client.baseURL = server.URL
+
+	issues, err := client.FetchCompletedIssues(context.Background(), "ENG", time.Now().AddDate(0, -1, 0), time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d", len(issues))
+	}
+	if issues[0].Assignee != "Alice" || len(issues[0].Attachments) != 1 {
+		t.Errorf("Expected ENG-1 assigned to Alice with one attachment, got %+v", issues[0])
+	}
+	if issues[1].StartedAt != nil {
+		t.Errorf("Expected ENG-2 to have no start time, got %v", issues[1].StartedAt)
+	}
+}
+
+func TestLinearClient_FetchCompletedIssues_GraphQLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"Team not found"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewLinearClient("test-key")
+	client.baseURL = server.URL
+
+	if _, err := client.FetchCompletedIssues(context.Background(), "NOPE", time.Now().AddDate(0, -1, 0), time.Now()); err == nil {
+		t.Error("Expected an error, got none")
+	}
+}
+
+func TestLinearClient_FetchCompletedIssues_BudgetExhausted(t *testing.T) {
+	client := NewLinearClient("test-key")
+	client.SetBudget(budget.New("Linear", 1))
+	client.budget.Spend()
+
+	_, err := client.FetchCompletedIssues(context.Background(), "ENG", time.Now().AddDate(0, -1, 0), time.Now())
+	if !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package linear
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/period"
+)
+
+// githubPRURL matches links to GitHub pull requests
+var githubPRURL = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/pull/(\d+)`)
+
+// ParseGitHubPRURL extracts the repository and number from a GitHub PR link
+func ParseGitHubPRURL(url string) (owner, repo string, number int, ok bool) {
+	matches := githubPRURL.FindStringSubmatch(url)
+	if matches == nil {
+		return "", "", 0, false
+	}
+	number, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return "", "", 0, false
+	}
+	return matches[1], matches[2], number, true
+}
+
+// MergedAtFunc looks up when a linked PR was merged, reporting false if it wasn't
+// or can't be determined
+type MergedAtFunc func(prURL string) (time.Time, bool)
+
+// ProcessIssues calculates planning and delivery timings for completed issues.
+// mergedAt may be nil, in which case MergeToDone isn't calculated.
+func ProcessIssues(issues []Issue, mergedAt MergedAtFunc) []IssueMetric {
+	var results []IssueMetric
+
+	for _, issue := range issues {
+		if issue.CompletedAt == nil {
+			continue
+		}
+
+		metric := IssueMetric{
+			Identifier: issue.Identifier,
+			Title:      issue.Title,
+			Assignee:   issue.Assignee,
+			LeadTime:   issue.CompletedAt.Sub(issue.CreatedAt),
+		}
+		workStarted := issue.CreatedAt
+		if issue.StartedAt != nil {
+			metric.CycleTime = issue.CompletedAt.Sub(*issue.StartedAt)
+			workStarted = *issue.StartedAt
+		}
+
+		// Find linked PRs, when the first was linked, and when the last was merged
+		var firstLinked, lastMerged time.Time
+		for _, attachment := range issue.Attachments {
+			if _, _, _, ok := ParseGitHubPRURL(attachment.URL); !ok {
+				continue
+			}
+			metric.PullRequests = append(metric.PullRequests, attachment.URL)
+			if firstLinked.IsZero() || attachment.CreatedAt.Before(firstLinked) {
+				firstLinked = attachment.CreatedAt
+			}
+			if mergedAt != nil {
+				if at, ok := mergedAt(attachment.URL); ok && at.After(lastMerged) {
+					lastMerged = at
+				}
+			}
+		}
+		if !firstLinked.IsZero() && firstLinked.After(workStarted) {
+			metric.TimeToFirstPR = firstLinked.Sub(workStarted)
+		}
+		if !lastMerged.IsZero() && issue.CompletedAt.After(lastMerged) {
+			metric.MergeToDone = issue.CompletedAt.Sub(lastMerged)
+		}
+
+		results = append(results, metric)
+	}
+
+	return results
+}
+
+// CyclePeriods converts cycles into reporting periods
+func CyclePeriods(cycles []Cycle) []period.Period {
+	periods := make([]period.Period, 0, len(cycles))
+	for _, cycle := range cycles {
+		name := cycle.Name
+		if name == "" {
+			name = fmt.Sprintf("Cycle %d", cycle.Number)
+		}
+		periods = append(periods, period.Period{Name: name, Start: cycle.StartsAt, End: cycle.EndsAt})
+	}
+	return periods
+}
@@ -0,0 +1,69 @@
+package linear
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGitHubPRURL(t *testing.T) {
+	owner, repo, number, ok := ParseGitHubPRURL("https://github.com/org/repo/pull/42#issuecomment-1")
+	if !ok || owner != "org" || repo != "repo" || number != 42 {
+		t.Errorf("Expected org/repo#42, got %s/%s#%d (ok=%v)", owner, repo, number, ok)
+	}
+	if _, _, _, ok := ParseGitHubPRURL("https://github.com/org/repo/issues/42"); ok {
+		t.Error("Expected an issue link not to parse as a PR")
+	}
+}
+
+func TestProcessIssues(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	startedAt := createdAt.Add(24 * time.Hour)
+	completedAt := createdAt.Add(96 * time.Hour)
+	mergedAt := createdAt.Add(72 * time.Hour)
+
+	issues := []Issue{
+		{
+			Identifier:  "ENG-1",
+			CreatedAt:   createdAt,
+			StartedAt:   &startedAt,
+			CompletedAt: &completedAt,
+			Attachments: []Attachment{
+				{URL: "https://www.figma.com/file/abc", CreatedAt: createdAt},
+				{URL: "https://github.com/org/repo/pull/7", CreatedAt: startedAt.Add(6 * time.Hour)},
+			},
+		},
+		{
+			Identifier:  "ENG-2",
+			CreatedAt:   createdAt,
+			CompletedAt: &completedAt,
+		},
+	}
+
+	mergedAtFunc := func(url string) (time.Time, bool) {
+		return mergedAt, url == "https://github.com/org/repo/pull/7"
+	}
+	results := ProcessIssues(issues, mergedAtFunc)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	linked := results[0]
+	if linked.LeadTime != 96*time.Hour || linked.CycleTime != 72*time.Hour {
+		t.Errorf("Expected lead time 96h and cycle time 72h, got %v and %v", linked.LeadTime, linked.CycleTime)
+	}
+	if len(linked.PullRequests) != 1 {
+		t.Errorf("Expected only the GitHub PR to be linked, got %v", linked.PullRequests)
+	}
+	if linked.TimeToFirstPR != 6*time.Hour {
+		t.Errorf("Expected TimeToFirstPR 6h, got %v", linked.TimeToFirstPR)
+	}
+	if linked.MergeToDone != 24*time.Hour {
+		t.Errorf("Expected MergeToDone 24h, got %v", linked.MergeToDone)
+	}
+
+	unlinked := results[1]
+	if unlinked.CycleTime != 0 || len(unlinked.PullRequests) != 0 {
+		t.Errorf("Expected no cycle time or PRs for an unstarted, unlinked issue, got %+v", unlinked)
+	}
+}
@@ -0,0 +1,42 @@
+package linear
+
+import "time"
+
+// Issue is a completed Linear issue with its workflow timestamps and attachments
+type Issue struct {
+	Identifier  string // e.g. ENG-123
+	Title       string
+	URL         string
+	Assignee    string
+	CreatedAt   time.Time
+	StartedAt   *time.Time // nil if the issue skipped the started state
+	CompletedAt *time.Time
+	Attachments []Attachment
+}
+
+// Attachment is a link attached to an issue, such as a GitHub PR
+type Attachment struct {
+	URL        string    `json:"url"`
+	SourceType string    `json:"sourceType"` // e.g. "github"
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// IssueMetric represents the planning and delivery timings for a single issue
+type IssueMetric struct {
+	Identifier    string
+	Title         string
+	Assignee      string
+	LeadTime      time.Duration // Created to completed
+	CycleTime     time.Duration // Started to completed, 0 if never started
+	PullRequests  []string      // Linked GitHub PR URLs
+	TimeToFirstPR time.Duration // Started (or created) until the first PR was linked, 0 if none
+	MergeToDone   time.Duration // Last linked PR merged until the issue was completed, 0 if unknown
+}
+
+// Cycle is a Linear team's sprint
+type Cycle struct {
+	Number   int       `json:"number"`
+	Name     string    `json:"name"` // Often empty; Linear shows "Cycle <number>" instead
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
@@ -0,0 +1,72 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is the -format flag value that selects Markdown output
+const Format = "markdown"
+
+// ParseFormat validates a -format flag value, reporting whether Markdown was requested
+func ParseFormat(s string) (bool, error) {
+	switch s {
+	case "", "text":
+		return false, nil
+	case Format:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported format %q (supported: text, markdown)", s)
+	}
+}
+
+// Table writes a GitHub-flavored Markdown table
+func Table(w io.Writer, headers []string, rows [][]string) {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(escapeAll(headers), " | "))
+	separators := make([]string, len(headers))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	fmt.Fprintf(w, "|%s|\n", strings.Join(separators, "|"))
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %s |\n", strings.Join(escapeAll(row), " | "))
+	}
+}
+
+// Escape makes s safe to use inside a table cell
+func Escape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func escapeAll(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = Escape(cell)
+	}
+	return escaped
+}
+
+// Duration formats d compactly to its two most significant units (e.g. "2d 3h", "4h 12m", "45m"),
+// which reads better in a chat message than time.Duration's "51h12m0s"
+func Duration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return "<1m"
+	}
+}
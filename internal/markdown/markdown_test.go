@@ -0,0 +1,42 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTable(t *testing.T) {
+	var sb strings.Builder
+	Table(&sb, []string{"PR", "Title"}, [][]string{
+		{"#1", "Fix a | b"},
+		{"#2", "Multi\nline"},
+	})
+
+	expected := "| PR | Title |\n" +
+		"|---|---|\n" +
+		"| #1 | Fix a \\| b |\n" +
+		"| #2 | Multi line |\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "-"},
+		{30 * time.Second, "<1m"},
+		{45 * time.Minute, "45m"},
+		{4*time.Hour + 12*time.Minute + 30*time.Second, "4h 12m"},
+		{51*time.Hour + 12*time.Minute, "2d 3h"},
+	}
+
+	for _, tt := range tests {
+		if got := Duration(tt.d); got != tt.expected {
+			t.Errorf("Duration(%v): expected %q, got %q", tt.d, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package netlify reads deployments from Netlify, for frontend teams that deploy
+// there rather than with Cloud Deploy. Each deploy that became ready is a
+// release.
+package netlify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	netlifyAPIBaseURL = "https://api.netlify.com/api/v1"
+	defaultTimeout    = 30 * time.Second
+	pageSize          = 100
+)
+
+// NetlifyClient handles Netlify API operations
+type NetlifyClient struct {
+	httpClient *http.Client
+	token      string
+	sites      []string
+	target     string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+	commitTime deploy.CommitTimeFunc
+
+	// The deploys listed, for the other methods
+	mu      sync.Mutex
+	deploys map[string]netlifyDeploy
+}
+
+var _ deploy.DeployProvider = (*NetlifyClient)(nil)
+
+// NewNetlifyClient creates a new client for the given sites, by ID or name,
+// authenticated with a personal access token. commitTime looks up when deployed
+// commits were made, usually in the sites' GitHub repository.
+func NewNetlifyClient(token string, sites []string, commitTime deploy.CommitTimeFunc) *NetlifyClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &NetlifyClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:      token,
+		sites:      sites,
+		target:     "production",
+		baseURL:    netlifyAPIBaseURL,
+		retry:      retrying,
+		commitTime: commitTime,
+		deploys:    make(map[string]netlifyDeploy),
+	}
+}
+
+// SetTarget sets which deploys are counted: production (the default), or preview
+// for deploy previews of PRs and branch deploys
+func (c *NetlifyClient) SetTarget(target string) {
+	c.target = target
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *NetlifyClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *NetlifyClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type netlifyDeploy struct {
+	ID          string     `json:"id"`
+	State       string     `json:"state"`
+	Context     string     `json:"context"` // production, deploy-preview or branch-deploy
+	CommitRef   string     `json:"commit_ref"`
+	Title       string     `json:"title"`     // The commit message's first line
+	ReviewID    int        `json:"review_id"` // The PR a deploy preview was built for
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// ListReleases lists the sites' deploys for the target made in the
+// date range that became ready, newest first within each site. Deploys that failed
+// or were cancelled, and ones not built from a commit, such as manual uploads, are
+// left out. If the API budget runs out part way through, the releases found so far
+// are returned along with the error.
+func (c *NetlifyClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, site := range c.sites {
+		// Deploys are listed newest first, with no date filter
+	pages:
+		for page := 1; ; page++ {
+			query := url.Values{
+				"page":     {strconv.Itoa(page)},
+				"per_page": {strconv.Itoa(pageSize)},
+			}
+			var deploys []netlifyDeploy
+			if err := c.get(ctx, "/sites/"+url.PathEscape(site)+"/deploys", query, &deploys); err != nil {
+				return releases, fmt.Errorf("failed to list deploys for %s: %w", site, err)
+			}
+			for _, d := range deploys {
+				if d.CreatedAt.Before(startDate) {
+					break pages
+				}
+				if d.CreatedAt.After(endDate) || d.State != "ready" || d.CommitRef == "" || !c.targeted(d) {
+					continue
+				}
+				releases = append(releases, c.release(site, d))
+			}
+			if len(deploys) < pageSize {
+				break
+			}
+		}
+	}
+	return releases, nil
+}
+
+// targeted reports whether a deploy is to the client's target
+func (c *NetlifyClient) targeted(d netlifyDeploy) bool {
+	if c.target == "production" {
+		return d.Context == "production"
+	}
+	return d.Context != "production"
+}
+
+// release converts a Netlify deploy to a deploy.Release, remembering it for
+// ExtractSource and GetRolloutCompletion
+func (c *NetlifyClient) release(site string, d netlifyDeploy) *deploy.Release {
+	// The deploy ID comes last, so it's used as the release ID
+	name := fmt.Sprintf("sites/%s/deploys/%s", site, d.ID)
+	c.mu.Lock()
+	c.deploys[name] = d
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        name,
+		CreateTime:  d.CreatedAt,
+		Annotations: map[string]string{"site": site, "context": d.Context},
+	}
+}
+
+// ExtractSource returns the commit a deploy built and when it was
+// made. The PR is the one a deploy preview was built for, or otherwise the one the
+// commit merged if its message names one.
+func (c *NetlifyClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	d, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	prNumber := deploy.PRFromCommitMessage(d.Title)
+	if d.ReviewID != 0 {
+		prNumber = strconv.Itoa(d.ReviewID)
+	}
+	commitTime, err := c.commitTime(ctx, d.CommitRef)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", d.CommitRef, err)
+	}
+	return d.CommitRef, prNumber, commitTime, nil
+}
+
+// GetRolloutCompletion returns when the deploy was published, or for previews and
+// branch deploys, which aren't published, when it became ready
+func (c *NetlifyClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	d, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.PublishedAt != nil {
+		return *d.PublishedAt, nil
+	}
+	return d.UpdatedAt, nil
+}
+
+// listed returns the Netlify deploy a release was converted from
+func (c *NetlifyClient) listed(release *deploy.Release) (netlifyDeploy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.deploys[release.Name]
+	if !ok {
+		return netlifyDeploy{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return d, nil
+}
+
+// get makes a Netlify API request and decodes the response into result
+func (c *NetlifyClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
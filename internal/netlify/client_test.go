@@ -0,0 +1,91 @@
+package netlify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024 as Netlify encodes times
+func at(hour int) string {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)
+}
+
+func TestNetlifyClient(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/sites/shop/deploys" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 404, "message": "Not Found"}`))
+			return
+		}
+		if r.URL.Query().Get("page") != "1" {
+			t.Errorf("Expected only the first page, got %v", r.URL.Query())
+		}
+		fmt.Fprintf(w, `[
+			{"id": "d4", "state": "ready", "context": "production", "commit_ref": "abc1234", "title": "Add search (#42)", "created_at": %q, "updated_at": %q, "published_at": %q},
+			{"id": "d3", "state": "ready", "context": "deploy-preview", "commit_ref": "fff0000", "title": "WIP", "review_id": 77, "created_at": %q, "updated_at": %q, "published_at": null},
+			{"id": "d2", "state": "error", "context": "production", "commit_ref": "bad0000", "created_at": %q, "updated_at": %q},
+			{"id": "d1", "state": "ready", "context": "production", "commit_ref": "", "created_at": %q, "updated_at": %q},
+			{"id": "d0", "state": "ready", "context": "production", "commit_ref": "old0000", "created_at": "2024-05-01T00:00:00Z", "updated_at": "2024-05-01T00:05:00Z"}
+		]`, at(10), at(11), at(11), at(8), at(9), at(6), at(7), at(4), at(5))
+	}))
+	defer server.Close()
+
+	commitTime := func(ctx context.Context, sha string) (time.Time, error) {
+		return time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), nil
+	}
+	client := NewNetlifyClient("token", []string{"shop"}, commitTime)
+	client.baseURL = server.URL
+
+	start, end := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)
+	releases, err := client.ListReleases(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected paging to stop at the start date, got %d calls", calls)
+	}
+	// The preview, the failed deploy and the manual upload are left out
+	if len(releases) != 1 || releases[0].Name != "sites/shop/deploys/d4" {
+		t.Fatalf("Expected d4, got %v", releases)
+	}
+
+	sha, prNumber, committed, err := client.ExtractSource(context.Background(), releases[0])
+	if err != nil || sha != "abc1234" || prNumber != "42" || committed.IsZero() {
+		t.Errorf("Expected PR #42's squashed commit, got %s %s %v (%v)", sha, prNumber, committed, err)
+	}
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[0])
+	if err != nil || finish.Format(time.RFC3339) != at(11) {
+		t.Errorf("Expected the deploy's publish time, got %v (%v)", finish, err)
+	}
+
+	// Previews know the PR they were built for, and finish when they're ready
+	previews := NewNetlifyClient("token", []string{"shop"}, commitTime)
+	previews.baseURL = server.URL
+	previews.SetTarget("preview")
+	releases, err = previews.ListReleases(context.Background(), start, end)
+	if err != nil || len(releases) != 1 || releases[0].Name != "sites/shop/deploys/d3" {
+		t.Fatalf("Expected d3, got %v (%v)", releases, err)
+	}
+	if _, prNumber, _, _ := previews.ExtractSource(context.Background(), releases[0]); prNumber != "77" {
+		t.Errorf("Expected the preview's PR, got %q", prNumber)
+	}
+	if finish, _ := previews.GetRolloutCompletion(context.Background(), releases[0]); finish.Format(time.RFC3339) != at(9) {
+		t.Errorf("Expected the preview's ready time, got %v", finish)
+	}
+
+	missing := NewNetlifyClient("token", []string{"missing"}, commitTime)
+	missing.baseURL = server.URL
+	if _, err := missing.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: Not Found") {
+		t.Errorf("Expected Netlify's error, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Package pagerduty looks up who was on call, so per-person reports can explain
+// dips in throughput during on-call weeks, and which incidents were opened, so
+// changes can be correlated with them.
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+const (
+	pagerDutyAPIURL = "https://api.pagerduty.com"
+	defaultTimeout  = 30 * time.Second
+	pageSize        = 100
+)
+
+// PagerDutyClient handles PagerDuty API operations
+type PagerDutyClient struct {
+	httpClient *http.Client
+	token      string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+}
+
+// NewPagerDutyClient creates a new PagerDuty client authenticated with a REST API key
+func NewPagerDutyClient(token string) *PagerDutyClient {
+	return &PagerDutyClient{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		token:   token,
+		baseURL: pagerDutyAPIURL,
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *PagerDutyClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+type oncallsResponse struct {
+	Oncalls []struct {
+		User struct {
+			Summary string `json:"summary"`
+			Name    string `json:"name"`
+			Email   string `json:"email"`
+		} `json:"user"`
+		Start *time.Time `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"oncalls"`
+	More bool `json:"more"`
+}
+
+// FetchShifts fetches the on-call shifts for the schedules that overlap the date
+// range. Shifts are clipped to the range. If the API budget runs out part way
+// through, the shifts fetched so far are returned along with the error.
+func (c *PagerDutyClient) FetchShifts(ctx context.Context, scheduleIDs []string, startDate, endDate time.Time) ([]Shift, error) {
+	var allShifts []Shift
+	for offset := 0; ; offset += pageSize {
+		if err := c.budget.Spend(); err != nil {
+			return allShifts, err
+		}
+
+		params := url.Values{}
+		params.Set("since", startDate.Format(time.RFC3339))
+		params.Set("until", endDate.Format(time.RFC3339))
+		params.Set("limit", strconv.Itoa(pageSize))
+		params.Set("offset", strconv.Itoa(offset))
+		params.Add("include[]", "users")
+		for _, id := range scheduleIDs {
+			params.Add("schedule_ids[]", id)
+		}
+
+		var resp oncallsResponse
+		if err := c.get(ctx, "/oncalls?"+params.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch on-call shifts: %w", err)
+		}
+
+		for _, oncall := range resp.Oncalls {
+			// Permanent on-call entries have no start or end
+			shift := Shift{Name: oncall.User.Name, Email: oncall.User.Email, Start: startDate, End: endDate}
+			if shift.Name == "" {
+				shift.Name = oncall.User.Summary
+			}
+			if oncall.Start != nil && oncall.Start.After(startDate) {
+				shift.Start = *oncall.Start
+			}
+			if oncall.End != nil && oncall.End.Before(endDate) {
+				shift.End = *oncall.End
+			}
+			allShifts = append(allShifts, shift)
+		}
+
+		if !resp.More {
+			break
+		}
+	}
+
+	return allShifts, nil
+}
+
+type incidentsResponse struct {
+	Incidents []struct {
+		IncidentNumber int        `json:"incident_number"`
+		Title          string     `json:"title"`
+		Urgency        string     `json:"urgency"`
+		CreatedAt      time.Time  `json:"created_at"`
+		ResolvedAt     *time.Time `json:"resolved_at"`
+		Service        struct {
+			Summary string `json:"summary"`
+		} `json:"service"`
+	} `json:"incidents"`
+	More bool `json:"more"`
+}
+
+// FetchIncidents fetches the incidents opened on the services in the date range,
+// or on every service if none are given. If the API budget runs out part way
+// through, the incidents fetched so far are returned along with the error.
+func (c *PagerDutyClient) FetchIncidents(ctx context.Context, serviceIDs []string, startDate, endDate time.Time) ([]Incident, error) {
+	var incidents []Incident
+	for offset := 0; ; offset += pageSize {
+		if err := c.budget.Spend(); err != nil {
+			return incidents, err
+		}
+
+		params := url.Values{}
+		params.Set("since", startDate.Format(time.RFC3339))
+		params.Set("until", endDate.Format(time.RFC3339))
+		params.Set("limit", strconv.Itoa(pageSize))
+		params.Set("offset", strconv.Itoa(offset))
+		for _, id := range serviceIDs {
+			params.Add("service_ids[]", id)
+		}
+
+		var resp incidentsResponse
+		if err := c.get(ctx, "/incidents?"+params.Encode(), &resp); err != nil {
+			return incidents, fmt.Errorf("failed to fetch incidents: %w", err)
+		}
+
+		for _, incident := range resp.Incidents {
+			i := Incident{
+				Number:    incident.IncidentNumber,
+				Title:     incident.Title,
+				Service:   incident.Service.Summary,
+				Urgency:   incident.Urgency,
+				CreatedAt: incident.CreatedAt,
+			}
+			if incident.ResolvedAt != nil {
+				i.ResolvedAt = *incident.ResolvedAt
+			}
+			incidents = append(incidents, i)
+		}
+
+		if !resp.More {
+			break
+		}
+	}
+
+	return incidents, nil
+}
+
+// get makes a GET request to the API and decodes the response into result
+func (c *PagerDutyClient) get(ctx context.Context, path string, result interface{}) error {
+	endpoint := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Token token="+c.token)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Close cleans up the client (no-op for HTTP client)
+func (c *PagerDutyClient) Close() error {
+	return nil
+}
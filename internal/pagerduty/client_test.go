@@ -0,0 +1,116 @@
+package pagerduty
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/period"
+)
+
+func TestPagerDutyClient_FetchShifts(t *testing.T) {
+	pages := []string{
+		`{"more":true,"oncalls":[
+			{"user":{"summary":"Alice Smith","name":"Alice Smith","email":"alice@example.com"},"start":"2024-03-04T09:00:00Z","end":"2024-03-11T09:00:00Z"}]}`,
+		`{"more":false,"oncalls":[
+			{"user":{"summary":"Bob Jones","email":"bob@example.com"},"start":null,"end":null}]}`,
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Token token=test-key" {
+			t.Errorf("Expected Authorization header 'Token token=test-key', got '%s'", auth)
+		}
+		if r.URL.Path != "/oncalls" {
+			t.Errorf("Expected /oncalls, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if ids := q["schedule_ids[]"]; len(ids) != 2 || ids[0] != "P1" || ids[1] != "P2" {
+			t.Errorf("Expected schedules P1 and P2, got %v", ids)
+		}
+		if want := []string{"0", "100"}[requests]; q.Get("offset") != want {
+			t.Errorf("Expected offset %s, got %s", want, q.Get("offset"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(pages[requests]))
+		requests++
+	}))
+	defer server.Close()
+
+	client := NewPagerDutyClient("test-key")
+	client.baseURL = server.URL
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	shifts, err := client.FetchShifts(context.Background(), []string{"P1", "P2"}, start, end)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(shifts) != 2 {
+		t.Fatalf("Expected 2 shifts, got %d", len(shifts))
+	}
+	if shifts[0].Name != "Alice Smith" || shifts[0].Email != "alice@example.com" || !shifts[0].Start.Equal(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected first shift: %+v", shifts[0])
+	}
+	// Permanent on-call covers the whole range, and falls back to the user's summary for a name
+	if shifts[1].Name != "Bob Jones" || !shifts[1].Start.Equal(start) || !shifts[1].End.Equal(end) {
+		t.Errorf("Unexpected permanent shift: %+v", shifts[1])
+	}
+
+	week := period.Period{Start: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)}
+	if !shifts[0].Overlaps(week) {
+		t.Error("Expected a shift ending Monday morning to overlap that week")
+	}
+	week.Start, week.End = week.Start.AddDate(0, 0, 7), week.End.AddDate(0, 0, 7)
+	if shifts[0].Overlaps(week) {
+		t.Error("Expected the shift not to overlap the week after")
+	}
+}
+
+func TestPagerDutyClient_FetchShifts_BudgetExhausted(t *testing.T) {
+	client := NewPagerDutyClient("test-key")
+	client.SetBudget(budget.New("PagerDuty", 1))
+	client.budget.Spend()
+
+	_, err := client.FetchShifts(context.Background(), []string{"P1"}, time.Now().AddDate(0, 0, -7), time.Now())
+	if !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
+
+func TestPagerDutyClient_FetchIncidents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/incidents" {
+			t.Errorf("Expected /incidents, got %s", r.URL.Path)
+		}
+		if ids := r.URL.Query()["service_ids[]"]; len(ids) != 1 || ids[0] != "S1" {
+			t.Errorf("Expected service S1, got %v", ids)
+		}
+		w.Write([]byte(`{"more":false,"incidents":[
+			{"incident_number":12,"title":"Checkout errors","urgency":"high","created_at":"2024-03-04T10:15:00Z","resolved_at":"2024-03-04T11:00:00Z","service":{"summary":"Checkout"}},
+			{"incident_number":13,"title":"Still broken","urgency":"low","created_at":"2024-03-05T10:15:00Z","resolved_at":null,"service":{"summary":"Checkout"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewPagerDutyClient("test-key")
+	client.baseURL = server.URL
+
+	incidents, err := client.FetchIncidents(context.Background(), []string{"S1"}, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []Incident{
+		{Number: 12, Title: "Checkout errors", Service: "Checkout", Urgency: "high", CreatedAt: time.Date(2024, 3, 4, 10, 15, 0, 0, time.UTC), ResolvedAt: time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{Number: 13, Title: "Still broken", Service: "Checkout", Urgency: "low", CreatedAt: time.Date(2024, 3, 5, 10, 15, 0, 0, time.UTC)},
+	}
+	if !slices.Equal(incidents, want) {
+		t.Errorf("Expected %+v, got %+v", want, incidents)
+	}
+}
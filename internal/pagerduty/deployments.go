@@ -0,0 +1,166 @@
+package pagerduty
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// MarkIncidents records on each successful deployment the incidents opened on its
+// service within window after it went live. A deployment's service is its
+// Service, or the PagerDuty service services maps that to, if it's named
+// differently in PagerDuty; names are compared ignoring case. Incidents already
+// recorded, as on results stored by an earlier incremental run, are replaced.
+func MarkIncidents(results []deploy.DeploymentMetric, incidents []Incident, window time.Duration, services map[string]string) {
+	for i, result := range results {
+		results[i].FollowingIncidents = nil
+		liveAt := result.ReleaseFinishTime
+		if !result.DeploymentSuccessful || liveAt.IsZero() {
+			continue
+		}
+		service := pagerDutyService(result, services)
+		for _, incident := range incidents {
+			if strings.EqualFold(incident.Service, service) && !incident.CreatedAt.Before(liveAt) && !incident.CreatedAt.After(liveAt.Add(window)) {
+				results[i].FollowingIncidents = append(results[i].FollowingIncidents, incident.String())
+			}
+		}
+	}
+}
+
+// pagerDutyService returns the name of the PagerDuty service a deployment deployed
+func pagerDutyService(result deploy.DeploymentMetric, services map[string]string) string {
+	service := result.Service()
+	if mapped, ok := services[service]; ok {
+		return mapped
+	}
+	return service
+}
+
+// ServiceRisk is how often a service's deployments were followed by an incident
+type ServiceRisk struct {
+	Service            string
+	Deployments        int // Successful deployments
+	FollowedByIncident int // Deployments followed by at least one incident
+}
+
+// Score returns the share of the service's deployments followed by an incident
+func (r ServiceRisk) Score() float64 {
+	if r.Deployments == 0 {
+		return 0
+	}
+	return float64(r.FollowedByIncident) / float64(r.Deployments)
+}
+
+// SummarizeRisk returns the risk of each service with successful deployments,
+// sorted by service
+func SummarizeRisk(results []deploy.DeploymentMetric) []ServiceRisk {
+	byService := make(map[string]*ServiceRisk)
+	for _, result := range results {
+		if !result.DeploymentSuccessful || result.ReleaseFinishTime.IsZero() {
+			continue
+		}
+		service := result.Service()
+		risk, ok := byService[service]
+		if !ok {
+			risk = &ServiceRisk{Service: service}
+			byService[service] = risk
+		}
+		risk.Deployments++
+		if len(result.FollowingIncidents) > 0 {
+			risk.FollowedByIncident++
+		}
+	}
+
+	risks := make([]ServiceRisk, 0, len(byService))
+	for _, risk := range byService {
+		risks = append(risks, *risk)
+	}
+	slices.SortFunc(risks, func(a, b ServiceRisk) int {
+		return strings.Compare(a.Service, b.Service)
+	})
+	return risks
+}
+
+// Restore is a resolved incident, and the deployment that remediated it, if any
+type Restore struct {
+	Incident      Incident
+	Remediation   string        // Release ID of the deployment that remediated it, "" if none did
+	TimeToRestore time.Duration // From the incident opening to its resolution
+}
+
+// PairRestores pairs each resolved incident with the deployment that remediated
+// it: the last successful deployment of its service to go live while it was open.
+// Services are matched as in MarkIncidents. Incidents that haven't been resolved
+// are left out.
+func PairRestores(results []deploy.DeploymentMetric, incidents []Incident, services map[string]string) []Restore {
+	var restores []Restore
+	for _, incident := range incidents {
+		if incident.ResolvedAt.IsZero() {
+			continue
+		}
+		restore := Restore{Incident: incident, TimeToRestore: incident.ResolvedAt.Sub(incident.CreatedAt)}
+		var remediatedAt time.Time
+		for _, result := range results {
+			liveAt := result.ReleaseFinishTime
+			if !result.DeploymentSuccessful || liveAt.Before(incident.CreatedAt) || liveAt.After(incident.ResolvedAt) {
+				continue
+			}
+			if strings.EqualFold(pagerDutyService(result, services), incident.Service) && liveAt.After(remediatedAt) {
+				restore.Remediation, remediatedAt = result.ReleaseID, liveAt
+			}
+		}
+		restores = append(restores, restore)
+	}
+	return restores
+}
+
+// ServiceRestores summarizes how long a service's incidents took to resolve
+type ServiceRestores struct {
+	Service    string
+	Incidents  int // Resolved incidents
+	Remediated int // Those remediated by a deployment
+
+	// Mean time to restore, over the incidents remediated by a deployment
+	MeanTimeToRestore   time.Duration
+	MedianTimeToRestore time.Duration
+}
+
+// SummarizeRestores returns the time to restore each service with resolved
+// incidents, sorted by service
+func SummarizeRestores(restores []Restore) []ServiceRestores {
+	byService := make(map[string]*ServiceRestores)
+	durations := make(map[string][]time.Duration)
+	for _, restore := range restores {
+		service := restore.Incident.Service
+		summary, ok := byService[service]
+		if !ok {
+			summary = &ServiceRestores{Service: service}
+			byService[service] = summary
+		}
+		summary.Incidents++
+		if restore.Remediation != "" {
+			summary.Remediated++
+			durations[service] = append(durations[service], restore.TimeToRestore)
+		}
+	}
+
+	summaries := make([]ServiceRestores, 0, len(byService))
+	for service, summary := range byService {
+		if times := durations[service]; len(times) > 0 {
+			var total time.Duration
+			for _, d := range times {
+				total += d
+			}
+			summary.MeanTimeToRestore = total / time.Duration(len(times))
+			summary.MedianTimeToRestore = stats.MedianOf(times)
+		}
+		summaries = append(summaries, *summary)
+	}
+	slices.SortFunc(summaries, func(a, b ServiceRestores) int {
+		return strings.Compare(a.Service, b.Service)
+	})
+	return summaries
+}
@@ -0,0 +1,96 @@
+package pagerduty
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/deploy"
+)
+
+func TestMarkIncidents(t *testing.T) {
+	liveAt := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	results := []deploy.DeploymentMetric{
+		{ReleaseID: "shop-1", ReleaseName: "apps/shop/releases/shop-v1", ReleaseFinishTime: liveAt, DeploymentSuccessful: true},
+		{ReleaseID: "shop-2", ReleaseName: "apps/shop/releases/shop-v2", ReleaseFinishTime: liveAt.Add(24 * time.Hour), DeploymentSuccessful: true},
+		{ReleaseID: "web-1", ReleaseName: "services/srv-web/deploys/dep-1", ReleaseFinishTime: liveAt, DeploymentSuccessful: true},
+		{ReleaseID: "web-2", ReleaseName: "services/srv-web/deploys/dep-2", ReleaseStartTime: liveAt},
+	}
+	incidents := []Incident{
+		{Number: 12, Title: "Checkout errors", Service: "Shop", CreatedAt: liveAt.Add(time.Hour)},
+		{Number: 13, Title: "Slow pages", Service: "Web", CreatedAt: liveAt.Add(30 * time.Minute)},
+		{Number: 14, Title: "Too late", Service: "Shop", CreatedAt: liveAt.Add(5 * time.Hour)},
+		{Number: 15, Title: "Before", Service: "Shop", CreatedAt: liveAt.Add(-time.Minute)},
+	}
+
+	MarkIncidents(results, incidents, 4*time.Hour, map[string]string{"srv-web": "web"})
+
+	expected := [][]string{{"#12 Checkout errors"}, nil, {"#13 Slow pages"}, nil}
+	for i, want := range expected {
+		if got := results[i].FollowingIncidents; !slices.Equal(got, want) {
+			t.Errorf("%s: expected incidents %v, got %v", results[i].ReleaseID, want, got)
+		}
+	}
+
+	risks := SummarizeRisk(results)
+	expectedRisks := []ServiceRisk{
+		{Service: "shop", Deployments: 2, FollowedByIncident: 1},
+		{Service: "srv-web", Deployments: 1, FollowedByIncident: 1},
+	}
+	if !slices.Equal(risks, expectedRisks) {
+		t.Fatalf("Expected risks %+v, got %+v", expectedRisks, risks)
+	}
+	if risks[0].Score() != 0.5 {
+		t.Errorf("Expected a risk score of 0.5 for shop, got %v", risks[0].Score())
+	}
+}
+
+func TestPairRestores(t *testing.T) {
+	openedAt := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	results := []deploy.DeploymentMetric{
+		{ReleaseID: "shop-1", ReleaseName: "apps/shop/releases/shop-v1", ReleaseFinishTime: openedAt.Add(-time.Hour), DeploymentSuccessful: true},
+		{ReleaseID: "shop-2", ReleaseName: "apps/shop/releases/shop-v2", ReleaseFinishTime: openedAt.Add(30 * time.Minute), DeploymentSuccessful: true},
+		{ReleaseID: "shop-3", ReleaseName: "apps/shop/releases/shop-v3", ReleaseFinishTime: openedAt.Add(90 * time.Minute), DeploymentSuccessful: true},
+		{ReleaseID: "shop-4", ReleaseName: "apps/shop/releases/shop-v4", ReleaseStartTime: openedAt.Add(100 * time.Minute)},
+		{ReleaseID: "web-1", ReleaseName: "services/srv-web/deploys/dep-1", ReleaseFinishTime: openedAt.Add(time.Hour), DeploymentSuccessful: true},
+	}
+	incidents := []Incident{
+		// The fix that went live last before it was resolved remediated it
+		{Number: 12, Service: "Shop", CreatedAt: openedAt, ResolvedAt: openedAt.Add(2 * time.Hour)},
+		// Resolved without a deployment
+		{Number: 13, Service: "Shop", CreatedAt: openedAt.Add(3 * time.Hour), ResolvedAt: openedAt.Add(4 * time.Hour)},
+		{Number: 14, Service: "Web", CreatedAt: openedAt, ResolvedAt: openedAt.Add(4 * time.Hour)},
+		// Still open
+		{Number: 15, Service: "Web", CreatedAt: openedAt},
+	}
+
+	restores := PairRestores(results, incidents, map[string]string{"srv-web": "web"})
+
+	expected := []struct {
+		number        int
+		remediation   string
+		timeToRestore time.Duration
+	}{
+		{12, "shop-3", 2 * time.Hour},
+		{13, "", time.Hour},
+		{14, "web-1", 4 * time.Hour},
+	}
+	if len(restores) != len(expected) {
+		t.Fatalf("Expected %d restores, got %+v", len(expected), restores)
+	}
+	for i, want := range expected {
+		got := restores[i]
+		if got.Incident.Number != want.number || got.Remediation != want.remediation || got.TimeToRestore != want.timeToRestore {
+			t.Errorf("Restore %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	summaries := SummarizeRestores(restores)
+	expectedSummaries := []ServiceRestores{
+		{Service: "Shop", Incidents: 2, Remediated: 1, MeanTimeToRestore: 2 * time.Hour, MedianTimeToRestore: 2 * time.Hour},
+		{Service: "Web", Incidents: 1, Remediated: 1, MeanTimeToRestore: 4 * time.Hour, MedianTimeToRestore: 4 * time.Hour},
+	}
+	if !slices.Equal(summaries, expectedSummaries) {
+		t.Errorf("Expected %+v, got %+v", expectedSummaries, summaries)
+	}
+}
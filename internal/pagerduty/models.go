@@ -0,0 +1,36 @@
+package pagerduty
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/period"
+)
+
+// Shift is a stretch of time someone was on call for a schedule
+type Shift struct {
+	Name  string
+	Email string
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps reports whether any of the shift falls within p
+func (s Shift) Overlaps(p period.Period) bool {
+	return s.Start.Before(p.End) && s.End.After(p.Start)
+}
+
+// Incident is a PagerDuty incident, used to see which changes were followed by one
+type Incident struct {
+	Number     int
+	Title      string
+	Service    string
+	Urgency    string // high or low
+	CreatedAt  time.Time
+	ResolvedAt time.Time // Zero if it hasn't been resolved
+}
+
+// String returns the incident's number and title, e.g. "#12 Checkout errors"
+func (i Incident) String() string {
+	return fmt.Sprintf("#%d %s", i.Number, i.Title)
+}
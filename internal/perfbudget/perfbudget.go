@@ -0,0 +1,60 @@
+// Package perfbudget holds benchmarks to performance budgets, so a change that makes
+// processing or exporting PRs much slower, or much more allocation-heavy, fails the
+// tests instead of going unnoticed until a large run crawls
+package perfbudget
+
+import (
+	"testing"
+	"time"
+)
+
+// Budget is the most a benchmark may spend per item it handles, such as a PR
+// processed or a record written
+type Budget struct {
+	// Time is generous, since test machines vary a lot; it catches slowdowns of
+	// several times, not a few percent
+	Time time.Duration
+	// Allocs doesn't depend on the machine, so it can be kept close to the
+	// measured figure
+	Allocs float64
+}
+
+// Result is what a benchmark spent per item
+type Result struct {
+	Time   time.Duration
+	Allocs float64
+}
+
+// Measure runs fn, which handles items items, as a benchmark and returns what it
+// spent per item
+func Measure(items int, fn func()) Result {
+	r := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fn()
+		}
+	})
+	return Result{
+		Time:   time.Duration(r.NsPerOp() / int64(items)),
+		Allocs: float64(r.AllocsPerOp()) / float64(items),
+	}
+}
+
+// Check measures fn, which handles items items, and fails t if it goes over
+// budget. Measurements are logged, so `go test -v -run Budget` shows how much
+// headroom is left. It's skipped with -short.
+func Check(t *testing.T, budget Budget, items int, fn func()) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping performance budget with -short")
+	}
+
+	got := Measure(items, fn)
+	t.Logf("%v and %.1f allocs per item (budget %v and %.1f)", got.Time, got.Allocs, budget.Time, budget.Allocs)
+	if got.Time > budget.Time {
+		t.Errorf("Took %v per item, over the budget of %v", got.Time, budget.Time)
+	}
+	if got.Allocs > budget.Allocs {
+		t.Errorf("Made %.1f allocations per item, over the budget of %.1f", got.Allocs, budget.Allocs)
+	}
+}
@@ -0,0 +1,185 @@
+package period
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period is a reporting interval, such as a week or sprint. Start is inclusive and End exclusive.
+type Period struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls within the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Weeks returns the calendar weeks (starting Monday) that overlap startDate to endDate
+func Weeks(startDate, endDate time.Time) []Period {
+	// Back up to the Monday on or before startDate
+	first := truncateToDay(startDate)
+	first = first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+
+	var periods []Period
+	for start := first; start.Before(endDate); start = start.AddDate(0, 0, 7) {
+		year, week := start.ISOWeek()
+		periods = append(periods, Period{
+			Name:  fmt.Sprintf("%d-W%02d", year, week),
+			Start: start,
+			End:   start.AddDate(0, 0, 7),
+		})
+	}
+	return periods
+}
+
+// Months returns the calendar months that overlap startDate to endDate
+func Months(startDate, endDate time.Time) []Period {
+	first := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location())
+
+	var periods []Period
+	for start := first; start.Before(endDate); start = start.AddDate(0, 1, 0) {
+		periods = append(periods, Period{
+			Name:  start.Format("2006-01"),
+			Start: start,
+			End:   start.AddDate(0, 1, 0),
+		})
+	}
+	return periods
+}
+
+// Sprints returns the fixed-length sprints that overlap startDate to endDate, given
+// the start date of any one sprint (past or future) and the sprint length in days
+func Sprints(anchor time.Time, lengthDays int, startDate, endDate time.Time) []Period {
+	anchor = truncateToDay(anchor)
+
+	// Step from the anchor to the sprint containing startDate
+	first := anchor
+	for first.After(startDate) {
+		first = first.AddDate(0, 0, -lengthDays)
+	}
+	for !first.AddDate(0, 0, lengthDays).After(startDate) {
+		first = first.AddDate(0, 0, lengthDays)
+	}
+
+	var periods []Period
+	for start := first; start.Before(endDate); start = start.AddDate(0, 0, lengthDays) {
+		periods = append(periods, Period{
+			Name:  "Sprint " + start.Format("2006-01-02"),
+			Start: start,
+			End:   start.AddDate(0, 0, lengthDays),
+		})
+	}
+	return periods
+}
+
+// Group buckets items into periods by the time at returns for each. The result has
+// one slice per period, in the same order; items outside every period are dropped.
+func Group[T any](periods []Period, items []T, at func(T) time.Time) [][]T {
+	groups := make([][]T, len(periods))
+	for _, item := range items {
+		t := at(item)
+		for i, p := range periods {
+			if p.Contains(t) {
+				groups[i] = append(groups[i], item)
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// ParseRange parses a date range given as YYYY-MM-DD..YYYY-MM-DD, both days
+// included, into a period with the given name
+func ParseRange(name, s string) (Period, error) {
+	first, last, ok := strings.Cut(s, "..")
+	if !ok {
+		return Period{}, fmt.Errorf("invalid date range %q. Please use YYYY-MM-DD..YYYY-MM-DD", s)
+	}
+	start, err := time.Parse("2006-01-02", first)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid date range %q. Please use YYYY-MM-DD..YYYY-MM-DD: %w", s, err)
+	}
+	end, err := time.Parse("2006-01-02", last)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid date range %q. Please use YYYY-MM-DD..YYYY-MM-DD: %w", s, err)
+	}
+	if end.Before(start) {
+		return Period{}, fmt.Errorf("invalid date range %q: it ends before it starts", s)
+	}
+	return Period{Name: name, Start: start, End: end.AddDate(0, 0, 1)}, nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Flags are the command-line flags that control how a report is bucketed
+type Flags struct {
+	GroupBy      *string
+	SprintStart  *string
+	SprintLength *int
+	LinearTeam   *string
+}
+
+// RegisterFlags defines the bucketing flags shared by the trackers
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		GroupBy:      fs.String("group-by", "", "Also break the summary down by period: week, month or sprint"),
+		SprintStart:  fs.String("sprint-start", "", "Start date of any sprint in YYYY-MM-DD format (used with -group-by sprint)"),
+		SprintLength: fs.Int("sprint-length", 14, "Sprint length in days (used with -group-by sprint)"),
+		LinearTeam:   fs.String("sprint-linear-team", "", "Read sprint boundaries from this Linear team's cycles instead of -sprint-start (needs LINEAR_API_KEY)"),
+	}
+}
+
+// Validate checks the flags before any work is done
+func (f *Flags) Validate() error {
+	switch *f.GroupBy {
+	case "", "week", "month":
+		return nil
+	case "sprint":
+		if *f.LinearTeam != "" {
+			return nil
+		}
+		if *f.SprintStart == "" {
+			return errors.New("-group-by sprint needs -sprint-start or -sprint-linear-team")
+		}
+		if _, err := time.Parse("2006-01-02", *f.SprintStart); err != nil {
+			return fmt.Errorf("invalid -sprint-start. Please use YYYY-MM-DD: %w", err)
+		}
+		if *f.SprintLength < 1 {
+			return errors.New("-sprint-length must be at least 1")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported -group-by %q (supported: week, month, sprint)", *f.GroupBy)
+	}
+}
+
+// Periods returns the periods to bucket a report covering startDate to endDate by,
+// or nil if no bucketing was requested. linearCycles is only called for sprints
+// read from Linear.
+func (f *Flags) Periods(startDate, endDate time.Time, linearCycles func(team string) ([]Period, error)) ([]Period, error) {
+	switch *f.GroupBy {
+	case "week":
+		return Weeks(startDate, endDate), nil
+	case "month":
+		return Months(startDate, endDate), nil
+	case "sprint":
+		if *f.LinearTeam != "" {
+			return linearCycles(*f.LinearTeam)
+		}
+		anchor, err := time.Parse("2006-01-02", *f.SprintStart)
+		if err != nil {
+			return nil, err
+		}
+		return Sprints(anchor, *f.SprintLength, startDate, endDate), nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,102 @@
+package period
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWeeks(t *testing.T) {
+	// 2024-03-06 is a Wednesday
+	periods := Weeks(date("2024-03-06"), date("2024-03-20"))
+
+	if len(periods) != 3 {
+		t.Fatalf("Expected 3 weeks, got %d: %v", len(periods), periods)
+	}
+	if !periods[0].Start.Equal(date("2024-03-04")) {
+		t.Errorf("Expected the first week to start on Monday 2024-03-04, got %v", periods[0].Start)
+	}
+	if periods[0].Name != "2024-W10" {
+		t.Errorf("Expected the first week to be named 2024-W10, got %s", periods[0].Name)
+	}
+}
+
+func TestMonths(t *testing.T) {
+	periods := Months(date("2024-01-15"), date("2024-03-02"))
+
+	if len(periods) != 3 {
+		t.Fatalf("Expected 3 months, got %d: %v", len(periods), periods)
+	}
+	if !periods[0].Start.Equal(date("2024-01-01")) || !periods[0].End.Equal(date("2024-02-01")) {
+		t.Errorf("Expected the first month to be January, got %v to %v", periods[0].Start, periods[0].End)
+	}
+	if periods[1].Name != "2024-02" || !periods[1].End.Equal(date("2024-03-01")) {
+		t.Errorf("Expected the second month to be 2024-02 ending 2024-03-01, got %s ending %v", periods[1].Name, periods[1].End)
+	}
+}
+
+func TestSprints(t *testing.T) {
+	tests := []struct {
+		name   string
+		anchor string
+	}{
+		{"anchor before range", "2024-01-01"},
+		{"anchor after range", "2024-06-03"},
+		{"anchor is first sprint", "2024-02-26"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods := Sprints(date(tt.anchor), 14, date("2024-03-01"), date("2024-04-01"))
+
+			expectedStarts := []string{"2024-02-26", "2024-03-11", "2024-03-25"}
+			if len(periods) != len(expectedStarts) {
+				t.Fatalf("Expected %d sprints, got %d: %v", len(expectedStarts), len(periods), periods)
+			}
+			for i, start := range expectedStarts {
+				if !periods[i].Start.Equal(date(start)) {
+					t.Errorf("Expected sprint %d to start %s, got %v", i, start, periods[i].Start)
+				}
+			}
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	periods := Sprints(date("2024-03-01"), 7, date("2024-03-01"), date("2024-03-15"))
+	items := []time.Time{date("2024-03-02"), date("2024-03-08"), date("2024-03-09"), date("2024-04-01")}
+
+	groups := Group(periods, items, func(t time.Time) time.Time { return t })
+
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+	if len(groups[0]) != 1 || len(groups[1]) != 2 {
+		t.Errorf("Expected 1 and 2 items in the two sprints, got %d and %d", len(groups[0]), len(groups[1]))
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	p, err := ParseRange("A", "2024-01-01..2024-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "A" || !p.Start.Equal(date("2024-01-01")) || !p.End.Equal(date("2024-04-01")) {
+		t.Errorf("Expected A to run through March 31st, got %+v", p)
+	}
+	if !p.Contains(date("2024-03-31").Add(23*time.Hour)) || p.Contains(date("2024-04-01")) {
+		t.Error("Expected the last day to be included, and no more")
+	}
+	for _, s := range []string{"2024-01-01", "2024-01-01..March", "2024-03-31..2024-01-01"} {
+		if _, err := ParseRange("A", s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+// Package phabricator reads Differential revisions from Phabricator's Conduit API,
+// so review history from before a migration to GitHub can be imported. Revisions,
+// their reviews, updates and inline comments are returned in the same form as
+// GitHub's PRs, so the PR metrics pipeline works on them unchanged. Repositories
+// are named by their callsign or short name; the owner is only a label.
+package phabricator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/github"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	pageSize       = 100
+)
+
+// ErrUnsupported is returned for data Differential doesn't have, such as CODEOWNERS
+// files and teams
+var ErrUnsupported = errors.New("not supported by Phabricator")
+
+// PhabricatorClient handles Conduit API operations
+type PhabricatorClient struct {
+	httpClient *http.Client
+	baseURL    string // e.g. https://phabricator.example.com
+	token      string
+	budget     *budget.Budget // nil means unlimited
+
+	// Repositories, users and revisions' transactions, kept for the calls that follow
+	mu           sync.Mutex
+	repositories map[string]string // PHID by callsign or short name
+	users        map[string]github.User
+	transactions map[int][]transaction
+}
+
+var _ github.GitHubClientInterface = (*PhabricatorClient)(nil)
+
+// NewPhabricatorClient creates a new client for the Phabricator install at
+// baseURL, authenticated with a Conduit API token
+func NewPhabricatorClient(baseURL, token string) *PhabricatorClient {
+	return &PhabricatorClient{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		token:        token,
+		repositories: make(map[string]string),
+		users:        make(map[string]github.User),
+		transactions: make(map[int][]transaction),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *PhabricatorClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// epochTime is a timestamp as Conduit encodes it, in seconds since the epoch
+type epochTime struct {
+	time.Time
+}
+
+func (t *epochTime) UnmarshalJSON(data []byte) error {
+	var seconds int64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	t.Time = time.Unix(seconds, 0).UTC()
+	return nil
+}
+
+// cursor pages through search results
+type cursor struct {
+	After *string `json:"after"`
+}
+
+type revision struct {
+	ID     int `json:"id"`
+	Fields struct {
+		Title      string `json:"title"`
+		URI        string `json:"uri"`
+		AuthorPHID string `json:"authorPHID"`
+		Status     struct {
+			Value  string `json:"value"`
+			Closed bool   `json:"closed"`
+		} `json:"status"`
+		IsDraft     bool      `json:"isDraft"`
+		DateCreated epochTime `json:"dateCreated"`
+	} `json:"fields"`
+}
+
+type transaction struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	AuthorPHID  string    `json:"authorPHID"`
+	DateCreated epochTime `json:"dateCreated"`
+	Fields      struct {
+		New string `json:"new"` // update: the new diff's PHID
+	} `json:"fields"`
+}
+
+// FetchPullRequests fetches the repository's revisions created in the date range,
+// newest first, along with each one's transactions, which say when it landed. If
+// the API budget runs out part way through, the revisions fetched so far are
+// returned along with the error.
+func (c *PhabricatorClient) FetchPullRequests(ctx context.Context, owner, repo string, startDate, endDate time.Time) ([]*github.PullRequest, error) {
+	repositoryPHID, err := c.repository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []revision
+	params := map[string]interface{}{
+		"constraints": map[string]interface{}{
+			"repositoryPHIDs": []string{repositoryPHID},
+			"createdStart":    startDate.Unix(),
+			"createdEnd":      endDate.Unix(),
+		},
+		"order": "newest",
+		"limit": pageSize,
+	}
+	for {
+		var resp struct {
+			Data   []revision `json:"data"`
+			Cursor cursor     `json:"cursor"`
+		}
+		if err := c.call(ctx, "differential.revision.search", params, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list revisions: %w", err)
+		}
+		revisions = append(revisions, resp.Data...)
+		if resp.Cursor.After == nil {
+			break
+		}
+		params["after"] = *resp.Cursor.After
+	}
+
+	var prs []*github.PullRequest
+	for _, rev := range revisions {
+		transactions, err := c.revisionTransactions(ctx, rev.ID)
+		if err != nil {
+			return prs, err
+		}
+		author, err := c.user(ctx, rev.Fields.AuthorPHID)
+		if err != nil {
+			return prs, err
+		}
+		prs = append(prs, toGitHubPullRequest(rev, author, transactions))
+	}
+	return prs, nil
+}
+
+// FetchPullRequestReviews returns the revision's accepts, requests for changes and
+// reviewers' comments as reviews. Inline comments submitted without any other
+// action count as a comment review too, as they would on GitHub.
+func (c *PhabricatorClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*github.Review, error) {
+	transactions, err := c.revisionTransactions(ctx, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []*github.Review
+	reviewed := make(map[string]bool) // by author and time, so a submission is one review
+	for _, txn := range transactions {
+		var state string
+		switch txn.Type {
+		case "accept":
+			state = "APPROVED"
+		case "request-changes":
+			state = "CHANGES_REQUESTED"
+		case "comment", "inline":
+			state = "COMMENTED"
+		default:
+			continue
+		}
+		submission := fmt.Sprintf("%s@%d", txn.AuthorPHID, txn.DateCreated.Unix())
+		if state == "COMMENTED" && reviewed[submission] {
+			continue
+		}
+		user, err := c.user(ctx, txn.AuthorPHID)
+		if err != nil {
+			return nil, err
+		}
+		// Replace a comment review with the action submitted along with it
+		if reviewed[submission] {
+			reviews = slices.DeleteFunc(reviews, func(r *github.Review) bool {
+				return r.User == user && r.SubmittedAt.Equal(txn.DateCreated.Time) && r.State == "COMMENTED"
+			})
+		}
+		reviewed[submission] = true
+		reviews = append(reviews, &github.Review{
+			User:        user,
+			State:       state,
+			SubmittedAt: txn.DateCreated.Time,
+		})
+	}
+	return reviews, nil
+}
+
+// FetchPullRequestCommits returns a commit for each diff the revision was updated
+// with, at the time of the update, named by the diff's PHID. Differential tracks
+// diffs rather than commits, and each update is a push as far as review is concerned.
+func (c *PhabricatorClient) FetchPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*github.Commit, error) {
+	transactions, err := c.revisionTransactions(ctx, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []*github.Commit
+	for _, txn := range transactions {
+		if txn.Type != "update" || txn.Fields.New == "" {
+			continue
+		}
+		date := github.CommitSignature{Date: txn.DateCreated.Time}
+		commits = append(commits, &github.Commit{
+			SHA:    txn.Fields.New,
+			Commit: github.CommitDetail{Author: date, Committer: date},
+		})
+	}
+	return commits, nil
+}
+
+// FetchPullRequestFiles isn't supported: changed files are per diff in
+// Differential, and importing them would cost a call per update
+func (c *PhabricatorClient) FetchPullRequestFiles(ctx context.Context, owner, repo string, prNumber int) ([]*github.File, error) {
+	return nil, fmt.Errorf("changed files: %w", ErrUnsupported)
+}
+
+// FetchPullRequestComments returns the revision's inline comments
+func (c *PhabricatorClient) FetchPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*github.Comment, error) {
+	transactions, err := c.revisionTransactions(ctx, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []*github.Comment
+	for _, txn := range transactions {
+		if txn.Type != "inline" {
+			continue
+		}
+		user, err := c.user(ctx, txn.AuthorPHID)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, &github.Comment{User: user, CreatedAt: txn.DateCreated.Time})
+	}
+	return comments, nil
+}
+
+// FetchPullRequestIssueComments returns no comments: a revision's top-level
+// comments are already among its reviews, as comment reviews
+func (c *PhabricatorClient) FetchPullRequestIssueComments(ctx context.Context, owner, repo string, prNumber int) ([]*github.Comment, error) {
+	return nil, nil
+}
+
+// FetchPullRequestTimeline returns no events: Differential's drafts aren't
+// recorded as timeline events, so draft time can't be measured
+func (c *PhabricatorClient) FetchPullRequestTimeline(ctx context.Context, owner, repo string, prNumber int) ([]*github.TimelineEvent, error) {
+	return nil, nil
+}
+
+// FetchPullRequestReviewRequests returns no events: Differential doesn't record
+// when reviewers were added
+func (c *PhabricatorClient) FetchPullRequestReviewRequests(ctx context.Context, owner, repo string, prNumber int) ([]*github.ReviewRequestEvent, error) {
+	return nil, nil
+}
+
+// FetchPullRequestDismissals returns no events: Differential's reviews aren't
+// dismissed, only resigned from, which doesn't leave a review behind
+func (c *PhabricatorClient) FetchPullRequestDismissals(ctx context.Context, owner, repo string, prNumber int) ([]*github.DismissalEvent, error) {
+	return nil, nil
+}
+
+// FetchRequiredApprovals returns 0, for the default of one approval. Herald rules
+// decide who must review, which isn't a count.
+func (c *PhabricatorClient) FetchRequiredApprovals(ctx context.Context, owner, repo, branch string) (int, error) {
+	return 0, nil
+}
+
+func (c *PhabricatorClient) FetchCodeowners(ctx context.Context, owner, repo, ref string) (string, error) {
+	return "", fmt.Errorf("CODEOWNERS: %w", ErrUnsupported)
+}
+
+func (c *PhabricatorClient) FetchTeamMembers(ctx context.Context, org, team string) ([]string, error) {
+	return nil, fmt.Errorf("team members: %w", ErrUnsupported)
+}
+
+func (c *PhabricatorClient) FetchCommits(ctx context.Context, owner, repo string, since, until time.Time) ([]*github.Commit, error) {
+	return nil, fmt.Errorf("commit history: %w", ErrUnsupported)
+}
+
+func (c *PhabricatorClient) FetchCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, error) {
+	return nil, fmt.Errorf("tag commits: %w", ErrUnsupported)
+}
+
+// repository looks up a repository's PHID by its callsign or short name, once
+func (c *PhabricatorClient) repository(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	phid, ok := c.repositories[name]
+	c.mu.Unlock()
+	if ok {
+		return phid, nil
+	}
+
+	for _, constraint := range []string{"callsigns", "shortNames"} {
+		var resp struct {
+			Data []struct {
+				PHID string `json:"phid"`
+			} `json:"data"`
+		}
+		params := map[string]interface{}{
+			"constraints": map[string]interface{}{constraint: []string{name}},
+		}
+		if err := c.call(ctx, "diffusion.repository.search", params, &resp); err != nil {
+			return "", fmt.Errorf("failed to look up repository %s: %w", name, err)
+		}
+		if len(resp.Data) > 0 {
+			phid = resp.Data[0].PHID
+			break
+		}
+	}
+	if phid == "" {
+		return "", fmt.Errorf("repository %s not found", name)
+	}
+
+	c.mu.Lock()
+	c.repositories[name] = phid
+	c.mu.Unlock()
+	return phid, nil
+}
+
+// user looks up the account with the given PHID, once. Bot accounts are given the
+// Bot type, so they're left out like GitHub's.
+func (c *PhabricatorClient) user(ctx context.Context, phid string) (github.User, error) {
+	c.mu.Lock()
+	user, ok := c.users[phid]
+	c.mu.Unlock()
+	if ok {
+		return user, nil
+	}
+
+	var resp struct {
+		Data []struct {
+			Fields struct {
+				Username string   `json:"username"`
+				Roles    []string `json:"roles"`
+			} `json:"fields"`
+		} `json:"data"`
+	}
+	params := map[string]interface{}{
+		"constraints": map[string]interface{}{"phids": []string{phid}},
+	}
+	if err := c.call(ctx, "user.search", params, &resp); err != nil {
+		return github.User{}, fmt.Errorf("failed to look up user %s: %w", phid, err)
+	}
+	if len(resp.Data) == 0 {
+		// Not a user, e.g. Herald or a deleted account
+		user = github.User{Login: phid, Type: "Bot"}
+	} else {
+		fields := resp.Data[0].Fields
+		user = github.User{Login: fields.Username, Type: "User"}
+		if slices.Contains(fields.Roles, "bot") {
+			user.Type = "Bot"
+		}
+	}
+
+	c.mu.Lock()
+	c.users[phid] = user
+	c.mu.Unlock()
+	return user, nil
+}
+
+// revisionTransactions fetches a revision's transactions, oldest first, once
+func (c *PhabricatorClient) revisionTransactions(ctx context.Context, id int) ([]transaction, error) {
+	c.mu.Lock()
+	transactions, ok := c.transactions[id]
+	c.mu.Unlock()
+	if ok {
+		return transactions, nil
+	}
+
+	params := map[string]interface{}{
+		"objectIdentifier": fmt.Sprintf("D%d", id),
+		"limit":            pageSize,
+	}
+	for {
+		var resp struct {
+			Data   []transaction `json:"data"`
+			Cursor cursor        `json:"cursor"`
+		}
+		if err := c.call(ctx, "transaction.search", params, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch transactions for D%d: %w", id, err)
+		}
+		transactions = append(transactions, resp.Data...)
+		if resp.Cursor.After == nil {
+			break
+		}
+		params["after"] = *resp.Cursor.After
+	}
+	slices.SortStableFunc(transactions, func(a, b transaction) int {
+		if n := a.DateCreated.Compare(b.DateCreated.Time); n != 0 {
+			return n
+		}
+		return a.ID - b.ID
+	})
+
+	c.mu.Lock()
+	c.transactions[id] = transactions
+	c.mu.Unlock()
+	return transactions, nil
+}
+
+// toGitHubPullRequest converts a revision to GitHub's form. A revision that was
+// closed (landed) counts as merged, and one that was abandoned as closed without
+// merging; the times come from its transactions.
+func toGitHubPullRequest(rev revision, author github.User, transactions []transaction) *github.PullRequest {
+	pr := &github.PullRequest{
+		Number:    rev.ID,
+		Title:     rev.Fields.Title,
+		State:     "open",
+		Draft:     rev.Fields.IsDraft,
+		User:      author,
+		CreatedAt: rev.Fields.DateCreated.Time,
+	}
+	if !rev.Fields.Status.Closed {
+		return pr
+	}
+
+	pr.State = "closed"
+	for _, txn := range transactions {
+		switch txn.Type {
+		case "close":
+			pr.ClosedAt = txn.DateCreated.Time
+			pr.MergedAt = pr.ClosedAt
+		case "abandon":
+			pr.ClosedAt = txn.DateCreated.Time
+			pr.MergedAt = time.Time{}
+		}
+	}
+	if rev.Fields.Status.Value != "published" {
+		pr.MergedAt = time.Time{}
+	}
+	return pr
+}
+
+// call makes a Conduit API request and decodes the response's result into result
+func (c *PhabricatorClient) call(ctx context.Context, method string, params map[string]interface{}, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	// Parameters are sent as JSON, with the token alongside them
+	withToken := map[string]interface{}{"__conduit__": map[string]string{"token": c.token}}
+	for k, v := range params {
+		withToken[k] = v
+	}
+	encoded, err := json.Marshal(withToken)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	form := url.Values{
+		"params":      {string(encoded)},
+		"output":      {"json"},
+		"__conduit__": {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/"+method, bytes.NewBufferString(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed with status %d", method, resp.StatusCode)
+	}
+
+	// Conduit reports errors in the body, with a 200 status
+	var envelope struct {
+		Result    json.RawMessage `json:"result"`
+		ErrorCode *string         `json:"error_code"`
+		ErrorInfo *string         `json:"error_info"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if envelope.ErrorCode != nil {
+		info := ""
+		if envelope.ErrorInfo != nil {
+			info = *envelope.ErrorInfo
+		}
+		return fmt.Errorf("%s failed: %s: %s", method, *envelope.ErrorCode, info)
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
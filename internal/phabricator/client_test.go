@@ -0,0 +1,158 @@
+package phabricator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// day returns the epoch seconds of a day in March 2019, as Conduit encodes times
+func day(d int) int64 {
+	return time.Date(2019, 3, d, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+func TestPhabricatorClient(t *testing.T) {
+	users := map[string]string{
+		"PHID-USER-alice": `{"fields": {"username": "alice", "roles": ["verified", "activated"]}}`,
+		"PHID-USER-bob":   `{"fields": {"username": "bob", "roles": ["verified", "activated"]}}`,
+		"PHID-USER-ci":    `{"fields": {"username": "ci", "roles": ["bot", "activated"]}}`,
+	}
+	// Newest first, as transaction.search returns them
+	transactions := fmt.Sprintf(`[
+		{"id": 9, "type": "close", "authorPHID": "PHID-USER-alice", "dateCreated": %d},
+		{"id": 8, "type": "accept", "authorPHID": "PHID-USER-bob", "dateCreated": %d},
+		{"id": 7, "type": "comment", "authorPHID": "PHID-USER-bob", "dateCreated": %d},
+		{"id": 6, "type": "update", "authorPHID": "PHID-USER-alice", "dateCreated": %d, "fields": {"old": "PHID-DIFF-1", "new": "PHID-DIFF-2"}},
+		{"id": 5, "type": "inline", "authorPHID": "PHID-USER-bob", "dateCreated": %d, "comments": [{"content": {"raw": "nit"}}], "fields": {"path": "main.go", "line": 3}},
+		{"id": 4, "type": "request-changes", "authorPHID": "PHID-USER-bob", "dateCreated": %d},
+		{"id": 3, "type": "comment", "authorPHID": "PHID-USER-ci", "dateCreated": %d},
+		{"id": 2, "type": "update", "authorPHID": "PHID-USER-alice", "dateCreated": %d, "fields": {"old": null, "new": "PHID-DIFF-1"}}
+	]`, day(6), day(5), day(5), day(4), day(3), day(3), day(2), day(2))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		if err := json.Unmarshal([]byte(r.FormValue("params")), &params); err != nil {
+			t.Fatalf("Bad params: %v", err)
+		}
+		if token := params["__conduit__"].(map[string]interface{})["token"]; token != "api-token" {
+			t.Errorf("Expected the API token, got %v", token)
+		}
+		constraints, _ := params["constraints"].(map[string]interface{})
+
+		var result string
+		switch strings.TrimPrefix(r.URL.Path, "/api/") {
+		case "diffusion.repository.search":
+			// There's no callsign, so it's found by its short name
+			if names, _ := constraints["shortNames"].([]interface{}); len(names) == 1 && names[0] == "web" {
+				result = `{"data": [{"phid": "PHID-REPO-web"}]}`
+			} else {
+				result = `{"data": []}`
+			}
+		case "differential.revision.search":
+			if constraints["repositoryPHIDs"].([]interface{})[0] != "PHID-REPO-web" || constraints["createdStart"] == nil {
+				t.Errorf("Unexpected constraints %v", constraints)
+			}
+			if params["after"] == nil {
+				result = fmt.Sprintf(`{"data": [{"id": 12, "fields": {"title": "Add search", "uri": "https://phab.example.com/D12", "authorPHID": "PHID-USER-alice",
+					"status": {"value": "published", "closed": true}, "dateCreated": %d}}], "cursor": {"after": "12"}}`, day(1))
+			} else {
+				result = fmt.Sprintf(`{"data": [{"id": 10, "fields": {"title": "Try caching", "authorPHID": "PHID-USER-bob",
+					"status": {"value": "abandoned", "closed": true}, "dateCreated": %d}}], "cursor": {"after": null}}`, day(1))
+			}
+		case "transaction.search":
+			if params["objectIdentifier"] == "D12" {
+				result = `{"data": ` + transactions + `, "cursor": {"after": null}}`
+			} else {
+				result = fmt.Sprintf(`{"data": [{"id": 1, "type": "abandon", "authorPHID": "PHID-USER-bob", "dateCreated": %d}], "cursor": {"after": null}}`, day(8))
+			}
+		case "user.search":
+			phid := constraints["phids"].([]interface{})[0].(string)
+			result = `{"data": [` + users[phid] + `]}`
+		default:
+			w.Write([]byte(`{"result": null, "error_code": "ERR-CONDUIT-CALL", "error_info": "Method does not exist."}`))
+			return
+		}
+		w.Write([]byte(`{"result": ` + result + `, "error_code": null, "error_info": null}`))
+	}))
+	defer server.Close()
+
+	client := NewPhabricatorClient(server.URL+"/", "api-token")
+	ctx := context.Background()
+
+	prs, err := client.FetchPullRequests(ctx, "phabricator", "web", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(prs) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(prs))
+	}
+	landed, abandoned := prs[0], prs[1]
+	if landed.Number != 12 || landed.State != "closed" || landed.User.Login != "alice" ||
+		!landed.MergedAt.Equal(time.Unix(day(6), 0)) || !landed.ClosedAt.Equal(time.Unix(day(6), 0)) {
+		t.Errorf("Expected D12 landed on March 6, got %v", landed)
+	}
+	if abandoned.Number != 10 || !abandoned.MergedAt.IsZero() || !abandoned.ClosedAt.Equal(time.Unix(day(8), 0)) {
+		t.Errorf("Expected D10 abandoned on March 8, got %v", abandoned)
+	}
+
+	reviews, err := client.FetchPullRequestReviews(ctx, "phabricator", "web", 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got []string
+	for _, review := range reviews {
+		got = append(got, fmt.Sprintf("%s %s %d", review.User.Login, review.State, review.SubmittedAt.Day()))
+	}
+	// The inline comment was submitted with the request for changes, and the
+	// comment with the accept, so each submission is one review
+	want := []string{"ci COMMENTED 2", "bob CHANGES_REQUESTED 3", "bob APPROVED 5"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Expected reviews %v, got %v", want, got)
+	}
+	if reviews[0].User.Type != "Bot" {
+		t.Errorf("Expected the ci account to be a bot, got %v", reviews[0].User)
+	}
+
+	commits, err := client.FetchPullRequestCommits(ctx, "phabricator", "web", 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].SHA != "PHID-DIFF-1" || commits[1].SHA != "PHID-DIFF-2" ||
+		!commits[1].Commit.Author.Date.Equal(time.Unix(day(4), 0)) {
+		t.Errorf("Expected a commit per diff, got %v", commits)
+	}
+
+	comments, err := client.FetchPullRequestComments(ctx, "phabricator", "web", 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(comments) != 1 || comments[0].User.Login != "bob" || comments[0].CreatedAt.Day() != 3 {
+		t.Errorf("Expected bob's inline comment, got %v", comments)
+	}
+
+	if _, err := client.FetchPullRequestFiles(ctx, "phabricator", "web", 12); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for files, got %v", err)
+	}
+	if _, err := client.FetchPullRequests(ctx, "phabricator", "missing", time.Time{}, time.Now()); err == nil {
+		t.Error("Expected an error for a missing repository")
+	}
+}
+
+func TestPhabricatorClient_ConduitError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result": null, "error_code": "ERR-INVALID-AUTH", "error_info": "API token is invalid."}`))
+	}))
+	defer server.Close()
+
+	client := NewPhabricatorClient(server.URL, "bad-token")
+	_, err := client.FetchPullRequests(context.Background(), "phabricator", "web", time.Time{}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "ERR-INVALID-AUTH: API token is invalid.") {
+		t.Errorf("Expected Conduit's error, got %v", err)
+	}
+}
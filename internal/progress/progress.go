@@ -0,0 +1,132 @@
+// Package progress reports how far through a long run is, so that a run fetching
+// reviews for a thousand PRs doesn't sit silent for minutes looking hung. On a
+// terminal the progress line is redrawn in place; otherwise, as in CI logs, a line
+// is written every so often.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+const (
+	// How often the progress line is redrawn on a terminal, and written elsewhere
+	terminalInterval = 100 * time.Millisecond
+	logInterval      = 10 * time.Second
+)
+
+// Reporter counts items processed out of a total, along with the API calls made
+// so far. A nil *Reporter reports nothing, so callers needn't check.
+type Reporter struct {
+	w        io.Writer
+	noun     string         // what's counted, e.g. "PRs"
+	calls    *budget.Budget // API calls are read from here; nil leaves them out
+	inPlace  bool
+	interval time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	done, total int
+	drawn       time.Time // when the line was last drawn
+	drawnDone   int       // the count it showed
+	pending     bool      // a line is drawn in place without a newline after it
+}
+
+// New returns a Reporter writing to w, counting noun (e.g. "PRs") and the calls
+// spent from calls, which is usually the run's top-level API budget
+func New(w io.Writer, noun string, calls *budget.Budget) *Reporter {
+	inPlace := isTerminal(w)
+	interval := logInterval
+	if inPlace {
+		interval = terminalInterval
+	}
+	return &Reporter{w: w, noun: noun, calls: calls, inPlace: inPlace, interval: interval, now: time.Now}
+}
+
+// isTerminal reports whether w is a terminal, which a progress line can be redrawn on
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start begins counting towards total items, from none processed
+func (r *Reporter) Start(total int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done, r.total = 0, total
+	r.draw()
+}
+
+// Increment records one more item processed
+func (r *Reporter) Increment() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done++
+	if r.now().Sub(r.drawn) >= r.interval {
+		r.draw()
+	}
+}
+
+// Finish writes the final count, ending the line so that other output can follow
+func (r *Reporter) Finish() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inPlace || r.drawnDone != r.done {
+		r.draw()
+	}
+	if r.pending {
+		fmt.Fprintln(r.w)
+		r.pending = false
+	}
+}
+
+// Write writes p, such as a log message, to the reporter's writer, first moving
+// off a progress line drawn in place and then redrawing it below. Setting the
+// reporter as the log output keeps log messages from running into the line.
+func (r *Reporter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending {
+		fmt.Fprint(r.w, "\r\033[K")
+	}
+	n, err := r.w.Write(p)
+	if r.pending {
+		r.pending = false
+		r.draw()
+	}
+	return n, err
+}
+
+// draw writes the progress line; r.mu must be held
+func (r *Reporter) draw() {
+	line := fmt.Sprintf("Processed %d/%d %s", r.done, r.total, r.noun)
+	if r.calls != nil {
+		line += fmt.Sprintf(" (%d API calls)", r.calls.Used())
+	}
+	if r.inPlace {
+		fmt.Fprint(r.w, "\r\033[K"+line)
+		r.pending = true
+	} else {
+		fmt.Fprintln(r.w, line)
+	}
+	r.drawn = r.now()
+	r.drawnDone = r.done
+}
@@ -0,0 +1,57 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	calls := budget.New("API", 0)
+	r := New(&buf, "PRs", calls)
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Start(3)
+	calls.Spend()
+	r.Increment() // too soon after the last line to write another
+	now = now.Add(logInterval)
+	calls.Spend()
+	r.Increment()
+	r.Increment()
+	r.Finish()
+
+	want := "Processed 0/3 PRs (0 API calls)\nProcessed 2/3 PRs (2 API calls)\nProcessed 3/3 PRs (2 API calls)\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestReporter_InPlace(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, "releases", nil)
+	r.inPlace, r.interval = true, 0
+
+	r.Start(2)
+	r.Increment()
+	r.Write([]byte("Error fetching release\n"))
+	r.Increment()
+	r.Finish()
+
+	// The log message replaces the progress line, which is redrawn after it
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "Error fetching release") || !strings.HasSuffix(lines[1], "Processed 2/2 releases") || lines[2] != "" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
+
+func TestReporter_Nil(t *testing.T) {
+	var r *Reporter
+	r.Start(10)
+	r.Increment()
+	r.Finish()
+}
@@ -0,0 +1,204 @@
+// Package render reads deployments from Render's deploy history, for teams that
+// host their services on Render rather than deploying with Cloud Deploy. Each
+// successful deploy is a release.
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	renderAPIBaseURL = "https://api.render.com/v1"
+	defaultTimeout   = 30 * time.Second
+	pageSize         = 100
+)
+
+// RenderClient handles Render API operations
+type RenderClient struct {
+	httpClient *http.Client
+	apiKey     string
+	services   []string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+
+	// The deploys listed, for the other methods
+	mu      sync.Mutex
+	deploys map[string]renderDeploy
+}
+
+var _ deploy.DeployProvider = (*RenderClient)(nil)
+
+// NewRenderClient creates a new client for the given services, by ID (srv-...),
+// authenticated with an API key
+func NewRenderClient(apiKey string, services []string) *RenderClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &RenderClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		apiKey:   apiKey,
+		services: services,
+		baseURL:  renderAPIBaseURL,
+		retry:    retrying,
+		deploys:  make(map[string]renderDeploy),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *RenderClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *RenderClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type renderDeploy struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Commit *struct {
+		ID        string    `json:"id"`
+		Message   string    `json:"message"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"commit"`
+	CreatedAt  time.Time `json:"createdAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// ListReleases lists the services' deploys made in the date range
+// that went live, newest first within each service. Deploys that failed or were
+// cancelled, and ones with no commit, such as image deploys, are left out. If the
+// API budget runs out part way through, the releases found so far are returned
+// along with the error.
+func (c *RenderClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, service := range c.services {
+		query := url.Values{
+			"limit":         {strconv.Itoa(pageSize)},
+			"createdAfter":  {startDate.Format(time.RFC3339)},
+			"createdBefore": {endDate.Format(time.RFC3339)},
+		}
+		for {
+			var page []struct {
+				Deploy renderDeploy `json:"deploy"`
+				Cursor string       `json:"cursor"`
+			}
+			if err := c.get(ctx, "/services/"+url.PathEscape(service)+"/deploys", query, &page); err != nil {
+				return releases, fmt.Errorf("failed to list deploys for %s: %w", service, err)
+			}
+			for _, item := range page {
+				// Deactivated deploys went live and were later replaced
+				d := item.Deploy
+				if (d.Status != "live" && d.Status != "deactivated") || d.Commit == nil || d.FinishedAt.IsZero() {
+					continue
+				}
+				releases = append(releases, c.release(service, d))
+			}
+			if len(page) < pageSize {
+				break
+			}
+			query.Set("cursor", page[len(page)-1].Cursor)
+		}
+	}
+	return releases, nil
+}
+
+// release converts a Render deploy to a deploy.Release, remembering it for
+// ExtractSource and GetRolloutCompletion
+func (c *RenderClient) release(service string, d renderDeploy) *deploy.Release {
+	// The deploy ID comes last, so it's used as the release ID
+	name := fmt.Sprintf("services/%s/deploys/%s", service, d.ID)
+	c.mu.Lock()
+	c.deploys[name] = d
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        name,
+		CreateTime:  d.CreatedAt,
+		Annotations: map[string]string{"service": service},
+	}
+}
+
+// ExtractSource returns the commit a deploy deployed, the PR it
+// merged if its message names one, and when it was made
+func (c *RenderClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	d, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return d.Commit.ID, deploy.PRFromCommitMessage(d.Commit.Message), d.Commit.CreatedAt, nil
+}
+
+// GetRolloutCompletion returns when the deploy went live
+func (c *RenderClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	d, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.FinishedAt, nil
+}
+
+// listed returns the Render deploy a release was converted from
+func (c *RenderClient) listed(release *deploy.Release) (renderDeploy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.deploys[release.Name]
+	if !ok {
+		return renderDeploy{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return d, nil
+}
+
+// get makes a Render API request and decodes the response into result
+func (c *RenderClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024 as Render encodes times
+func at(hour int) string {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)
+}
+
+func TestRenderClient(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer api-key" {
+			t.Errorf("Expected the API key, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/services/srv-web/deploys" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id": "not-found", "message": "service not found"}`))
+			return
+		}
+		if r.URL.Query().Get("createdAfter") != "2024-06-01T00:00:00Z" {
+			t.Errorf("Unexpected query %v", r.URL.Query())
+		}
+		fmt.Fprintf(w, `[
+			{"deploy": {"id": "dep-3", "status": "live", "commit": {"id": "abc1234", "message": "Add search (#42)\n\nDetails", "createdAt": %q}, "createdAt": %q, "finishedAt": %q}, "cursor": "c3"},
+			{"deploy": {"id": "dep-2", "status": "build_failed", "commit": {"id": "fff0000", "message": "Break things", "createdAt": %q}, "createdAt": %q, "finishedAt": %q}, "cursor": "c2"},
+			{"deploy": {"id": "dep-1", "status": "deactivated", "commit": {"id": "def5678", "message": "Merge pull request #41 from someone/branch", "createdAt": %q}, "createdAt": %q, "finishedAt": %q}, "cursor": "c1"},
+			{"deploy": {"id": "dep-0", "status": "deactivated", "commit": null, "createdAt": %q, "finishedAt": %q}, "cursor": "c0"}
+		]`, at(9), at(10), at(11), at(7), at(8), at(8), at(5), at(6), at(7), at(4), at(5))
+	}))
+	defer server.Close()
+
+	client := NewRenderClient("api-key", []string{"srv-web"})
+	client.baseURL = server.URL
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single page, got %d calls", calls)
+	}
+	// The failed deploy and the image deploy are left out
+	if len(releases) != 2 || releases[0].Name != "services/srv-web/deploys/dep-3" || releases[1].Name != "services/srv-web/deploys/dep-1" {
+		t.Fatalf("Expected dep-3 and dep-1, got %v", releases)
+	}
+
+	sha, prNumber, commitTime, err := client.ExtractSource(context.Background(), releases[0])
+	if err != nil || sha != "abc1234" || prNumber != "42" || commitTime.Format(time.RFC3339) != at(9) {
+		t.Errorf("Expected PR #42's squashed commit, got %s %s %v (%v)", sha, prNumber, commitTime, err)
+	}
+	if _, prNumber, _, _ := client.ExtractSource(context.Background(), releases[1]); prNumber != "41" {
+		t.Errorf("Expected PR #41's merge commit, got %q", prNumber)
+	}
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[0])
+	if err != nil || finish.Format(time.RFC3339) != at(11) {
+		t.Errorf("Expected the deploy's finish time, got %v (%v)", finish, err)
+	}
+
+	missing := NewRenderClient("api-key", []string{"srv-missing"})
+	missing.baseURL = server.URL
+	if _, err := missing.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: service not found") {
+		t.Errorf("Expected Render's error, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Package retry retries API calls that failed for reasons that usually pass, such
+// as a server error or a dropped connection, so one blip doesn't fail a whole run.
+// Retries back off exponentially, with jitter so that concurrent workers don't
+// retry in lockstep, and each one is logged.
+package retry
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Policy says how often, and how patiently, to retry
+type Policy struct {
+	Retries   int           // retries after the first attempt; 0 disables retrying
+	BaseDelay time.Duration // longest wait before the first retry, doubling for each one after
+	MaxDelay  time.Duration // longest wait before any retry
+}
+
+// DefaultPolicy retries three times, waiting up to 1s, 2s and then 4s
+var DefaultPolicy = Policy{Retries: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+
+// Delay returns how long to wait before the nth retry (1 for the first). It's
+// chosen at random up to the exponential backoff ("full jitter").
+func (p Policy) Delay(n int) time.Duration {
+	backoff := p.BaseDelay << (n - 1)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay // also covers overflow
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff) + 1
+}
+
+// logRetry records a retry with its context as structured fields
+func logRetry(op string, n int, p Policy, delay time.Duration, reason string) {
+	slog.Warn("Retrying after transient error",
+		"op", op,
+		"retry", n,
+		"max_retries", p.Retries,
+		"delay", delay.Round(time.Millisecond),
+		"reason", reason,
+	)
+}
+
+// Transport retries HTTP requests that failed with a network error or a 5xx
+// server error. Other responses, including rate limits, are passed on as they are.
+type Transport struct {
+	Base   http.RoundTripper
+	Policy Policy
+
+	// For tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewTransport wraps base, retrying with policy
+func NewTransport(base http.RoundTripper, policy Policy) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Policy: policy, sleep: sleep}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for n := 1; ; n++ {
+		resp, err := t.Base.RoundTrip(req)
+		reason := ""
+		switch {
+		case err != nil && req.Context().Err() == nil && transientNetworkError(err):
+			reason = err.Error()
+		case err == nil && transientStatus(resp.StatusCode):
+			reason = resp.Status
+		}
+		if reason == "" || n > t.Policy.Retries {
+			return resp, err
+		}
+
+		// Retrying needs a fresh copy of the body, if there was one
+		retry := req
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			retry = req.Clone(req.Context())
+			retry.Body = body
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		delay := t.Policy.Delay(n)
+		logRetry(req.Method+" "+req.URL.Path, n, t.Policy, delay, reason)
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		req = retry
+	}
+}
+
+// transientStatus reports whether a response status is a server error worth
+// retrying. 501 Not Implemented won't change on a retry.
+func transientStatus(code int) bool {
+	return code >= 500 && code != http.StatusNotImplemented
+}
+
+// transientNetworkError reports whether a request failed to get a response for a
+// reason that may pass: a timeout, a refused or reset connection, or one closed
+// part way through a response
+func transientNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Retryer decides whether to retry calls made through client libraries that take
+// a retry strategy, such as Google Cloud's (it satisfies gax.Retryer). Create one
+// per call, as it counts the call's retries.
+type Retryer struct {
+	policy    Policy
+	op        string
+	transient func(error) bool
+	retries   int
+}
+
+// NewRetryer returns a Retryer for the call op, retrying the errors transient
+// accepts according to policy
+func NewRetryer(op string, policy Policy, transient func(error) bool) *Retryer {
+	return &Retryer{policy: policy, op: op, transient: transient}
+}
+
+// Retry returns how long to wait before retrying after err, and whether to retry at all
+func (r *Retryer) Retry(err error) (time.Duration, bool) {
+	if r.retries >= r.policy.Retries || !r.transient(err) {
+		return 0, false
+	}
+	r.retries++
+	delay := r.policy.Delay(r.retries)
+	logRetry(r.op, r.retries, r.policy, delay, err.Error())
+	return delay, true
+}
+
+// sleep waits for d, or returns early with ctx's error if it's cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flags holds the retry flag shared by the trackers
+type Flags struct {
+	Retries *int
+}
+
+// RegisterFlags defines the retry flag shared by the trackers
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		Retries: fs.Int("retries", DefaultPolicy.Retries, "Times to retry an API call that failed with a server or network error, backing off between tries (0 = never)"),
+	}
+}
+
+// Policy returns the retry policy the flags describe
+func (f *Flags) Policy() Policy {
+	policy := DefaultPolicy
+	policy.Retries = max(*f.Retries, 0)
+	return policy
+}
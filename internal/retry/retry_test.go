@@ -0,0 +1,137 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func response(status int) *http.Response {
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []interface{} // status codes or errors, in order
+		wantCalls int
+		wantCode  int
+		wantErr   bool
+	}{
+		{"success", []interface{}{200}, 1, 200, false},
+		{"server errors then success", []interface{}{502, 503, 200}, 3, 200, false},
+		{"network error then success", []interface{}{syscall.ECONNRESET, 200}, 2, 200, false},
+		{"client errors aren't retried", []interface{}{404}, 1, 404, false},
+		{"rate limits are left to the rate limit handling", []interface{}{429}, 1, 429, false},
+		{"not implemented isn't retried", []interface{}{501}, 1, 501, false},
+		{"other errors aren't retried", []interface{}{errors.New("unsupported protocol scheme")}, 1, 0, true},
+		{"gives up after the last retry", []interface{}{500, 500, 500, 500}, 3, 500, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var bodies []string
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(body))
+				next := tt.responses[calls]
+				calls++
+				if err, ok := next.(error); ok {
+					return nil, err
+				}
+				return response(next.(int)), nil
+			})
+			var delays []time.Duration
+			transport := NewTransport(base, Policy{Retries: 2, BaseDelay: time.Second, MaxDelay: time.Minute})
+			transport.sleep = func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "https://api.example.com/graphql", strings.NewReader(`{"query": "x"}`))
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(`{"query": "x"}`)), nil }
+			resp, err := transport.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if resp != nil && resp.StatusCode != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, resp.StatusCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			for _, body := range bodies {
+				if body != `{"query": "x"}` {
+					t.Errorf("Expected every attempt to send the body, got %q", body)
+				}
+			}
+			for i, d := range delays {
+				if limit := time.Second << i; d <= 0 || d > limit {
+					t.Errorf("Expected retry %d to wait up to %v, waited %v", i+1, limit, d)
+				}
+			}
+		})
+	}
+}
+
+func TestTransport_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		cancel()
+		return response(503), nil
+	})
+	transport := NewTransport(base, DefaultPolicy)
+
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil).WithContext(ctx)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancellation, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected no retries once cancelled, got %d calls", calls)
+	}
+}
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{Retries: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for n, limit := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 60: 5 * time.Second} {
+		for range 100 {
+			if d := policy.Delay(n); d <= 0 || d > limit {
+				t.Fatalf("Delay(%d) = %v, want up to %v", n, d, limit)
+			}
+		}
+	}
+}
+
+func TestRetryer(t *testing.T) {
+	transient := errors.New("unavailable")
+	r := NewRetryer("ListReleases", Policy{Retries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second}, func(err error) bool {
+		return err == transient
+	})
+
+	if _, ok := r.Retry(errors.New("permission denied")); ok {
+		t.Error("Expected permanent errors not to be retried")
+	}
+	for i := 1; i <= 2; i++ {
+		if _, ok := r.Retry(transient); !ok {
+			t.Errorf("Expected retry %d", i)
+		}
+	}
+	if _, ok := r.Retry(transient); ok {
+		t.Error("Expected no more retries than the policy allows")
+	}
+}
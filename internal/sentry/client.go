@@ -0,0 +1,201 @@
+// Package sentry reads release health from Sentry: how many of a release's
+// sessions crashed, and how many issues were first seen in it. Joined to
+// deployments by release or commit, it shows the quality of what each deployment
+// shipped, not just how quickly it shipped.
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	// DefaultURL is Sentry's SaaS instance
+	DefaultURL     = "https://sentry.io"
+	defaultTimeout = 30 * time.Second
+	pageSize       = 100
+
+	// healthPeriod is how far back release health is summarized, which is as
+	// long as Sentry keeps session data
+	healthPeriod = "90d"
+)
+
+// SentryClient handles Sentry API operations
+type SentryClient struct {
+	httpClient   *http.Client
+	token        string
+	organization string
+	baseURL      string
+	budget       *budget.Budget // nil means unlimited
+	retry        *retry.Transport
+}
+
+// NewSentryClient creates a new client for an organization, by slug, on the
+// Sentry instance at baseURL (DefaultURL unless self-hosted), authenticated with
+// an auth token with the project:releases scope
+func NewSentryClient(baseURL, token, organization string) *SentryClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &SentryClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:        token,
+		organization: organization,
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		retry:        retrying,
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *SentryClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *SentryClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type sentryRelease struct {
+	Version     string    `json:"version"`
+	DateCreated time.Time `json:"dateCreated"`
+	LastCommit  *struct {
+		ID string `json:"id"`
+	} `json:"lastCommit"`
+	Projects []struct {
+		Slug       string `json:"slug"`
+		NewGroups  int    `json:"newGroups"`
+		HealthData *struct {
+			HasHealthData     bool     `json:"hasHealthData"`
+			CrashFreeSessions *float64 `json:"crashFreeSessions"`
+		} `json:"healthData"`
+	} `json:"projects"`
+}
+
+// FetchReleases lists the project's releases created in the date range, newest
+// first, with their health. If the API budget runs out part way through, the
+// releases found so far are returned along with the error.
+func (c *SentryClient) FetchReleases(ctx context.Context, project string, startDate, endDate time.Time) ([]Release, error) {
+	query := url.Values{
+		"health":             {"1"},
+		"summaryStatsPeriod": {healthPeriod},
+		"sort":               {"date"},
+		"per_page":           {strconv.Itoa(pageSize)},
+	}
+	next := c.baseURL + fmt.Sprintf("/api/0/organizations/%s/releases/?", url.PathEscape(c.organization)) + query.Encode()
+
+	var releases []Release
+	for next != "" {
+		var page []sentryRelease
+		header, err := c.get(ctx, next, &page)
+		if err != nil {
+			return releases, fmt.Errorf("failed to list releases: %w", err)
+		}
+		next = nextPage(header.Get("Link"))
+		for _, r := range page {
+			// Releases are listed newest first, so the rest are older still
+			if r.DateCreated.Before(startDate) {
+				next = ""
+				break
+			}
+			if r.DateCreated.After(endDate) {
+				continue
+			}
+			if release, ok := projectRelease(r, project); ok {
+				releases = append(releases, release)
+			}
+		}
+	}
+	return releases, nil
+}
+
+// projectRelease converts a release to the project's view of it, if the project
+// is one of those it was made for
+func projectRelease(r sentryRelease, project string) (Release, bool) {
+	for _, p := range r.Projects {
+		if p.Slug != project {
+			continue
+		}
+		release := Release{
+			Version:   r.Version,
+			CreatedAt: r.DateCreated,
+			NewIssues: p.NewGroups,
+		}
+		if r.LastCommit != nil {
+			release.CommitSHA = r.LastCommit.ID
+		}
+		if p.HealthData != nil && p.HealthData.HasHealthData && p.HealthData.CrashFreeSessions != nil {
+			release.HasHealthData = true
+			release.CrashFreeRate = *p.HealthData.CrashFreeSessions
+		}
+		return release, true
+	}
+	return Release{}, false
+}
+
+// nextPage returns the URL of the next page from a Link header, or "" if there
+// isn't one. Sentry always links a next page, marking whether it has results.
+func nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		if !strings.Contains(part, `rel="next"`) || !strings.Contains(part, `results="true"`) {
+			continue
+		}
+		start, end := strings.Index(part, "<"), strings.Index(part, ">")
+		if start >= 0 && end > start {
+			return part[start+1 : end]
+		}
+	}
+	return ""
+}
+
+// get makes a Sentry API request for a URL and decodes the response into result,
+// returning the response's headers for pagination
+func (c *SentryClient) get(ctx context.Context, u string, result interface{}) (http.Header, error) {
+	if err := c.budget.Spend(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Detail != "" {
+			return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Detail)
+		}
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.Header, nil
+}
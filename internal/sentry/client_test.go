@@ -0,0 +1,86 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+func TestSentryClient_FetchReleases(t *testing.T) {
+	var server *httptest.Server
+	pages := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/api/0/organizations/acme/releases/" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail": "The requested resource does not exist"}`))
+			return
+		}
+		pages++
+		if r.URL.Query().Get("cursor") == "" {
+			// Sentry's links to later pages carry the query on
+			if r.URL.Query().Get("health") != "1" {
+				t.Errorf("Expected health data to be asked for, got %v", r.URL.Query())
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%[1]s/api/0/organizations/acme/releases/?cursor=0:0:1>; rel="previous"; results="false"; cursor="0:0:1", <%[1]s/api/0/organizations/acme/releases/?cursor=0:100:0>; rel="next"; results="true"; cursor="0:100:0"`, server.URL))
+			w.Write([]byte(`[
+				{"version": "web@2.0.0", "dateCreated": "2024-06-10T00:00:00Z", "projects": [{"slug": "web", "newGroups": 0}]},
+				{"version": "abc1234def", "dateCreated": "2024-06-05T00:00:00Z", "lastCommit": {"id": "abc1234def5678"},
+				 "projects": [{"slug": "web", "newGroups": 3, "healthData": {"hasHealthData": true, "crashFreeSessions": 99.5}}]},
+				{"version": "api@1.0.0", "dateCreated": "2024-06-04T00:00:00Z", "projects": [{"slug": "api", "newGroups": 1}]}
+			]`))
+			return
+		}
+		// The second page holds the last release in range, then older ones
+		w.Header().Set("Link", fmt.Sprintf(`<%s/api/0/organizations/acme/releases/?cursor=0:200:0>; rel="next"; results="true"; cursor="0:200:0"`, server.URL))
+		w.Write([]byte(`[
+			{"version": "web@1.9.0", "dateCreated": "2024-06-02T00:00:00Z", "projects": [{"slug": "web", "newGroups": 1, "healthData": {"hasHealthData": false}}]},
+			{"version": "web@1.8.0", "dateCreated": "2024-05-20T00:00:00Z", "projects": [{"slug": "web", "newGroups": 0}]}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewSentryClient(server.URL+"/", "token", "acme")
+	releases, err := client.FetchReleases(context.Background(), "web", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Older releases stop the listing without fetching a third page
+	if pages != 2 {
+		t.Errorf("Expected 2 pages, got %d", pages)
+	}
+	if len(releases) != 2 || releases[0].Version != "abc1234def" || releases[1].Version != "web@1.9.0" {
+		t.Fatalf("Expected web's two releases in range, got %v", releases)
+	}
+	if releases[0].CommitSHA != "abc1234def5678" || releases[0].NewIssues != 3 || !releases[0].HasHealthData || releases[0].CrashFreeRate != 99.5 {
+		t.Errorf("Unexpected release health %+v", releases[0])
+	}
+	if releases[1].HasHealthData {
+		t.Errorf("Expected a release without sessions to have no health data, got %+v", releases[1])
+	}
+
+	missing := NewSentryClient(server.URL, "token", "other")
+	missing.baseURL = server.URL + "/missing"
+	if _, err := missing.FetchReleases(context.Background(), "web", time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: The requested resource does not exist") {
+		t.Errorf("Expected Sentry's error, got %v", err)
+	}
+}
+
+func TestSentryClient_Budget(t *testing.T) {
+	client := NewSentryClient("http://127.0.0.1:0", "token", "acme") // never reached
+	b := budget.New("API", 1)
+	b.Spend()
+	client.SetBudget(b)
+	if _, err := client.FetchReleases(context.Background(), "web", time.Now().AddDate(0, 0, -1), time.Now()); !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package sentry
+
+import (
+	"strings"
+
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// minSHALength is the shortest abbreviated commit SHA matched against a full one
+const minSHALength = 7
+
+// JoinReleases fills in the release health of each deployment from the Sentry
+// release it shipped: the release named like the deployment's release, or failing
+// that, the one made from the deployed commit. A version of the form
+// package@version is matched by the part after the @.
+func JoinReleases(results []deploy.DeploymentMetric, releases []Release) {
+	for i := range results {
+		release, ok := matchRelease(results[i], releases)
+		if !ok {
+			continue
+		}
+		results[i].SentryRelease = release.Version
+		results[i].HasHealthData = release.HasHealthData
+		results[i].CrashFreeRate = release.CrashFreeRate
+		results[i].NewIssues = release.NewIssues
+	}
+}
+
+// matchRelease returns the release a deployment shipped, if any
+func matchRelease(result deploy.DeploymentMetric, releases []Release) (Release, bool) {
+	for _, release := range releases {
+		version := releaseVersion(release.Version)
+		if version == result.ReleaseID || release.Version == result.ReleaseName {
+			return release, true
+		}
+	}
+	if result.CommitSHA == "" {
+		return Release{}, false
+	}
+	for _, release := range releases {
+		if sameCommit(release.CommitSHA, result.CommitSHA) || sameCommit(releaseVersion(release.Version), result.CommitSHA) {
+			return release, true
+		}
+	}
+	return Release{}, false
+}
+
+// releaseVersion returns a version without its package@ prefix, if it has one
+func releaseVersion(version string) string {
+	if i := strings.LastIndex(version, "@"); i >= 0 {
+		return version[i+1:]
+	}
+	return version
+}
+
+// sameCommit reports whether two SHAs, either of which may be abbreviated, name
+// the same commit
+func sameCommit(a, b string) bool {
+	if len(a) < minSHALength || len(b) < minSHALength {
+		return false
+	}
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// SummarizeHealth summarizes the release health of successful deployments. A
+// release deployed more than once counts its new issues once.
+func SummarizeHealth(results []deploy.DeploymentMetric) HealthStats {
+	var summary HealthStats
+	var rates []float64
+	counted := make(map[string]bool)
+	for _, result := range results {
+		if !result.DeploymentSuccessful {
+			continue
+		}
+		summary.Deployments++
+		if result.SentryRelease == "" {
+			continue
+		}
+		summary.Matched++
+		if result.HasHealthData {
+			summary.WithHealthData++
+			rates = append(rates, result.CrashFreeRate)
+		}
+		if result.NewIssues > 0 {
+			summary.DeploymentsWithNewIssues++
+		}
+		if !counted[result.SentryRelease] {
+			counted[result.SentryRelease] = true
+			summary.NewIssues += result.NewIssues
+		}
+	}
+	summary.MedianCrashFreeRate = stats.MedianOf(rates)
+	return summary
+}
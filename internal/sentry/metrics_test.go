@@ -0,0 +1,44 @@
+package sentry
+
+import (
+	"testing"
+
+	"github.com/reillywatson/statstracker/internal/deploy"
+)
+
+func TestJoinReleases(t *testing.T) {
+	releases := []Release{
+		{Version: "web@v42", NewIssues: 2, HasHealthData: true, CrashFreeRate: 98},
+		{Version: "abc1234", NewIssues: 1, HasHealthData: true, CrashFreeRate: 99.9},
+		{Version: "web@3.1.0", CommitSHA: "def5678def5678", HasHealthData: true, CrashFreeRate: 97},
+	}
+	results := []deploy.DeploymentMetric{
+		{ReleaseID: "v42", CommitSHA: "fff0000fff0000", DeploymentSuccessful: true},       // by release name
+		{ReleaseID: "dpl_1", CommitSHA: "abc1234abc1234abc", DeploymentSuccessful: true},  // by a version that's a commit
+		{ReleaseID: "dpl_2", CommitSHA: "DEF5678", DeploymentSuccessful: true},            // by the release's commit
+		{ReleaseID: "dpl_3", CommitSHA: "0123456789", DeploymentSuccessful: true},         // unmatched
+		{ReleaseID: "dpl_4", CommitSHA: "abc1234abc1234abc", DeploymentSuccessful: false}, // failed
+	}
+
+	JoinReleases(results, releases)
+
+	for i, want := range []string{"web@v42", "abc1234", "web@3.1.0", "", "abc1234"} {
+		if results[i].SentryRelease != want {
+			t.Errorf("Expected deployment %s to match %q, got %q", results[i].ReleaseID, want, results[i].SentryRelease)
+		}
+	}
+	if results[0].NewIssues != 2 || !results[0].HasHealthData || results[0].CrashFreeRate != 98 {
+		t.Errorf("Expected the release's health, got %+v", results[0])
+	}
+
+	stats := SummarizeHealth(results)
+	if stats.Deployments != 4 || stats.Matched != 3 || stats.WithHealthData != 3 {
+		t.Errorf("Expected 3 of 4 successful deployments matched, got %+v", stats)
+	}
+	if stats.MedianCrashFreeRate != 98 {
+		t.Errorf("Expected a median crash-free rate of 98, got %v", stats.MedianCrashFreeRate)
+	}
+	if stats.NewIssues != 3 || stats.DeploymentsWithNewIssues != 2 {
+		t.Errorf("Expected 3 new issues over 2 deployments, got %+v", stats)
+	}
+}
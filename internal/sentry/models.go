@@ -0,0 +1,24 @@
+package sentry
+
+import "time"
+
+// Release is a Sentry release of one project, with its health since it was created
+type Release struct {
+	Version       string // e.g. a commit SHA, or web@1.4.2
+	CommitSHA     string // The release's last commit, "" if no commits were associated with it
+	CreatedAt     time.Time
+	NewIssues     int     // Issues first seen in the release
+	HasHealthData bool    // Whether the release sent sessions, which crash-free rates need
+	CrashFreeRate float64 // Percentage of the release's sessions that didn't crash
+}
+
+// HealthStats summarizes the release health of the deployments matched to a
+// Sentry release
+type HealthStats struct {
+	Deployments              int     // Successful deployments
+	Matched                  int     // Of those, deployments matched to a Sentry release
+	WithHealthData           int     // Of those, deployments whose release had session data
+	MedianCrashFreeRate      float64 // Median crash-free session rate over those with session data
+	NewIssues                int     // New issues across the matched releases, each release counted once
+	DeploymentsWithNewIssues int     // Matched deployments whose release introduced at least one issue
+}
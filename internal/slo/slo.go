@@ -0,0 +1,122 @@
+// Package slo reports delivery metrics against service-level objectives, SRE
+// style: each period's error budget is the share of samples allowed to miss the
+// objective, and the burn rate is how fast misses are using it up.
+package slo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/period"
+)
+
+// Objective is a delivery SLO: Target (a fraction) of samples should take no longer than Threshold
+type Objective struct {
+	Metric    string // What's measured, such as first-review
+	Threshold time.Duration
+	Target    float64
+}
+
+// ParseObjective parses an objective written as metric=threshold@target, such as
+// first-review=8h@90%
+func ParseObjective(spec string) (Objective, error) {
+	metric, rest, ok := strings.Cut(spec, "=")
+	if !ok {
+		return Objective{}, fmt.Errorf("invalid SLO %q. Use metric=threshold@target, e.g. first-review=8h@90%%", spec)
+	}
+	thresholdStr, targetStr, ok := strings.Cut(rest, "@")
+	if !ok {
+		return Objective{}, fmt.Errorf("invalid SLO %q. Use metric=threshold@target, e.g. first-review=8h@90%%", spec)
+	}
+
+	threshold, err := time.ParseDuration(strings.TrimSpace(thresholdStr))
+	if err != nil || threshold <= 0 {
+		return Objective{}, fmt.Errorf("invalid SLO threshold %q in %q", thresholdStr, spec)
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(targetStr), "%"), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return Objective{}, fmt.Errorf("invalid SLO target %q in %q: must be a percentage between 0 and 100", targetStr, spec)
+	}
+
+	return Objective{Metric: strings.TrimSpace(metric), Threshold: threshold, Target: percent / 100}, nil
+}
+
+// String describes the objective, e.g. "first-review within 8h0m0s for 90%"
+func (o Objective) String() string {
+	return fmt.Sprintf("%s within %v for %s%%", o.Metric, o.Threshold, strconv.FormatFloat(o.Target*100, 'f', -1, 64))
+}
+
+// Sample is one measurement against an objective, such as one PR's time to first review
+type Sample struct {
+	At      time.Time     // When the clock started, used to assign the sample to a period
+	Elapsed time.Duration // Time taken, or time so far if not Done
+	Done    bool
+}
+
+// Result is how an objective fared over one period. Samples still running but
+// within the threshold haven't met or missed it yet, so aren't counted.
+type Result struct {
+	Period          period.Period
+	Met             int
+	Missed          int
+	Compliance      float64 // Share of counted samples that met the objective
+	BudgetRemaining float64 // Share of the error budget left; negative once overspent
+	BurnRate        float64 // Miss rate relative to the budgeted rate; above 1 overspends the budget
+}
+
+// Total is the number of samples counted against the objective
+func (r Result) Total() int {
+	return r.Met + r.Missed
+}
+
+// Evaluate measures samples against the objective in each period
+func Evaluate(o Objective, periods []period.Period, samples []Sample) []Result {
+	groups := period.Group(periods, samples, func(s Sample) time.Time { return s.At })
+
+	results := make([]Result, len(periods))
+	for i, p := range periods {
+		result := Result{Period: p}
+		for _, s := range groups[i] {
+			switch {
+			case s.Elapsed > o.Threshold:
+				result.Missed++
+			case s.Done:
+				result.Met++
+			}
+		}
+
+		if total := result.Total(); total > 0 {
+			result.Compliance = float64(result.Met) / float64(total)
+			missRate := float64(result.Missed) / float64(total)
+			result.BurnRate = missRate / (1 - o.Target)
+			result.BudgetRemaining = 1 - result.BurnRate
+		} else {
+			result.BudgetRemaining = 1
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// Objectives is a repeatable command-line flag of objectives
+type Objectives []Objective
+
+func (o *Objectives) String() string {
+	var specs []string
+	for _, objective := range *o {
+		specs = append(specs, objective.String())
+	}
+	return strings.Join(specs, ", ")
+}
+
+// Set parses and adds an objective
+func (o *Objectives) Set(spec string) error {
+	objective, err := ParseObjective(spec)
+	if err != nil {
+		return err
+	}
+	*o = append(*o, objective)
+	return nil
+}
@@ -0,0 +1,69 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/period"
+)
+
+func TestParseObjective(t *testing.T) {
+	o, err := ParseObjective("first-review=8h@90%")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if o.Metric != "first-review" || o.Threshold != 8*time.Hour || o.Target != 0.9 {
+		t.Errorf("Unexpected objective %+v", o)
+	}
+	if o.String() != "first-review within 8h0m0s for 90%" {
+		t.Errorf("Unexpected description %q", o.String())
+	}
+
+	for _, spec := range []string{"first-review", "first-review=8h", "first-review=soon@90%", "first-review=8h@100%", "first-review=8h@abc"} {
+		if _, err := ParseObjective(spec); err == nil {
+			t.Errorf("Expected an error parsing %q", spec)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	o := Objective{Metric: "first-review", Threshold: 8 * time.Hour, Target: 0.9}
+	march := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+	periods := period.Months(march, april)
+
+	var samples []Sample
+	// March: 18 met, 2 missed (one still waiting), 1 still within the threshold
+	for i := 0; i < 18; i++ {
+		samples = append(samples, Sample{At: march, Elapsed: time.Hour, Done: true})
+	}
+	samples = append(samples,
+		Sample{At: march, Elapsed: 9 * time.Hour, Done: true},
+		Sample{At: march, Elapsed: 20 * time.Hour},
+		Sample{At: march, Elapsed: 2 * time.Hour},
+	)
+
+	results := Evaluate(o, periods, samples)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 months, got %d", len(results))
+	}
+	got := results[0]
+	if got.Met != 18 || got.Missed != 2 || got.Total() != 20 {
+		t.Errorf("Expected 18 met and 2 missed, got %d and %d", got.Met, got.Missed)
+	}
+	if got.Compliance != 0.9 {
+		t.Errorf("Expected 90%% compliance, got %v", got.Compliance)
+	}
+	// Missing 10% against a 10% budget burns it exactly
+	if diff := got.BurnRate - 1; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected a burn rate of 1, got %v", got.BurnRate)
+	}
+	if got.BudgetRemaining > 1e-9 || got.BudgetRemaining < -1e-9 {
+		t.Errorf("Expected no budget remaining, got %v", got.BudgetRemaining)
+	}
+
+	if results[1].Total() != 0 || results[1].BudgetRemaining != 1 {
+		t.Errorf("Expected an untouched budget for April, got %+v", results[1])
+	}
+}
@@ -0,0 +1,303 @@
+// Package spinnaker reads deployments from Spinnaker pipeline executions through
+// its Gate API, for teams that deploy with Spinnaker rather than Cloud Deploy.
+// Each successful execution is a release, with the commit it deployed found from
+// its trigger's artifacts.
+package spinnaker
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// executionLimit is how many of each pipeline's latest executions Gate is asked
+// for. Gate can't filter executions by date, so older ones can't be found.
+const executionLimit = 100
+
+// CommitFunc looks up the message of a commit in a GitHub repository and when it
+// was made. owner and repo are empty when the trigger doesn't say which
+// repository the commit is in, for the caller to look in the services'
+// repository instead.
+type CommitFunc func(ctx context.Context, owner, repo, sha string) (message string, committed time.Time, err error)
+
+// SpinnakerClient handles Spinnaker Gate API operations
+type SpinnakerClient struct {
+	httpClient *http.Client
+	token      string // "" if Gate doesn't need one
+	apps       []string
+	pipelines  []string // Pipeline names to count, all of them if empty
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+	commits    CommitFunc
+
+	// The executions listed, for the other methods
+	mu         sync.Mutex
+	executions map[string]execution
+}
+
+var _ deploy.DeployProvider = (*SpinnakerClient)(nil)
+
+// NewSpinnakerClient creates a new client for the given applications on the Gate
+// server at gateURL, sending token as a bearer token if it isn't empty. commits
+// looks up the commits that were deployed.
+func NewSpinnakerClient(gateURL, token string, apps []string, commits CommitFunc) *SpinnakerClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &SpinnakerClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:      token,
+		apps:       apps,
+		baseURL:    strings.TrimSuffix(gateURL, "/"),
+		retry:      retrying,
+		commits:    commits,
+		executions: make(map[string]execution),
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *SpinnakerClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *SpinnakerClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// SetPipelines restricts deployments to executions of the given pipelines, such
+// as the one deploying to production, by name
+func (c *SpinnakerClient) SetPipelines(pipelines []string) {
+	c.pipelines = pipelines
+}
+
+type execution struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"` // The pipeline's name
+	Status    string  `json:"status"`
+	StartTime int64   `json:"startTime"` // milliseconds since the epoch
+	EndTime   int64   `json:"endTime"`
+	Trigger   trigger `json:"trigger"`
+}
+
+type trigger struct {
+	Type      string     `json:"type"`
+	Source    string     `json:"source"`  // For git triggers, github, gitlab or bitbucket
+	Project   string     `json:"project"` // For git triggers, the repository's owner
+	Slug      string     `json:"slug"`    // For git triggers, the repository's name
+	Hash      string     `json:"hash"`    // For git triggers, the commit pushed
+	Artifacts []artifact `json:"artifacts"`
+
+	// For pipeline triggers, the execution that triggered this one
+	ParentExecution *struct {
+		Trigger trigger `json:"trigger"`
+	} `json:"parentExecution"`
+}
+
+type artifact struct {
+	Type      string `json:"type"` // Such as git/repo, github/file or docker/image
+	Reference string `json:"reference"`
+	Version   string `json:"version"`
+}
+
+// ListReleases lists the applications' successful pipeline executions started in
+// the date range, newest first within each application. Only executions of the
+// pipelines set with SetPipelines are included, if any were. If the API budget
+// runs out part way through, the releases found so far are returned along with
+// the error.
+func (c *SpinnakerClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, app := range c.apps {
+		query := url.Values{
+			"limit":    {fmt.Sprint(executionLimit)},
+			"statuses": {"SUCCEEDED"},
+		}
+		var executions []execution
+		if err := c.get(ctx, "/applications/"+url.PathEscape(app)+"/pipelines?"+query.Encode(), &executions); err != nil {
+			return releases, fmt.Errorf("failed to list executions for application %s: %w", app, err)
+		}
+
+		slices.SortFunc(executions, func(a, b execution) int {
+			return cmp.Compare(b.StartTime, a.StartTime)
+		})
+		for _, e := range executions {
+			started := time.UnixMilli(e.StartTime)
+			if e.Status != "SUCCEEDED" || started.Before(startDate) || started.After(endDate) {
+				continue
+			}
+			if len(c.pipelines) > 0 && !slices.Contains(c.pipelines, e.Name) {
+				continue
+			}
+			releases = append(releases, c.release(app, e))
+		}
+	}
+	return releases, nil
+}
+
+// release converts an execution to a deploy.Release, remembering it for
+// ExtractSource and GetRolloutCompletion
+func (c *SpinnakerClient) release(app string, e execution) *deploy.Release {
+	name := fmt.Sprintf("applications/%s/executions/%s", app, e.ID)
+	c.mu.Lock()
+	c.executions[name] = e
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        name,
+		CreateTime:  time.UnixMilli(e.StartTime),
+		Annotations: map[string]string{"app": app, "pipeline": e.Name},
+	}
+}
+
+// shaPattern matches a Git commit SHA, as opposed to a version or image tag
+var shaPattern = regexp.MustCompile(`^[a-f0-9]{40}$`)
+
+// ExtractSource returns the commit an execution deployed, the PR it merged if its
+// message names one, and when it was made. The commit comes from the trigger: a
+// Git artifact's version, then the commit a git trigger was pushed, then any
+// other artifact versioned or tagged with a commit SHA, such as a Docker image.
+// Executions started by another pipeline use the commit that one deployed.
+func (c *SpinnakerClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	e, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	sha, owner, repo := triggerCommit(e.Trigger)
+	if sha == "" {
+		return "", "", time.Time{}, fmt.Errorf("no Git commit found in the execution's trigger")
+	}
+	message, committed, err := c.commits(ctx, owner, repo, sha)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", sha, err)
+	}
+	return sha, deploy.PRFromCommitMessage(message), committed, nil
+}
+
+// triggerCommit finds the commit a trigger deployed, and the GitHub repository
+// it's in if the trigger says
+func triggerCommit(t trigger) (sha, owner, repo string) {
+	for _, a := range t.Artifacts {
+		if isGitArtifact(a.Type) && shaPattern.MatchString(a.Version) {
+			owner, repo := githubRepository(a.Reference)
+			return a.Version, owner, repo
+		}
+	}
+	if t.Type == "git" && shaPattern.MatchString(t.Hash) {
+		if t.Source == "github" {
+			return t.Hash, t.Project, t.Slug
+		}
+		return t.Hash, "", ""
+	}
+	for _, a := range t.Artifacts {
+		if shaPattern.MatchString(a.Version) {
+			return a.Version, "", ""
+		}
+		// An image reference ends in its tag, as in gcr.io/project/shop:<sha>
+		if i := strings.LastIndex(a.Reference, ":"); i >= 0 && shaPattern.MatchString(a.Reference[i+1:]) {
+			return a.Reference[i+1:], "", ""
+		}
+	}
+	if t.ParentExecution != nil {
+		return triggerCommit(t.ParentExecution.Trigger)
+	}
+	return "", "", ""
+}
+
+// isGitArtifact reports whether an artifact type is a file or repository in Git
+func isGitArtifact(artifactType string) bool {
+	return artifactType == "git/repo" || strings.HasPrefix(artifactType, "github/") ||
+		strings.HasPrefix(artifactType, "gitlab/") || strings.HasPrefix(artifactType, "bitbucket/")
+}
+
+// GetRolloutCompletion returns when the execution finished
+func (c *SpinnakerClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	e, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(e.EndTime), nil
+}
+
+// listed returns the execution a release was converted from
+func (c *SpinnakerClient) listed(release *deploy.Release) (execution, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.executions[release.Name]
+	if !ok {
+		return execution{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return e, nil
+}
+
+// githubRepoPattern matches the owner and name of a GitHub repository in a clone
+// URL, or in the API URL of a file in it, as github/file artifacts reference
+var githubRepoPattern = regexp.MustCompile(`^(?:https://github\.com/|https://api\.github\.com/repos/|git@github\.com:)([^/]+)/([^/]+?)(?:\.git)?(?:/.*)?$`)
+
+// githubRepository returns the owner and name of the GitHub repository an
+// artifact references, or empty strings if it isn't in one
+func githubRepository(reference string) (owner, repo string) {
+	matches := githubRepoPattern.FindStringSubmatch(reference)
+	if matches == nil {
+		return "", ""
+	}
+	return matches[1], matches[2]
+}
+
+// get makes a Gate API request and decodes the response into result
+func (c *SpinnakerClient) get(ctx context.Context, path string, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
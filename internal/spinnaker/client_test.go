@@ -0,0 +1,118 @@
+package spinnaker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024 in milliseconds since the epoch, as Gate encodes times
+func at(hour int) int64 {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+const (
+	shaApp   = "abc1234abc1234abc1234abc1234abc1234abc12"
+	shaImage = "def5678def5678def5678def5678def5678def56"
+	shaPush  = "1234567812345678123456781234567812345678"
+)
+
+func TestSpinnakerClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/applications/shop/pipelines" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status": 404, "error": "Not Found", "message": "Application not found (id: missing)"}`))
+			return
+		}
+		if r.URL.Query().Get("statuses") != "SUCCEEDED" || r.URL.Query().Get("limit") != "100" {
+			t.Errorf("Expected the latest successful executions to be asked for, got %s", r.URL.RawQuery)
+		}
+		// Not in any order: one triggered by a Git artifact, one by an image tagged
+		// with its commit, one by another pipeline that was pushed to, one of a
+		// pipeline that isn't counted, and one before the date range
+		fmt.Fprintf(w, `[
+			{"id": "e2", "name": "Deploy", "status": "SUCCEEDED", "startTime": %d, "endTime": %d,
+			 "trigger": {"type": "docker", "artifacts": [{"type": "docker/image", "reference": "gcr.io/p/shop:%s", "version": "%s"}]}},
+			{"id": "e1", "name": "Deploy", "status": "SUCCEEDED", "startTime": %d, "endTime": %d,
+			 "trigger": {"type": "webhook", "artifacts": [
+				{"type": "docker/image", "reference": "gcr.io/p/shop:v1.2.0", "version": "v1.2.0"},
+				{"type": "github/file", "reference": "https://api.github.com/repos/someorg/shop/contents/deploy.yml", "version": "%s"}]}},
+			{"id": "e3", "name": "Deploy", "status": "SUCCEEDED", "startTime": %d, "endTime": %d,
+			 "trigger": {"type": "pipeline", "parentExecution": {"trigger": {"type": "git", "source": "github", "project": "someorg", "slug": "shop-ops", "hash": "%s"}}}},
+			{"id": "e4", "name": "Bake", "status": "SUCCEEDED", "startTime": %d, "endTime": %d, "trigger": {"type": "manual"}},
+			{"id": "e0", "name": "Deploy", "status": "SUCCEEDED", "startTime": %d, "endTime": %d, "trigger": {"type": "manual"}}
+		]`, at(11), at(12), shaImage, shaImage, at(9), at(10), shaApp, at(13), at(14), shaPush, at(15), at(16),
+			time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC).UnixMilli(), time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).UnixMilli())
+	}))
+	defer server.Close()
+
+	var looked []string
+	commits := func(ctx context.Context, owner, repo, sha string) (string, time.Time, error) {
+		looked = append(looked, owner+"/"+repo)
+		if sha == shaApp {
+			return "Add search (#42)", time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC), nil
+		}
+		return "Bump dependencies", time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC), nil
+	}
+	client := NewSpinnakerClient(server.URL+"/", "token", []string{"shop"}, commits)
+	client.SetPipelines([]string{"Deploy"})
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Newest first, leaving out the Bake pipeline and the execution before the range
+	var names []string
+	for _, release := range releases {
+		names = append(names, release.Name)
+	}
+	if strings.Join(names, ",") != "applications/shop/executions/e3,applications/shop/executions/e2,applications/shop/executions/e1" {
+		t.Fatalf("Expected executions e3, e2 and e1, got %v", names)
+	}
+
+	sha, prNumber, commitTime, err := client.ExtractSource(context.Background(), releases[2])
+	if err != nil || sha != shaApp || prNumber != "42" || !commitTime.Equal(time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected PR #42's squashed commit from the Git artifact, got %s %s %v (%v)", sha, prNumber, commitTime, err)
+	}
+	if sha, _, _, err := client.ExtractSource(context.Background(), releases[1]); err != nil || sha != shaImage {
+		t.Errorf("Expected the image's tag, got %s (%v)", sha, err)
+	}
+	if sha, prNumber, _, err := client.ExtractSource(context.Background(), releases[0]); err != nil || sha != shaPush || prNumber != "" {
+		t.Errorf("Expected the commit pushed to the parent pipeline, got %s %q (%v)", sha, prNumber, err)
+	}
+	if strings.Join(looked, ",") != "someorg/shop,/,someorg/shop-ops" {
+		t.Errorf("Expected commits to be looked up in the repositories the triggers name, got %v", looked)
+	}
+
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[2])
+	if err != nil || !finish.Equal(time.UnixMilli(at(10))) {
+		t.Errorf("Expected the execution's end time, got %v (%v)", finish, err)
+	}
+
+	missing := NewSpinnakerClient(server.URL, "token", []string{"missing"}, commits)
+	if _, err := missing.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: Application not found (id: missing)") {
+		t.Errorf("Expected Gate's error, got %v", err)
+	}
+}
+
+func TestGitHubRepository(t *testing.T) {
+	for reference, want := range map[string]string{
+		"https://github.com/someorg/shop.git":                               "someorg/shop",
+		"https://github.com/someorg/shop":                                   "someorg/shop",
+		"git@github.com:someorg/shop.git":                                   "someorg/shop",
+		"https://api.github.com/repos/someorg/shop/contents/deploy/k8s.yml": "someorg/shop",
+		"https://gitlab.com/someorg/shop.git":                               "/",
+		"gcr.io/p/shop:v1.2.0":                                              "/",
+	} {
+		if owner, repo := githubRepository(reference); owner+"/"+repo != want {
+			t.Errorf("githubRepository(%q) = %s/%s, want %s", reference, owner, repo, want)
+		}
+	}
+}
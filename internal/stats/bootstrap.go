@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// MinSample is the fewest values a summary statistic is compared on without a
+// warning. With fewer, a single slow PR moves the mean or median a long way, so
+// a week with four PRs can look like a trend when it's chance.
+const MinSample = 8
+
+// DefaultResamples is how many times Bootstrap resamples the values
+const DefaultResamples = 2000
+
+// seed seeds the resampling, so the same values always get the same intervals
+const seed = 1
+
+// Bootstrap estimates confidence intervals for a statistic by resampling: it
+// recomputes the statistic on many samples drawn with replacement from the
+// values, and takes the middle of those results. Unlike the textbook interval
+// for a mean, it needs no assumptions about the shape of the distribution and
+// works for medians too. A nil Bootstrap estimates nothing.
+type Bootstrap struct {
+	confidence float64
+	resamples  int
+}
+
+// NewBootstrap returns a Bootstrap for intervals at the given confidence level,
+// as a percentage such as 95
+func NewBootstrap(confidence float64) (*Bootstrap, error) {
+	if confidence <= 0 || confidence >= 100 {
+		return nil, fmt.Errorf("confidence must be between 0 and 100 percent, got %v", confidence)
+	}
+	return &Bootstrap{
+		confidence: confidence / 100,
+		resamples:  DefaultResamples,
+	}, nil
+}
+
+// Confidence returns the confidence level as a percentage
+func (b *Bootstrap) Confidence() float64 {
+	return b.confidence * 100
+}
+
+// Interval returns the confidence interval of statistic over values. ok is false
+// if b is nil or there are fewer than two values, as one value's resamples are
+// all the same. Each call resamples from the same seed, so the same values always
+// get the same interval, whatever was estimated before.
+func (b *Bootstrap) Interval(values []float64, statistic func([]float64) float64) (low, high float64, ok bool) {
+	if b == nil || len(values) < 2 {
+		return 0, 0, false
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	estimates := make([]float64, b.resamples)
+	resample := make([]float64, len(values))
+	for i := range estimates {
+		for j := range resample {
+			resample[j] = values[rng.IntN(len(values))]
+		}
+		estimates[i] = statistic(resample)
+	}
+	slices.Sort(estimates)
+
+	tail := (1 - b.confidence) / 2
+	return quantile(estimates, tail), quantile(estimates, 1-tail), true
+}
+
+// quantile returns the q quantile of sorted values, interpolating between the
+// two nearest
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// Mean returns the mean of values, or NaN if there are none
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBootstrap(t *testing.T) {
+	b, err := NewBootstrap(95)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The interval brackets the statistic, and narrows as the sample grows
+	var small, large []float64
+	for i := range 10 {
+		small = append(small, float64(i))
+	}
+	for i := range 1000 {
+		large = append(large, float64(i%10))
+	}
+	low, high, ok := b.Interval(small, Mean)
+	if !ok || low > 4.5 || high < 4.5 || low < 0 || high > 9 {
+		t.Errorf("Expected an interval around the mean of 4.5, got %v to %v", low, high)
+	}
+	largeLow, largeHigh, _ := b.Interval(large, Mean)
+	if largeHigh-largeLow >= (high-low)/3 {
+		t.Errorf("Expected a much narrower interval from 100 times the values, got %v to %v against %v to %v", largeLow, largeHigh, low, high)
+	}
+	if low, high, _ := b.Interval(large, Median); low != 4 || high != 5 {
+		t.Errorf("Expected the median's interval to be 4 to 5, got %v to %v", low, high)
+	}
+
+	// The same values get the same interval, from another Bootstrap or after
+	// other intervals were estimated
+	again, _ := NewBootstrap(95)
+	if againLow, againHigh, _ := again.Interval(small, Mean); againLow != low || againHigh != high {
+		t.Errorf("Expected the same interval again, got %v to %v", againLow, againHigh)
+	}
+	if againLow, againHigh, _ := b.Interval(small, Mean); againLow != low || againHigh != high {
+		t.Errorf("Expected the same interval after estimating others, got %v to %v", againLow, againHigh)
+	}
+
+	if _, _, ok := b.Interval([]float64{3}, Mean); ok {
+		t.Error("Expected no interval from a single value")
+	}
+	var none *Bootstrap
+	if _, _, ok := none.Interval(small, Mean); ok {
+		t.Error("Expected no interval from a nil Bootstrap")
+	}
+	if _, err := NewBootstrap(100); err == nil {
+		t.Error("Expected an error for 100% confidence")
+	}
+}
+
+func TestMeanMedian(t *testing.T) {
+	values := []float64{4, 1, 3, 2}
+	if Mean(values) != 2.5 || Median(values) != 2.5 || Median(values[:3]) != 3 {
+		t.Errorf("Expected a mean and median of 2.5, got %v and %v", Mean(values), Median(values))
+	}
+	if values[0] != 4 {
+		t.Error("Expected Median to leave the values in order")
+	}
+	if !math.IsNaN(Mean(nil)) || !math.IsNaN(Median(nil)) {
+		t.Error("Expected NaN without values")
+	}
+
+	durations := []time.Duration{3 * time.Hour, time.Hour, 4 * time.Hour, 2 * time.Hour}
+	if got := MedianOf(durations); got != 150*time.Minute || durations[0] != 3*time.Hour {
+		t.Errorf("Expected a median of 2h30m with the durations left in order, got %v", got)
+	}
+	if MedianOf([]time.Duration(nil)) != 0 {
+		t.Error("Expected 0 without durations")
+	}
+}
@@ -0,0 +1,32 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	// No overlap at all: as scipy.stats.mannwhitneyu(method="asymptotic") finds
+	a := []float64{1, 2, 3, 4, 5}
+	b := []float64{6, 7, 8, 9, 10}
+	u, p := MannWhitneyU(a, b)
+	if u != 0 || math.Abs(p-0.01219) > 0.0001 {
+		t.Errorf("Expected U 0 and p 0.0122, got %v and %.4f", u, p)
+	}
+	if u, p2 := MannWhitneyU(b, a); u != 25 || p2 != p {
+		t.Errorf("Expected U 25 and the same p-value the other way round, got %v and %.4f", u, p2)
+	}
+
+	// Ties share their ranks, and interleaved samples aren't significant
+	u, p = MannWhitneyU([]float64{1, 2, 2, 5, 7}, []float64{2, 3, 4, 6})
+	if u != 8 || p < 0.5 {
+		t.Errorf("Expected U 8 and no significance, got %v and %.4f", u, p)
+	}
+
+	if _, p := MannWhitneyU([]float64{3, 3}, []float64{3, 3, 3}); p != 1 {
+		t.Errorf("Expected p 1 when every value is the same, got %v", p)
+	}
+	if _, p := MannWhitneyU(nil, b); !math.IsNaN(p) {
+		t.Errorf("Expected NaN with an empty sample, got %v", p)
+	}
+}
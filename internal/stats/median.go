@@ -0,0 +1,35 @@
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// Number is a value whose median can be taken, such as a time.Duration or a float64
+type Number interface {
+	~int64 | ~float64
+}
+
+// MedianOf returns the median of values, or 0 if there are none. values is left
+// as it was.
+func MedianOf[T Number](values []T) T {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	if n%2 != 0 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Median returns the median of values, or NaN if there are none, for statistics
+// that shouldn't pass off no data as 0. values is left as it was.
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return MedianOf(values)
+}
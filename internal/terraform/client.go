@@ -0,0 +1,293 @@
+// Package terraform reads runs from Terraform Cloud or Terraform Enterprise, so that
+// infrastructure changes are measured like deployments: how often each workspace
+// applies, how long its plans and applies take, and how often they fail.
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	// DefaultHostname is Terraform Cloud's; Terraform Enterprise runs on its own
+	DefaultHostname = "app.terraform.io"
+	defaultTimeout  = 30 * time.Second
+	pageSize        = 100
+)
+
+// TerraformClient handles Terraform Cloud and Enterprise API operations
+type TerraformClient struct {
+	httpClient   *http.Client
+	token        string
+	organization string
+	baseURL      string
+	budget       *budget.Budget // nil means unlimited
+	retry        *retry.Transport
+}
+
+// NewTerraformClient creates a new client for an organization on hostname (which
+// is DefaultHostname for Terraform Cloud), authenticated with a user or team API
+// token
+func NewTerraformClient(hostname, token, organization string) *TerraformClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &TerraformClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:        token,
+		organization: organization,
+		baseURL:      "https://" + hostname + "/api/v2",
+		retry:        retrying,
+	}
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *TerraformClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *TerraformClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+// resource is a JSON:API resource, as the API returns everything
+type resource struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Attributes    json.RawMessage `json:"attributes"`
+	Relationships map[string]struct {
+		Data *struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"data"`
+	} `json:"relationships"`
+}
+
+// related returns the ID of the resource a relationship points at, if any
+func (r resource) related(name string) string {
+	if rel, ok := r.Relationships[name]; ok && rel.Data != nil {
+		return rel.Data.ID
+	}
+	return ""
+}
+
+// document is a page of JSON:API resources
+type document struct {
+	Data     json.RawMessage `json:"data"`
+	Included []resource      `json:"included"`
+	Meta     struct {
+		Pagination struct {
+			NextPage *int `json:"next-page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+// FetchWorkspaces returns the organization's workspaces with the given names, or
+// all of its workspaces if names is empty
+func (c *TerraformClient) FetchWorkspaces(ctx context.Context, names []string) ([]Workspace, error) {
+	var workspaces []Workspace
+	if len(names) > 0 {
+		for _, name := range names {
+			var doc document
+			if err := c.get(ctx, "/organizations/"+url.PathEscape(c.organization)+"/workspaces/"+url.PathEscape(name), nil, &doc); err != nil {
+				return workspaces, fmt.Errorf("failed to fetch workspace %s: %w", name, err)
+			}
+			var ws resource
+			if err := json.Unmarshal(doc.Data, &ws); err != nil {
+				return workspaces, fmt.Errorf("failed to decode workspace %s: %w", name, err)
+			}
+			workspaces = append(workspaces, Workspace{ID: ws.ID, Name: name})
+		}
+		return workspaces, nil
+	}
+
+	for page := 1; ; page++ {
+		query := url.Values{
+			"page[number]": {strconv.Itoa(page)},
+			"page[size]":   {strconv.Itoa(pageSize)},
+		}
+		var doc document
+		if err := c.get(ctx, "/organizations/"+url.PathEscape(c.organization)+"/workspaces", query, &doc); err != nil {
+			return workspaces, fmt.Errorf("failed to list workspaces: %w", err)
+		}
+		var resources []resource
+		if err := json.Unmarshal(doc.Data, &resources); err != nil {
+			return workspaces, fmt.Errorf("failed to decode workspaces: %w", err)
+		}
+		for _, ws := range resources {
+			var attributes struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(ws.Attributes, &attributes); err != nil {
+				return workspaces, fmt.Errorf("failed to decode workspace %s: %w", ws.ID, err)
+			}
+			workspaces = append(workspaces, Workspace{ID: ws.ID, Name: attributes.Name})
+		}
+		if doc.Meta.Pagination.NextPage == nil {
+			break
+		}
+	}
+	return workspaces, nil
+}
+
+type runAttributes struct {
+	Status           string    `json:"status"`
+	Message          string    `json:"message"`
+	Source           string    `json:"source"`
+	CreatedAt        time.Time `json:"created-at"`
+	PlanOnly         bool      `json:"plan-only"`
+	IsDestroy        bool      `json:"is-destroy"`
+	StatusTimestamps struct {
+		PlanningAt *time.Time `json:"planning-at"`
+		PlannedAt  *time.Time `json:"planned-at"`
+		ApplyingAt *time.Time `json:"applying-at"`
+		AppliedAt  *time.Time `json:"applied-at"`
+		ErroredAt  *time.Time `json:"errored-at"`
+	} `json:"status-timestamps"`
+}
+
+// FetchRuns returns a workspace's runs created in the date range, newest first,
+// with the commit each run's configuration came from. If the API budget runs out
+// part way through, the runs found so far are returned along with the error.
+func (c *TerraformClient) FetchRuns(ctx context.Context, workspace Workspace, startDate, endDate time.Time) ([]Run, error) {
+	var runs []Run
+	for page := 1; ; page++ {
+		// The commit is on the configuration version's ingress attributes
+		query := url.Values{
+			"page[number]": {strconv.Itoa(page)},
+			"page[size]":   {strconv.Itoa(pageSize)},
+			"include":      {"configuration_version.ingress_attributes"},
+		}
+		var doc document
+		if err := c.get(ctx, "/workspaces/"+url.PathEscape(workspace.ID)+"/runs", query, &doc); err != nil {
+			return runs, fmt.Errorf("failed to list runs for %s: %w", workspace.Name, err)
+		}
+		var resources []resource
+		if err := json.Unmarshal(doc.Data, &resources); err != nil {
+			return runs, fmt.Errorf("failed to decode runs for %s: %w", workspace.Name, err)
+		}
+		commits := includedCommits(doc.Included)
+
+		// Runs are listed newest first, with no date filter
+		for _, r := range resources {
+			var attributes runAttributes
+			if err := json.Unmarshal(r.Attributes, &attributes); err != nil {
+				return runs, fmt.Errorf("failed to decode run %s: %w", r.ID, err)
+			}
+			if attributes.CreatedAt.Before(startDate) {
+				return runs, nil
+			}
+			if attributes.CreatedAt.After(endDate) {
+				continue
+			}
+			timestamps := attributes.StatusTimestamps
+			runs = append(runs, Run{
+				ID:         r.ID,
+				Workspace:  workspace.Name,
+				Status:     attributes.Status,
+				Message:    attributes.Message,
+				Source:     attributes.Source,
+				CommitSHA:  commits[r.related("configuration-version")],
+				CreatedAt:  attributes.CreatedAt,
+				PlanOnly:   attributes.PlanOnly,
+				IsDestroy:  attributes.IsDestroy,
+				PlanningAt: timestamps.PlanningAt,
+				PlannedAt:  timestamps.PlannedAt,
+				ApplyingAt: timestamps.ApplyingAt,
+				AppliedAt:  timestamps.AppliedAt,
+				ErroredAt:  timestamps.ErroredAt,
+			})
+		}
+		if doc.Meta.Pagination.NextPage == nil {
+			return runs, nil
+		}
+	}
+}
+
+// includedCommits maps configuration version IDs to the commit they came from,
+// from the resources included with a page of runs
+func includedCommits(included []resource) map[string]string {
+	shas := make(map[string]string) // by ingress attributes ID
+	for _, r := range included {
+		if r.Type != "ingress-attributes" {
+			continue
+		}
+		var attributes struct {
+			CommitSHA string `json:"commit-sha"`
+		}
+		if json.Unmarshal(r.Attributes, &attributes) == nil {
+			shas[r.ID] = attributes.CommitSHA
+		}
+	}
+	commits := make(map[string]string)
+	for _, r := range included {
+		if r.Type == "configuration-versions" {
+			if sha := shas[r.related("ingress-attributes")]; sha != "" {
+				commits[r.ID] = sha
+			}
+		}
+	}
+	return commits
+}
+
+// get makes a Terraform API request and decodes the response into result
+func (c *TerraformClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Errors []struct {
+				Title  string `json:"title"`
+				Detail string `json:"detail"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			message := apiErr.Errors[0].Title
+			if apiErr.Errors[0].Detail != "" {
+				message += ": " + apiErr.Errors[0].Detail
+			}
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
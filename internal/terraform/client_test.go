@@ -0,0 +1,123 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+)
+
+func TestTerraformClient_FetchRuns(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/workspaces/ws-1/runs" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("include") != "configuration_version.ingress_attributes" {
+			t.Errorf("Expected the commits to be included, got %v", query)
+		}
+		pages = append(pages, query.Get("page[number]"))
+		if query.Get("page[number]") == "2" {
+			// The second page reaches runs from before the range
+			w.Write([]byte(`{"data": [
+				{"id": "run-2", "type": "runs", "attributes": {"status": "errored", "created-at": "2024-06-02T09:00:00Z", "status-timestamps": {"planning-at": "2024-06-02T09:00:10Z", "errored-at": "2024-06-02T09:01:10Z"}}},
+				{"id": "run-1", "type": "runs", "attributes": {"status": "applied", "created-at": "2024-05-30T09:00:00Z"}}
+			], "meta": {"pagination": {"next-page": 3}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": [
+			{"id": "run-4", "type": "runs", "attributes": {"status": "applied", "created-at": "2024-06-05T09:00:00Z"}},
+			{"id": "run-3", "type": "runs", "attributes": {"status": "applied", "message": "Add a bucket", "created-at": "2024-06-03T09:00:00Z", "status-timestamps": {"planning-at": "2024-06-03T09:00:05Z", "planned-at": "2024-06-03T09:01:05Z", "applying-at": "2024-06-03T09:02:00Z", "applied-at": "2024-06-03T09:05:00Z"}},
+				"relationships": {"configuration-version": {"data": {"id": "cv-3", "type": "configuration-versions"}}}}
+		], "included": [
+			{"id": "cv-3", "type": "configuration-versions", "relationships": {"ingress-attributes": {"data": {"id": "ia-3", "type": "ingress-attributes"}}}},
+			{"id": "ia-3", "type": "ingress-attributes", "attributes": {"commit-sha": "abc1234"}}
+		], "meta": {"pagination": {"next-page": 2}}}`))
+	}))
+	defer server.Close()
+
+	client := NewTerraformClient("unused", "token", "acme")
+	client.baseURL = server.URL
+
+	runs, err := client.FetchRuns(context.Background(), Workspace{ID: "ws-1", Name: "network"}, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Listing stops at the first run from before the range
+	if len(pages) != 2 {
+		t.Errorf("Expected two pages, got %v", pages)
+	}
+	// run-4 is after the range
+	if len(runs) != 2 || runs[0].ID != "run-3" || runs[1].ID != "run-2" {
+		t.Fatalf("Expected run-3 and run-2, got %+v", runs)
+	}
+	run := runs[0]
+	if run.Workspace != "network" || run.CommitSHA != "abc1234" || run.Message != "Add a bucket" || run.AppliedAt == nil {
+		t.Errorf("Unexpected run %+v", run)
+	}
+	if runs[1].CommitSHA != "" || runs[1].ErroredAt == nil || runs[1].PlannedAt != nil {
+		t.Errorf("Unexpected errored run %+v", runs[1])
+	}
+}
+
+func TestTerraformClient_FetchWorkspaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/organizations/acme/workspaces":
+			if r.URL.Query().Get("page[number]") == "2" {
+				w.Write([]byte(`{"data": [{"id": "ws-2", "type": "workspaces", "attributes": {"name": "dns"}}], "meta": {"pagination": {"next-page": null}}}`))
+				return
+			}
+			w.Write([]byte(`{"data": [{"id": "ws-1", "type": "workspaces", "attributes": {"name": "network"}}], "meta": {"pagination": {"next-page": 2}}}`))
+		case "/organizations/acme/workspaces/network":
+			w.Write([]byte(`{"data": {"id": "ws-1", "type": "workspaces", "attributes": {"name": "network"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": [{"status": "404", "title": "not found"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewTerraformClient("unused", "token", "acme")
+	client.baseURL = server.URL
+
+	workspaces, err := client.FetchWorkspaces(context.Background(), nil)
+	if err != nil || len(workspaces) != 2 || workspaces[0] != (Workspace{ID: "ws-1", Name: "network"}) || workspaces[1] != (Workspace{ID: "ws-2", Name: "dns"}) {
+		t.Errorf("Expected both workspaces, got %v (%v)", workspaces, err)
+	}
+	workspaces, err = client.FetchWorkspaces(context.Background(), []string{"network"})
+	if err != nil || len(workspaces) != 1 || workspaces[0].ID != "ws-1" {
+		t.Errorf("Expected the named workspace, got %v (%v)", workspaces, err)
+	}
+	if _, err := client.FetchWorkspaces(context.Background(), []string{"missing"}); err == nil || !strings.Contains(err.Error(), "API returned status 404: not found") {
+		t.Errorf("Expected the API's error, got %v", err)
+	}
+}
+
+func TestTerraformClient_Budget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"id": "ws-1", "type": "workspaces", "attributes": {"name": "network"}}], "meta": {"pagination": {"next-page": 2}}}`))
+	}))
+	defer server.Close()
+
+	client := NewTerraformClient("unused", "token", "acme")
+	client.baseURL = server.URL
+	client.SetBudget(budget.New("Terraform API", 1))
+
+	workspaces, err := client.FetchWorkspaces(context.Background(), nil)
+	if !errors.Is(err, budget.ErrExhausted) {
+		t.Errorf("Expected the budget to run out, got %v", err)
+	}
+	if len(workspaces) != 1 {
+		t.Errorf("Expected the first page's workspace, got %v", workspaces)
+	}
+}
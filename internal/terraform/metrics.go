@@ -0,0 +1,112 @@
+package terraform
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// ProcessRuns calculates each run's plan and apply durations and outcome
+func ProcessRuns(runs []Run) []RunMetric {
+	var results []RunMetric
+
+	for _, run := range runs {
+		metric := RunMetric{
+			Workspace: run.Workspace,
+			RunID:     run.ID,
+			Status:    run.Status,
+			Message:   run.Message,
+			CommitSHA: run.CommitSHA,
+			CreatedAt: run.CreatedAt,
+			PlanOnly:  run.PlanOnly,
+			Applied:   run.Status == "applied",
+			Failed:    run.Status == "errored",
+		}
+
+		// A stage that errored ended when the run did
+		if run.PlanningAt != nil {
+			if end := stageEnd(run.PlannedAt, run.ErroredAt, run.ApplyingAt); end != nil {
+				metric.PlanDuration = end.Sub(*run.PlanningAt)
+			}
+		}
+		if run.ApplyingAt != nil {
+			if end := stageEnd(run.AppliedAt, run.ErroredAt, nil); end != nil {
+				metric.ApplyDuration = end.Sub(*run.ApplyingAt)
+			}
+		}
+
+		results = append(results, metric)
+	}
+
+	return results
+}
+
+// stageEnd returns when a stage finished: when it completed, or else when the run
+// errored, as long as that wasn't in a later stage (which started at next)
+func stageEnd(completed, errored, next *time.Time) *time.Time {
+	if completed != nil {
+		return completed
+	}
+	if errored != nil && next == nil {
+		return errored
+	}
+	return nil
+}
+
+// finished reports whether a run that could apply got as far as it was going to:
+// it applied, had nothing to apply, or errored
+func finished(result RunMetric) bool {
+	return result.Applied || result.Failed || result.Status == "planned_and_finished"
+}
+
+// SummarizeWorkspaces aggregates runs per workspace, by workspace name, with apply
+// frequency measured over the weeks from start to end
+func SummarizeWorkspaces(results []RunMetric, start, end time.Time) []WorkspaceStats {
+	byWorkspace := make(map[string]*WorkspaceStats)
+	planDurations := make(map[string][]time.Duration)
+	applyDurations := make(map[string][]time.Duration)
+
+	for _, result := range results {
+		stats, ok := byWorkspace[result.Workspace]
+		if !ok {
+			stats = &WorkspaceStats{Workspace: result.Workspace}
+			byWorkspace[result.Workspace] = stats
+		}
+		stats.Runs++
+		if result.PlanDuration > 0 {
+			planDurations[result.Workspace] = append(planDurations[result.Workspace], result.PlanDuration)
+		}
+		if result.PlanOnly {
+			stats.SpeculativePlans++
+			continue
+		}
+		if result.Applied {
+			stats.Applies++
+			applyDurations[result.Workspace] = append(applyDurations[result.Workspace], result.ApplyDuration)
+		}
+		if result.Failed {
+			stats.Failures++
+		}
+		if finished(result) {
+			stats.Finished++
+		}
+	}
+
+	weeks := end.Sub(start).Hours() / (24 * 7)
+	var summaries []WorkspaceStats
+	for workspace, s := range byWorkspace {
+		s.MedianPlanDuration = stats.MedianOf(planDurations[workspace])
+		s.MedianApplyDuration = stats.MedianOf(applyDurations[workspace])
+		if weeks > 0 {
+			s.AppliesPerWeek = float64(s.Applies) / weeks
+		}
+		summaries = append(summaries, *s)
+	}
+
+	slices.SortFunc(summaries, func(a, b WorkspaceStats) int {
+		return cmp.Compare(a.Workspace, b.Workspace)
+	})
+	return summaries
+}
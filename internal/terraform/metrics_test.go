@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcessRuns(t *testing.T) {
+	at := func(minute int) *time.Time {
+		t := time.Date(2024, 6, 3, 9, minute, 0, 0, time.UTC)
+		return &t
+	}
+	runs := []Run{
+		{ID: "run-applied", Status: "applied", PlanningAt: at(0), PlannedAt: at(2), ApplyingAt: at(5), AppliedAt: at(8)},
+		{ID: "run-plan-errored", Status: "errored", PlanningAt: at(0), ErroredAt: at(1)},
+		{ID: "run-apply-errored", Status: "errored", PlanningAt: at(0), PlannedAt: at(3), ApplyingAt: at(4), ErroredAt: at(9)},
+		{ID: "run-discarded", Status: "discarded", PlanningAt: at(0), PlannedAt: at(1)},
+	}
+
+	results := ProcessRuns(runs)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	tests := []struct {
+		plan, apply     time.Duration
+		applied, failed bool
+	}{
+		{2 * time.Minute, 3 * time.Minute, true, false},
+		{time.Minute, 0, false, true},
+		{3 * time.Minute, 5 * time.Minute, false, true},
+		{time.Minute, 0, false, false},
+	}
+	for i, want := range tests {
+		got := results[i]
+		if got.PlanDuration != want.plan || got.ApplyDuration != want.apply || got.Applied != want.applied || got.Failed != want.failed {
+			t.Errorf("%s: expected %+v, got %+v", got.RunID, want, got)
+		}
+	}
+}
+
+func TestSummarizeWorkspaces(t *testing.T) {
+	results := []RunMetric{
+		{Workspace: "network", Status: "applied", Applied: true, PlanDuration: time.Minute, ApplyDuration: 4 * time.Minute},
+		{Workspace: "network", Status: "applied", Applied: true, PlanDuration: 3 * time.Minute, ApplyDuration: 2 * time.Minute},
+		{Workspace: "network", Status: "errored", Failed: true, PlanDuration: 2 * time.Minute},
+		{Workspace: "network", Status: "planned_and_finished", PlanDuration: 2 * time.Minute},
+		{Workspace: "network", Status: "discarded", PlanDuration: 2 * time.Minute},
+		// A speculative plan's failure isn't a failed change
+		{Workspace: "network", Status: "errored", Failed: true, PlanOnly: true, PlanDuration: 2 * time.Minute},
+		{Workspace: "dns", Status: "applied", Applied: true, PlanDuration: time.Minute, ApplyDuration: time.Minute},
+	}
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	stats := SummarizeWorkspaces(results, start, start.AddDate(0, 0, 14))
+	if len(stats) != 2 || stats[0].Workspace != "dns" || stats[1].Workspace != "network" {
+		t.Fatalf("Expected dns and network, got %+v", stats)
+	}
+	network := stats[1]
+	if network.Runs != 6 || network.SpeculativePlans != 1 || network.Applies != 2 || network.Failures != 1 || network.Finished != 4 {
+		t.Errorf("Unexpected counts %+v", network)
+	}
+	if network.FailureRate() != 0.25 {
+		t.Errorf("Expected a 25%% failure rate, got %v", network.FailureRate())
+	}
+	if network.AppliesPerWeek != 1 {
+		t.Errorf("Expected 1 apply a week, got %v", network.AppliesPerWeek)
+	}
+	if network.MedianPlanDuration != 2*time.Minute || network.MedianApplyDuration != 3*time.Minute {
+		t.Errorf("Unexpected medians %v and %v", network.MedianPlanDuration, network.MedianApplyDuration)
+	}
+	if (WorkspaceStats{}).FailureRate() != 0 {
+		t.Error("Expected no failure rate without finished runs")
+	}
+}
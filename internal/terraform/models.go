@@ -0,0 +1,67 @@
+package terraform
+
+import "time"
+
+// Workspace is a Terraform Cloud or Enterprise workspace
+type Workspace struct {
+	ID   string // e.g. ws-abc123
+	Name string
+}
+
+// Run is a run in a workspace: a plan, and unless it was plan-only, an apply
+type Run struct {
+	ID        string
+	Workspace string // The workspace's name
+	Status    string // e.g. applied, planned_and_finished, errored, discarded or canceled
+	Message   string
+	Source    string // What started the run, e.g. tfe-configuration-version for a VCS push
+	CommitSHA string // The commit the run's configuration came from, if it came from VCS
+	CreatedAt time.Time
+	PlanOnly  bool // A speculative plan, such as for a PR, which can't be applied
+	IsDestroy bool
+
+	// When the run reached each stage; nil if it didn't
+	PlanningAt *time.Time
+	PlannedAt  *time.Time
+	ApplyingAt *time.Time
+	AppliedAt  *time.Time
+	ErroredAt  *time.Time
+}
+
+// RunMetric is the timings and outcome of a single run
+type RunMetric struct {
+	Workspace     string
+	RunID         string
+	Status        string
+	Message       string
+	CommitSHA     string
+	CreatedAt     time.Time
+	PlanOnly      bool
+	PlanDuration  time.Duration     // Planning until planned (or until the plan errored), 0 if it never planned
+	ApplyDuration time.Duration     // Applying until applied (or until the apply errored), 0 if it never applied
+	Applied       bool              // Whether the run's changes were applied
+	Failed        bool              // Whether the plan or apply errored
+	Extra         map[string]string // Fields added by a hook program, if any
+}
+
+// WorkspaceStats summarizes one workspace's runs over a date range
+type WorkspaceStats struct {
+	Workspace           string
+	Runs                int // All runs, including speculative plans and discarded or canceled ones
+	SpeculativePlans    int
+	Applies             int
+	Failures            int // Runs that could have applied but errored
+	Finished            int // Runs that could have applied and were applied, finished with no changes or errored
+	AppliesPerWeek      float64
+	MedianPlanDuration  time.Duration
+	MedianApplyDuration time.Duration
+}
+
+// FailureRate is the fraction of finished runs that errored, leaving out
+// speculative plans, whose failures are feedback on a PR rather than failed changes
+func (s WorkspaceStats) FailureRate() float64 {
+	if s.Finished == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Finished)
+}
@@ -0,0 +1,249 @@
+// Package vercel reads deployments from Vercel, for frontend teams that deploy
+// there rather than with Cloud Deploy. A deployment counts once it became ready,
+// and only production deployments count unless previews are asked for.
+package vercel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/budget"
+	"github.com/reillywatson/statstracker/internal/deploy"
+	"github.com/reillywatson/statstracker/internal/retry"
+)
+
+const (
+	vercelAPIBaseURL = "https://api.vercel.com"
+	defaultTimeout   = 30 * time.Second
+	pageSize         = 100
+)
+
+// VercelClient handles Vercel API operations
+type VercelClient struct {
+	httpClient *http.Client
+	token      string
+	teamID     string
+	projects   []string
+	target     string
+	baseURL    string
+	budget     *budget.Budget // nil means unlimited
+	retry      *retry.Transport
+	commitTime deploy.CommitTimeFunc
+
+	// The deployments listed, for the other methods
+	mu          sync.Mutex
+	deployments map[string]vercelDeployment
+}
+
+var _ deploy.DeployProvider = (*VercelClient)(nil)
+
+// NewVercelClient creates a new client for the given projects, by name or ID,
+// authenticated with an access token. teamID is the team owning the projects, or
+// empty for a personal account's. commitTime looks up when deployed commits were
+// made, usually in the projects' GitHub repository.
+func NewVercelClient(token, teamID string, projects []string, commitTime deploy.CommitTimeFunc) *VercelClient {
+	retrying := retry.NewTransport(nil, retry.DefaultPolicy)
+	return &VercelClient{
+		httpClient: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: retrying,
+		},
+		token:       token,
+		teamID:      teamID,
+		projects:    projects,
+		target:      "production",
+		baseURL:     vercelAPIBaseURL,
+		retry:       retrying,
+		commitTime:  commitTime,
+		deployments: make(map[string]vercelDeployment),
+	}
+}
+
+// SetTarget sets which deployments are counted: production (the default), or
+// preview for the deployments Vercel makes of branches and PRs
+func (c *VercelClient) SetTarget(target string) {
+	c.target = target
+}
+
+// SetBudget limits the number of API calls the client may make. Once the budget
+// is spent, calls fail with an error wrapping budget.ErrExhausted.
+func (c *VercelClient) SetBudget(b *budget.Budget) {
+	c.budget = b
+}
+
+// SetRetryPolicy sets how requests that fail with a server or network error are
+// retried (retry.DefaultPolicy unless set)
+func (c *VercelClient) SetRetryPolicy(p retry.Policy) {
+	c.retry.Policy = p
+}
+
+type vercelDeployment struct {
+	UID        string `json:"uid"`
+	State      string `json:"state"`
+	Target     string `json:"target"` // production, or empty for previews
+	Created    int64  `json:"created"`
+	BuildingAt int64  `json:"buildingAt"`
+	Ready      int64  `json:"ready"`
+	Meta       struct {
+		CommitSHA     string `json:"githubCommitSha"`
+		CommitMessage string `json:"githubCommitMessage"`
+		PRID          string `json:"githubPrId"`
+	} `json:"meta"`
+}
+
+// ListReleases lists the projects' deployments to the target made
+// in the date range that became ready, newest first within each project.
+// Deployments that failed or were cancelled, and ones not made from a GitHub
+// commit, such as from the CLI, are left out. If the API budget runs out part way
+// through, the releases found so far are returned along with the error.
+func (c *VercelClient) ListReleases(ctx context.Context, startDate, endDate time.Time) ([]*deploy.Release, error) {
+	var releases []*deploy.Release
+	for _, project := range c.projects {
+		query := url.Values{
+			"projectId": {project},
+			"target":    {c.target},
+			"state":     {"READY"},
+			"limit":     {strconv.Itoa(pageSize)},
+			"since":     {strconv.FormatInt(startDate.UnixMilli(), 10)},
+			"until":     {strconv.FormatInt(endDate.UnixMilli(), 10)},
+		}
+		if c.teamID != "" {
+			query.Set("teamId", c.teamID)
+		}
+		for {
+			var page struct {
+				Deployments []vercelDeployment `json:"deployments"`
+				Pagination  struct {
+					Next *int64 `json:"next"`
+				} `json:"pagination"`
+			}
+			if err := c.get(ctx, "/v6/deployments", query, &page); err != nil {
+				return releases, fmt.Errorf("failed to list deployments for %s: %w", project, err)
+			}
+			for _, d := range page.Deployments {
+				if d.State != "READY" || d.Meta.CommitSHA == "" || d.Ready == 0 {
+					continue
+				}
+				releases = append(releases, c.release(project, d))
+			}
+			// Older pages are fetched by moving the end of the range back
+			if page.Pagination.Next == nil {
+				break
+			}
+			query.Set("until", strconv.FormatInt(*page.Pagination.Next, 10))
+		}
+	}
+	return releases, nil
+}
+
+// release converts a Vercel deployment to a deploy.Release, remembering it
+// for ExtractSource and GetRolloutCompletion
+func (c *VercelClient) release(project string, d vercelDeployment) *deploy.Release {
+	// The deployment ID comes last, so it's used as the release ID
+	name := fmt.Sprintf("projects/%s/deployments/%s", project, d.UID)
+	target := d.Target
+	if target == "" {
+		target = "preview"
+	}
+	// The release starts when the build did, so it runs for the build's duration
+	started := d.BuildingAt
+	if started == 0 {
+		started = d.Created
+	}
+	c.mu.Lock()
+	c.deployments[name] = d
+	c.mu.Unlock()
+	return &deploy.Release{
+		Name:        name,
+		CreateTime:  time.UnixMilli(started),
+		Annotations: map[string]string{"project": project, "target": target},
+	}
+}
+
+// ExtractSource returns the commit a deployment built and when it
+// was made. The PR is the one a preview was built for, or for production, the one
+// the commit merged if its message names one.
+func (c *VercelClient) ExtractSource(ctx context.Context, release *deploy.Release) (string, string, time.Time, error) {
+	d, err := c.listed(release)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	prNumber := d.Meta.PRID
+	if prNumber == "" {
+		prNumber = deploy.PRFromCommitMessage(d.Meta.CommitMessage)
+	}
+	commitTime, err := c.commitTime(ctx, d.Meta.CommitSHA)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to look up commit %s: %w", d.Meta.CommitSHA, err)
+	}
+	return d.Meta.CommitSHA, prNumber, commitTime, nil
+}
+
+// GetRolloutCompletion returns when the deployment was built and ready to serve
+func (c *VercelClient) GetRolloutCompletion(ctx context.Context, release *deploy.Release) (time.Time, error) {
+	d, err := c.listed(release)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(d.Ready), nil
+}
+
+// listed returns the Vercel deployment a release was converted from
+func (c *VercelClient) listed(release *deploy.Release) (vercelDeployment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.deployments[release.Name]
+	if !ok {
+		return vercelDeployment{}, fmt.Errorf("release %s wasn't listed by this client", release.Name)
+	}
+	return d, nil
+}
+
+// get makes a Vercel API request and decodes the response into result
+func (c *VercelClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	if err := c.budget.Spend(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package vercel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns a time on June 3rd 2024, in the milliseconds Vercel uses
+func at(hour int) int64 {
+	return time.Date(2024, 6, 3, hour, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestVercelClient(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		query := r.URL.Query()
+		if query.Get("projectId") != "shop" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": "not_found", "message": "Project not found"}}`))
+			return
+		}
+		if query.Get("target") != "production" || query.Get("teamId") != "team_1" {
+			t.Errorf("Unexpected query %v", query)
+		}
+		requests = append(requests, query.Get("until"))
+		if query.Get("until") == fmt.Sprint(at(8)) {
+			// The second, last page
+			fmt.Fprintf(w, `{"deployments": [
+				{"uid": "dpl_1", "state": "READY", "target": "production", "created": %d, "ready": %d, "meta": {"githubCommitSha": "def5678", "githubCommitMessage": "Merge pull request #41 from someone/branch"}}
+			], "pagination": {"next": null}}`, at(5), at(6))
+			return
+		}
+		fmt.Fprintf(w, `{"deployments": [
+			{"uid": "dpl_3", "state": "READY", "target": "production", "created": %d, "buildingAt": %d, "ready": %d, "meta": {"githubCommitSha": "abc1234", "githubCommitMessage": "Add search (#42)"}},
+			{"uid": "dpl_cli", "state": "READY", "target": "production", "created": %d, "ready": %d, "meta": {}}
+		], "pagination": {"next": %d}}`, at(9), at(10), at(11), at(8), at(9), at(8))
+	}))
+	defer server.Close()
+
+	commitTime := func(ctx context.Context, sha string) (time.Time, error) {
+		return time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), nil
+	}
+	client := NewVercelClient("token", "team_1", []string{"shop"}, commitTime)
+	client.baseURL = server.URL
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected two pages, got %v", requests)
+	}
+	// The CLI deployment has no commit, so it's left out
+	if len(releases) != 2 || releases[0].Name != "projects/shop/deployments/dpl_3" || releases[1].Name != "projects/shop/deployments/dpl_1" {
+		t.Fatalf("Expected dpl_3 and dpl_1, got %v", releases)
+	}
+	// The release runs from when the build started until it was ready
+	if releases[0].CreateTime.UnixMilli() != at(10) {
+		t.Errorf("Expected the release to start with its build, got %v", releases[0].CreateTime)
+	}
+
+	sha, prNumber, committed, err := client.ExtractSource(context.Background(), releases[0])
+	if err != nil || sha != "abc1234" || prNumber != "42" || committed.IsZero() {
+		t.Errorf("Expected PR #42's squashed commit, got %s %s %v (%v)", sha, prNumber, committed, err)
+	}
+	if _, prNumber, _, _ := client.ExtractSource(context.Background(), releases[1]); prNumber != "41" {
+		t.Errorf("Expected PR #41's merge commit, got %q", prNumber)
+	}
+	finish, err := client.GetRolloutCompletion(context.Background(), releases[0])
+	if err != nil || finish.UnixMilli() != at(11) {
+		t.Errorf("Expected the deployment's ready time, got %v (%v)", finish, err)
+	}
+
+	missing := NewVercelClient("token", "team_1", []string{"missing"}, commitTime)
+	missing.baseURL = server.URL
+	if _, err := missing.ListReleases(context.Background(), time.Time{}, time.Now()); err == nil || !strings.Contains(err.Error(), "status 404: Project not found") {
+		t.Errorf("Expected Vercel's error, got %v", err)
+	}
+}
+
+func TestVercelClient_Preview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("target") != "preview" {
+			t.Errorf("Expected previews to be asked for, got %v", r.URL.Query())
+		}
+		fmt.Fprintf(w, `{"deployments": [
+			{"uid": "dpl_p", "state": "READY", "target": null, "created": %d, "ready": %d, "meta": {"githubCommitSha": "abc1234", "githubCommitMessage": "WIP", "githubPrId": "77"}}
+		], "pagination": {"next": null}}`, at(9), at(10))
+	}))
+	defer server.Close()
+
+	client := NewVercelClient("token", "", []string{"shop"}, func(ctx context.Context, sha string) (time.Time, error) {
+		return time.Time{}, nil
+	})
+	client.baseURL = server.URL
+	client.SetTarget("preview")
+
+	releases, err := client.ListReleases(context.Background(), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(releases) != 1 || releases[0].Annotations["target"] != "preview" {
+		t.Fatalf("Expected a preview deployment, got %v (%v)", releases, err)
+	}
+	// Previews know the PR they were built for
+	if _, prNumber, _, _ := client.ExtractSource(context.Background(), releases[0]); prNumber != "77" {
+		t.Errorf("Expected the preview's PR, got %q", prNumber)
+	}
+}
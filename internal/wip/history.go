@@ -0,0 +1,88 @@
+package wip
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// History keeps each repository's daily WIP snapshots in a JSON file. A day's
+// snapshot replaces any taken earlier that day, so a repository has one per day.
+type History struct {
+	path      string
+	snapshots []Snapshot
+}
+
+// OpenHistory reads the history at path. A missing file is an empty history, as on a first run.
+func OpenHistory(path string) (*History, error) {
+	h := &History{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WIP history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse WIP history %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Record adds snapshots, replacing any for the same repository and day. The
+// history is written out by Close.
+func (h *History) Record(snapshots []Snapshot) {
+	for _, snapshot := range snapshots {
+		h.snapshots = slices.DeleteFunc(h.snapshots, func(s Snapshot) bool {
+			return s.Date == snapshot.Date && s.Repository == snapshot.Repository
+		})
+		h.snapshots = append(h.snapshots, snapshot)
+	}
+	slices.SortStableFunc(h.snapshots, func(a, b Snapshot) int {
+		if c := cmp.Compare(a.Date, b.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Repository, b.Repository)
+	})
+}
+
+// Snapshots returns the snapshots of the given repositories, oldest first, or of
+// every repository if repos is empty
+func (h *History) Snapshots(repos []string) []Snapshot {
+	var snapshots []Snapshot
+	for _, snapshot := range h.snapshots {
+		if len(repos) == 0 || slices.Contains(repos, snapshot.Repository) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots
+}
+
+// Close writes the history out, replacing the file atomically so an interrupted
+// write can't lose earlier days' snapshots
+func (h *History) Close() error {
+	data, err := json.MarshalIndent(h.snapshots, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode WIP history: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write WIP history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write WIP history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write WIP history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return fmt.Errorf("failed to write WIP history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package wip takes point-in-time snapshots of work in progress: the PRs open
+// now, however old, with how long each has been open, how far through review it
+// has got and whether it has already missed a delivery SLO. A History keeps a
+// summary of each day's snapshot, so WIP can be charted over time.
+package wip
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/businesshours"
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/slo"
+	"github.com/reillywatson/statstracker/internal/stats"
+)
+
+// How far through review an open PR has got, from the latest review each reviewer
+// left, most pressing first
+const (
+	StateChangesRequested = "changes requested" // Some reviewer's latest review requested changes
+	StateAwaitingReview   = "awaiting review"   // No one has reviewed it
+	StateCommented        = "commented"         // Reviewed, but with comments only
+	StateApproved         = "approved"          // Approved, and no reviewer wants changes
+)
+
+// Stages of a cumulative flow diagram an open PR can be in. A draft is in
+// StageDraft whatever its reviews say.
+const (
+	StageDraft    = "draft"    // Still a draft
+	StageOpen     = "open"     // Ready for review, but not approved
+	StageApproved = "approved" // Ready for review and approved
+)
+
+// SLA status of an open PR against the delivery SLOs whose clocks are still running on it
+const (
+	SLAWithin   = "within"   // Still within every SLO it could miss
+	SLABreached = "breached" // Already over an SLO's threshold
+)
+
+// Item is one open PR in a snapshot
+type Item struct {
+	Repository  string // owner/repo
+	Number      int
+	Title       string
+	Author      string
+	Team        string // The author's team, "" if not known; set by the caller
+	CreatedAt   time.Time
+	Draft       bool
+	Age         time.Duration // How long it has been open
+	Size        string        // github.SizeXS to github.SizeXL, "" if not measured
+	ReviewState string        // StateAwaitingReview, StateCommented, StateChangesRequested or StateApproved
+	SLA         string        // SLAWithin or SLABreached, "" if no SLO's clock is running on it
+	Breached    []string      // The SLO metrics it's over, such as first-review
+}
+
+// Items snapshots the open PRs among results at now, oldest first. Each PR is
+// measured against the objectives it hasn't yet met (first-review until it's
+// reviewed, approval until it's approved), in business hours if schedule is set.
+func Items(results []github.PullRequestMetric, objectives slo.Objectives, now time.Time, schedule *businesshours.Schedule) []Item {
+	var items []Item
+	for _, result := range results {
+		item := Item{
+			Repository:  result.Repository,
+			Number:      result.PRNumber,
+			Title:       result.PRTitle,
+			Author:      result.Author,
+			CreatedAt:   result.CreatedAt,
+			Draft:       result.Draft,
+			Age:         now.Sub(result.CreatedAt),
+			Size:        result.Size,
+			ReviewState: reviewState(result),
+		}
+
+		elapsed := item.Age
+		if schedule != nil {
+			elapsed = schedule.Between(result.CreatedAt, now)
+		}
+		for _, objective := range objectives {
+			var running bool
+			switch objective.Metric {
+			case "first-review":
+				running = !result.HasReview
+			case "approval":
+				running = result.Approver == ""
+			}
+			if !running {
+				continue
+			}
+			if item.SLA == "" {
+				item.SLA = SLAWithin
+			}
+			if elapsed > objective.Threshold {
+				item.SLA = SLABreached
+				item.Breached = append(item.Breached, objective.Metric)
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	slices.SortStableFunc(items, func(a, b Item) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return items
+}
+
+// Stage is the stage of a cumulative flow diagram the PR is in
+func (i Item) Stage() string {
+	switch {
+	case i.Draft:
+		return StageDraft
+	case i.ReviewState == StateApproved:
+		return StageApproved
+	default:
+		return StageOpen
+	}
+}
+
+// reviewState works out how far through review a PR has got from each reviewer's
+// latest review. Comments don't change a reviewer's verdict, and a dismissal
+// withdraws it.
+func reviewState(result github.PullRequestMetric) string {
+	verdicts := make(map[string]string)
+	for _, review := range result.Reviews {
+		switch review.State {
+		case "APPROVED", "CHANGES_REQUESTED":
+			verdicts[review.Reviewer] = review.State
+		case "DISMISSED":
+			delete(verdicts, review.Reviewer)
+		}
+	}
+
+	approved := false
+	for _, verdict := range verdicts {
+		if verdict == "CHANGES_REQUESTED" {
+			return StateChangesRequested
+		}
+		approved = true
+	}
+	switch {
+	case approved:
+		return StateApproved
+	case result.HasReview || len(result.Reviews) > 0:
+		return StateCommented
+	default:
+		return StateAwaitingReview
+	}
+}
+
+// Snapshot summarizes one repository's open PRs on one day
+type Snapshot struct {
+	Date             string         `json:"date"` // YYYY-MM-DD
+	Repository       string         `json:"repository"`
+	Open             int            `json:"open"`
+	ByState          map[string]int `json:"by_state"`           // Open PRs by review state
+	ByTeam           map[string]int `json:"by_team,omitempty"`  // Open PRs by their author's team, for authors with one
+	ByStage          map[string]int `json:"by_stage,omitempty"` // Open PRs by stage, nil in snapshots from before stages were recorded
+	Merged           int            `json:"merged,omitempty"`   // PRs ever merged in the repository, 0 if not counted
+	Breached         int            `json:"breached"`           // Open PRs over an SLO's threshold
+	MedianAgeSeconds int64          `json:"median_age_seconds"`
+	OldestAgeSeconds int64          `json:"oldest_age_seconds"`
+}
+
+// Summarize summarizes a snapshot's items for each repository, in repository order.
+// Repositories with nothing open should be in repos, so their empty days are recorded too.
+// merged has the number of PRs ever merged in each repository, if they were counted.
+func Summarize(date time.Time, repos []string, items []Item, merged map[string]int) []Snapshot {
+	byRepo := make(map[string][]Item)
+	for _, repo := range repos {
+		byRepo[repo] = nil
+	}
+	for _, item := range items {
+		byRepo[item.Repository] = append(byRepo[item.Repository], item)
+	}
+
+	var snapshots []Snapshot
+	for repo, repoItems := range byRepo {
+		snapshot := Snapshot{
+			Date:       date.Format("2006-01-02"),
+			Repository: repo,
+			Open:       len(repoItems),
+			ByState:    make(map[string]int),
+			ByStage:    make(map[string]int),
+			Merged:     merged[repo],
+		}
+		var ages []time.Duration
+		for _, item := range repoItems {
+			snapshot.ByState[item.ReviewState]++
+			snapshot.ByStage[item.Stage()]++
+			if item.Team != "" {
+				if snapshot.ByTeam == nil {
+					snapshot.ByTeam = make(map[string]int)
+				}
+				snapshot.ByTeam[item.Team]++
+			}
+			if item.SLA == SLABreached {
+				snapshot.Breached++
+			}
+			ages = append(ages, item.Age)
+		}
+		if len(ages) > 0 {
+			snapshot.MedianAgeSeconds = int64(stats.MedianOf(ages).Seconds())
+			snapshot.OldestAgeSeconds = int64(slices.Max(ages).Seconds())
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return cmp.Compare(a.Repository, b.Repository)
+	})
+	return snapshots
+}
@@ -0,0 +1,132 @@
+package wip
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/reillywatson/statstracker/internal/github"
+	"github.com/reillywatson/statstracker/internal/slo"
+)
+
+func TestItems(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	objectives := slo.Objectives{
+		{Metric: "first-review", Threshold: 8 * time.Hour, Target: 0.9},
+		{Metric: "approval", Threshold: 48 * time.Hour, Target: 0.8},
+	}
+	results := []github.PullRequestMetric{
+		// Reviewed, but one reviewer still wants changes and it's been open 3 days
+		{Repository: "o/r", PRNumber: 1, CreatedAt: now.Add(-72 * time.Hour), HasReview: true, Reviews: []github.ReviewMetric{
+			{Reviewer: "bob", State: "APPROVED"},
+			{Reviewer: "carol", State: "CHANGES_REQUESTED"},
+			{Reviewer: "carol", State: "COMMENTED"},
+		}},
+		// Nobody has looked at it for 10 hours
+		{Repository: "o/r", PRNumber: 2, CreatedAt: now.Add(-10 * time.Hour)},
+		// Approved, once carol's request for changes was dismissed
+		{Repository: "o/r", PRNumber: 3, CreatedAt: now.Add(-30 * time.Hour), HasReview: true, Approver: "bob", Reviews: []github.ReviewMetric{
+			{Reviewer: "carol", State: "CHANGES_REQUESTED"},
+			{Reviewer: "bob", State: "APPROVED"},
+			{Reviewer: "carol", State: "DISMISSED"},
+		}},
+		// Just opened as a draft, with a comment
+		{Repository: "o/r", PRNumber: 4, CreatedAt: now.Add(-time.Hour), Draft: true, HasReview: true, Reviews: []github.ReviewMetric{
+			{Reviewer: "bob", State: "COMMENTED"},
+		}},
+	}
+
+	items := Items(results, objectives, now, nil)
+	want := []struct {
+		number   int
+		state    string
+		sla      string
+		breached []string
+	}{
+		{1, StateChangesRequested, SLABreached, []string{"approval"}},
+		{3, StateApproved, "", nil},
+		{2, StateAwaitingReview, SLABreached, []string{"first-review"}},
+		{4, StateCommented, SLAWithin, nil},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Expected %d items, got %d", len(want), len(items))
+	}
+	for i, w := range want {
+		item := items[i]
+		if item.Number != w.number || item.ReviewState != w.state || item.SLA != w.sla || !slices.Equal(item.Breached, w.breached) {
+			t.Errorf("Item %d: expected #%d %s, SLA %q %v, got #%d %s, SLA %q %v", i, w.number, w.state, w.sla, w.breached, item.Number, item.ReviewState, item.SLA, item.Breached)
+		}
+	}
+
+	snapshots := Summarize(now, []string{"o/empty", "o/r"}, items, map[string]int{"o/r": 42})
+	if len(snapshots) != 2 || snapshots[0].Repository != "o/empty" || snapshots[0].Open != 0 {
+		t.Fatalf("Expected an empty snapshot for o/empty and one for o/r, got %+v", snapshots)
+	}
+	got := snapshots[1]
+	if got.Date != "2024-06-10" || got.Open != 4 || got.Breached != 2 || got.ByState[StateApproved] != 1 || got.OldestAgeSeconds != 72*60*60 || got.MedianAgeSeconds != 20*60*60 {
+		t.Errorf("Unexpected snapshot: %+v", got)
+	}
+	if got.ByStage[StageDraft] != 1 || got.ByStage[StageOpen] != 2 || got.ByStage[StageApproved] != 1 || got.Merged != 42 {
+		t.Errorf("Expected 1 draft, 2 open, 1 approved and 42 merged, got %v and %d", got.ByStage, got.Merged)
+	}
+}
+
+// reviewlessClient answers the only call ProcessPullRequests makes for PRs
+// nobody has reviewed
+type reviewlessClient struct {
+	github.GitHubClientInterface
+}
+
+func (reviewlessClient) FetchPullRequestReviews(ctx context.Context, owner, repo string, prNumber int) ([]*github.Review, error) {
+	return nil, nil
+}
+
+func TestItems_Drafts(t *testing.T) {
+	now := time.Now()
+	prs := []*github.PullRequest{
+		{Number: 1, User: github.User{Login: "alice"}, State: "open", CreatedAt: now.Add(-2 * time.Hour)},
+		{Number: 2, User: github.User{Login: "bob"}, State: "open", Draft: true, CreatedAt: now.Add(-time.Hour)},
+	}
+	opts := github.ProcessOptions{RequiredApprovals: 1, IncludeDrafts: true}
+	results := github.ProcessPullRequests(context.Background(), reviewlessClient{}, prs, "o", "r", opts)
+
+	items := Items(results, nil, now, nil)
+	if len(items) != 2 || items[1].Number != 2 || !items[1].Draft {
+		t.Fatalf("Expected both open PRs, the draft last, got %+v", items)
+	}
+	snapshots := Summarize(now, []string{"o/r"}, items, nil)
+	if len(snapshots) != 1 || snapshots[0].Open != 2 {
+		t.Errorf("Expected 2 open PRs, got %+v", snapshots)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wip.json")
+
+	h, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Record([]Snapshot{{Date: "2024-06-10", Repository: "o/r", Open: 4}, {Date: "2024-06-10", Repository: "o/other", Open: 1}})
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later snapshot the same day replaces the earlier one
+	h, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Record([]Snapshot{{Date: "2024-06-11", Repository: "o/r", Open: 6}})
+	h.Record([]Snapshot{{Date: "2024-06-10", Repository: "o/r", Open: 5}})
+
+	got := h.Snapshots([]string{"o/r"})
+	if len(got) != 2 || got[0].Date != "2024-06-10" || got[0].Open != 5 || got[1].Open != 6 {
+		t.Errorf("Expected o/r's two days, oldest first, with the later snapshot of the 10th, got %+v", got)
+	}
+	if all := h.Snapshots(nil); len(all) != 3 {
+		t.Errorf("Expected 3 snapshots across repositories, got %+v", all)
+	}
+}